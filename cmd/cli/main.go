@@ -4,6 +4,8 @@ import (
 	goflag "flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/nirmata/kyverno/pkg/config"
 	kyverno "github.com/nirmata/kyverno/pkg/kyverno"
@@ -12,6 +14,10 @@ import (
 
 func main() {
 	cmd := kyverno.NewDefaultKyvernoCommand()
+	// installed in the PATH as kubectl-kyverno, the CLI is the kubectl plugin
+	if strings.HasPrefix(filepath.Base(os.Args[0]), kyverno.PluginPrefix) {
+		cmd = kyverno.NewDefaultKubectlPluginCommand()
+	}
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
@@ -21,5 +27,6 @@ func main() {
 func init() {
 	flag.CommandLine.AddGoFlagSet(goflag.CommandLine)
 	config.LogDefaultFlags()
-	flag.Parse()
+	// the flags are parsed by the commands, the log flags are persistent flags of the root command
+	goflag.CommandLine.Parse([]string{})
 }
@@ -39,7 +39,7 @@ func main() {
 
 	// DYNAMIC CLIENT
 	// - client for all registered resources
-	client, err := client.NewClient(clientConfig, 10*time.Second, stopCh)
+	client, err := client.NewClient(clientConfig, 10*time.Second, client.DefaultRetry, stopCh)
 	if err != nil {
 		glog.Fatalf("Error creating client: %v\n", err)
 	}
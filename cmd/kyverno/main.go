@@ -3,27 +3,53 @@ package main
 import (
 	"context"
 	"flag"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/golang/glog"
 	"github.com/nirmata/kyverno/pkg/checker"
 	kyvernoclient "github.com/nirmata/kyverno/pkg/client/clientset/versioned"
 	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions"
+	kyvernov1informer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/compliance"
 	"github.com/nirmata/kyverno/pkg/config"
+	"github.com/nirmata/kyverno/pkg/cosign"
 	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/engine"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/evaluation"
 	event "github.com/nirmata/kyverno/pkg/event"
+	"github.com/nirmata/kyverno/pkg/externaldata"
 	"github.com/nirmata/kyverno/pkg/generate"
 	generatecleanup "github.com/nirmata/kyverno/pkg/generate/cleanup"
+	"github.com/nirmata/kyverno/pkg/git"
+	"github.com/nirmata/kyverno/pkg/informers"
+	"github.com/nirmata/kyverno/pkg/leader"
+	"github.com/nirmata/kyverno/pkg/metrics"
+	"github.com/nirmata/kyverno/pkg/notary"
+	"github.com/nirmata/kyverno/pkg/notification"
 	"github.com/nirmata/kyverno/pkg/policy"
+	"github.com/nirmata/kyverno/pkg/policyexception"
+	"github.com/nirmata/kyverno/pkg/policyreport"
+	"github.com/nirmata/kyverno/pkg/policysource"
 	"github.com/nirmata/kyverno/pkg/policystore"
 	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"github.com/nirmata/kyverno/pkg/ratelimit"
+	"github.com/nirmata/kyverno/pkg/registry"
+	"github.com/nirmata/kyverno/pkg/reportrequest"
 	"github.com/nirmata/kyverno/pkg/signal"
+	"github.com/nirmata/kyverno/pkg/tracing"
 	"github.com/nirmata/kyverno/pkg/utils"
 	"github.com/nirmata/kyverno/pkg/version"
 	"github.com/nirmata/kyverno/pkg/webhookconfig"
 	"github.com/nirmata/kyverno/pkg/webhooks"
 	webhookgenerate "github.com/nirmata/kyverno/pkg/webhooks/generate"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	kubeinformers "k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
 )
 
 var (
@@ -35,6 +61,70 @@ var (
 	filterK8Resources string
 	// User FQDN as CSR CN
 	fqdncn bool
+	// docker config with the credentials of the private registries
+	dockerConfig string
+	// the Secret of the kyverno namespace with the CA certificates and the insecure registries of the registry client
+	registryTLSSecret string
+	// the mirrors of the registries, e.g. docker.io=mirror.corp.com/docker.io
+	registryMirrors string
+	// verify the images without outbound internet calls
+	offline bool
+	// the namespaces of the authorization Secrets of the external services
+	serviceSecretNamespaces string
+	// the output of the policy violations: violations, policyreports or both
+	reports string
+	// the address of the Prometheus metrics endpoint
+	metricsAddr string
+	// the OTLP HTTP endpoint of the OpenTelemetry collector the traces are exported to
+	otlpEndpoint string
+	// the config file of the sinks the violations and the blocked requests are sent to
+	notificationConfig string
+	// the retention of the policy violations and of the results of the policy reports
+	retentionMaxAge          time.Duration
+	retentionMaxPerNamespace int
+	// the maximum count of results of a policy report, the larger reports are split in chunks
+	reportMaxResults int
+	// where the violations are written: embedded, requests for the reports controller, or controller
+	reportsMode string
+	// the token buckets limiting the writes of the events, and of the violations, reports and report requests
+	eventsQPS       float64
+	eventsBurst     int
+	violationsQPS   float64
+	violationsBurst int
+	// maintain the ComplianceSummary of the namespaces
+	complianceSummaries bool
+	// run the controllers on the elected leader of the replicas only
+	leaderElection bool
+	// workers and retries of the policy controller
+	policyWorkers        int
+	policyRetryBaseDelay time.Duration
+	policyRetryMaxDelay  time.Duration
+	policyQueueQPS       float64
+	policyQueueBurst     int
+	// interval of the background scans of the existing resources
+	backgroundScanInterval time.Duration
+	// resync period of the informers
+	resyncPeriod time.Duration
+	// time to write the queued events, violations, reports and generate requests on shutdown
+	shutdownTimeout time.Duration
+	// serve the pprof profiles and the expvar variables on the port of localhost
+	profile     bool
+	profilePort int
+	// rate limits of the requests to the API server, and retries of the transient errors of the dynamic client
+	clientQPS        float64
+	clientBurst      int
+	clientRetries    int
+	clientRetryDelay time.Duration
+	// period of the refresh of the discovered resources
+	discoveryRefresh time.Duration
+	// maximum number of resources listed at once by the background scans
+	listPageSize int64
+	// kubeconfig and contexts of the remote clusters scanned by kyverno, and interval of their scans
+	clusterKubeconfig   string
+	clusterContexts     string
+	clusterScanInterval time.Duration
+	// the address of the evaluation API
+	evaluationAddr string
 )
 
 func main() {
@@ -50,6 +140,9 @@ func main() {
 	if err != nil {
 		glog.Fatalf("Error building kubeconfig: %v\n", err)
 	}
+	// the rate limits of the requests of the clients to the API server
+	clientConfig.QPS = float32(clientQPS)
+	clientConfig.Burst = clientBurst
 
 	// KYVENO CRD CLIENT
 	// access CRD resources
@@ -62,11 +155,13 @@ func main() {
 
 	// DYNAMIC CLIENT
 	// - client for all registered resources
-	// - invalidate local cache of registered resource every 10 seconds
-	client, err := dclient.NewClient(clientConfig, 10*time.Second, stopCh)
+	// - invalidate local cache of registered resource every discoveryRefresh, and on the changes of the CRDs
+	// - retry the transient errors of the requests
+	client, err := dclient.NewClient(clientConfig, discoveryRefresh, dclient.NewRetry(clientRetries, clientRetryDelay), stopCh)
 	if err != nil {
 		glog.Fatalf("Error creating client: %v\n", err)
 	}
+	client.SetListPageSize(listPageSize)
 	// CRD CHECK
 	// - verify if the CRD for Policy & PolicyViolation are available
 	if !utils.CRDInstalled(client.DiscoveryClient) {
@@ -83,13 +178,22 @@ func main() {
 
 	// KUBERNETES RESOURCES INFORMER
 	// watches namespace resource
-	// - cache resync time: 10 seconds
+	// - cache resync time: resyncPeriod
+	// - the managed fields and the last applied configuration of the cached objects are stripped
 	kubeInformer := kubeinformers.NewSharedInformerFactoryWithOptions(
 		kubeClient,
-		10*time.Second)
+		resyncPeriod)
+	informers.TransformKubeInformers(kubeInformer)
 	// KUBERNETES Dynamic informer
-	// - cahce resync time: 10 seconds
-	kubedynamicInformer := client.NewDynamicSharedInformerFactory(10 * time.Second)
+	// - cahce resync time: resyncPeriod
+	kubedynamicInformer := client.NewDynamicSharedInformerFactory(resyncPeriod)
+	// KUBERNETES RESOURCES INFORMER of the kyverno namespace
+	// watches the Secrets of the kyverno configuration
+	// - cache resync time: resyncPeriod
+	kubeKyvernoInformer := kubeinformers.NewSharedInformerFactoryWithOptions(
+		kubeClient,
+		resyncPeriod,
+		kubeinformers.WithNamespace(config.KubePolicyNamespace))
 
 	// WERBHOOK REGISTRATION CLIENT
 	webhookRegistrationClient := webhookconfig.NewWebhookRegistrationClient(
@@ -98,69 +202,403 @@ func main() {
 		serverIP,
 		int32(webhookTimeout))
 
+	// KYVERNO CRD INFORMER
+	// watches CRD resources:
+	//		- Policy
+	//		- PolicyVolation
+	// - cache resync time: resyncPeriod
+	pInformer := kyvernoinformer.NewSharedInformerFactoryWithOptions(
+		pclient,
+		resyncPeriod)
+	informers.TransformKyvernoInformers(pInformer)
+
 	// Resource Mutating Webhook Watcher
+	// - the webhook of the policies with the Fail failure policy is registered if one of them exists
 	lastReqTime := checker.NewLastReqTime()
 	rWebhookWatcher := webhookconfig.NewResourceWebhookRegister(
 		lastReqTime,
 		kubeInformer.Admissionregistration().V1beta1().MutatingWebhookConfigurations(),
+		pInformer.Kyverno().V1().ClusterPolicies(),
+		pInformer.Kyverno().V1().Policies(),
 		webhookRegistrationClient,
 	)
 
-	// KYVERNO CRD INFORMER
-	// watches CRD resources:
-	//		- Policy
-	//		- PolicyVolation
-	// - cache resync time: 10 seconds
-	pInformer := kyvernoinformer.NewSharedInformerFactoryWithOptions(
-		pclient,
-		10*time.Second)
-
 	// Configuration Data
 	// dynamically load the configuration from configMap
 	// - resource filters
+	// - global context entries
 	// if the configMap is update, the configuration will be updated :D
+	// the KyvernoConfig kyverno overrides the flags and the configMap, and is reloaded on change
+	var kcInformer kyvernov1informer.KyvernoConfigInformer
+	if reflect.DeepEqual(client.DiscoveryClient.GetGVRFromKind("KyvernoConfig"), schema.GroupVersionResource{}) {
+		glog.Warning("KyvernoConfig CRD not installed, the configuration is loaded from the flags and the ConfigMap only")
+	} else {
+		kcInformer = pInformer.Kyverno().V1().KyvernoConfigs()
+	}
 	configData := config.NewConfigData(
 		kubeClient,
 		kubeInformer.Core().V1().ConfigMaps(),
+		kcInformer,
 		filterK8Resources)
+	configData.AddListener(func() {
+		metrics.DefaultRegistry.Disable(configData.DisabledMetrics())
+	})
+
+	// ConfigMap resolver
+	// - gets the ConfigMaps referenced in the rule context from the informer cache
+	configMapResolver := engine.NewConfigMapResolver(kubeInformer.Core().V1().ConfigMaps().Lister())
+
+	// Image registry client
+	// - fetches the image data referenced in the rule context and the image signatures
+	// - authenticates with the mounted docker config and the cloud identity, for ECR, GCR and ACR
+	// - trusts the CA certificates of the registry TLS Secret, reloaded when the Secret is updated
+	// - fetches the repositories of the mirrored registries from their mirrors
+	// in offline mode the cloud credentials, that are requested from the cloud APIs, are not used
+	// and the registries without mirror are not contacted, unless they are services of the cluster
+	var dockerConfigKeychain registry.Keychain
+	if dockerConfig != "" {
+		dockerConfigKeychain = registry.NewFileKeychain(dockerConfig)
+	}
+	var cloudKeychain registry.Keychain
+	if !offline {
+		cloudKeychain = registry.NewCloudKeychain(nil)
+	}
+	mirrors, err := registry.ParseMirrors(registryMirrors)
+	if err != nil {
+		glog.Fatalf("Invalid registry mirrors: %v\n", err)
+	}
+	var registryHTTPClient *http.Client
+	var registryTLS *registry.TLSConfig
+	if registryTLSSecret != "" {
+		registryTLS = registry.NewTLSConfig(kubeKyvernoInformer.Core().V1().Secrets(), registryTLSSecret, enginelog.Glog)
+		registryHTTPClient = &http.Client{Timeout: 10 * time.Second, Transport: registryTLS}
+	}
+	registryClient := registry.NewClient(registryHTTPClient, registry.NewMultiKeychain(dockerConfigKeychain, cloudKeychain), mirrors)
+	registryClient.SetOffline(offline)
+	registryClient.SetLogger(enginelog.Glog)
+
+	// External service client
+	// - calls the external services referenced in the rule context, the responses are cached
+	// - in offline mode only the services of the cluster are called
+	// - the authorization Secrets are read from the informer cache of the allowed namespaces
+	serviceClient := externaldata.NewClient()
+	serviceClient.SetOffline(offline)
+	serviceClient.SetLogger(enginelog.Glog)
+	var secretNamespaces []string
+	var secretInformers []kubeinformers.SharedInformerFactory
+	secretListers := map[string]corelisters.SecretLister{}
+	for _, ns := range strings.Split(serviceSecretNamespaces, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" {
+			continue
+		}
+		secretNamespaces = append(secretNamespaces, ns)
+		if ns == config.KubePolicyNamespace {
+			secretListers[ns] = kubeKyvernoInformer.Core().V1().Secrets().Lister()
+			continue
+		}
+		secretInformer := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, resyncPeriod, kubeinformers.WithNamespace(ns))
+		secretListers[ns] = secretInformer.Core().V1().Secrets().Lister()
+		secretInformers = append(secretInformers, secretInformer)
+	}
+	serviceClient.SetSecretListers(secretListers)
+
+	// Global context
+	// - caches the data of the global context entries in the configuration
+	globalContext := engine.NewGlobalContext(configData, configMapResolver, client)
+
+	// Image verifiers
+	// - verify the cosign and the Notary v2 image signatures of the verifyImages rules, the verified digests are cached
+	// - in offline mode the Rekor entries are not fetched, the keyless signatures must have a bundle
+	imageVerifier := cosign.NewVerifier(registryClient)
+	if offline {
+		imageVerifier = cosign.NewOfflineVerifier(registryClient)
+	}
+	imageVerifier.SetLogger(enginelog.Glog)
+	notaryVerifier := notary.NewVerifier(registryClient)
 
 	// Policy meta-data store
-	policyMetaStore := policystore.NewPolicyStore(pInformer.Kyverno().V1().ClusterPolicies())
+	policyMetaStore := policystore.NewPolicyStore(pInformer.Kyverno().V1().ClusterPolicies(), pInformer.Kyverno().V1().Policies())
+	// Policy exception store, the exceptions of the kyverno namespace apply to all the namespaces
+	exceptionStore := policyexception.NewStore(pInformer.Kyverno().V1().PolicyExceptions(), config.KubePolicyNamespace)
+	exceptionStore.SetLogger(enginelog.Glog)
+
+	// WRITE RATE LIMITERS
+	// - the events, and the violations written by the generators of the violations, the reports and the report requests,
+	//   are limited separately
+	eventLimiter := ratelimit.NewLimiter(eventsQPS, eventsBurst)
+	violationLimiter := ratelimit.NewLimiter(violationsQPS, violationsBurst)
 
 	// EVENT GENERATOR
 	// - generate event with retry mechanism
+	// - the events on the policies are batched
 	egen := event.NewEventGenerator(
 		client,
-		pInformer.Kyverno().V1().ClusterPolicies())
+		pInformer.Kyverno().V1().ClusterPolicies(),
+		eventLimiter)
 
 	// POLICY VIOLATION GENERATOR
 	// -- generate policy violation
 	pvgen := policyviolation.NewPVGenerator(pclient,
 		client,
 		pInformer.Kyverno().V1().ClusterPolicyViolations(),
-		pInformer.Kyverno().V1().PolicyViolations())
+		pInformer.Kyverno().V1().PolicyViolations(),
+		violationLimiter)
+
+	// POLICY REPORT GENERATOR
+	// -- aggregates the policy violations in the PolicyReports of the namespaces and the ClusterPolicyReport
+	// -- the pending violations of a namespace are merged in a single write
+	prgen := policyreport.NewGenerator(client, reportMaxResults, violationLimiter)
+	var violationGen policyviolation.GeneratorInterface
+	switch reports {
+	case "violations":
+		violationGen = pvgen
+	case "policyreports":
+		violationGen = prgen
+	case "both":
+		violationGen = policyviolation.NewMultiGenerator(pvgen, prgen)
+	default:
+		glog.Fatalf("Invalid reports %q, must be violations, policyreports or both\n", reports)
+	}
+
+	// COMPLIANCE SUMMARIES
+	// -- maintains the ComplianceSummary of the namespaces, the counts of the results per policy
+	csc := compliance.NewController(pclient, client, pInformer.Kyverno().V1().ClusterPolicies(), violationLimiter)
+	if complianceSummaries {
+		if reflect.DeepEqual(client.DiscoveryClient.GetGVRFromKind("ComplianceSummary"), schema.GroupVersionResource{}) {
+			glog.Warning("ComplianceSummary CRD not installed, the compliance summaries are disabled")
+			complianceSummaries = false
+		} else {
+			violationGen = policyviolation.NewMultiGenerator(violationGen, csc)
+		}
+	}
+
+	// NOTIFIER
+	// -- sends the new violations and the blocked requests to the webhooks, Slack and syslog sinks
+	var notifierConfig notification.Config
+	if notificationConfig != "" {
+		notifierConfig, err = notification.LoadConfig(notificationConfig)
+		if err != nil {
+			glog.Fatalf("Failed to load the notification config: %v\n", err)
+		}
+	}
+	notifier, err := notification.NewNotifier(notifierConfig)
+	if err != nil {
+		glog.Fatalf("Failed to initialize the notification sinks: %v\n", err)
+	}
+	if len(notifierConfig.Sinks) != 0 {
+		violationGen = policyviolation.NewMultiGenerator(violationGen, notifier)
+	}
+
+	// VIOLATION CLEANUP
+	// -- removes the violations of the deleted resources, policies and rules
+	// -- removes the violations above the retention limits
+	retention := policyviolation.Retention{MaxAge: retentionMaxAge, MaxPerNamespace: retentionMaxPerNamespace}
+	pvcc := policyviolation.NewCleanupController(pclient,
+		client,
+		pInformer.Kyverno().V1().ClusterPolicies(),
+		pInformer.Kyverno().V1().ClusterPolicyViolations(),
+		pInformer.Kyverno().V1().PolicyViolations(),
+		retention)
+	prcc := policyreport.NewCleanupController(client, pInformer.Kyverno().V1().ClusterPolicies(), retention)
+	// runReports starts the generators of the violations and the reports, and the notifier
+	runReports := func() {
+		if reports != "policyreports" {
+			go pvgen.Run(1, stopCh)
+		}
+		if reports != "violations" {
+			go prgen.Run(1, stopCh)
+		}
+		if len(notifierConfig.Sinks) != 0 {
+			go notifier.Run(stopCh)
+		}
+	}
+	// runReportControllers starts the cleanup of the violations and the reports, and the compliance summaries,
+	// on the leader with the leader election
+	runReportControllers := func(stopCh <-chan struct{}) {
+		if reports != "policyreports" {
+			go pvcc.Run(stopCh)
+		}
+		if reports != "violations" {
+			go prcc.Run(stopCh)
+		}
+		if complianceSummaries {
+			go csc.Run(1, stopCh)
+		}
+	}
+
+	// SHUTDOWN
+	// -- the writers stop queueing and write the queued items, in order, until the shutdown timeout
+	// -- the writers started last are drained last, e.g. the events of the violations
+	type writer struct {
+		name  string
+		drain func(timeout time.Duration) bool
+	}
+	drain := func(writers ...writer) {
+		deadline := time.Now().Add(shutdownTimeout)
+		for _, w := range writers {
+			if !w.drain(time.Until(deadline)) {
+				glog.Warningf("shutdown timeout: the queued %s are not all written", w.name)
+			}
+		}
+	}
+
+	// LEADER ELECTION
+	// -- the controllers run on the leader of the replicas, all the replicas serve the webhooks
+	// -- the reports controllers elect their own leader
+	// -- the leaders release their lock on shutdown, kyverno waits for the release
+	var electors utils.Workers
+	runLeader := func(name string, run func(stopCh <-chan struct{})) {
+		if !leaderElection {
+			run(stopCh)
+			return
+		}
+		id, err := os.Hostname()
+		if err != nil {
+			glog.Fatalf("Failed to get the identity of the replica: %v\n", err)
+		}
+		elector, err := leader.NewElector(kubeClient, name, id, run)
+		if err != nil {
+			glog.Fatalf("Failed to initialize the leader election: %v\n", err)
+		}
+		electors.Go(func() { elector.Run(stopCh) }, stopCh)
+	}
+
+	// REPORT REQUESTS
+	// -- embedded: the violations are written by kyverno
+	// -- requests: the violations are written as ReportRequests, processed by the reports controller Deployment,
+	//    the admission requests do not wait for the writes of the violations and the reports
+	// -- controller: runs the reports controller only, without the webhooks
+	rrgen := reportrequest.NewGenerator(pclient, violationLimiter)
+	switch reportsMode {
+	case "embedded":
+	case "requests", "controller":
+		if reflect.DeepEqual(client.DiscoveryClient.GetGVRFromKind("ReportRequest"), schema.GroupVersionResource{}) {
+			glog.Fatalf("ReportRequest CRD not installed, required by the reports mode %s\n", reportsMode)
+		}
+	default:
+		glog.Fatalf("Invalid reports mode %q, must be embedded, requests or controller\n", reportsMode)
+	}
+	if reportsMode == "requests" {
+		violationGen = rrgen
+	}
+	if reportsMode == "controller" {
+		rrc := reportrequest.NewController(pclient, pInformer.Kyverno().V1().ReportRequests(), violationGen)
+		pInformer.Start(stopCh)
+		runReports()
+		runLeader("kyverno-reports-controller", func(stopCh <-chan struct{}) {
+			runReportControllers(stopCh)
+			go rrc.Run(1, stopCh)
+		})
+		registerMetrics(pInformer.Kyverno().V1().ClusterPolicies().Lister(), map[string]func() int{
+			"report-request":     rrc.Len,
+			"policy-violation":   pvgen.Len,
+			"policy-report":      prgen.Len,
+			"notification":       notifier.Len,
+			"compliance-summary": csc.Len,
+		})
+		if metricsAddr != "" {
+			go serveMetrics(metricsAddr, stopCh)
+		}
+		if profile {
+			go serveProfile(profilePort, stopCh)
+		}
+		<-stopCh
+		drain(
+			writer{"policy violations", pvgen.Drain},
+			writer{"policy reports", prgen.Drain},
+			writer{"notifications", notifier.Drain})
+		if !electors.Wait(shutdownTimeout) {
+			glog.Warning("shutdown timeout: the leader lock is not released")
+		}
+		glog.Info("successful shutdown of kyverno reports controller")
+		return
+	}
+
+	// GENERATE REQUEST GENERATOR
+	grgen := webhookgenerate.NewGenerator(pclient, stopCh)
 
 	// POLICY CONTROLLER
 	// - reconciliation policy and policy violation
 	// - process policy on existing resources
+	// - creates generate requests for existing resources (generateExisting)
 	// - status aggregator: receives stats when a policy is applied
 	//					    & updates the policy status
 	pc, err := policy.NewPolicyController(pclient,
 		client,
 		pInformer.Kyverno().V1().ClusterPolicies(),
+		pInformer.Kyverno().V1().Policies(),
 		pInformer.Kyverno().V1().ClusterPolicyViolations(),
 		pInformer.Kyverno().V1().PolicyViolations(),
+		pInformer.Kyverno().V1().GenerateRequests(),
+		kubeInformer.Core().V1().Namespaces(),
+		kubedynamicInformer,
 		configData,
 		egen,
-		pvgen,
+		violationGen,
 		policyMetaStore,
-		rWebhookWatcher)
+		rWebhookWatcher,
+		grgen,
+		configMapResolver,
+		registryClient,
+		serviceClient,
+		globalContext,
+		exceptionStore,
+		ratelimit.NewQueueRateLimiter(policyRetryBaseDelay, policyRetryMaxDelay, policyQueueQPS, policyQueueBurst),
+		backgroundScanInterval)
 	if err != nil {
 		glog.Fatalf("error creating policy controller: %v\n", err)
 	}
+	// the settings not set in the KyvernoConfig use the flags
+	configData.AddListener(func() {
+		timeout, ok := configData.WebhookTimeout()
+		if !ok {
+			timeout = int32(webhookTimeout)
+		}
+		if err := webhookRegistrationClient.SetTimeout(timeout); err != nil {
+			glog.Error(err)
+		}
+		interval, ok := configData.BackgroundScanInterval()
+		if !ok {
+			interval = backgroundScanInterval
+		}
+		pc.SetScanInterval(interval)
+	})
 
-	// GENERATE REQUEST GENERATOR
-	grgen := webhookgenerate.NewGenerator(pclient, stopCh)
+	// REMOTE CLUSTER SCANNER
+	// - scans the existing resources of the remote clusters of the kubeconfig contexts, read-only
+	// - the violations of each cluster are reported in its ClusterPolicyReport
+	var remoteScanner *policy.RemoteScanner
+	if clusterKubeconfig != "" {
+		var contexts []string
+		if clusterContexts != "" {
+			contexts = strings.Split(clusterContexts, ",")
+		}
+		configs, err := config.CreateContextConfigs(clusterKubeconfig, contexts)
+		if err != nil {
+			glog.Fatalf("Error loading the remote clusters: %v\n", err)
+		}
+		var clusters []policy.RemoteCluster
+		for _, c := range configs {
+			c.Config.QPS = float32(clientQPS)
+			c.Config.Burst = clientBurst
+			remoteClient, err := dclient.NewClient(c.Config, discoveryRefresh, dclient.NewRetry(clientRetries, clientRetryDelay), stopCh)
+			if err != nil {
+				glog.Fatalf("Error creating the client of cluster %s: %v\n", c.Name, err)
+			}
+			remoteClient.SetListPageSize(listPageSize)
+			clusters = append(clusters, policy.RemoteCluster{Name: c.Name, Client: remoteClient})
+		}
+		remoteScanner = policy.NewRemoteScanner(clusters,
+			client,
+			pInformer.Kyverno().V1().ClusterPolicies(),
+			configData,
+			registryClient,
+			serviceClient,
+			reportMaxResults,
+			violationLimiter,
+			clusterScanInterval)
+	}
 
 	// GENERATE CONTROLLER
 	// - applies generate rules on resources based on generate requests created by webhook
@@ -170,11 +608,16 @@ func main() {
 		pInformer.Kyverno().V1().ClusterPolicies(),
 		pInformer.Kyverno().V1().GenerateRequests(),
 		egen,
-		pvgen,
+		violationGen,
 		kubedynamicInformer,
+		configMapResolver,
+		registryClient,
+		serviceClient,
+		globalContext,
 	)
 	// GENERATE REQUEST CLEANUP
 	// -- cleans up the generate requests that have not been processed(i.e. state = [Pending, Failed]) for more than defined timeout
+	// -- deletes the generated resources when the policy or the trigger resource is deleted
 	grcc := generatecleanup.NewController(
 		pclient,
 		client,
@@ -183,6 +626,11 @@ func main() {
 		kubedynamicInformer,
 	)
 
+	// POLICY SOURCE CONTROLLER
+	// - loads the cluster policies of the policy bundles and the Git repositories of the KyvernoConfig
+	// - the signatures of the bundles are verified with their key, the sync results are reported in the status of the KyvernoConfig
+	psc := policysource.NewController(pclient, kubeClient, configData, registryClient, imageVerifier, git.NewClient(nil))
+
 	// CONFIGURE CERTIFICATES
 	tlsPair, err := client.InitTLSPemPair(clientConfig, fqdncn)
 	if err != nil {
@@ -194,8 +642,11 @@ func main() {
 	// - verifymutatingwebhookconfiguration (Kyverno Deployment)
 	// resource webhook confgiuration is generated dynamically in the webhook server and policy controller
 	// based on the policy resources created
-	if err = webhookRegistrationClient.Register(); err != nil {
-		glog.Fatalf("Failed registering Admission Webhooks: %v\n", err)
+	// with the leader election the missing configurations are created by the leader, the existing ones are kept
+	if !leaderElection {
+		if err = webhookRegistrationClient.Register(); err != nil {
+			glog.Fatalf("Failed registering Admission Webhooks: %v\n", err)
+		}
 	}
 
 	// WEBHOOOK
@@ -216,31 +667,124 @@ func main() {
 		pc.GetPolicyStatusAggregator(),
 		configData,
 		policyMetaStore,
-		pvgen,
+		violationGen,
 		grgen,
 		rWebhookWatcher,
+		configMapResolver,
+		registryClient,
+		serviceClient,
+		globalContext,
+		exceptionStore,
+		imageVerifier,
+		notaryVerifier,
+		notifier,
 		cleanUp)
 	if err != nil {
 		glog.Fatalf("Unable to create webhook server: %v\n", err)
 	}
+	server.SetServiceSecretNamespaces(secretNamespaces)
 	// Start the components
 	pInformer.Start(stopCh)
 	kubeInformer.Start(stopCh)
 	kubedynamicInformer.Start(stopCh)
+	kubeKyvernoInformer.Start(stopCh)
+	for _, secretInformer := range secretInformers {
+		secretInformer.Start(stopCh)
+	}
 	go grgen.Run(1)
-	go rWebhookWatcher.Run(stopCh)
 	go configData.Run(stopCh)
+	if registryTLS != nil {
+		go registryTLS.Run(stopCh)
+	}
 	go policyMetaStore.Run(stopCh)
-	go pc.Run(1, stopCh)
+	go exceptionStore.Run(stopCh)
+	go pc.RunStatusAggregator(stopCh)
 	go egen.Run(1, stopCh)
-	go grc.Run(1, stopCh)
-	go grcc.Run(1, stopCh)
-	go pvgen.Run(1, stopCh)
-
-	// verifys if the admission control is enabled and active
-	// resync: 60 seconds
-	// deadline: 60 seconds (send request)
-	// max deadline: deadline*3 (set the deployment annotation as false)
+	if reportsMode == "requests" {
+		// the blocked requests are notified by the webhooks
+		go rrgen.Run(1, stopCh)
+		if len(notifierConfig.Sinks) != 0 {
+			go notifier.Run(stopCh)
+		}
+	} else {
+		runReports()
+	}
+	runLeader("kyverno", func(stopCh <-chan struct{}) {
+		if leaderElection {
+			if err := webhookRegistrationClient.EnsureRegistered(); err != nil {
+				glog.Fatalf("Failed registering Admission Webhooks: %v\n", err)
+			}
+		}
+		// the deployment annotations of the webhook status are updated by the leader only
+		go rWebhookWatcher.Run(stopCh)
+		go server.RunChecker(stopCh)
+		go pc.Run(policyWorkers, stopCh)
+		if remoteScanner != nil {
+			go remoteScanner.Run(stopCh)
+		}
+		go grc.Run(1, stopCh)
+		go grcc.Run(1, stopCh)
+		go psc.Run(stopCh)
+		if reportsMode != "requests" {
+			runReportControllers(stopCh)
+		}
+	})
+
+	// METRICS
+	// - Prometheus metrics served at /metrics
+	registerMetrics(pInformer.Kyverno().V1().ClusterPolicies().Lister(), map[string]func() int{
+		"policy":             pc.Len,
+		"background-scan":    pc.ScanLen,
+		"generate":           grc.Len,
+		"generate-request":   grgen.Len,
+		"event":              egen.Len,
+		"policy-violation":   pvgen.Len,
+		"policy-report":      prgen.Len,
+		"report-request":     rrgen.Len,
+		"notification":       notifier.Len,
+		"compliance-summary": csc.Len,
+	})
+	if metricsAddr != "" {
+		go serveMetrics(metricsAddr, stopCh)
+	}
+
+	// PROFILING
+	// - pprof profiles and expvar variables on localhost, to investigate the latency and the memory in production
+	if profile {
+		go serveProfile(profilePort, stopCh)
+	}
+
+	// EVALUATION
+	// - the authenticated endpoint applying the policies on the resources posted by the external tools
+	if evaluationAddr != "" {
+		evaluationServer, err := evaluation.NewServer(
+			evaluationAddr,
+			tlsPair,
+			evaluation.NewAuthorizer(kubeClient),
+			policyMetaStore,
+			client,
+			configMapResolver,
+			registryClient,
+			serviceClient,
+			globalContext,
+			exceptionStore,
+			kubeInformer.Rbac().V1().RoleBindings(),
+			kubeInformer.Rbac().V1().ClusterRoleBindings())
+		if err != nil {
+			glog.Fatalf("Unable to create the evaluation server: %v\n", err)
+		}
+		go evaluationServer.Run(stopCh)
+	}
+
+	// TRACING
+	// - spans of the admission requests, their policies, rules, context lookups and image verifications
+	if otlpEndpoint != "" {
+		tracer := tracing.NewTracer(otlpEndpoint, &http.Client{Timeout: 10 * time.Second})
+		tracing.SetTracer(tracer)
+		go tracer.Run(stopCh)
+	}
+
+	// all the replicas serve the webhooks
 	server.RunAsync(stopCh)
 
 	<-stopCh
@@ -253,10 +797,26 @@ func main() {
 		cancel()
 	}()
 	// cleanup webhookconfigurations followed by webhook shutdown
-	server.Stop(ctx)
+	// with the leader election the webhook configurations are kept for the other replicas
+	if leaderElection {
+		server.StopServing(ctx)
+	} else {
+		server.Stop(ctx)
+	}
 	// resource cleanup
 	// remove webhook configurations
 	<-cleanUp
+	// the webhooks are stopped, write the queued items
+	drain(
+		writer{"generate requests", grgen.Drain},
+		writer{"report requests", rrgen.Drain},
+		writer{"policy violations", pvgen.Drain},
+		writer{"policy reports", prgen.Drain},
+		writer{"notifications", notifier.Drain},
+		writer{"events", egen.Drain})
+	if !electors.Wait(shutdownTimeout) {
+		glog.Warning("shutdown timeout: the leader lock is not released")
+	}
 	glog.Info("successful shutdown of kyverno controller")
 }
 
@@ -267,6 +827,45 @@ func init() {
 	flag.StringVar(&serverIP, "serverIP", "", "IP address where Kyverno controller runs. Only required if out-of-cluster.")
 	// Generate CSR with CN as FQDN due to https://github.com/nirmata/kyverno/issues/542
 	flag.BoolVar(&fqdncn, "fqdn-as-cn", false, "use FQDN as Common Name in CSR")
+	flag.StringVar(&dockerConfig, "dockerConfig", "", "Path to a docker config.json with the credentials of the private registries, e.g. a mounted image pull secret.")
+	flag.StringVar(&registryTLSSecret, "registryTLSSecret", "", "Name of the Secret of the kyverno namespace with the CA certificates (ca.crt) and the insecure registries (insecureRegistries) used to connect to the registries.")
+	flag.StringVar(&registryMirrors, "registryMirrors", "", "Comma separated mirrors of the registries the images and their signatures are fetched from, e.g. docker.io=mirror.corp.com/docker.io,ghcr.io=mirror.corp.com/ghcr.io")
+	flag.BoolVar(&offline, "offline", false, "Make no outbound internet calls, for disconnected clusters: the keyless signatures must have a Rekor bundle, the cloud registry credentials are not used, the registries without mirror and the external services that are not services of the cluster (*.svc) are not contacted.")
+	flag.StringVar(&serviceSecretNamespaces, "serviceSecretNamespaces", config.KubePolicyNamespace, "Comma separated namespaces of the Secrets the authSecret of the service context entries can refer to, the policies referring to the Secrets of the other namespaces are rejected.")
+	flag.StringVar(&reports, "reports", "violations", "Output of the policy violations: violations for the ClusterPolicyViolations and PolicyViolations, policyreports for the wgpolicyk8s.io PolicyReports and ClusterPolicyReport, or both.")
+	flag.Float64Var(&clientQPS, "clientQPS", 20, "Average number of requests per second of the clients to the API server.")
+	flag.IntVar(&clientBurst, "clientBurst", 50, "Maximum burst of requests of the clients to the API server above clientQPS.")
+	flag.IntVar(&clientRetries, "clientRetries", 3, "Number of retries of the get, list, create and update requests failing with a transient error, e.g. a timeout or an unavailable API server. Disabled if 0.")
+	flag.DurationVar(&clientRetryDelay, "clientRetryDelay", 100*time.Millisecond, "Delay of the first retry of a request failing with a transient error, doubled on each retry.")
+	flag.DurationVar(&discoveryRefresh, "discoveryRefresh", 5*time.Minute, "Period of the refresh of the resources discovered from the API server. The resources are also discovered again when a CRD is added, changed or removed.")
+	flag.Int64Var(&listPageSize, "listPageSize", dclient.DefaultListPageSize, "Maximum number of resources listed at once by the background scans, all the resources of a kind in a namespace are listed at once if 0.")
+	flag.StringVar(&clusterKubeconfig, "clusterKubeconfig", "", "Path to the kubeconfig of the remote clusters whose existing resources are scanned with the cluster policies, read-only. The violations of each cluster are reported in its ClusterPolicyReport clusterpolicyreport-cluster-<context>.")
+	flag.StringVar(&clusterContexts, "clusterContexts", "", "Comma separated contexts of the remote clusters of clusterKubeconfig to scan, all its contexts if empty.")
+	flag.DurationVar(&clusterScanInterval, "clusterScanInterval", time.Hour, "Interval of the scans of the remote clusters.")
+	flag.BoolVar(&profile, "profile", false, "Serve the pprof profiles at /debug/pprof/ and the expvar variables at /debug/vars on localhost, reached with kubectl port-forward.")
+	flag.IntVar(&profilePort, "profilePort", 6060, "Port of localhost the profiles are served on with --profile.")
+	flag.StringVar(&evaluationAddr, "evaluationAddr", "", "Address of the HTTPS evaluation API "+evaluation.Path+", the users and the service accounts allowed to create the evaluations of the kyverno.io group post a resource and get the results of the policies. Disabled if empty.")
+	flag.StringVar(&metricsAddr, "metricsAddr", ":8000", "Address of the Prometheus metrics endpoint /metrics, disabled if empty.")
+	flag.StringVar(&otlpEndpoint, "otlpEndpoint", "", "OTLP HTTP endpoint of the OpenTelemetry collector the traces of the admission requests are exported to, e.g. http://otel-collector.monitoring:4318. Tracing is disabled if empty.")
+	flag.StringVar(&notificationConfig, "notificationConfig", "", "Path to the YAML config of the sinks (webhook, slack or syslog) the new policy violations and the blocked requests are sent to, e.g. mounted from a Secret.")
+	flag.DurationVar(&retentionMaxAge, "retentionMaxAge", 0, "Maximum age of the policy violations and of the results of the policy reports not reported since, e.g. 720h. Disabled if 0.")
+	flag.IntVar(&retentionMaxPerNamespace, "retentionMaxPerNamespace", 0, "Maximum count of policy violations, and of results of the policy reports, per namespace; the least recently reported are removed first. Disabled if 0.")
+	flag.IntVar(&reportMaxResults, "reportMaxResults", 1000, "Maximum count of results of a PolicyReport or ClusterPolicyReport, the larger reports are split in chunks named <report>-1, <report>-2... Unlimited if 0.")
+	flag.StringVar(&reportsMode, "reportsMode", "embedded", "Where the policy violations are written: embedded by kyverno, requests to write them as ReportRequests processed by the reports controller, or controller to run the reports controller only, without the webhooks.")
 	config.LogDefaultFlags()
+	flag.Float64Var(&eventsQPS, "eventsQPS", 10, "Average number of events written per second, unlimited if 0.")
+	flag.IntVar(&eventsBurst, "eventsBurst", 50, "Maximum burst of events written above eventsQPS.")
+	flag.Float64Var(&violationsQPS, "violationsQPS", 10, "Average number of policy violations, policy reports and report requests written per second, unlimited if 0.")
+	flag.IntVar(&violationsBurst, "violationsBurst", 50, "Maximum burst of policy violations, policy reports and report requests written above violationsQPS.")
+	flag.BoolVar(&complianceSummaries, "complianceSummaries", true, "Maintain a ComplianceSummary per namespace with the count of the resources passing and failing each policy, requires the ComplianceSummary CRD.")
+	flag.BoolVar(&leaderElection, "leaderElection", false, "Run the controllers on the elected leader of the replicas only, all the replicas serve the webhooks. Required to run several replicas.")
+	flag.IntVar(&policyWorkers, "policyWorkers", 1, "Number of workers of the policy controller processing the policies, e.g. the background scans.")
+	flag.DurationVar(&policyRetryBaseDelay, "policyRetryBaseDelay", 5*time.Millisecond, "Delay of the first retry of a failed policy of the policy controller, doubled on each retry.")
+	flag.DurationVar(&policyRetryMaxDelay, "policyRetryMaxDelay", 1000*time.Second, "Maximum delay of the retries of a failed policy of the policy controller.")
+	flag.Float64Var(&policyQueueQPS, "policyQueueQPS", 10, "Average number of retries of the policies of the policy controller per second, unlimited if 0.")
+	flag.IntVar(&policyQueueBurst, "policyQueueBurst", 100, "Maximum burst of retries of the policies of the policy controller above policyQueueQPS.")
+	flag.DurationVar(&backgroundScanInterval, "backgroundScanInterval", time.Hour, "Interval of the background scans of the existing resources with the policies with background processing, disabled if 0. The scans are also triggered by the kyverno.io/scan annotation of a ClusterPolicy or Namespace.")
+	flag.DurationVar(&shutdownTimeout, "shutdownTimeout", 15*time.Second, "Time to write the queued generate requests, violations, reports, notifications and events on shutdown, after the webhook server is stopped. It must be shorter than the termination grace period of the pod.")
+	flag.DurationVar(&resyncPeriod, "resyncPeriod", 10*time.Second, "Resync period of the informers caching the policies and the watched resources, the update handlers of the controllers are called for all the cached objects on each resync.")
 	flag.Parse()
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/metrics"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// registerMetrics registers the number of the policies by rule type and the depths of the queues
+func registerMetrics(pLister kyvernolister.ClusterPolicyLister, queues map[string]func() int) {
+	countPolicies := func() map[string]int {
+		policies, err := pLister.List(labels.Everything())
+		if err != nil {
+			glog.V(4).Infof("failed to list policies: %v", err)
+			return nil
+		}
+		return metrics.CountPolicies(policies)
+	}
+	for _, ruleType := range []string{"mutate", "validate", "generate", "verifyImages"} {
+		ruleType := ruleType
+		metrics.Policies.Set(ruleType, func() float64 {
+			return float64(countPolicies()[ruleType])
+		})
+	}
+	for name, length := range queues {
+		length := length
+		metrics.QueueDepth.Set(name, func() float64 {
+			return float64(length())
+		})
+	}
+}
+
+// serveMetrics serves the metrics over HTTP until stopCh is closed
+func serveMetrics(addr string, stopCh <-chan struct{}) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.DefaultRegistry.Handler())
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+	}
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	glog.Infof("serving metrics on %s", addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		glog.Errorf("metrics server failed: %v", err)
+	}
+}
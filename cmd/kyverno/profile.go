@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// serveProfile serves the pprof profiles and the expvar variables on the port of localhost until stopCh is closed,
+// they are reached with kubectl port-forward
+func serveProfile(port int, stopCh <-chan struct{}) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	// no write timeout, the CPU profiles and the traces last for the requested duration
+	server := &http.Server{
+		Addr:        addr,
+		Handler:     mux,
+		ReadTimeout: 15 * time.Second,
+	}
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	glog.Infof("serving the profiles on %s", addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		glog.Errorf("profile server failed: %v", err)
+	}
+}
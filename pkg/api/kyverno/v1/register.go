@@ -33,10 +33,20 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ClusterPolicyList{},
 		&ClusterPolicyViolation{},
 		&ClusterPolicyViolationList{},
+		&Policy{},
+		&PolicyList{},
 		&PolicyViolation{},
 		&PolicyViolationList{},
 		&GenerateRequest{},
 		&GenerateRequestList{},
+		&ReportRequest{},
+		&ReportRequestList{},
+		&ComplianceSummary{},
+		&ComplianceSummaryList{},
+		&KyvernoConfig{},
+		&KyvernoConfigList{},
+		&PolicyException{},
+		&PolicyExceptionList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
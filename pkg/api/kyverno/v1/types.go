@@ -2,6 +2,7 @@ package v1
 
 import (
 	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -45,7 +46,16 @@ type GenerateRequestStatus struct {
 	Message string               `json:"message,omitempty"`
 	// This will track the resources that are generated by the generate Policy
 	// Will be used during clean up resources
-	GeneratedResources []ResourceSpec `json:"generatedResources,omitempty"`
+	GeneratedResources []GeneratedResource `json:"generatedResources,omitempty"`
+}
+
+// GeneratedResource stores the information of a resource created by a generate rule
+type GeneratedResource struct {
+	ResourceSpec `json:",inline"`
+	// Rule is the name of the generate rule that created the resource
+	Rule string `json:"rule,omitempty"`
+	// DeletionPolicy is the deletion policy of the rule that created the resource
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
 //GenerateRequestState defines the state of
@@ -69,6 +79,232 @@ type GenerateRequestList struct {
 	Items           []GenerateRequest `json:"items"`
 }
 
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+//ReportRequest is a request of the admission webhook or of the background scans to report the results of a policy
+// for a resource, processed by the reports controller
+type ReportRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ReportRequestSpec `json:"spec"`
+}
+
+//ReportRequestSpec stores the violated rules of the policy for the resource, none if the resource is compliant
+type ReportRequestSpec struct {
+	Policy        string         `json:"policy"`
+	Resource      ReportResource `json:"resource"`
+	ViolatedRules []ViolatedRule `json:"rules,omitempty"`
+}
+
+//ReportResource identifies the resource of a report request
+type ReportResource struct {
+	APIVersion   string `json:"apiVersion,omitempty"`
+	ResourceSpec `json:",inline"`
+	UID          string `json:"uid,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+//ReportRequestList stores the list of report requests
+type ReportRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ReportRequest `json:"items"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+//ComplianceSummary stores the count of the resources of a namespace passing and failing each policy,
+// maintained by kyverno from the results of the policies
+type ComplianceSummary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Summary is the total of the counts of the policies
+	Summary  ComplianceCounts   `json:"summary"`
+	Policies []PolicyCompliance `json:"policies,omitempty"`
+}
+
+//ComplianceCounts stores the count of the resources per result:
+// pass if compliant, fail if violating an enforce policy, warn if violating an audit policy
+type ComplianceCounts struct {
+	Pass int `json:"pass"`
+	Fail int `json:"fail"`
+	Warn int `json:"warn"`
+}
+
+//PolicyCompliance stores the counts of the resources of the namespace for a policy
+type PolicyCompliance struct {
+	Policy           string `json:"policy"`
+	ComplianceCounts `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+//ComplianceSummaryList stores the list of compliance summaries
+type ComplianceSummaryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []ComplianceSummary `json:"items"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+//KyvernoConfig stores the configuration of kyverno, reloaded by the kyverno components when it changes.
+// Only the KyvernoConfig named kyverno is used.
+type KyvernoConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              KyvernoConfigSpec   `json:"spec"`
+	Status            KyvernoConfigStatus `json:"status,omitempty"`
+}
+
+//KyvernoConfigSpec stores the settings of kyverno, they override the flags and the keys of the ConfigMap.
+// The settings not set keep the values of the flags and the ConfigMap.
+type KyvernoConfigSpec struct {
+	// Webhook configures the webhook configurations registered by kyverno
+	Webhook *WebhookSettings `json:"webhook,omitempty"`
+	// ResourceFilters are the resources not processed by the webhooks
+	ResourceFilters []ResourceFilter `json:"resourceFilters,omitempty"`
+	// ExcludedNamespaces are the namespaces whose resources are not processed by the webhooks, with wildcards
+	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
+	// BackgroundScanInterval is the interval of the background scans of the existing resources, 0 disables them
+	BackgroundScanInterval *metav1.Duration `json:"backgroundScanInterval,omitempty"`
+	// GlobalContext are the context entries shared by all policies
+	GlobalContext []GlobalContextEntry `json:"globalContext,omitempty"`
+	// Metrics configures the metrics endpoint
+	Metrics *MetricsSettings `json:"metrics,omitempty"`
+	// PolicySources are the policy bundles whose cluster policies are loaded in the cluster
+	PolicySources []PolicySource `json:"policySources,omitempty"`
+}
+
+//WebhookSettings stores the settings of the webhook configurations
+type WebhookSettings struct {
+	// TimeoutSeconds is the timeout of the admission requests sent to kyverno
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+//ResourceFilter identifies the resources by kind, namespace and name, with wildcards. The fields not set match any value.
+type ResourceFilter struct {
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+//MetricsSettings stores the settings of the metrics endpoint
+type MetricsSettings struct {
+	// DisabledMetrics are the names of the metrics not served, e.g. to limit the cardinality of kyverno_policy_results_total
+	DisabledMetrics []string `json:"disabledMetrics,omitempty"`
+}
+
+//PolicySource stores the location of a policy bundle, the cluster policies of the bundle are created and updated in the cluster
+type PolicySource struct {
+	// Name identifies the source in the label policies.kyverno.io/source of its policies
+	Name string `json:"name"`
+	// OCI is the policy bundle stored in an OCI registry
+	OCI *OCISource `json:"oci,omitempty"`
+	// Git is the directory of the policies in a Git repository
+	Git *GitSource `json:"git,omitempty"`
+	// Interval is the interval of the checks of the updates of the bundle, 5m if not set
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+//OCISource stores the image of a policy bundle pushed with kyverno oci push
+type OCISource struct {
+	// Image is the reference of the bundle, with a tag or a digest
+	Image string `json:"image"`
+	// Key is the PEM encoded public key of the cosign signature of the bundle, the signature is not verified if not set
+	Key string `json:"key,omitempty"`
+}
+
+//GitSource stores the location of the policies in a Git repository, the files of the directory and its subdirectories are loaded
+type GitSource struct {
+	// URL is the URL of the repository, https://host/path, ssh://[user@]host[:port]/path or [user@]host:path
+	URL string `json:"url"`
+	// Branch is the branch of the policies, the default branch of the repository if not set
+	Branch string `json:"branch,omitempty"`
+	// Path is the directory of the policies in the repository, the root of the repository if not set
+	Path string `json:"path,omitempty"`
+	// SecretName is the Secret of the kyverno namespace with the credentials of the repository,
+	// the keys username and password for HTTPS, identity and known_hosts for SSH
+	SecretName string `json:"secretName,omitempty"`
+}
+
+//KyvernoConfigStatus stores the status of the settings of the KyvernoConfig
+type KyvernoConfigStatus struct {
+	// PolicySources are the results of the last sync of the policy sources
+	PolicySources []PolicySourceStatus `json:"policySources,omitempty"`
+}
+
+//PolicySourceStatus stores the result of the last sync of a policy source
+type PolicySourceStatus struct {
+	Name string `json:"name"`
+	// Revision is the digest of the bundle or the commit of the repository of the loaded policies
+	Revision string `json:"revision,omitempty"`
+	// Policies are the names of the loaded policies
+	Policies []string `json:"policies,omitempty"`
+	// LastSyncTime is the time of the last successful sync
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// Error is the error of the last sync, empty if it succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+//KyvernoConfigList stores the list of kyverno configurations
+type KyvernoConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []KyvernoConfig `json:"items"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+//PolicyException exempts the matching resources from rules of policies, without editing the policies.
+// The exceptions of a namespace exempt the resources of the namespace, the exceptions of the kyverno namespace
+// exempt the resources of all the namespaces and the cluster-wide resources.
+type PolicyException struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              PolicyExceptionSpec `json:"spec"`
+}
+
+//PolicyExceptionSpec stores the rules exempted and the resources they are exempted for
+type PolicyExceptionSpec struct {
+	// Exceptions are the policies and the rules the resources are exempted from
+	Exceptions []Exception `json:"exceptions"`
+	// Match selects the exempted resources, as the match block of the rules
+	Match ResourceDescription `json:"match"`
+	// Justification is the reason of the exemption, it is required
+	Justification string `json:"justification"`
+	// ExpiresAt is the time the exception expires at, it does not expire if not set
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+}
+
+//Exception stores the rules of a policy a resource is exempted from
+type Exception struct {
+	// PolicyName is the name of the policy, namespace/name for the namespaced policies
+	PolicyName string `json:"policyName"`
+	// RuleNames are the names of the rules, all the rules of the policy if empty
+	RuleNames []string `json:"ruleNames,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+//PolicyExceptionList stores the list of policy exceptions
+type PolicyExceptionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []PolicyException `json:"items"`
+}
+
 // +genclient
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -116,7 +352,11 @@ type PolicyViolationList struct {
 	Items           []PolicyViolation `json:"items"`
 }
 
-// Policy contains rules to be applied to created resources
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Policy contains rules to be applied to created resources, a Policy applies
+// to the resources of its namespace only
 type Policy struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -124,23 +364,110 @@ type Policy struct {
 	Status            PolicyStatus `json:"status"`
 }
 
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PolicyList ...
+type PolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []Policy `json:"items"`
+}
+
 // Spec describes policy behavior by its rules
 type Spec struct {
 	Rules                   []Rule `json:"rules"`
 	ValidationFailureAction string `json:"validationFailureAction"`
 	Background              *bool  `json:"background"`
+	// GenerateExisting applies the generate rules on the resources that exist when the policy is created
+	GenerateExisting bool `json:"generateExisting,omitempty"`
+	// FailurePolicy is the failure policy of the resource webhook applying the policy, Ignore or Fail,
+	// the requests matched by the policy are rejected with Fail if the webhook server is unavailable
+	FailurePolicy string `json:"failurePolicy,omitempty"`
 }
 
+const (
+	//FailurePolicyIgnore allows the requests if the webhook server is unavailable
+	FailurePolicyIgnore = "Ignore"
+	//FailurePolicyFail rejects the requests if the webhook server is unavailable
+	FailurePolicyFail = "Fail"
+)
+
 // Rule is set of mutation, validation and generation actions
 // for the single resource description
 type Rule struct {
 	Name             string           `json:"name"`
+	Context          []ContextEntry   `json:"context,omitempty"`
 	MatchResources   MatchResources   `json:"match"`
 	ExcludeResources ExcludeResources `json:"exclude,omitempty"`
 	Conditions       []Condition      `json:"preconditions,omitempty"`
 	Mutation         Mutation         `json:"mutate,omitempty"`
 	Validation       Validation       `json:"validate,omitempty"`
 	Generation       Generation       `json:"generate,omitempty"`
+	// VerifyImages verifies the signatures of the images of the matching resources
+	VerifyImages []ImageVerification `json:"verifyImages,omitempty"`
+}
+
+// ContextEntry adds data from an external source to the rule context,
+// the data is available as variables under the name of the entry
+type ContextEntry struct {
+	Name          string              `json:"name"`
+	ConfigMap     *ConfigMapReference `json:"configMap,omitempty"`
+	APICall       *APICall            `json:"apiCall,omitempty"`
+	ImageRegistry *ImageRegistry      `json:"imageRegistry,omitempty"`
+	Service       *ServiceCall        `json:"service,omitempty"`
+}
+
+// ConfigMapReference refers to a ConfigMap
+type ConfigMapReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// APICall gets a resource or a list of resources from the Kubernetes API
+type APICall struct {
+	// URLPath is the path of the API request, e.g. /api/v1/namespaces/{{request.object.metadata.namespace}}/services
+	URLPath string `json:"urlPath"`
+	// JMESPath is applied on the response, the result is added to the context
+	JMESPath string `json:"jmesPath,omitempty"`
+}
+
+// ImageRegistry gets the manifest and the configuration of an image from its registry
+type ImageRegistry struct {
+	// Reference is the image reference, e.g. {{request.object.spec.containers[0].image}}
+	Reference string `json:"reference"`
+	// JMESPath is applied on the image data, the result is added to the context
+	JMESPath string `json:"jmesPath,omitempty"`
+}
+
+// ServiceCall gets JSON data from an external HTTPS service
+type ServiceCall struct {
+	// URL is the HTTPS URL of the service, e.g. https://cmdb.corp.com/apps/{{request.object.metadata.labels.app}}
+	URL string `json:"url"`
+	// CABundle is the PEM encoded CA bundle used to verify the certificate of the service
+	CABundle string `json:"caBundle,omitempty"`
+	// AuthSecret is the key of the Secret with the value of the Authorization header
+	AuthSecret *SecretKeyReference `json:"authSecret,omitempty"`
+	// Timeout of the request, e.g. 5s
+	Timeout string `json:"timeout,omitempty"`
+	// TTL is the duration the response is cached, e.g. 1m
+	TTL string `json:"ttl,omitempty"`
+	// JMESPath is applied on the response, the result is added to the context
+	JMESPath string `json:"jmesPath,omitempty"`
+}
+
+// SecretKeyReference refers to a key of a Secret
+type SecretKeyReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+}
+
+// GlobalContextEntry is a context entry shared by all policies, the data is fetched once,
+// cached for the TTL and available as variables under globalContext.<name>
+type GlobalContextEntry struct {
+	ContextEntry `json:",inline"`
+	// TTL is the duration the data is cached, e.g. 5m
+	TTL string `json:"ttl,omitempty"`
 }
 
 //Condition defines the evaluation condition
@@ -213,13 +540,89 @@ type Validation struct {
 	AnyPattern []interface{} `json:"anyPattern,omitempty"`
 }
 
+// ImageVerification verifies the signatures of the images that match the image reference,
+// the cosign signatures are signed with a key or keyless with a Fulcio certificate recorded in Rekor
+// and the Notary v2 signatures are verified with a trust policy
+type ImageVerification struct {
+	// Image is the image reference pattern, with wildcards, e.g. ghcr.io/kyverno/*
+	// the pattern is matched against the fully qualified image reference, e.g. docker.io/library/nginx:latest
+	Image string `json:"image"`
+	// Key is the PEM encoded public key the images must be signed with
+	Key string `json:"key,omitempty"`
+	// Roots are the PEM encoded root certificates of Fulcio, for keyless signatures
+	Roots string `json:"roots,omitempty"`
+	// Subject is the identity of the certificate, the email or the URI, with wildcards
+	Subject string `json:"subject,omitempty"`
+	// Issuer is the OIDC issuer of the certificate, e.g. https://accounts.google.com
+	Issuer string `json:"issuer,omitempty"`
+	// Rekor is the transparency log the keyless signatures are recorded in
+	Rekor *Rekor `json:"rekor,omitempty"`
+	// Attestations are the in-toto attestations the images must have, signed as the images
+	Attestations []Attestation `json:"attestations,omitempty"`
+	// Notary is the trust policy of Notary v2 signatures, instead of cosign signatures
+	Notary *NotaryVerification `json:"notary,omitempty"`
+	// MutateDigest replaces the tags of the verified images with their digests
+	// and records the verified images in the annotation kyverno.io/verify-images
+	MutateDigest bool `json:"mutateDigest,omitempty"`
+}
+
+// NotaryVerification is the notation trust policy of the Notary v2 signatures
+type NotaryVerification struct {
+	// TrustStore are the PEM encoded certificates of the signing authorities
+	TrustStore string `json:"trustStore"`
+	// TrustedIdentities are the subjects of the signing certificates,
+	// e.g. "x509.subject: C=US, O=Corp, CN=release", or "*" for any subject issued by the trust store
+	TrustedIdentities []string `json:"trustedIdentities"`
+	// SignatureVerification is the verification level: strict (default), permissive or audit
+	SignatureVerification string `json:"signatureVerification,omitempty"`
+}
+
+// Attestation requires an in-toto attestation with the predicate type that satisfies the conditions
+type Attestation struct {
+	// PredicateType is the type of the predicate, e.g. https://slsa.dev/provenance/v0.2
+	PredicateType string `json:"predicateType"`
+	// Conditions are evaluated on the predicate, the variables are relative to the predicate
+	// e.g. {{builder.id}}, one of the attestations of the type must satisfy all the conditions
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// Rekor is a Rekor transparency log
+type Rekor struct {
+	// URL of the Rekor API, used for the signatures that have no bundle, e.g. https://rekor.sigstore.dev
+	// the signatures must have a bundle if not set
+	URL string `json:"url,omitempty"`
+	// PubKey is the PEM encoded public key of Rekor, the signed entry timestamps are verified with it
+	PubKey string `json:"pubKey"`
+}
+
 // Generation describes which resources will be created when other resource is created
 type Generation struct {
 	ResourceSpec
-	Data  interface{} `json:"data"`
-	Clone CloneFrom   `json:"clone"`
+	// Namespaces is a list of target namespaces, the resource is generated in each of them
+	Namespaces []string `json:"namespaces,omitempty"`
+	// NamespaceSelector selects the target namespaces by label, the resource is generated in each matching namespace
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// DeletionPolicy defines what happens to the generated resources when the policy or the trigger resource is deleted
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	Data           interface{}    `json:"data"`
+	Clone          CloneFrom      `json:"clone"`
+	// CloneList clones every resource in a namespace that matches the kinds and the selector
+	CloneList *CloneList `json:"cloneList,omitempty"`
 }
 
+// DeletionPolicy defines what happens to the generated resources
+// when the policy or the trigger resource is deleted
+type DeletionPolicy string
+
+const (
+	//Delete - the generated resources are deleted (default)
+	Delete DeletionPolicy = "Delete"
+	//Orphan - the generated resources are left as is
+	Orphan DeletionPolicy = "Orphan"
+	//OrphanAndLabel - the generated resources are left and labeled as orphaned
+	OrphanAndLabel DeletionPolicy = "OrphanAndLabel"
+)
+
 // CloneFrom - location of the resource
 // which will be used as source when applying 'generate'
 type CloneFrom struct {
@@ -227,6 +630,14 @@ type CloneFrom struct {
 	Name      string `json:"name,omitempty"`
 }
 
+// CloneList - resources in a namespace, of one of the kinds and matching the selector,
+// which will be used as source when applying 'generate'
+type CloneList struct {
+	Namespace string                `json:"namespace,omitempty"`
+	Kinds     []string              `json:"kinds,omitempty"`
+	Selector  *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
 //PolicyStatus provides status for violations
 type PolicyStatus struct {
 	ViolationCount int `json:"violationCount"`
@@ -242,6 +653,60 @@ type PolicyStatus struct {
 	AvgExecutionTimeGeneration string `json:"averageGenerationRulesExecutionTime"`
 	// statistics per rule
 	Rules []RuleStats `json:"ruleStatus"`
+	// progress of the generate rules applied on the existing resources
+	GenerateExisting *GenerateExistingStatus `json:"generateExisting,omitempty"`
+	// Count of rules per type
+	RuleCount RuleCountStatus `json:"ruleCount"`
+	// observations of the state of the policy
+	Conditions []PolicyCondition `json:"conditions,omitempty"`
+}
+
+//RuleCountStatus provides the count of rules per type
+type RuleCountStatus struct {
+	Total        int `json:"total"`
+	Mutate       int `json:"mutate"`
+	Validate     int `json:"validate"`
+	Generate     int `json:"generate"`
+	VerifyImages int `json:"verifyImages"`
+}
+
+const (
+	//PolicyReady is the condition of the policies enforced by the admission webhooks,
+	// it is True once the other conditions are True
+	PolicyReady = "Ready"
+	//PolicyWebhookConfigured is the condition of the policies whose kinds are forwarded to the webhook server
+	PolicyWebhookConfigured = "WebhookConfigured"
+	//PolicyRulesValidated is the condition of the policies whose rules are valid
+	PolicyRulesValidated = "RulesValidated"
+	//PolicyKindsResolved is the condition of the policies whose kinds are served by the API server,
+	// it is a warning and is not required by the Ready condition
+	PolicyKindsResolved = "KindsResolved"
+)
+
+//PolicyCondition provides an observation of the state of the policy
+type PolicyCondition struct {
+	// Type of the condition, e.g. Ready
+	Type string `json:"type"`
+	// Status of the condition, True, False or Unknown
+	Status corev1.ConditionStatus `json:"status"`
+	// Reason of the last transition of the condition
+	Reason string `json:"reason,omitempty"`
+	// Message describing the last transition of the condition
+	Message string `json:"message,omitempty"`
+	// Time of the last transition of the condition
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+//GenerateExistingStatus provides the progress of the generate requests created for the existing resources
+type GenerateExistingStatus struct {
+	// Count of generate requests
+	Total int `json:"total"`
+	// Count of generate requests yet to be processed
+	Pending int `json:"pending"`
+	// Count of generate requests processed successfully
+	Completed int `json:"completed"`
+	// Count of generate requests that failed
+	Failed int `json:"failed"`
 }
 
 //RuleStats provides status per rule
@@ -256,6 +721,12 @@ type RuleStats struct {
 	ViolationCount int `json:"violationCount"`
 	// Count of mutations
 	MutationCount int `json:"mutationsCount"`
+	// Count of executions of the rule
+	ExecutionCount int `json:"executionCount"`
+	// 95th percentile of the recent execution times of the rule
+	P95ExecutionTime string `json:"p95ExecutionTime,omitempty"`
+	// 99th percentile of the recent execution times of the rule
+	P99ExecutionTime string `json:"p99ExecutionTime,omitempty"`
 }
 
 // PolicyList is a list of Policy resources
@@ -292,6 +763,10 @@ type ViolatedRule struct {
 //PolicyViolationStatus provides information regarding policyviolation status
 // status:
 //		LastUpdateTime : the time the polivy violation was updated
+//		Count : the number of times the violation was reported, by the admission requests and the background scans
+//		LastSeen : the last time the violation was reported
 type PolicyViolationStatus struct {
 	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+	Count          int         `json:"count,omitempty"`
+	LastSeen       metav1.Time `json:"lastSeen,omitempty"`
 }
@@ -1,17 +1,54 @@
 package v1
 
-import "reflect"
+import (
+	"reflect"
+	"time"
+)
 
 //HasMutateOrValidateOrGenerate checks for rule types
 func (p ClusterPolicy) HasMutateOrValidateOrGenerate() bool {
 	for _, rule := range p.Spec.Rules {
-		if rule.HasMutate() || rule.HasValidate() || rule.HasGenerate() {
+		if rule.HasMutate() || rule.HasValidate() || rule.HasGenerate() || rule.HasVerifyImages() {
 			return true
 		}
 	}
 	return false
 }
 
+//ToClusterPolicy converts the namespaced policy to the ClusterPolicy applied by the engine,
+//the rules are restricted to the namespace of the policy
+func (p *Policy) ToClusterPolicy() *ClusterPolicy {
+	policy := ClusterPolicy(*p.DeepCopy())
+	for i := range policy.Spec.Rules {
+		policy.Spec.Rules[i].MatchResources.Namespaces = []string{p.Namespace}
+	}
+	return &policy
+}
+
+//Contains returns true if the exception exempts the resources from the rule of the policy,
+//the name of the namespaced policies is namespace/name
+func (e *PolicyException) Contains(policy, rule string) bool {
+	for _, exception := range e.Spec.Exceptions {
+		if exception.PolicyName != policy {
+			continue
+		}
+		if len(exception.RuleNames) == 0 {
+			return true
+		}
+		for _, name := range exception.RuleNames {
+			if name == rule {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//HasExpired returns true if the exception expires before now
+func (e *PolicyException) HasExpired(now time.Time) bool {
+	return e.Spec.ExpiresAt != nil && !now.Before(e.Spec.ExpiresAt.Time)
+}
+
 //HasMutate checks for mutate rule
 func (r Rule) HasMutate() bool {
 	return !reflect.DeepEqual(r.Mutation, Mutation{})
@@ -27,6 +64,11 @@ func (r Rule) HasGenerate() bool {
 	return !reflect.DeepEqual(r.Generation, Generation{})
 }
 
+//HasVerifyImages checks for verifyImages rule
+func (r Rule) HasVerifyImages() bool {
+	return len(r.VerifyImages) > 0
+}
+
 // DeepCopyInto is declared because k8s:deepcopy-gen is
 // not able to generate this method for interface{} member
 func (in *Mutation) DeepCopyInto(out *Mutation) {
@@ -56,6 +98,16 @@ func (in *Validation) DeepCopyInto(out *Validation) {
 func (gen *Generation) DeepCopyInto(out *Generation) {
 	if out != nil {
 		*out = *gen
+		if gen.Namespaces != nil {
+			out.Namespaces = make([]string, len(gen.Namespaces))
+			copy(out.Namespaces, gen.Namespaces)
+		}
+		if gen.NamespaceSelector != nil {
+			out.NamespaceSelector = gen.NamespaceSelector.DeepCopy()
+		}
+		if gen.CloneList != nil {
+			out.CloneList = gen.CloneList.DeepCopy()
+		}
 	}
 }
 
@@ -36,14 +36,34 @@ func (c *FakeKyvernoV1) ClusterPolicyViolations() v1.ClusterPolicyViolationInter
 	return &FakeClusterPolicyViolations{c}
 }
 
+func (c *FakeKyvernoV1) ComplianceSummaries(namespace string) v1.ComplianceSummaryInterface {
+	return &FakeComplianceSummaries{c, namespace}
+}
+
 func (c *FakeKyvernoV1) GenerateRequests(namespace string) v1.GenerateRequestInterface {
 	return &FakeGenerateRequests{c, namespace}
 }
 
+func (c *FakeKyvernoV1) KyvernoConfigs() v1.KyvernoConfigInterface {
+	return &FakeKyvernoConfigs{c}
+}
+
+func (c *FakeKyvernoV1) Policies(namespace string) v1.PolicyInterface {
+	return &FakePolicies{c, namespace}
+}
+
+func (c *FakeKyvernoV1) PolicyExceptions(namespace string) v1.PolicyExceptionInterface {
+	return &FakePolicyExceptions{c, namespace}
+}
+
 func (c *FakeKyvernoV1) PolicyViolations(namespace string) v1.PolicyViolationInterface {
 	return &FakePolicyViolations{c, namespace}
 }
 
+func (c *FakeKyvernoV1) ReportRequests(namespace string) v1.ReportRequestInterface {
+	return &FakeReportRequests{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeKyvernoV1) RESTClient() rest.Interface {
@@ -22,6 +22,16 @@ type ClusterPolicyExpansion interface{}
 
 type ClusterPolicyViolationExpansion interface{}
 
+type ComplianceSummaryExpansion interface{}
+
 type GenerateRequestExpansion interface{}
 
+type KyvernoConfigExpansion interface{}
+
+type PolicyExpansion interface{}
+
+type PolicyExceptionExpansion interface{}
+
 type PolicyViolationExpansion interface{}
+
+type ReportRequestExpansion interface{}
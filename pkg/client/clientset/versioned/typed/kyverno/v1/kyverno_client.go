@@ -29,8 +29,13 @@ type KyvernoV1Interface interface {
 	RESTClient() rest.Interface
 	ClusterPoliciesGetter
 	ClusterPolicyViolationsGetter
+	ComplianceSummariesGetter
 	GenerateRequestsGetter
+	KyvernoConfigsGetter
+	PoliciesGetter
+	PolicyExceptionsGetter
 	PolicyViolationsGetter
+	ReportRequestsGetter
 }
 
 // KyvernoV1Client is used to interact with features provided by the kyverno.io group.
@@ -46,14 +51,34 @@ func (c *KyvernoV1Client) ClusterPolicyViolations() ClusterPolicyViolationInterf
 	return newClusterPolicyViolations(c)
 }
 
+func (c *KyvernoV1Client) ComplianceSummaries(namespace string) ComplianceSummaryInterface {
+	return newComplianceSummaries(c, namespace)
+}
+
 func (c *KyvernoV1Client) GenerateRequests(namespace string) GenerateRequestInterface {
 	return newGenerateRequests(c, namespace)
 }
 
+func (c *KyvernoV1Client) KyvernoConfigs() KyvernoConfigInterface {
+	return newKyvernoConfigs(c)
+}
+
+func (c *KyvernoV1Client) Policies(namespace string) PolicyInterface {
+	return newPolicies(c, namespace)
+}
+
+func (c *KyvernoV1Client) PolicyExceptions(namespace string) PolicyExceptionInterface {
+	return newPolicyExceptions(c, namespace)
+}
+
 func (c *KyvernoV1Client) PolicyViolations(namespace string) PolicyViolationInterface {
 	return newPolicyViolations(c, namespace)
 }
 
+func (c *KyvernoV1Client) ReportRequests(namespace string) ReportRequestInterface {
+	return newReportRequests(c, namespace)
+}
+
 // NewForConfig creates a new KyvernoV1Client for the given config.
 func NewForConfig(c *rest.Config) (*KyvernoV1Client, error) {
 	config := *c
@@ -57,10 +57,20 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Kyverno().V1().ClusterPolicies().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("clusterpolicyviolations"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Kyverno().V1().ClusterPolicyViolations().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("compliancesummaries"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Kyverno().V1().ComplianceSummaries().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("generaterequests"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Kyverno().V1().GenerateRequests().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("kyvernoconfigs"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Kyverno().V1().KyvernoConfigs().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("policies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Kyverno().V1().Policies().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("policyexceptions"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Kyverno().V1().PolicyExceptions().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("policyviolations"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Kyverno().V1().PolicyViolations().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("reportrequests"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Kyverno().V1().ReportRequests().Informer()}, nil
 
 	}
 
@@ -28,10 +28,20 @@ type Interface interface {
 	ClusterPolicies() ClusterPolicyInformer
 	// ClusterPolicyViolations returns a ClusterPolicyViolationInformer.
 	ClusterPolicyViolations() ClusterPolicyViolationInformer
+	// ComplianceSummaries returns a ComplianceSummaryInformer.
+	ComplianceSummaries() ComplianceSummaryInformer
 	// GenerateRequests returns a GenerateRequestInformer.
 	GenerateRequests() GenerateRequestInformer
+	// KyvernoConfigs returns a KyvernoConfigInformer.
+	KyvernoConfigs() KyvernoConfigInformer
+	// Policies returns a PolicyInformer.
+	Policies() PolicyInformer
+	// PolicyExceptions returns a PolicyExceptionInformer.
+	PolicyExceptions() PolicyExceptionInformer
 	// PolicyViolations returns a PolicyViolationInformer.
 	PolicyViolations() PolicyViolationInformer
+	// ReportRequests returns a ReportRequestInformer.
+	ReportRequests() ReportRequestInformer
 }
 
 type version struct {
@@ -55,12 +65,37 @@ func (v *version) ClusterPolicyViolations() ClusterPolicyViolationInformer {
 	return &clusterPolicyViolationInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// ComplianceSummaries returns a ComplianceSummaryInformer.
+func (v *version) ComplianceSummaries() ComplianceSummaryInformer {
+	return &complianceSummaryInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // GenerateRequests returns a GenerateRequestInformer.
 func (v *version) GenerateRequests() GenerateRequestInformer {
 	return &generateRequestInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// KyvernoConfigs returns a KyvernoConfigInformer.
+func (v *version) KyvernoConfigs() KyvernoConfigInformer {
+	return &kyvernoConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// Policies returns a PolicyInformer.
+func (v *version) Policies() PolicyInformer {
+	return &policyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// PolicyExceptions returns a PolicyExceptionInformer.
+func (v *version) PolicyExceptions() PolicyExceptionInformer {
+	return &policyExceptionInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // PolicyViolations returns a PolicyViolationInformer.
 func (v *version) PolicyViolations() PolicyViolationInformer {
 	return &policyViolationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// ReportRequests returns a ReportRequestInformer.
+func (v *version) ReportRequests() ReportRequestInformer {
+	return &reportRequestInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
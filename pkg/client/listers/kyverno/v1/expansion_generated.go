@@ -41,6 +41,22 @@ type ClusterPolicyViolationListerExpansion interface {
 	ListResources(selector labels.Selector) (ret []*kyvernov1.ClusterPolicyViolation, err error)
 }
 
+// PolicyListerExpansion allows custom methods to be added to
+// PolicyLister.
+type PolicyListerExpansion interface{}
+
+// PolicyNamespaceListerExpansion allows custom methods to be added to
+// PolicyNamespaceLister.
+type PolicyNamespaceListerExpansion interface{}
+
+// PolicyExceptionListerExpansion allows custom methods to be added to
+// PolicyExceptionLister.
+type PolicyExceptionListerExpansion interface{}
+
+// PolicyExceptionNamespaceListerExpansion allows custom methods to be added to
+// PolicyExceptionNamespaceLister.
+type PolicyExceptionNamespaceListerExpansion interface{}
+
 // PolicyViolationListerExpansion allows custom methods to be added to
 // PolicyViolationLister.
 type PolicyViolationListerExpansion interface{}
@@ -49,6 +65,26 @@ type PolicyViolationListerExpansion interface{}
 // PolicyViolationNamespaceLister.
 type PolicyViolationNamespaceListerExpansion interface{}
 
+// ReportRequestListerExpansion allows custom methods to be added to
+// ReportRequestLister.
+type ReportRequestListerExpansion interface{}
+
+// ReportRequestNamespaceListerExpansion allows custom methods to be added to
+// ReportRequestNamespaceLister.
+type ReportRequestNamespaceListerExpansion interface{}
+
+// ComplianceSummaryListerExpansion allows custom methods to be added to
+// ComplianceSummaryLister.
+type ComplianceSummaryListerExpansion interface{}
+
+// ComplianceSummaryNamespaceListerExpansion allows custom methods to be added to
+// ComplianceSummaryNamespaceLister.
+type ComplianceSummaryNamespaceListerExpansion interface{}
+
+// KyvernoConfigListerExpansion allows custom methods to be added to
+// KyvernoConfigLister.
+type KyvernoConfigListerExpansion interface{}
+
 //ListResources is a wrapper to List and adds the resource kind information
 // as the lister is specific to a gvk we can harcode the values here
 func (pvl *clusterPolicyViolationLister) ListResources(selector labels.Selector) (ret []*kyvernov1.ClusterPolicyViolation, err error) {
@@ -0,0 +1,150 @@
+package compliance
+
+import (
+	"sort"
+	"sync"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+)
+
+// summaryName is the name of the ComplianceSummary of a namespace
+const summaryName = "compliance-summary"
+
+// enforce is the validation failure action of the policies blocking the violating resources
+const enforce = "enforce"
+
+// resourceResult is the latest result of a policy for a resource
+type resourceResult struct {
+	kind     string
+	name     string
+	violated bool
+}
+
+// results stores the latest result of each policy for each resource, per namespace
+type results struct {
+	// namespace -> policy -> kind/name of the resource
+	data map[string]map[string]map[string]resourceResult
+	mu   sync.Mutex
+}
+
+func newResults() *results {
+	return &results{data: map[string]map[string]map[string]resourceResult{}}
+}
+
+// set stores the result of the violation, it returns false if the result did not change
+func (r *results) set(info policyviolation.Info) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	namespace := info.Resource.GetNamespace()
+	if r.data[namespace] == nil {
+		r.data[namespace] = map[string]map[string]resourceResult{}
+	}
+	resources := r.data[namespace][info.PolicyName]
+	if resources == nil {
+		resources = map[string]resourceResult{}
+		r.data[namespace][info.PolicyName] = resources
+	}
+	result := resourceResult{kind: info.Resource.GetKind(), name: info.Resource.GetName(), violated: len(info.Rules) != 0}
+	key := result.kind + "/" + result.name
+	if previous, ok := resources[key]; ok && previous == result {
+		return false
+	}
+	resources[key] = result
+	return true
+}
+
+// namespaces returns the namespaces with results
+func (r *results) namespaces() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var namespaces []string
+	for namespace := range r.data {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// remove removes the results of the deleted policies and resources of the namespace,
+// it returns false if the results did not change
+func (r *results) remove(namespace string, policyExists func(name string) bool, resourceExists func(kind, namespace, name string) bool) bool {
+	r.mu.Lock()
+	policies := map[string][]resourceResult{}
+	for policy, resources := range r.data[namespace] {
+		for _, result := range resources {
+			policies[policy] = append(policies[policy], result)
+		}
+	}
+	r.mu.Unlock()
+
+	// the lookups are done without the lock, the results set in the meantime are kept
+	removedPolicies := map[string]bool{}
+	removedResources := map[string]bool{}
+	for policy, resources := range policies {
+		if !policyExists(policy) {
+			removedPolicies[policy] = true
+			continue
+		}
+		for _, result := range resources {
+			key := result.kind + "/" + result.name
+			if _, ok := removedResources[key]; ok {
+				continue
+			}
+			removedResources[key] = !resourceExists(result.kind, namespace, result.name)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed := false
+	for policy, resources := range r.data[namespace] {
+		if removedPolicies[policy] {
+			delete(r.data[namespace], policy)
+			changed = true
+			continue
+		}
+		for key := range resources {
+			if removedResources[key] {
+				delete(resources, key)
+				changed = true
+			}
+		}
+		if len(resources) == 0 {
+			delete(r.data[namespace], policy)
+		}
+	}
+	return changed
+}
+
+// summarize returns the counts of the results of the namespace per policy, sorted by policy,
+// the violations of the enforce policies are failures and the violations of the audit policies are warnings
+func (r *results) summarize(namespace string, action func(policy string) string) (kyverno.ComplianceCounts, []kyverno.PolicyCompliance) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var total kyverno.ComplianceCounts
+	var policies []kyverno.PolicyCompliance
+	for policy, resources := range r.data[namespace] {
+		if len(resources) == 0 {
+			continue
+		}
+		enforced := action(policy) == enforce
+		counts := kyverno.ComplianceCounts{}
+		for _, result := range resources {
+			switch {
+			case !result.violated:
+				counts.Pass++
+			case enforced:
+				counts.Fail++
+			default:
+				counts.Warn++
+			}
+		}
+		total.Pass += counts.Pass
+		total.Fail += counts.Fail
+		total.Warn += counts.Warn
+		policies = append(policies, kyverno.PolicyCompliance{Policy: policy, ComplianceCounts: counts})
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Policy < policies[j].Policy })
+	return total, policies
+}
@@ -0,0 +1,109 @@
+package compliance
+
+import (
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/client/clientset/versioned/fake"
+	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+var rule = kyverno.ViolatedRule{Name: "check-app", Type: "Validation", Message: "label app is required"}
+
+func newInfo(policy, namespace, name string, rules ...kyverno.ViolatedRule) policyviolation.Info {
+	resource := unstructured.Unstructured{}
+	resource.SetAPIVersion("v1")
+	resource.SetKind("Pod")
+	resource.SetNamespace(namespace)
+	resource.SetName(name)
+	return policyviolation.Info{PolicyName: policy, Resource: resource, Rules: rules}
+}
+
+func newPolicy(name, action string) *kyverno.ClusterPolicy {
+	return &kyverno.ClusterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       kyverno.Spec{ValidationFailureAction: action},
+	}
+}
+
+func Test_results_summarize(t *testing.T) {
+	r := newResults()
+	assert.Assert(t, r.set(newInfo("require-labels", "default", "nginx", rule)))
+	assert.Assert(t, r.set(newInfo("require-labels", "default", "redis")))
+	assert.Assert(t, r.set(newInfo("disallow-latest-tag", "default", "nginx", rule)))
+	assert.Assert(t, r.set(newInfo("require-labels", "test", "nginx")))
+	// the unchanged results are ignored
+	assert.Assert(t, !r.set(newInfo("require-labels", "default", "redis")))
+
+	actions := map[string]string{"require-labels": "enforce", "disallow-latest-tag": "audit"}
+	total, policies := r.summarize("default", func(policy string) string { return actions[policy] })
+	assert.DeepEqual(t, total, kyverno.ComplianceCounts{Pass: 1, Fail: 1, Warn: 1})
+	assert.DeepEqual(t, policies, []kyverno.PolicyCompliance{
+		{Policy: "disallow-latest-tag", ComplianceCounts: kyverno.ComplianceCounts{Warn: 1}},
+		{Policy: "require-labels", ComplianceCounts: kyverno.ComplianceCounts{Pass: 1, Fail: 1}},
+	})
+	assert.DeepEqual(t, r.namespaces(), []string{"default", "test"})
+
+	// the latest result of the resource replaces the previous one
+	assert.Assert(t, r.set(newInfo("require-labels", "default", "nginx")))
+	total, _ = r.summarize("default", func(policy string) string { return actions[policy] })
+	assert.DeepEqual(t, total, kyverno.ComplianceCounts{Pass: 2, Warn: 1})
+}
+
+func Test_results_remove(t *testing.T) {
+	r := newResults()
+	r.set(newInfo("require-labels", "default", "nginx", rule))
+	r.set(newInfo("require-labels", "default", "redis"))
+	r.set(newInfo("disallow-latest-tag", "default", "redis", rule))
+
+	policyExists := func(name string) bool { return name != "disallow-latest-tag" }
+	resourceExists := func(kind, namespace, name string) bool { return name != "nginx" }
+	assert.Assert(t, r.remove("default", policyExists, resourceExists))
+	total, policies := r.summarize("default", func(string) string { return "" })
+	assert.DeepEqual(t, total, kyverno.ComplianceCounts{Pass: 1})
+	assert.Equal(t, len(policies), 1)
+	assert.Assert(t, !r.remove("default", policyExists, resourceExists))
+}
+
+func Test_Controller_syncHandler(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	assert.NilError(t, indexer.Add(newPolicy("require-labels", "audit")))
+	c := &Controller{
+		kyvernoInterface: client.KyvernoV1(),
+		pLister:          kyvernolister.NewClusterPolicyLister(indexer),
+		queue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), workQueueName),
+		results:          newResults(),
+	}
+	c.Add(newInfo("require-labels", "default", "nginx", rule), newInfo("require-labels", "default", "redis"))
+	// the cluster-wide resources are not summarized
+	c.Add(newInfo("require-labels", "", "default"))
+	assert.Equal(t, c.Len(), 1)
+
+	assert.NilError(t, c.syncHandler("default"))
+	summary, err := client.KyvernoV1().ComplianceSummaries("default").Get(summaryName, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, summary.Summary, kyverno.ComplianceCounts{Pass: 1, Warn: 1})
+
+	// the violations of the enforce policies are failures
+	assert.NilError(t, indexer.Update(newPolicy("require-labels", "enforce")))
+	assert.NilError(t, c.syncHandler("default"))
+	summary, err = client.KyvernoV1().ComplianceSummaries("default").Get(summaryName, metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, summary.Summary, kyverno.ComplianceCounts{Pass: 1, Fail: 1})
+	assert.DeepEqual(t, summary.Policies, []kyverno.PolicyCompliance{
+		{Policy: "require-labels", ComplianceCounts: kyverno.ComplianceCounts{Pass: 1, Fail: 1}},
+	})
+
+	// no summary is created without results
+	assert.NilError(t, c.syncHandler("test"))
+	list, err := client.KyvernoV1().ComplianceSummaries("test").List(metav1.ListOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(list.Items), 0)
+}
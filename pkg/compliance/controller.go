@@ -0,0 +1,197 @@
+package compliance
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	kyvernoclient "github.com/nirmata/kyverno/pkg/client/clientset/versioned"
+	kyvernov1 "github.com/nirmata/kyverno/pkg/client/clientset/versioned/typed/kyverno/v1"
+	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
+	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"github.com/nirmata/kyverno/pkg/ratelimit"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const workQueueName = "compliance-summary-controller"
+const workQueueRetryLimit = 3
+
+// Controller maintains the ComplianceSummary of the namespaces from the results of the policies,
+// the results of the deleted policies and resources are removed every policyviolation.CleanupInterval.
+// The results of the cluster-wide resources are not summarized.
+type Controller struct {
+	kyvernoInterface kyvernov1.KyvernoV1Interface
+	dclient          *dclient.Client
+	pLister          kyvernolister.ClusterPolicyLister
+	pSynced          cache.InformerSynced
+	limiter          *ratelimit.Limiter
+	queue            workqueue.RateLimitingInterface
+	results          *results
+}
+
+// NewController returns a new instance of the compliance summary controller, the writes of the summaries are limited by limiter
+func NewController(client *kyvernoclient.Clientset,
+	dclient *dclient.Client,
+	pInformer kyvernoinformer.ClusterPolicyInformer,
+	limiter *ratelimit.Limiter) *Controller {
+	return &Controller{
+		kyvernoInterface: client.KyvernoV1(),
+		dclient:          dclient,
+		pLister:          pInformer.Lister(),
+		pSynced:          pInformer.Informer().HasSynced,
+		limiter:          limiter,
+		queue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), workQueueName),
+		results:          newResults(),
+	}
+}
+
+// Add stores the results of the policies, the summaries of the changed namespaces are queued
+func (c *Controller) Add(infos ...policyviolation.Info) {
+	for _, info := range infos {
+		namespace := info.Resource.GetNamespace()
+		if namespace == "" || info.Resource.GetName() == "" {
+			continue
+		}
+		if c.results.set(info) {
+			c.queue.Add(namespace)
+		}
+	}
+}
+
+// Run starts the workers, and removes the results of the deleted policies and resources every policyviolation.CleanupInterval
+func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+	glog.Info("Starting compliance summary controller")
+	defer glog.Info("Shutting down compliance summary controller")
+
+	if !cache.WaitForCacheSync(stopCh, c.pSynced) {
+		glog.Error("compliance summary controller: failed to sync informer cache")
+		return
+	}
+	for i := 0; i < workers; i++ {
+		go wait.Until(c.runWorker, time.Second, stopCh)
+	}
+	go wait.Until(c.cleanup, policyviolation.CleanupInterval, stopCh)
+	<-stopCh
+}
+
+func (c *Controller) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *Controller) processNextWorkItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+	err := c.syncHandler(key.(string))
+	if err == nil {
+		c.queue.Forget(key)
+		return true
+	}
+	if c.queue.NumRequeues(key) < workQueueRetryLimit {
+		glog.V(4).Infof("Error syncing compliance summary of namespace %v: %v", key, err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	glog.Errorf("Dropping compliance summary of namespace %v out of the queue: %v", key, err)
+	return true
+}
+
+// cleanup removes the results of the deleted policies and resources, and queues every summary
+// as the validation failure actions of the policies may have changed
+func (c *Controller) cleanup() {
+	for _, namespace := range c.results.namespaces() {
+		if c.results.remove(namespace, c.policyExists, c.resourceExists) {
+			glog.V(4).Infof("cleanup the compliance results of namespace %s", namespace)
+		}
+		c.queue.Add(namespace)
+	}
+}
+
+// syncHandler creates or updates the summary of the namespace
+func (c *Controller) syncHandler(namespace string) error {
+	total, policies := c.results.summarize(namespace, c.policyAction)
+	summaries := c.kyvernoInterface.ComplianceSummaries(namespace)
+	existing, err := summaries.Get(summaryName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		if len(policies) == 0 {
+			return nil
+		}
+		summary := &kyverno.ComplianceSummary{
+			ObjectMeta: metav1.ObjectMeta{Name: summaryName, Namespace: namespace},
+			Summary:    total,
+			Policies:   policies,
+		}
+		c.limiter.Wait()
+		if _, err := summaries.Create(summary); err != nil {
+			if apierrors.IsNotFound(err) {
+				// the namespace was deleted
+				return nil
+			}
+			return err
+		}
+		glog.V(4).Infof("Created compliance summary of namespace %s", namespace)
+		return nil
+	}
+	if existing.Summary == total && reflect.DeepEqual(existing.Policies, policies) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Summary = total
+	updated.Policies = policies
+	c.limiter.Wait()
+	if _, err := summaries.Update(updated); err != nil {
+		return err
+	}
+	glog.V(4).Infof("Updated compliance summary of namespace %s", namespace)
+	return nil
+}
+
+// policyAction returns the validation failure action of the policy, audit if not set
+func (c *Controller) policyAction(name string) string {
+	policy, err := c.pLister.Get(name)
+	if err != nil {
+		return ""
+	}
+	return policy.Spec.ValidationFailureAction
+}
+
+// policyExists returns false if the policy was deleted, true if the lookup fails
+func (c *Controller) policyExists(name string) bool {
+	if _, err := c.pLister.Get(name); err != nil && apierrors.IsNotFound(err) {
+		return false
+	}
+	return true
+}
+
+// resourceExists returns false if the resource was deleted, true if the lookup fails
+func (c *Controller) resourceExists(kind, namespace, name string) bool {
+	if _, err := c.dclient.GetResource(kind, namespace, name); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		glog.V(4).Infof("failed to get resource %s/%s/%s: %v", kind, namespace, name, err)
+	}
+	return true
+}
+
+// Len returns the number of the namespaces with queued summaries
+func (c *Controller) Len() int {
+	return c.queue.Len()
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"flag"
+	"fmt"
+	"sort"
 
 	"github.com/golang/glog"
 	rest "k8s.io/client-go/rest"
@@ -22,6 +24,8 @@ const (
 	MutatingWebhookConfigurationDebugName = "kyverno-resource-mutating-webhook-cfg-debug"
 	//MutatingWebhookName default resource mutating webhook name
 	MutatingWebhookName = "nirmata.kyverno.resource.mutating-webhook"
+	//MutatingWebhookFailName is the resource mutating webhook applying the policies with the Fail failure policy
+	MutatingWebhookFailName = "nirmata.kyverno.resource-fail.mutating-webhook"
 
 	// ValidatingWebhookConfigurationName  = "kyverno-validating-webhook-cfg"
 	// ValidatingWebhookConfigurationDebug = "kyverno-validating-webhook-cfg-debug"
@@ -40,6 +44,8 @@ const (
 	PolicyValidatingWebhookConfigurationDebugName = "kyverno-policy-validating-webhook-cfg-debug"
 	//PolicyValidatingWebhookName default policy validating webhook name
 	PolicyValidatingWebhookName = "nirmata.kyverno.policy-validating-webhook"
+	//NamespacedPolicyValidatingWebhookName default namespaced policy validating webhook name
+	NamespacedPolicyValidatingWebhookName = "nirmata.kyverno.namespaced-policy-validating-webhook"
 
 	//PolicyMutatingWebhookConfigurationName default policy mutating webhook configuration name
 	PolicyMutatingWebhookConfigurationName = "kyverno-policy-mutating-webhook-cfg"
@@ -47,6 +53,8 @@ const (
 	PolicyMutatingWebhookConfigurationDebugName = "kyverno-policy-mutating-webhook-cfg-debug"
 	//PolicyMutatingWebhookName default policy mutating webhook name
 	PolicyMutatingWebhookName = "nirmata.kyverno.policy-mutating-webhook"
+	//NamespacedPolicyMutatingWebhookName default namespaced policy mutating webhook name
+	NamespacedPolicyMutatingWebhookName = "nirmata.kyverno.namespaced-policy-mutating-webhook"
 
 	// Due to kubernetes issue, we must use next literal constants instead of deployment TypeMeta fields
 	// Issue: https://github.com/kubernetes/kubernetes/pull/63972
@@ -59,15 +67,25 @@ const (
 	DeploymentAPIVersion = "extensions/v1beta1"
 	// KubePolicyDeploymentName define the default deployment namespace
 	KubePolicyDeploymentName = "kyverno"
+
+	// GenerateFieldManager is the field manager used to apply the generated resources
+	GenerateFieldManager = "kyverno-generate"
+
+	// ScanAnnotation triggers a background scan of the ClusterPolicy or of the Namespace it is set on, when its value changes
+	ScanAnnotation = "kyverno.io/scan"
 )
 
 var (
 	//MutatingWebhookServicePath is the path for mutation webhook
 	MutatingWebhookServicePath = "/mutate"
+	//MutatingWebhookFailServicePath is the path for mutation webhook of the policies with the Fail failure policy
+	MutatingWebhookFailServicePath = "/mutate/fail"
 	//ValidatingWebhookServicePath is the path for validation webhook
 	ValidatingWebhookServicePath = "/validate"
 	//PolicyValidatingWebhookServicePath is the path for policy validation webhook(used to validate policy resource)
 	PolicyValidatingWebhookServicePath = "/policyvalidate"
+	//NamespacedPolicyValidatingWebhookServicePath is the path for namespaced policy validation webhook(used to validate namespaced policy resource)
+	NamespacedPolicyValidatingWebhookServicePath = "/namespacedpolicyvalidate"
 	//PolicyMutatingWebhookServicePath is the path for policy mutation webhook(used to default)
 	PolicyMutatingWebhookServicePath = "/policymutate"
 	//VerifyMutatingWebhookServicePath is the path for verify webhook(used to veryfing if admission control is enabled and active)
@@ -100,3 +118,38 @@ func CreateClientConfig(kubeconfig string) (*rest.Config, error) {
 	glog.Infof("Using configuration from '%s'", kubeconfig)
 	return clientcmd.BuildConfigFromFlags("", kubeconfig)
 }
+
+// ContextConfig is the client config of a context of a kubeconfig
+type ContextConfig struct {
+	Name   string
+	Config *rest.Config
+}
+
+// CreateContextConfigs creates the client configs of the contexts of the kubeconfig, of all its contexts sorted by name if none is set.
+// The kubeconfig is loaded from $KUBECONFIG or ~/.kube/config if empty
+func CreateContextConfigs(kubeconfig string, contexts []string) ([]ContextConfig, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	rawConfig, err := loadingRules.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(contexts) == 0 {
+		for name := range rawConfig.Contexts {
+			contexts = append(contexts, name)
+		}
+		sort.Strings(contexts)
+	}
+	var configs []ContextConfig
+	for _, name := range contexts {
+		if _, ok := rawConfig.Contexts[name]; !ok {
+			return nil, fmt.Errorf("context %s not found", name)
+		}
+		config, err := clientcmd.NewNonInteractiveClientConfig(*rawConfig, name, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the config of context %s: %v", name, err)
+		}
+		configs = append(configs, ContextConfig{Name: name, Config: config})
+	}
+	return configs, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+- name: staging
+  cluster:
+    server: https://staging.example.com
+users:
+- name: scanner
+  user:
+    token: secret
+contexts:
+- name: staging
+  context:
+    cluster: staging
+    user: scanner
+- name: prod
+  context:
+    cluster: prod
+    user: scanner
+current-context: prod
+`
+
+func Test_CreateContextConfigs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	kubeconfig := filepath.Join(dir, "config")
+	assert.NilError(t, ioutil.WriteFile(kubeconfig, []byte(testKubeconfig), 0600))
+
+	// all the contexts, sorted
+	configs, err := CreateContextConfigs(kubeconfig, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(configs), 2)
+	assert.Equal(t, configs[0].Name, "prod")
+	assert.Equal(t, configs[0].Config.Host, "https://prod.example.com")
+	assert.Equal(t, configs[0].Config.BearerToken, "secret")
+	assert.Equal(t, configs[1].Name, "staging")
+	assert.Equal(t, configs[1].Config.Host, "https://staging.example.com")
+
+	configs, err = CreateContextConfigs(kubeconfig, []string{"staging"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(configs), 1)
+	assert.Equal(t, configs[0].Config.Host, "https://staging.example.com")
+
+	_, err = CreateContextConfigs(kubeconfig, []string{"dev"})
+	assert.Error(t, err, "context dev not found")
+}
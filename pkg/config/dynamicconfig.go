@@ -7,10 +7,14 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/minio/minio/pkg/wildcard"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
 	informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
@@ -20,6 +24,9 @@ import (
 // this configmap stores the resources that are to be filtered
 const cmNameEnv string = "INIT_CONFIG"
 
+// KyvernoConfigName is the name of the KyvernoConfig used by kyverno, the other ones are ignored
+const KyvernoConfigName = "kyverno"
+
 // ConfigData stores the configuration
 type ConfigData struct {
 	client kubernetes.Interface
@@ -29,15 +36,32 @@ type ConfigData struct {
 	mux sync.RWMutex
 	// configuration data
 	filters []k8Resource
+	// context entries shared by all policies
+	globalContext []kyverno.GlobalContextEntry
+	// settings of the KyvernoConfig, nil if it does not exist
+	settings        *kyverno.KyvernoConfigSpec
+	settingsFilters []k8Resource
+	// listeners are called when the KyvernoConfig changes
+	listeners []func()
 	// hasynced
 	cmSycned cache.InformerSynced
+	kcSynced cache.InformerSynced
 }
 
 // ToFilter checks if the given resource is set to be filtered in the configuration
 func (cd *ConfigData) ToFilter(kind, namespace, name string) bool {
 	cd.mux.RLock()
 	defer cd.mux.RUnlock()
-	for _, f := range cd.filters {
+	filters := cd.filters
+	if cd.settings != nil {
+		if len(cd.settings.ResourceFilters) != 0 {
+			filters = cd.settingsFilters
+		}
+		if namespace != "" && contains(cd.settings.ExcludedNamespaces, namespace) {
+			return true
+		}
+	}
+	for _, f := range filters {
 		if wildcard.Match(f.Kind, kind) && wildcard.Match(f.Namespace, namespace) && wildcard.Match(f.Name, name) {
 			return true
 		}
@@ -45,13 +69,80 @@ func (cd *ConfigData) ToFilter(kind, namespace, name string) bool {
 	return false
 }
 
+func contains(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if wildcard.Match(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// GlobalContextEntries returns the context entries shared by all policies
+func (cd *ConfigData) GlobalContextEntries() []kyverno.GlobalContextEntry {
+	cd.mux.RLock()
+	defer cd.mux.RUnlock()
+	if cd.settings != nil && len(cd.settings.GlobalContext) != 0 {
+		return cd.settings.GlobalContext
+	}
+	return cd.globalContext
+}
+
+// WebhookTimeout returns the timeout of the webhook configurations of the KyvernoConfig, false if not set
+func (cd *ConfigData) WebhookTimeout() (int32, bool) {
+	cd.mux.RLock()
+	defer cd.mux.RUnlock()
+	if cd.settings == nil || cd.settings.Webhook == nil || cd.settings.Webhook.TimeoutSeconds == nil {
+		return 0, false
+	}
+	return *cd.settings.Webhook.TimeoutSeconds, true
+}
+
+// BackgroundScanInterval returns the interval of the background scans of the KyvernoConfig, false if not set
+func (cd *ConfigData) BackgroundScanInterval() (time.Duration, bool) {
+	cd.mux.RLock()
+	defer cd.mux.RUnlock()
+	if cd.settings == nil || cd.settings.BackgroundScanInterval == nil {
+		return 0, false
+	}
+	return cd.settings.BackgroundScanInterval.Duration, true
+}
+
+// DisabledMetrics returns the names of the metrics not served of the KyvernoConfig
+func (cd *ConfigData) DisabledMetrics() []string {
+	cd.mux.RLock()
+	defer cd.mux.RUnlock()
+	if cd.settings == nil || cd.settings.Metrics == nil {
+		return nil
+	}
+	return cd.settings.Metrics.DisabledMetrics
+}
+
+// PolicySources returns the policy sources of the KyvernoConfig
+func (cd *ConfigData) PolicySources() []kyverno.PolicySource {
+	cd.mux.RLock()
+	defer cd.mux.RUnlock()
+	if cd.settings == nil {
+		return nil
+	}
+	return cd.settings.PolicySources
+}
+
+// AddListener adds a function called when the KyvernoConfig is loaded, changed or deleted
+func (cd *ConfigData) AddListener(listener func()) {
+	cd.mux.Lock()
+	defer cd.mux.Unlock()
+	cd.listeners = append(cd.listeners, listener)
+}
+
 // Interface to be used by consumer to check filters
 type Interface interface {
 	ToFilter(kind, namespace, name string) bool
 }
 
-// NewConfigData ...
-func NewConfigData(rclient kubernetes.Interface, cmInformer informers.ConfigMapInformer, filterK8Resources string) *ConfigData {
+// NewConfigData returns the configuration loaded from the flags, the ConfigMap and the KyvernoConfig kyverno,
+// kcInformer is nil if the KyvernoConfig CRD is not installed
+func NewConfigData(rclient kubernetes.Interface, cmInformer informers.ConfigMapInformer, kcInformer kyvernoinformer.KyvernoConfigInformer, filterK8Resources string) *ConfigData {
 	// environment var is read at start only
 	if cmNameEnv == "" {
 		glog.Info("ConfigMap name not defined in env:INIT_CONFIG: loading no default configuration")
@@ -73,13 +164,25 @@ func NewConfigData(rclient kubernetes.Interface, cmInformer informers.ConfigMapI
 		UpdateFunc: cd.updateCM,
 		DeleteFunc: cd.deleteCM,
 	})
+	if kcInformer != nil {
+		cd.kcSynced = kcInformer.Informer().HasSynced
+		kcInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    cd.addKC,
+			UpdateFunc: cd.updateKC,
+			DeleteFunc: cd.deleteKC,
+		})
+	}
 	return &cd
 }
 
 //Run checks syncing
 func (cd *ConfigData) Run(stopCh <-chan struct{}) {
 	// wait for cache to populate first time
-	if !cache.WaitForCacheSync(stopCh, cd.cmSycned) {
+	synced := []cache.InformerSynced{cd.cmSycned}
+	if cd.kcSynced != nil {
+		synced = append(synced, cd.kcSynced)
+	}
+	if !cache.WaitForCacheSync(stopCh, synced...) {
 		glog.Error("configuration: failed to sync informer cache")
 	}
 }
@@ -129,6 +232,11 @@ func (cd *ConfigData) load(cm v1.ConfigMap) {
 		glog.V(4).Infof("Configuration: No data defined in ConfigMap %s", cm.Name)
 		return
 	}
+	cd.loadFilters(cm)
+	cd.loadGlobalContext(cm)
+}
+
+func (cd *ConfigData) loadFilters(cm v1.ConfigMap) {
 	// get resource filters
 	filters, ok := cm.Data["resourceFilters"]
 	if !ok {
@@ -155,6 +263,24 @@ func (cd *ConfigData) load(cm v1.ConfigMap) {
 	cd.filters = newFilters
 }
 
+// loadGlobalContext loads the global context entries, a YAML list of context entries with a TTL
+func (cd *ConfigData) loadGlobalContext(cm v1.ConfigMap) {
+	var entries []kyverno.GlobalContextEntry
+	if raw, ok := cm.Data["globalContext"]; ok && strings.TrimSpace(raw) != "" {
+		if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(raw), 4096).Decode(&entries); err != nil {
+			glog.Errorf("Configuration: failed to parse globalContext in ConfigMap %s: %v", cm.Name, err)
+			return
+		}
+	}
+	cd.mux.Lock()
+	defer cd.mux.Unlock()
+	if reflect.DeepEqual(entries, cd.globalContext) {
+		return
+	}
+	glog.Infof("Configuration: New global context entries %v", entries)
+	cd.globalContext = entries
+}
+
 //TODO: this has been added to backward support command line arguments
 // will be removed in future and the configuration will be set only via configmaps
 func (cd *ConfigData) initFilters(filters string) {
@@ -175,6 +301,108 @@ func (cd *ConfigData) unload(cm v1.ConfigMap) {
 	cd.mux.Lock()
 	defer cd.mux.Unlock()
 	cd.filters = []k8Resource{}
+	cd.globalContext = nil
+}
+
+func (cd *ConfigData) addKC(obj interface{}) {
+	kc := obj.(*kyverno.KyvernoConfig)
+	if kc.Name != KyvernoConfigName {
+		return
+	}
+	cd.loadSettings(&kc.Spec)
+}
+
+func (cd *ConfigData) updateKC(old, cur interface{}) {
+	oldKC := old.(*kyverno.KyvernoConfig)
+	curKC := cur.(*kyverno.KyvernoConfig)
+	if curKC.Name != KyvernoConfigName || reflect.DeepEqual(oldKC.Spec, curKC.Spec) {
+		return
+	}
+	cd.loadSettings(&curKC.Spec)
+}
+
+func (cd *ConfigData) deleteKC(obj interface{}) {
+	kc, ok := obj.(*kyverno.KyvernoConfig)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			glog.Info(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		kc, ok = tombstone.Obj.(*kyverno.KyvernoConfig)
+		if !ok {
+			glog.Info(fmt.Errorf("Tombstone contained object that is not a KyvernoConfig %#v", obj))
+			return
+		}
+	}
+	if kc.Name != KyvernoConfigName {
+		return
+	}
+	glog.Infof("Configuration: KyvernoConfig %s deleted, using the flags and the ConfigMap", kc.Name)
+	cd.loadSettings(nil)
+}
+
+// loadSettings loads the settings of the KyvernoConfig, nil if deleted, and calls the listeners.
+// The invalid settings are ignored.
+func (cd *ConfigData) loadSettings(spec *kyverno.KyvernoConfigSpec) {
+	if spec != nil {
+		spec = spec.DeepCopy()
+		if spec.Webhook != nil && spec.Webhook.TimeoutSeconds != nil && (*spec.Webhook.TimeoutSeconds < 1 || *spec.Webhook.TimeoutSeconds > 30) {
+			glog.Errorf("Configuration: invalid webhook timeout %ds in KyvernoConfig %s, must be between 1 and 30", *spec.Webhook.TimeoutSeconds, KyvernoConfigName)
+			spec.Webhook.TimeoutSeconds = nil
+		}
+		if spec.BackgroundScanInterval != nil && spec.BackgroundScanInterval.Duration < 0 {
+			glog.Errorf("Configuration: invalid background scan interval %v in KyvernoConfig %s", spec.BackgroundScanInterval.Duration, KyvernoConfigName)
+			spec.BackgroundScanInterval = nil
+		}
+		spec.PolicySources = validPolicySources(spec.PolicySources)
+		glog.Infof("Configuration: loaded KyvernoConfig %s", KyvernoConfigName)
+	}
+	cd.mux.Lock()
+	cd.settings = spec
+	cd.settingsFilters = nil
+	if spec != nil {
+		for _, f := range spec.ResourceFilters {
+			cd.settingsFilters = append(cd.settingsFilters, k8Resource{Kind: orAny(f.Kind), Namespace: orAny(f.Namespace), Name: orAny(f.Name)})
+		}
+	}
+	listeners := cd.listeners
+	cd.mux.Unlock()
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// validPolicySources returns the policy sources with a unique name, a bundle or a repository, and a positive interval
+func validPolicySources(sources []kyverno.PolicySource) []kyverno.PolicySource {
+	var valid []kyverno.PolicySource
+	names := map[string]bool{}
+	for _, source := range sources {
+		switch {
+		case source.Name == "" || names[source.Name]:
+			glog.Errorf("Configuration: invalid policy source in KyvernoConfig %s, the name %q must be set and unique", KyvernoConfigName, source.Name)
+		case (source.OCI == nil) == (source.Git == nil):
+			glog.Errorf("Configuration: invalid policy source %s in KyvernoConfig %s, either oci or git must be set", source.Name, KyvernoConfigName)
+		case source.OCI != nil && source.OCI.Image == "":
+			glog.Errorf("Configuration: invalid policy source %s in KyvernoConfig %s, the image of the bundle is not set", source.Name, KyvernoConfigName)
+		case source.Git != nil && source.Git.URL == "":
+			glog.Errorf("Configuration: invalid policy source %s in KyvernoConfig %s, the URL of the repository is not set", source.Name, KyvernoConfigName)
+		case source.Interval != nil && source.Interval.Duration <= 0:
+			glog.Errorf("Configuration: invalid interval %v of the policy source %s in KyvernoConfig %s", source.Interval.Duration, source.Name, KyvernoConfigName)
+		default:
+			valid = append(valid, source)
+		}
+		names[source.Name] = true
+	}
+	return valid
+}
+
+// orAny returns the wildcard matching any value if the value is not set
+func orAny(value string) string {
+	if value == "" {
+		return "*"
+	}
+	return value
 }
 
 type k8Resource struct {
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_KyvernoConfig_OverridesFlags(t *testing.T) {
+	cd := &ConfigData{}
+	cd.initFilters("[Event,*,*]")
+	var notified int
+	cd.AddListener(func() { notified++ })
+
+	timeout := int32(10)
+	invalidTimeout := int32(60)
+	cd.addKC(&kyverno.KyvernoConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: KyvernoConfigName},
+		Spec: kyverno.KyvernoConfigSpec{
+			Webhook:                &kyverno.WebhookSettings{TimeoutSeconds: &timeout},
+			ResourceFilters:        []kyverno.ResourceFilter{{Kind: "ReplicaSet"}},
+			ExcludedNamespaces:     []string{"kube-*"},
+			BackgroundScanInterval: &metav1.Duration{Duration: 10 * time.Minute},
+			Metrics:                &kyverno.MetricsSettings{DisabledMetrics: []string{"kyverno_policy_results_total"}},
+			PolicySources: []kyverno.PolicySource{
+				{Name: "baseline", OCI: &kyverno.OCISource{Image: "ghcr.io/org/baseline:v1"}},
+				{Name: "baseline", OCI: &kyverno.OCISource{Image: "ghcr.io/org/other:v1"}},
+				{Name: "no-image", OCI: &kyverno.OCISource{}},
+				{Name: "both", OCI: &kyverno.OCISource{Image: "ghcr.io/org/both:v1"}, Git: &kyverno.GitSource{URL: "https://github.com/org/both"}},
+				{Name: "git", Git: &kyverno.GitSource{URL: "https://github.com/org/policies", Path: "policies"}},
+				{Name: "restricted", OCI: &kyverno.OCISource{Image: "ghcr.io/org/restricted:v1"}, Interval: &metav1.Duration{Duration: -time.Minute}},
+			},
+		},
+	})
+	assert.Equal(t, notified, 1)
+	// the resource filters replace the filters of the flag, the empty fields match any value
+	assert.Assert(t, cd.ToFilter("ReplicaSet", "default", "nginx-5c7588df"))
+	assert.Assert(t, !cd.ToFilter("Event", "default", "nginx.15f8"))
+	assert.Assert(t, cd.ToFilter("Pod", "kube-system", "coredns"))
+	assert.Assert(t, !cd.ToFilter("Pod", "default", "nginx"))
+	got, ok := cd.WebhookTimeout()
+	assert.Assert(t, ok)
+	assert.Equal(t, got, int32(10))
+	interval, ok := cd.BackgroundScanInterval()
+	assert.Assert(t, ok)
+	assert.Equal(t, interval, 10*time.Minute)
+	assert.DeepEqual(t, cd.DisabledMetrics(), []string{"kyverno_policy_results_total"})
+	// the sources with a duplicate name, without a single bundle or repository, or with an invalid interval are ignored
+	assert.DeepEqual(t, cd.PolicySources(), []kyverno.PolicySource{
+		{Name: "baseline", OCI: &kyverno.OCISource{Image: "ghcr.io/org/baseline:v1"}},
+		{Name: "git", Git: &kyverno.GitSource{URL: "https://github.com/org/policies", Path: "policies"}},
+	})
+
+	// the other KyvernoConfigs are ignored
+	cd.addKC(&kyverno.KyvernoConfig{ObjectMeta: metav1.ObjectMeta{Name: "other"}})
+	assert.Equal(t, notified, 1)
+
+	// the invalid settings are ignored
+	cd.updateKC(&kyverno.KyvernoConfig{}, &kyverno.KyvernoConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: KyvernoConfigName},
+		Spec:       kyverno.KyvernoConfigSpec{Webhook: &kyverno.WebhookSettings{TimeoutSeconds: &invalidTimeout}},
+	})
+	assert.Equal(t, notified, 2)
+	_, ok = cd.WebhookTimeout()
+	assert.Assert(t, !ok)
+
+	// the flags are used once the KyvernoConfig is deleted
+	cd.deleteKC(&kyverno.KyvernoConfig{ObjectMeta: metav1.ObjectMeta{Name: KyvernoConfigName}})
+	assert.Equal(t, notified, 3)
+	assert.Assert(t, cd.ToFilter("Event", "default", "nginx.15f8"))
+	assert.Assert(t, !cd.ToFilter("Pod", "kube-system", "coredns"))
+	_, ok = cd.BackgroundScanInterval()
+	assert.Assert(t, !ok)
+}
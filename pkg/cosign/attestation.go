@@ -0,0 +1,147 @@
+package cosign
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// media type of the layers of the attestation manifests and payload type of the in-toto envelopes
+const (
+	dsseMediaType     = "application/vnd.dsse.envelope.v1+json"
+	inTotoPayloadType = "application/vnd.in-toto+json"
+)
+
+// Statement is an in-toto statement, the predicate is e.g. a SLSA provenance or a CycloneDX SBOM
+type Statement struct {
+	Type          string                 `json:"_type"`
+	PredicateType string                 `json:"predicateType"`
+	Subject       []Subject              `json:"subject"`
+	Predicate     map[string]interface{} `json:"predicate"`
+}
+
+// Subject is an artifact of a statement
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// envelope is a DSSE envelope, the payload is signed with its type
+type envelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     []byte `json:"payload"`
+	Signatures  []struct {
+		KeyID string `json:"keyid"`
+		Sig   []byte `json:"sig"`
+	} `json:"signatures"`
+}
+
+type cachedStatements struct {
+	statements []Statement
+	expires    time.Time
+}
+
+// FetchAttestations returns the in-toto statements of the image attested as the options require,
+// the statements that are not signed as required or not for the digest of the image are skipped
+// the statements are cached per digest
+func (v *Verifier) FetchAttestations(image string, opts Options) ([]Statement, error) {
+	publicKey, ref, digest, err := v.resolve(image, opts)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey, err := verificationKey(ref, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	v.mu.Lock()
+	cached, ok := v.attestations[cacheKey]
+	v.mu.Unlock()
+	if ok && v.now().Before(cached.expires) {
+		v.log.V(4).Infof("using the cached attestations of %s@%s", ref.Repository, digest)
+		return cached.statements, nil
+	}
+	attestations := artifactReference(ref, digest, attestationSuffix)
+	body, _, err := v.registry.FetchManifest(attestations.String(), opts.Keychain)
+	if err != nil {
+		return nil, fmt.Errorf("no attestations found for %s@%s: %v", ref.Repository, digest, err)
+	}
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode the attestations of %s@%s: %v", ref.Repository, digest, err)
+	}
+	var statements []Statement
+	var errs []string
+	for _, l := range m.Layers {
+		if l.MediaType != dsseMediaType {
+			continue
+		}
+		statement, err := v.verifyEnvelope(attestations.String(), l, digest, publicKey, opts)
+		if err != nil {
+			v.log.V(4).Infof("skipping the attestation %s of %s@%s: %v", l.Digest, ref.Repository, digest, err)
+			errs = append(errs, err.Error())
+			continue
+		}
+		statements = append(statements, statement)
+	}
+	if len(statements) == 0 {
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("no attestations found for %s@%s", ref.Repository, digest)
+		}
+		return nil, fmt.Errorf("no valid attestation for %s@%s: %s", ref.Repository, digest, strings.Join(errs, "; "))
+	}
+	v.mu.Lock()
+	v.attestations[cacheKey] = cachedStatements{statements: statements, expires: v.now().Add(verifiedTTL)}
+	v.mu.Unlock()
+	return statements, nil
+}
+
+// verifyEnvelope checks one of the signatures of the envelope is valid and returns its statement
+func (v *Verifier) verifyEnvelope(attestations string, l layer, digest string, publicKey crypto.PublicKey, opts Options) (Statement, error) {
+	var statement Statement
+	raw, err := v.registry.FetchBlob(attestations, l.Digest, opts.Keychain)
+	if err != nil {
+		return statement, err
+	}
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return statement, fmt.Errorf("failed to decode the envelope of layer %s: %v", l.Digest, err)
+	}
+	if env.PayloadType != inTotoPayloadType {
+		return statement, fmt.Errorf("unsupported payload type %s", env.PayloadType)
+	}
+	if len(env.Signatures) == 0 {
+		return statement, fmt.Errorf("the envelope of layer %s is not signed", l.Digest)
+	}
+	if publicKey == nil {
+		if publicKey, err = v.verifyKeyless(l.Annotations, env.Payload, env.Signatures[0].Sig, opts); err != nil {
+			return statement, err
+		}
+	}
+	pae := preAuthEncoding(env.PayloadType, env.Payload)
+	verified := false
+	for _, sig := range env.Signatures {
+		if VerifySignature(publicKey, pae, sig.Sig) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return statement, fmt.Errorf("invalid signature")
+	}
+	if err := json.Unmarshal(env.Payload, &statement); err != nil {
+		return statement, fmt.Errorf("failed to decode the statement of layer %s: %v", l.Digest, err)
+	}
+	for _, subject := range statement.Subject {
+		if "sha256:"+subject.Digest["sha256"] == digest {
+			return statement, nil
+		}
+	}
+	return statement, fmt.Errorf("the statement of layer %s is not for the digest %s", l.Digest, digest)
+}
+
+// preAuthEncoding returns the DSSE pre-authentication encoding of the payload, the signed data
+func preAuthEncoding(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
@@ -0,0 +1,98 @@
+package cosign
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+// attest adds the attestation of the statement, signed with the key
+// the annotations of keyless attestations are added to the layer
+func (reg *fakeRegistry) attest(t *testing.T, repository, digest, statement string, key *ecdsa.PrivateKey, annotations map[string]string) []byte {
+	sig := signECDSA(t, key, preAuthEncoding(inTotoPayloadType, []byte(statement)))
+	env, err := json.Marshal(map[string]interface{}{
+		"payloadType": inTotoPayloadType,
+		"payload":     base64.StdEncoding.EncodeToString([]byte(statement)),
+		"signatures":  []map[string]string{{"keyid": "", "sig": base64.StdEncoding.EncodeToString(sig)}},
+	})
+	assert.NilError(t, err)
+	envDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(env))
+	reg.blobs[envDigest] = env
+	tag := fmt.Sprintf("%s:sha256-%s.att", repository, digest[len("sha256:"):])
+	var m manifest
+	if existing, ok := reg.manifests[tag]; ok {
+		assert.NilError(t, json.Unmarshal([]byte(existing), &m))
+	}
+	m.Layers = append(m.Layers, layer{MediaType: dsseMediaType, Digest: envDigest, Annotations: annotations})
+	raw, err := json.Marshal(m)
+	assert.NilError(t, err)
+	reg.manifests[tag] = string(raw)
+	return sig
+}
+
+func provenance(digest, builder string) string {
+	return fmt.Sprintf(`{"_type": "https://in-toto.io/Statement/v0.1", "predicateType": "https://slsa.dev/provenance/v0.2",
+		"subject": [{"name": "ghcr.io/test/app", "digest": {"sha256": "%s"}}],
+		"predicate": {"builder": {"id": "%s"}}}`, digest[len("sha256:"):], builder)
+}
+
+func Test_FetchAttestations(t *testing.T) {
+	privateKey, key := newKey(t)
+	otherPrivateKey, _ := newKey(t)
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte("app")))
+	reg := &fakeRegistry{manifests: map[string]string{}, digests: map[string]string{}, blobs: map[string][]byte{}}
+	reg.attest(t, "ghcr.io/test/app", digest, provenance(digest, "https://github.com/actions/runner"), privateKey, nil)
+	// the statements signed with another key or for another digest are skipped
+	reg.attest(t, "ghcr.io/test/app", digest, provenance(digest, "https://evil.com"), otherPrivateKey, nil)
+	reg.attest(t, "ghcr.io/test/app", digest, provenance("sha256:bbbb", "https://evil.com"), privateKey, nil)
+
+	verifier := NewVerifier(reg)
+	statements, err := verifier.FetchAttestations("ghcr.io/test/app@"+digest, Options{Key: key})
+	assert.NilError(t, err)
+	assert.Equal(t, len(statements), 1)
+	assert.Equal(t, statements[0].PredicateType, "https://slsa.dev/provenance/v0.2")
+	assert.DeepEqual(t, statements[0].Predicate, map[string]interface{}{"builder": map[string]interface{}{"id": "https://github.com/actions/runner"}})
+
+	// the statements are cached
+	fetches := reg.fetches
+	_, err = verifier.FetchAttestations("ghcr.io/test/app@"+digest, Options{Key: key})
+	assert.NilError(t, err)
+	assert.Equal(t, reg.fetches, fetches)
+
+	_, otherKey := newKey(t)
+	_, err = verifier.FetchAttestations("ghcr.io/test/app@"+digest, Options{Key: otherKey})
+	assert.ErrorContains(t, err, "no valid attestation for test/app@"+digest)
+
+	_, err = verifier.FetchAttestations("ghcr.io/test/app@sha256:cccc", Options{Key: key})
+	assert.ErrorContains(t, err, "no attestations found for test/app@sha256:cccc")
+}
+
+func Test_FetchAttestations_Keyless(t *testing.T) {
+	f := newFulcio(t)
+	r := newRekor(t)
+	signedAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	privateKey, cert := f.issue(t, "release@corp.com", "https://accounts.google.com", signedAt)
+	reg := &fakeRegistry{manifests: map[string]string{}, digests: map[string]string{}, blobs: map[string][]byte{}}
+
+	statement := provenance("sha256:aaaa", "https://github.com/actions/runner")
+	var spec intotoSpec
+	spec.Content.PayloadHash = rekorHash{Algorithm: "sha256", Value: fmt.Sprintf("%x", sha256.Sum256([]byte(statement)))}
+	spec.PublicKey = []byte(cert)
+	b := r.add(t, "intoto", spec, []byte(statement), signedAt)
+	reg.attest(t, "ghcr.io/test/app", "sha256:aaaa", statement, privateKey, map[string]string{certificateAnnotation: cert, bundleAnnotation: b})
+
+	opts := Options{Roots: f.roots, Subject: "release@corp.com", RekorPubKey: r.pubKey}
+	statements, err := NewVerifier(reg).FetchAttestations("ghcr.io/test/app@sha256:aaaa", opts)
+	assert.NilError(t, err)
+	assert.Equal(t, len(statements), 1)
+
+	opts.Subject = "admin@corp.com"
+	_, err = NewVerifier(reg).FetchAttestations("ghcr.io/test/app@sha256:aaaa", opts)
+	assert.ErrorContains(t, err, "the certificate subject release@corp.com does not match admin@corp.com")
+}
@@ -0,0 +1,322 @@
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/registry"
+)
+
+// media type of the layers of the signature manifests and annotations of the layers
+// the keyless signatures have the certificate, its chain and the Rekor bundle
+const (
+	signatureMediaType    = "application/vnd.dev.cosign.simplesigning.v1+json"
+	signatureAnnotation   = "dev.cosignproject.cosign/signature"
+	certificateAnnotation = "dev.sigstore.cosign/certificate"
+	chainAnnotation       = "dev.sigstore.cosign/chain"
+	bundleAnnotation      = "dev.sigstore.cosign/bundle"
+)
+
+// suffixes of the tags of the signatures and the attestations of a digest
+const (
+	signatureSuffix   = ".sig"
+	attestationSuffix = ".att"
+)
+
+// verifiedTTL is the duration a verified image digest is cached
+const verifiedTTL = time.Hour
+
+// Interface verifies the signatures of the images
+type Interface interface {
+	// Verify checks the image is signed as the options require and returns the digest of the image
+	Verify(image string, opts Options) (string, error)
+	// FetchAttestations returns the in-toto statements of the image attested as the options require
+	FetchAttestations(image string, opts Options) ([]Statement, error)
+}
+
+// Options are the requirements of the signatures, the signatures are made with the key if set, else keyless
+type Options struct {
+	// Key is the PEM encoded public key
+	Key string
+	// Roots are the PEM encoded root certificates of Fulcio
+	Roots string
+	// Subject is the pattern of the email or URI of the certificate
+	Subject string
+	// Issuer is the OIDC issuer of the certificate, any issuer if empty
+	Issuer string
+	// RekorURL is the URL of Rekor, the entries of the signatures without a bundle are fetched from it
+	RekorURL string
+	// RekorPubKey is the PEM encoded public key of Rekor
+	RekorPubKey string
+	// Keychain are the credentials of the registries, e.g. of the image pull secrets of the pod
+	// it does not change the verification, so it is not part of the cache key
+	Keychain registry.Keychain `json:"-"`
+}
+
+// Registry fetches the manifests and the blobs of the images
+type Registry interface {
+	FetchManifest(image string, keychain registry.Keychain) ([]byte, string, error)
+	FetchBlob(image, digest string, keychain registry.Keychain) ([]byte, error)
+}
+
+// Verifier verifies the cosign signatures stored in the registry of the image,
+// the signatures of the image with the digest sha256:<hex> are in the manifest with the tag sha256-<hex>.sig
+type Verifier struct {
+	registry Registry
+	// rekorClient fetches the Rekor entries, nil in offline mode
+	rekorClient *http.Client
+	mu          sync.Mutex
+	// verified are the expiration times of the verified digests per repository and options
+	verified map[string]time.Time
+	// attestations are the verified statements per repository, digest and options
+	attestations map[string]cachedStatements
+	// now is replaced in tests
+	now func() time.Time
+	log log.Logger
+}
+
+// NewVerifier returns a verifier that fetches the signatures from the registry
+func NewVerifier(registry Registry) *Verifier {
+	return &Verifier{
+		registry:     registry,
+		rekorClient:  &http.Client{Timeout: 10 * time.Second},
+		verified:     map[string]time.Time{},
+		attestations: map[string]cachedStatements{},
+		now:          time.Now,
+	}
+}
+
+// NewOfflineVerifier returns a verifier that does not fetch the Rekor entries,
+// the keyless signatures must have a bundle with their entry, e.g. for disconnected clusters
+func NewOfflineVerifier(registry Registry) *Verifier {
+	v := NewVerifier(registry)
+	v.rekorClient = nil
+	return v
+}
+
+// SetLogger sets the logger of the verifier, the logs are discarded by default
+func (v *Verifier) SetLogger(logger log.Logger) {
+	v.log = logger
+}
+
+// payload is the simple signing payload signed by cosign
+type payload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+type manifest struct {
+	Layers []layer `json:"layers"`
+}
+
+type layer struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// Verify checks the image is signed as the options require, the tags are resolved to digests
+// the verified digests are cached so the signatures are fetched once per digest
+func (v *Verifier) Verify(image string, opts Options) (string, error) {
+	publicKey, ref, digest, err := v.resolve(image, opts)
+	if err != nil {
+		return "", err
+	}
+	cacheKey, err := verificationKey(ref, digest, opts)
+	if err != nil {
+		return "", err
+	}
+	if v.isVerified(cacheKey) {
+		v.log.V(4).Infof("using the cached verification of %s@%s", ref.Repository, digest)
+		return digest, nil
+	}
+	if err := v.verifyDigest(ref, digest, publicKey, opts); err != nil {
+		return "", err
+	}
+	v.mu.Lock()
+	v.verified[cacheKey] = v.now().Add(verifiedTTL)
+	v.mu.Unlock()
+	return digest, nil
+}
+
+// resolve parses the key of the options and resolves the digest of the image
+// the key is nil for keyless signatures
+func (v *Verifier) resolve(image string, opts Options) (crypto.PublicKey, registry.ImageReference, string, error) {
+	var publicKey crypto.PublicKey
+	if opts.Key != "" {
+		var err error
+		if publicKey, err = ParsePublicKey(opts.Key); err != nil {
+			return nil, registry.ImageReference{}, "", err
+		}
+	} else if opts.Roots == "" {
+		return nil, registry.ImageReference{}, "", fmt.Errorf("a key or the Fulcio roots are required")
+	}
+	ref, err := registry.ParseImageReference(image)
+	if err != nil {
+		return nil, ref, "", err
+	}
+	digest := ref.Digest
+	if digest == "" {
+		if _, digest, err = v.registry.FetchManifest(image, opts.Keychain); err != nil {
+			return nil, ref, "", fmt.Errorf("failed to resolve the digest of %s: %v", image, err)
+		}
+	}
+	return publicKey, ref, digest, nil
+}
+
+// verificationKey identifies the verification of the digest with the options
+func verificationKey(ref registry.ImageReference, digest string, opts Options) (string, error) {
+	rawOpts, err := json.Marshal(opts)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s@%s/%x", ref.Registry, ref.Repository, digest, sha256.Sum256(rawOpts)), nil
+}
+
+func (v *Verifier) isVerified(cacheKey string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	expires, ok := v.verified[cacheKey]
+	if !ok {
+		return false
+	}
+	if !v.now().Before(expires) {
+		delete(v.verified, cacheKey)
+		return false
+	}
+	return true
+}
+
+// verifyDigest checks one of the signatures of the digest is valid
+func (v *Verifier) verifyDigest(ref registry.ImageReference, digest string, publicKey crypto.PublicKey, opts Options) error {
+	signatures := artifactReference(ref, digest, signatureSuffix)
+	body, _, err := v.registry.FetchManifest(signatures.String(), opts.Keychain)
+	if err != nil {
+		return fmt.Errorf("no signatures found for %s@%s: %v", ref.Repository, digest, err)
+	}
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return fmt.Errorf("failed to decode the signatures of %s@%s: %v", ref.Repository, digest, err)
+	}
+	var errs []string
+	for _, l := range m.Layers {
+		if l.MediaType != signatureMediaType {
+			continue
+		}
+		err := v.verifyLayer(signatures.String(), l, digest, publicKey, opts)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err.Error())
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("no signatures found for %s@%s", ref.Repository, digest)
+	}
+	return fmt.Errorf("no valid signature for %s@%s: %s", ref.Repository, digest, strings.Join(errs, "; "))
+}
+
+// artifactReference returns the reference of the signatures or the attestations of the digest
+func artifactReference(ref registry.ImageReference, digest, suffix string) registry.ImageReference {
+	return registry.ImageReference{
+		Registry:   ref.Registry,
+		Repository: ref.Repository,
+		Tag:        strings.Replace(digest, ":", "-", 1) + suffix,
+	}
+}
+
+// verifyLayer checks the signature of the payload and the payload is for the digest
+// the public key of a keyless signature is the key of its certificate
+func (v *Verifier) verifyLayer(signatures string, l layer, digest string, publicKey crypto.PublicKey, opts Options) error {
+	sig, err := base64.StdEncoding.DecodeString(l.Annotations[signatureAnnotation])
+	if err != nil || len(sig) == 0 {
+		return fmt.Errorf("invalid signature in layer %s", l.Digest)
+	}
+	raw, err := v.registry.FetchBlob(signatures, l.Digest, opts.Keychain)
+	if err != nil {
+		return err
+	}
+	if publicKey == nil {
+		if publicKey, err = v.verifyKeyless(l.Annotations, raw, sig, opts); err != nil {
+			return err
+		}
+	}
+	if err := VerifySignature(publicKey, raw, sig); err != nil {
+		return err
+	}
+	var p payload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("failed to decode the payload of layer %s: %v", l.Digest, err)
+	}
+	if p.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("the signature is for the digest %s", p.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
+
+// ParsePublicKey parses the PEM encoded ECDSA, RSA or Ed25519 public key
+func ParsePublicKey(key string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, fmt.Errorf("invalid key, no PEM encoded public key found")
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %v", err)
+	}
+	switch publicKey.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		return publicKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+// VerifySignature verifies the signature of the payload as cosign signs it
+// - ECDSA: ASN.1 signature of the SHA-256 hash
+// - RSA: PKCS #1 v1.5 signature of the SHA-256 hash
+// - Ed25519: signature of the payload
+func VerifySignature(publicKey crypto.PublicKey, data, sig []byte) error {
+	hash := sha256.Sum256(data)
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		var esig struct {
+			R, S *big.Int
+		}
+		if _, err := asn1.Unmarshal(sig, &esig); err != nil {
+			return fmt.Errorf("invalid ECDSA signature: %v", err)
+		}
+		if !ecdsa.Verify(key, hash[:], esig.R, esig.S) {
+			return fmt.Errorf("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig); err != nil {
+			return fmt.Errorf("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, data, sig) {
+			return fmt.Errorf("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package cosign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/nirmata/kyverno/pkg/registry"
+	"gotest.tools/assert"
+)
+
+// fakeRegistry serves the manifests by image reference and the blobs by digest
+// the manifests of the private repositories require a keychain
+type fakeRegistry struct {
+	manifests map[string]string
+	digests   map[string]string
+	blobs     map[string][]byte
+	private   map[string]bool
+	fetches   int
+}
+
+func (r *fakeRegistry) FetchManifest(image string, keychain registry.Keychain) ([]byte, string, error) {
+	r.fetches++
+	if r.private[image] && keychain == nil {
+		return nil, "", fmt.Errorf("%s: 401 Unauthorized", image)
+	}
+	manifest, ok := r.manifests[image]
+	if !ok {
+		return nil, "", fmt.Errorf("%s: 404 Not Found", image)
+	}
+	return []byte(manifest), r.digests[image], nil
+}
+
+func (r *fakeRegistry) FetchBlob(image, digest string, keychain registry.Keychain) ([]byte, error) {
+	blob, ok := r.blobs[digest]
+	if !ok {
+		return nil, fmt.Errorf("%s: 404 Not Found", digest)
+	}
+	return blob, nil
+}
+
+func newKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	assert.NilError(t, err)
+	return privateKey, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// sign adds the signature manifest of the digest signed with the key
+func (r *fakeRegistry) sign(t *testing.T, repository, digest string, privateKey *ecdsa.PrivateKey) {
+	payload := []byte(fmt.Sprintf(`{"critical": {"identity": {"docker-reference": "%s"}, "image": {"docker-manifest-digest": "%s"}, "type": "cosign container image signature"}, "optional": null}`, repository, digest))
+	hash := sha256.Sum256(payload)
+	r1, s1, err := ecdsa.Sign(rand.Reader, privateKey, hash[:])
+	assert.NilError(t, err)
+	sig, err := asn1.Marshal(struct{ R, S *big.Int }{r1, s1})
+	assert.NilError(t, err)
+	payloadDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(payload))
+	r.blobs[payloadDigest] = payload
+	r.manifests[fmt.Sprintf("%s:sha256-%s.sig", repository, digest[len("sha256:"):])] = fmt.Sprintf(`{"layers": [{"mediaType": "%s", "digest": "%s", "annotations": {"%s": "%s"}}]}`,
+		signatureMediaType, payloadDigest, signatureAnnotation, base64.StdEncoding.EncodeToString(sig))
+}
+
+func Test_Verify(t *testing.T) {
+	privateKey, key := newKey(t)
+	_, otherKey := newKey(t)
+	digest := "sha256:" + fmt.Sprintf("%x", sha256.Sum256([]byte("app")))
+	reg := &fakeRegistry{
+		manifests: map[string]string{
+			"ghcr.io/test/app:v1":      `{}`,
+			"ghcr.io/test/unsigned:v1": `{}`,
+		},
+		digests: map[string]string{
+			"ghcr.io/test/app:v1":      digest,
+			"ghcr.io/test/unsigned:v1": digest,
+		},
+		blobs: map[string][]byte{},
+	}
+	reg.sign(t, "ghcr.io/test/app", digest, privateKey)
+	verifier := NewVerifier(reg)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	verifier.now = func() time.Time { return now }
+
+	verified, err := verifier.Verify("ghcr.io/test/app:v1", Options{Key: key})
+	assert.NilError(t, err)
+	assert.Equal(t, verified, digest)
+	assert.Equal(t, reg.fetches, 2)
+
+	// the verified digests are cached
+	verified, err = verifier.Verify("ghcr.io/test/app@"+digest, Options{Key: key})
+	assert.NilError(t, err)
+	assert.Equal(t, verified, digest)
+	assert.Equal(t, reg.fetches, 2)
+	now = now.Add(2 * verifiedTTL)
+	_, err = verifier.Verify("ghcr.io/test/app@"+digest, Options{Key: key})
+	assert.NilError(t, err)
+	assert.Equal(t, reg.fetches, 3)
+
+	_, err = verifier.Verify("ghcr.io/test/app:v1", Options{Key: otherKey})
+	assert.ErrorContains(t, err, "no valid signature for test/app@"+digest)
+
+	_, err = verifier.Verify("ghcr.io/test/unsigned:v1", Options{Key: key})
+	assert.ErrorContains(t, err, "no signatures found for test/unsigned@"+digest)
+
+	_, err = verifier.Verify("ghcr.io/test/app:v2", Options{Key: key})
+	assert.ErrorContains(t, err, "failed to resolve the digest of ghcr.io/test/app:v2")
+
+	_, err = verifier.Verify("ghcr.io/test/app:v1", Options{Key: "invalid"})
+	assert.ErrorContains(t, err, "invalid key")
+}
+
+func Test_Verify_Private(t *testing.T) {
+	privateKey, key := newKey(t)
+	reg := &fakeRegistry{manifests: map[string]string{}, digests: map[string]string{}, blobs: map[string][]byte{}}
+	reg.sign(t, "registry.corp.com/app", "sha256:aaaa", privateKey)
+	reg.private = map[string]bool{"registry.corp.com/app:sha256-aaaa.sig": true}
+
+	_, err := NewVerifier(reg).Verify("registry.corp.com/app@sha256:aaaa", Options{Key: key})
+	assert.ErrorContains(t, err, "401 Unauthorized")
+
+	// the signatures are fetched with the keychain of the options
+	keychain, err := registry.ParseDockerConfig([]byte(`{"auths": {"registry.corp.com": {"username": "user", "password": "secret"}}}`))
+	assert.NilError(t, err)
+	_, err = NewVerifier(reg).Verify("registry.corp.com/app@sha256:aaaa", Options{Key: key, Keychain: keychain})
+	assert.NilError(t, err)
+}
+
+func Test_Verify_Digest_Mismatch(t *testing.T) {
+	privateKey, key := newKey(t)
+	reg := &fakeRegistry{manifests: map[string]string{}, digests: map[string]string{}, blobs: map[string][]byte{}}
+	// the signature of another digest is copied to the tag of the digest
+	reg.sign(t, "ghcr.io/test/app", "sha256:aaaa", privateKey)
+	reg.manifests["ghcr.io/test/app:sha256-bbbb.sig"] = reg.manifests["ghcr.io/test/app:sha256-aaaa.sig"]
+
+	_, err := NewVerifier(reg).Verify("ghcr.io/test/app@sha256:bbbb", Options{Key: key})
+	assert.ErrorContains(t, err, "the signature is for the digest sha256:aaaa")
+}
@@ -0,0 +1,310 @@
+package cosign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minio/minio/pkg/wildcard"
+)
+
+// OIDs of the Fulcio extensions of the OIDC issuer, the first one is the raw issuer
+// and the second one is the DER encoded issuer
+var (
+	oidIssuer   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// rekorEntry is a Rekor log entry, the bundle of a keyless signature has the entry of the signature
+type rekorEntry struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogIndex       int64  `json:"logIndex"`
+	LogID          string `json:"logID"`
+}
+
+type bundle struct {
+	SignedEntryTimestamp []byte     `json:"SignedEntryTimestamp"`
+	Payload              rekorEntry `json:"Payload"`
+}
+
+// rekorBody is the body of the entries, the signatures are hashedrekord entries
+// and the attestations are intoto entries
+type rekorBody struct {
+	Kind string          `json:"kind"`
+	Spec json.RawMessage `json:"spec"`
+}
+
+type rekorHash struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"value"`
+}
+
+type hashedRekordSpec struct {
+	Data struct {
+		Hash rekorHash `json:"hash"`
+	} `json:"data"`
+	Signature struct {
+		Content   []byte `json:"content"`
+		PublicKey struct {
+			Content []byte `json:"content"`
+		} `json:"publicKey"`
+	} `json:"signature"`
+}
+
+type intotoSpec struct {
+	Content struct {
+		Hash        rekorHash `json:"hash"`
+		PayloadHash rekorHash `json:"payloadHash"`
+	} `json:"content"`
+	PublicKey []byte `json:"publicKey"`
+}
+
+// verifyKeyless verifies the certificate of a keyless signature and returns its public key
+// - the signature is recorded in Rekor, with the bundle or the entry fetched from Rekor
+// - the certificate is issued by the Fulcio roots when the signature was recorded
+// - the certificate identity and issuer match the options
+func (v *Verifier) verifyKeyless(annotations map[string]string, payload, sig []byte, opts Options) (crypto.PublicKey, error) {
+	cert, err := parseCertificate(annotations[certificateAnnotation])
+	if err != nil {
+		return nil, err
+	}
+	if opts.RekorPubKey == "" {
+		return nil, fmt.Errorf("the Rekor public key is required")
+	}
+	rekorKey, err := ParsePublicKey(opts.RekorPubKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Rekor public key: %v", err)
+	}
+	var integratedTime int64
+	if raw, ok := annotations[bundleAnnotation]; ok {
+		var b bundle
+		if err := json.Unmarshal([]byte(raw), &b); err != nil {
+			return nil, fmt.Errorf("failed to decode the bundle: %v", err)
+		}
+		if err := verifyRekorEntry(b.Payload, b.SignedEntryTimestamp, rekorKey, cert, payload, sig); err != nil {
+			return nil, err
+		}
+		integratedTime = b.Payload.IntegratedTime
+	} else {
+		if v.rekorClient == nil {
+			return nil, fmt.Errorf("the signature has no bundle, the Rekor entries are not fetched in offline mode")
+		}
+		if opts.RekorURL == "" {
+			return nil, fmt.Errorf("the signature has no bundle and no Rekor URL is set")
+		}
+		if integratedTime, err = v.fetchRekorEntry(opts.RekorURL, rekorKey, cert, payload, sig); err != nil {
+			return nil, err
+		}
+	}
+	if err := verifyCertificate(cert, annotations[chainAnnotation], opts, time.Unix(integratedTime, 0)); err != nil {
+		return nil, err
+	}
+	return cert.PublicKey, nil
+}
+
+func parseCertificate(certificate string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certificate))
+	if block == nil {
+		return nil, fmt.Errorf("the signature has no certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// ParseCertificates parses the PEM encoded certificates
+func ParseCertificates(certificates string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(certificates)) {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	return pool, nil
+}
+
+// verifyCertificate checks the certificate is a code signing certificate issued by the roots
+// that was valid at the time, with the subject and the issuer of the options
+func verifyCertificate(cert *x509.Certificate, chain string, opts Options, at time.Time) error {
+	roots, err := ParseCertificates(opts.Roots)
+	if err != nil {
+		return fmt.Errorf("invalid roots: %v", err)
+	}
+	intermediates := x509.NewCertPool()
+	intermediates.AppendCertsFromPEM([]byte(chain))
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return fmt.Errorf("invalid certificate: %v", err)
+	}
+	if opts.Subject != "" {
+		subjects := cert.EmailAddresses
+		for _, uri := range cert.URIs {
+			subjects = append(subjects, uri.String())
+		}
+		if !matchAny(opts.Subject, subjects) {
+			return fmt.Errorf("the certificate subject %s does not match %s", strings.Join(subjects, ", "), opts.Subject)
+		}
+	}
+	if opts.Issuer != "" {
+		if issuer := certificateIssuer(cert); issuer != opts.Issuer {
+			return fmt.Errorf("the certificate issuer %s does not match %s", issuer, opts.Issuer)
+		}
+	}
+	return nil
+}
+
+func matchAny(pattern string, values []string) bool {
+	for _, value := range values {
+		if wildcard.Match(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// certificateIssuer returns the OIDC issuer of the Fulcio extension
+func certificateIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(oidIssuerV2):
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err == nil {
+				return issuer
+			}
+		case ext.Id.Equal(oidIssuer):
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+// verifyRekorEntry checks the signed entry timestamp of the entry and the entry is for the signature of the payload
+// the timestamp is the signature of the canonical JSON of the entry
+func verifyRekorEntry(entry rekorEntry, set []byte, rekorKey crypto.PublicKey, cert *x509.Certificate, payload, sig []byte) error {
+	// the keys of the maps are sorted
+	canonical, err := json.Marshal(map[string]interface{}{
+		"body":           entry.Body,
+		"integratedTime": entry.IntegratedTime,
+		"logIndex":       entry.LogIndex,
+		"logID":          entry.LogID,
+	})
+	if err != nil {
+		return err
+	}
+	if err := VerifySignature(rekorKey, canonical, set); err != nil {
+		return fmt.Errorf("invalid Rekor signed entry timestamp: %v", err)
+	}
+	rawBody, err := base64.StdEncoding.DecodeString(entry.Body)
+	if err != nil {
+		return fmt.Errorf("invalid Rekor entry: %v", err)
+	}
+	var body rekorBody
+	if err := json.Unmarshal(rawBody, &body); err != nil {
+		return fmt.Errorf("invalid Rekor entry: %v", err)
+	}
+	payloadHash := fmt.Sprintf("%x", sha256.Sum256(payload))
+	var entryCert []byte
+	switch body.Kind {
+	case "hashedrekord":
+		var spec hashedRekordSpec
+		if err := json.Unmarshal(body.Spec, &spec); err != nil {
+			return fmt.Errorf("invalid Rekor entry: %v", err)
+		}
+		if spec.Data.Hash.Value != payloadHash {
+			return fmt.Errorf("the Rekor entry is for another payload")
+		}
+		if !bytes.Equal(spec.Signature.Content, sig) {
+			return fmt.Errorf("the Rekor entry is for another signature")
+		}
+		entryCert = spec.Signature.PublicKey.Content
+	case "intoto":
+		// the envelope is recorded with the hash of its payload
+		var spec intotoSpec
+		if err := json.Unmarshal(body.Spec, &spec); err != nil {
+			return fmt.Errorf("invalid Rekor entry: %v", err)
+		}
+		if spec.Content.PayloadHash.Value != payloadHash {
+			return fmt.Errorf("the Rekor entry is for another payload")
+		}
+		entryCert = spec.PublicKey
+	default:
+		return fmt.Errorf("unsupported Rekor entry kind %s", body.Kind)
+	}
+	if c, err := parseCertificate(string(entryCert)); err != nil || !c.Equal(cert) {
+		return fmt.Errorf("the Rekor entry is for another certificate")
+	}
+	return nil
+}
+
+// fetchRekorEntry looks up the entries of the payload in Rekor and returns the time the signature was recorded
+func (v *Verifier) fetchRekorEntry(rekorURL string, rekorKey crypto.PublicKey, cert *x509.Certificate, payload, sig []byte) (int64, error) {
+	rekorURL = strings.TrimSuffix(rekorURL, "/")
+	query, err := json.Marshal(map[string]string{"hash": fmt.Sprintf("sha256:%x", sha256.Sum256(payload))})
+	if err != nil {
+		return 0, err
+	}
+	var uuids []string
+	if err := v.rekorRequest(http.MethodPost, rekorURL+"/api/v1/index/retrieve", query, &uuids); err != nil {
+		return 0, err
+	}
+	var errs []string
+	for _, uuid := range uuids {
+		var entries map[string]struct {
+			rekorEntry
+			Verification struct {
+				SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+			} `json:"verification"`
+		}
+		if err := v.rekorRequest(http.MethodGet, rekorURL+"/api/v1/log/entries/"+uuid, nil, &entries); err != nil {
+			return 0, err
+		}
+		for _, entry := range entries {
+			err := verifyRekorEntry(entry.rekorEntry, entry.Verification.SignedEntryTimestamp, rekorKey, cert, payload, sig)
+			if err == nil {
+				return entry.IntegratedTime, nil
+			}
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) == 0 {
+		return 0, fmt.Errorf("the signature is not recorded in Rekor")
+	}
+	return 0, fmt.Errorf("no valid Rekor entry: %s", strings.Join(errs, "; "))
+}
+
+func (v *Verifier) rekorRequest(method, url string, body []byte, result interface{}) error {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	v.log.V(4).Infof("Rekor request %s %s", method, url)
+	resp, err := v.rekorClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get %s: %s", url, resp.Status)
+	}
+	return json.Unmarshal(raw, result)
+}
@@ -0,0 +1,261 @@
+package cosign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+// fulcio issues short lived code signing certificates with the OIDC issuer extension
+type fulcio struct {
+	root    *x509.Certificate
+	rootKey *ecdsa.PrivateKey
+	roots   string
+}
+
+func newFulcio(t *testing.T) *fulcio {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fulcio"},
+		NotBefore:             time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &rootKey.PublicKey, rootKey)
+	assert.NilError(t, err)
+	root, err := x509.ParseCertificate(der)
+	assert.NilError(t, err)
+	return &fulcio{root: root, rootKey: rootKey, roots: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}
+}
+
+// issue returns a key and its certificate valid for 10 minutes from the time
+func (f *fulcio) issue(t *testing.T, email, issuer string, at time.Time) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	issuerValue, err := asn1.Marshal(issuer)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(at.Unix()),
+		NotBefore:       at,
+		NotAfter:        at.Add(10 * time.Minute),
+		EmailAddresses:  []string{email},
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		ExtraExtensions: []pkix.Extension{{Id: oidIssuerV2, Value: issuerValue}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, f.root, &key.PublicKey, f.rootKey)
+	assert.NilError(t, err)
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func signECDSA(t *testing.T, key *ecdsa.PrivateKey, data []byte) []byte {
+	hash := sha256.Sum256(data)
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	assert.NilError(t, err)
+	sig, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	assert.NilError(t, err)
+	return sig
+}
+
+// rekor records the entries and signs the entry timestamps
+type rekor struct {
+	key     *ecdsa.PrivateKey
+	pubKey  string
+	entries map[string]rekorEntry
+	sets    map[string][]byte
+	index   map[string][]string
+}
+
+func newRekor(t *testing.T) *rekor {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NilError(t, err)
+	return &rekor{
+		key:     key,
+		pubKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		entries: map[string]rekorEntry{},
+		sets:    map[string][]byte{},
+		index:   map[string][]string{},
+	}
+}
+
+// record adds the hashedrekord entry of the signature and returns its bundle
+func (r *rekor) record(t *testing.T, payload, sig []byte, cert string, at time.Time) string {
+	var spec hashedRekordSpec
+	spec.Data.Hash = rekorHash{Algorithm: "sha256", Value: fmt.Sprintf("%x", sha256.Sum256(payload))}
+	spec.Signature.Content = sig
+	spec.Signature.PublicKey.Content = []byte(cert)
+	return r.add(t, "hashedrekord", spec, payload, at)
+}
+
+// add adds the entry of the payload and returns its bundle
+func (r *rekor) add(t *testing.T, kind string, spec interface{}, payload []byte, at time.Time) string {
+	rawSpec, err := json.Marshal(spec)
+	assert.NilError(t, err)
+	rawBody, err := json.Marshal(rekorBody{Kind: kind, Spec: rawSpec})
+	assert.NilError(t, err)
+	entry := rekorEntry{
+		Body:           base64.StdEncoding.EncodeToString(rawBody),
+		IntegratedTime: at.Unix(),
+		LogIndex:       int64(len(r.entries)),
+		LogID:          "test",
+	}
+	canonical, err := json.Marshal(map[string]interface{}{"body": entry.Body, "integratedTime": entry.IntegratedTime, "logIndex": entry.LogIndex, "logID": entry.LogID})
+	assert.NilError(t, err)
+	set := signECDSA(t, r.key, canonical)
+	uuid := fmt.Sprintf("%x", sha256.Sum256(rawBody))
+	r.entries[uuid] = entry
+	r.sets[uuid] = set
+	hash := fmt.Sprintf("sha256:%x", sha256.Sum256(payload))
+	r.index[hash] = append(r.index[hash], uuid)
+	raw, err := json.Marshal(bundle{SignedEntryTimestamp: set, Payload: entry})
+	assert.NilError(t, err)
+	return string(raw)
+}
+
+func (r *rekor) serve(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/index/retrieve", func(w http.ResponseWriter, req *http.Request) {
+		var query map[string]string
+		assert.NilError(t, json.NewDecoder(req.Body).Decode(&query))
+		assert.NilError(t, json.NewEncoder(w).Encode(append([]string{}, r.index[query["hash"]]...)))
+	})
+	mux.HandleFunc("/api/v1/log/entries/", func(w http.ResponseWriter, req *http.Request) {
+		uuid := req.URL.Path[len("/api/v1/log/entries/"):]
+		entry, ok := r.entries[uuid]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"%s": {"body": "%s", "integratedTime": %d, "logIndex": %d, "logID": "%s", "verification": {"signedEntryTimestamp": "%s"}}}`,
+			uuid, entry.Body, entry.IntegratedTime, entry.LogIndex, entry.LogID, base64.StdEncoding.EncodeToString(r.sets[uuid]))
+	})
+	return httptest.NewTLSServer(mux)
+}
+
+// signKeyless adds the keyless signature of the digest, with its bundle if withBundle
+func (reg *fakeRegistry) signKeyless(t *testing.T, repository, digest string, key *ecdsa.PrivateKey, cert string, r *rekor, at time.Time, withBundle bool) {
+	payload := []byte(fmt.Sprintf(`{"critical": {"identity": {"docker-reference": "%s"}, "image": {"docker-manifest-digest": "%s"}, "type": "cosign container image signature"}, "optional": null}`, repository, digest))
+	sig := signECDSA(t, key, payload)
+	payloadDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(payload))
+	reg.blobs[payloadDigest] = payload
+	annotations := map[string]string{
+		signatureAnnotation:   base64.StdEncoding.EncodeToString(sig),
+		certificateAnnotation: cert,
+	}
+	b := r.record(t, payload, sig, cert, at)
+	if withBundle {
+		annotations[bundleAnnotation] = b
+	}
+	m, err := json.Marshal(manifest{Layers: []layer{{MediaType: signatureMediaType, Digest: payloadDigest, Annotations: annotations}}})
+	assert.NilError(t, err)
+	reg.manifests[fmt.Sprintf("%s:sha256-%s.sig", repository, digest[len("sha256:"):])] = string(m)
+}
+
+func Test_Verify_Keyless(t *testing.T) {
+	f := newFulcio(t)
+	r := newRekor(t)
+	signedAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	key, cert := f.issue(t, "release@corp.com", "https://accounts.google.com", signedAt)
+	reg := &fakeRegistry{manifests: map[string]string{}, digests: map[string]string{}, blobs: map[string][]byte{}}
+	reg.signKeyless(t, "ghcr.io/test/app", "sha256:aaaa", key, cert, r, signedAt, true)
+	reg.signKeyless(t, "ghcr.io/test/online", "sha256:bbbb", key, cert, r, signedAt, false)
+
+	server := r.serve(t)
+	defer server.Close()
+	verifier := NewVerifier(reg)
+	verifier.rekorClient = server.Client()
+
+	opts := Options{
+		Roots:       f.roots,
+		Subject:     "*@corp.com",
+		Issuer:      "https://accounts.google.com",
+		RekorPubKey: r.pubKey,
+	}
+	// the certificate expired but was valid when the signature was recorded
+	_, err := verifier.Verify("ghcr.io/test/app@sha256:aaaa", opts)
+	assert.NilError(t, err)
+
+	// the entry is fetched from Rekor if the signature has no bundle
+	_, err = verifier.Verify("ghcr.io/test/online@sha256:bbbb", opts)
+	assert.ErrorContains(t, err, "the signature has no bundle and no Rekor URL is set")
+	online := opts
+	online.RekorURL = server.URL
+	_, err = verifier.Verify("ghcr.io/test/online@sha256:bbbb", online)
+	assert.NilError(t, err)
+
+	invalid := opts
+	invalid.Subject = "*@other.com"
+	_, err = verifier.Verify("ghcr.io/test/app@sha256:aaaa", invalid)
+	assert.ErrorContains(t, err, "the certificate subject release@corp.com does not match *@other.com")
+
+	invalid = opts
+	invalid.Issuer = "https://token.actions.githubusercontent.com"
+	_, err = verifier.Verify("ghcr.io/test/app@sha256:aaaa", invalid)
+	assert.ErrorContains(t, err, "the certificate issuer https://accounts.google.com does not match")
+
+	invalid = opts
+	invalid.Roots = newFulcio(t).roots
+	_, err = verifier.Verify("ghcr.io/test/app@sha256:aaaa", invalid)
+	assert.ErrorContains(t, err, "invalid certificate")
+
+	invalid = opts
+	invalid.RekorPubKey = newRekor(t).pubKey
+	_, err = verifier.Verify("ghcr.io/test/app@sha256:aaaa", invalid)
+	assert.ErrorContains(t, err, "invalid Rekor signed entry timestamp")
+}
+
+func Test_Verify_Keyless_Offline(t *testing.T) {
+	f := newFulcio(t)
+	r := newRekor(t)
+	signedAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	key, cert := f.issue(t, "release@corp.com", "https://accounts.google.com", signedAt)
+	reg := &fakeRegistry{manifests: map[string]string{}, digests: map[string]string{}, blobs: map[string][]byte{}}
+	reg.signKeyless(t, "ghcr.io/test/app", "sha256:aaaa", key, cert, r, signedAt, true)
+	reg.signKeyless(t, "ghcr.io/test/online", "sha256:bbbb", key, cert, r, signedAt, false)
+
+	verifier := NewOfflineVerifier(reg)
+	opts := Options{
+		Roots:       f.roots,
+		Subject:     "*@corp.com",
+		RekorURL:    "https://rekor.sigstore.dev",
+		RekorPubKey: r.pubKey,
+	}
+	// the bundle is verified with the pinned Rekor public key
+	_, err := verifier.Verify("ghcr.io/test/app@sha256:aaaa", opts)
+	assert.NilError(t, err)
+
+	// Rekor is not called even if its URL is set
+	_, err = verifier.Verify("ghcr.io/test/online@sha256:bbbb", opts)
+	assert.ErrorContains(t, err, "not fetched in offline mode")
+}
+
+func Test_certificateIssuer(t *testing.T) {
+	issuer, err := asn1.Marshal("https://accounts.google.com")
+	assert.NilError(t, err)
+	cert := &x509.Certificate{Extensions: []pkix.Extension{{Id: oidIssuerV2, Value: issuer}}}
+	assert.Equal(t, certificateIssuer(cert), "https://accounts.google.com")
+	cert = &x509.Certificate{Extensions: []pkix.Extension{{Id: oidIssuer, Value: []byte("https://github.com/login/oauth")}}}
+	assert.Equal(t, certificateIssuer(cert), "https://github.com/login/oauth")
+	assert.Equal(t, certificateIssuer(&x509.Certificate{}), "")
+}
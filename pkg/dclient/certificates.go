@@ -11,6 +11,7 @@ import (
 	tls "github.com/nirmata/kyverno/pkg/tls"
 	certificates "k8s.io/api/certificates/v1beta1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 )
@@ -31,6 +32,13 @@ func (c *Client) InitTLSPemPair(configuration *rest.Config, fqdncn bool) (*tls.T
 			return nil, err
 		}
 		if err = c.WriteTlsPair(certProps, tlsPair); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				// the pair was written by another replica in the meantime
+				if existing := c.ReadTlsPair(certProps); existing != nil {
+					glog.Infoln("Using the key/certificate pair written by another replica")
+					return existing, nil
+				}
+			}
 			return nil, fmt.Errorf("Unable to save TLS pair to the cluster: %v", err)
 		}
 		return tlsPair, nil
@@ -7,15 +7,20 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/nirmata/kyverno/pkg/config"
+	engineutils "github.com/nirmata/kyverno/pkg/engine/utils"
+	"github.com/nirmata/kyverno/pkg/informers"
 	apps "k8s.io/api/apps/v1"
 	certificates "k8s.io/api/certificates/v1beta1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	helperv1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	patchTypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
@@ -32,15 +37,22 @@ type Client struct {
 	clientConfig    *rest.Config
 	kclient         kubernetes.Interface
 	DiscoveryClient IDiscovery
+	// backoff of the retries of the transient errors of the get, list, create and update requests
+	backoff wait.Backoff
+	// maximum number of resources of the pages of ListResourcePages
+	pageSize int64
 }
 
-//NewClient creates new instance of client
-func NewClient(config *rest.Config, resync time.Duration, stopCh <-chan struct{}) (*Client, error) {
+//NewClient creates new instance of client, the transient errors of the get, list, create and update requests
+// are retried with the backoff. The registered resources are discovered again every resync period, and when
+// a custom resource definition changes
+func NewClient(config *rest.Config, resync time.Duration, backoff wait.Backoff, stopCh <-chan struct{}) (*Client, error) {
 	dclient, err := dynamic.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
-	kclient, err := kubernetes.NewForConfig(config)
+	// the built-in types are read with protobuf, the dynamic client only supports JSON
+	kclient, err := kubernetes.NewForConfig(ProtobufConfig(config))
 	if err != nil {
 		return nil, err
 	}
@@ -48,6 +60,8 @@ func NewClient(config *rest.Config, resync time.Duration, stopCh <-chan struct{}
 		client:       dclient,
 		clientConfig: config,
 		kclient:      kclient,
+		backoff:      backoff,
+		pageSize:     DefaultListPageSize,
 	}
 	// Set discovery client
 	discoveryClient := ServerPreferredResources{memory.NewMemCacheClient(kclient.Discovery())}
@@ -57,14 +71,18 @@ func NewClient(config *rest.Config, resync time.Duration, stopCh <-chan struct{}
 	// If a resource is removed then and cache is not invalidate yet, we will not detect the removal
 	// but the re-sync shall re-evaluate
 	go discoveryClient.Poll(resync, stopCh)
+	// the cache is also invalidated on the changes of the custom resource definitions,
+	// so the new custom resources are found within seconds
+	discoveryClient.WatchCRDs(&client, stopCh)
 
 	client.SetDiscovery(discoveryClient)
 	return &client, nil
 }
 
-//NewDynamicSharedInformerFactory returns a new instance of DynamicSharedInformerFactory
+//NewDynamicSharedInformerFactory returns a new instance of DynamicSharedInformerFactory,
+// the metadata of the cached objects is stripped
 func (c *Client) NewDynamicSharedInformerFactory(defaultResync time.Duration) dynamicinformer.DynamicSharedInformerFactory {
-	return dynamicinformer.NewDynamicSharedInformerFactory(c.client, defaultResync)
+	return informers.NewDynamicSharedInformerFactory(c.client, defaultResync)
 }
 
 //GetKubePolicyDeployment returns kube policy depoyment value
@@ -116,7 +134,18 @@ func (c *Client) getGroupVersionMapper(kind string) schema.GroupVersionResource
 
 // GetResource returns the resource in unstructured/json format
 func (c *Client) GetResource(kind string, namespace string, name string, subresources ...string) (*unstructured.Unstructured, error) {
-	return c.getResourceInterface(kind, namespace).Get(name, meta.GetOptions{}, subresources...)
+	var obj *unstructured.Unstructured
+	err := c.retry("get", kind, func() (err error) {
+		if len(subresources) == 0 {
+			var typed bool
+			if obj, typed, err = c.getTyped(kind, namespace, name); typed {
+				return err
+			}
+		}
+		obj, err = c.getResourceInterface(kind, namespace).Get(name, meta.GetOptions{}, subresources...)
+		return err
+	})
+	return obj, err
 }
 
 //PatchResource patches the resource
@@ -127,11 +156,72 @@ func (c *Client) PatchResource(kind string, namespace string, name string, patch
 // ListResource returns the list of resources in unstructured/json format
 // Access items using []Items
 func (c *Client) ListResource(kind string, namespace string, lselector *meta.LabelSelector) (*unstructured.UnstructuredList, error) {
+	return c.ListResourceWithSelectors(kind, namespace, lselector, nil)
+}
+
+// ListResourceWithSelectors returns the list of resources matching the label selector and the field selector,
+// the resources are filtered by the API server. Either selector may be nil to match all the resources.
+// The fields supported by the field selectors depend on the kind, metadata.name and metadata.namespace are supported by all kinds
+func (c *Client) ListResourceWithSelectors(kind string, namespace string, lselector *meta.LabelSelector, fselector fields.Selector) (*unstructured.UnstructuredList, error) {
+	return c.list(kind, namespace, listOptions(lselector, fselector))
+}
+
+// listOptions returns the list options of the selectors
+func listOptions(lselector *meta.LabelSelector, fselector fields.Selector) meta.ListOptions {
 	options := meta.ListOptions{}
 	if lselector != nil {
-		options = meta.ListOptions{LabelSelector: helperv1.FormatLabelSelector(lselector)}
+		options.LabelSelector = helperv1.FormatLabelSelector(lselector)
+	}
+	if fselector != nil && !fselector.Empty() {
+		options.FieldSelector = fselector.String()
+	}
+	return options
+}
+
+// list lists the resources, with the typed client for the kinds encoded with protobuf
+func (c *Client) list(kind string, namespace string, options meta.ListOptions) (*unstructured.UnstructuredList, error) {
+	var list *unstructured.UnstructuredList
+	err := c.retry("list", kind, func() (err error) {
+		var typed bool
+		if list, typed, err = c.listTyped(kind, namespace, options); typed {
+			return err
+		}
+		list, err = c.getResourceInterface(kind, namespace).List(options)
+		return err
+	})
+	return list, err
+}
+
+// DefaultListPageSize is the default maximum number of resources of the pages of ListResourcePages
+const DefaultListPageSize = 500
+
+// SetListPageSize sets the maximum number of resources of the pages of ListResourcePages, 0 lists all the resources at once
+func (c *Client) SetListPageSize(pageSize int64) {
+	c.pageSize = pageSize
+}
+
+// ListResourcePages lists the resources matching the selectors page by page and calls fn on each page, so the resources
+// of a kind are never all in memory at once. Listing stops on the first error of fn.
+// The list fails if the listing of the pages takes longer than the expiration of the continue token, 5m by default
+func (c *Client) ListResourcePages(kind string, namespace string, lselector *meta.LabelSelector, fselector fields.Selector, fn func(*unstructured.UnstructuredList) error) error {
+	options := listOptions(lselector, fselector)
+	options.Limit = c.pageSize
+	for {
+		list, err := c.list(kind, namespace, options)
+		if err != nil {
+			if options.Continue != "" && errors.IsResourceExpired(err) {
+				return fmt.Errorf("listing of %s expired before its last page: %v", kind, err)
+			}
+			return err
+		}
+		if err := fn(list); err != nil {
+			return err
+		}
+		if list.GetContinue() == "" {
+			return nil
+		}
+		options.Continue = list.GetContinue()
 	}
-	return c.getResourceInterface(kind, namespace).List(options)
 }
 
 // DeleteResource deletes the specified resource
@@ -151,8 +241,14 @@ func (c *Client) CreateResource(kind string, namespace string, obj interface{},
 		options = meta.CreateOptions{DryRun: []string{meta.DryRunAll}}
 	}
 	// convert typed to unstructured obj
+	// a retried create may fail as already existing, if the failed request created the resource
 	if unstructuredObj := convertToUnstructured(obj); unstructuredObj != nil {
-		return c.getResourceInterface(kind, namespace).Create(unstructuredObj, options)
+		var created *unstructured.Unstructured
+		err := c.retry("create", kind, func() (err error) {
+			created, err = c.getResourceInterface(kind, namespace).Create(unstructuredObj, options)
+			return err
+		})
+		return created, err
 	}
 	return nil, fmt.Errorf("Unable to create resource ")
 }
@@ -164,12 +260,52 @@ func (c *Client) UpdateResource(kind string, namespace string, obj interface{},
 		options = meta.UpdateOptions{DryRun: []string{meta.DryRunAll}}
 	}
 	// convert typed to unstructured obj
+	// the conflicts are not retried, the resource must be read again
 	if unstructuredObj := convertToUnstructured(obj); unstructuredObj != nil {
-		return c.getResourceInterface(kind, namespace).Update(unstructuredObj, options)
+		var updated *unstructured.Unstructured
+		err := c.retry("update", kind, func() (err error) {
+			updated, err = c.getResourceInterface(kind, namespace).Update(unstructuredObj, options)
+			return err
+		})
+		return updated, err
 	}
 	return nil, fmt.Errorf("Unable to update resource ")
 }
 
+// ApplyResource creates or updates object for the specified resource/namespace with server-side apply
+// the fields set in the object are owned by the field manager, conflicts with other field managers are returned as errors
+func (c *Client) ApplyResource(kind string, namespace string, obj interface{}, fieldManager string, dryRun bool) (*unstructured.Unstructured, error) {
+	options := meta.PatchOptions{FieldManager: fieldManager}
+	if dryRun {
+		options.DryRun = []string{meta.DryRunAll}
+	}
+	// convert typed to unstructured obj
+	unstructuredObj := convertToUnstructured(obj)
+	if unstructuredObj == nil {
+		return nil, fmt.Errorf("Unable to apply resource ")
+	}
+	// apply requests require the apiVersion and kind to be set
+	if unstructuredObj.GetKind() == "" {
+		unstructuredObj.SetKind(kind)
+	}
+	if unstructuredObj.GetAPIVersion() == "" {
+		unstructuredObj.SetAPIVersion(c.getGroupVersionMapper(kind).GroupVersion().String())
+	}
+	data, err := unstructuredObj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return c.getResourceInterface(kind, namespace).Patch(unstructuredObj.GetName(), patchTypes.ApplyPatchType, data, options)
+}
+
+// RawAbsPath performs a GET request on the API server path and returns the raw response
+func (c *Client) RawAbsPath(path string) ([]byte, error) {
+	if c.kclient == nil || c.kclient.Discovery().RESTClient() == nil {
+		return nil, fmt.Errorf("Unable to get %s, REST client is not initialized", path)
+	}
+	return c.kclient.Discovery().RESTClient().Get().AbsPath(path).DoRaw()
+}
+
 // UpdateStatusResource updates the resource "status" subresource
 func (c *Client) UpdateStatusResource(kind string, namespace string, obj interface{}, dryRun bool) (*unstructured.Unstructured, error) {
 	options := meta.UpdateOptions{}
@@ -213,6 +349,7 @@ func convertToCSR(obj *unstructured.Unstructured) (*certificates.CertificateSign
 //IDiscovery provides interface to mange Kind and GVR mapping
 type IDiscovery interface {
 	GetGVRFromKind(kind string) schema.GroupVersionResource
+	IsNamespaced(kind string) (bool, error)
 }
 
 // SetDiscovery sets the discovery client implementation
@@ -230,7 +367,7 @@ func (c ServerPreferredResources) Poll(resync time.Duration, stopCh <-chan struc
 	// start a ticker
 	ticker := time.NewTicker(resync)
 	defer func() { ticker.Stop() }()
-	glog.Infof("Starting registered resources sync: every %d seconds", resync)
+	glog.Infof("Starting registered resources sync: every %v", resync)
 	for {
 		select {
 		case <-stopCh:
@@ -248,7 +385,7 @@ func (c ServerPreferredResources) Poll(resync time.Duration, stopCh <-chan struc
 // if kind is not found in first attempt we invalidate the cache,
 // the retry will then fetch the new registered resources and check again
 // if not found after 2 attempts, we declare kind is not found
-// kind is Case sensitive
+// kind is Case sensitive, it may be prefixed with an API version, e.g. apps/v1/Deployment
 func (c ServerPreferredResources) GetGVRFromKind(kind string) schema.GroupVersionResource {
 	var gvr schema.GroupVersionResource
 	var err error
@@ -265,6 +402,45 @@ func (c ServerPreferredResources) GetGVRFromKind(kind string) schema.GroupVersio
 	return gvr
 }
 
+//IsNamespaced returns true if the resources of the kind are namespaced, false if they are cluster-scoped
+func (c ServerPreferredResources) IsNamespaced(kind string) (bool, error) {
+	resource, err := loadServerResource(kind, c.cachedClient)
+	if err != nil && !c.cachedClient.Fresh() {
+		// invalidate cache & re-try once more
+		c.cachedClient.Invalidate()
+		resource, err = loadServerResource(kind, c.cachedClient)
+	}
+	if err != nil {
+		return false, err
+	}
+	return resource.Namespaced, nil
+}
+
+func loadServerResource(k string, cdi discovery.CachedDiscoveryInterface) (meta.APIResource, error) {
+	serverresources, err := cdi.ServerPreferredResources()
+	if err != nil {
+		return meta.APIResource{}, err
+	}
+	gvk := engineutils.ParseKind(k)
+	for _, serverresource := range serverresources {
+		if !matchesGroupVersion(gvk, serverresource.GroupVersion) {
+			continue
+		}
+		for _, resource := range serverresource.APIResources {
+			if resource.Kind == gvk.Kind && !strings.Contains(resource.Name, "/") {
+				return resource, nil
+			}
+		}
+	}
+	return meta.APIResource{}, fmt.Errorf("kind '%s' not found", k)
+}
+
+// matchesGroupVersion checks if the API version of the kind is the group version of the server resources,
+// the kinds without an API version are looked up in all the group versions
+func matchesGroupVersion(gvk schema.GroupVersionKind, groupVersion string) bool {
+	return gvk.Group == engineutils.AnyGroupVersion || gvk.GroupVersion().String() == groupVersion
+}
+
 func loadServerResources(k string, cdi discovery.CachedDiscoveryInterface) (schema.GroupVersionResource, error) {
 	serverresources, err := cdi.ServerPreferredResources()
 	emptyGVR := schema.GroupVersionResource{}
@@ -272,11 +448,15 @@ func loadServerResources(k string, cdi discovery.CachedDiscoveryInterface) (sche
 		glog.Error(err)
 		return emptyGVR, err
 	}
+	gvk := engineutils.ParseKind(k)
 	for _, serverresource := range serverresources {
+		if !matchesGroupVersion(gvk, serverresource.GroupVersion) {
+			continue
+		}
 		for _, resource := range serverresource.APIResources {
 			// skip the resource names with "/", to avoid comparison with subresources
 
-			if resource.Kind == k && !strings.Contains(resource.Name, "/") {
+			if resource.Kind == gvk.Kind && !strings.Contains(resource.Name, "/") {
 				gv, err := schema.ParseGroupVersion(serverresource.GroupVersion)
 				if err != nil {
 					glog.Error(err)
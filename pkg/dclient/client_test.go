@@ -1,11 +1,20 @@
 package client
 
 import (
+	"fmt"
+	"strconv"
 	"testing"
+	"time"
 
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 )
 
 // GetResource
@@ -123,3 +132,153 @@ func TestKubePolicyDeployment(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRetryTransientErrors(t *testing.T) {
+	f := newFixture(t)
+	f.client.backoff = NewRetry(3, time.Millisecond)
+	gr := schema.GroupResource{Group: "group", Resource: "thekinds"}
+
+	// transient errors are retried
+	calls := 0
+	err := f.client.retry("get", "thekind", func() error {
+		calls++
+		if calls < 3 {
+			return errors.NewServerTimeout(gr, "get", 1)
+		}
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, calls, 3)
+
+	// the retries are exhausted
+	calls = 0
+	err = f.client.retry("get", "thekind", func() error {
+		calls++
+		return errors.NewServiceUnavailable("unavailable")
+	})
+	assert.Assert(t, errors.IsServiceUnavailable(err))
+	assert.Equal(t, calls, 4)
+
+	// other errors are not retried
+	calls = 0
+	err = f.client.retry("get", "thekind", func() error {
+		calls++
+		return errors.NewNotFound(gr, "name-foo")
+	})
+	assert.Assert(t, errors.IsNotFound(err))
+	assert.Equal(t, calls, 1)
+
+	// no retries by default in the mock client
+	f.client.backoff = wait.Backoff{}
+	calls = 0
+	f.client.retry("get", "thekind", func() error {
+		calls++
+		return errors.NewTooManyRequests("throttled", 1)
+	})
+	assert.Equal(t, calls, 1)
+}
+
+// pagedClient serves the lists by pages of opts.Limit resources, the continue token is the index of the next resource
+type pagedClient struct {
+	dynamic.Interface
+	dynamic.NamespaceableResourceInterface
+	items []unstructured.Unstructured
+	calls int
+	// options of the last list
+	options meta.ListOptions
+}
+
+func (c *pagedClient) Resource(schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return c
+}
+
+func (c *pagedClient) Namespace(string) dynamic.ResourceInterface {
+	return c
+}
+
+func (c *pagedClient) List(opts meta.ListOptions) (*unstructured.UnstructuredList, error) {
+	c.calls++
+	c.options = opts
+	start, end := 0, len(c.items)
+	if opts.Continue != "" {
+		start, _ = strconv.Atoi(opts.Continue)
+	}
+	list := &unstructured.UnstructuredList{}
+	if opts.Limit > 0 && start+int(opts.Limit) < end {
+		end = start + int(opts.Limit)
+		list.SetContinue(strconv.Itoa(end))
+	}
+	list.Items = c.items[start:end]
+	return list, nil
+}
+
+func TestListResourcePages(t *testing.T) {
+	f := newFixture(t)
+	paged := &pagedClient{}
+	for i := 0; i < 5; i++ {
+		paged.items = append(paged.items, *newUnstructured("group/version", "TheKind", "ns-foo", fmt.Sprintf("name-%d", i)))
+	}
+	f.client.client = paged
+
+	var pages [][]string
+	list := func() error {
+		pages = nil
+		return f.client.ListResourcePages("thekind", "ns-foo", nil, nil, func(list *unstructured.UnstructuredList) error {
+			var names []string
+			for _, r := range list.Items {
+				names = append(names, r.GetName())
+			}
+			pages = append(pages, names)
+			return nil
+		})
+	}
+
+	f.client.SetListPageSize(2)
+	assert.NilError(t, list())
+	assert.DeepEqual(t, pages, [][]string{{"name-0", "name-1"}, {"name-2", "name-3"}, {"name-4"}})
+	assert.Equal(t, paged.calls, 3)
+
+	// all the resources at once
+	f.client.SetListPageSize(0)
+	assert.NilError(t, list())
+	assert.Equal(t, len(pages), 1)
+	assert.Equal(t, len(pages[0]), 5)
+
+	// the errors of fn stop the listing
+	f.client.SetListPageSize(2)
+	paged.calls = 0
+	err := f.client.ListResourcePages("thekind", "ns-foo", nil, nil, func(*unstructured.UnstructuredList) error {
+		return fmt.Errorf("failed")
+	})
+	assert.Error(t, err, "failed")
+	assert.Equal(t, paged.calls, 1)
+}
+
+func TestListResourceWithSelectors(t *testing.T) {
+	f := newFixture(t)
+	paged := &pagedClient{}
+	f.client.client = paged
+
+	// the selectors are passed to the API server
+	lselector := &meta.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}}
+	fselector := fields.AndSelectors(fields.OneTermEqualSelector("metadata.name", "name-foo"), fields.OneTermNotEqualSelector("status.phase", "Running"))
+	_, err := f.client.ListResourceWithSelectors("thekind", "ns-foo", lselector, fselector)
+	assert.NilError(t, err)
+	assert.Equal(t, paged.options.LabelSelector, "app=nginx")
+	assert.Equal(t, paged.options.FieldSelector, "metadata.name=name-foo,status.phase!=Running")
+
+	f.client.SetListPageSize(10)
+	err = f.client.ListResourcePages("thekind", "ns-foo", nil, fields.OneTermEqualSelector("metadata.name", "name-foo"), func(*unstructured.UnstructuredList) error {
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, paged.options.LabelSelector, "")
+	assert.Equal(t, paged.options.FieldSelector, "metadata.name=name-foo")
+	assert.Equal(t, paged.options.Limit, int64(10))
+
+	// no selectors
+	_, err = f.client.ListResource("thekind", "ns-foo", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, paged.options.LabelSelector, "")
+	assert.Equal(t, paged.options.FieldSelector, "")
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"reflect"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// crdGVR is the resource of the custom resource definitions
+var crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1beta1", Resource: "customresourcedefinitions"}
+
+// WatchCRDs invalidates the cached registered resources when a custom resource definition is added, changed or removed,
+// so the kinds of the new custom resources are found without waiting for the periodic refresh
+func (c ServerPreferredResources) WatchCRDs(client *Client, stopCh <-chan struct{}) {
+	factory := client.NewDynamicSharedInformerFactory(0)
+	factory.ForResource(crdGVR).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.invalidate("added", obj)
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			if crdChanged(old, cur) {
+				c.invalidate("updated", cur)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			c.invalidate("deleted", obj)
+		},
+	})
+	factory.Start(stopCh)
+}
+
+func (c ServerPreferredResources) invalidate(event string, obj interface{}) {
+	if crd, ok := obj.(*unstructured.Unstructured); ok {
+		glog.V(4).Infof("invalidating local client cache for registered resources: custom resource definition %s %s", crd.GetName(), event)
+	}
+	c.cachedClient.Invalidate()
+}
+
+// crdChanged returns true if the served resources of the custom resource definition may have changed:
+// its spec changed, e.g. a version was added, or it was established, its resources are only discovered once established
+func crdChanged(old, cur interface{}) bool {
+	oldCRD, ok := old.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+	curCRD, ok := cur.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+	if oldCRD.GetResourceVersion() == curCRD.GetResourceVersion() {
+		// resync
+		return false
+	}
+	return !reflect.DeepEqual(oldCRD.Object["spec"], curCRD.Object["spec"]) || established(oldCRD) != established(curCRD)
+}
+
+func established(crd *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Established" {
+			return condition["status"] == "True"
+		}
+	}
+	return false
+}
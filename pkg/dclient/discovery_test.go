@@ -0,0 +1,88 @@
+package client
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+)
+
+type fakeCachedDiscovery struct {
+	discovery.CachedDiscoveryInterface
+	mu          sync.Mutex
+	invalidated int
+}
+
+func (f *fakeCachedDiscovery) Invalidate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invalidated++
+}
+
+func (f *fakeCachedDiscovery) Invalidated() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.invalidated
+}
+
+func newCRD(resourceVersion string, versions []interface{}, established string) *unstructured.Unstructured {
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1beta1",
+		"kind":       "CustomResourceDefinition",
+		"metadata": map[string]interface{}{
+			"name":            "foos.example.com",
+			"resourceVersion": resourceVersion,
+		},
+		"spec": map[string]interface{}{
+			"group":    "example.com",
+			"versions": versions,
+		},
+	}}
+	if established != "" {
+		crd.Object["status"] = map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "NamesAccepted", "status": "True"},
+				map[string]interface{}{"type": "Established", "status": established},
+			},
+		}
+	}
+	return crd
+}
+
+func Test_CRDChanged(t *testing.T) {
+	v1 := []interface{}{map[string]interface{}{"name": "v1", "served": true}}
+	v2 := []interface{}{map[string]interface{}{"name": "v1", "served": true}, map[string]interface{}{"name": "v2", "served": true}}
+
+	// resync
+	assert.Assert(t, !crdChanged(newCRD("1", v1, "True"), newCRD("1", v1, "True")))
+	// status changes other than established
+	assert.Assert(t, !crdChanged(newCRD("1", v1, "True"), newCRD("2", v1, "True")))
+	// established
+	assert.Assert(t, crdChanged(newCRD("1", v1, ""), newCRD("2", v1, "True")))
+	assert.Assert(t, crdChanged(newCRD("1", v1, "False"), newCRD("2", v1, "True")))
+	// version added
+	assert.Assert(t, crdChanged(newCRD("1", v1, "True"), newCRD("2", v2, "True")))
+}
+
+func Test_CRDEventsInvalidateCache(t *testing.T) {
+	client, err := NewMockClient(runtime.NewScheme())
+	assert.NilError(t, err)
+	cached := &fakeCachedDiscovery{}
+	c := ServerPreferredResources{cachedClient: cached}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	c.WatchCRDs(client, stopCh)
+
+	_, err = client.client.Resource(crdGVR).Create(newCRD("1", nil, ""), meta.CreateOptions{})
+	assert.NilError(t, err)
+	err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return cached.Invalidated() > 0, nil
+	})
+	assert.NilError(t, err)
+}
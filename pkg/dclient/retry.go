@@ -0,0 +1,52 @@
+package client
+
+import (
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// DefaultRetry retries the transient errors 3 times, after 100ms, 200ms and 400ms
+var DefaultRetry = NewRetry(3, 100*time.Millisecond)
+
+// NewRetry returns the backoff of the retries of the transient errors, the delay doubles after each retry.
+// The errors are not retried if retries is 0.
+func NewRetry(retries int, delay time.Duration) wait.Backoff {
+	return wait.Backoff{
+		Steps:    retries,
+		Duration: delay,
+		Factor:   2,
+		Jitter:   0.1,
+	}
+}
+
+// isTransient returns true for the errors of the requests which may succeed if retried:
+// the timeouts, the throttled requests, the errors of the API server and the broken connections
+func isTransient(err error) bool {
+	switch {
+	case errors.IsTimeout(err), errors.IsServerTimeout(err), errors.IsTooManyRequests(err),
+		errors.IsInternalError(err), errors.IsServiceUnavailable(err), errors.IsUnexpectedServerError(err):
+		return true
+	case utilnet.IsConnectionReset(err), utilnet.IsProbableEOF(err):
+		return true
+	}
+	return strings.Contains(err.Error(), "connection refused")
+}
+
+// retry calls fn until it succeeds, fails with an error which is not transient, or the retries are exhausted
+func (c *Client) retry(verb, kind string, fn func() error) error {
+	backoff := c.backoff
+	for {
+		err := fn()
+		if err == nil || backoff.Steps <= 0 || !isTransient(err) {
+			return err
+		}
+		delay := backoff.Step()
+		glog.V(4).Infof("retrying %s %s in %v: %v", verb, kind, delay, err)
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+)
+
+// contentTypeProtobuf is the protobuf encoding of the built-in types
+const contentTypeProtobuf = "application/vnd.kubernetes.protobuf"
+
+// ProtobufConfig returns a copy of the config of the clients of the built-in types, whose requests and responses are encoded with protobuf.
+// The encoding is cheaper to decode and smaller than JSON, the types not supporting protobuf fall back to JSON
+func ProtobufConfig(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.ContentType = contentTypeProtobuf
+	config.AcceptContentTypes = contentTypeProtobuf + "," + runtime.ContentTypeJSON
+	return config
+}
+
+// getTyped gets the namespaces, config maps and secrets with the typed client, encoded with protobuf,
+// it returns false for the other kinds, read with the dynamic client
+func (c *Client) getTyped(kind, namespace, name string) (*unstructured.Unstructured, bool, error) {
+	if c.kclient == nil {
+		return nil, false, nil
+	}
+	var obj runtime.Object
+	var err error
+	switch kind {
+	case "Namespace":
+		obj, err = c.kclient.CoreV1().Namespaces().Get(name, meta.GetOptions{})
+	case "ConfigMap":
+		obj, err = c.kclient.CoreV1().ConfigMaps(namespace).Get(name, meta.GetOptions{})
+	case Secrets:
+		obj, err = c.kclient.CoreV1().Secrets(namespace).Get(name, meta.GetOptions{})
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	resource, err := typedToUnstructured(kind, obj)
+	return resource, true, err
+}
+
+// listTyped lists the namespaces, config maps and secrets with the typed client, encoded with protobuf,
+// it returns false for the other kinds, listed with the dynamic client
+func (c *Client) listTyped(kind, namespace string, options meta.ListOptions) (*unstructured.UnstructuredList, bool, error) {
+	if c.kclient == nil {
+		return nil, false, nil
+	}
+	var items []runtime.Object
+	var listMeta meta.ListMeta
+	switch kind {
+	case "Namespace":
+		list, err := c.kclient.CoreV1().Namespaces().List(options)
+		if err != nil {
+			return nil, true, err
+		}
+		for i := range list.Items {
+			items = append(items, &list.Items[i])
+		}
+		listMeta = list.ListMeta
+	case "ConfigMap":
+		list, err := c.kclient.CoreV1().ConfigMaps(namespace).List(options)
+		if err != nil {
+			return nil, true, err
+		}
+		for i := range list.Items {
+			items = append(items, &list.Items[i])
+		}
+		listMeta = list.ListMeta
+	case Secrets:
+		list, err := c.kclient.CoreV1().Secrets(namespace).List(options)
+		if err != nil {
+			return nil, true, err
+		}
+		for i := range list.Items {
+			items = append(items, &list.Items[i])
+		}
+		listMeta = list.ListMeta
+	default:
+		return nil, false, nil
+	}
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{}}
+	list.SetAPIVersion("v1")
+	list.SetKind(kind + "List")
+	list.SetResourceVersion(listMeta.ResourceVersion)
+	list.SetContinue(listMeta.Continue)
+	for _, item := range items {
+		resource, err := typedToUnstructured(kind, item)
+		if err != nil {
+			return nil, true, err
+		}
+		list.Items = append(list.Items, *resource)
+	}
+	return list, true, nil
+}
+
+// typedToUnstructured converts the typed object of the core group to unstructured,
+// the kind is set as it is not decoded from the protobuf responses
+func typedToUnstructured(kind string, obj runtime.Object) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	resource := &unstructured.Unstructured{Object: content}
+	resource.SetAPIVersion("v1")
+	resource.SetKind(kind)
+	return resource, nil
+}
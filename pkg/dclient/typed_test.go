@@ -0,0 +1,63 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+// the typed objects are converted as if decoded from JSON by the dynamic client
+func Test_TypedToUnstructured(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: meta.ObjectMeta{Name: "registry", Namespace: "default", Labels: map[string]string{"app": "foo"}},
+		Type:       v1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{".dockerconfigjson": []byte(`{"auths":{}}`)},
+	}
+	cm := &v1.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{Name: "settings", Namespace: "default"},
+		Data:       map[string]string{"key": "value"},
+	}
+	ns := &v1.Namespace{
+		ObjectMeta: meta.ObjectMeta{Name: "default"},
+	}
+	c := &Client{kclient: kubernetesfake.NewSimpleClientset(secret, cm, ns)}
+
+	for _, test := range []struct {
+		kind, namespace, name string
+		obj                   interface{}
+	}{
+		{Secrets, "default", "registry", secret},
+		{"ConfigMap", "default", "settings", cm},
+		{"Namespace", "", "default", ns},
+	} {
+		raw, err := json.Marshal(test.obj)
+		assert.NilError(t, err)
+		expected := map[string]interface{}{}
+		assert.NilError(t, json.Unmarshal(raw, &expected))
+		expected["apiVersion"] = "v1"
+		expected["kind"] = test.kind
+
+		resource, err := c.GetResource(test.kind, test.namespace, test.name)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, resource.Object, expected)
+
+		list, err := c.ListResource(test.kind, test.namespace, nil)
+		assert.NilError(t, err)
+		assert.Equal(t, len(list.Items), 1)
+		assert.DeepEqual(t, list.Items[0].Object, expected)
+	}
+}
+
+func Test_ProtobufConfig(t *testing.T) {
+	config := &rest.Config{Host: "https://localhost"}
+	protobuf := ProtobufConfig(config)
+	assert.Equal(t, protobuf.ContentType, "application/vnd.kubernetes.protobuf")
+	assert.Equal(t, protobuf.AcceptContentTypes, "application/vnd.kubernetes.protobuf,application/json")
+	// the config of the dynamic client is unchanged
+	assert.Equal(t, config.ContentType, "")
+}
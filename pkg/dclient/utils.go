@@ -1,13 +1,16 @@
 package client
 
 import (
+	"fmt"
 	"strings"
 
+	engineutils "github.com/nirmata/kyverno/pkg/engine/utils"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic/fake"
 	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
 )
 
 const (
@@ -33,6 +36,13 @@ func NewMockClient(scheme *runtime.Scheme, objects ...runtime.Object) (*Client,
 
 }
 
+// PrependReactor adds a reactor to the fake dynamic client of a mock client, e.g. to handle the server-side apply patches
+func (c *Client) PrependReactor(verb, resource string, reaction clienttesting.ReactionFunc) {
+	if client, ok := c.client.(*fake.FakeDynamicClient); ok {
+		client.PrependReactor(verb, resource, reaction)
+	}
+}
+
 // NewFakeDiscoveryClient returns a fakediscovery client
 func NewFakeDiscoveryClient(registeredResouces []schema.GroupVersionResource) *fakeDiscoveryClient {
 	// Load some-preregistd resources
@@ -65,10 +75,19 @@ func (c *fakeDiscoveryClient) getGVR(resource string) schema.GroupVersionResourc
 }
 
 func (c *fakeDiscoveryClient) GetGVRFromKind(kind string) schema.GroupVersionResource {
-	resource := strings.ToLower(kind) + "s"
+	resource := strings.ToLower(engineutils.ParseKind(kind).Kind) + "s"
 	return c.getGVR(resource)
 }
 
+// IsNamespaced returns false for the namespaces and the resources named cluster*, e.g. clusterroles
+func (c *fakeDiscoveryClient) IsNamespaced(kind string) (bool, error) {
+	gvr := c.GetGVRFromKind(kind)
+	if gvr.Resource == "" {
+		return false, fmt.Errorf("kind '%s' not found", kind)
+	}
+	return gvr.Resource != "namespaces" && !strings.HasPrefix(gvr.Resource, "cluster"), nil
+}
+
 func newUnstructured(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
 	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strconv"
 
-	"github.com/golang/glog"
+
+
+	"github.com/nirmata/kyverno/pkg/engine/log"
 )
 
 //ValidationHandler for element processes
@@ -15,12 +17,12 @@ type ValidationHandler interface {
 type resourceElementHandler = func(resourceElement, patternElement, originPattern interface{}, path string) (string, error)
 
 //CreateElementHandler factory to process elements
-func CreateElementHandler(element string, pattern interface{}, path string) ValidationHandler {
+func CreateElementHandler(log log.Logger, element string, pattern interface{}, path string) ValidationHandler {
 	switch {
 	case IsConditionAnchor(element):
 		return NewConditionAnchorHandler(element, pattern, path)
 	case IsExistenceAnchor(element):
-		return NewExistenceHandler(element, pattern, path)
+		return NewExistenceHandler(log, element, pattern, path)
 	case IsEqualityAnchor(element):
 		return NewEqualityHandler(element, pattern, path)
 	case IsNegationAnchor(element):
@@ -157,8 +159,9 @@ func (ch ConditionAnchorHandler) Handle(handler resourceElementHandler, resource
 }
 
 //NewExistenceHandler returns existence handler
-func NewExistenceHandler(anchor string, pattern interface{}, path string) ValidationHandler {
+func NewExistenceHandler(log log.Logger, anchor string, pattern interface{}, path string) ValidationHandler {
 	return ExistenceHandler{
+		log:     log,
 		anchor:  anchor,
 		pattern: pattern,
 		path:    path,
@@ -167,6 +170,7 @@ func NewExistenceHandler(anchor string, pattern interface{}, path string) Valida
 
 //ExistenceHandler provides handlers to process exitence anchor handler
 type ExistenceHandler struct {
+	log     log.Logger
 	anchor  string
 	pattern interface{}
 	path    string
@@ -192,16 +196,16 @@ func (eh ExistenceHandler) Handle(handler resourceElementHandler, resourceMap ma
 			if !ok {
 				return currentPath, fmt.Errorf("Invalid pattern type %T: Pattern has to be of type map to compare against items in resource", eh.pattern)
 			}
-			return validateExistenceListResource(handler, typedResource, typedPatternMap, originPattern, currentPath)
+			return validateExistenceListResource(eh.log, handler, typedResource, typedPatternMap, originPattern, currentPath)
 		default:
-			glog.Error("Invalid type: Existence ^ () anchor can be used only on list/array type resource")
+			eh.log.Error("Invalid type: Existence ^ () anchor can be used only on list/array type resource")
 			return currentPath, fmt.Errorf("Invalid resource type %T: Existence ^ () anchor can be used only on list/array type resource", value)
 		}
 	}
 	return "", nil
 }
 
-func validateExistenceListResource(handler resourceElementHandler, resourceList []interface{}, patternMap map[string]interface{}, originPattern interface{}, path string) (string, error) {
+func validateExistenceListResource(log log.Logger, handler resourceElementHandler, resourceList []interface{}, patternMap map[string]interface{}, originPattern interface{}, path string) (string, error) {
 	// the idea is atleast on the elements in the array should satisfy the pattern
 	// if non satisfy then throw an error
 	for i, resourceElement := range resourceList {
@@ -209,7 +213,7 @@ func validateExistenceListResource(handler resourceElementHandler, resourceList
 		_, err := handler(resourceElement, patternMap, originPattern, currentPath)
 		if err == nil {
 			// condition is satisfied, dont check further
-			glog.V(4).Infof("Existence check satisfied at path %s, for pattern %v", currentPath, patternMap)
+			log.V(4).Infof("Existence check satisfied at path %s, for pattern %v", currentPath, patternMap)
 			return "", nil
 		}
 	}
@@ -6,7 +6,6 @@ import (
 	"sync"
 
 	jsonpatch "github.com/evanphx/json-patch"
-	"github.com/golang/glog"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 )
 
@@ -14,10 +13,10 @@ import (
 type Interface interface {
 	//AddJSON  merges the json with context
 	AddJSON(dataRaw []byte) error
-	//AddResource merges resource json under request.object
+	//AddResource merges resource json under request.object and the images of its containers under images
 	AddResource(dataRaw []byte) error
 	//AddUserInfo merges userInfo json under kyverno.userInfo
-	AddUserInfo(userInfo kyverno.UserInfo) error
+	AddUserInfo(userInfo kyverno.RequestInfo) error
 	//AddSA merges serrviceaccount
 	AddSA(userName string) error
 	EvalInterface
@@ -52,19 +51,18 @@ func (ctx *Context) AddJSON(dataRaw []byte) error {
 	// merge json
 	ctx.jsonRaw, err = jsonpatch.MergePatch(ctx.jsonRaw, dataRaw)
 	if err != nil {
-		glog.V(4).Infof("failed to merge JSON data: %v", err)
 		return err
 	}
 	return nil
 }
 
 //AddResource data at path: request.object
+// the parsed images of the containers are added at path: images.containers.<name> and images.initContainers.<name>
 func (ctx *Context) AddResource(dataRaw []byte) error {
 
 	// unmarshall the resource struct
 	var data interface{}
 	if err := json.Unmarshal(dataRaw, &data); err != nil {
-		glog.V(4).Infof("failed to unmarshall the context data: %v", err)
 		return err
 	}
 
@@ -80,10 +78,25 @@ func (ctx *Context) AddResource(dataRaw []byte) error {
 
 	objRaw, err := json.Marshal(modifiedResource)
 	if err != nil {
-		glog.V(4).Infof("failed to marshall the updated context data")
 		return err
 	}
-	return ctx.AddJSON(objRaw)
+	if err := ctx.AddJSON(objRaw); err != nil {
+		return err
+	}
+	return ctx.addImageInfo(data)
+}
+
+// addImageInfo adds the parsed images of the resource at path: images
+func (ctx *Context) addImageInfo(resource interface{}) error {
+	images := ExtractImageInfo(resource)
+	if len(images) == 0 {
+		return nil
+	}
+	imagesRaw, err := json.Marshal(map[string]interface{}{"images": images})
+	if err != nil {
+		return err
+	}
+	return ctx.AddJSON(imagesRaw)
 }
 
 //AddUserInfo adds userInfo at path request.userInfo
@@ -96,7 +109,26 @@ func (ctx *Context) AddUserInfo(userRequestInfo kyverno.RequestInfo) error {
 
 	objRaw, err := json.Marshal(modifiedResource)
 	if err != nil {
-		glog.V(4).Infof("failed to marshall the updated context data")
+		return err
+	}
+	return ctx.AddJSON(objRaw)
+}
+
+//AddRequestInfo adds the operation and the namespace of the admission request at path: request.operation and request.namespace
+func (ctx *Context) AddRequestInfo(operation string, namespace string) error {
+	requestInfo := struct {
+		Request interface{} `json:"request"`
+	}{
+		Request: struct {
+			Operation string `json:"operation"`
+			Namespace string `json:"namespace"`
+		}{
+			Operation: operation,
+			Namespace: namespace,
+		},
+	}
+	objRaw, err := json.Marshal(requestInfo)
+	if err != nil {
 		return err
 	}
 	return ctx.AddJSON(objRaw)
@@ -116,13 +148,10 @@ func (ctx *Context) AddSA(userName string) error {
 	// filter namespace
 	groups := strings.Split(sa, ":")
 	if len(groups) >= 2 {
-		glog.V(4).Infof("serviceAccount namespace: %s", groups[0])
-		glog.V(4).Infof("serviceAccount name: %s", groups[1])
 		saName = groups[1]
 		saNamespace = groups[0]
 	}
 
-	glog.V(4).Infof("Loading variable serviceAccountName with value: %s", saName)
 	saNameObj := struct {
 		SA string `json:"serviceAccountName"`
 	}{
@@ -130,14 +159,12 @@ func (ctx *Context) AddSA(userName string) error {
 	}
 	saNameRaw, err := json.Marshal(saNameObj)
 	if err != nil {
-		glog.V(4).Infof("failed to marshall the updated context data")
 		return err
 	}
 	if err := ctx.AddJSON(saNameRaw); err != nil {
 		return err
 	}
 
-	glog.V(4).Infof("Loading variable serviceAccountNamespace with value: %s", saNamespace)
 	saNsObj := struct {
 		SA string `json:"serviceAccountNamespace"`
 	}{
@@ -145,7 +172,6 @@ func (ctx *Context) AddSA(userName string) error {
 	}
 	saNsRaw, err := json.Marshal(saNsObj)
 	if err != nil {
-		glog.V(4).Infof("failed to marshall the updated context data")
 		return err
 	}
 	if err := ctx.AddJSON(saNsRaw); err != nil {
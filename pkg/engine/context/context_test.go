@@ -117,4 +117,91 @@ func Test_addResourceAndUserContext(t *testing.T) {
 	if !reflect.DeepEqual(expectedResult, result) {
 		t.Error("exected result does not match")
 	}
+
+	// Add the operation and the namespace of the request
+	err = ctx.AddRequestInfo("CREATE", "default")
+	if err != nil {
+		t.Error(err)
+	}
+	result, err = ctx.Query("request.operation")
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual("CREATE", result) {
+		t.Error("exected result does not match")
+	}
+	result, err = ctx.Query("request.namespace")
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual("default", result) {
+		t.Error("exected result does not match")
+	}
+	// the resource and the user info are kept
+	result, err = ctx.Query("request.userInfo.username")
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual("system:serviceaccount:nirmata:user1", result) {
+		t.Errorf("exected result does not match, got %v", result)
+	}
+}
+
+func Test_addImageInfo(t *testing.T) {
+	rawResource := []byte(`{
+		"apiVersion": "apps/v1",
+		"kind": "Deployment",
+		"metadata": {
+			"name": "nginx"
+		},
+		"spec": {
+			"template": {
+				"spec": {
+					"initContainers": [
+						{
+							"name": "init",
+							"image": "busybox"
+						}
+					],
+					"containers": [
+						{
+							"name": "nginx",
+							"image": "nginx:1.17"
+						},
+						{
+							"name": "sidecar",
+							"image": "localhost:5000/tools/sidecar@sha256:0123456789abcdef"
+						}
+					]
+				}
+			}
+		}
+	}`)
+
+	ctx := NewContext()
+	if err := ctx.AddResource(rawResource); err != nil {
+		t.Fatal(err)
+	}
+	testCases := map[string]interface{}{
+		"images.containers.nginx.registry":       "docker.io",
+		"images.containers.nginx.repository":     "library/nginx",
+		"images.containers.nginx.tag":            "1.17",
+		"images.containers.nginx.reference":      "docker.io/library/nginx:1.17",
+		"images.containers.sidecar.registry":     "localhost:5000",
+		"images.containers.sidecar.repository":   "tools/sidecar",
+		"images.containers.sidecar.digest":       "sha256:0123456789abcdef",
+		"images.containers.sidecar.tag":          nil,
+		"images.initContainers.init.reference":   "docker.io/library/busybox:latest",
+		"images.containers.sidecar.jsonPointer":  "/spec/template/spec/containers/1/image",
+		"images.containers.*.registry | sort(@)": []interface{}{"docker.io", "localhost:5000"},
+	}
+	for query, expected := range testCases {
+		result, err := ctx.Query(query)
+		if err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(expected, result) {
+			t.Errorf("%s: expected %v, got %v", query, expected, result)
+		}
+	}
 }
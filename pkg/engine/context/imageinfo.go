@@ -0,0 +1,79 @@
+package context
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nirmata/kyverno/pkg/registry"
+)
+
+// ImageInfo is the parsed image of a container, available as images.containers.<name>
+// and images.initContainers.<name>
+type ImageInfo struct {
+	registry.ImageReference
+	// Reference is the fully qualified image reference, e.g. docker.io/library/nginx:latest
+	Reference string `json:"reference"`
+	// JSONPointer is the path of the image in the resource, e.g. /spec/containers/0/image
+	JSONPointer string `json:"jsonPointer"`
+}
+
+// podSpecPaths are the paths of the pod specs in the resources
+// - Pod: spec
+// - Deployment, DaemonSet, StatefulSet, Job, ReplicaSet, ...: spec.template.spec
+// - CronJob: spec.jobTemplate.spec.template.spec
+var podSpecPaths = [][]string{
+	{"spec"},
+	{"spec", "template", "spec"},
+	{"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// ExtractImageInfo parses the images of the containers and init containers of the resource
+// the images are returned per container type and container name
+func ExtractImageInfo(resource interface{}) map[string]map[string]ImageInfo {
+	images := map[string]map[string]ImageInfo{}
+	for _, path := range podSpecPaths {
+		podSpec, ok := getPath(resource, path).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, containerType := range []string{"containers", "initContainers"} {
+			containers, ok := podSpec[containerType].([]interface{})
+			if !ok {
+				continue
+			}
+			for i, c := range containers {
+				container, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := container["name"].(string)
+				image, _ := container["image"].(string)
+				if name == "" || image == "" {
+					continue
+				}
+				ref, err := registry.ParseImageReference(image)
+				if err != nil {
+					// the images that cannot be parsed have no image variables
+					continue
+				}
+				if images[containerType] == nil {
+					images[containerType] = map[string]ImageInfo{}
+				}
+				pointer := fmt.Sprintf("/%s/%s/%d/image", strings.Join(path, "/"), containerType, i)
+				images[containerType][name] = ImageInfo{ImageReference: ref, Reference: ref.String(), JSONPointer: pointer}
+			}
+		}
+	}
+	return images
+}
+
+func getPath(data interface{}, path []string) interface{} {
+	for _, key := range path {
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		data = m[key]
+	}
+	return data
+}
@@ -0,0 +1,279 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/jmespath"
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/engine/variables"
+	"github.com/nirmata/kyverno/pkg/externaldata"
+	"github.com/nirmata/kyverno/pkg/registry"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+// ConfigMapResolver gets the ConfigMaps referenced in the rule context
+type ConfigMapResolver interface {
+	Get(namespace, name string) (*v1.ConfigMap, error)
+}
+
+// configMapListerResolver resolves ConfigMaps from the informer cache
+type configMapListerResolver struct {
+	lister corelisters.ConfigMapLister
+}
+
+// NewConfigMapResolver returns a ConfigMapResolver that reads from the informer cache,
+// the cache is updated by the informer when the ConfigMaps change
+func NewConfigMapResolver(lister corelisters.ConfigMapLister) ConfigMapResolver {
+	return configMapListerResolver{lister: lister}
+}
+
+func (r configMapListerResolver) Get(namespace, name string) (*v1.ConfigMap, error) {
+	return r.lister.ConfigMaps(namespace).Get(name)
+}
+
+// apiCaller performs GET requests on the API server
+type apiCaller interface {
+	RawAbsPath(path string) ([]byte, error)
+}
+
+// resourceGetter gets resources from the API server
+type resourceGetter interface {
+	GetResource(kind string, namespace string, name string, subresources ...string) (*unstructured.Unstructured, error)
+}
+
+// loadRuleContext loads the global context, if the rule uses it, and the context entries of the rule,
+// the entries are only loaded if the rule applies to the resource
+func loadRuleContext(policyContext PolicyContext, rule kyverno.Rule, resource unstructured.Unstructured) error {
+	log := policyContext.Log
+	useGlobalContext := policyContext.GlobalContext != nil && policyContext.GlobalContext.usedBy(policyContext.Policy, rule)
+	if len(rule.Context) == 0 && !useGlobalContext {
+		return nil
+	}
+	if !MatchesResourceDescription(resource, rule) {
+		return nil
+	}
+	ctx, ok := policyContext.Context.(context.Interface)
+	if !ok {
+		return fmt.Errorf("context does not support loading data")
+	}
+	if useGlobalContext {
+		span := policyContext.Span.Start("global context")
+		err := loadGlobalContext(log, policyContext.GlobalContext, ctx)
+		span.SetError(err)
+		span.End()
+		if err != nil {
+			return err
+		}
+	}
+	for _, entry := range rule.Context {
+		span := policyContext.Span.StartClient("context "+entry.Name, "context.entry", entry.Name)
+		err := loadContextEntry(policyContext, entry, ctx)
+		span.SetError(err)
+		span.End()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadContextEntry loads the data of the context entry from its source
+func loadContextEntry(policyContext PolicyContext, entry kyverno.ContextEntry, ctx context.Interface) error {
+	log := policyContext.Log
+	if entry.ConfigMap != nil {
+		if err := loadConfigMap(log, entry, policyContext.ConfigMapResolver, ctx); err != nil {
+			return err
+		}
+	}
+	if entry.APICall != nil {
+		if policyContext.Client == nil {
+			return fmt.Errorf("failed to load API data for context entry %s: API calls are not supported", entry.Name)
+		}
+		if err := loadAPIData(log, entry, policyContext.Client, ctx); err != nil {
+			return err
+		}
+	}
+	if entry.ImageRegistry != nil {
+		if err := loadImageData(log, entry, policyContext.ImageRegistryClient, ctx); err != nil {
+			return err
+		}
+	}
+	if entry.Service != nil {
+		if err := loadServiceData(log, entry, policyContext.ServiceClient, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadConfigMap adds the ConfigMap at path: <entry name>
+// - data
+// - metadata
+func loadConfigMap(log log.Logger, entry kyverno.ContextEntry, resolver ConfigMapResolver, ctx context.Interface) error {
+	if resolver == nil {
+		return fmt.Errorf("failed to load ConfigMap for context entry %s: ConfigMaps are not supported", entry.Name)
+	}
+	// variables can be used in the name and namespace
+	name, ok := variables.SubstituteVariables(ctx, entry.ConfigMap.Name).(string)
+	if !ok {
+		return fmt.Errorf("failed to substitute variables in ConfigMap name %s", entry.ConfigMap.Name)
+	}
+	namespace, ok := variables.SubstituteVariables(ctx, entry.ConfigMap.Namespace).(string)
+	if !ok {
+		return fmt.Errorf("failed to substitute variables in ConfigMap namespace %s", entry.ConfigMap.Namespace)
+	}
+	cm, err := resolver.Get(namespace, name)
+	if err != nil {
+		return fmt.Errorf("failed to load ConfigMap %s/%s for context entry %s: %v", namespace, name, entry.Name, err)
+	}
+	log.V(4).Infof("loading ConfigMap %s/%s in context entry %s", namespace, name, entry.Name)
+	data := map[string]interface{}{
+		entry.Name: map[string]interface{}{
+			"data":     cm.Data,
+			"metadata": cm.ObjectMeta,
+		},
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return ctx.AddJSON(raw)
+}
+
+// loadAPIData adds the response of the API call at path: <entry name>
+// if a JMESPath is set, the result of the JMESPath on the response is added
+func loadAPIData(log log.Logger, entry kyverno.ContextEntry, client apiCaller, ctx context.Interface) error {
+	// variables can be used in the path and the JMESPath
+	path, ok := variables.SubstituteVariables(ctx, entry.APICall.URLPath).(string)
+	if !ok {
+		return fmt.Errorf("failed to substitute variables in API call path %s", entry.APICall.URLPath)
+	}
+	raw, err := client.RawAbsPath(path)
+	if err != nil {
+		return fmt.Errorf("failed to get %s for context entry %s: %v", path, entry.Name, err)
+	}
+	log.V(4).Infof("loading API data %s in context entry %s", path, entry.Name)
+	var data interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("failed to decode the response of %s for context entry %s: %v", path, entry.Name, err)
+	}
+	if entry.APICall.JMESPath != "" {
+		query, ok := variables.SubstituteVariables(ctx, entry.APICall.JMESPath).(string)
+		if !ok {
+			return fmt.Errorf("failed to substitute variables in JMESPath %s", entry.APICall.JMESPath)
+		}
+		data, err = jmespath.Search(query, data)
+		if err != nil {
+			return fmt.Errorf("failed to apply JMESPath %s on the response of %s for context entry %s: %v", query, path, entry.Name, err)
+		}
+	}
+	result, err := json.Marshal(map[string]interface{}{entry.Name: data})
+	if err != nil {
+		return err
+	}
+	return ctx.AddJSON(result)
+}
+
+// loadImageData adds the data of the image fetched from its registry at path: <entry name>
+// - image, resolvedImage, registry, repository, identifier
+// - manifest
+// - configData
+// if a JMESPath is set, the result of the JMESPath on the image data is added
+func loadImageData(log log.Logger, entry kyverno.ContextEntry, client registry.Interface, ctx context.Interface) error {
+	if client == nil {
+		return fmt.Errorf("failed to load image data for context entry %s: image registries are not supported", entry.Name)
+	}
+	// variables can be used in the reference and the JMESPath
+	image, ok := variables.SubstituteVariables(ctx, entry.ImageRegistry.Reference).(string)
+	if !ok {
+		return fmt.Errorf("failed to substitute variables in image reference %s", entry.ImageRegistry.Reference)
+	}
+	imageData, err := client.FetchImageData(image)
+	if err != nil {
+		return fmt.Errorf("failed to fetch image data of %s for context entry %s: %v", image, entry.Name, err)
+	}
+	log.V(4).Infof("loading image data of %s in context entry %s", image, entry.Name)
+	raw, err := json.Marshal(imageData)
+	if err != nil {
+		return err
+	}
+	var data interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return err
+	}
+	if entry.ImageRegistry.JMESPath != "" {
+		query, ok := variables.SubstituteVariables(ctx, entry.ImageRegistry.JMESPath).(string)
+		if !ok {
+			return fmt.Errorf("failed to substitute variables in JMESPath %s", entry.ImageRegistry.JMESPath)
+		}
+		data, err = jmespath.Search(query, data)
+		if err != nil {
+			return fmt.Errorf("failed to apply JMESPath %s on the image data of %s for context entry %s: %v", query, image, entry.Name, err)
+		}
+	}
+	result, err := json.Marshal(map[string]interface{}{entry.Name: data})
+	if err != nil {
+		return err
+	}
+	return ctx.AddJSON(result)
+}
+
+// loadServiceData adds the JSON response of the external service at path: <entry name>
+// if a JMESPath is set, the result of the JMESPath on the response is added
+func loadServiceData(log log.Logger, entry kyverno.ContextEntry, client externaldata.Interface, ctx context.Interface) error {
+	if client == nil {
+		return fmt.Errorf("failed to load service data for context entry %s: external services are not supported", entry.Name)
+	}
+	service := entry.Service
+	// variables can be used in the URL and the JMESPath
+	url, ok := variables.SubstituteVariables(ctx, service.URL).(string)
+	if !ok {
+		return fmt.Errorf("failed to substitute variables in service URL %s", service.URL)
+	}
+	request := externaldata.Request{URL: url, CABundle: service.CABundle}
+	var err error
+	if service.Timeout != "" {
+		if request.Timeout, err = time.ParseDuration(service.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout %s for context entry %s: %v", service.Timeout, entry.Name, err)
+		}
+	}
+	if service.TTL != "" {
+		if request.TTL, err = time.ParseDuration(service.TTL); err != nil {
+			return fmt.Errorf("invalid ttl %s for context entry %s: %v", service.TTL, entry.Name, err)
+		}
+	}
+	if service.AuthSecret != nil {
+		// the Secret is read by the client from the informer cache of the allowed namespaces
+		request.AuthSecret = &externaldata.SecretKeyRef{Namespace: service.AuthSecret.Namespace, Name: service.AuthSecret.Name, Key: service.AuthSecret.Key}
+	}
+	raw, err := client.Get(request)
+	if err != nil {
+		return fmt.Errorf("failed to call %s for context entry %s: %v", url, entry.Name, err)
+	}
+	log.V(4).Infof("loading service data %s in context entry %s", url, entry.Name)
+	var data interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("failed to decode the response of %s for context entry %s: %v", url, entry.Name, err)
+	}
+	if service.JMESPath != "" {
+		query, ok := variables.SubstituteVariables(ctx, service.JMESPath).(string)
+		if !ok {
+			return fmt.Errorf("failed to substitute variables in JMESPath %s", service.JMESPath)
+		}
+		data, err = jmespath.Search(query, data)
+		if err != nil {
+			return fmt.Errorf("failed to apply JMESPath %s on the response of %s for context entry %s: %v", query, url, entry.Name, err)
+		}
+	}
+	result, err := json.Marshal(map[string]interface{}{entry.Name: data})
+	if err != nil {
+		return err
+	}
+	return ctx.AddJSON(result)
+}
@@ -0,0 +1,207 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/externaldata"
+	"github.com/nirmata/kyverno/pkg/registry"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type fakeAPICaller map[string]string
+
+func (c fakeAPICaller) RawAbsPath(path string) ([]byte, error) {
+	if data, ok := c[path]; ok {
+		return []byte(data), nil
+	}
+	return nil, fmt.Errorf("%s not found", path)
+}
+
+func Test_loadAPIData(t *testing.T) {
+	resourceRaw := []byte(`{
+		"apiVersion": "v1",
+		"kind": "Service",
+		"metadata": {
+		  "name": "test",
+		  "namespace": "ns1"
+		},
+		"spec": {
+		  "type": "LoadBalancer"
+		}
+	  }`)
+	client := fakeAPICaller{
+		"/api/v1/namespaces/ns1/services": `{
+			"kind": "ServiceList",
+			"items": [
+			  {"metadata": {"name": "svc1"}, "spec": {"type": "LoadBalancer"}},
+			  {"metadata": {"name": "svc2"}, "spec": {"type": "ClusterIP"}},
+			  {"metadata": {"name": "svc3"}, "spec": {"type": "LoadBalancer"}}
+			]
+		  }`,
+	}
+
+	ctx := context.NewContext()
+	assert.NilError(t, ctx.AddResource(resourceRaw))
+	entry := kyverno.ContextEntry{
+		Name: "loadBalancers",
+		APICall: &kyverno.APICall{
+			URLPath:  "/api/v1/namespaces/{{request.object.metadata.namespace}}/services",
+			JMESPath: "length(items[?spec.type == 'LoadBalancer'])",
+		},
+	}
+	assert.NilError(t, loadAPIData(log.Discard, entry, client, ctx))
+	result, err := ctx.Query("loadBalancers")
+	assert.NilError(t, err)
+	assert.Equal(t, result, float64(2))
+
+	// without JMESPath the response is added
+	entry.APICall.JMESPath = ""
+	assert.NilError(t, loadAPIData(log.Discard, entry, client, ctx))
+	result, err = ctx.Query("loadBalancers.kind")
+	assert.NilError(t, err)
+	assert.Equal(t, result, "ServiceList")
+
+	// the request fails
+	entry.APICall.URLPath = "/api/v1/namespaces/ns2/services"
+	assert.Assert(t, loadAPIData(log.Discard, entry, client, ctx) != nil)
+}
+
+type fakeRegistryClient map[string]*registry.ImageData
+
+func (c fakeRegistryClient) FetchImageData(image string) (*registry.ImageData, error) {
+	if data, ok := c[image]; ok {
+		return data, nil
+	}
+	return nil, fmt.Errorf("image %s not found", image)
+}
+
+func Test_loadImageData(t *testing.T) {
+	resourceRaw := []byte(`{
+		"apiVersion": "v1",
+		"kind": "Pod",
+		"metadata": {
+		  "name": "test"
+		},
+		"spec": {
+		  "containers": [
+			{
+			  "name": "nginx",
+			  "image": "nginx:1.17"
+			}
+		  ]
+		}
+	  }`)
+	client := fakeRegistryClient{
+		"nginx:1.17": &registry.ImageData{
+			Image:         "nginx:1.17",
+			ResolvedImage: "docker.io/library/nginx:1.17@sha256:abc",
+			Registry:      "docker.io",
+			Repository:    "library/nginx",
+			Identifier:    "1.17",
+			Manifest:      map[string]interface{}{"layers": []interface{}{map[string]interface{}{"digest": "sha256:layer1"}}},
+			ConfigData:    map[string]interface{}{"config": map[string]interface{}{"User": "root"}},
+		},
+	}
+
+	ctx := context.NewContext()
+	assert.NilError(t, ctx.AddResource(resourceRaw))
+	entry := kyverno.ContextEntry{
+		Name: "imageData",
+		ImageRegistry: &kyverno.ImageRegistry{
+			Reference: "{{request.object.spec.containers[0].image}}",
+		},
+	}
+	assert.NilError(t, loadImageData(log.Discard, entry, client, ctx))
+	result, err := ctx.Query("imageData.configData.config.User")
+	assert.NilError(t, err)
+	assert.Equal(t, result, "root")
+	result, err = ctx.Query("imageData.manifest.layers[0].digest")
+	assert.NilError(t, err)
+	assert.Equal(t, result, "sha256:layer1")
+
+	// with JMESPath the result is added
+	entry.ImageRegistry.JMESPath = "configData.config.User"
+	assert.NilError(t, loadImageData(log.Discard, entry, client, ctx))
+	result, err = ctx.Query("imageData")
+	assert.NilError(t, err)
+	assert.Equal(t, result, "root")
+
+	// the image is not found
+	entry.ImageRegistry.Reference = "busybox"
+	assert.Assert(t, loadImageData(log.Discard, entry, client, ctx) != nil)
+}
+
+// fakeServiceClient returns the responses of the URLs if the request refers to the authorization Secret
+type fakeServiceClient struct {
+	authSecret externaldata.SecretKeyRef
+	responses  map[string]string
+}
+
+func (c fakeServiceClient) Get(request externaldata.Request) ([]byte, error) {
+	if request.AuthSecret == nil || *request.AuthSecret != c.authSecret {
+		return nil, fmt.Errorf("401 Unauthorized")
+	}
+	if data, ok := c.responses[request.URL]; ok {
+		return []byte(data), nil
+	}
+	return nil, fmt.Errorf("%s not found", request.URL)
+}
+
+type fakeResourceGetter map[string]*unstructured.Unstructured
+
+func (g fakeResourceGetter) GetResource(kind string, namespace string, name string, subresources ...string) (*unstructured.Unstructured, error) {
+	if r, ok := g[kind+"/"+namespace+"/"+name]; ok {
+		return r, nil
+	}
+	return nil, fmt.Errorf("%s %s/%s not found", kind, namespace, name)
+}
+
+func Test_loadServiceData(t *testing.T) {
+	resourceRaw := []byte(`{
+		"apiVersion": "apps/v1",
+		"kind": "Deployment",
+		"metadata": {
+		  "name": "nginx",
+		  "namespace": "default"
+		}
+	  }`)
+	client := fakeServiceClient{
+		authSecret: externaldata.SecretKeyRef{Namespace: "kyverno", Name: "cmdb", Key: "token"},
+		responses: map[string]string{
+			"https://cmdb.corp.com/apps/nginx": `{"name": "nginx", "owner": "team-a", "tier": 1}`,
+		},
+	}
+
+	ctx := context.NewContext()
+	assert.NilError(t, ctx.AddResource(resourceRaw))
+	entry := kyverno.ContextEntry{
+		Name: "app",
+		Service: &kyverno.ServiceCall{
+			URL:        "https://cmdb.corp.com/apps/{{request.object.metadata.name}}",
+			AuthSecret: &kyverno.SecretKeyReference{Name: "cmdb", Namespace: "kyverno", Key: "token"},
+		},
+	}
+	assert.NilError(t, loadServiceData(log.Discard, entry, client, ctx))
+	result, err := ctx.Query("app.owner")
+	assert.NilError(t, err)
+	assert.Equal(t, result, "team-a")
+
+	// with JMESPath the result is added
+	entry.Service.JMESPath = "tier"
+	assert.NilError(t, loadServiceData(log.Discard, entry, client, ctx))
+	result, err = ctx.Query("app")
+	assert.NilError(t, err)
+	assert.Equal(t, result, float64(1))
+
+	// the service rejects the request without authorization
+	entry.Service.AuthSecret = nil
+	assert.ErrorContains(t, loadServiceData(log.Discard, entry, client, ctx), "401")
+
+	// external services are not configured
+	assert.ErrorContains(t, loadServiceData(log.Discard, entry, nil, ctx), "not supported")
+}
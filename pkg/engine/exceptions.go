@@ -0,0 +1,36 @@
+package engine
+
+import (
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// isExempted returns true if a PolicyException of the context exempts the resource from the rule,
+// the expired exceptions are ignored
+func isExempted(policyContext PolicyContext, rule kyverno.Rule, resource unstructured.Unstructured) bool {
+	log := policyContext.Log
+	if len(policyContext.Exceptions) == 0 {
+		return false
+	}
+	policy := policyContext.Policy.Name
+	if policyContext.Policy.Namespace != "" {
+		policy = policyContext.Policy.Namespace + "/" + policy
+	}
+	now := time.Now()
+	for i := range policyContext.Exceptions {
+		exception := &policyContext.Exceptions[i]
+		if !exception.Contains(policy, rule.Name) || exception.HasExpired(now) {
+			continue
+		}
+		// the exceptions select the resources as the match block of the rules
+		if !MatchesResourceDescription(resource, kyverno.Rule{MatchResources: kyverno.MatchResources{ResourceDescription: exception.Spec.Match}}) {
+			continue
+		}
+		log.V(3).Infof("resource %s/%s/%s is exempted from rule %s of policy %s by PolicyException %s/%s: %s",
+			resource.GetKind(), resource.GetNamespace(), resource.GetName(), rule.Name, policy, exception.Namespace, exception.Name, exception.Spec.Justification)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_Validate_Exceptions(t *testing.T) {
+	rawPolicy := []byte(`{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {"name": "require-labels"},
+		"spec": {
+			"rules": [{
+				"name": "check-app",
+				"match": {"resources": {"kinds": ["Pod"]}},
+				"validate": {"message": "The label app is required.", "pattern": {"metadata": {"labels": {"app": "?*"}}}}
+			}, {
+				"name": "check-team",
+				"match": {"resources": {"kinds": ["Pod"]}},
+				"validate": {"message": "The label team is required.", "pattern": {"metadata": {"labels": {"team": "?*"}}}}
+			}]
+		}
+	}`)
+	var policy kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal(rawPolicy, &policy))
+	resource := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "legacy-web", "namespace": "team-a"},
+	}}
+	exception := func(rules []string, name string, expiresAt time.Time) kyverno.PolicyException {
+		return kyverno.PolicyException{
+			ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "team-a"},
+			Spec: kyverno.PolicyExceptionSpec{
+				Exceptions:    []kyverno.Exception{{PolicyName: "require-labels", RuleNames: rules}},
+				Match:         kyverno.ResourceDescription{Kinds: []string{"Pod"}, Name: name},
+				Justification: "migrated next quarter",
+				ExpiresAt:     &metav1.Time{Time: expiresAt},
+			},
+		}
+	}
+	tomorrow := time.Now().Add(24 * time.Hour)
+	yesterday := time.Now().Add(-24 * time.Hour)
+	testCases := []struct {
+		name       string
+		exceptions []kyverno.PolicyException
+		rules      []string
+	}{
+		{name: "no exception", rules: []string{"check-app", "check-team"}},
+		{name: "rule", exceptions: []kyverno.PolicyException{exception([]string{"check-app"}, "legacy-*", tomorrow)}, rules: []string{"check-team"}},
+		{name: "policy", exceptions: []kyverno.PolicyException{exception(nil, "legacy-*", tomorrow)}},
+		{name: "expired", exceptions: []kyverno.PolicyException{exception(nil, "legacy-*", yesterday)}, rules: []string{"check-app", "check-team"}},
+		{name: "other resource", exceptions: []kyverno.PolicyException{exception(nil, "web", tomorrow)}, rules: []string{"check-app", "check-team"}},
+	}
+	for _, tc := range testCases {
+		raw, err := resource.MarshalJSON()
+		assert.NilError(t, err)
+		ctx := context.NewContext()
+		assert.NilError(t, ctx.AddResource(raw))
+		er := Validate(PolicyContext{Policy: policy, NewResource: resource, Context: ctx, Exceptions: tc.exceptions})
+		var rules []string
+		for _, rule := range er.PolicyResponse.Rules {
+			assert.Equal(t, rule.Success, false, tc.name)
+			rules = append(rules, rule.Name)
+		}
+		assert.DeepEqual(t, rules, tc.rules)
+	}
+}
@@ -3,9 +3,9 @@ package engine
 import (
 	"fmt"
 
-	"github.com/golang/glog"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
 	"github.com/nirmata/kyverno/pkg/engine/rbac"
 	"github.com/nirmata/kyverno/pkg/engine/response"
 	"github.com/nirmata/kyverno/pkg/engine/utils"
@@ -18,14 +18,10 @@ import (
 //    - the caller has to check the ruleResponse to determine whether the path exist
 // 2. returns the list of rules that are applicable on this policy and resource, if 1 succeed
 func Generate(policyContext PolicyContext) (resp response.EngineResponse) {
-	policy := policyContext.Policy
-	resource := policyContext.NewResource
-	admissionInfo := policyContext.AdmissionInfo
-	ctx := policyContext.Context
-	return filterRules(policy, resource, admissionInfo, ctx)
+	return filterRules(policyContext)
 }
 
-func filterRule(rule kyverno.Rule, resource unstructured.Unstructured, admissionInfo kyverno.RequestInfo, ctx context.EvalInterface) *response.RuleResponse {
+func filterRule(log log.Logger, rule kyverno.Rule, resource unstructured.Unstructured, admissionInfo kyverno.RequestInfo, ctx context.EvalInterface) *response.RuleResponse {
 	if !rule.HasGenerate() {
 		return nil
 	}
@@ -37,8 +33,8 @@ func filterRule(rule kyverno.Rule, resource unstructured.Unstructured, admission
 	}
 
 	// evaluate pre-conditions
-	if !variables.EvaluateConditions(ctx, rule.Conditions) {
-		glog.V(4).Infof("resource %s/%s does not satisfy the conditions for the rule ", resource.GetNamespace(), resource.GetName())
+	if !variables.EvaluateConditions(log, ctx, rule.Conditions) {
+		log.V(4).Infof("resource %s/%s does not satisfy the conditions for the rule ", resource.GetNamespace(), resource.GetName())
 		return nil
 	}
 	// build rule Response
@@ -48,10 +44,16 @@ func filterRule(rule kyverno.Rule, resource unstructured.Unstructured, admission
 	}
 }
 
-func filterRules(policy kyverno.ClusterPolicy, resource unstructured.Unstructured, admissionInfo kyverno.RequestInfo, ctx context.EvalInterface) response.EngineResponse {
+func filterRules(policyContext PolicyContext) response.EngineResponse {
+	log := policyContext.Log
+	policy := policyContext.Policy
+	resource := policyContext.NewResource
+	admissionInfo := policyContext.AdmissionInfo
+	ctx := policyContext.Context
 	resp := response.EngineResponse{
 		PolicyResponse: response.PolicyResponse{
-			Policy: policy.Name,
+			Policy:          policy.Name,
+			PolicyNamespace: policy.Namespace,
 			Resource: response.ResourceSpec{
 				Kind:      resource.GetKind(),
 				Name:      resource.GetName(),
@@ -61,14 +63,21 @@ func filterRules(policy kyverno.ClusterPolicy, resource unstructured.Unstructure
 	}
 
 	for _, rule := range policy.Spec.Rules {
-		if paths := validateGeneralRuleInfoVariables(ctx, rule); len(paths) != 0 {
-			glog.Infof("referenced path not present in generate rule %s, resource %s/%s/%s, path: %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), paths)
+		if rule.HasGenerate() {
+			if err := loadRuleContext(policyContext, rule, resource); err != nil {
+				log.Infof("failed to load context in generate rule %s, resource %s/%s/%s: %v", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), err)
+				continue
+			}
+		}
+
+		if paths := validateGeneralRuleInfoVariables(log, ctx, rule); len(paths) != 0 {
+			log.Infof("referenced path not present in generate rule %s, resource %s/%s/%s, path: %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), paths)
 			resp.PolicyResponse.Rules = append(resp.PolicyResponse.Rules,
 				newPathNotPresentRuleResponse(rule.Name, utils.Mutation.String(), fmt.Sprintf("path not present: %s", paths)))
 			continue
 		}
 
-		if ruleResp := filterRule(rule, resource, admissionInfo, ctx); ruleResp != nil {
+		if ruleResp := filterRule(log, rule, resource, admissionInfo, ctx); ruleResp != nil {
 			resp.PolicyResponse.Rules = append(resp.PolicyResponse.Rules, *ruleResp)
 		}
 	}
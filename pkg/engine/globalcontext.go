@@ -0,0 +1,222 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
+)
+
+// globalContextKey is the root of the global context variables
+const globalContextKey = "globalContext"
+
+// defaultGlobalContextTTL is the duration the data is cached if the entry does not set a TTL
+const defaultGlobalContextTTL = time.Minute
+
+// GlobalContextProvider returns the context entries shared by all policies
+type GlobalContextProvider interface {
+	GlobalContextEntries() []kyverno.GlobalContextEntry
+}
+
+// GlobalContext loads the global context entries, the data of each entry is fetched
+// once and cached for the TTL of the entry instead of on every admission request
+type GlobalContext struct {
+	provider          GlobalContextProvider
+	configMapResolver ConfigMapResolver
+	client            apiCaller
+	// mu guards the cache, the fetches and the policies, the data is fetched without the lock
+	mu    sync.RWMutex
+	cache map[string]globalContextData
+	// fetches are the fetches in progress per entry, the concurrent requests wait for the same fetch
+	fetches map[string]*globalContextFetch
+	// policies are the rules using the global context per policy
+	policies map[string]policyGlobalContext
+	// now is replaced in tests
+	now func() time.Time
+}
+
+type globalContextData struct {
+	entry   kyverno.GlobalContextEntry
+	data    interface{}
+	expires time.Time
+}
+
+type globalContextFetch struct {
+	done chan struct{}
+	data interface{}
+	err  error
+}
+
+// policyGlobalContext are the rules of a version of a policy that use the global context
+type policyGlobalContext struct {
+	resourceVersion string
+	rules           map[string]bool
+}
+
+// NewGlobalContext returns a GlobalContext, the ConfigMaps are read with the resolver
+// and the API calls are made with the client
+func NewGlobalContext(provider GlobalContextProvider, configMapResolver ConfigMapResolver, client Client) *GlobalContext {
+	gc := &GlobalContext{
+		provider:          provider,
+		configMapResolver: configMapResolver,
+		cache:             map[string]globalContextData{},
+		now:               time.Now,
+	}
+	if client != nil {
+		gc.client = client
+	}
+	return gc
+}
+
+// Load adds the data of the global context entries at path: globalContext.<name>
+// an entry that fails to load is skipped, the cached data is used until it loads again
+func (gc *GlobalContext) Load(log log.Logger, ctx context.Interface) error {
+	entries := gc.provider.GlobalContextEntries()
+	if len(entries) == 0 {
+		return nil
+	}
+	data := map[string]interface{}{}
+	for _, entry := range entries {
+		d, err := gc.get(log, entry)
+		if err != nil {
+			log.Errorf("failed to load global context entry %s: %v", entry.Name, err)
+			continue
+		}
+		data[entry.Name] = d
+	}
+	raw, err := json.Marshal(map[string]interface{}{globalContextKey: data})
+	if err != nil {
+		return err
+	}
+	return ctx.AddJSON(raw)
+}
+
+// get returns the cached data of the entry, the data is fetched if it expired or the entry changed,
+// once for the concurrent requests
+func (gc *GlobalContext) get(log log.Logger, entry kyverno.GlobalContextEntry) (interface{}, error) {
+	gc.mu.RLock()
+	cached, ok := gc.cache[entry.Name]
+	gc.mu.RUnlock()
+	ok = ok && reflect.DeepEqual(cached.entry, entry)
+	if ok && gc.now().Before(cached.expires) {
+		return cached.data, nil
+	}
+	ttl := defaultGlobalContextTTL
+	if entry.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(entry.TTL); err != nil {
+			return nil, fmt.Errorf("invalid ttl %s: %v", entry.TTL, err)
+		}
+	}
+	data, err := gc.fetchOnce(log, entry, ttl)
+	if err != nil {
+		if ok {
+			log.V(4).Infof("using the cached data of global context entry %s: %v", entry.Name, err)
+			return cached.data, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// fetchOnce fetches the entry and caches its data, the requests that need the entry while it is fetched
+// wait for the fetch in progress
+func (gc *GlobalContext) fetchOnce(log log.Logger, entry kyverno.GlobalContextEntry, ttl time.Duration) (interface{}, error) {
+	gc.mu.Lock()
+	if f, ok := gc.fetches[entry.Name]; ok {
+		gc.mu.Unlock()
+		<-f.done
+		return f.data, f.err
+	}
+	if gc.fetches == nil {
+		gc.fetches = map[string]*globalContextFetch{}
+	}
+	f := &globalContextFetch{done: make(chan struct{})}
+	gc.fetches[entry.Name] = f
+	gc.mu.Unlock()
+
+	f.data, f.err = gc.fetch(log, entry.ContextEntry)
+
+	gc.mu.Lock()
+	if f.err == nil {
+		log.V(4).Infof("caching global context entry %s for %v", entry.Name, ttl)
+		gc.cache[entry.Name] = globalContextData{entry: entry, data: f.data, expires: gc.now().Add(ttl)}
+	}
+	delete(gc.fetches, entry.Name)
+	gc.mu.Unlock()
+	close(f.done)
+	return f.data, f.err
+}
+
+// fetch loads the entry in an empty context, the request variables are not available
+func (gc *GlobalContext) fetch(log log.Logger, entry kyverno.ContextEntry) (interface{}, error) {
+	ctx := context.NewContext()
+	switch {
+	case entry.ConfigMap != nil:
+		if err := loadConfigMap(log, entry, gc.configMapResolver, ctx); err != nil {
+			return nil, err
+		}
+	case entry.APICall != nil:
+		if gc.client == nil {
+			return nil, fmt.Errorf("API calls are not supported")
+		}
+		if err := loadAPIData(log, entry, gc.client, ctx); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("a configMap or an apiCall is required")
+	}
+	return ctx.Query(entry.Name)
+}
+
+// loadGlobalContext loads the global context if it is not loaded in the context yet
+func loadGlobalContext(log log.Logger, gc *GlobalContext, ctx context.Interface) error {
+	if loaded, err := ctx.Query(globalContextKey); err == nil && loaded != nil {
+		return nil
+	}
+	return gc.Load(log, ctx)
+}
+
+// usedBy checks if the rule of the policy has a variable in the global context, the rules are checked
+// once per version of the policy
+func (gc *GlobalContext) usedBy(policy kyverno.ClusterPolicy, rule kyverno.Rule) bool {
+	if policy.ResourceVersion == "" {
+		return usesGlobalContext(rule)
+	}
+	key := policy.Namespace + "/" + policy.Name
+	gc.mu.RLock()
+	p, ok := gc.policies[key]
+	gc.mu.RUnlock()
+	if !ok || p.resourceVersion != policy.ResourceVersion {
+		p = policyGlobalContext{resourceVersion: policy.ResourceVersion, rules: map[string]bool{}}
+		for _, r := range policy.Spec.Rules {
+			p.rules[r.Name] = usesGlobalContext(r)
+		}
+		gc.mu.Lock()
+		if gc.policies == nil {
+			gc.policies = map[string]policyGlobalContext{}
+		}
+		gc.policies[key] = p
+		gc.mu.Unlock()
+	}
+	uses, ok := p.rules[rule.Name]
+	if !ok {
+		return usesGlobalContext(rule)
+	}
+	return uses
+}
+
+// usesGlobalContext checks if the rule has a variable in the global context
+func usesGlobalContext(rule kyverno.Rule) bool {
+	raw, err := json.Marshal(rule)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(raw), globalContextKey+".")
+}
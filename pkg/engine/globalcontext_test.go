@@ -0,0 +1,246 @@
+package engine
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/engine/utils"
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeGlobalContextProvider []kyverno.GlobalContextEntry
+
+func (p fakeGlobalContextProvider) GlobalContextEntries() []kyverno.GlobalContextEntry {
+	return p
+}
+
+// countingAPICaller counts the API calls
+type countingAPICaller struct {
+	fakeAPICaller
+	calls int
+}
+
+func (c *countingAPICaller) RawAbsPath(path string) ([]byte, error) {
+	c.calls++
+	return c.fakeAPICaller.RawAbsPath(path)
+}
+
+func Test_GlobalContext_Cache(t *testing.T) {
+	client := &countingAPICaller{fakeAPICaller: fakeAPICaller{
+		"/api/v1/namespaces": `{"items": [{"metadata": {"name": "default"}}, {"metadata": {"name": "prod"}}]}`,
+	}}
+	entry := kyverno.GlobalContextEntry{
+		ContextEntry: kyverno.ContextEntry{
+			Name:    "namespaces",
+			APICall: &kyverno.APICall{URLPath: "/api/v1/namespaces", JMESPath: "items[].metadata.name"},
+		},
+		TTL: "5m",
+	}
+	provider := fakeGlobalContextProvider{entry}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	gc := &GlobalContext{
+		provider: provider,
+		client:   client,
+		cache:    map[string]globalContextData{},
+		now:      func() time.Time { return now },
+	}
+
+	load := func() interface{} {
+		ctx := context.NewContext()
+		assert.NilError(t, gc.Load(log.Discard, ctx))
+		result, err := ctx.Query("globalContext.namespaces")
+		assert.NilError(t, err)
+		return result
+	}
+
+	expected := []interface{}{"default", "prod"}
+	assert.DeepEqual(t, load(), expected)
+	assert.DeepEqual(t, load(), expected)
+	assert.Equal(t, client.calls, 1)
+
+	// the data is fetched again once the TTL expired
+	now = now.Add(6 * time.Minute)
+	assert.DeepEqual(t, load(), expected)
+	assert.Equal(t, client.calls, 2)
+
+	// the cached data is used if the data cannot be fetched
+	delete(client.fakeAPICaller, "/api/v1/namespaces")
+	now = now.Add(6 * time.Minute)
+	assert.DeepEqual(t, load(), expected)
+	assert.Equal(t, client.calls, 3)
+
+	// the data is fetched if the entry changed
+	client.fakeAPICaller["/api/v1/namespaces"] = `{"items": [{"metadata": {"name": "dev"}}]}`
+	provider[0].TTL = "10m"
+	assert.DeepEqual(t, load(), []interface{}{"dev"})
+	assert.Equal(t, client.calls, 4)
+}
+
+// blockingAPICaller counts the API calls, which return once released
+type blockingAPICaller struct {
+	fakeAPICaller
+	release chan struct{}
+	calls   int32
+}
+
+func (c *blockingAPICaller) RawAbsPath(path string) ([]byte, error) {
+	atomic.AddInt32(&c.calls, 1)
+	<-c.release
+	return c.fakeAPICaller.RawAbsPath(path)
+}
+
+func Test_GlobalContext_ConcurrentLoad(t *testing.T) {
+	client := &blockingAPICaller{
+		fakeAPICaller: fakeAPICaller{"/api/v1/namespaces": `{"items": [{"metadata": {"name": "default"}}]}`},
+		release:       make(chan struct{}),
+	}
+	gc := NewGlobalContext(fakeGlobalContextProvider{{
+		ContextEntry: kyverno.ContextEntry{
+			Name:    "namespaces",
+			APICall: &kyverno.APICall{URLPath: "/api/v1/namespaces", JMESPath: "items[].metadata.name"},
+		},
+	}}, nil, nil)
+	gc.client = client
+
+	// the concurrent requests wait for the same fetch
+	var wg sync.WaitGroup
+	results := make([]interface{}, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := context.NewContext()
+			assert.NilError(t, gc.Load(log.Discard, ctx))
+			results[i], _ = ctx.Query("globalContext.namespaces")
+		}(i)
+	}
+	// the lock is not held during the fetch
+	for atomic.LoadInt32(&client.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	gc.mu.Lock()
+	gc.mu.Unlock()
+	close(client.release)
+	wg.Wait()
+	assert.Equal(t, atomic.LoadInt32(&client.calls), int32(1))
+	for _, result := range results {
+		assert.DeepEqual(t, result, []interface{}{"default"})
+	}
+}
+
+func Test_GlobalContext_UsedBy(t *testing.T) {
+	gc := NewGlobalContext(fakeGlobalContextProvider{}, nil, nil)
+	policy := kyverno.ClusterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "check", ResourceVersion: "1"},
+		Spec: kyverno.Spec{Rules: []kyverno.Rule{
+			{Name: "global", Validation: kyverno.Validation{Message: "{{globalContext.namespaces}}"}},
+			{Name: "local", Validation: kyverno.Validation{Message: "{{request.object.metadata.name}}"}},
+		}},
+	}
+	assert.Equal(t, gc.usedBy(policy, policy.Spec.Rules[0]), true)
+	assert.Equal(t, gc.usedBy(policy, policy.Spec.Rules[1]), false)
+	assert.Equal(t, len(gc.policies), 1)
+
+	// the rules are checked again when the policy changes
+	policy.ResourceVersion = "2"
+	policy.Spec.Rules[1].Validation.Message = "{{globalContext.namespaces}}"
+	assert.Equal(t, gc.usedBy(policy, policy.Spec.Rules[1]), true)
+	assert.Equal(t, gc.policies["/check"].resourceVersion, "2")
+}
+
+func Test_GlobalContext_Validate(t *testing.T) {
+	resourceRaw := []byte(`{
+		"apiVersion": "v1",
+		"kind": "Pod",
+		"metadata": {
+		  "name": "test"
+		},
+		"spec": {
+		  "containers": [
+			{
+			  "name": "nginx",
+			  "image": "registry.corp.com/nginx"
+			}
+		  ]
+		}
+	  }`)
+
+	policyraw := []byte(`{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "allowed-registries"
+		},
+		"spec": {
+		  "rules": [
+			{
+			  "name": "check-registry",
+			  "match": {
+				"resources": {
+				  "kinds": [
+					"Pod"
+				  ]
+				}
+			  },
+			  "validate": {
+				"message": "images must be pulled from {{globalContext.registries.data.registry}}",
+				"pattern": {
+				  "spec": {
+					"containers": [
+					  {
+						"image": "{{globalContext.registries.data.registry}}/*"
+					  }
+					]
+				  }
+				}
+			  }
+			}
+		  ]
+		}
+	  }`)
+
+	resourceUnstructured, err := utils.ConvertToUnstructured(resourceRaw)
+	assert.NilError(t, err)
+
+	resolver := fakeConfigMapResolver{
+		"kyverno/registries": &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "registries", Namespace: "kyverno"},
+			Data:       map[string]string{"registry": "registry.corp.com"},
+		},
+	}
+	provider := fakeGlobalContextProvider{
+		{ContextEntry: kyverno.ContextEntry{Name: "registries", ConfigMap: &kyverno.ConfigMapReference{Name: "registries", Namespace: "kyverno"}}},
+	}
+	gc := NewGlobalContext(provider, resolver, nil)
+
+	validate := func() response.EngineResponse {
+		var policy kyverno.ClusterPolicy
+		assert.NilError(t, json.Unmarshal(policyraw, &policy))
+		ctx := context.NewContext()
+		assert.NilError(t, ctx.AddResource(resourceRaw))
+		return Validate(PolicyContext{Policy: policy, Context: ctx, NewResource: *resourceUnstructured, GlobalContext: gc})
+	}
+
+	er := validate()
+	assert.Assert(t, er.PolicyResponse.Rules[0].Success)
+
+	// the ConfigMap is cached
+	resolver["kyverno/registries"].Data["registry"] = "docker.io"
+	er = validate()
+	assert.Assert(t, er.PolicyResponse.Rules[0].Success)
+
+	gc.cache = map[string]globalContextData{}
+	er = validate()
+	assert.Assert(t, !er.PolicyResponse.Rules[0].Success)
+	assert.Assert(t, strings.Contains(er.PolicyResponse.Rules[0].Message, "images must be pulled from docker.io"))
+}
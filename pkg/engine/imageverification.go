@@ -0,0 +1,400 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio/pkg/wildcard"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/cosign"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/engine/rbac"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/engine/utils"
+	"github.com/nirmata/kyverno/pkg/engine/variables"
+	"github.com/nirmata/kyverno/pkg/notary"
+	"github.com/nirmata/kyverno/pkg/registry"
+	"github.com/nirmata/kyverno/pkg/tracing"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// verifiedImagesAnnotation records the images verified by the entries with mutateDigest,
+// the value is a JSON object of the image references and their verified digests
+const verifiedImagesAnnotation = "kyverno.io/verify-images"
+
+// VerifyImages verifies the signatures of the images of the resource with the verifyImages rules
+// a rule fails if one of the images that match an image reference pattern is not signed as the entry requires
+// the patches of a successful rule replace the tags of the images verified with mutateDigest by their digests
+func VerifyImages(policyContext PolicyContext) (resp response.EngineResponse) {
+	log := policyContext.Log
+	startTime := time.Now()
+	policy := policyContext.Policy
+	resource := policyContext.NewResource
+	resp.PatchedResource = resource
+	startResultResponse(&resp, policy, resource)
+	log.V(4).Infof("started verifying the images of policy %q (%v)", policy.Name, startTime)
+	policySpan := policyContext.Span.Start("verifyImages "+policy.Name, "policy", policy.Name)
+	defer policySpan.End()
+	// the span of a rule ends when the next rule starts
+	var ruleSpan *tracing.Span
+	defer func() { ruleSpan.End() }()
+	defer func() {
+		resp.PolicyResponse.ProcessingTime = time.Since(startTime)
+		log.V(4).Infof("finished verifying the images of policy %q (%v)", policy.Name, resp.PolicyResponse.ProcessingTime)
+	}()
+
+	images := context.ExtractImageInfo(resource.Object)
+	if len(images) == 0 {
+		return resp
+	}
+	// the image pull secrets are loaded once, for the first rule that verifies images
+	var keychain registry.Keychain
+	keychainLoaded := false
+	for _, rule := range policy.Spec.Rules {
+		if !rule.HasVerifyImages() {
+			continue
+		}
+		if !rbac.MatchAdmissionInfo(rule, policyContext.AdmissionInfo) {
+			continue
+		}
+		if !MatchesResourceDescription(resource, rule) {
+			log.V(4).Infof("resource %s/%s does not satisfy the resource description for the rule ", resource.GetNamespace(), resource.GetName())
+			continue
+		}
+		if isExempted(policyContext, rule, resource) {
+			continue
+		}
+		if !variables.EvaluateConditions(log, policyContext.Context, rule.Conditions) {
+			log.V(4).Infof("resource %s/%s does not satisfy the conditions for the rule ", resource.GetNamespace(), resource.GetName())
+			continue
+		}
+		ruleSpan.End()
+		ruleSpan = policySpan.Start("rule "+rule.Name, "policy", policy.Name, "rule", rule.Name)
+		policyContext.Span = ruleSpan
+		if !keychainLoaded {
+			keychain = imagePullKeychain(policyContext, resource)
+			keychainLoaded = true
+		}
+		ruleResponse, digests, ok := verifyRuleImages(policyContext, rule, images, resource, keychain)
+		if !ok {
+			continue
+		}
+		if ruleResponse.Success && len(digests) != 0 {
+			patches, patchedResource, err := mutateDigests(log, resp.PatchedResource, images, digests)
+			if err != nil {
+				log.Errorf("failed to mutate the digests of the images of resource %s/%s/%s: %v", resource.GetKind(), resource.GetNamespace(), resource.GetName(), err)
+			} else {
+				ruleResponse.Patches = patches
+				resp.PatchedResource = patchedResource
+			}
+		}
+		resp.PolicyResponse.RulesAppliedCount++
+		resp.PolicyResponse.Rules = append(resp.PolicyResponse.Rules, ruleResponse)
+	}
+	return resp
+}
+
+// verifyRuleImages verifies the images that match the image reference patterns of the rule
+// and returns the digests of the images verified by the entries with mutateDigest,
+// the rule is not applied if no image matches
+func verifyRuleImages(policyContext PolicyContext, rule kyverno.Rule, images map[string]map[string]context.ImageInfo, resource unstructured.Unstructured, keychain registry.Keychain) (response.RuleResponse, map[string]string, bool) {
+	log := policyContext.Log
+	startTime := time.Now()
+	resp := response.RuleResponse{
+		Name: rule.Name,
+		Type: utils.ImageVerify.String(),
+	}
+	var verified, failed []string
+	digests := map[string]string{}
+	for _, image := range sortedImages(images) {
+		for _, verification := range rule.VerifyImages {
+			if !wildcard.Match(verification.Image, image) {
+				continue
+			}
+			digest, err := verifyImage(policyContext, image, verification, keychain)
+			if err != nil {
+				log.V(4).Infof("failed to verify image %s of resource %s/%s/%s: %v", image, resource.GetKind(), resource.GetNamespace(), resource.GetName(), err)
+				failed = append(failed, fmt.Sprintf("%s: %v", image, err))
+				continue
+			}
+			log.V(4).Infof("verified image %s (%s) of resource %s/%s/%s", image, digest, resource.GetKind(), resource.GetNamespace(), resource.GetName())
+			if len(verification.Attestations) != 0 {
+				if err := verifyAttestations(log, policyContext.ImageVerifier, image, verification, keychain); err != nil {
+					log.V(4).Infof("failed to verify the attestations of image %s of resource %s/%s/%s: %v", image, resource.GetKind(), resource.GetNamespace(), resource.GetName(), err)
+					failed = append(failed, fmt.Sprintf("%s: %v", image, err))
+					continue
+				}
+			}
+			verified = append(verified, image)
+			if verification.MutateDigest {
+				digests[image] = digest
+			}
+		}
+	}
+	resp.RuleStats.ProcessingTime = time.Since(startTime)
+	if len(verified) == 0 && len(failed) == 0 {
+		return resp, nil, false
+	}
+	if len(failed) != 0 {
+		resp.Success = false
+		resp.Message = fmt.Sprintf("image verification failed: %s", strings.Join(failed, "; "))
+		return resp, nil, true
+	}
+	resp.Success = true
+	resp.Message = fmt.Sprintf("image verification rule '%s' succeeded for %s", rule.Name, strings.Join(verified, ", "))
+	return resp, digests, true
+}
+
+type jsonPatch struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// mutateDigests returns the patches that replace the tags of the verified images by their digests and add the images
+// to the verified images annotation, and the resource with the patches applied
+func mutateDigests(log log.Logger, resource unstructured.Unstructured, images map[string]map[string]context.ImageInfo, digests map[string]string) ([][]byte, unstructured.Unstructured, error) {
+	var ops []jsonPatch
+	for _, containerType := range []string{"containers", "initContainers"} {
+		names := make([]string, 0, len(images[containerType]))
+		for name := range images[containerType] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			info := images[containerType][name]
+			digest, ok := digests[info.Reference]
+			if !ok || info.Digest != "" {
+				continue
+			}
+			pinned := info.ImageReference
+			pinned.Digest = digest
+			ops = append(ops, jsonPatch{Op: "replace", Path: info.JSONPointer, Value: pinned.String()})
+		}
+	}
+	annotations := resource.GetAnnotations()
+	recorded := map[string]string{}
+	if raw, ok := annotations[verifiedImagesAnnotation]; ok {
+		if err := json.Unmarshal([]byte(raw), &recorded); err != nil {
+			log.V(4).Infof("replacing the invalid annotation %s: %v", verifiedImagesAnnotation, err)
+			recorded = map[string]string{}
+		}
+	}
+	for image, digest := range digests {
+		recorded[image] = digest
+	}
+	value, err := json.Marshal(recorded)
+	if err != nil {
+		return nil, resource, err
+	}
+	if annotations == nil {
+		ops = append(ops, jsonPatch{Op: "add", Path: "/metadata/annotations", Value: map[string]string{verifiedImagesAnnotation: string(value)}})
+	} else {
+		ops = append(ops, jsonPatch{Op: "add", Path: "/metadata/annotations/" + strings.Replace(verifiedImagesAnnotation, "/", "~1", -1), Value: string(value)})
+	}
+	var patches [][]byte
+	for _, op := range ops {
+		patch, err := json.Marshal(op)
+		if err != nil {
+			return nil, resource, err
+		}
+		patches = append(patches, patch)
+	}
+	raw, err := resource.MarshalJSON()
+	if err != nil {
+		return nil, resource, err
+	}
+	patchedRaw, err := utils.ApplyPatches(raw, patches)
+	if err != nil {
+		return nil, resource, err
+	}
+	patched, err := utils.ConvertToUnstructured(patchedRaw)
+	if err != nil {
+		return nil, resource, err
+	}
+	return patches, *patched, nil
+}
+
+// verifyImage verifies the signatures of the image and traces the registry calls
+func verifyImage(policyContext PolicyContext, image string, verification kyverno.ImageVerification, keychain registry.Keychain) (string, error) {
+	span := policyContext.Span.StartClient("verify "+image, "image", image)
+	defer span.End()
+	digest, err := verifyImageSignatures(policyContext, image, verification, keychain)
+	span.SetError(err)
+	return digest, err
+}
+
+// verifyImageSignatures verifies the Notary v2 signatures of the image if the entry has a trust policy, else the cosign signatures
+func verifyImageSignatures(policyContext PolicyContext, image string, verification kyverno.ImageVerification, keychain registry.Keychain) (string, error) {
+	if verification.Notary != nil {
+		if policyContext.NotaryVerifier == nil {
+			return "", fmt.Errorf("the verification of Notary v2 signatures is not supported")
+		}
+		return policyContext.NotaryVerifier.Verify(image, notary.Options{
+			TrustStore:        verification.Notary.TrustStore,
+			TrustedIdentities: verification.Notary.TrustedIdentities,
+			Level:             verification.Notary.SignatureVerification,
+			Keychain:          keychain,
+		})
+	}
+	if policyContext.ImageVerifier == nil {
+		return "", fmt.Errorf("image verification is not supported")
+	}
+	return policyContext.ImageVerifier.Verify(image, verificationOptions(verification, keychain))
+}
+
+// verifyAttestations checks the image has, for each attestation of the entry, an attested statement
+// of the predicate type whose predicate satisfies the conditions
+func verifyAttestations(log log.Logger, verifier cosign.Interface, image string, verification kyverno.ImageVerification, keychain registry.Keychain) error {
+	statements, err := verifier.FetchAttestations(image, verificationOptions(verification, keychain))
+	if err != nil {
+		return err
+	}
+	for _, attestation := range verification.Attestations {
+		satisfied := false
+		for _, statement := range statements {
+			if statement.PredicateType != attestation.PredicateType {
+				continue
+			}
+			ok, err := evaluatePredicate(log, statement.Predicate, attestation.Conditions)
+			if err != nil {
+				return err
+			}
+			if ok {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			return fmt.Errorf("no attestation of type %s satisfies the conditions", attestation.PredicateType)
+		}
+	}
+	return nil
+}
+
+// evaluatePredicate evaluates the conditions with the predicate as context,
+// the variables of the conditions are relative to the predicate
+func evaluatePredicate(log log.Logger, predicate map[string]interface{}, conditions []kyverno.Condition) (bool, error) {
+	raw, err := json.Marshal(predicate)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode the predicate: %v", err)
+	}
+	ctx := context.NewContext()
+	if err := ctx.AddJSON(raw); err != nil {
+		return false, fmt.Errorf("failed to load the predicate: %v", err)
+	}
+	return variables.EvaluateConditions(log, ctx, conditions), nil
+}
+
+// verificationOptions returns the options of the image verification, the signatures are keyless if there is no key
+func verificationOptions(verification kyverno.ImageVerification, keychain registry.Keychain) cosign.Options {
+	opts := cosign.Options{
+		Key:      verification.Key,
+		Roots:    verification.Roots,
+		Subject:  verification.Subject,
+		Issuer:   verification.Issuer,
+		Keychain: keychain,
+	}
+	if verification.Rekor != nil {
+		opts.RekorURL = verification.Rekor.URL
+		opts.RekorPubKey = verification.Rekor.PubKey
+	}
+	return opts
+}
+
+// imagePullKeychain returns the credentials of the image pull secrets of the pod spec and of its service account,
+// the secrets that cannot be read are skipped
+func imagePullKeychain(policyContext PolicyContext, resource unstructured.Unstructured) registry.Keychain {
+	log := policyContext.Log
+	if policyContext.Client == nil {
+		return nil
+	}
+	return loadImagePullSecrets(log, policyContext.Client, resource)
+}
+
+func loadImagePullSecrets(log log.Logger, client resourceGetter, resource unstructured.Unstructured) registry.Keychain {
+	namespace := resource.GetNamespace()
+	if namespace == "" {
+		return nil
+	}
+	podSpec := findPodSpec(resource.Object)
+	if podSpec == nil {
+		return nil
+	}
+	names := secretNames(podSpec["imagePullSecrets"])
+	serviceAccount, _ := podSpec["serviceAccountName"].(string)
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+	if sa, err := client.GetResource("ServiceAccount", namespace, serviceAccount); err != nil {
+		log.V(4).Infof("failed to get ServiceAccount %s/%s: %v", namespace, serviceAccount, err)
+	} else {
+		names = append(names, secretNames(sa.Object["imagePullSecrets"])...)
+	}
+	var secrets []v1.Secret
+	for _, name := range names {
+		obj, err := client.GetResource("Secret", namespace, name)
+		if err != nil {
+			log.V(4).Infof("failed to get image pull secret %s/%s: %v", namespace, name, err)
+			continue
+		}
+		var secret v1.Secret
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), &secret); err != nil {
+			log.V(4).Infof("failed to convert image pull secret %s/%s: %v", namespace, name, err)
+			continue
+		}
+		secrets = append(secrets, secret)
+	}
+	if len(secrets) == 0 {
+		return nil
+	}
+	return registry.NewSecretKeychain(log, secrets)
+}
+
+// findPodSpec returns the pod spec of a Pod, of the template of a workload or of the job template of a CronJob
+func findPodSpec(resource map[string]interface{}) map[string]interface{} {
+	for _, path := range [][]string{{"spec", "jobTemplate", "spec", "template", "spec"}, {"spec", "template", "spec"}, {"spec"}} {
+		if podSpec, ok, _ := unstructured.NestedMap(resource, path...); ok {
+			if _, ok := podSpec["containers"]; ok {
+				return podSpec
+			}
+		}
+	}
+	return nil
+}
+
+// secretNames returns the names of the local object references
+func secretNames(refs interface{}) []string {
+	list, _ := refs.([]interface{})
+	var names []string
+	for _, ref := range list {
+		if m, ok := ref.(map[string]interface{}); ok {
+			if name, ok := m["name"].(string); ok && name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// sortedImages returns the distinct fully qualified image references in order
+func sortedImages(images map[string]map[string]context.ImageInfo) []string {
+	set := map[string]bool{}
+	for _, containers := range images {
+		for _, info := range containers {
+			set[info.Reference] = true
+		}
+	}
+	refs := make([]string, 0, len(set))
+	for ref := range set {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
@@ -0,0 +1,377 @@
+package engine
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/cosign"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/engine/utils"
+	"github.com/nirmata/kyverno/pkg/notary"
+	"github.com/nirmata/kyverno/pkg/registry"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fakeImageVerifier verifies the images in the map, signed with the key
+type fakeImageVerifier map[string]string
+
+func (v fakeImageVerifier) Verify(image string, opts cosign.Options) (string, error) {
+	if signedKey, ok := v[image]; ok && signedKey == opts.Key {
+		return "sha256:" + image, nil
+	}
+	return "", fmt.Errorf("no valid signature")
+}
+
+func (v fakeImageVerifier) FetchAttestations(image string, opts cosign.Options) ([]cosign.Statement, error) {
+	return nil, fmt.Errorf("no attestations found")
+}
+
+// fakeNotaryVerifier verifies the images in the map, signed by the trust store
+type fakeNotaryVerifier map[string]string
+
+func (v fakeNotaryVerifier) Verify(image string, opts notary.Options) (string, error) {
+	if trustStore, ok := v[image]; ok && trustStore == opts.TrustStore {
+		return "sha256:" + image, nil
+	}
+	return "", fmt.Errorf("no valid signature")
+}
+
+// fakeAttestationVerifier verifies the images in the map and returns their statements
+type fakeAttestationVerifier struct {
+	fakeImageVerifier
+	statements map[string][]cosign.Statement
+}
+
+func (v fakeAttestationVerifier) FetchAttestations(image string, opts cosign.Options) ([]cosign.Statement, error) {
+	if statements, ok := v.statements[image]; ok {
+		return statements, nil
+	}
+	return nil, fmt.Errorf("no attestations found")
+}
+
+func Test_VerifyImages(t *testing.T) {
+	policyRaw := []byte(`{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "verify-images"
+		},
+		"spec": {
+		  "validationFailureAction": "enforce",
+		  "rules": [
+			{
+			  "name": "verify-corp-images",
+			  "match": {
+				"resources": {
+				  "kinds": [
+					"Pod",
+					"Deployment"
+				  ]
+				}
+			  },
+			  "verifyImages": [
+				{
+				  "image": "registry.corp.com/*",
+				  "key": "corp-key"
+				}
+			  ]
+			}
+		  ]
+		}
+	  }`)
+
+	verify := func(resourceRaw string) (bool, string, int) {
+		var policy kyverno.ClusterPolicy
+		assert.NilError(t, json.Unmarshal(policyRaw, &policy))
+		resource, err := utils.ConvertToUnstructured([]byte(resourceRaw))
+		assert.NilError(t, err)
+		verifier := fakeImageVerifier{
+			"registry.corp.com/app:v1":     "corp-key",
+			"registry.corp.com/sidecar:v1": "corp-key",
+			"registry.corp.com/app:v2":     "other-key",
+		}
+		er := VerifyImages(PolicyContext{Policy: policy, NewResource: *resource, Context: context.NewContext(), ImageVerifier: verifier})
+		if len(er.PolicyResponse.Rules) == 0 {
+			return true, "", 0
+		}
+		assert.Equal(t, er.PolicyResponse.Rules[0].Type, utils.ImageVerify.String())
+		return er.IsSuccesful(), er.PolicyResponse.Rules[0].Message, len(er.PolicyResponse.Rules)
+	}
+
+	ok, message, _ := verify(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "test"},
+		"spec": {"containers": [{"name": "app", "image": "registry.corp.com/app:v1"}], "initContainers": [{"name": "init", "image": "registry.corp.com/sidecar:v1"}]}}`)
+	assert.Assert(t, ok)
+	assert.Equal(t, message, "image verification rule 'verify-corp-images' succeeded for registry.corp.com/app:v1, registry.corp.com/sidecar:v1")
+
+	ok, message, _ = verify(`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "test"},
+		"spec": {"template": {"spec": {"containers": [{"name": "app", "image": "registry.corp.com/app:v2"}]}}}}`)
+	assert.Assert(t, !ok)
+	assert.Assert(t, strings.Contains(message, "registry.corp.com/app:v2: no valid signature"), message)
+
+	// the images that do not match the pattern are not verified
+	ok, _, count := verify(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "test"},
+		"spec": {"containers": [{"name": "nginx", "image": "nginx"}]}}`)
+	assert.Assert(t, ok)
+	assert.Equal(t, count, 0)
+}
+
+func Test_VerifyImages_Attestations(t *testing.T) {
+	policyRaw := []byte(`{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "verify-provenance"
+		},
+		"spec": {
+		  "validationFailureAction": "enforce",
+		  "rules": [
+			{
+			  "name": "verify-provenance",
+			  "match": {
+				"resources": {
+				  "kinds": [
+					"Pod"
+				  ]
+				}
+			  },
+			  "verifyImages": [
+				{
+				  "image": "registry.corp.com/*",
+				  "key": "corp-key",
+				  "attestations": [
+					{
+					  "predicateType": "https://slsa.dev/provenance/v0.2",
+					  "conditions": [
+						{
+						  "key": "{{builder.id}}",
+						  "operator": "Equal",
+						  "value": "https://github.com/actions/runner"
+						}
+					  ]
+					},
+					{
+					  "predicateType": "https://cyclonedx.org/bom",
+					  "conditions": [
+						{
+						  "key": "{{vulnerabilities.critical}}",
+						  "operator": "Equal",
+						  "value": 0
+						}
+					  ]
+					}
+				  ]
+				}
+			  ]
+			}
+		  ]
+		}
+	  }`)
+	var policy kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal(policyRaw, &policy))
+
+	provenance := func(builder string) cosign.Statement {
+		return cosign.Statement{
+			PredicateType: "https://slsa.dev/provenance/v0.2",
+			Predicate:     map[string]interface{}{"builder": map[string]interface{}{"id": builder}},
+		}
+	}
+	sbom := func(critical float64) cosign.Statement {
+		return cosign.Statement{
+			PredicateType: "https://cyclonedx.org/bom",
+			Predicate:     map[string]interface{}{"vulnerabilities": map[string]interface{}{"critical": critical}},
+		}
+	}
+	verifier := fakeAttestationVerifier{
+		fakeImageVerifier: fakeImageVerifier{
+			"registry.corp.com/app:v1":        "corp-key",
+			"registry.corp.com/app:v2":        "corp-key",
+			"registry.corp.com/app:v3":        "corp-key",
+			"registry.corp.com/unattested:v1": "corp-key",
+		},
+		statements: map[string][]cosign.Statement{
+			// one of the provenances satisfies the conditions
+			"registry.corp.com/app:v1": {provenance("https://evil.com"), provenance("https://github.com/actions/runner"), sbom(0)},
+			"registry.corp.com/app:v2": {provenance("https://evil.com"), sbom(0)},
+			"registry.corp.com/app:v3": {provenance("https://github.com/actions/runner"), sbom(2)},
+		},
+	}
+
+	verify := func(image string) (bool, string) {
+		resource, err := utils.ConvertToUnstructured([]byte(fmt.Sprintf(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "test"},
+			"spec": {"containers": [{"name": "app", "image": "%s"}]}}`, image)))
+		assert.NilError(t, err)
+		er := VerifyImages(PolicyContext{Policy: policy, NewResource: *resource, Context: context.NewContext(), ImageVerifier: verifier})
+		assert.Equal(t, len(er.PolicyResponse.Rules), 1)
+		return er.IsSuccesful(), er.PolicyResponse.Rules[0].Message
+	}
+
+	ok, message := verify("registry.corp.com/app:v1")
+	assert.Assert(t, ok, message)
+
+	ok, message = verify("registry.corp.com/app:v2")
+	assert.Assert(t, !ok)
+	assert.Assert(t, strings.Contains(message, "no attestation of type https://slsa.dev/provenance/v0.2 satisfies the conditions"), message)
+
+	ok, message = verify("registry.corp.com/app:v3")
+	assert.Assert(t, !ok)
+	assert.Assert(t, strings.Contains(message, "no attestation of type https://cyclonedx.org/bom satisfies the conditions"), message)
+
+	ok, message = verify("registry.corp.com/unattested:v1")
+	assert.Assert(t, !ok)
+	assert.Assert(t, strings.Contains(message, "registry.corp.com/unattested:v1: no attestations found"), message)
+}
+
+func Test_VerifyImages_Notary(t *testing.T) {
+	policyRaw := []byte(`{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "verify-notary"
+		},
+		"spec": {
+		  "validationFailureAction": "enforce",
+		  "rules": [
+			{
+			  "name": "verify-notary",
+			  "match": {
+				"resources": {
+				  "kinds": [
+					"Pod"
+				  ]
+				}
+			  },
+			  "verifyImages": [
+				{
+				  "image": "registry.corp.com/*",
+				  "notary": {
+					"trustStore": "corp-ca",
+					"trustedIdentities": ["*"]
+				  }
+				}
+			  ]
+			}
+		  ]
+		}
+	  }`)
+	var policy kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal(policyRaw, &policy))
+
+	verify := func(image string, notaryVerifier notary.Interface) (bool, string) {
+		resource, err := utils.ConvertToUnstructured([]byte(fmt.Sprintf(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "test"},
+			"spec": {"containers": [{"name": "app", "image": "%s"}]}}`, image)))
+		assert.NilError(t, err)
+		// the cosign verifier is not used for the entries with a trust policy
+		er := VerifyImages(PolicyContext{Policy: policy, NewResource: *resource, Context: context.NewContext(),
+			ImageVerifier: fakeImageVerifier{}, NotaryVerifier: notaryVerifier})
+		assert.Equal(t, len(er.PolicyResponse.Rules), 1)
+		return er.IsSuccesful(), er.PolicyResponse.Rules[0].Message
+	}
+	verifier := fakeNotaryVerifier{"registry.corp.com/app:v1": "corp-ca", "registry.corp.com/app:v2": "other-ca"}
+
+	ok, message := verify("registry.corp.com/app:v1", verifier)
+	assert.Assert(t, ok, message)
+
+	ok, message = verify("registry.corp.com/app:v2", verifier)
+	assert.Assert(t, !ok)
+	assert.Assert(t, strings.Contains(message, "registry.corp.com/app:v2: no valid signature"), message)
+
+	ok, message = verify("registry.corp.com/app:v1", nil)
+	assert.Assert(t, !ok)
+	assert.Assert(t, strings.Contains(message, "the verification of Notary v2 signatures is not supported"), message)
+}
+
+func Test_VerifyImages_MutateDigest(t *testing.T) {
+	policyRaw := []byte(`{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {"name": "verify-images"},
+		"spec": {
+		  "validationFailureAction": "enforce",
+		  "rules": [
+			{
+			  "name": "verify-corp-images",
+			  "match": {"resources": {"kinds": ["Pod"]}},
+			  "verifyImages": [{"image": "registry.corp.com/*", "key": "corp-key", "mutateDigest": true}]
+			}
+		  ]
+		}
+	  }`)
+	var policy kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal(policyRaw, &policy))
+	verifier := fakeImageVerifier{
+		"registry.corp.com/app:v1":              "corp-key",
+		"registry.corp.com/sidecar@sha256:aaaa": "corp-key",
+		"registry.corp.com/app:v2":              "other-key",
+	}
+	verify := func(resourceRaw string) response.EngineResponse {
+		resource, err := utils.ConvertToUnstructured([]byte(resourceRaw))
+		assert.NilError(t, err)
+		return VerifyImages(PolicyContext{Policy: policy, NewResource: *resource, Context: context.NewContext(), ImageVerifier: verifier})
+	}
+
+	// the tags are replaced by the digests, the images with a digest are only recorded
+	er := verify(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "test"},
+		"spec": {"containers": [{"name": "app", "image": "registry.corp.com/app:v1"}, {"name": "sidecar", "image": "registry.corp.com/sidecar@sha256:aaaa"}]}}`)
+	assert.Assert(t, er.IsSuccesful())
+	assert.Equal(t, string(utils.JoinPatches(er.GetPatches())), `[
+{"op":"replace","path":"/spec/containers/0/image","value":"registry.corp.com/app:v1@sha256:registry.corp.com/app:v1"},
+{"op":"add","path":"/metadata/annotations","value":{"kyverno.io/verify-images":"{\"registry.corp.com/app:v1\":\"sha256:registry.corp.com/app:v1\",\"registry.corp.com/sidecar@sha256:aaaa\":\"sha256:registry.corp.com/sidecar@sha256:aaaa\"}"}}
+]`)
+	containers, _, _ := unstructured.NestedSlice(er.PatchedResource.Object, "spec", "containers")
+	assert.Equal(t, containers[0].(map[string]interface{})["image"], "registry.corp.com/app:v1@sha256:registry.corp.com/app:v1")
+
+	// the verified images are added to the annotation
+	er = verify(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "test", "annotations": {"kyverno.io/verify-images": "{\"ghcr.io/app:v1\":\"sha256:bbbb\"}"}},
+		"spec": {"containers": [{"name": "app", "image": "registry.corp.com/app:v1"}]}}`)
+	assert.Assert(t, er.IsSuccesful())
+	assert.Equal(t, er.PatchedResource.GetAnnotations()[verifiedImagesAnnotation], `{"ghcr.io/app:v1":"sha256:bbbb","registry.corp.com/app:v1":"sha256:registry.corp.com/app:v1"}`)
+
+	// the resource is not mutated if the rule fails
+	er = verify(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "test"},
+		"spec": {"containers": [{"name": "app", "image": "registry.corp.com/app:v1"}, {"name": "other", "image": "registry.corp.com/app:v2"}]}}`)
+	assert.Assert(t, !er.IsSuccesful())
+	assert.Equal(t, len(er.GetPatches()), 0)
+}
+
+func Test_loadImagePullSecrets(t *testing.T) {
+	dockerConfig := func(registry, user string) string {
+		return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(`{"auths": {"%s": {"username": "%s", "password": "secret"}}}`, registry, user)))
+	}
+	object := func(raw string) *unstructured.Unstructured {
+		obj, err := utils.ConvertToUnstructured([]byte(raw))
+		assert.NilError(t, err)
+		return obj
+	}
+	getter := fakeResourceGetter{
+		"ServiceAccount/prod/builder": object(`{"apiVersion": "v1", "kind": "ServiceAccount", "metadata": {"name": "builder", "namespace": "prod"},
+			"imagePullSecrets": [{"name": "ghcr"}]}`),
+		"Secret/prod/corp": object(fmt.Sprintf(`{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "corp", "namespace": "prod"},
+			"type": "kubernetes.io/dockerconfigjson", "data": {".dockerconfigjson": "%s"}}`, dockerConfig("registry.corp.com", "corp"))),
+		"Secret/prod/ghcr": object(fmt.Sprintf(`{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "ghcr", "namespace": "prod"},
+			"type": "kubernetes.io/dockerconfigjson", "data": {".dockerconfigjson": "%s"}}`, dockerConfig("ghcr.io", "ghcr"))),
+	}
+
+	// the secrets of the pod template and of its service account, a missing secret is skipped
+	deployment := object(`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "app", "namespace": "prod"},
+		"spec": {"template": {"spec": {"serviceAccountName": "builder", "imagePullSecrets": [{"name": "corp"}, {"name": "missing"}],
+		"containers": [{"name": "app", "image": "registry.corp.com/app:v1"}]}}}}`)
+	keychain := loadImagePullSecrets(log.Discard, getter, *deployment)
+	assert.Assert(t, keychain != nil)
+	creds, err := keychain.Resolve("registry.corp.com")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, creds, &registry.Credentials{Username: "corp", Password: "secret"})
+	creds, err = keychain.Resolve("ghcr.io")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, creds, &registry.Credentials{Username: "ghcr", Password: "secret"})
+
+	pod := object(`{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "app", "namespace": "prod"},
+		"spec": {"containers": [{"name": "app", "image": "registry.corp.com/app:v1"}]}}`)
+	assert.Assert(t, loadImagePullSecrets(log.Discard, getter, *pod) == nil)
+}
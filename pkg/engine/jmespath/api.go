@@ -0,0 +1,32 @@
+package jmespath
+
+import (
+	gojmespath "github.com/jmespath/go-jmespath"
+)
+
+func init() {
+	for _, function := range customFunctions {
+		gojmespath.RegisterFunction(function)
+	}
+}
+
+// JMESPath is a compiled JMESPath expression, it is safe for concurrent use by multiple goroutines
+type JMESPath = gojmespath.JMESPath
+
+// ASTNode is a node of the syntax tree of an expression
+type ASTNode = gojmespath.ASTNode
+
+// Compile parses the expression and returns the compiled expression
+func Compile(expression string) (*JMESPath, error) {
+	return gojmespath.Compile(expression)
+}
+
+// Search evaluates the expression on the data
+func Search(expression string, data interface{}) (interface{}, error) {
+	return gojmespath.Search(expression, data)
+}
+
+// Parse returns the syntax tree of the expression
+func Parse(expression string) (ASTNode, error) {
+	return gojmespath.NewParser().Parse(expression)
+}
@@ -0,0 +1,382 @@
+package jmespath
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gojmespath "github.com/jmespath/go-jmespath"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// the types of the arguments of the functions
+const (
+	jpString = "string"
+	jpNumber = "number"
+)
+
+// customFunctions are the functions added to the JMESPath functions
+// - time: time_now, time_parse_rfc3339, time_since, time_add
+// - resource quantities: quantity_value, quantity_add, quantity_subtract, quantity_compare
+// - strings: split, regex_match, truncate
+// - encoding: base64_decode, parse_json
+// - certificates: x509_decode
+var customFunctions = map[string]gojmespath.Function{
+	"time_now": {
+		Name:    "time_now",
+		Handler: jpfTimeNow,
+	},
+	"time_parse_rfc3339": {
+		Name: "time_parse_rfc3339",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString}},
+		},
+		Handler: jpfTimeParseRFC3339,
+	},
+	"time_since": {
+		Name: "time_since",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString}},
+		},
+		Handler: jpfTimeSince,
+	},
+	"time_add": {
+		Name: "time_add",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString}},
+			{Types: []string{jpString}},
+		},
+		Handler: jpfTimeAdd,
+	},
+	"quantity_value": {
+		Name: "quantity_value",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString, jpNumber}},
+		},
+		Handler: jpfQuantityValue,
+	},
+	"quantity_add": {
+		Name: "quantity_add",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString, jpNumber}},
+			{Types: []string{jpString, jpNumber}},
+		},
+		Handler: jpfQuantityAdd,
+	},
+	"quantity_subtract": {
+		Name: "quantity_subtract",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString, jpNumber}},
+			{Types: []string{jpString, jpNumber}},
+		},
+		Handler: jpfQuantitySubtract,
+	},
+	"quantity_compare": {
+		Name: "quantity_compare",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString, jpNumber}},
+			{Types: []string{jpString, jpNumber}},
+		},
+		Handler: jpfQuantityCompare,
+	},
+	"split": {
+		Name: "split",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString}},
+			{Types: []string{jpString}},
+		},
+		Handler: jpfSplit,
+	},
+	"regex_match": {
+		Name: "regex_match",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString}},
+			{Types: []string{jpString}},
+		},
+		Handler: jpfRegexMatch,
+	},
+	"truncate": {
+		Name: "truncate",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString}},
+			{Types: []string{jpNumber}},
+		},
+		Handler: jpfTruncate,
+	},
+	"base64_decode": {
+		Name: "base64_decode",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString}},
+		},
+		Handler: jpfBase64Decode,
+	},
+	"parse_json": {
+		Name: "parse_json",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString}},
+		},
+		Handler: jpfParseJSON,
+	},
+	"x509_decode": {
+		Name: "x509_decode",
+		Arguments: []gojmespath.Argument{
+			{Types: []string{jpString}},
+		},
+		Handler: jpfX509Decode,
+	},
+}
+
+// timeNow is replaced in tests
+var timeNow = time.Now
+
+// time_now() returns the current time in RFC3339 format
+func jpfTimeNow(arguments []interface{}) (interface{}, error) {
+	if len(arguments) != 0 {
+		return nil, fmt.Errorf("time_now does not take arguments")
+	}
+	return timeNow().UTC().Format(time.RFC3339), nil
+}
+
+// time_parse_rfc3339(time) returns the time as the number of seconds since the Unix epoch
+func jpfTimeParseRFC3339(arguments []interface{}) (interface{}, error) {
+	t, err := time.Parse(time.RFC3339, arguments[0].(string))
+	if err != nil {
+		return nil, err
+	}
+	return float64(t.Unix()), nil
+}
+
+// time_since(time) returns the number of seconds elapsed since the RFC3339 time
+func jpfTimeSince(arguments []interface{}) (interface{}, error) {
+	t, err := time.Parse(time.RFC3339, arguments[0].(string))
+	if err != nil {
+		return nil, err
+	}
+	return timeNow().Sub(t).Seconds(), nil
+}
+
+// time_add(time, duration) adds the duration, e.g. 24h or -1h30m, to the RFC3339 time
+func jpfTimeAdd(arguments []interface{}) (interface{}, error) {
+	t, err := time.Parse(time.RFC3339, arguments[0].(string))
+	if err != nil {
+		return nil, err
+	}
+	d, err := time.ParseDuration(arguments[1].(string))
+	if err != nil {
+		return nil, err
+	}
+	return t.Add(d).UTC().Format(time.RFC3339), nil
+}
+
+// toQuantity converts a string, e.g. 100Mi, or a number to a resource quantity
+func toQuantity(arg interface{}) (resource.Quantity, error) {
+	switch v := arg.(type) {
+	case string:
+		return resource.ParseQuantity(v)
+	case float64:
+		return resource.ParseQuantity(strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return resource.Quantity{}, fmt.Errorf("invalid quantity %v", arg)
+}
+
+func toQuantities(arguments []interface{}) (resource.Quantity, resource.Quantity, error) {
+	q1, err := toQuantity(arguments[0])
+	if err != nil {
+		return q1, resource.Quantity{}, err
+	}
+	q2, err := toQuantity(arguments[1])
+	return q1, q2, err
+}
+
+// quantity_value(quantity) returns the value of the quantity as a number, e.g. 500m is 0.5
+func jpfQuantityValue(arguments []interface{}) (interface{}, error) {
+	q, err := toQuantity(arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	return float64(q.MilliValue()) / 1000, nil
+}
+
+// quantity_add(quantity, quantity) returns the sum of the quantities, e.g. 1Gi + 512Mi is 1536Mi
+func jpfQuantityAdd(arguments []interface{}) (interface{}, error) {
+	q1, q2, err := toQuantities(arguments)
+	if err != nil {
+		return nil, err
+	}
+	q1.Add(q2)
+	return q1.String(), nil
+}
+
+// quantity_subtract(quantity, quantity) returns the difference of the quantities
+func jpfQuantitySubtract(arguments []interface{}) (interface{}, error) {
+	q1, q2, err := toQuantities(arguments)
+	if err != nil {
+		return nil, err
+	}
+	q1.Sub(q2)
+	return q1.String(), nil
+}
+
+// quantity_compare(quantity, quantity) returns -1, 0 or 1 if the first quantity is less than, equal to or greater than the second
+func jpfQuantityCompare(arguments []interface{}) (interface{}, error) {
+	q1, q2, err := toQuantities(arguments)
+	if err != nil {
+		return nil, err
+	}
+	return float64(q1.Cmp(q2)), nil
+}
+
+// split(string, separator) returns the substrings between the separators
+func jpfSplit(arguments []interface{}) (interface{}, error) {
+	var result []interface{}
+	for _, s := range strings.Split(arguments[0].(string), arguments[1].(string)) {
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// regex_match(pattern, string) checks if the string contains a match of the regular expression
+func jpfRegexMatch(arguments []interface{}) (interface{}, error) {
+	return regexp.MatchString(arguments[0].(string), arguments[1].(string))
+}
+
+// truncate(string, length) returns the first characters of the string
+func jpfTruncate(arguments []interface{}) (interface{}, error) {
+	s := []rune(arguments[0].(string))
+	length := int(arguments[1].(float64))
+	if length < 0 {
+		return nil, fmt.Errorf("invalid length %d", length)
+	}
+	if length < len(s) {
+		s = s[:length]
+	}
+	return string(s), nil
+}
+
+// base64_decode(string) decodes the base64 string, e.g. the data of a Secret
+func jpfBase64Decode(arguments []interface{}) (interface{}, error) {
+	decoded, err := base64.StdEncoding.DecodeString(arguments[0].(string))
+	if err != nil {
+		return nil, err
+	}
+	return string(decoded), nil
+}
+
+// parse_json(string) decodes the JSON string
+func jpfParseJSON(arguments []interface{}) (interface{}, error) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(arguments[0].(string)), &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// x509_decode(string) decodes the first PEM encoded certificate, e.g. the tls.crt of a Secret, to:
+// - subject, issuer: commonName, organization, organizationalUnit, country, locality, province
+// - serialNumber, notBefore, notAfter (RFC3339), isCA
+// - dnsNames, ipAddresses, emailAddresses, uris
+// - signatureAlgorithm, publicKeyAlgorithm
+func jpfX509Decode(arguments []interface{}) (interface{}, error) {
+	block, _ := pem.Decode([]byte(arguments[0].(string)))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	var ipAddresses, uris []interface{}
+	for _, ip := range cert.IPAddresses {
+		ipAddresses = append(ipAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+	return map[string]interface{}{
+		"subject":            pkixName(cert.Subject),
+		"issuer":             pkixName(cert.Issuer),
+		"serialNumber":       cert.SerialNumber.String(),
+		"notBefore":          cert.NotBefore.UTC().Format(time.RFC3339),
+		"notAfter":           cert.NotAfter.UTC().Format(time.RFC3339),
+		"isCA":               cert.IsCA,
+		"dnsNames":           toInterfaces(cert.DNSNames),
+		"ipAddresses":        ipAddresses,
+		"emailAddresses":     toInterfaces(cert.EmailAddresses),
+		"uris":               uris,
+		"signatureAlgorithm": cert.SignatureAlgorithm.String(),
+		"publicKeyAlgorithm": publicKeyAlgorithm(cert.PublicKeyAlgorithm),
+	}, nil
+}
+
+func pkixName(name pkix.Name) map[string]interface{} {
+	return map[string]interface{}{
+		"commonName":         name.CommonName,
+		"organization":       toInterfaces(name.Organization),
+		"organizationalUnit": toInterfaces(name.OrganizationalUnit),
+		"country":            toInterfaces(name.Country),
+		"locality":           toInterfaces(name.Locality),
+		"province":           toInterfaces(name.Province),
+	}
+}
+
+func publicKeyAlgorithm(algorithm x509.PublicKeyAlgorithm) string {
+	switch algorithm {
+	case x509.RSA:
+		return "RSA"
+	case x509.DSA:
+		return "DSA"
+	case x509.ECDSA:
+		return "ECDSA"
+	case x509.Ed25519:
+		return "Ed25519"
+	}
+	return "Unknown"
+}
+
+// toInterfaces converts the strings to the JSON array type used by JMESPath
+func toInterfaces(values []string) []interface{} {
+	var result []interface{}
+	for _, v := range values {
+		result = append(result, v)
+	}
+	return result
+}
+
+// FunctionSignature is the signature of a function, e.g. split(string, string)
+type FunctionSignature struct {
+	Name      string
+	Signature string
+	// Custom is true for the functions added to the JMESPath functions
+	Custom bool
+}
+
+// Functions returns the signatures of the functions, sorted by name
+func Functions() []FunctionSignature {
+	var functions []FunctionSignature
+	for _, function := range gojmespath.Functions() {
+		var arguments []string
+		for _, argument := range function.Arguments {
+			signature := strings.Join(argument.Types, "|")
+			if argument.Variadic {
+				signature += "..."
+			}
+			arguments = append(arguments, signature)
+		}
+		_, custom := customFunctions[function.Name]
+		functions = append(functions, FunctionSignature{
+			Name:      function.Name,
+			Signature: fmt.Sprintf("%s(%s)", function.Name, strings.Join(arguments, ", ")),
+			Custom:    custom,
+		})
+	}
+	sort.Slice(functions, func(i, j int) bool { return functions[i].Name < functions[j].Name })
+	return functions
+}
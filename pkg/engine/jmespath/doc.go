@@ -0,0 +1,5 @@
+// Package jmespath evaluates the JMESPath expressions of the policies with github.com/jmespath/go-jmespath
+// and the additional functions of custom_functions.go.
+// The go-jmespath module is replaced by third_party/github.com/jmespath/go-jmespath, the same version
+// with the registration of the functions, as the package does not support registering functions.
+package jmespath
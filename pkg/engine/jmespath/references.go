@@ -0,0 +1,55 @@
+package jmespath
+
+import "strings"
+
+// References returns the paths of the data referenced from the root by the expression,
+// the indexes and the projections are omitted from the paths
+// e.g. contains(request.object.spec.containers[].image, 'nginx') references request.object.spec.containers
+// the expressions evaluated on the elements of a projection, a filter or after a pipe are relative and not returned
+func References(expression string) ([]string, error) {
+	ast, err := Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	collectReferences(ast, &refs)
+	return refs, nil
+}
+
+func collectReferences(node ASTNode, refs *[]string) {
+	switch node.Type() {
+	case "ASTField", "ASTSubexpression":
+		if path := fieldPath(node); path != nil {
+			*refs = append(*refs, strings.Join(path, "."))
+			return
+		}
+		// the left of a subexpression can be an expression, e.g. a function
+		collectReferences(node.Children()[0], refs)
+	case "ASTIndexExpression", "ASTProjection", "ASTFilterProjection", "ASTValueProjection", "ASTFlatten", "ASTPipe":
+		// only the left is evaluated on the root
+		collectReferences(node.Children()[0], refs)
+	case "ASTFunctionExpression", "ASTComparator", "ASTOrExpression", "ASTAndExpression", "ASTNotExpression",
+		"ASTMultiSelectList", "ASTMultiSelectHash", "ASTKeyValPair":
+		for _, child := range node.Children() {
+			collectReferences(child, refs)
+		}
+	}
+}
+
+// fieldPath returns the fields of a chain of subexpressions that starts with a field
+func fieldPath(node ASTNode) []string {
+	switch node.Type() {
+	case "ASTField":
+		return []string{node.Value().(string)}
+	case "ASTSubexpression":
+		path := fieldPath(node.Children()[0])
+		if path == nil {
+			return nil
+		}
+		// the right can be a field with an index, e.g. containers[0]
+		return append(path, fieldPath(node.Children()[1])...)
+	case "ASTIndexExpression", "ASTProjection", "ASTFlatten":
+		return fieldPath(node.Children()[0])
+	}
+	return nil
+}
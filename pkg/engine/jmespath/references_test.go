@@ -0,0 +1,33 @@
+package jmespath
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func Test_References(t *testing.T) {
+	testCases := []struct {
+		expression string
+		expected   []string
+	}{
+		{"request.object.metadata.name", []string{"request.object.metadata.name"}},
+		{"request.object.spec.containers[0].image", []string{"request.object.spec.containers.image"}},
+		{"request.object.spec.containers[].image", []string{"request.object.spec.containers"}},
+		{"contains(images.containers.*.tag, 'latest')", []string{"images.containers"}},
+		{"items[?spec.type == 'LoadBalancer'] | length(@)", []string{"items"}},
+		{"join(', ', request.roles) || serviceAccountName", []string{"request.roles", "serviceAccountName"}},
+		{"{name: request.object.metadata.name, tag: images.containers.app.tag}", []string{"request.object.metadata.name", "images.containers.app.tag"}},
+		{"sort_by(request.object.spec.containers, &name)", []string{"request.object.spec.containers"}},
+		{"parse_json(base64_decode(cm.data.config)).replicas", []string{"cm.data.config"}},
+		{"`1`", nil},
+	}
+	for _, tc := range testCases {
+		refs, err := References(tc.expression)
+		assert.NilError(t, err, tc.expression)
+		assert.DeepEqual(t, refs, tc.expected)
+	}
+
+	_, err := References("request.object.[")
+	assert.Assert(t, err != nil)
+}
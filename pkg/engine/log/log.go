@@ -0,0 +1,116 @@
+// Package log is the logger of the policy engine. The engine has no global logger: the Logger is passed
+// with the PolicyContext, so the programs embedding the engine choose where its logs are written.
+package log
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+)
+
+// Sink writes the logs of the engine
+type Sink interface {
+	// Enabled returns true if the info logs of the verbosity level are written
+	Enabled(level int) bool
+	Info(message string)
+	Warning(message string)
+	Error(message string)
+}
+
+// Logger writes the logs to its sink, the zero Logger discards the logs
+type Logger struct {
+	sink Sink
+}
+
+// New returns a Logger writing to the sink, the logs are discarded if it is nil
+func New(sink Sink) Logger {
+	return Logger{sink: sink}
+}
+
+// Discard discards the logs
+var Discard = Logger{}
+
+// Glog writes the logs with glog, the info logs of a level are written if the verbosity of glog is at least the level
+var Glog = New(glogSink{})
+
+type glogSink struct{}
+
+func (glogSink) Enabled(level int) bool { return bool(glog.V(glog.Level(level))) }
+func (glogSink) Info(message string)    { glog.InfoDepth(2, message) }
+func (glogSink) Warning(message string) { glog.WarningDepth(2, message) }
+func (glogSink) Error(message string)   { glog.ErrorDepth(2, message) }
+
+// Verbose writes the info logs if its level is enabled
+type Verbose struct {
+	// sink is nil if the level is disabled
+	sink Sink
+}
+
+// V returns the Verbose of the level, as glog.V
+func (l Logger) V(level int) Verbose {
+	if l.sink == nil || !l.sink.Enabled(level) {
+		return Verbose{}
+	}
+	return Verbose{sink: l.sink}
+}
+
+// Enabled returns true if the level is enabled
+func (v Verbose) Enabled() bool {
+	return v.sink != nil
+}
+
+// Info logs the arguments if the level is enabled
+func (v Verbose) Info(args ...interface{}) {
+	if v.sink != nil {
+		v.sink.Info(fmt.Sprint(args...))
+	}
+}
+
+// Infof logs the formatted message if the level is enabled
+func (v Verbose) Infof(format string, args ...interface{}) {
+	if v.sink != nil {
+		v.sink.Info(fmt.Sprintf(format, args...))
+	}
+}
+
+// Info logs the arguments
+func (l Logger) Info(args ...interface{}) {
+	if l.sink != nil {
+		l.sink.Info(fmt.Sprint(args...))
+	}
+}
+
+// Infof logs the formatted message
+func (l Logger) Infof(format string, args ...interface{}) {
+	if l.sink != nil {
+		l.sink.Info(fmt.Sprintf(format, args...))
+	}
+}
+
+// Warning logs the arguments as a warning
+func (l Logger) Warning(args ...interface{}) {
+	if l.sink != nil {
+		l.sink.Warning(fmt.Sprint(args...))
+	}
+}
+
+// Warningf logs the formatted message as a warning
+func (l Logger) Warningf(format string, args ...interface{}) {
+	if l.sink != nil {
+		l.sink.Warning(fmt.Sprintf(format, args...))
+	}
+}
+
+// Error logs the arguments as an error
+func (l Logger) Error(args ...interface{}) {
+	if l.sink != nil {
+		l.sink.Error(fmt.Sprint(args...))
+	}
+}
+
+// Errorf logs the formatted message as an error
+func (l Logger) Errorf(format string, args ...interface{}) {
+	if l.sink != nil {
+		l.sink.Error(fmt.Sprintf(format, args...))
+	}
+}
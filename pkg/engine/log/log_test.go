@@ -0,0 +1,36 @@
+package log
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+type recorder struct {
+	level int
+	logs  []string
+}
+
+func (r *recorder) Enabled(level int) bool { return level <= r.level }
+func (r *recorder) Info(message string)    { r.logs = append(r.logs, "I "+message) }
+func (r *recorder) Warning(message string) { r.logs = append(r.logs, "W "+message) }
+func (r *recorder) Error(message string)   { r.logs = append(r.logs, "E "+message) }
+
+func Test_Logger(t *testing.T) {
+	r := &recorder{level: 2}
+	log := New(r)
+
+	log.V(2).Infof("rule %s", "check-labels")
+	log.V(4).Info("skipped")
+	log.Warning("no match")
+	log.Errorf("failed: %v", "invalid pattern")
+	assert.DeepEqual(t, r.logs, []string{"I rule check-labels", "W no match", "E failed: invalid pattern"})
+
+	// the zero logger discards the logs
+	var discard Logger
+	discard.Info("discarded")
+	discard.V(0).Info("discarded")
+	assert.Equal(t, discard.V(0).Enabled(), false)
+	assert.Equal(t, Discard.V(0).Enabled(), false)
+	assert.Equal(t, len(r.logs), 3)
+}
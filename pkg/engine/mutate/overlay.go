@@ -10,7 +10,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/golang/glog"
+	"github.com/nirmata/kyverno/pkg/engine/log"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	jsonpatch "github.com/evanphx/json-patch"
@@ -23,14 +23,14 @@ import (
 )
 
 // ProcessOverlay processes mutation overlay on the resource
-func ProcessOverlay(ctx context.EvalInterface, rule kyverno.Rule, resource unstructured.Unstructured) (resp response.RuleResponse, patchedResource unstructured.Unstructured) {
+func ProcessOverlay(log log.Logger, ctx context.EvalInterface, rule kyverno.Rule, resource unstructured.Unstructured) (resp response.RuleResponse, patchedResource unstructured.Unstructured) {
 	startTime := time.Now()
-	glog.V(4).Infof("started applying overlay rule %q (%v)", rule.Name, startTime)
+	log.V(4).Infof("started applying overlay rule %q (%v)", rule.Name, startTime)
 	resp.Name = rule.Name
 	resp.Type = utils.Mutation.String()
 	defer func() {
 		resp.RuleStats.ProcessingTime = time.Since(startTime)
-		glog.V(4).Infof("finished applying overlay rule %q (%v)", resp.Name, resp.RuleStats.ProcessingTime)
+		log.V(4).Infof("finished applying overlay rule %q (%v)", resp.Name, resp.RuleStats.ProcessingTime)
 	}()
 
 	// if referenced path not present, we skip processing the rule and report violation
@@ -38,7 +38,7 @@ func ProcessOverlay(ctx context.EvalInterface, rule kyverno.Rule, resource unstr
 		resp.Success = true
 		resp.PathNotPresent = true
 		resp.Message = fmt.Sprintf("referenced path not present: %s", invalidPaths)
-		glog.V(3).Infof("Skip applying rule '%s' on resource '%s/%s/%s': %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), resp.Message)
+		log.V(3).Infof("Skip applying rule '%s' on resource '%s/%s/%s': %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), resp.Message)
 		return resp, resource
 	}
 
@@ -48,32 +48,33 @@ func ProcessOverlay(ctx context.EvalInterface, rule kyverno.Rule, resource unstr
 	// if a JMESPATH fails, we dont return error but variable is substitured with nil and error log
 	overlay := variables.SubstituteVariables(ctx, rule.Mutation.Overlay)
 
-	patches, overlayerr := processOverlayPatches(resource.UnstructuredContent(), overlay)
+	patches, overlayerr := processOverlayPatches(log, resource.UnstructuredContent(), overlay)
 	// resource does not satisfy the overlay pattern, we don't apply this rule
 	if !reflect.DeepEqual(overlayerr, overlayError{}) {
 		switch overlayerr.statusCode {
 		// condition key is not present in the resource, don't apply this rule
 		// consider as success
 		case conditionNotPresent:
-			glog.V(3).Infof("Skip applying rule '%s' on resource '%s/%s/%s': %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), overlayerr.ErrorMsg())
+			log.V(3).Infof("Skip applying rule '%s' on resource '%s/%s/%s': %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), overlayerr.ErrorMsg())
 			resp.Success = true
 			return resp, resource
 		// conditions are not met, don't apply this rule
 		case conditionFailure:
-			glog.V(3).Infof("Skip applying rule '%s' on resource '%s/%s/%s': %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), overlayerr.ErrorMsg())
+			log.V(3).Infof("Skip applying rule '%s' on resource '%s/%s/%s': %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), overlayerr.ErrorMsg())
 			//TODO: send zero response and not consider this as applied?
 			resp.Success = true
 			resp.Message = overlayerr.ErrorMsg()
 			return resp, resource
 		// rule application failed
 		case overlayFailure:
-			glog.Errorf("Resource %s/%s/%s: failed to process overlay: %v in the rule %s", resource.GetKind(), resource.GetNamespace(), resource.GetName(), overlayerr.ErrorMsg(), rule.Name)
+			log.Errorf("Resource %s/%s/%s: failed to process overlay: %v in the rule %s", resource.GetKind(), resource.GetNamespace(), resource.GetName(), overlayerr.ErrorMsg(), rule.Name)
 			resp.Success = false
 			resp.Message = fmt.Sprintf("failed to process overlay: %v", overlayerr.ErrorMsg())
 			return resp, resource
 		default:
-			glog.Errorf("Resource %s/%s/%s: Unknown type of error: %v", resource.GetKind(), resource.GetNamespace(), resource.GetName(), overlayerr.Error())
+			log.Errorf("Resource %s/%s/%s: Unknown type of error: %v", resource.GetKind(), resource.GetNamespace(), resource.GetName(), overlayerr.Error())
 			resp.Success = false
+			resp.Error = true
 			resp.Message = fmt.Sprintf("Unknown type of error: %v", overlayerr.Error())
 			return resp, resource
 		}
@@ -88,7 +89,8 @@ func ProcessOverlay(ctx context.EvalInterface, rule kyverno.Rule, resource unstr
 	resourceRaw, err := resource.MarshalJSON()
 	if err != nil {
 		resp.Success = false
-		glog.Infof("unable to marshall resource: %v", err)
+		resp.Error = true
+		log.Infof("unable to marshall resource: %v", err)
 		resp.Message = fmt.Sprintf("failed to process JSON patches: %v", err)
 		return resp, resource
 	}
@@ -97,16 +99,18 @@ func ProcessOverlay(ctx context.EvalInterface, rule kyverno.Rule, resource unstr
 	patchResource, err = utils.ApplyPatches(resourceRaw, patches)
 	if err != nil {
 		msg := fmt.Sprintf("failed to apply JSON patches: %v", err)
-		glog.V(2).Infof("%s, patches=%s", msg, string(utils.JoinPatches(patches)))
+		log.V(2).Infof("%s, patches=%s", msg, string(utils.JoinPatches(patches)))
 		resp.Success = false
+		resp.Error = true
 		resp.Message = msg
 		return resp, resource
 	}
 
 	err = patchedResource.UnmarshalJSON(patchResource)
 	if err != nil {
-		glog.Infof("failed to unmarshall resource to undstructured: %v", err)
+		log.Infof("failed to unmarshall resource to undstructured: %v", err)
 		resp.Success = false
+		resp.Error = true
 		resp.Message = fmt.Sprintf("failed to process JSON patches: %v", err)
 		return resp, resource
 	}
@@ -119,22 +123,22 @@ func ProcessOverlay(ctx context.EvalInterface, rule kyverno.Rule, resource unstr
 	return resp, patchedResource
 }
 
-func processOverlayPatches(resource, overlay interface{}) ([][]byte, overlayError) {
-	if path, overlayerr := meetConditions(resource, overlay); !reflect.DeepEqual(overlayerr, overlayError{}) {
+func processOverlayPatches(log log.Logger, resource, overlay interface{}) ([][]byte, overlayError) {
+	if path, overlayerr := meetConditions(log, resource, overlay); !reflect.DeepEqual(overlayerr, overlayError{}) {
 		switch overlayerr.statusCode {
 		// anchor key does not exist in the resource, skip applying policy
 		case conditionNotPresent:
-			glog.V(4).Infof("Mutate rule: skip applying policy: %v at %s", overlayerr, path)
+			log.V(4).Infof("Mutate rule: skip applying policy: %v at %s", overlayerr, path)
 			return nil, newOverlayError(overlayerr.statusCode, fmt.Sprintf("Policy not applied, condition tag not present: %v at %s", overlayerr.ErrorMsg(), path))
 		// anchor key is not satisfied in the resource, skip applying policy
 		case conditionFailure:
 			// anchor key is not satisfied in the resource, skip applying policy
-			glog.V(4).Infof("Mutate rule: failed to validate condition at %s, err: %v", path, overlayerr)
+			log.V(4).Infof("Mutate rule: failed to validate condition at %s, err: %v", path, overlayerr)
 			return nil, newOverlayError(overlayerr.statusCode, fmt.Sprintf("Policy not applied, conditions are not met at %s, %v", path, overlayerr))
 		}
 	}
 
-	patchBytes, err := mutateResourceWithOverlay(resource, overlay)
+	patchBytes, err := mutateResourceWithOverlay(log, resource, overlay)
 	if err != nil {
 		return patchBytes, newOverlayError(overlayFailure, err.Error())
 	}
@@ -143,29 +147,29 @@ func processOverlayPatches(resource, overlay interface{}) ([][]byte, overlayErro
 }
 
 // mutateResourceWithOverlay is a start of overlaying process
-func mutateResourceWithOverlay(resource, pattern interface{}) ([][]byte, error) {
+func mutateResourceWithOverlay(log log.Logger, resource, pattern interface{}) ([][]byte, error) {
 	// It assumes that mutation is started from root, so "/" is passed
-	return applyOverlay(resource, pattern, "/")
+	return applyOverlay(log, resource, pattern, "/")
 }
 
 // applyOverlay detects type of current item and goes down through overlay and resource trees applying overlay
-func applyOverlay(resource, overlay interface{}, path string) ([][]byte, error) {
+func applyOverlay(log log.Logger, resource, overlay interface{}, path string) ([][]byte, error) {
 
 	// resource item exists but has different type - replace
 	// all subtree within this path by overlay
 	if reflect.TypeOf(resource) != reflect.TypeOf(overlay) {
-		patch, err := replaceSubtree(overlay, path)
+		patch, err := replaceSubtree(log, overlay, path)
 		if err != nil {
 			return nil, err
 		}
 
 		return [][]byte{patch}, nil
 	}
-	return applyOverlayForSameTypes(resource, overlay, path)
+	return applyOverlayForSameTypes(log, resource, overlay, path)
 }
 
 // applyOverlayForSameTypes is applyOverlay for cases when TypeOf(resource) == TypeOf(overlay)
-func applyOverlayForSameTypes(resource, overlay interface{}, path string) ([][]byte, error) {
+func applyOverlayForSameTypes(log log.Logger, resource, overlay interface{}, path string) ([][]byte, error) {
 	var appliedPatches [][]byte
 
 	// detect the type of resource and overlay and select corresponding handler
@@ -173,7 +177,7 @@ func applyOverlayForSameTypes(resource, overlay interface{}, path string) ([][]b
 	// map
 	case map[string]interface{}:
 		typedResource := resource.(map[string]interface{})
-		patches, err := applyOverlayToMap(typedResource, typedOverlay, path)
+		patches, err := applyOverlayToMap(log, typedResource, typedOverlay, path)
 		if err != nil {
 			return nil, err
 		}
@@ -181,14 +185,14 @@ func applyOverlayForSameTypes(resource, overlay interface{}, path string) ([][]b
 	// array
 	case []interface{}:
 		typedResource := resource.([]interface{})
-		patches, err := applyOverlayToArray(typedResource, typedOverlay, path)
+		patches, err := applyOverlayToArray(log, typedResource, typedOverlay, path)
 		if err != nil {
 			return nil, err
 		}
 		appliedPatches = append(appliedPatches, patches...)
 	// elementary types
 	case string, float64, int64, bool:
-		patch, err := replaceSubtree(overlay, path)
+		patch, err := replaceSubtree(log, overlay, path)
 		if err != nil {
 			return nil, err
 		}
@@ -201,7 +205,7 @@ func applyOverlayForSameTypes(resource, overlay interface{}, path string) ([][]b
 }
 
 // for each overlay and resource map elements applies overlay
-func applyOverlayToMap(resourceMap, overlayMap map[string]interface{}, path string) ([][]byte, error) {
+func applyOverlayToMap(log log.Logger, resourceMap, overlayMap map[string]interface{}, path string) ([][]byte, error) {
 	var appliedPatches [][]byte
 
 	for key, value := range overlayMap {
@@ -217,7 +221,7 @@ func applyOverlayToMap(resourceMap, overlayMap map[string]interface{}, path stri
 
 		if ok && !anchor.IsAddingAnchor(key) {
 			// Key exists - go down through the overlay and resource trees
-			patches, err := applyOverlay(resourcePart, value, currentPath)
+			patches, err := applyOverlay(log, resourcePart, value, currentPath)
 			if err != nil {
 				return nil, err
 			}
@@ -226,7 +230,7 @@ func applyOverlayToMap(resourceMap, overlayMap map[string]interface{}, path stri
 
 		if !ok {
 			// Key does not exist - insert entire overlay subtree
-			patch, err := insertSubtree(value, currentPath)
+			patch, err := insertSubtree(log, value, currentPath)
 			if err != nil {
 				return nil, err
 			}
@@ -238,7 +242,7 @@ func applyOverlayToMap(resourceMap, overlayMap map[string]interface{}, path stri
 }
 
 // for each overlay and resource array elements applies overlay
-func applyOverlayToArray(resource, overlay []interface{}, path string) ([][]byte, error) {
+func applyOverlayToArray(log log.Logger, resource, overlay []interface{}, path string) ([][]byte, error) {
 	var appliedPatches [][]byte
 
 	if 0 == len(overlay) {
@@ -247,7 +251,7 @@ func applyOverlayToArray(resource, overlay []interface{}, path string) ([][]byte
 
 	if 0 == len(resource) {
 		// If array resource is empty, insert part from overlay
-		patch, err := insertSubtree(overlay, path)
+		patch, err := insertSubtree(log, overlay, path)
 		if err != nil {
 			return nil, err
 		}
@@ -260,16 +264,16 @@ func applyOverlayToArray(resource, overlay []interface{}, path string) ([][]byte
 		return nil, fmt.Errorf("Overlay array and resource array have elements of different types: %T and %T", overlay[0], resource[0])
 	}
 
-	return applyOverlayToArrayOfSameTypes(resource, overlay, path)
+	return applyOverlayToArrayOfSameTypes(log, resource, overlay, path)
 }
 
 // applyOverlayToArrayOfSameTypes applies overlay to array elements if they (resource and overlay elements) have same type
-func applyOverlayToArrayOfSameTypes(resource, overlay []interface{}, path string) ([][]byte, error) {
+func applyOverlayToArrayOfSameTypes(log log.Logger, resource, overlay []interface{}, path string) ([][]byte, error) {
 	var appliedPatches [][]byte
 
 	switch overlay[0].(type) {
 	case map[string]interface{}:
-		return applyOverlayToArrayOfMaps(resource, overlay, path)
+		return applyOverlayToArrayOfMaps(log, resource, overlay, path)
 	default:
 		lastElementIdx := len(resource)
 
@@ -277,7 +281,7 @@ func applyOverlayToArrayOfSameTypes(resource, overlay []interface{}, path string
 		for i, value := range overlay {
 			currentPath := path + strconv.Itoa(lastElementIdx+i) + "/"
 			// currentPath example: /spec/template/spec/containers/3/
-			patch, err := insertSubtree(value, currentPath)
+			patch, err := insertSubtree(log, value, currentPath)
 			if err != nil {
 				return nil, err
 			}
@@ -289,7 +293,7 @@ func applyOverlayToArrayOfSameTypes(resource, overlay []interface{}, path string
 }
 
 // Array of maps needs special handling as far as it can have anchors.
-func applyOverlayToArrayOfMaps(resource, overlay []interface{}, path string) ([][]byte, error) {
+func applyOverlayToArrayOfMaps(log log.Logger, resource, overlay []interface{}, path string) ([][]byte, error) {
 	var appliedPatches [][]byte
 
 	lastElementIdx := len(resource)
@@ -299,7 +303,7 @@ func applyOverlayToArrayOfMaps(resource, overlay []interface{}, path string) ([]
 
 		if len(anchors) > 0 {
 			// If we have anchors - choose corresponding resource element and mutate it
-			patches, err := applyOverlayWithAnchors(resource, overlayElement, path)
+			patches, err := applyOverlayWithAnchors(log, resource, overlayElement, path)
 			if err != nil {
 				return nil, err
 			}
@@ -309,7 +313,7 @@ func applyOverlayToArrayOfMaps(resource, overlay []interface{}, path string) ([]
 			for j, resourceElement := range resource {
 				currentPath := path + strconv.Itoa(j) + "/"
 				// currentPath example: /spec/template/spec/containers/3/
-				patches, err := applyOverlay(resourceElement, overlayElement, currentPath)
+				patches, err := applyOverlay(log, resourceElement, overlayElement, currentPath)
 				if err != nil {
 					return nil, err
 				}
@@ -319,7 +323,7 @@ func applyOverlayToArrayOfMaps(resource, overlay []interface{}, path string) ([]
 			// Overlay subtree has no anchors - insert new element
 			currentPath := path + strconv.Itoa(lastElementIdx+i) + "/"
 			// currentPath example: /spec/template/spec/containers/3/
-			patch, err := insertSubtree(overlayElement, currentPath)
+			patch, err := insertSubtree(log, overlayElement, currentPath)
 			if err != nil {
 				return nil, err
 			}
@@ -330,13 +334,13 @@ func applyOverlayToArrayOfMaps(resource, overlay []interface{}, path string) ([]
 	return appliedPatches, nil
 }
 
-func applyOverlayWithAnchors(resource []interface{}, overlay interface{}, path string) ([][]byte, error) {
+func applyOverlayWithAnchors(log log.Logger, resource []interface{}, overlay interface{}, path string) ([][]byte, error) {
 	var appliedPatches [][]byte
 
 	for i, resourceElement := range resource {
 		currentPath := path + strconv.Itoa(i) + "/"
 		// currentPath example: /spec/template/spec/containers/3/
-		patches, err := applyOverlay(resourceElement, overlay, currentPath)
+		patches, err := applyOverlay(log, resourceElement, overlay, currentPath)
 		if err != nil {
 			return nil, err
 		}
@@ -346,22 +350,22 @@ func applyOverlayWithAnchors(resource []interface{}, overlay interface{}, path s
 	return appliedPatches, nil
 }
 
-func insertSubtree(overlay interface{}, path string) ([]byte, error) {
-	return processSubtree(overlay, path, "add")
+func insertSubtree(log log.Logger, overlay interface{}, path string) ([]byte, error) {
+	return processSubtree(log, overlay, path, "add")
 }
 
-func replaceSubtree(overlay interface{}, path string) ([]byte, error) {
-	return processSubtree(overlay, path, "replace")
+func replaceSubtree(log log.Logger, overlay interface{}, path string) ([]byte, error) {
+	return processSubtree(log, overlay, path, "replace")
 }
 
-func processSubtree(overlay interface{}, path string, op string) ([]byte, error) {
+func processSubtree(log log.Logger, overlay interface{}, path string, op string) ([]byte, error) {
 
 	if len(path) > 1 && path[len(path)-1] == '/' {
 		path = path[:len(path)-1]
 	}
 
 	path = preparePath(path)
-	value := prepareJSONValue(overlay)
+	value := prepareJSONValue(log, overlay)
 	patchStr := fmt.Sprintf(`{ "op": "%s", "path": "%s", "value":%s }`, op, path, value)
 
 	// explicitly handle boolean type in annotation
@@ -395,13 +399,13 @@ func preparePath(path string) string {
 }
 
 // converts overlay to JSON string to be inserted into the JSON Patch
-func prepareJSONValue(overlay interface{}) string {
+func prepareJSONValue(log log.Logger, overlay interface{}) string {
 	var err error
 	// Need to remove anchors from the overlay struct
 	overlayWithoutAnchors := removeAnchorFromSubTree(overlay)
 	jsonOverlay, err := json.Marshal(overlayWithoutAnchors)
 	if err != nil || hasOnlyAnchors(overlay) {
-		glog.V(3).Info(err)
+		log.V(3).Info(err)
 		return ""
 	}
 
@@ -5,17 +5,17 @@ import (
 	"reflect"
 	"strconv"
 
-	"github.com/golang/glog"
 	"github.com/nirmata/kyverno/pkg/engine/anchor"
+	"github.com/nirmata/kyverno/pkg/engine/log"
 	"github.com/nirmata/kyverno/pkg/engine/validate"
 )
 
-func meetConditions(resource, overlay interface{}) (string, overlayError) {
-	return checkConditions(resource, overlay, "/")
+func meetConditions(log log.Logger, resource, overlay interface{}) (string, overlayError) {
+	return checkConditions(log, resource, overlay, "/")
 }
 
 // resource and overlay should be the same type
-func checkConditions(resource, overlay interface{}, path string) (string, overlayError) {
+func checkConditions(log log.Logger, resource, overlay interface{}, path string) (string, overlayError) {
 	// overlay has no anchor, return true
 	if !hasNestedAnchors(overlay) {
 		return "", overlayError{}
@@ -26,7 +26,7 @@ func checkConditions(resource, overlay interface{}, path string) (string, overla
 	// condition never be true in this case
 	if reflect.TypeOf(resource) != reflect.TypeOf(overlay) {
 		if hasNestedAnchors(overlay) {
-			glog.V(4).Infof("Found anchor on different types of element at path %s: overlay %T, resource %T", path, overlay, resource)
+			log.V(4).Infof("Found anchor on different types of element at path %s: overlay %T, resource %T", path, overlay, resource)
 			return path, newOverlayError(conditionFailure,
 				fmt.Sprintf("Found anchor on different types of element at path %s: overlay %T %v, resource %T %v", path, overlay, overlay, resource, resource))
 
@@ -37,28 +37,28 @@ func checkConditions(resource, overlay interface{}, path string) (string, overla
 	switch typedOverlay := overlay.(type) {
 	case map[string]interface{}:
 		typedResource := resource.(map[string]interface{})
-		return checkConditionOnMap(typedResource, typedOverlay, path)
+		return checkConditionOnMap(log, typedResource, typedOverlay, path)
 	case []interface{}:
 		typedResource := resource.([]interface{})
-		return checkConditionOnArray(typedResource, typedOverlay, path)
+		return checkConditionOnArray(log, typedResource, typedOverlay, path)
 	default:
 		// anchor on non map/array is invalid:
 		// - anchor defined on values
-		glog.Warningln("Found invalid conditional anchor: anchor defined on values")
+		log.Warning("Found invalid conditional anchor: anchor defined on values")
 		return "", overlayError{}
 	}
 }
 
-func checkConditionOnMap(resourceMap, overlayMap map[string]interface{}, path string) (string, overlayError) {
+func checkConditionOnMap(log log.Logger, resourceMap, overlayMap map[string]interface{}, path string) (string, overlayError) {
 	anchors, overlayWithoutAnchor := getAnchorAndElementsFromMap(overlayMap)
 
 	// validate resource with conditions
-	if newPath, err := validateConditionAnchorMap(resourceMap, anchors, path); !reflect.DeepEqual(err, overlayError{}) {
+	if newPath, err := validateConditionAnchorMap(log, resourceMap, anchors, path); !reflect.DeepEqual(err, overlayError{}) {
 		return newPath, err
 	}
 
 	// traverse overlay pattern to further validate conditions
-	if newPath, err := validateNonAnchorOverlayMap(resourceMap, overlayWithoutAnchor, path); !reflect.DeepEqual(err, overlayError{}) {
+	if newPath, err := validateNonAnchorOverlayMap(log, resourceMap, overlayWithoutAnchor, path); !reflect.DeepEqual(err, overlayError{}) {
 		return newPath, err
 	}
 
@@ -66,22 +66,22 @@ func checkConditionOnMap(resourceMap, overlayMap map[string]interface{}, path st
 	return "", overlayError{}
 }
 
-func checkConditionOnArray(resource, overlay []interface{}, path string) (string, overlayError) {
+func checkConditionOnArray(log log.Logger, resource, overlay []interface{}, path string) (string, overlayError) {
 	if 0 == len(overlay) {
-		glog.Infof("Mutate overlay pattern is empty, path %s", path)
+		log.Infof("Mutate overlay pattern is empty, path %s", path)
 		return "", overlayError{}
 	}
 
 	if reflect.TypeOf(resource[0]) != reflect.TypeOf(overlay[0]) {
-		glog.V(4).Infof("Overlay array and resource array have elements of different types: %T and %T", overlay[0], resource[0])
+		log.V(4).Infof("Overlay array and resource array have elements of different types: %T and %T", overlay[0], resource[0])
 		return path, newOverlayError(conditionFailure,
 			fmt.Sprintf("Overlay array and resource array have elements of different types: %T and %T", overlay[0], resource[0]))
 	}
 
-	return checkConditionsOnArrayOfSameTypes(resource, overlay, path)
+	return checkConditionsOnArrayOfSameTypes(log, resource, overlay, path)
 }
 
-func validateConditionAnchorMap(resourceMap, anchors map[string]interface{}, path string) (string, overlayError) {
+func validateConditionAnchorMap(log log.Logger, resourceMap, anchors map[string]interface{}, path string) (string, overlayError) {
 	for key, overlayValue := range anchors {
 		// skip if key does not have condition anchor
 		if !anchor.IsConditionAnchor(key) {
@@ -94,7 +94,7 @@ func validateConditionAnchorMap(resourceMap, anchors map[string]interface{}, pat
 		if resourceValue, ok := resourceMap[noAnchorKey]; ok {
 			// compare entire resourceValue block
 			// return immediately on err since condition fails on this block
-			if newPath, err := compareOverlay(resourceValue, overlayValue, curPath); !reflect.DeepEqual(err, overlayError{}) {
+			if newPath, err := compareOverlay(log, resourceValue, overlayValue, curPath); !reflect.DeepEqual(err, overlayError{}) {
 				return newPath, err
 			}
 		} else {
@@ -109,9 +109,9 @@ func validateConditionAnchorMap(resourceMap, anchors map[string]interface{}, pat
 // i.e. check if B1 == B2
 // overlay - (A): B1
 // resource - A: B2
-func compareOverlay(resource, overlay interface{}, path string) (string, overlayError) {
+func compareOverlay(log log.Logger, resource, overlay interface{}, path string) (string, overlayError) {
 	if reflect.TypeOf(resource) != reflect.TypeOf(overlay) {
-		glog.V(4).Infof("Found anchor on different types of element: overlay %T, resource %T", overlay, resource)
+		log.V(4).Infof("Found anchor on different types of element: overlay %T, resource %T", overlay, resource)
 		return path, newOverlayError(conditionFailure, fmt.Sprintf("Found anchor on different types of element: overlay %T, resource %T", overlay, resource))
 	}
 
@@ -125,7 +125,7 @@ func compareOverlay(resource, overlay interface{}, path string) (string, overlay
 			if !ok {
 				return curPath, newOverlayError(conditionFailure, fmt.Sprintf("Field %s is not present", noAnchorKey))
 			}
-			if newPath, err := compareOverlay(resourceVal, overlayVal, curPath); !reflect.DeepEqual(err, overlayError{}) {
+			if newPath, err := compareOverlay(log, resourceVal, overlayVal, curPath); !reflect.DeepEqual(err, overlayError{}) {
 				return newPath, err
 			}
 		}
@@ -133,14 +133,14 @@ func compareOverlay(resource, overlay interface{}, path string) (string, overlay
 		typedResource := resource.([]interface{})
 		for _, overlayElement := range typedOverlay {
 			for _, resourceElement := range typedResource {
-				if newPath, err := compareOverlay(resourceElement, overlayElement, path); !reflect.DeepEqual(err, overlayError{}) {
+				if newPath, err := compareOverlay(log, resourceElement, overlayElement, path); !reflect.DeepEqual(err, overlayError{}) {
 					return newPath, err
 				}
 			}
 		}
 	case string, float64, int, int64, bool, nil:
-		if !validate.ValidateValueWithPattern(resource, overlay) {
-			glog.V(4).Infof("Mutate rule: failed validating value %v with overlay %v", resource, overlay)
+		if !validate.ValidateValueWithPattern(log, resource, overlay) {
+			log.V(4).Infof("Mutate rule: failed validating value %v with overlay %v", resource, overlay)
 			return path, newOverlayError(conditionFailure, fmt.Sprintf("Failed validating value %v with overlay %v", resource, overlay))
 		}
 	default:
@@ -151,7 +151,7 @@ func compareOverlay(resource, overlay interface{}, path string) (string, overlay
 }
 
 // validateNonAnchorOverlayMap validate anchor condition in overlay block without anchor
-func validateNonAnchorOverlayMap(resourceMap, overlayWithoutAnchor map[string]interface{}, path string) (string, overlayError) {
+func validateNonAnchorOverlayMap(log log.Logger, resourceMap, overlayWithoutAnchor map[string]interface{}, path string) (string, overlayError) {
 	// validate resource map (anchors could exist in resource)
 	for key, overlayValue := range overlayWithoutAnchor {
 		curPath := path + key + "/"
@@ -165,21 +165,21 @@ func validateNonAnchorOverlayMap(resourceMap, overlayWithoutAnchor map[string]in
 				continue
 			}
 		}
-		if newPath, err := checkConditions(resourceValue, overlayValue, curPath); !reflect.DeepEqual(err, overlayError{}) {
+		if newPath, err := checkConditions(log, resourceValue, overlayValue, curPath); !reflect.DeepEqual(err, overlayError{}) {
 			return newPath, err
 		}
 	}
 	return "", overlayError{}
 }
 
-func checkConditionsOnArrayOfSameTypes(resource, overlay []interface{}, path string) (string, overlayError) {
+func checkConditionsOnArrayOfSameTypes(log log.Logger, resource, overlay []interface{}, path string) (string, overlayError) {
 	switch overlay[0].(type) {
 	case map[string]interface{}:
-		return checkConditionsOnArrayOfMaps(resource, overlay, path)
+		return checkConditionsOnArrayOfMaps(log, resource, overlay, path)
 	default:
 		for i, overlayElement := range overlay {
 			curPath := path + strconv.Itoa(i) + "/"
-			path, err := checkConditions(resource[i], overlayElement, curPath)
+			path, err := checkConditions(log, resource[i], overlayElement, curPath)
 			if !reflect.DeepEqual(err, overlayError{}) {
 				return path, err
 			}
@@ -188,14 +188,14 @@ func checkConditionsOnArrayOfSameTypes(resource, overlay []interface{}, path str
 	return "", overlayError{}
 }
 
-func checkConditionsOnArrayOfMaps(resource, overlay []interface{}, path string) (string, overlayError) {
+func checkConditionsOnArrayOfMaps(log log.Logger, resource, overlay []interface{}, path string) (string, overlayError) {
 	var newPath string
 	var err overlayError
 
 	for i, overlayElement := range overlay {
 		for _, resourceMap := range resource {
 			curPath := path + strconv.Itoa(i) + "/"
-			newPath, err = checkConditionOnMap(resourceMap.(map[string]interface{}), overlayElement.(map[string]interface{}), curPath)
+			newPath, err = checkConditionOnMap(log, resourceMap.(map[string]interface{}), overlayElement.(map[string]interface{}), curPath)
 			// when resource has multiple same blocks of the overlay block
 			// return true if there is one resource block meet the overlay pattern
 			// reference: TestMeetConditions_AtleastOneExist
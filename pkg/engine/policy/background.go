@@ -23,13 +23,16 @@ func ContainsUserInfo(policy kyverno.ClusterPolicy) error {
 		// - condition.key
 		// - condition.value
 		// - mutate.overlay
+		// - validate.message
 		// - validate.pattern
 		// - validate.anyPattern[*]
 		// variables to filter
 		// - request.userInfo*
+		// - request.roles, request.clusterRoles
+		// - request.operation
 		// - serviceAccountName
 		// - serviceAccountNamespace
-		filterVars := []string{"request.userInfo*", "serviceAccountName", "serviceAccountNamespace"}
+		filterVars := []string{"request.userInfo*", "request.roles", "request.clusterRoles", "request.operation", "serviceAccountName", "serviceAccountNamespace"}
 		for condIdx, condition := range rule.Conditions {
 			if err := variables.CheckVariables(condition.Key, filterVars, "/"); err != nil {
 				return fmt.Errorf("path: spec/rules[%d]/condition[%d]/key%s", idx, condIdx, err)
@@ -42,6 +45,9 @@ func ContainsUserInfo(policy kyverno.ClusterPolicy) error {
 		if err := variables.CheckVariables(rule.Mutation.Overlay, filterVars, "/"); err != nil {
 			return fmt.Errorf("path: spec/rules[%d]/mutate/overlay%s", idx, err)
 		}
+		if err := variables.CheckVariables(rule.Validation.Message, filterVars, "/"); err != nil {
+			return fmt.Errorf("path: spec/rules[%d]/validate/message%s", idx, err)
+		}
 		if err := variables.CheckVariables(rule.Validation.Pattern, filterVars, "/"); err != nil {
 			return fmt.Errorf("path: spec/rules[%d]/validate/pattern%s", idx, err)
 		}
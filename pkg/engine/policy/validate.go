@@ -1,15 +1,24 @@
 package policy
 
 import (
+	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/cosign"
 	"github.com/nirmata/kyverno/pkg/engine/anchor"
+	"github.com/nirmata/kyverno/pkg/engine/jmespath"
+	"github.com/nirmata/kyverno/pkg/engine/variables"
+	"github.com/nirmata/kyverno/pkg/engine/variables/operator"
+	"github.com/nirmata/kyverno/pkg/notary"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -21,11 +30,25 @@ func Validate(p kyverno.ClusterPolicy) error {
 	if path, err := validateUniqueRuleName(p); err != nil {
 		return fmt.Errorf("path: spec.%s: %v", path, err)
 	}
-	if p.Spec.Background == nil {
-		//skipped policy mutation default -> skip validation -> will not be processed for background processing
-		return nil
+	if p.Spec.FailurePolicy != "" && p.Spec.FailurePolicy != kyverno.FailurePolicyIgnore && p.Spec.FailurePolicy != kyverno.FailurePolicyFail {
+		return fmt.Errorf("path: spec.failurePolicy: invalid value '%s', must be %s or %s", p.Spec.FailurePolicy, kyverno.FailurePolicyIgnore, kyverno.FailurePolicyFail)
 	}
-	if *p.Spec.Background {
+	if p.Spec.GenerateExisting {
+		// the existing resources are processed in the background, there is no admission request
+		if err := ContainsUserInfo(p); err != nil {
+			return fmt.Errorf("userInfo is not allowed when generateExisting is true. Failure path %s ", err)
+		}
+		for i, rule := range p.Spec.Rules {
+			if !rule.HasGenerate() {
+				continue
+			}
+			if path, err := validateGenerationVariables(rule.Generation, withContextVariables(generateExistingVariables, rule)); err != nil {
+				return fmt.Errorf("path: spec.rules[%d].generate.%s: only request.object variables are allowed when generateExisting is true: %v", i, path, err)
+			}
+		}
+	}
+	// background is not set if the policy mutation default is skipped, the policy is not processed in the background
+	if p.Spec.Background != nil && *p.Spec.Background {
 		if err := ContainsUserInfo(p); err != nil {
 			// policy.spec.background -> "true"
 			// - cannot use variables with request.userInfo
@@ -44,6 +67,14 @@ func Validate(p kyverno.ClusterPolicy) error {
 		if path, err := validateResources(rule); err != nil {
 			return fmt.Errorf("path: spec.rules[%d].%s: %v", i, path, err)
 		}
+		// validate context entries
+		if path, err := validateContext(rule.Context); err != nil {
+			return fmt.Errorf("path: spec.rules[%d].context%s: %v", i, path, err)
+		}
+		// validate preconditions
+		if path, err := validateConditions(rule.Conditions); err != nil {
+			return fmt.Errorf("path: spec.rules[%d].preconditions%s: %v", i, path, err)
+		}
 		// validate rule types
 		// only one type of rule is allowed per rule
 		if err := validateRuleType(rule); err != nil {
@@ -65,14 +96,51 @@ func Validate(p kyverno.ClusterPolicy) error {
 		}
 		// Generation
 		if rule.HasGenerate() {
-			if path, err := validateGeneration(rule.Generation); err != nil {
+			if path, err := validateGenerationWithVariables(rule.Generation, withContextVariables(builtinVariables, rule)); err != nil {
 				return fmt.Errorf("path: spec.rules[%d].generate.%s.: %v", i, path, err)
 			}
 		}
+		// Image verification
+		if rule.HasVerifyImages() {
+			if path, err := validateVerifyImages(rule.VerifyImages); err != nil {
+				return fmt.Errorf("path: spec.rules[%d].verifyImages%s: %v", i, path, err)
+			}
+		}
+		// the variables must reference a built-in variable or a context entry of the rule
+		if path, err := validateRuleVariables(rule); err != nil {
+			return fmt.Errorf("path: spec.rules[%d].%s: %v", i, path, err)
+		}
 	}
 	return nil
 }
 
+// validateConditions checks the conditions have a key and an operator with a handler,
+// a condition with an unsupported operator would never be satisfied
+func validateConditions(conditions []kyverno.Condition) (string, error) {
+	for i, condition := range conditions {
+		if condition.Key == nil {
+			return fmt.Sprintf("[%d].key", i), fmt.Errorf("key is required")
+		}
+		if !supportedOperator(condition.Operator) {
+			supported := make([]string, len(operator.SupportedOperators))
+			for j, op := range operator.SupportedOperators {
+				supported[j] = string(op)
+			}
+			return fmt.Sprintf("[%d].operator", i), fmt.Errorf("unsupported operator '%s', must be one of %s", condition.Operator, strings.Join(supported, ", "))
+		}
+	}
+	return "", nil
+}
+
+func supportedOperator(op kyverno.ConditionOperator) bool {
+	for _, supported := range operator.SupportedOperators {
+		if op == supported {
+			return true
+		}
+	}
+	return false
+}
+
 func validateResources(rule kyverno.Rule) (string, error) {
 	// validate userInfo in match and exclude
 	if path, err := validateUserInfo(rule); err != nil {
@@ -105,7 +173,7 @@ func validateUniqueRuleName(p kyverno.ClusterPolicy) (string, error) {
 
 // validateRuleType checks only one type of rule is defined per rule
 func validateRuleType(r kyverno.Rule) error {
-	ruleTypes := []bool{r.HasMutate(), r.HasValidate(), r.HasGenerate()}
+	ruleTypes := []bool{r.HasMutate(), r.HasValidate(), r.HasGenerate(), r.HasVerifyImages()}
 
 	operationCount := func() int {
 		count := 0
@@ -118,13 +186,96 @@ func validateRuleType(r kyverno.Rule) error {
 	}()
 
 	if operationCount == 0 {
-		return fmt.Errorf("no operation defined in the rule '%s'.(supported operations: mutation,validation,generation)", r.Name)
+		return fmt.Errorf("no operation defined in the rule '%s'.(supported operations: mutation,validation,generation,verifyImages)", r.Name)
 	} else if operationCount != 1 {
 		return fmt.Errorf("multiple operations defined in the rule '%s', only one type of operation is allowed per rule", r.Name)
 	}
 	return nil
 }
 
+// validateVerifyImages checks the image reference patterns are set and each entry has a valid key
+// or the Fulcio roots, the subject and the Rekor public key of keyless signatures
+func validateVerifyImages(verifications []kyverno.ImageVerification) (string, error) {
+	for i, v := range verifications {
+		if v.Image == "" {
+			return fmt.Sprintf("[%d].image", i), fmt.Errorf("image is required")
+		}
+		for j, attestation := range v.Attestations {
+			if attestation.PredicateType == "" {
+				return fmt.Sprintf("[%d].attestations[%d].predicateType", i, j), fmt.Errorf("predicateType is required")
+			}
+			if path, err := validateConditions(attestation.Conditions); err != nil {
+				return fmt.Sprintf("[%d].attestations[%d].conditions%s", i, j, path), err
+			}
+		}
+		if v.Notary != nil {
+			if path, err := validateNotary(v); err != nil {
+				return fmt.Sprintf("[%d]%s", i, path), err
+			}
+			continue
+		}
+		if v.Key != "" {
+			if v.Roots != "" || v.Subject != "" || v.Issuer != "" || v.Rekor != nil {
+				return fmt.Sprintf("[%d]", i), fmt.Errorf("roots, subject, issuer and rekor are only allowed for keyless signatures, without key")
+			}
+			if _, err := cosign.ParsePublicKey(v.Key); err != nil {
+				return fmt.Sprintf("[%d].key", i), err
+			}
+			continue
+		}
+		if path, err := validateKeyless(v); err != nil {
+			return fmt.Sprintf("[%d]%s", i, path), err
+		}
+	}
+	return "", nil
+}
+
+// validateNotary checks the trust policy of the Notary v2 signatures, the cosign fields are not allowed
+func validateNotary(v kyverno.ImageVerification) (string, error) {
+	if v.Key != "" || v.Roots != "" || v.Subject != "" || v.Issuer != "" || v.Rekor != nil {
+		return "", fmt.Errorf("key, roots, subject, issuer and rekor are only allowed for cosign signatures, without notary")
+	}
+	if len(v.Attestations) != 0 {
+		return ".attestations", fmt.Errorf("attestations are only supported for cosign signatures")
+	}
+	if _, err := cosign.ParseCertificates(v.Notary.TrustStore); err != nil {
+		return ".notary.trustStore", err
+	}
+	if _, err := notary.ParseTrustedIdentities(v.Notary.TrustedIdentities); err != nil {
+		return ".notary.trustedIdentities", err
+	}
+	switch v.Notary.SignatureVerification {
+	case "", notary.LevelStrict, notary.LevelPermissive, notary.LevelAudit:
+	default:
+		return ".notary.signatureVerification", fmt.Errorf("invalid signature verification level %s, must be one of %s, %s, %s",
+			v.Notary.SignatureVerification, notary.LevelStrict, notary.LevelPermissive, notary.LevelAudit)
+	}
+	return "", nil
+}
+
+func validateKeyless(v kyverno.ImageVerification) (string, error) {
+	if v.Roots == "" {
+		return "", fmt.Errorf("a key or the roots of keyless signatures are required")
+	}
+	if _, err := cosign.ParseCertificates(v.Roots); err != nil {
+		return ".roots", err
+	}
+	// any identity of the roots could sign the images
+	if v.Subject == "" {
+		return ".subject", fmt.Errorf("subject is required for keyless signatures")
+	}
+	if v.Rekor == nil {
+		return ".rekor", fmt.Errorf("rekor is required for keyless signatures")
+	}
+	if _, err := cosign.ParsePublicKey(v.Rekor.PubKey); err != nil {
+		return ".rekor.pubKey", err
+	}
+	if v.Rekor.URL != "" && !strings.HasPrefix(v.Rekor.URL, "https://") {
+		return ".rekor.url", fmt.Errorf("invalid URL %s, the URL must use https", v.Rekor.URL)
+	}
+	return "", nil
+}
+
 // validateResourceDescription checks if all necesarry fields are present and have values. Also checks a Selector.
 // field type is checked through openapi
 // Returns error if
@@ -298,7 +449,18 @@ func validateOverlayPattern(v kyverno.Validation) error {
 
 // Validate returns error if generator is configured incompletely
 func validateGeneration(gen kyverno.Generation) (string, error) {
+	return validateGenerationWithVariables(gen, builtinVariables)
+}
 
+// validateGenerationWithVariables validates the generate rule,
+// the variables used in the rule must reference one of the allowed variables
+func validateGenerationWithVariables(gen kyverno.Generation, allowedVariables []string) (string, error) {
+	if path, err := validateDeletionPolicy(gen.DeletionPolicy); err != nil {
+		return path, err
+	}
+	if gen.CloneList != nil {
+		return validateCloneListGeneration(gen, allowedVariables)
+	}
 	if gen.Data == nil && gen.Clone == (kyverno.CloneFrom{}) {
 		return "", fmt.Errorf("clone or data are required")
 	}
@@ -313,11 +475,17 @@ func validateGeneration(gen kyverno.Generation) (string, error) {
 	if gen.Kind == "" {
 		return "kind", fmt.Errorf("kind cannot be empty")
 	}
+	if path, err := validateTargetNamespaces(gen); err != nil {
+		return path, err
+	}
 	if !reflect.DeepEqual(gen.Clone, kyverno.CloneFrom{}) {
 		if path, err := validateClone(gen.Clone); err != nil {
 			return fmt.Sprintf("clone.%s", path), err
 		}
 	}
+	if path, err := validateGenerationVariables(gen, allowedVariables); err != nil {
+		return path, err
+	}
 	if gen.Data != nil {
 		//TODO: is this required ?? as anchors can only be on pattern and not resource
 		// we can add this check by not sure if its needed here
@@ -328,6 +496,155 @@ func validateGeneration(gen kyverno.Generation) (string, error) {
 	return "", nil
 }
 
+// validateCloneListGeneration validates a generate rule with cloneList
+// the kind and name of the generated resources are the ones of the source resources
+func validateCloneListGeneration(gen kyverno.Generation, allowedVariables []string) (string, error) {
+	if gen.Data != nil || gen.Clone != (kyverno.CloneFrom{}) {
+		return "", fmt.Errorf("only one operation allowed per generate rule(data, clone or cloneList)")
+	}
+	if gen.Name != "" {
+		return "name", fmt.Errorf("name cannot be used with cloneList, the names of the source resources are used")
+	}
+	if gen.Kind != "" {
+		return "kind", fmt.Errorf("kind cannot be used with cloneList, the kinds are specified in cloneList")
+	}
+	if gen.Namespace == "" && len(gen.Namespaces) == 0 && gen.NamespaceSelector == nil {
+		return "namespace", fmt.Errorf("namespace, namespaces or namespaceSelector is required with cloneList")
+	}
+	if path, err := validateTargetNamespaces(gen); err != nil {
+		return path, err
+	}
+	if path, err := validateCloneList(*gen.CloneList); err != nil {
+		return fmt.Sprintf("cloneList.%s", path), err
+	}
+	return validateGenerationVariables(gen, allowedVariables)
+}
+
+func validateCloneList(c kyverno.CloneList) (string, error) {
+	if c.Namespace == "" {
+		return "namespace", fmt.Errorf("namespace cannot be empty")
+	}
+	if len(c.Kinds) == 0 {
+		return "kinds", fmt.Errorf("kinds cannot be empty")
+	}
+	for i, kind := range c.Kinds {
+		if kind == "" {
+			return fmt.Sprintf("kinds[%d]", i), fmt.Errorf("kind cannot be empty")
+		}
+	}
+	// a selector is required, to not clone every resource of the namespace by mistake
+	if c.Selector == nil {
+		return "selector", fmt.Errorf("selector cannot be empty")
+	}
+	if _, err := metav1.LabelSelectorAsSelector(c.Selector); err != nil {
+		return "selector", err
+	}
+	return "", nil
+}
+
+// builtinVariables are the variables kyverno adds to the context
+// - request.object: the trigger resource
+// - request.userInfo, request.roles, request.clusterRoles: the requester of the trigger resource
+// - request.operation, request.namespace: the operation and the namespace of the admission request
+// - serviceAccountName, serviceAccountNamespace
+// - images: the parsed images of the trigger resource
+// - globalContext: the context entries shared by all policies
+var builtinVariables = []string{"request.object", "request.userInfo", "request.roles", "request.clusterRoles", "request.operation", "request.namespace", "serviceAccountName", "serviceAccountNamespace", "images", "globalContext"}
+
+// generateExistingVariables are the variables available to generate rules applied on existing resources
+var generateExistingVariables = []string{"request.object", "images", "globalContext"}
+
+// validateRuleVariables checks the variables of the rule reference a built-in variable or a context entry,
+// a typo like {{request.objct.metadata.name}} would otherwise fail when the rule is applied
+func validateRuleVariables(rule kyverno.Rule) (string, error) {
+	// the variables of the attestation conditions reference the attested predicate, not the request
+	rule = *rule.DeepCopy()
+	for i := range rule.VerifyImages {
+		for j := range rule.VerifyImages[i].Attestations {
+			rule.VerifyImages[i].Attestations[j].Conditions = nil
+		}
+	}
+	raw, err := json.Marshal(rule)
+	if err != nil {
+		return "", err
+	}
+	var data interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return "", err
+	}
+	path, err := variables.CheckVariablePaths(data, withContextVariables(builtinVariables, rule), "")
+	if err != nil {
+		return strings.Replace(path, "/", ".", -1), err
+	}
+	return "", nil
+}
+
+// withContextVariables returns the variables along with the context entries of the rule
+func withContextVariables(vars []string, rule kyverno.Rule) []string {
+	allowed := make([]string, 0, len(vars)+len(rule.Context))
+	allowed = append(allowed, vars...)
+	for _, entry := range rule.Context {
+		allowed = append(allowed, entry.Name)
+	}
+	return allowed
+}
+
+// validateGenerationVariables checks the variables used in the generate rule reference one of the allowed variables
+func validateGenerationVariables(gen kyverno.Generation, allowedVariables []string) (string, error) {
+	attributes := map[string]interface{}{
+		"name":      gen.Name,
+		"namespace": gen.Namespace,
+	}
+	for i, ns := range gen.Namespaces {
+		attributes[fmt.Sprintf("namespaces[%d]", i)] = ns
+	}
+	if gen.Clone != (kyverno.CloneFrom{}) {
+		attributes["clone.name"] = gen.Clone.Name
+		attributes["clone.namespace"] = gen.Clone.Namespace
+	}
+	if gen.CloneList != nil {
+		attributes["cloneList.namespace"] = gen.CloneList.Namespace
+	}
+	paths := make([]string, 0, len(attributes))
+	for path := range attributes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if _, err := variables.CheckVariablePaths(attributes[path], allowedVariables, "/"); err != nil {
+			return path, err
+		}
+	}
+	if gen.Data != nil {
+		if path, err := variables.CheckVariablePaths(gen.Data, allowedVariables, "/"); err != nil {
+			return fmt.Sprintf("data%s", path), err
+		}
+	}
+	return "", nil
+}
+
+func validateDeletionPolicy(deletionPolicy kyverno.DeletionPolicy) (string, error) {
+	switch deletionPolicy {
+	case "", kyverno.Delete, kyverno.Orphan, kyverno.OrphanAndLabel:
+		return "", nil
+	default:
+		return "deletionPolicy", fmt.Errorf("unsupported deletionPolicy %s, must be one of %s, %s or %s", deletionPolicy, kyverno.Delete, kyverno.Orphan, kyverno.OrphanAndLabel)
+	}
+}
+
+// validateTargetNamespaces checks that namespace is not combined with namespaces or namespaceSelector
+func validateTargetNamespaces(gen kyverno.Generation) (string, error) {
+	if gen.Namespace != "" && (len(gen.Namespaces) != 0 || gen.NamespaceSelector != nil) {
+		return "namespace", fmt.Errorf("namespace cannot be used with namespaces or namespaceSelector")
+	}
+	if gen.NamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(gen.NamespaceSelector); err != nil {
+			return "namespaceSelector", err
+		}
+	}
+	return "", nil
+}
+
 func validateClone(c kyverno.CloneFrom) (string, error) {
 	if c.Name == "" {
 		return "name", fmt.Errorf("name cannot be empty")
@@ -413,3 +730,133 @@ func checkAnchors(key string, supportedAnchors []anchor.IsAnchor) bool {
 	}
 	return false
 }
+
+// contextEntryName is the format of the context entry names, the name is used as the root of the variables
+var contextEntryName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedContextNames are the names used by the variables kyverno adds to the context
+var reservedContextNames = []string{"request", "serviceAccountName", "serviceAccountNamespace", "images", "globalContext"}
+
+// validateContext checks the context entries of a rule
+// - name is required, unique and can be used as a variable
+// - one source of data is required
+func validateContext(entries []kyverno.ContextEntry) (string, error) {
+	var names []string
+	for i, entry := range entries {
+		if entry.Name == "" {
+			return fmt.Sprintf("[%d].name", i), fmt.Errorf("name cannot be empty")
+		}
+		if !contextEntryName.MatchString(entry.Name) {
+			return fmt.Sprintf("[%d].name", i), fmt.Errorf("invalid name '%s', must start with a letter or '_' and contain only letters, digits and '_'", entry.Name)
+		}
+		if containString(reservedContextNames, entry.Name) {
+			return fmt.Sprintf("[%d].name", i), fmt.Errorf("name '%s' is reserved", entry.Name)
+		}
+		if containString(names, entry.Name) {
+			return fmt.Sprintf("[%d].name", i), fmt.Errorf("duplicate name '%s'", entry.Name)
+		}
+		names = append(names, entry.Name)
+		sources := 0
+		for _, set := range []bool{entry.ConfigMap != nil, entry.APICall != nil, entry.ImageRegistry != nil, entry.Service != nil} {
+			if set {
+				sources++
+			}
+		}
+		if sources == 0 {
+			return fmt.Sprintf("[%d]", i), fmt.Errorf("configMap, apiCall, imageRegistry or service is required")
+		}
+		if sources > 1 {
+			return fmt.Sprintf("[%d]", i), fmt.Errorf("only one of configMap, apiCall, imageRegistry or service is allowed per context entry")
+		}
+		if entry.ConfigMap != nil {
+			if entry.ConfigMap.Name == "" {
+				return fmt.Sprintf("[%d].configMap.name", i), fmt.Errorf("name cannot be empty")
+			}
+			if entry.ConfigMap.Namespace == "" {
+				return fmt.Sprintf("[%d].configMap.namespace", i), fmt.Errorf("namespace cannot be empty")
+			}
+		}
+		if entry.APICall != nil {
+			if path, err := validateAPICall(*entry.APICall); err != nil {
+				return fmt.Sprintf("[%d].apiCall.%s", i, path), err
+			}
+		}
+		if entry.ImageRegistry != nil {
+			if entry.ImageRegistry.Reference == "" {
+				return fmt.Sprintf("[%d].imageRegistry.reference", i), fmt.Errorf("reference cannot be empty")
+			}
+			if err := validateJMESPath(entry.ImageRegistry.JMESPath); err != nil {
+				return fmt.Sprintf("[%d].imageRegistry.jmesPath", i), err
+			}
+		}
+		if entry.Service != nil {
+			if path, err := validateServiceCall(*entry.Service); err != nil {
+				return fmt.Sprintf("[%d].service.%s", i, path), err
+			}
+		}
+	}
+	return "", nil
+}
+
+// validateServiceCall checks the service is called with https,
+// the durations and the CA bundle can be parsed and the Secret key is set
+func validateServiceCall(service kyverno.ServiceCall) (string, error) {
+	if service.URL == "" {
+		return "url", fmt.Errorf("url cannot be empty")
+	}
+	if !strings.HasPrefix(service.URL, "https://") {
+		return "url", fmt.Errorf("url must use https, e.g. https://cmdb.corp.com/apps")
+	}
+	if service.CABundle != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(service.CABundle)) {
+		return "caBundle", fmt.Errorf("caBundle must contain a PEM encoded certificate")
+	}
+	if service.AuthSecret != nil {
+		if service.AuthSecret.Name == "" {
+			return "authSecret.name", fmt.Errorf("name cannot be empty")
+		}
+		if service.AuthSecret.Namespace == "" {
+			return "authSecret.namespace", fmt.Errorf("namespace cannot be empty")
+		}
+		if service.AuthSecret.Key == "" {
+			return "authSecret.key", fmt.Errorf("key cannot be empty")
+		}
+	}
+	for _, d := range []struct{ path, duration string }{{"timeout", service.Timeout}, {"ttl", service.TTL}} {
+		if d.duration == "" {
+			continue
+		}
+		if parsed, err := time.ParseDuration(d.duration); err != nil || parsed < 0 {
+			return d.path, fmt.Errorf("invalid duration '%s', e.g. 30s or 5m", d.duration)
+		}
+	}
+	if err := validateJMESPath(service.JMESPath); err != nil {
+		return "jmesPath", err
+	}
+	return "", nil
+}
+
+// validateAPICall checks the path is an absolute path of the API server
+// and the JMESPath is valid
+func validateAPICall(apiCall kyverno.APICall) (string, error) {
+	if apiCall.URLPath == "" {
+		return "urlPath", fmt.Errorf("urlPath cannot be empty")
+	}
+	if !strings.HasPrefix(apiCall.URLPath, "/") {
+		return "urlPath", fmt.Errorf("urlPath must be an absolute path, e.g. /api/v1/namespaces")
+	}
+	if err := validateJMESPath(apiCall.JMESPath); err != nil {
+		return "jmesPath", err
+	}
+	return "", nil
+}
+
+// validateJMESPath checks the JMESPath can be compiled, if it does not use variables
+func validateJMESPath(query string) error {
+	if query == "" || strings.Contains(query, "{{") {
+		return nil
+	}
+	if _, err := jmespath.Compile(query); err != nil {
+		return fmt.Errorf("invalid JMESPath: %v", err)
+	}
+	return nil
+}
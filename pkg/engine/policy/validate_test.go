@@ -1,7 +1,13 @@
 package policy
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
+	"math/big"
 	"testing"
 
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
@@ -973,6 +979,384 @@ func Test_Validate_Generate_HasAnchors(t *testing.T) {
 	}
 }
 
+func Test_Validate_Generate_TargetNamespaces(t *testing.T) {
+	var generate kyverno.Generation
+	rawGenerate := []byte(`
+	{
+		"kind": "Secret",
+		"name": "ca-cert",
+		"namespaceSelector": {
+			"matchLabels": {
+				"cert-manager": "enabled"
+			}
+		},
+		"clone": {
+			"namespace": "default",
+			"name": "ca-cert"
+		}
+	}`)
+	err := json.Unmarshal(rawGenerate, &generate)
+	assert.NilError(t, err)
+	_, err = validateGeneration(generate)
+	assert.NilError(t, err)
+
+	rawGenerate = []byte(`
+	{
+		"kind": "Secret",
+		"name": "ca-cert",
+		"namespace": "default",
+		"namespaces": ["dev", "prod"],
+		"clone": {
+			"namespace": "default",
+			"name": "ca-cert"
+		}
+	}`)
+	generate = kyverno.Generation{}
+	err = json.Unmarshal(rawGenerate, &generate)
+	assert.NilError(t, err)
+	path, err := validateGeneration(generate)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "namespace")
+}
+
+func Test_Validate_Generate_Variables(t *testing.T) {
+	var generate kyverno.Generation
+	rawGenerate := []byte(`
+	{
+		"kind": "RoleBinding",
+		"name": "{{request.object.metadata.annotations.owner}}-admin",
+		"namespace": "{{request.object.metadata.name}}",
+		"data": {
+			"subjects": [
+				{
+					"kind": "User",
+					"name": "{{request.object.metadata.annotations.owner}}"
+				}
+			]
+		}
+	}`)
+	err := json.Unmarshal(rawGenerate, &generate)
+	assert.NilError(t, err)
+	_, err = validateGeneration(generate)
+	assert.NilError(t, err)
+
+	rawGenerate = []byte(`
+	{
+		"kind": "RoleBinding",
+		"name": "{{request.object.metadata.name}}",
+		"namespace": "default",
+		"data": {
+			"subjects": [
+				{
+					"kind": "User",
+					"name": "{{request.owner}}"
+				}
+			]
+		}
+	}`)
+	generate = kyverno.Generation{}
+	err = json.Unmarshal(rawGenerate, &generate)
+	assert.NilError(t, err)
+	path, err := validateGeneration(generate)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "data/subjects/0/name")
+
+	rawGenerate = []byte(`
+	{
+		"kind": "RoleBinding",
+		"name": "{{request.object.metadata.[name}}",
+		"namespace": "default",
+		"data": {}
+	}`)
+	generate = kyverno.Generation{}
+	err = json.Unmarshal(rawGenerate, &generate)
+	assert.NilError(t, err)
+	path, err = validateGeneration(generate)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "name")
+}
+
+func Test_Validate_Generate_CloneList(t *testing.T) {
+	var generate kyverno.Generation
+	rawGenerate := []byte(`
+	{
+		"namespace": "{{request.object.metadata.name}}",
+		"cloneList": {
+			"namespace": "default",
+			"kinds": ["Secret", "ConfigMap"],
+			"selector": {
+				"matchLabels": {
+					"sync": "true"
+				}
+			}
+		}
+	}`)
+	err := json.Unmarshal(rawGenerate, &generate)
+	assert.NilError(t, err)
+	_, err = validateGeneration(generate)
+	assert.NilError(t, err)
+
+	rawGenerate = []byte(`
+	{
+		"kind": "Secret",
+		"namespace": "{{request.object.metadata.name}}",
+		"cloneList": {
+			"namespace": "default",
+			"kinds": ["Secret"],
+			"selector": {
+				"matchLabels": {
+					"sync": "true"
+				}
+			}
+		}
+	}`)
+	generate = kyverno.Generation{}
+	err = json.Unmarshal(rawGenerate, &generate)
+	assert.NilError(t, err)
+	path, err := validateGeneration(generate)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "kind")
+
+	rawGenerate = []byte(`
+	{
+		"namespace": "{{request.object.metadata.name}}",
+		"cloneList": {
+			"namespace": "default",
+			"kinds": ["Secret"]
+		}
+	}`)
+	generate = kyverno.Generation{}
+	err = json.Unmarshal(rawGenerate, &generate)
+	assert.NilError(t, err)
+	path, err = validateGeneration(generate)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "cloneList.selector")
+}
+
+func Test_Validate_Generate_DeletionPolicy(t *testing.T) {
+	var generate kyverno.Generation
+	rawGenerate := []byte(`
+	{
+		"kind": "NetworkPolicy",
+		"name": "deny-all-traffic",
+		"namespace": "{{request.object.metadata.name}}",
+		"deletionPolicy": "OrphanAndLabel",
+		"data": {
+			"spec": {
+				"podSelector": {}
+			}
+		}
+	}`)
+	err := json.Unmarshal(rawGenerate, &generate)
+	assert.NilError(t, err)
+	_, err = validateGeneration(generate)
+	assert.NilError(t, err)
+
+	generate.DeletionPolicy = "Retain"
+	path, err := validateGeneration(generate)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "deletionPolicy")
+}
+
+func Test_Validate_GenerateExisting_UserInfo(t *testing.T) {
+	rawPolicy := []byte(`
+	{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+			"name": "default-deny"
+		},
+		"spec": {
+			"generateExisting": true,
+			"rules": [
+				{
+					"name": "default-deny",
+					"match": {
+						"resources": {
+							"kinds": ["Namespace"]
+						}
+					},
+					"generate": {
+						"kind": "NetworkPolicy",
+						"name": "default-deny",
+						"namespace": "{{request.object.metadata.name}}",
+						"data": {
+							"metadata": {
+								"labels": {
+									"owner": "{{request.userInfo.username}}"
+								}
+							},
+							"spec": {
+								"podSelector": {}
+							}
+						}
+					}
+				}
+			]
+		}
+	}`)
+	var policy kyverno.ClusterPolicy
+	err := json.Unmarshal(rawPolicy, &policy)
+	assert.NilError(t, err)
+	err = Validate(policy)
+	assert.Assert(t, err != nil)
+}
+
+func Test_Validate_Context(t *testing.T) {
+	entries := []kyverno.ContextEntry{
+		{
+			Name:      "registries",
+			ConfigMap: &kyverno.ConfigMapReference{Name: "allowed-registries", Namespace: "default"},
+		},
+	}
+	_, err := validateContext(entries)
+	assert.NilError(t, err)
+
+	// the name must be a valid variable name
+	entries[0].Name = "allowed-registries"
+	path, err := validateContext(entries)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "[0].name")
+
+	// the name cannot override the request variables
+	entries[0].Name = "request"
+	_, err = validateContext(entries)
+	assert.Assert(t, err != nil)
+
+	// the names must be unique
+	entries[0].Name = "registries"
+	entries = append(entries, entries[0])
+	path, err = validateContext(entries)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "[1].name")
+
+	// the ConfigMap namespace is required
+	entries = []kyverno.ContextEntry{
+		{
+			Name:      "registries",
+			ConfigMap: &kyverno.ConfigMapReference{Name: "allowed-registries"},
+		},
+	}
+	path, err = validateContext(entries)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "[0].configMap.namespace")
+
+	// only one source of data per entry
+	entries[0].ConfigMap.Namespace = "default"
+	entries[0].APICall = &kyverno.APICall{URLPath: "/api/v1/namespaces"}
+	path, err = validateContext(entries)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "[0]")
+
+	// the API call path must be absolute
+	entries[0].ConfigMap = nil
+	_, err = validateContext(entries)
+	assert.NilError(t, err)
+	entries[0].APICall.URLPath = "api/v1/namespaces"
+	path, err = validateContext(entries)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "[0].apiCall.urlPath")
+
+	// the JMESPath must be valid
+	entries[0].APICall = &kyverno.APICall{URLPath: "/api/v1/namespaces", JMESPath: "items[?"}
+	path, err = validateContext(entries)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "[0].apiCall.jmesPath")
+
+	// the image reference is required
+	entries[0].APICall = nil
+	entries[0].ImageRegistry = &kyverno.ImageRegistry{}
+	path, err = validateContext(entries)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "[0].imageRegistry.reference")
+	entries[0].ImageRegistry.Reference = "{{request.object.spec.containers[0].image}}"
+	_, err = validateContext(entries)
+	assert.NilError(t, err)
+
+	// the service must be called with https
+	entries[0].ImageRegistry = nil
+	entries[0].Service = &kyverno.ServiceCall{URL: "http://cmdb.corp.com/apps/{{request.object.metadata.name}}"}
+	path, err = validateContext(entries)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "[0].service.url")
+	entries[0].Service.URL = "https://cmdb.corp.com/apps/{{request.object.metadata.name}}"
+	_, err = validateContext(entries)
+	assert.NilError(t, err)
+
+	// the durations must be valid
+	entries[0].Service.TTL = "5 minutes"
+	path, err = validateContext(entries)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "[0].service.ttl")
+
+	// the Secret key is required
+	entries[0].Service.TTL = "5m"
+	entries[0].Service.AuthSecret = &kyverno.SecretKeyReference{Name: "cmdb", Namespace: "kyverno"}
+	path, err = validateContext(entries)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "[0].service.authSecret.key")
+
+	// the CA bundle must contain a certificate
+	entries[0].Service.AuthSecret.Key = "token"
+	entries[0].Service.CABundle = "not a certificate"
+	path, err = validateContext(entries)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, path, "[0].service.caBundle")
+}
+
+func Test_Validate_Generate_ContextVariables(t *testing.T) {
+	rawPolicy := []byte(`
+	{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+			"name": "default-quota"
+		},
+		"spec": {
+			"background": false,
+			"rules": [
+				{
+					"name": "default-quota",
+					"context": [
+						{
+							"name": "quota",
+							"configMap": {
+								"name": "default-quota",
+								"namespace": "default"
+							}
+						}
+					],
+					"match": {
+						"resources": {
+							"kinds": ["Namespace"]
+						}
+					},
+					"generate": {
+						"kind": "ResourceQuota",
+						"name": "default-quota",
+						"namespace": "{{request.object.metadata.name}}",
+						"data": {
+							"spec": {
+								"hard": {
+									"pods": "{{quota.data.pods}}"
+								}
+							}
+						}
+					}
+				}
+			]
+		}
+	}`)
+	var policy kyverno.ClusterPolicy
+	err := json.Unmarshal(rawPolicy, &policy)
+	assert.NilError(t, err)
+	assert.NilError(t, Validate(policy))
+
+	// variables must reference a context entry of the rule
+	policy.Spec.Rules[0].Context = nil
+	assert.Assert(t, Validate(policy) != nil)
+}
+
 func Test_Validate_ErrorFormat(t *testing.T) {
 	rawPolicy := []byte(`
 	{
@@ -1359,6 +1743,42 @@ func Test_BackGroundUserInfo_mutate_overlay2(t *testing.T) {
 	}
 }
 
+func Test_BackGroundUserInfo_validate_message_operation(t *testing.T) {
+	rawPolicy := []byte(`
+	{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "disallow-updates"
+		},
+		"spec": {
+		  "rules": [
+			{
+			  "name": "validate-message",
+			  "validate": {
+				"message": "{{request.operation}} is not allowed in {{request.namespace}}",
+				"pattern": {
+				  "metadata": {
+					"name": "*"
+				  }
+				}
+			  }
+			}
+		  ]
+		}
+	  }
+	`)
+	var policy *kyverno.ClusterPolicy
+	err := json.Unmarshal(rawPolicy, &policy)
+	assert.NilError(t, err)
+
+	// the existing resources have a namespace but no operation
+	err = ContainsUserInfo(*policy)
+	assert.Error(t, err, "path: spec/rules[0]/validate/message/{{request.operation}} is not allowed in {{request.namespace}}")
+	policy.Spec.Rules[0].Validation.Message = "not allowed in {{request.namespace}}"
+	assert.NilError(t, ContainsUserInfo(*policy))
+}
+
 func Test_BackGroundUserInfo_validate_pattern(t *testing.T) {
 	var err error
 	rawPolicy := []byte(`
@@ -1510,3 +1930,316 @@ func Test_BackGroundUserInfo_validate_anyPattern_serviceAccount(t *testing.T) {
 		t.Error("Incorrect Path")
 	}
 }
+
+func Test_BackGroundUserInfo_validate_message_roles(t *testing.T) {
+	rawPolicy := []byte(`
+	{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "disallow-root-user"
+		},
+		"spec": {
+		  "rules": [
+			{
+			  "name": "validate.message",
+			  "validate": {
+				"message": "{{request.roles}} cannot run as root",
+				"pattern": {
+				  "metadata": {
+					"name": "{{request.object.metadata.name}}"
+				  }
+				}
+			  }
+			}
+		  ]
+		}
+	  }	`)
+	var policy *kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal(rawPolicy, &policy))
+	err := ContainsUserInfo(*policy)
+	assert.Assert(t, err != nil)
+	assert.Equal(t, err.Error(), "path: spec/rules[0]/validate/message/{{request.roles}} cannot run as root")
+
+	// the resource variables are allowed in background mode
+	policy.Spec.Rules[0].Validation.Message = "{{request.object.metadata.name}} cannot run as root"
+	assert.NilError(t, ContainsUserInfo(*policy))
+}
+
+func Test_Validate_Rule_Variables(t *testing.T) {
+	rawPolicy := []byte(`
+	{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "require-owner"
+		},
+		"spec": {
+		  "background": false,
+		  "rules": [
+			{
+			  "name": "check-owner",
+			  "match": {
+				"resources": {
+				  "kinds": [
+					"Pod"
+				  ]
+				}
+			  },
+			  "context": [
+				{
+				  "name": "owners",
+				  "configMap": {
+					"name": "owners",
+					"namespace": "default"
+				  }
+				}
+			  ],
+			  "validate": {
+				"message": "{{request.object.metadata.name}} must be owned by {{ join(', ', keys(owners.data)) }}",
+				"pattern": {
+				  "metadata": {
+					"labels": {
+					  "owner": "{{request.object.metadata.name}}"
+					}
+				  }
+				}
+			  }
+			}
+		  ]
+		}
+	  }`)
+
+	var policy kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal(rawPolicy, &policy))
+	assert.NilError(t, Validate(policy))
+
+	// the built-in variables and the global context are allowed
+	policy.Spec.Rules[0].Validation.Message = "{{images.containers.nginx.tag}} {{request.userInfo.username}} {{globalContext.registries.data}}"
+	assert.NilError(t, Validate(policy))
+	policy.Spec.Rules[0].Validation.Message = "{{request.operation}} is not allowed in {{request.namespace}}"
+	assert.NilError(t, Validate(policy))
+
+	policy.Spec.Rules[0].Validation.Message = "{{request.objct.metadata.name}} must be owned by a team"
+	err := Validate(policy)
+	assert.ErrorContains(t, err, "path: spec.rules[0].validate.message: invalid variable {{request.objct.metadata.name}}: request.objct.metadata.name is not defined")
+
+	// the arguments of the functions are checked
+	policy.Spec.Rules[0].Validation.Message = "must be owned by {{ join(', ', keys(ownrs.data)) }}"
+	err = Validate(policy)
+	assert.ErrorContains(t, err, "path: spec.rules[0].validate.message: invalid variable {{ join(', ', keys(ownrs.data)) }}: ownrs.data is not defined")
+}
+
+func Test_Validate_Preconditions(t *testing.T) {
+	rawPolicy := []byte(`
+	{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "check-name"
+		},
+		"spec": {
+		  "rules": [
+			{
+			  "name": "check-name",
+			  "match": {
+				"resources": {
+				  "kinds": [
+					"Pod"
+				  ]
+				}
+			  },
+			  "preconditions": [
+				{
+				  "key": "{{request.object.metadata.name}}",
+				  "operator": "NotEqual",
+				  "value": "nginx"
+				}
+			  ],
+			  "validate": {
+				"pattern": {
+				  "metadata": {
+					"name": "?*"
+				  }
+				}
+			  }
+			}
+		  ]
+		}
+	  }`)
+
+	var policy kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal(rawPolicy, &policy))
+	assert.NilError(t, Validate(policy))
+
+	// the rules are validated when background is not set
+	policy.Spec.Rules[0].Conditions[0].Operator = "In"
+	err := Validate(policy)
+	assert.Error(t, err, "path: spec.rules[0].preconditions[0].operator: unsupported operator 'In', must be one of Equal, NotEqual")
+
+	policy.Spec.Rules[0].Conditions[0].Operator = kyverno.Equal
+	policy.Spec.Rules[0].Conditions[0].Key = nil
+	err = Validate(policy)
+	assert.Error(t, err, "path: spec.rules[0].preconditions[0].key: key is required")
+}
+
+func Test_Validate_VerifyImages(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	assert.NilError(t, err)
+	key := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	rawPolicy := []byte(`
+	{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "verify-images"
+		},
+		"spec": {
+		  "background": false,
+		  "rules": [
+			{
+			  "name": "verify-corp-images",
+			  "match": {
+				"resources": {
+				  "kinds": [
+					"Pod"
+				  ]
+				}
+			  },
+			  "verifyImages": [
+				{
+				  "image": "registry.corp.com/*"
+				}
+			  ]
+			}
+		  ]
+		}
+	  }`)
+
+	var policy kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal(rawPolicy, &policy))
+	err = Validate(policy)
+	assert.ErrorContains(t, err, "path: spec.rules[0].verifyImages[0]: a key or the roots of keyless signatures are required")
+
+	policy.Spec.Rules[0].VerifyImages[0].Key = "invalid"
+	err = Validate(policy)
+	assert.ErrorContains(t, err, "path: spec.rules[0].verifyImages[0].key: invalid key")
+
+	policy.Spec.Rules[0].VerifyImages[0].Key = key
+	assert.NilError(t, Validate(policy))
+
+	policy.Spec.Rules[0].VerifyImages[0].Image = ""
+	err = Validate(policy)
+	assert.ErrorContains(t, err, "path: spec.rules[0].verifyImages[0].image: image is required")
+
+	// the variables of the attestation conditions reference the predicate
+	policy.Spec.Rules[0].VerifyImages[0].Image = "registry.corp.com/*"
+	policy.Spec.Rules[0].VerifyImages[0].Attestations = []kyverno.Attestation{{
+		Conditions: []kyverno.Condition{{Key: "{{builder.id}}", Operator: kyverno.Equal, Value: "https://github.com/actions/runner"}},
+	}}
+	err = Validate(policy)
+	assert.ErrorContains(t, err, "path: spec.rules[0].verifyImages[0].attestations[0].predicateType: predicateType is required")
+
+	policy.Spec.Rules[0].VerifyImages[0].Attestations[0].PredicateType = "https://slsa.dev/provenance/v0.2"
+	assert.NilError(t, Validate(policy))
+
+	// a rule cannot verify images and validate
+	policy.Spec.Rules[0].Validation.Message = "invalid"
+	err = Validate(policy)
+	assert.ErrorContains(t, err, "multiple operations defined in the rule")
+}
+
+func Test_Validate_VerifyImages_Keyless(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	assert.NilError(t, err)
+	rekorKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), IsCA: true, BasicConstraintsValid: true}
+	der, err = x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	assert.NilError(t, err)
+	roots := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	verification := kyverno.ImageVerification{
+		Image:   "registry.corp.com/*",
+		Roots:   roots,
+		Subject: "*@corp.com",
+		Issuer:  "https://accounts.google.com",
+		Rekor:   &kyverno.Rekor{URL: "https://rekor.sigstore.dev", PubKey: rekorKey},
+	}
+	_, err = validateVerifyImages([]kyverno.ImageVerification{verification})
+	assert.NilError(t, err)
+
+	invalid := verification
+	invalid.Subject = ""
+	path, err := validateVerifyImages([]kyverno.ImageVerification{invalid})
+	assert.Equal(t, path, "[0].subject")
+	assert.ErrorContains(t, err, "subject is required")
+
+	invalid = verification
+	invalid.Roots = "invalid"
+	path, _ = validateVerifyImages([]kyverno.ImageVerification{invalid})
+	assert.Equal(t, path, "[0].roots")
+
+	invalid = verification
+	invalid.Rekor = &kyverno.Rekor{URL: "http://rekor.corp.com", PubKey: rekorKey}
+	path, err = validateVerifyImages([]kyverno.ImageVerification{invalid})
+	assert.Equal(t, path, "[0].rekor.url")
+	assert.ErrorContains(t, err, "must use https")
+
+	invalid = verification
+	invalid.Key = rekorKey
+	path, err = validateVerifyImages([]kyverno.ImageVerification{invalid})
+	assert.Equal(t, path, "[0]")
+	assert.ErrorContains(t, err, "only allowed for keyless signatures")
+}
+
+func Test_Validate_VerifyImages_Notary(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), IsCA: true, BasicConstraintsValid: true}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	assert.NilError(t, err)
+	trustStore := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	verification := kyverno.ImageVerification{
+		Image: "registry.corp.com/*",
+		Notary: &kyverno.NotaryVerification{
+			TrustStore:        trustStore,
+			TrustedIdentities: []string{"x509.subject: C=US, O=Corp"},
+		},
+	}
+	_, err = validateVerifyImages([]kyverno.ImageVerification{verification})
+	assert.NilError(t, err)
+
+	invalid := *verification.DeepCopy()
+	invalid.Notary.TrustedIdentities = []string{"O=Corp"}
+	path, err := validateVerifyImages([]kyverno.ImageVerification{invalid})
+	assert.Equal(t, path, "[0].notary.trustedIdentities")
+	assert.ErrorContains(t, err, "must be * or x509.subject")
+
+	invalid = *verification.DeepCopy()
+	invalid.Notary.TrustStore = "invalid"
+	path, _ = validateVerifyImages([]kyverno.ImageVerification{invalid})
+	assert.Equal(t, path, "[0].notary.trustStore")
+
+	invalid = *verification.DeepCopy()
+	invalid.Notary.SignatureVerification = "none"
+	path, err = validateVerifyImages([]kyverno.ImageVerification{invalid})
+	assert.Equal(t, path, "[0].notary.signatureVerification")
+	assert.ErrorContains(t, err, "invalid signature verification level none")
+
+	invalid = *verification.DeepCopy()
+	invalid.Subject = "*@corp.com"
+	path, err = validateVerifyImages([]kyverno.ImageVerification{invalid})
+	assert.Equal(t, path, "[0]")
+	assert.ErrorContains(t, err, "only allowed for cosign signatures")
+
+	invalid = *verification.DeepCopy()
+	invalid.Attestations = []kyverno.Attestation{{PredicateType: "https://slsa.dev/provenance/v0.2"}}
+	path, _ = validateVerifyImages([]kyverno.ImageVerification{invalid})
+	assert.Equal(t, path, "[0].attestations")
+}
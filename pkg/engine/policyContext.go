@@ -2,11 +2,24 @@ package engine
 
 import (
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
-	client "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/cosign"
 	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/externaldata"
+	"github.com/nirmata/kyverno/pkg/notary"
+	"github.com/nirmata/kyverno/pkg/registry"
+	"github.com/nirmata/kyverno/pkg/tracing"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
+// Client is the client of the Kubernetes API used by the rules, *dclient.Client implements it
+type Client interface {
+	// RawAbsPath performs a GET request on the path of the API server
+	RawAbsPath(path string) ([]byte, error)
+	// GetResource gets a resource from the API server
+	GetResource(kind string, namespace string, name string, subresources ...string) (*unstructured.Unstructured, error)
+}
+
 // PolicyContext contains the contexts for engine to process
 type PolicyContext struct {
 	// policy to be processed
@@ -16,8 +29,26 @@ type PolicyContext struct {
 	// old Resource - Update operations
 	OldResource   unstructured.Unstructured
 	AdmissionInfo kyverno.RequestInfo
-	// Dynamic client - used by generate
-	Client *client.Client
+	// Client - used by the API calls of the rule context and to get the Secrets of the services and the image pull secrets
+	Client Client
 	// Contexts to store resources
 	Context context.EvalInterface
+	// ConfigMapResolver - used to load ConfigMaps in the rule context
+	ConfigMapResolver ConfigMapResolver
+	// ImageRegistryClient - used to load image data in the rule context
+	ImageRegistryClient registry.Interface
+	// ServiceClient - used to call the external services in the rule context
+	ServiceClient externaldata.Interface
+	// GlobalContext - the context entries shared by all policies
+	GlobalContext *GlobalContext
+	// ImageVerifier - used to verify the image signatures of the verifyImages rules
+	ImageVerifier cosign.Interface
+	// NotaryVerifier - used to verify the Notary v2 signatures of the verifyImages rules
+	NotaryVerifier notary.Interface
+	// Exceptions - the PolicyExceptions exempting resources from the rules of the policy
+	Exceptions []kyverno.PolicyException
+	// Span - the parent span of the policy, nil if the request is not traced
+	Span *tracing.Span
+	// Log - the logger of the engine, the logs are discarded if it is not set
+	Log log.Logger
 }
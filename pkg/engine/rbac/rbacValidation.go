@@ -4,7 +4,7 @@ import (
 	"reflect"
 
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
-	utils "github.com/nirmata/kyverno/pkg/utils"
+	"github.com/nirmata/kyverno/pkg/engine/utils"
 	authenticationv1 "k8s.io/api/authentication/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 )
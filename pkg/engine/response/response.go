@@ -19,6 +19,8 @@ type EngineResponse struct {
 type PolicyResponse struct {
 	// policy name
 	Policy string `json:"policy"`
+	// policy namespace, set for the namespaced policies
+	PolicyNamespace string `json:"policyNamespace,omitempty"`
 	// resource details
 	Resource ResourceSpec `json:"resource"`
 	// policy statistics
@@ -67,6 +69,8 @@ type RuleResponse struct {
 	RuleStats `json:",inline"`
 	// PathNotPresent indicates whether referenced path in variable substitution exist
 	PathNotPresent bool `json:"pathNotPresent"`
+	// Error indicates the rule could not be applied, e.g. its context failed to load, rather than the resource violating it
+	Error bool `json:"error,omitempty"`
 }
 
 //ToString ...
@@ -0,0 +1,250 @@
+package sarif
+
+import (
+	"encoding/json"
+	"io"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+)
+
+// SARIF 2.1.0, https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+const (
+	schema         = "https://json.schemastore.org/sarif-2.1.0.json"
+	version        = "2.1.0"
+	toolName       = "kyverno"
+	informationURI = "https://github.com/nirmata/kyverno"
+)
+
+// levels of the results, the rules of the enforced policies are errors
+const (
+	levelError   = "error"
+	levelWarning = "warning"
+)
+
+// descriptionAnnotation is the annotation of the policies used as the description of their rules
+const descriptionAnnotation = "policies.kyverno.io/description"
+
+// ResourceResult is the engine response of a resource loaded from a file
+type ResourceResult struct {
+	// File is the path of the manifest of the resource, relative to the root of the repository
+	File string
+	// Line is the line of the resource in the file, starting at 1, 0 if unknown
+	Line           int
+	EngineResponse response.EngineResponse
+}
+
+// Log is a SARIF log with a run of kyverno
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run are the results of the policies
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes kyverno and the rules of the policies
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is kyverno, the rules are the rules of the policies
+type Driver struct {
+	Name           string                `json:"name"`
+	Version        string                `json:"version,omitempty"`
+	InformationURI string                `json:"informationUri"`
+	Rules          []ReportingDescriptor `json:"rules"`
+}
+
+// ReportingDescriptor is a rule of a policy, its id is <policy>/<rule>
+type ReportingDescriptor struct {
+	ID                   string          `json:"id"`
+	Name                 string          `json:"name"`
+	ShortDescription     Message         `json:"shortDescription"`
+	FullDescription      *Message        `json:"fullDescription,omitempty"`
+	DefaultConfiguration ReportingConfig `json:"defaultConfiguration"`
+	Properties           *PropertyBag    `json:"properties,omitempty"`
+}
+
+// ReportingConfig is the default level of the results of the rule
+type ReportingConfig struct {
+	Level string `json:"level"`
+}
+
+// PropertyBag are the tags of the rule
+type PropertyBag struct {
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Message is a text message
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Result is a failed rule of a resource
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	RuleIndex int        `json:"ruleIndex"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations"`
+}
+
+// Location is the file of the resource, and the resource
+type Location struct {
+	PhysicalLocation *PhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []LogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+// PhysicalLocation is the file and the line of the resource
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation is the path of the file
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Region is the line of the resource
+type Region struct {
+	StartLine int `json:"startLine"`
+}
+
+// LogicalLocation is the resource, its fully qualified name is <namespace>/<kind>/<name>
+type LogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// NewLog returns the SARIF log of the failed rules of the results, the rules of the policies are the rules of the tool
+func NewLog(policies []kyverno.ClusterPolicy, results []ResourceResult, toolVersion string) Log {
+	run := Run{
+		Tool: Tool{Driver: Driver{
+			Name:           toolName,
+			Version:        toolVersion,
+			InformationURI: informationURI,
+			Rules:          []ReportingDescriptor{},
+		}},
+		Results: []Result{},
+	}
+	ruleIndex := map[string]int{}
+	addRule := func(descriptor ReportingDescriptor) int {
+		if i, ok := ruleIndex[descriptor.ID]; ok {
+			return i
+		}
+		ruleIndex[descriptor.ID] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, descriptor)
+		return ruleIndex[descriptor.ID]
+	}
+	levels := map[string]string{}
+	for _, policy := range policies {
+		levels[policy.Name] = level(policy.Spec.ValidationFailureAction)
+		for _, rule := range policy.Spec.Rules {
+			addRule(newDescriptor(policy, rule))
+		}
+	}
+
+	for _, result := range results {
+		policyResponse := result.EngineResponse.PolicyResponse
+		for _, rule := range policyResponse.Rules {
+			if rule.Success {
+				continue
+			}
+			id := ruleID(policyResponse.Policy, rule.Name)
+			resultLevel, ok := levels[policyResponse.Policy]
+			if !ok {
+				resultLevel = level(policyResponse.ValidationFailureAction)
+			}
+			// the rules of the responses are described even if their policy is unknown
+			index := addRule(ReportingDescriptor{
+				ID:                   id,
+				Name:                 rule.Name,
+				ShortDescription:     Message{Text: rule.Name + " of policy " + policyResponse.Policy},
+				DefaultConfiguration: ReportingConfig{Level: resultLevel},
+			})
+			run.Results = append(run.Results, Result{
+				RuleID:    id,
+				RuleIndex: index,
+				Level:     resultLevel,
+				Message:   Message{Text: rule.Message},
+				Locations: []Location{newLocation(result, policyResponse.Resource)},
+			})
+		}
+	}
+	return Log{Schema: schema, Version: version, Runs: []Run{run}}
+}
+
+// Write writes the indented SARIF log
+func Write(w io.Writer, log Log) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+func ruleID(policy, rule string) string {
+	return policy + "/" + rule
+}
+
+// level returns the level of the results of the policies with the validation failure action
+func level(validationFailureAction string) string {
+	if validationFailureAction == "enforce" {
+		return levelError
+	}
+	return levelWarning
+}
+
+func newDescriptor(policy kyverno.ClusterPolicy, rule kyverno.Rule) ReportingDescriptor {
+	descriptor := ReportingDescriptor{
+		ID:                   ruleID(policy.Name, rule.Name),
+		Name:                 rule.Name,
+		ShortDescription:     Message{Text: rule.Name + " of policy " + policy.Name},
+		DefaultConfiguration: ReportingConfig{Level: level(policy.Spec.ValidationFailureAction)},
+	}
+	if description := policy.GetAnnotations()[descriptionAnnotation]; description != "" {
+		descriptor.FullDescription = &Message{Text: description}
+	} else if rule.Validation.Message != "" {
+		descriptor.FullDescription = &Message{Text: rule.Validation.Message}
+	}
+	var tags []string
+	for _, t := range []struct {
+		has bool
+		tag string
+	}{
+		{rule.HasMutate(), "mutate"},
+		{rule.HasValidate(), "validate"},
+		{rule.HasGenerate(), "generate"},
+		{rule.HasVerifyImages(), "verifyImages"},
+	} {
+		if t.has {
+			tags = append(tags, t.tag)
+		}
+	}
+	if len(tags) != 0 {
+		descriptor.Properties = &PropertyBag{Tags: tags}
+	}
+	return descriptor
+}
+
+func newLocation(result ResourceResult, resource response.ResourceSpec) Location {
+	location := Location{
+		LogicalLocations: []LogicalLocation{{
+			Name:               resource.Name,
+			FullyQualifiedName: resource.Namespace + "/" + resource.Kind + "/" + resource.Name,
+			Kind:               "resource",
+		}},
+	}
+	if result.File != "" {
+		location.PhysicalLocation = &PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: result.File}}
+		if result.Line > 0 {
+			location.PhysicalLocation.Region = &Region{StartLine: result.Line}
+		}
+	}
+	return location
+}
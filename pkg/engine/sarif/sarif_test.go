@@ -0,0 +1,70 @@
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"gotest.tools/assert"
+)
+
+func Test_NewLog(t *testing.T) {
+	policy := kyverno.ClusterPolicy{}
+	policy.Name = "disallow-latest-tag"
+	policy.Spec.ValidationFailureAction = "enforce"
+	policy.Spec.Rules = []kyverno.Rule{
+		{Name: "require-image-tag", Validation: kyverno.Validation{Message: "An image tag is required", Pattern: map[string]interface{}{}}},
+		{Name: "validate-image-tag", Validation: kyverno.Validation{Message: "Using the latest tag is not allowed", Pattern: map[string]interface{}{}}},
+	}
+
+	er := response.EngineResponse{}
+	er.PolicyResponse.Policy = "disallow-latest-tag"
+	er.PolicyResponse.Resource = response.ResourceSpec{Kind: "Pod", Namespace: "web", Name: "nginx"}
+	er.PolicyResponse.Rules = []response.RuleResponse{
+		{Name: "require-image-tag", Success: true},
+		{Name: "validate-image-tag", Message: "image tag latest is not allowed", Success: false},
+	}
+	// the rules of an unknown policy are added to the tool
+	audit := response.EngineResponse{}
+	audit.PolicyResponse.Policy = "require-labels"
+	audit.PolicyResponse.Resource = response.ResourceSpec{Kind: "Namespace", Name: "web"}
+	audit.PolicyResponse.Rules = []response.RuleResponse{{Name: "check-team", Message: "label team is required", Success: false}}
+
+	log := NewLog([]kyverno.ClusterPolicy{policy}, []ResourceResult{
+		{File: "deploy/pods.yaml", Line: 10, EngineResponse: er},
+		{EngineResponse: audit},
+	}, "v1.1.0")
+
+	assert.Equal(t, log.Version, "2.1.0")
+	assert.Equal(t, len(log.Runs), 1)
+	run := log.Runs[0]
+	assert.Equal(t, run.Tool.Driver.Version, "v1.1.0")
+	assert.Equal(t, len(run.Tool.Driver.Rules), 3)
+	assert.Equal(t, run.Tool.Driver.Rules[1].ID, "disallow-latest-tag/validate-image-tag")
+	assert.Equal(t, run.Tool.Driver.Rules[1].FullDescription.Text, "Using the latest tag is not allowed")
+	assert.DeepEqual(t, run.Tool.Driver.Rules[1].Properties.Tags, []string{"validate"})
+
+	assert.Equal(t, len(run.Results), 2)
+	result := run.Results[0]
+	assert.Equal(t, result.RuleID, "disallow-latest-tag/validate-image-tag")
+	assert.Equal(t, result.RuleIndex, 1)
+	assert.Equal(t, result.Level, "error")
+	assert.Equal(t, result.Message.Text, "image tag latest is not allowed")
+	assert.Equal(t, result.Locations[0].PhysicalLocation.ArtifactLocation.URI, "deploy/pods.yaml")
+	assert.Equal(t, result.Locations[0].PhysicalLocation.Region.StartLine, 10)
+	assert.Equal(t, result.Locations[0].LogicalLocations[0].FullyQualifiedName, "web/Pod/nginx")
+
+	result = run.Results[1]
+	assert.Equal(t, result.RuleID, "require-labels/check-team")
+	assert.Equal(t, result.RuleIndex, 2)
+	assert.Equal(t, result.Level, "warning")
+	assert.Assert(t, result.Locations[0].PhysicalLocation == nil)
+
+	var buf bytes.Buffer
+	assert.NilError(t, Write(&buf, log))
+	var decoded map[string]interface{}
+	assert.NilError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, decoded["$schema"], "https://json.schemastore.org/sarif-2.1.0.json")
+}
@@ -2,20 +2,21 @@ package engine
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
-	"github.com/golang/glog"
-
 	"github.com/minio/minio/pkg/wildcard"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
 	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/engine/utils"
 	"github.com/nirmata/kyverno/pkg/engine/variables"
-	"github.com/nirmata/kyverno/pkg/utils"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 //EngineStats stores in the statistics for a single application of resource
@@ -31,7 +32,7 @@ func MatchesResourceDescription(resource unstructured.Unstructured, rule kyverno
 	matches := rule.MatchResources.ResourceDescription
 	exclude := rule.ExcludeResources.ResourceDescription
 
-	if !findKind(matches.Kinds, resource.GetKind()) {
+	if !findKind(matches.Kinds, resource.GroupVersionKind()) {
 		return false
 	}
 
@@ -48,15 +49,15 @@ func MatchesResourceDescription(resource unstructured.Unstructured, rule kyverno
 
 	// Matches
 	// check if the resource namespace is defined in the list of namespace pattern
-	if len(matches.Namespaces) > 0 && !utils.ContainsNamepace(matches.Namespaces, namespace) {
+	if len(matches.Namespaces) > 0 && !utils.ContainsNamespace(matches.Namespaces, namespace) {
 		return false
 	}
 
 	// Matches
 	if matches.Selector != nil {
+		// the selectors are validated when the policy is created
 		selector, err := metav1.LabelSelectorAsSelector(matches.Selector)
 		if err != nil {
-			glog.Error(err)
 			return false
 		}
 		if !selector.Matches(labels.Set(resource.GetLabels())) {
@@ -78,7 +79,7 @@ func MatchesResourceDescription(resource unstructured.Unstructured, rule kyverno
 		if len(exclude.Namespaces) == 0 {
 			return NotEvaluate
 		}
-		if utils.ContainsNamepace(exclude.Namespaces, namespace) {
+		if utils.ContainsNamespace(exclude.Namespaces, namespace) {
 			return Skip
 		}
 		return Process
@@ -91,7 +92,6 @@ func MatchesResourceDescription(resource unstructured.Unstructured, rule kyverno
 		selector, err := metav1.LabelSelectorAsSelector(exclude.Selector)
 		// if the label selector is incorrect, should be fail or
 		if err != nil {
-			glog.Error(err)
 			return Skip
 		}
 		if selector.Matches(labels.Set(labelsMap)) {
@@ -100,12 +100,12 @@ func MatchesResourceDescription(resource unstructured.Unstructured, rule kyverno
 		return Process
 	}
 
-	excludeKind := func(kind string) Condition {
+	excludeKind := func(gvk schema.GroupVersionKind) Condition {
 		if len(exclude.Kinds) == 0 {
 			return NotEvaluate
 		}
 
-		if findKind(exclude.Kinds, kind) {
+		if findKind(exclude.Kinds, gvk) {
 			return Skip
 		}
 
@@ -126,7 +126,7 @@ func MatchesResourceDescription(resource unstructured.Unstructured, rule kyverno
 	if ret := excludeSelector(resource.GetLabels()); ret != NotEvaluate {
 		excludeEval = append(excludeEval, ret)
 	}
-	if ret := excludeKind(resource.GetKind()); ret != NotEvaluate {
+	if ret := excludeKind(resource.GroupVersionKind()); ret != NotEvaluate {
 		excludeEval = append(excludeEval, ret)
 	}
 	// Filtered NotEvaluate
@@ -213,9 +213,10 @@ func ParseNamespaceFromObject(bytes []byte) string {
 	return ""
 }
 
-func findKind(kinds []string, kindGVK string) bool {
+// findKind checks if one of the kinds of the rule matches the group, the version and the kind of the resource
+func findKind(kinds []string, gvk schema.GroupVersionKind) bool {
 	for _, kind := range kinds {
-		if kind == kindGVK {
+		if utils.MatchesKind(kind, gvk) {
 			return true
 		}
 	}
@@ -226,7 +227,7 @@ func findKind(kinds []string, kindGVK string) bool {
 // - MatchResources
 // - ExcludeResources
 // - Conditions
-func validateGeneralRuleInfoVariables(ctx context.EvalInterface, rule kyverno.Rule) string {
+func validateGeneralRuleInfoVariables(log log.Logger, ctx context.EvalInterface, rule kyverno.Rule) string {
 	var tempRule kyverno.Rule
 	var tempRulePattern interface{}
 
@@ -236,12 +237,12 @@ func validateGeneralRuleInfoVariables(ctx context.EvalInterface, rule kyverno.Ru
 
 	raw, err := json.Marshal(tempRule)
 	if err != nil {
-		glog.Infof("failed to serilize rule info while validating variable substitution: %v", err)
+		log.Infof("failed to serilize rule info while validating variable substitution: %v", err)
 		return ""
 	}
 
 	if err := json.Unmarshal(raw, &tempRulePattern); err != nil {
-		glog.Infof("failed to serilize rule info while validating variable substitution: %v", err)
+		log.Infof("failed to serilize rule info while validating variable substitution: %v", err)
 		return ""
 	}
 
@@ -257,3 +258,13 @@ func newPathNotPresentRuleResponse(rname, rtype, msg string) response.RuleRespon
 		PathNotPresent: true,
 	}
 }
+
+func newContextFailedRuleResponse(rname, rtype string, err error) response.RuleResponse {
+	return response.RuleResponse{
+		Name:    rname,
+		Type:    rtype,
+		Message: fmt.Sprintf("failed to load context: %v", err),
+		Success: false,
+		Error:   true,
+	}
+}
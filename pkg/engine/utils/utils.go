@@ -1,11 +1,18 @@
 package utils
 
 import (
+	"strings"
+
 	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/minio/minio/pkg/wildcard"
 	"github.com/nirmata/kyverno/pkg/engine/anchor"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// AnyGroupVersion is the group and the version of the kinds of the rules without an API version
+const AnyGroupVersion = "*"
+
 //RuleType defines the type for rule
 type RuleType int
 
@@ -16,6 +23,8 @@ const (
 	Validation
 	//Generation type for generation rule
 	Generation
+	//ImageVerify type for verifyImages rule
+	ImageVerify
 	//All type for other rule operations(future)
 	All
 )
@@ -25,6 +34,7 @@ func (ri RuleType) String() string {
 		"Mutation",
 		"Validation",
 		"Generation",
+		"ImageVerify",
 		"All",
 	}[ri]
 }
@@ -100,3 +110,48 @@ func GetAnchorsFromMap(anchorsMap map[string]interface{}) map[string]interface{}
 
 	return result
 }
+
+//ContainsNamespace checks if the namespace matches one of the wildcard patterns
+func ContainsNamespace(patterns []string, ns string) bool {
+	for _, pattern := range patterns {
+		if wildcard.Match(pattern, ns) {
+			return true
+		}
+	}
+	return false
+}
+
+//ContainsString checks if the string is in the list
+func ContainsString(list []string, element string) bool {
+	for _, e := range list {
+		if e == element {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseKind returns the group, the version and the kind of a kind of a rule, either a kind, e.g. Pod,
+// or an API version and a kind, e.g. v1/Pod or apps/v1/Deployment. The group and the version of
+// a kind without an API version are AnyGroupVersion
+func ParseKind(kind string) schema.GroupVersionKind {
+	i := strings.LastIndex(kind, "/")
+	if i < 0 {
+		return schema.GroupVersionKind{Group: AnyGroupVersion, Version: AnyGroupVersion, Kind: kind}
+	}
+	gv, err := schema.ParseGroupVersion(kind[:i])
+	if err != nil {
+		// the kind never matches
+		return schema.GroupVersionKind{Kind: kind}
+	}
+	return gv.WithKind(kind[i+1:])
+}
+
+// MatchesKind checks if the kind of a rule matches the group, the version and the kind of a resource
+func MatchesKind(kind string, gvk schema.GroupVersionKind) bool {
+	k := ParseKind(kind)
+	if k.Kind != gvk.Kind {
+		return false
+	}
+	return k.Group == AnyGroupVersion || (k.Group == gvk.Group && k.Version == gvk.Version)
+}
@@ -7,6 +7,7 @@ import (
 
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	context "github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
 	"github.com/nirmata/kyverno/pkg/engine/utils"
 	"gotest.tools/assert"
 	authenticationv1 "k8s.io/api/authentication/v1"
@@ -505,7 +506,7 @@ func Test_validateGeneralRuleInfoVariables(t *testing.T) {
 	expectPaths := []string{"request.userInfo.username1", "request.object.namespace", ""}
 
 	for i, rule := range policy.Spec.Rules {
-		invalidPaths := validateGeneralRuleInfoVariables(ctx, rule)
+		invalidPaths := validateGeneralRuleInfoVariables(log.Discard, ctx, rule)
 		assert.Assert(t, invalidPaths == expectPaths[i], fmt.Sprintf("result not match, got invalidPaths %s", invalidPaths))
 	}
 }
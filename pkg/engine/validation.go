@@ -7,36 +7,39 @@ import (
 	"strings"
 	"time"
 
-	"github.com/golang/glog"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
 	"github.com/nirmata/kyverno/pkg/engine/rbac"
 	"github.com/nirmata/kyverno/pkg/engine/response"
 	"github.com/nirmata/kyverno/pkg/engine/utils"
 	"github.com/nirmata/kyverno/pkg/engine/validate"
 	"github.com/nirmata/kyverno/pkg/engine/variables"
+	"github.com/nirmata/kyverno/pkg/tracing"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 //Validate applies validation rules from policy on the resource
 func Validate(policyContext PolicyContext) (resp response.EngineResponse) {
+	log := policyContext.Log
 	startTime := time.Now()
 	policy := policyContext.Policy
 	newR := policyContext.NewResource
 	oldR := policyContext.OldResource
-	ctx := policyContext.Context
-	admissionInfo := policyContext.AdmissionInfo
 
 	// policy information
-	glog.V(4).Infof("started applying validation rules of policy %q (%v)", policy.Name, startTime)
+	log.V(4).Infof("started applying validation rules of policy %q (%v)", policy.Name, startTime)
+	policySpan := policyContext.Span.Start("validate "+policy.Name, "policy", policy.Name)
+	defer policySpan.End()
+	policyContext.Span = policySpan
 
 	// Process new & old resource
 	if reflect.DeepEqual(oldR, unstructured.Unstructured{}) {
 		// Create Mode
 		// Operate on New Resource only
-		resp := validateResource(ctx, policy, newR, admissionInfo)
+		resp := validateResource(policyContext, newR)
 		startResultResponse(resp, policy, newR)
-		defer endResultResponse(resp, startTime)
+		defer endResultResponse(log, resp, startTime)
 		// set PatchedResource with origin resource if empty
 		// in order to create policy violation
 		if reflect.DeepEqual(resp.PatchedResource, unstructured.Unstructured{}) {
@@ -47,14 +50,14 @@ func Validate(policyContext PolicyContext) (resp response.EngineResponse) {
 	// Update Mode
 	// Operate on New and Old Resource only
 	// New resource
-	oldResponse := validateResource(ctx, policy, oldR, admissionInfo)
-	newResponse := validateResource(ctx, policy, newR, admissionInfo)
+	oldResponse := validateResource(policyContext, oldR)
+	newResponse := validateResource(policyContext, newR)
 
 	// if the old and new response is same then return empty response
 	if !isSameResponse(oldResponse, newResponse) {
 		// there are changes send response
 		startResultResponse(newResponse, policy, newR)
-		defer endResultResponse(newResponse, startTime)
+		defer endResultResponse(log, newResponse, startTime)
 		if reflect.DeepEqual(newResponse.PatchedResource, unstructured.Unstructured{}) {
 			newResponse.PatchedResource = newR
 		}
@@ -68,6 +71,7 @@ func Validate(policyContext PolicyContext) (resp response.EngineResponse) {
 func startResultResponse(resp *response.EngineResponse, policy kyverno.ClusterPolicy, newR unstructured.Unstructured) {
 	// set policy information
 	resp.PolicyResponse.Policy = policy.Name
+	resp.PolicyResponse.PolicyNamespace = policy.Namespace
 	// resource details
 	resp.PolicyResponse.Resource.Name = newR.GetName()
 	resp.PolicyResponse.Resource.Namespace = newR.GetNamespace()
@@ -76,10 +80,10 @@ func startResultResponse(resp *response.EngineResponse, policy kyverno.ClusterPo
 	resp.PolicyResponse.ValidationFailureAction = policy.Spec.ValidationFailureAction
 }
 
-func endResultResponse(resp *response.EngineResponse, startTime time.Time) {
+func endResultResponse(log log.Logger, resp *response.EngineResponse, startTime time.Time) {
 	resp.PolicyResponse.ProcessingTime = time.Since(startTime)
-	glog.V(4).Infof("Finished applying validation rules policy %v (%v)", resp.PolicyResponse.Policy, resp.PolicyResponse.ProcessingTime)
-	glog.V(4).Infof("Validation Rules appplied successfully count %v for policy %q", resp.PolicyResponse.RulesAppliedCount, resp.PolicyResponse.Policy)
+	log.V(4).Infof("Finished applying validation rules policy %v (%v)", resp.PolicyResponse.Policy, resp.PolicyResponse.ProcessingTime)
+	log.V(4).Infof("Validation Rules appplied successfully count %v for policy %q", resp.PolicyResponse.RulesAppliedCount, resp.PolicyResponse.Policy)
 }
 
 func incrementAppliedCount(resp *response.EngineResponse) {
@@ -87,45 +91,67 @@ func incrementAppliedCount(resp *response.EngineResponse) {
 	resp.PolicyResponse.RulesAppliedCount++
 }
 
-func validateResource(ctx context.EvalInterface, policy kyverno.ClusterPolicy, resource unstructured.Unstructured, admissionInfo kyverno.RequestInfo) *response.EngineResponse {
+func validateResource(policyContext PolicyContext, resource unstructured.Unstructured) *response.EngineResponse {
+	log := policyContext.Log
+	policy := policyContext.Policy
+	ctx := policyContext.Context
+	admissionInfo := policyContext.AdmissionInfo
 	resp := &response.EngineResponse{}
+	policySpan := policyContext.Span
+	// the span of a rule ends when the next rule starts
+	var ruleSpan *tracing.Span
+	defer func() { ruleSpan.End() }()
 	for _, rule := range policy.Spec.Rules {
 		if !rule.HasValidate() {
 			continue
 		}
+		ruleSpan.End()
+		ruleSpan = policySpan.Start("rule "+rule.Name, "policy", policy.Name, "rule", rule.Name)
+		policyContext.Span = ruleSpan
 		startTime := time.Now()
 
-		if paths := validateGeneralRuleInfoVariables(ctx, rule); len(paths) != 0 {
-			glog.Infof("referenced path not present in rule %s/, resource %s/%s/%s, path: %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), paths)
+		if err := loadRuleContext(policyContext, rule, resource); err != nil {
+			log.Infof("failed to load context in rule %s, resource %s/%s/%s: %v", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), err)
+			resp.PolicyResponse.Rules = append(resp.PolicyResponse.Rules,
+				newContextFailedRuleResponse(rule.Name, utils.Validation.String(), err))
+			continue
+		}
+
+		if paths := validateGeneralRuleInfoVariables(log, ctx, rule); len(paths) != 0 {
+			log.Infof("referenced path not present in rule %s/, resource %s/%s/%s, path: %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), paths)
 			resp.PolicyResponse.Rules = append(resp.PolicyResponse.Rules,
 				newPathNotPresentRuleResponse(rule.Name, utils.Validation.String(), fmt.Sprintf("path not present: %s", paths)))
 			continue
 		}
 
 		if !rbac.MatchAdmissionInfo(rule, admissionInfo) {
-			glog.V(3).Infof("rule '%s' cannot be applied on %s/%s/%s, admission permission: %v",
+			log.V(3).Infof("rule '%s' cannot be applied on %s/%s/%s, admission permission: %v",
 				rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), admissionInfo)
 			continue
 		}
-		glog.V(4).Infof("Time: Validate matchAdmissionInfo %v", time.Since(startTime))
+		log.V(4).Infof("Time: Validate matchAdmissionInfo %v", time.Since(startTime))
 
 		// check if the resource satisfies the filter conditions defined in the rule
 		// TODO: this needs to be extracted, to filter the resource so that we can avoid passing resources that
 		// dont statisfy a policy rule resource description
 		ok := MatchesResourceDescription(resource, rule)
 		if !ok {
-			glog.V(4).Infof("resource %s/%s does not satisfy the resource description for the rule ", resource.GetNamespace(), resource.GetName())
+			log.V(4).Infof("resource %s/%s does not satisfy the resource description for the rule ", resource.GetNamespace(), resource.GetName())
+			continue
+		}
+
+		if isExempted(policyContext, rule, resource) {
 			continue
 		}
 
 		// evaluate pre-conditions
-		if !variables.EvaluateConditions(ctx, rule.Conditions) {
-			glog.V(4).Infof("resource %s/%s does not satisfy the conditions for the rule ", resource.GetNamespace(), resource.GetName())
+		if !variables.EvaluateConditions(log, ctx, rule.Conditions) {
+			log.V(4).Infof("resource %s/%s does not satisfy the conditions for the rule ", resource.GetNamespace(), resource.GetName())
 			continue
 		}
 
 		if rule.Validation.Pattern != nil || rule.Validation.AnyPattern != nil {
-			ruleResponse := validatePatterns(ctx, resource, rule)
+			ruleResponse := validatePatterns(log, ctx, resource, rule)
 			incrementAppliedCount(resp)
 			resp.PolicyResponse.Rules = append(resp.PolicyResponse.Rules, ruleResponse)
 		}
@@ -173,29 +199,33 @@ func isSameRules(oldRules []response.RuleResponse, newRules []response.RuleRespo
 }
 
 // validatePatterns validate pattern and anyPattern
-func validatePatterns(ctx context.EvalInterface, resource unstructured.Unstructured, rule kyverno.Rule) (resp response.RuleResponse) {
+func validatePatterns(log log.Logger, ctx context.EvalInterface, resource unstructured.Unstructured, rule kyverno.Rule) (resp response.RuleResponse) {
 	startTime := time.Now()
-	glog.V(4).Infof("started applying validation rule %q (%v)", rule.Name, startTime)
+	log.V(4).Infof("started applying validation rule %q (%v)", rule.Name, startTime)
 	resp.Name = rule.Name
 	resp.Type = utils.Validation.String()
 	defer func() {
 		resp.RuleStats.ProcessingTime = time.Since(startTime)
-		glog.V(4).Infof("finished applying validation rule %q (%v)", resp.Name, resp.RuleStats.ProcessingTime)
+		log.V(4).Infof("finished applying validation rule %q (%v)", resp.Name, resp.RuleStats.ProcessingTime)
 	}()
+	// variables can be used in the message
+	if message, ok := variables.SubstituteVariables(ctx, rule.Validation.Message).(string); ok {
+		rule.Validation.Message = message
+	}
 
 	// either pattern or anyPattern can be specified in Validation rule
 	if rule.Validation.Pattern != nil {
-		path, err := validate.ValidateResourceWithPattern(ctx, resource.Object, rule.Validation.Pattern)
+		path, err := validate.ValidateResourceWithPattern(log, ctx, resource.Object, rule.Validation.Pattern)
 		if !reflect.DeepEqual(err, validate.ValidationError{}) {
 			switch err.StatusCode {
 			case validate.PathNotPresent:
 				resp.Success = true
 				resp.PathNotPresent = true
 				resp.Message = fmt.Sprintf("referenced path not present: %s", err.ErrorMsg)
-				glog.V(4).Infof("Skip applying rule '%s' on resource '%s/%s/%s': %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), resp.Message)
+				log.V(4).Infof("Skip applying rule '%s' on resource '%s/%s/%s': %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), resp.Message)
 			case validate.Rulefailure:
 				// rule application failed
-				glog.V(4).Infof("Validation rule '%s' failed at '%s' for resource %s/%s/%s. %s: %v", rule.Name, path, resource.GetKind(), resource.GetNamespace(), resource.GetName(), rule.Validation.Message, err)
+				log.V(4).Infof("Validation rule '%s' failed at '%s' for resource %s/%s/%s. %s: %v", rule.Name, path, resource.GetKind(), resource.GetNamespace(), resource.GetName(), rule.Validation.Message, err)
 				resp.Success = false
 				resp.Message = fmt.Sprintf("Validation error: %s; Validation rule '%s' failed at path '%s'",
 					rule.Validation.Message, rule.Name, path)
@@ -204,7 +234,7 @@ func validatePatterns(ctx context.EvalInterface, resource unstructured.Unstructu
 		}
 
 		// rule application successful
-		glog.V(4).Infof("rule %s pattern validated successfully on resource %s/%s/%s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName())
+		log.V(4).Infof("rule %s pattern validated successfully on resource %s/%s/%s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName())
 		resp.Success = true
 		resp.Message = fmt.Sprintf("Validation rule '%s' succeeded.", rule.Name)
 		return resp
@@ -217,10 +247,10 @@ func validatePatterns(ctx context.EvalInterface, resource unstructured.Unstructu
 		var ruleFailureErrs []error
 		var failedPaths, invalidPaths []string
 		for index, pattern := range rule.Validation.AnyPattern {
-			path, err := validate.ValidateResourceWithPattern(ctx, resource.Object, pattern)
+			path, err := validate.ValidateResourceWithPattern(log, ctx, resource.Object, pattern)
 			// this pattern was successfully validated
 			if reflect.DeepEqual(err, validate.ValidationError{}) {
-				glog.V(4).Infof("anyPattern %v successfully validated on resource %s/%s/%s", pattern, resource.GetKind(), resource.GetNamespace(), resource.GetName())
+				log.V(4).Infof("anyPattern %v successfully validated on resource %s/%s/%s", pattern, resource.GetKind(), resource.GetNamespace(), resource.GetName())
 				resp.Success = true
 				resp.Message = fmt.Sprintf("Validation rule '%s' anyPattern[%d] succeeded.", rule.Name, index)
 				return resp
@@ -230,7 +260,7 @@ func validatePatterns(ctx context.EvalInterface, resource unstructured.Unstructu
 			case validate.PathNotPresent:
 				invalidPaths = append(invalidPaths, err.ErrorMsg)
 			case validate.Rulefailure:
-				glog.V(4).Infof("Validation error: %s; Validation rule %s anyPattern[%d] failed at path %s for %s/%s/%s",
+				log.V(4).Infof("Validation error: %s; Validation rule %s anyPattern[%d] failed at path %s for %s/%s/%s",
 					rule.Validation.Message, rule.Name, index, path, resource.GetKind(), resource.GetNamespace(), resource.GetName())
 				ruleFailureErrs = append(ruleFailureErrs, errors.New(err.ErrorMsg))
 				failedPaths = append(failedPaths, path)
@@ -242,16 +272,16 @@ func validatePatterns(ctx context.EvalInterface, resource unstructured.Unstructu
 			resp.Success = true
 			resp.PathNotPresent = true
 			resp.Message = fmt.Sprintf("referenced path not present: %s", strings.Join(invalidPaths, ";"))
-			glog.V(4).Infof("Skip applying rule '%s' on resource '%s/%s/%s': %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), resp.Message)
+			log.V(4).Infof("Skip applying rule '%s' on resource '%s/%s/%s': %s", rule.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), resp.Message)
 			return resp
 		}
 
 		// none of the anyPatterns succeed: len(ruleFailureErrs) > 0
-		glog.V(4).Infof("none of anyPattern comply with resource: %v", ruleFailureErrs)
+		log.V(4).Infof("none of anyPattern comply with resource: %v", ruleFailureErrs)
 		resp.Success = false
 		var errorStr []string
 		for index, err := range ruleFailureErrs {
-			glog.V(4).Infof("anyPattern[%d] failed at path %s: %v", index, failedPaths[index], err)
+			log.V(4).Infof("anyPattern[%d] failed at path %s: %v", index, failedPaths[index], err)
 			str := fmt.Sprintf("Validation rule %s anyPattern[%d] failed at path %s.", rule.Name, index, failedPaths[index])
 			errorStr = append(errorStr, str)
 		}
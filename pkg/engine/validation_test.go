@@ -2,12 +2,17 @@ package engine
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/response"
 	"github.com/nirmata/kyverno/pkg/engine/utils"
 	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestGetAnchorsFromMap_ThereAreAnchors(t *testing.T) {
@@ -1777,3 +1782,185 @@ func Test_VariableSubstitutionPathNotExistInAnyPattern_AllPathPresent_NonePatter
 	assert.Assert(t, er.PolicyResponse.Rules[0].PathNotPresent == false)
 	assert.Assert(t, er.PolicyResponse.Rules[0].Message == expectedMsg)
 }
+
+type fakeConfigMapResolver map[string]*v1.ConfigMap
+
+func (r fakeConfigMapResolver) Get(namespace, name string) (*v1.ConfigMap, error) {
+	if cm, ok := r[namespace+"/"+name]; ok {
+		return cm, nil
+	}
+	return nil, fmt.Errorf("configmap %s/%s not found", namespace, name)
+}
+
+func Test_ConfigMapContext(t *testing.T) {
+	resourceRaw := []byte(`{
+		"apiVersion": "v1",
+		"kind": "Pod",
+		"metadata": {
+		  "name": "test"
+		},
+		"spec": {
+		  "containers": [
+			{
+			  "name": "nginx",
+			  "image": "registry.corp.com/nginx"
+			}
+		  ]
+		}
+	  }`)
+
+	policyraw := []byte(`{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "allowed-registries"
+		},
+		"spec": {
+		  "rules": [
+			{
+			  "name": "check-registry",
+			  "context": [
+				{
+				  "name": "registries",
+				  "configMap": {
+					"name": "allowed-registries",
+					"namespace": "default"
+				  }
+				}
+			  ],
+			  "match": {
+				"resources": {
+				  "kinds": [
+					"Pod"
+				  ]
+				}
+			  },
+			  "validate": {
+				"message": "images must be pulled from {{registries.data.registry}}",
+				"pattern": {
+				  "spec": {
+					"containers": [
+					  {
+						"image": "{{registries.data.registry}}/*"
+					  }
+					]
+				  }
+				}
+			  }
+			}
+		  ]
+		}
+	  }`)
+
+	resourceUnstructured, err := utils.ConvertToUnstructured(resourceRaw)
+	assert.NilError(t, err)
+
+	validate := func(resolver ConfigMapResolver) response.EngineResponse {
+		// the variables are substituted in the policy
+		var policy kyverno.ClusterPolicy
+		assert.NilError(t, json.Unmarshal(policyraw, &policy))
+		ctx := context.NewContext()
+		ctx.AddResource(resourceRaw)
+		return Validate(PolicyContext{
+			Policy:            policy,
+			Context:           ctx,
+			NewResource:       *resourceUnstructured,
+			ConfigMapResolver: resolver,
+		})
+	}
+
+	allowedRegistry := func(registry string) ConfigMapResolver {
+		return fakeConfigMapResolver{
+			"default/allowed-registries": &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "allowed-registries", Namespace: "default"},
+				Data:       map[string]string{"registry": registry},
+			},
+		}
+	}
+
+	er := validate(allowedRegistry("registry.corp.com"))
+	assert.Assert(t, er.PolicyResponse.Rules[0].Success)
+
+	er = validate(allowedRegistry("docker.io"))
+	assert.Assert(t, !er.PolicyResponse.Rules[0].Success)
+	assert.Assert(t, strings.Contains(er.PolicyResponse.Rules[0].Message, "images must be pulled from docker.io"))
+
+	// the rule fails if the ConfigMap cannot be loaded
+	er = validate(fakeConfigMapResolver{})
+	assert.Assert(t, !er.PolicyResponse.Rules[0].Success)
+	assert.Assert(t, strings.Contains(er.PolicyResponse.Rules[0].Message, "failed to load context"))
+}
+
+func Test_ImageVariables(t *testing.T) {
+	policyraw := []byte(`{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "disallow-latest-tag"
+		},
+		"spec": {
+		  "rules": [
+			{
+			  "name": "disallow-latest-tag",
+			  "match": {
+				"resources": {
+				  "kinds": [
+					"Pod"
+				  ]
+				}
+			  },
+			  "preconditions": [
+				{
+				  "key": "{{ contains(images.containers.*.tag, 'latest') }}",
+				  "operator": "Equal",
+				  "value": true
+				}
+			  ],
+			  "validate": {
+				"message": "the image tag latest is not allowed",
+				"pattern": {
+				  "metadata": {
+					"name": "!*"
+				  }
+				}
+			  }
+			}
+		  ]
+		}
+	  }`)
+
+	validate := func(image string) response.EngineResponse {
+		resourceRaw := []byte(fmt.Sprintf(`{
+			"apiVersion": "v1",
+			"kind": "Pod",
+			"metadata": {
+			  "name": "test"
+			},
+			"spec": {
+			  "containers": [
+				{
+				  "name": "app",
+				  "image": "%s"
+				}
+			  ]
+			}
+		  }`, image))
+		resourceUnstructured, err := utils.ConvertToUnstructured(resourceRaw)
+		assert.NilError(t, err)
+		var policy kyverno.ClusterPolicy
+		assert.NilError(t, json.Unmarshal(policyraw, &policy))
+		ctx := context.NewContext()
+		assert.NilError(t, ctx.AddResource(resourceRaw))
+		return Validate(PolicyContext{Policy: policy, NewResource: *resourceUnstructured, Context: ctx})
+	}
+
+	for image, success := range map[string]bool{
+		"nginx":                            false,
+		"nginx:latest":                     false,
+		"nginx:1.17":                       true,
+		"registry.corp.com:5000/app:1.0.1": true,
+	} {
+		er := validate(image)
+		assert.Equal(t, er.IsSuccesful(), success, image)
+	}
+}
@@ -5,13 +5,14 @@ import (
 	"reflect"
 	"strconv"
 
-	"github.com/golang/glog"
 	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
 )
 
 //NewEqualHandler returns handler to manage Equal operations
-func NewEqualHandler(ctx context.EvalInterface, subHandler VariableSubstitutionHandler) OperatorHandler {
+func NewEqualHandler(log log.Logger, ctx context.EvalInterface, subHandler VariableSubstitutionHandler) OperatorHandler {
 	return EqualHandler{
+		log:        log,
 		ctx:        ctx,
 		subHandler: subHandler,
 	}
@@ -19,6 +20,7 @@ func NewEqualHandler(ctx context.EvalInterface, subHandler VariableSubstitutionH
 
 //EqualHandler provides implementation to handle NotEqual Operator
 type EqualHandler struct {
+	log        log.Logger
 	ctx        context.EvalInterface
 	subHandler VariableSubstitutionHandler
 }
@@ -45,7 +47,7 @@ func (eh EqualHandler) Evaluate(key, value interface{}) bool {
 	case []interface{}:
 		return eh.validateValueWithSlicePattern(typedKey, nValue)
 	default:
-		glog.Errorf("Unsupported type %v", typedKey)
+		eh.log.Errorf("Unsupported type %v", typedKey)
 		return false
 	}
 }
@@ -54,7 +56,7 @@ func (eh EqualHandler) validateValueWithSlicePattern(key []interface{}, value in
 	if val, ok := value.([]interface{}); ok {
 		return reflect.DeepEqual(key, val)
 	}
-	glog.Warningf("Expected []interface{}, %v is of type %T", value, value)
+	eh.log.Warningf("Expected []interface{}, %v is of type %T", value, value)
 	return false
 }
 
@@ -62,7 +64,7 @@ func (eh EqualHandler) validateValueWithMapPattern(key map[string]interface{}, v
 	if val, ok := value.(map[string]interface{}); ok {
 		return reflect.DeepEqual(key, val)
 	}
-	glog.Warningf("Expected map[string]interface{}, %v is of type %T", value, value)
+	eh.log.Warningf("Expected map[string]interface{}, %v is of type %T", value, value)
 	return false
 }
 
@@ -70,7 +72,7 @@ func (eh EqualHandler) validateValuewithStringPattern(key string, value interfac
 	if val, ok := value.(string); ok {
 		return key == val
 	}
-	glog.Warningf("Expected string, %v is of type %T", value, value)
+	eh.log.Warningf("Expected string, %v is of type %T", value, value)
 	return false
 }
 
@@ -81,25 +83,25 @@ func (eh EqualHandler) validateValuewithFloatPattern(key float64, value interfac
 		if key == math.Trunc(key) {
 			return int(key) == typedValue
 		}
-		glog.Warningf("Expected float, found int: %d\n", typedValue)
+		eh.log.Warningf("Expected float, found int: %d\n", typedValue)
 	case int64:
 		// check that float has not fraction
 		if key == math.Trunc(key) {
 			return int64(key) == typedValue
 		}
-		glog.Warningf("Expected float, found int: %d\n", typedValue)
+		eh.log.Warningf("Expected float, found int: %d\n", typedValue)
 	case float64:
 		return typedValue == key
 	case string:
 		// extract float from string
 		float64Num, err := strconv.ParseFloat(typedValue, 64)
 		if err != nil {
-			glog.Warningf("Failed to parse float64 from string: %v", err)
+			eh.log.Warningf("Failed to parse float64 from string: %v", err)
 			return false
 		}
 		return float64Num == key
 	default:
-		glog.Warningf("Expected float, found: %T\n", value)
+		eh.log.Warningf("Expected float, found: %T\n", value)
 		return false
 	}
 	return false
@@ -108,7 +110,7 @@ func (eh EqualHandler) validateValuewithFloatPattern(key float64, value interfac
 func (eh EqualHandler) validateValuewithBoolPattern(key bool, value interface{}) bool {
 	typedValue, ok := value.(bool)
 	if !ok {
-		glog.Error("Expected bool, found %V", value)
+		eh.log.Error("Expected bool, found %V", value)
 		return false
 	}
 	return key == typedValue
@@ -125,18 +127,18 @@ func (eh EqualHandler) validateValuewithIntPattern(key int64, value interface{})
 		if typedValue == math.Trunc(typedValue) {
 			return int64(typedValue) == key
 		}
-		glog.Warningf("Expected int, found float: %f", typedValue)
+		eh.log.Warningf("Expected int, found float: %f", typedValue)
 		return false
 	case string:
 		// extract in64 from string
 		int64Num, err := strconv.ParseInt(typedValue, 10, 64)
 		if err != nil {
-			glog.Warningf("Failed to parse int64 from string: %v", err)
+			eh.log.Warningf("Failed to parse int64 from string: %v", err)
 			return false
 		}
 		return int64Num == key
 	default:
-		glog.Warningf("Expected int, %v is of type %T", value, value)
+		eh.log.Warningf("Expected int, %v is of type %T", value, value)
 		return false
 	}
 }
@@ -1,9 +1,9 @@
 package operator
 
 import (
-	"github.com/golang/glog"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
 )
 
 //OperatorHandler provides interface to manage types
@@ -19,15 +19,18 @@ type OperatorHandler interface {
 //VariableSubstitutionHandler defines the handler function for variable substitution
 type VariableSubstitutionHandler = func(ctx context.EvalInterface, pattern interface{}) interface{}
 
+//SupportedOperators are the operators with a handler, the conditions with another operator are never satisfied
+var SupportedOperators = []kyverno.ConditionOperator{kyverno.Equal, kyverno.NotEqual}
+
 //CreateOperatorHandler returns the operator handler based on the operator used in condition
-func CreateOperatorHandler(ctx context.EvalInterface, op kyverno.ConditionOperator, subHandler VariableSubstitutionHandler) OperatorHandler {
+func CreateOperatorHandler(log log.Logger, ctx context.EvalInterface, op kyverno.ConditionOperator, subHandler VariableSubstitutionHandler) OperatorHandler {
 	switch op {
 	case kyverno.Equal:
-		return NewEqualHandler(ctx, subHandler)
+		return NewEqualHandler(log, ctx, subHandler)
 	case kyverno.NotEqual:
-		return NewNotEqualHandler(ctx, subHandler)
+		return NewNotEqualHandler(log, ctx, subHandler)
 	default:
-		glog.Errorf("unsupported operator: %s", string(op))
+		log.Errorf("unsupported operator: %s", string(op))
 	}
 	return nil
 }
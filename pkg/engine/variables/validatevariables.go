@@ -1,11 +1,9 @@
 package variables
 
 import (
-	"fmt"
 	"regexp"
 	"strings"
 
-	"github.com/golang/glog"
 	"github.com/nirmata/kyverno/pkg/engine/context"
 )
 
@@ -16,9 +14,7 @@ func ValidateVariables(ctx context.EvalInterface, pattern interface{}) string {
 	variableList := extractVariables(pattern)
 	for _, variable := range variableList {
 		if len(variable) == 2 {
-			varName := variable[0]
 			varValue := variable[1]
-			glog.V(3).Infof("validating variable %s", varName)
 			val, err := ctx.Query(varValue)
 			if err == nil && val == nil {
 				// path is not present, returns nil interface
@@ -43,7 +39,6 @@ func extractVariables(pattern interface{}) [][]string {
 	case string:
 		return extractValue(typedPattern)
 	default:
-		fmt.Printf("variable type %T", typedPattern)
 		return nil
 	}
 }
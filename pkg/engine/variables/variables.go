@@ -4,7 +4,6 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/golang/glog"
 	"github.com/nirmata/kyverno/pkg/engine/context"
 	"github.com/nirmata/kyverno/pkg/engine/operator"
 )
@@ -66,7 +65,7 @@ func substituteValue(ctx context.EvalInterface, valuePattern string) interface{}
 	case string:
 		return string(operatorVariable) + value.(string)
 	default:
-		glog.Infof("cannot use operator with object variables. operator used %s in pattern %v", string(operatorVariable), valuePattern)
+		// operators cannot be used with object variables
 		var emptyInterface interface{}
 		return emptyInterface
 	}
@@ -114,7 +113,7 @@ func getValues(ctx context.EvalInterface, groups [][]string) map[string]interfac
 			varValue := group[1]
 			variable, err := ctx.Query(varValue)
 			if err != nil {
-				glog.V(4).Infof("variable substitution failed for query %s: %v", varName, err)
+				// the queries are checked when the policy is created, the variable is empty
 				subs[varName] = emptyInterface
 				continue
 			}
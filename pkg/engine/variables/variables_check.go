@@ -3,7 +3,11 @@ package variables
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+
+	"github.com/nirmata/kyverno/pkg/engine/jmespath"
 )
 
 //CheckVariables checks if the variable regex has been used
@@ -76,5 +80,62 @@ func variablePatternSearch(pattern string, regexs []string) bool {
 			return true
 		}
 	}
-	return true
+	return false
+}
+
+//CheckVariablePaths checks if the variables are valid JMESPath expressions that reference one of the allowed paths
+// returns the path of the first invalid variable, the keys of the maps are checked in sorted order
+func CheckVariablePaths(pattern interface{}, allowedPaths []string, path string) (string, error) {
+	switch typedPattern := pattern.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typedPattern))
+		for patternKey := range typedPattern {
+			keys = append(keys, patternKey)
+		}
+		sort.Strings(keys)
+		for _, patternKey := range keys {
+			if errPath, err := CheckVariablePaths(typedPattern[patternKey], allowedPaths, path+patternKey+"/"); err != nil {
+				return errPath, err
+			}
+		}
+	case []interface{}:
+		for idx, patternElement := range typedPattern {
+			if errPath, err := CheckVariablePaths(patternElement, allowedPaths, path+strconv.Itoa(idx)+"/"); err != nil {
+				return errPath, err
+			}
+		}
+	case string:
+		for _, variable := range extractValue(typedPattern) {
+			// variable[0] -> {{variable}}
+			// variable[1] -> variable
+			if err := checkVariablePath(strings.TrimSpace(variable[1]), allowedPaths); err != nil {
+				return strings.TrimSuffix(path, "/"), fmt.Errorf("invalid variable %s: %v", variable[0], err)
+			}
+		}
+	}
+	return "", nil
+}
+
+// checkVariablePath checks the paths referenced by the expression start with one of the allowed paths,
+// the arguments of the functions are checked e.g. to_upper(request.object.metadata.name)
+func checkVariablePath(variable string, allowedPaths []string) error {
+	refs, err := jmespath.References(variable)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if !isAllowedPath(ref, allowedPaths) {
+			return fmt.Errorf("%s is not defined, variable must reference one of %s", ref, strings.Join(allowedPaths, ", "))
+		}
+	}
+	return nil
+}
+
+func isAllowedPath(ref string, allowedPaths []string) bool {
+	for _, allowed := range allowedPaths {
+		if ref == allowed || strings.HasPrefix(ref, allowed+".") {
+			return true
+		}
+	}
+	return false
 }
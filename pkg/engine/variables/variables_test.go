@@ -595,3 +595,27 @@ func Test_variableSubstitutionMultipleObject(t *testing.T) {
 		t.Error("result does not match")
 	}
 }
+
+func Test_CheckVariablePaths(t *testing.T) {
+	pattern := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"b": "{{request.objct.metadata.name}}",
+			"a": []interface{}{"{{request.object.metadata.name}}", "{{unknown.name}}"},
+		},
+	}
+	allowed := []string{"request.object"}
+	// the first invalid variable in the sorted order of the keys is returned, without a trailing slash
+	for i := 0; i < 10; i++ {
+		path, err := CheckVariablePaths(pattern, allowed, "/")
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if path != "/spec/a/1" {
+			t.Errorf("expected path /spec/a/1, got %s", path)
+		}
+	}
+	path, err := CheckVariablePaths(pattern["spec"].(map[string]interface{})["a"].([]interface{})[0], allowed, "")
+	if err != nil || path != "" {
+		t.Errorf("expected a valid variable, got %s: %v", path, err)
+	}
+}
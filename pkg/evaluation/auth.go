@@ -0,0 +1,140 @@
+package evaluation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	kubernetes "k8s.io/client-go/kubernetes"
+)
+
+// the resource of the evaluations, the callers of the API need the permission to create it
+const (
+	EvaluationsGroup    = "kyverno.io"
+	EvaluationsResource = "evaluations"
+)
+
+// the decisions are cached for a short time, so the revoked tokens and permissions are rejected soon
+const (
+	authCacheTTL       = 10 * time.Second
+	maxCachedDecisions = 1000
+)
+
+var (
+	errUnauthenticated = errors.New("the bearer token is not valid")
+	errForbidden       = errors.New("the user is not allowed to create evaluations")
+)
+
+// Authorizer authenticates the bearer tokens of the callers of the API and authorizes their users
+type Authorizer interface {
+	// Authorize returns the user of the token, errUnauthenticated if the token is not valid
+	// and errForbidden if the user is not allowed to evaluate the resources of the namespace,
+	// the namespace is empty for the cluster-wide resources
+	Authorize(token, namespace string) (*authenticationv1.UserInfo, error)
+}
+
+// kubeAuthorizer authenticates the tokens with TokenReviews and authorizes the users with SubjectAccessReviews,
+// the users are allowed with the permission to create the evaluations of the kyverno.io group in the namespace of the resource,
+// e.g. with a ClusterRole
+type kubeAuthorizer struct {
+	client kubernetes.Interface
+	mu     sync.Mutex
+	// decisions are the cached decisions per token hash and namespace
+	decisions map[string]authDecision
+	// now is replaced in tests
+	now func() time.Time
+}
+
+type authDecision struct {
+	user    *authenticationv1.UserInfo
+	err     error
+	expires time.Time
+}
+
+// NewAuthorizer returns the authorizer of the callers of the API, the Kubernetes users and service accounts
+func NewAuthorizer(client kubernetes.Interface) Authorizer {
+	return &kubeAuthorizer{client: client, decisions: map[string]authDecision{}, now: time.Now}
+}
+
+func (a *kubeAuthorizer) Authorize(token, namespace string) (*authenticationv1.UserInfo, error) {
+	if token == "" {
+		return nil, errUnauthenticated
+	}
+	hash := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(hash[:]) + "/" + namespace
+	a.mu.Lock()
+	decision, ok := a.decisions[key]
+	a.mu.Unlock()
+	if ok && a.now().Before(decision.expires) {
+		return decision.user, decision.err
+	}
+	user, err := a.review(token, namespace)
+	if err != nil && err != errUnauthenticated && err != errForbidden {
+		// the failed reviews are not cached
+		return nil, err
+	}
+	a.store(key, authDecision{user: user, err: err, expires: a.now().Add(authCacheTTL)})
+	return user, err
+}
+
+// store caches the decision, the expired decisions are removed when the cache is full
+func (a *kubeAuthorizer) store(key string, decision authDecision) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.decisions) >= maxCachedDecisions {
+		now := a.now()
+		for k, d := range a.decisions {
+			if !now.Before(d.expires) {
+				delete(a.decisions, k)
+			}
+		}
+		if len(a.decisions) >= maxCachedDecisions {
+			a.decisions = map[string]authDecision{}
+		}
+	}
+	a.decisions[key] = decision
+}
+
+// review authenticates the token with a TokenReview and authorizes its user with a SubjectAccessReview
+func (a *kubeAuthorizer) review(token, namespace string) (*authenticationv1.UserInfo, error) {
+	review, err := a.client.AuthenticationV1().TokenReviews().Create(&authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to review the token: %v", err)
+	}
+	if !review.Status.Authenticated {
+		return nil, errUnauthenticated
+	}
+	user := review.Status.User
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	access, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(&authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Group:     EvaluationsGroup,
+				Resource:  EvaluationsResource,
+				Verb:      "create",
+			},
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to review the access of %s: %v", user.Username, err)
+	}
+	if !access.Status.Allowed {
+		return nil, errForbidden
+	}
+	return &user, nil
+}
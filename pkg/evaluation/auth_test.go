@@ -0,0 +1,67 @@
+package evaluation
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func Test_Authorize(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	reviews := 0
+	client.PrependReactor("create", "tokenreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		review := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		switch review.Spec.Token {
+		case "ci":
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "system:serviceaccount:ci:pipeline"}}
+		case "dev":
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "dev", Groups: []string{"developers"}}}
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = review.Spec.User == "system:serviceaccount:ci:pipeline" && attributes.Namespace == "ci" &&
+			attributes.Group == EvaluationsGroup && attributes.Resource == EvaluationsResource && attributes.Verb == "create"
+		return true, review, nil
+	})
+	authorizer := NewAuthorizer(client).(*kubeAuthorizer)
+	now := time.Now()
+	authorizer.now = func() time.Time { return now }
+
+	user, err := authorizer.Authorize("ci", "ci")
+	assert.NilError(t, err)
+	assert.Equal(t, user.Username, "system:serviceaccount:ci:pipeline")
+	// the user is not allowed in the other namespaces and for the cluster-wide resources
+	_, err = authorizer.Authorize("ci", "default")
+	assert.Equal(t, err, errForbidden)
+	_, err = authorizer.Authorize("ci", "")
+	assert.Equal(t, err, errForbidden)
+	_, err = authorizer.Authorize("dev", "ci")
+	assert.Equal(t, err, errForbidden)
+	_, err = authorizer.Authorize("invalid", "ci")
+	assert.Equal(t, err, errUnauthenticated)
+	_, err = authorizer.Authorize("", "ci")
+	assert.Equal(t, err, errUnauthenticated)
+	assert.Equal(t, reviews, 5)
+
+	// the decisions are cached until they expire
+	user, err = authorizer.Authorize("ci", "ci")
+	assert.NilError(t, err)
+	assert.Equal(t, user.Username, "system:serviceaccount:ci:pipeline")
+	_, err = authorizer.Authorize("invalid", "ci")
+	assert.Equal(t, err, errUnauthenticated)
+	assert.Equal(t, reviews, 5)
+	now = now.Add(authCacheTTL)
+	_, err = authorizer.Authorize("ci", "ci")
+	assert.NilError(t, err)
+	assert.Equal(t, reviews, 6)
+}
@@ -0,0 +1,108 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"fmt"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/policyengine"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// statuses of the results of the rules
+const (
+	StatusPass  = policyengine.StatusPass
+	StatusFail  = policyengine.StatusFail
+	StatusError = policyengine.StatusError
+)
+
+// Request is the body of an evaluation request
+type Request struct {
+	// Resource is the resource the policies are applied on
+	Resource map[string]interface{} `json:"resource"`
+	// OldResource is the resource before the update, the resource is evaluated as created if it is not set
+	OldResource map[string]interface{} `json:"oldResource,omitempty"`
+	// Policies are the names of the policies to apply, namespace/name for the namespaced policies,
+	// all the policies matching the kind and the namespace of the resource if empty
+	Policies []string `json:"policies,omitempty"`
+}
+
+// Response is the body of the response of an evaluation request
+type Response struct {
+	// Allowed is false if a rule of an enforced policy fails, the request would be rejected by the admission webhook
+	Allowed bool `json:"allowed"`
+	// Message is the message of the admission webhook if the request is not allowed
+	Message string `json:"message,omitempty"`
+	// Patches are the JSON patches of the mutate rules
+	Patches []json.RawMessage `json:"patches,omitempty"`
+	// Resource is the resource mutated by the policies
+	Resource map[string]interface{} `json:"resource"`
+	// Policies are the results of the policies applied on the resource
+	Policies []PolicyResult `json:"policies"`
+}
+
+// PolicyResult is the result of a policy
+type PolicyResult = policyengine.PolicyResult
+
+// RuleResult is the result of a rule of a policy
+type RuleResult = policyengine.RuleResult
+
+// Evaluate applies the policies on the resource of the request as the admission webhook does: the resource is mutated
+// by all the policies before it is validated. The policies are the candidates of the kind and the namespace of the resource,
+// the user info is the authenticated user of the request with its roles and cluster roles.
+func (s *Server) Evaluate(request Request, userInfo kyverno.RequestInfo, policies []kyverno.ClusterPolicy) (*Response, error) {
+	if len(request.Resource) == 0 {
+		return nil, fmt.Errorf("the resource is required")
+	}
+	engineRequest := policyengine.Request{Resource: unstructured.Unstructured{Object: request.Resource}, UserInfo: userInfo}
+	if len(request.OldResource) != 0 {
+		engineRequest.OldResource.Object = request.OldResource
+	}
+	options := policyengine.Options{
+		Client:            s.client,
+		ConfigMapResolver: s.configMapResolver,
+		RegistryClient:    s.registryClient,
+		ServiceClient:     s.serviceClient,
+		GlobalContext:     s.globalContext,
+		Log:               enginelog.Glog,
+	}
+	if s.exceptionStore != nil {
+		// the exceptions exempt the resources as in the admission webhook
+		options.Exceptions = s.exceptionStore.LookUp(engineRequest.Resource.GetNamespace())
+	}
+	policyEngine := policyengine.New(selectPolicies(policies, request.Policies), options)
+	result, err := policyEngine.Evaluate(engineRequest)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		Allowed:  result.Allowed(),
+		Message:  result.Message(),
+		Patches:  result.Patches,
+		Resource: result.Resource.Object,
+		Policies: result.Policies,
+	}, nil
+}
+
+// selectPolicies returns the policies with the names, all the policies if there is no name
+func selectPolicies(policies []kyverno.ClusterPolicy, names []string) []kyverno.ClusterPolicy {
+	if len(names) == 0 {
+		return policies
+	}
+	selected := map[string]bool{}
+	for _, name := range names {
+		selected[name] = true
+	}
+	var filtered []kyverno.ClusterPolicy
+	for _, policy := range policies {
+		key := policy.Name
+		if policy.Namespace != "" {
+			key = policy.Namespace + "/" + policy.Name
+		}
+		if selected[key] {
+			filtered = append(filtered, policy)
+		}
+	}
+	return filtered
+}
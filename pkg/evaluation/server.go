@@ -0,0 +1,229 @@
+package evaluation
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine"
+	"github.com/nirmata/kyverno/pkg/externaldata"
+	"github.com/nirmata/kyverno/pkg/policyexception"
+	"github.com/nirmata/kyverno/pkg/policystore"
+	"github.com/nirmata/kyverno/pkg/registry"
+	tlsutils "github.com/nirmata/kyverno/pkg/tls"
+	"github.com/nirmata/kyverno/pkg/userinfo"
+	"k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	rbacinformer "k8s.io/client-go/informers/rbac/v1"
+	rbaclister "k8s.io/client-go/listers/rbac/v1"
+)
+
+// Path is the path of the evaluation endpoint
+const Path = "/evaluate"
+
+// maxRequestSize is the maximum size of the body of an evaluation request, the size of the resources is limited by etcd
+const maxRequestSize = 3 << 20
+
+// Server serves the evaluation API: the external tools, e.g. the CI systems, post a resource and get the results of the policies
+// of the cluster, as if the resource was admitted, without creating it
+type Server struct {
+	server     http.Server
+	authorizer Authorizer
+	// look up the policies of the kind and of the namespace of the resources
+	pMetaStore policystore.LookupInterface
+	// the clients of the rule context, as in the admission webhook
+	client            engine.Client
+	configMapResolver engine.ConfigMapResolver
+	registryClient    registry.Interface
+	serviceClient     externaldata.Interface
+	globalContext     *engine.GlobalContext
+	// look up the policy exceptions of the namespace of the resources
+	exceptionStore policyexception.LookupInterface
+	// list the bindings of the roles of the users
+	rbLister  rbaclister.RoleBindingLister
+	crbLister rbaclister.ClusterRoleBindingLister
+}
+
+// NewServer returns the evaluation server listening on the address with the TLS certificate of the webhook server
+func NewServer(
+	addr string,
+	tlsPair *tlsutils.TlsPemPair,
+	authorizer Authorizer,
+	pMetaStore policystore.LookupInterface,
+	client engine.Client,
+	configMapResolver engine.ConfigMapResolver,
+	registryClient registry.Interface,
+	serviceClient externaldata.Interface,
+	globalContext *engine.GlobalContext,
+	exceptionStore policyexception.LookupInterface,
+	rbInformer rbacinformer.RoleBindingInformer,
+	crbInformer rbacinformer.ClusterRoleBindingInformer) (*Server, error) {
+	pair, err := tls.X509KeyPair(tlsPair.Certificate, tlsPair.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		authorizer:        authorizer,
+		pMetaStore:        pMetaStore,
+		client:            client,
+		configMapResolver: configMapResolver,
+		registryClient:    registryClient,
+		serviceClient:     serviceClient,
+		globalContext:     globalContext,
+		exceptionStore:    exceptionStore,
+		rbLister:          rbInformer.Lister(),
+		crbLister:         crbInformer.Lister(),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(Path, s.serve)
+	s.server = http.Server{
+		Addr:         addr,
+		TLSConfig:    &tls.Config{Certificates: []tls.Certificate{pair}},
+		Handler:      mux,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	return s, nil
+}
+
+// Run serves the evaluation requests until stopCh is closed
+func (s *Server) Run(stopCh <-chan struct{}) {
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.server.Shutdown(ctx)
+	}()
+	glog.Infof("serving the evaluation API on %s", s.server.Addr)
+	if err := s.server.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
+		glog.Errorf("evaluation server failed: %v", err)
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		http.Error(w, errUnauthenticated.Error(), http.StatusUnauthorized)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		http.Error(w, "invalid Content-Type, expect `application/json`", http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read the request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxRequestSize {
+		http.Error(w, "the request is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	var request Request
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(request.Resource) == 0 {
+		http.Error(w, "invalid request: the resource is required", http.StatusBadRequest)
+		return
+	}
+	kind, _ := request.Resource["kind"].(string)
+	if kind == "" {
+		http.Error(w, "invalid request: the kind of the resource is required", http.StatusBadRequest)
+		return
+	}
+	var namespace, name string
+	if metadata, ok := request.Resource["metadata"].(map[string]interface{}); ok {
+		namespace, _ = metadata["namespace"].(string)
+		name, _ = metadata["name"].(string)
+	}
+	// the user is allowed to evaluate the resources of the namespace
+	user, err := s.authorizer.Authorize(token, namespace)
+	switch err {
+	case nil:
+	case errUnauthenticated:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case errForbidden:
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	default:
+		glog.Errorf("Failed to authorize the evaluation request: %v", err)
+		http.Error(w, "failed to authorize the request", http.StatusInternalServerError)
+		return
+	}
+	apiVersion, _ := request.Resource["apiVersion"].(string)
+	policies, err := s.pMetaStore.LookUpRules(schema.FromAPIVersionAndKind(apiVersion, kind), namespace)
+	if err != nil {
+		glog.Errorf("Failed to look up the policies of %s: %v", kind, err)
+		http.Error(w, "failed to look up the policies", http.StatusInternalServerError)
+		return
+	}
+	glog.V(4).Infof("evaluation request of %s: %s %s/%s", user.Username, kind, namespace, name)
+	userInfo, err := s.userInfo(*user, policies)
+	if err != nil {
+		glog.Errorf("Failed to get the roles of %s: %v", user.Username, err)
+		http.Error(w, "failed to get the roles of the user", http.StatusInternalServerError)
+		return
+	}
+	response, err := s.Evaluate(request, userInfo, policies)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if _, err := w.Write(data); err != nil {
+		glog.Errorf("Failed to write the evaluation response: %v", err)
+	}
+}
+
+// userInfo returns the user info of the authenticated user, its roles and cluster roles are listed
+// only if a policy uses the roles, as in the admission webhook
+func (s *Server) userInfo(user authenticationv1.UserInfo, policies []kyverno.ClusterPolicy) (kyverno.RequestInfo, error) {
+	userInfo := kyverno.RequestInfo{AdmissionUserInfo: user}
+	if !usesRoles(policies) {
+		return userInfo, nil
+	}
+	roles, clusterRoles, err := userinfo.GetRoleRef(s.rbLister, s.crbLister, &v1beta1.AdmissionRequest{UserInfo: user})
+	if err != nil {
+		return userInfo, err
+	}
+	userInfo.Roles = roles
+	userInfo.ClusterRoles = clusterRoles
+	return userInfo, nil
+}
+
+// usesRoles checks if a rule matches the roles or the cluster roles of the users, or uses them as variables
+func usesRoles(policies []kyverno.ClusterPolicy) bool {
+	for _, policy := range policies {
+		for _, rule := range policy.Spec.Rules {
+			if len(rule.MatchResources.Roles) > 0 || len(rule.MatchResources.ClusterRoles) > 0 {
+				return true
+			}
+			raw, err := json.Marshal(rule)
+			if err == nil && (strings.Contains(string(raw), "request.roles") || strings.Contains(string(raw), "request.clusterRoles")) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"gotest.tools/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fakeAuthorizer allows the tokens with a nil error in all the namespaces but kube-system
+type fakeAuthorizer map[string]error
+
+func (a fakeAuthorizer) Authorize(token, namespace string) (*authenticationv1.UserInfo, error) {
+	if namespace == "kube-system" {
+		return nil, errForbidden
+	}
+	err, ok := a[token]
+	if !ok {
+		return nil, errUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &authenticationv1.UserInfo{Username: "system:serviceaccount:ci:pipeline"}, nil
+}
+
+type fakeExceptions []kyverno.PolicyException
+
+func (e fakeExceptions) LookUp(namespace string) []kyverno.PolicyException {
+	var exceptions []kyverno.PolicyException
+	for _, exception := range e {
+		if exception.Namespace == namespace {
+			exceptions = append(exceptions, exception)
+		}
+	}
+	return exceptions
+}
+
+type fakeStore []kyverno.ClusterPolicy
+
+func (s fakeStore) LookUp(gvk schema.GroupVersionKind, namespace string) ([]kyverno.ClusterPolicy, error) {
+	return s, nil
+}
+
+func (s fakeStore) LookUpRules(gvk schema.GroupVersionKind, namespace string) ([]kyverno.ClusterPolicy, error) {
+	return s, nil
+}
+
+const testPolicies = `[
+{
+  "apiVersion": "kyverno.io/v1",
+  "kind": "ClusterPolicy",
+  "metadata": {"name": "add-labels"},
+  "spec": {
+    "rules": [{
+      "name": "add-team",
+      "match": {"resources": {"kinds": ["Pod"]}},
+      "mutate": {"overlay": {"metadata": {"labels": {"+(team)": "web"}}}}
+    }]
+  }
+},
+{
+  "apiVersion": "kyverno.io/v1",
+  "kind": "ClusterPolicy",
+  "metadata": {"name": "require-labels"},
+  "spec": {
+    "validationFailureAction": "enforce",
+    "rules": [{
+      "name": "check-team",
+      "match": {"resources": {"kinds": ["Pod"]}},
+      "validate": {"message": "The label team is required.", "pattern": {"metadata": {"labels": {"team": "?*"}}}}
+    }, {
+      "name": "check-app",
+      "match": {"resources": {"kinds": ["Pod"]}},
+      "validate": {"message": "The label app is required.", "pattern": {"metadata": {"labels": {"app": "?*"}}}}
+    }]
+  }
+}]`
+
+const testRequest = `{
+  "resource": {
+    "apiVersion": "v1",
+    "kind": "Pod",
+    "metadata": {"name": "web", "namespace": "default", "labels": {"app": "web"}},
+    "spec": {"containers": [{"name": "nginx", "image": "nginx"}]}
+  }
+}`
+
+func newTestServer(t *testing.T) *Server {
+	var policies []kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal([]byte(testPolicies), &policies))
+	return &Server{
+		authorizer: fakeAuthorizer{"token": nil, "forbidden": errForbidden},
+		pMetaStore: fakeStore(policies),
+	}
+}
+
+func post(s *Server, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, Path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.serve(w, r)
+	return w
+}
+
+func Test_Evaluate(t *testing.T) {
+	s := newTestServer(t)
+	w := post(s, "token", testRequest)
+	assert.Equal(t, w.Code, http.StatusOK, w.Body.String())
+	var response Response
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, response.Allowed, true)
+	assert.Equal(t, len(response.Patches), 1)
+	labels := response.Resource["metadata"].(map[string]interface{})["labels"].(map[string]interface{})
+	assert.DeepEqual(t, labels, map[string]interface{}{"app": "web", "team": "web"})
+	assert.Equal(t, len(response.Policies), 2)
+	assert.Equal(t, response.Policies[0].Name, "add-labels")
+	assert.Equal(t, response.Policies[0].Rules[0].Type, "Mutation")
+	assert.Equal(t, response.Policies[0].Rules[0].Status, StatusPass)
+	assert.Equal(t, response.Policies[1].ValidationFailureAction, "enforce")
+	assert.Equal(t, len(response.Policies[1].Rules), 2)
+
+	// the rules of the policy that is not selected are not applied
+	w = post(s, "token", `{"policies": ["require-labels"], "resource": {"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "web"}}}`)
+	assert.Equal(t, w.Code, http.StatusOK, w.Body.String())
+	response = Response{}
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, response.Allowed, false)
+	assert.Equal(t, len(response.Patches), 0)
+	assert.Equal(t, len(response.Policies), 1)
+	assert.Equal(t, response.Policies[0].Rules[0].Status, StatusFail)
+	assert.Assert(t, strings.Contains(response.Message, "The label team is required."), response.Message)
+	assert.Assert(t, strings.Contains(response.Message, "The label app is required."), response.Message)
+}
+
+func Test_EvaluateErrors(t *testing.T) {
+	s := newTestServer(t)
+	testCases := []struct {
+		token string
+		body  string
+		code  int
+	}{
+		{token: "", body: testRequest, code: http.StatusUnauthorized},
+		{token: "invalid", body: testRequest, code: http.StatusUnauthorized},
+		{token: "forbidden", body: testRequest, code: http.StatusForbidden},
+		{token: "token", body: `{"resource": {"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "web", "namespace": "kube-system"}}}`, code: http.StatusForbidden},
+		{token: "token", body: `{"resource": `, code: http.StatusBadRequest},
+		{token: "token", body: `{"policies": ["require-labels"]}`, code: http.StatusBadRequest},
+		{token: "token", body: `{"resource": {"metadata": {"name": "web"}}}`, code: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		w := post(s, tc.token, tc.body)
+		assert.Equal(t, w.Code, tc.code, "%s %s: %s", tc.token, tc.body, w.Body.String())
+	}
+
+	r := httptest.NewRequest(http.MethodGet, Path, nil)
+	w := httptest.NewRecorder()
+	s.serve(w, r)
+	assert.Equal(t, w.Code, http.StatusMethodNotAllowed)
+}
+
+func Test_EvaluateExceptions(t *testing.T) {
+	s := newTestServer(t)
+	exception := func(name string, expiresAt time.Time) kyverno.PolicyException {
+		return kyverno.PolicyException{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: kyverno.PolicyExceptionSpec{
+				Exceptions:    []kyverno.Exception{{PolicyName: "require-labels", RuleNames: []string{"check-app"}}},
+				Match:         kyverno.ResourceDescription{Kinds: []string{"Pod"}, Name: "legacy"},
+				Justification: "migrated next quarter",
+				ExpiresAt:     &metav1.Time{Time: expiresAt},
+			},
+		}
+	}
+	body := `{"resource": {"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "legacy", "namespace": "default"}}}`
+
+	// the active exception exempts the pod from the rule check-app
+	s.exceptionStore = fakeExceptions{exception("legacy", time.Now().Add(time.Hour))}
+	w := post(s, "token", body)
+	assert.Equal(t, w.Code, http.StatusOK, w.Body.String())
+	var response Response
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, response.Allowed, true, response.Message)
+	for _, policy := range response.Policies {
+		for _, rule := range policy.Rules {
+			assert.Assert(t, rule.Name != "check-app", "rule check-app is applied")
+		}
+	}
+
+	// the expired exception is ignored
+	s.exceptionStore = fakeExceptions{exception("legacy", time.Now().Add(-time.Hour))}
+	w = post(s, "token", body)
+	assert.Equal(t, w.Code, http.StatusOK, w.Body.String())
+	response = Response{}
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, response.Allowed, false)
+	assert.Assert(t, strings.Contains(response.Message, "The label app is required."), response.Message)
+}
+
+func Test_EvaluateUserInfo(t *testing.T) {
+	s := newTestServer(t)
+	var policy kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal([]byte(`{
+  "apiVersion": "kyverno.io/v1",
+  "kind": "ClusterPolicy",
+  "metadata": {"name": "check-user"},
+  "spec": {
+    "validationFailureAction": "enforce",
+    "rules": [{
+      "name": "check-pipeline",
+      "match": {"resources": {"kinds": ["Pod"]}},
+      "validate": {"message": "created by {{request.userInfo.username}}", "pattern": {"metadata": {"name": "admin"}}}
+    }]
+  }
+}`), &policy))
+	s.pMetaStore = fakeStore{policy}
+
+	// the user info of the request body is ignored, the user is the authenticated user of the token
+	w := post(s, "token", `{"userInfo": {"userInfo": {"username": "admin"}}, "resource": {"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "web", "namespace": "default"}}}`)
+	assert.Equal(t, w.Code, http.StatusOK, w.Body.String())
+	var response Response
+	assert.NilError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, response.Allowed, false)
+	assert.Assert(t, strings.Contains(response.Message, "created by system:serviceaccount:ci:pipeline"), response.Message)
+}
@@ -1,6 +1,8 @@
 package event
 
 import (
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -9,6 +11,8 @@ import (
 	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
 	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
 	client "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/ratelimit"
+	"github.com/nirmata/kyverno/pkg/utils"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -34,6 +38,24 @@ type Generator struct {
 	admissionCtrRecorder record.EventRecorder
 	// events generated at namespaced policy controller to process 'generate' rule
 	genPolicyRecorder record.EventRecorder
+	// limits the writes of the events
+	limiter *ratelimit.Limiter
+	// events on the policies batched until the next flush
+	batch   map[batchKey]*batchedEvents
+	batchMu sync.Mutex
+	workers utils.Workers
+}
+
+// batchKey identifies the similar events on a policy, batched in a single event
+type batchKey struct {
+	policy string
+	reason string
+	source Source
+}
+
+type batchedEvents struct {
+	message string
+	count   int
 }
 
 //Interface to generate event
@@ -41,8 +63,8 @@ type Interface interface {
 	Add(infoList ...Info)
 }
 
-//NewEventGenerator to generate a new event controller
-func NewEventGenerator(client *client.Client, pInformer kyvernoinformer.ClusterPolicyInformer) *Generator {
+//NewEventGenerator to generate a new event controller, the writes of the events are limited by limiter
+func NewEventGenerator(client *client.Client, pInformer kyvernoinformer.ClusterPolicyInformer, limiter *ratelimit.Limiter) *Generator {
 
 	gen := Generator{
 		client:               client,
@@ -52,6 +74,8 @@ func NewEventGenerator(client *client.Client, pInformer kyvernoinformer.ClusterP
 		policyCtrRecorder:    initRecorder(client, PolicyController),
 		admissionCtrRecorder: initRecorder(client, AdmissionController),
 		genPolicyRecorder:    initRecorder(client, GeneratePolicyController),
+		limiter:              limiter,
+		batch:                map[batchKey]*batchedEvents{},
 	}
 	return &gen
 }
@@ -88,10 +112,49 @@ func (gen *Generator) Add(infos ...Info) {
 			glog.V(4).Infof("received info %v, not creating an event as the resource has not been assigned a name yet", info)
 			continue
 		}
+		if info.Kind == "ClusterPolicy" {
+			// an event is reported on the policy for every resource it applies to
+			gen.addToBatch(info)
+			continue
+		}
+		// the identical events are coalesced by the queue
 		gen.queue.Add(info)
 	}
 }
 
+func (gen *Generator) addToBatch(info Info) {
+	gen.batchMu.Lock()
+	defer gen.batchMu.Unlock()
+	key := batchKey{policy: info.Name, reason: info.Reason, source: info.Source}
+	if b, ok := gen.batch[key]; ok {
+		b.count++
+		return
+	}
+	gen.batch[key] = &batchedEvents{message: info.Message, count: 1}
+}
+
+// flush queues a single event for the similar events on a policy batched since the last flush
+func (gen *Generator) flush() {
+	gen.batchMu.Lock()
+	batch := gen.batch
+	gen.batch = map[batchKey]*batchedEvents{}
+	gen.batchMu.Unlock()
+
+	for key, b := range batch {
+		message := b.message
+		if b.count > 1 {
+			message = fmt.Sprintf("%s (and %d more similar events)", message, b.count-1)
+		}
+		gen.queue.Add(Info{
+			Kind:    "ClusterPolicy",
+			Name:    key.policy,
+			Reason:  key.reason,
+			Message: message,
+			Source:  key.source,
+		})
+	}
+}
+
 // Run begins generator
 func (gen *Generator) Run(workers int, stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
@@ -103,11 +166,20 @@ func (gen *Generator) Run(workers int, stopCh <-chan struct{}) {
 	}
 
 	for i := 0; i < workers; i++ {
-		go wait.Until(gen.runWorker, time.Second, stopCh)
+		gen.workers.Go(gen.runWorker, stopCh)
 	}
+	go wait.Until(gen.flush, batchInterval, stopCh)
 	<-stopCh
 }
 
+// Drain stops queueing the events, flushes the batched events and writes the queued ones,
+// it returns false if they are not written before the timeout
+func (gen *Generator) Drain(timeout time.Duration) bool {
+	gen.flush()
+	gen.queue.ShutDown()
+	return gen.workers.Wait(timeout)
+}
+
 func (gen *Generator) runWorker() {
 	for gen.processNextWorkItem() {
 	}
@@ -183,6 +255,9 @@ func (gen *Generator) syncHandler(key Info) error {
 		eventType = v1.EventTypeNormal
 	}
 
+	if waited := gen.limiter.Wait(); waited > 0 {
+		glog.V(5).Infof("Event write throttled for %v", waited)
+	}
 	// based on the source of event generation, use different event recorders
 	switch key.Source {
 	case AdmissionController:
@@ -220,3 +295,8 @@ func NewEvent(
 		Message:   msgText,
 	}
 }
+
+//Len returns the number of the queued events
+func (gen *Generator) Len() int {
+	return gen.queue.Len()
+}
@@ -0,0 +1,42 @@
+package event
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func Test_Generator_Add_BatchesPolicyEvents(t *testing.T) {
+	gen := &Generator{
+		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), eventWorkQueueName),
+		batch: map[batchKey]*batchedEvents{},
+	}
+	defer gen.queue.ShutDown()
+
+	for _, name := range []string{"nginx", "redis", "mysql"} {
+		gen.Add(NewEvent("ClusterPolicy", "kyverno.io/v1", "", "require-labels", PolicyViolation.String(), PolicyController, FPolicyViolation, "check-app", "require-labels", name))
+	}
+	gen.Add(NewEvent("ClusterPolicy", "kyverno.io/v1", "", "disallow-latest-tag", PolicyViolation.String(), PolicyController, FPolicyViolation, "validate-image-tag", "disallow-latest-tag", "nginx"))
+	// the events on the resources are not batched
+	gen.Add(NewEvent("Pod", "v1", "default", "nginx", PolicyViolation.String(), PolicyController, FResourceViolation, "nginx", "check-app", "label app is required"))
+	assert.Equal(t, gen.queue.Len(), 1)
+
+	gen.flush()
+	assert.Equal(t, gen.queue.Len(), 3)
+	assert.Equal(t, len(gen.batch), 0)
+
+	messages := map[string]string{}
+	for gen.queue.Len() > 0 {
+		obj, _ := gen.queue.Get()
+		info := obj.(Info)
+		messages[info.Name] = info.Message
+		gen.queue.Done(obj)
+	}
+	assert.Equal(t, messages["require-labels"], "Rule(s) 'check-app' of policy 'require-labels' not satisfied: nginx (and 2 more similar events)")
+	assert.Equal(t, messages["disallow-latest-tag"], "Rule(s) 'validate-image-tag' of policy 'disallow-latest-tag' not satisfied: nginx")
+
+	// nothing is queued without new events
+	gen.flush()
+	assert.Equal(t, gen.queue.Len(), 0)
+}
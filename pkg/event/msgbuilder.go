@@ -17,6 +17,9 @@ const (
 	FPolicyApplyBlockCreate
 	FPolicyApplyBlockUpdate
 	FPolicyBlockResourceUpdate
+	FPolicyViolation
+	FResourceViolation
+	SResourceApply
 )
 
 func (k MsgKey) String() string {
@@ -28,6 +31,9 @@ func (k MsgKey) String() string {
 		"Resource %s creation blocked by rule(s) %s",
 		"Rule(s) '%s' of policy '%s' blocked update of the resource",
 		"Resource %s update blocked by rule(s) %s",
+		"Rule(s) '%s' of policy '%s' not satisfied: %s",
+		"Resource %s does not satisfy rule(s) %s: %s",
+		"Rule(s) '%s' applied successfully on the resource %s",
 	}[k]
 }
 
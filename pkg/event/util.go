@@ -1,9 +1,14 @@
 package event
 
+import "time"
+
 const eventWorkQueueName = "kyverno-events"
 
 const workQueueRetryLimit = 5
 
+// batchInterval is the interval the similar events on a policy are batched in a single event
+const batchInterval = 10 * time.Second
+
 //Info defines the event details
 type Info struct {
 	Kind      string
@@ -0,0 +1,252 @@
+package externaldata
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+// DefaultTimeout is the timeout of the requests that do not set a timeout
+const DefaultTimeout = 10 * time.Second
+
+// maxResponseSize is the maximum size of a response
+const maxResponseSize = 2 << 20
+
+// maxCachedResponses is the maximum number of cached responses
+const maxCachedResponses = 1000
+
+// Interface calls the external services
+type Interface interface {
+	Get(request Request) ([]byte, error)
+}
+
+// Request is a GET request to an external HTTPS service
+type Request struct {
+	// URL is the HTTPS URL of the service
+	URL string
+	// CABundle is the PEM encoded CA bundle used to verify the certificate of the service,
+	// the system CAs are used if empty
+	CABundle string
+	// Authorization is the value of the Authorization header
+	Authorization string
+	// AuthSecret is the key of the Secret with the value of the Authorization header,
+	// the Secret must be in a namespace of the Secret listers of the client
+	AuthSecret *SecretKeyRef
+	// Timeout of the request
+	Timeout time.Duration
+	// TTL is the duration the response is cached, the response is not cached if zero
+	TTL time.Duration
+}
+
+// SecretKeyRef refers to a key of a Secret
+type SecretKeyRef struct {
+	Namespace string
+	Name      string
+	Key       string
+}
+
+// Client calls the external services, the responses are cached for the TTL of the requests
+type Client struct {
+	mu sync.Mutex
+	// clients are the HTTP clients per CA bundle
+	clients map[string]*http.Client
+	cache   map[string]cachedResponse
+	// maxCached is the maximum number of cached responses
+	maxCached int
+	// now is replaced in tests
+	now func() time.Time
+	// offline is true if only the services of the cluster can be called
+	offline bool
+	// secrets are the listers of the Secrets per allowed namespace
+	secrets map[string]corelisters.SecretLister
+	log     log.Logger
+}
+
+type cachedResponse struct {
+	body    []byte
+	expires time.Time
+}
+
+// NewClient returns a client for the external services
+func NewClient() *Client {
+	return &Client{
+		clients:   map[string]*http.Client{},
+		cache:     map[string]cachedResponse{},
+		maxCached: maxCachedResponses,
+		now:       time.Now,
+	}
+}
+
+// Get returns the response of the service, from the cache if the request was made within its TTL
+func (c *Client) Get(request Request) ([]byte, error) {
+	if !strings.HasPrefix(request.URL, "https://") {
+		return nil, fmt.Errorf("invalid URL %s, the URL must use https", request.URL)
+	}
+	if c.offline {
+		u, err := url.Parse(request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL %s: %v", request.URL, err)
+		}
+		if host := u.Hostname(); !strings.HasSuffix(host, ".svc") && !strings.Contains(host, ".svc.") {
+			return nil, fmt.Errorf("%s is not a service of the cluster, only the services of the cluster are called in offline mode", host)
+		}
+	}
+	if request.AuthSecret != nil {
+		authorization, err := c.secretValue(*request.AuthSecret)
+		if err != nil {
+			return nil, err
+		}
+		request.Authorization = authorization
+	}
+	if request.Timeout == 0 {
+		request.Timeout = DefaultTimeout
+	}
+	key := cacheKey(request)
+	if body, ok := c.cached(key); ok {
+		c.log.V(4).Infof("using the cached response of %s", request.URL)
+		return body, nil
+	}
+	httpClient, err := c.httpClient(request.CABundle)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, request.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if request.Authorization != "" {
+		req.Header.Set("Authorization", request.Authorization)
+	}
+	c.log.V(4).Infof("external service request %s", request.URL)
+	// the timeout is set per request, the HTTP clients are shared
+	timeoutClient := *httpClient
+	timeoutClient.Timeout = request.Timeout
+	resp, err := timeoutClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response of %s: %v", request.URL, err)
+	}
+	if len(body) > maxResponseSize {
+		return nil, fmt.Errorf("the response of %s exceeds %d bytes", request.URL, maxResponseSize)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: %s", request.URL, resp.Status)
+	}
+	if request.TTL > 0 {
+		c.store(key, body, request.TTL)
+	}
+	return body, nil
+}
+
+// SetSecretListers sets the listers of the Secrets of the namespaces the authorization of the requests is read from,
+// the Secrets of the other namespaces are rejected
+func (c *Client) SetSecretListers(listers map[string]corelisters.SecretLister) {
+	c.secrets = listers
+}
+
+// secretValue returns the value of the Secret key from the informer cache
+func (c *Client) secretValue(ref SecretKeyRef) (string, error) {
+	lister, ok := c.secrets[ref.Namespace]
+	if !ok {
+		return "", fmt.Errorf("the Secrets of the namespace %s cannot be read, the authorization Secrets must be in the namespaces of --serviceSecretNamespaces", ref.Namespace)
+	}
+	secret, err := lister.Secrets(ref.Namespace).Get(ref.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Secret %s/%s: %v", ref.Namespace, ref.Name, err)
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in Secret %s/%s", ref.Key, ref.Namespace, ref.Name)
+	}
+	return string(value), nil
+}
+
+// SetLogger sets the logger of the client, the logs are discarded by default
+func (c *Client) SetLogger(logger log.Logger) {
+	c.log = logger
+}
+
+// SetOffline sets the offline mode: only the services of the cluster are called, e.g. https://cmdb.tools.svc/apps
+func (c *Client) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+func (c *Client) cached(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(cached.expires) {
+		delete(c.cache, key)
+		return nil, false
+	}
+	return cached.body, true
+}
+
+// store caches the response, when the cache is full the expired responses are removed,
+// then the response that expires first if none is expired
+func (c *Client) store(key string, body []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if _, ok := c.cache[key]; !ok && len(c.cache) >= c.maxCached {
+		for k, cached := range c.cache {
+			if !now.Before(cached.expires) {
+				delete(c.cache, k)
+			}
+		}
+		if len(c.cache) >= c.maxCached {
+			var first string
+			for k, cached := range c.cache {
+				if first == "" || cached.expires.Before(c.cache[first].expires) {
+					first = k
+				}
+			}
+			delete(c.cache, first)
+		}
+	}
+	c.cache[key] = cachedResponse{body: body, expires: now.Add(ttl)}
+}
+
+// httpClient returns the HTTP client that verifies the certificates with the CA bundle
+func (c *Client) httpClient(caBundle string) (*http.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, ok := c.clients[caBundle]; ok {
+		return client, nil
+	}
+	tlsConfig := &tls.Config{}
+	if caBundle != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caBundle)) {
+			return nil, fmt.Errorf("invalid CA bundle, no PEM encoded certificate found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	c.clients[caBundle] = client
+	return client, nil
+}
+
+// cacheKey identifies the response of the request, the responses are cached per Authorization header
+func cacheKey(request Request) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(request.URL+"\n"+request.CABundle+"\n"+request.Authorization)))
+}
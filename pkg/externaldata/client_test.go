@@ -0,0 +1,173 @@
+package externaldata
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newTestService serves /apps/<name> and counts the requests, the requests require a token
+func newTestService(t *testing.T, requests *int) (*httptest.Server, string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apps/", func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"name": "%s", "owner": "team-a"}`, r.URL.Path[len("/apps/"):])
+	})
+	server := httptest.NewTLSServer(mux)
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server, string(caBundle)
+}
+
+func Test_Get(t *testing.T) {
+	var requests int
+	server, caBundle := newTestService(t, &requests)
+	defer server.Close()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	client := NewClient()
+	client.now = func() time.Time { return now }
+
+	request := Request{
+		URL:           server.URL + "/apps/nginx",
+		CABundle:      caBundle,
+		Authorization: "Bearer secret",
+		TTL:           time.Minute,
+	}
+	body, err := client.Get(request)
+	assert.NilError(t, err)
+	assert.Equal(t, string(body), `{"name": "nginx", "owner": "team-a"}`)
+
+	// the response is cached for the TTL
+	_, err = client.Get(request)
+	assert.NilError(t, err)
+	assert.Equal(t, requests, 1)
+	now = now.Add(2 * time.Minute)
+	_, err = client.Get(request)
+	assert.NilError(t, err)
+	assert.Equal(t, requests, 2)
+
+	// the responses are cached per Authorization header
+	request.Authorization = "Bearer invalid"
+	_, err = client.Get(request)
+	assert.ErrorContains(t, err, "401")
+	assert.Equal(t, requests, 3)
+}
+
+func Test_Get_Eviction(t *testing.T) {
+	var requests int
+	server, caBundle := newTestService(t, &requests)
+	defer server.Close()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	client := NewClient()
+	client.now = func() time.Time { return now }
+	client.maxCached = 2
+
+	get := func(name string, ttl time.Duration) {
+		_, err := client.Get(Request{URL: server.URL + "/apps/" + name, CABundle: caBundle, Authorization: "Bearer secret", TTL: ttl})
+		assert.NilError(t, err)
+	}
+	get("nginx", time.Minute)
+	get("redis", 2*time.Minute)
+	assert.Equal(t, len(client.cache), 2)
+
+	// the response that expires first is evicted when the cache is full
+	get("mysql", 3*time.Minute)
+	assert.Equal(t, len(client.cache), 2)
+	assert.Equal(t, requests, 3)
+	get("redis", 2*time.Minute)
+	assert.Equal(t, requests, 3)
+	get("nginx", time.Minute)
+	assert.Equal(t, requests, 4)
+	assert.Equal(t, len(client.cache), 2)
+
+	// the expired responses are removed when the cache is full
+	now = now.Add(5 * time.Minute)
+	get("postgres", time.Minute)
+	assert.Equal(t, len(client.cache), 1)
+}
+
+func Test_Get_AuthSecret(t *testing.T) {
+	var requests int
+	server, caBundle := newTestService(t, &requests)
+	defer server.Close()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	assert.NilError(t, indexer.Add(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cmdb", Namespace: "kyverno"},
+		Data:       map[string][]byte{"token": []byte("Bearer secret")},
+	}))
+	assert.NilError(t, indexer.Add(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cmdb", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte("Bearer secret")},
+	}))
+	client := NewClient()
+	client.SetSecretListers(map[string]corelisters.SecretLister{"kyverno": corelisters.NewSecretLister(indexer)})
+
+	request := Request{URL: server.URL + "/apps/nginx", CABundle: caBundle, AuthSecret: &SecretKeyRef{Namespace: "kyverno", Name: "cmdb", Key: "token"}}
+	_, err := client.Get(request)
+	assert.NilError(t, err)
+
+	request.AuthSecret.Key = "password"
+	_, err = client.Get(request)
+	assert.ErrorContains(t, err, "key password not found")
+
+	// the Secrets of the namespaces that are not allowed are not read
+	request.AuthSecret = &SecretKeyRef{Namespace: "default", Name: "cmdb", Key: "token"}
+	_, err = client.Get(request)
+	assert.ErrorContains(t, err, "the Secrets of the namespace default cannot be read")
+	assert.Equal(t, requests, 1)
+}
+
+func Test_Get_Errors(t *testing.T) {
+	var requests int
+	server, _ := newTestService(t, &requests)
+	defer server.Close()
+	client := NewClient()
+
+	// the certificate of the service is verified
+	_, err := client.Get(Request{URL: server.URL + "/apps/nginx", Authorization: "Bearer secret"})
+	assert.Assert(t, err != nil)
+
+	_, err = client.Get(Request{URL: server.URL + "/apps/nginx", CABundle: "invalid"})
+	assert.ErrorContains(t, err, "invalid CA bundle")
+
+	_, err = client.Get(Request{URL: "http://cmdb.corp.com/apps/nginx"})
+	assert.ErrorContains(t, err, "must use https")
+	assert.Equal(t, requests, 0)
+}
+
+func Test_Get_Offline(t *testing.T) {
+	var requests int
+	server, _ := newTestService(t, &requests)
+	defer server.Close()
+	client := NewClient()
+	client.SetOffline(true)
+
+	// the services outside of the cluster are not called
+	_, err := client.Get(Request{URL: server.URL + "/apps/nginx"})
+	assert.ErrorContains(t, err, "only the services of the cluster are called in offline mode")
+	_, err = client.Get(Request{URL: "https://cmdb.corp.com/apps/nginx"})
+	assert.ErrorContains(t, err, "only the services of the cluster are called in offline mode")
+	assert.Equal(t, requests, 0)
+
+	// the services of the cluster are called, they do not resolve in the tests
+	_, err = client.Get(Request{URL: "https://cmdb.tools.svc:8443/apps/nginx", Timeout: time.Second})
+	assert.Assert(t, err != nil && !strings.Contains(err.Error(), "offline mode"), err)
+	_, err = client.Get(Request{URL: "https://cmdb.tools.svc.cluster.local/apps/nginx", Timeout: time.Second})
+	assert.Assert(t, err != nil && !strings.Contains(err.Error(), "offline mode"), err)
+}
@@ -0,0 +1,237 @@
+package gatekeeper
+
+import (
+	"fmt"
+	"strings"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// groups of the ConstraintTemplates and of the constraints of Gatekeeper
+const (
+	TemplatesGroup   = "templates.gatekeeper.sh"
+	ConstraintsGroup = "constraints.gatekeeper.sh"
+)
+
+// enforcement actions of the constraints, the violations of the other actions are only reported
+const enforcementDeny = "deny"
+
+// template converts the constraints of a ConstraintTemplate of the Gatekeeper library
+type template struct {
+	// pods is true if the Rego of the template checks the spec of the pods, the rule only matches the pods
+	pods    bool
+	convert func(c *converter) error
+}
+
+// templates are the supported templates of the Gatekeeper library, per kind of their constraints
+var templates = map[string]template{
+	"K8sAllowedRepos":           {pods: true, convert: convertAllowedRepos},
+	"K8sRequiredLabels":         {convert: convertRequiredLabels},
+	"K8sPSPHostNamespace":       {pods: true, convert: convertHostNamespace},
+	"K8sPSPPrivilegedContainer": {pods: true, convert: convertPrivilegedContainer},
+}
+
+// Supported returns true if the constraints of the kind are converted
+func Supported(kind string) bool {
+	_, ok := templates[kind]
+	return ok
+}
+
+// converter accumulates the rule of a constraint
+type converter struct {
+	constraint unstructured.Unstructured
+	rule       kyverno.Rule
+	warnings   []string
+}
+
+// Convert returns the ClusterPolicy of the constraint of a template of the Gatekeeper library, with the match of the constraint
+// and its parameters. The settings of the constraint that are not converted are returned as warnings.
+func Convert(constraint unstructured.Unstructured) (*kyverno.ClusterPolicy, []string, error) {
+	kind := constraint.GetKind()
+	t, ok := templates[kind]
+	if !ok {
+		return nil, nil, fmt.Errorf("the constraints of kind %s are not supported, the Rego of the template cannot be converted", kind)
+	}
+	c := &converter{constraint: constraint}
+	if err := c.convertMatch(t.pods); err != nil {
+		return nil, nil, err
+	}
+	if err := t.convert(c); err != nil {
+		return nil, nil, err
+	}
+	action, _, _ := unstructured.NestedString(constraint.Object, "spec", "enforcementAction")
+	policy := &kyverno.ClusterPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "kyverno.io/v1", Kind: "ClusterPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: constraint.GetName()},
+		Spec: kyverno.Spec{
+			Rules:                   []kyverno.Rule{c.rule},
+			ValidationFailureAction: "audit",
+		},
+	}
+	if action == "" || action == enforcementDeny {
+		policy.Spec.ValidationFailureAction = "enforce"
+	}
+	return policy, c.warnings, nil
+}
+
+func (c *converter) warn(format string, args ...interface{}) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+// match is the match of the constraints
+type match struct {
+	Kinds []struct {
+		APIGroups []string `json:"apiGroups"`
+		Kinds     []string `json:"kinds"`
+	} `json:"kinds"`
+	Namespaces         []string              `json:"namespaces"`
+	ExcludedNamespaces []string              `json:"excludedNamespaces"`
+	LabelSelector      *metav1.LabelSelector `json:"labelSelector"`
+	NamespaceSelector  *metav1.LabelSelector `json:"namespaceSelector"`
+	Name               string                `json:"name"`
+	Scope              string                `json:"scope"`
+}
+
+// convertMatch sets the match and the exclude of the rule, the rules of the pod templates only match the pods
+func (c *converter) convertMatch(pods bool) error {
+	var m match
+	if object, ok, _ := unstructured.NestedMap(c.constraint.Object, "spec", "match"); ok {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object, &m); err != nil {
+			return fmt.Errorf("invalid match: %v", err)
+		}
+	}
+	var kinds []string
+	for _, k := range m.Kinds {
+		kinds = append(kinds, k.Kinds...)
+	}
+	if pods {
+		for _, kind := range kinds {
+			if kind != "Pod" {
+				c.warn("match.kinds: the template checks the pods, the kind %s is not matched", kind)
+			}
+		}
+		kinds = []string{"Pod"}
+	}
+	if len(kinds) == 0 {
+		return fmt.Errorf("the kinds of the match of the constraint are required")
+	}
+	for _, kind := range kinds {
+		if kind == "*" {
+			return fmt.Errorf("the match of all the kinds is not supported, the kinds of the match of the constraint are required")
+		}
+	}
+	c.rule = kyverno.Rule{
+		MatchResources: kyverno.MatchResources{ResourceDescription: kyverno.ResourceDescription{
+			Kinds:      kinds,
+			Name:       m.Name,
+			Namespaces: m.Namespaces,
+			Selector:   m.LabelSelector,
+		}},
+		ExcludeResources: kyverno.ExcludeResources{ResourceDescription: kyverno.ResourceDescription{
+			Namespaces: m.ExcludedNamespaces,
+		}},
+	}
+	if m.NamespaceSelector != nil {
+		c.warn("match.namespaceSelector: the namespaces are not selected by their labels")
+	}
+	if m.Scope != "" && m.Scope != "*" {
+		c.warn("match.scope: the %s resources are not selected", m.Scope)
+	}
+	return nil
+}
+
+// containersPattern returns the pattern of the fields of the containers and of the init containers
+func containersPattern(fields map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers":        []interface{}{fields},
+			"=(initContainers)": []interface{}{fields},
+		},
+	}
+}
+
+func convertAllowedRepos(c *converter) error {
+	repos, _, err := unstructured.NestedStringSlice(c.constraint.Object, "spec", "parameters", "repos")
+	if err != nil {
+		return fmt.Errorf("invalid parameters.repos: %v", err)
+	}
+	if len(repos) == 0 {
+		return fmt.Errorf("the parameters.repos of the constraint are required")
+	}
+	// the images start with one of the repositories
+	var patterns []string
+	for _, repo := range repos {
+		patterns = append(patterns, repo+"*")
+	}
+	c.rule.Name = "allowed-repos"
+	c.rule.Validation = kyverno.Validation{
+		Message: fmt.Sprintf("The images must be from the repositories %s.", strings.Join(repos, ", ")),
+		Pattern: containersPattern(map[string]interface{}{"image": strings.Join(patterns, " | ")}),
+	}
+	return nil
+}
+
+func convertRequiredLabels(c *converter) error {
+	labels, _, err := unstructured.NestedSlice(c.constraint.Object, "spec", "parameters", "labels")
+	if err != nil {
+		return fmt.Errorf("invalid parameters.labels: %v", err)
+	}
+	required := map[string]interface{}{}
+	var keys []string
+	for _, label := range labels {
+		// the labels of the first versions of the template are their keys
+		switch typed := label.(type) {
+		case string:
+			keys = append(keys, typed)
+		case map[string]interface{}:
+			key, _ := typed["key"].(string)
+			if key == "" {
+				return fmt.Errorf("invalid parameters.labels: the key of a label is required")
+			}
+			keys = append(keys, key)
+			if regex, _ := typed["allowedRegex"].(string); regex != "" {
+				c.warn("parameters.labels: the values of the label %s are not checked against the regular expression %s", key, regex)
+			}
+		default:
+			return fmt.Errorf("invalid parameters.labels: invalid label %v", label)
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("the parameters.labels of the constraint are required")
+	}
+	for _, key := range keys {
+		// the label is set, with any value
+		required[key] = "*"
+	}
+	message, _, _ := unstructured.NestedString(c.constraint.Object, "spec", "parameters", "message")
+	if message == "" {
+		message = fmt.Sprintf("The labels %s are required.", strings.Join(keys, ", "))
+	}
+	c.rule.Name = "required-labels"
+	c.rule.Validation = kyverno.Validation{
+		Message: message,
+		Pattern: map[string]interface{}{"metadata": map[string]interface{}{"labels": required}},
+	}
+	return nil
+}
+
+func convertHostNamespace(c *converter) error {
+	c.rule.Name = "host-namespaces"
+	c.rule.Validation = kyverno.Validation{
+		Message: "Sharing the host PID and IPC namespaces is not allowed.",
+		Pattern: map[string]interface{}{"spec": map[string]interface{}{"=(hostPID)": false, "=(hostIPC)": false}},
+	}
+	return nil
+}
+
+func convertPrivilegedContainer(c *converter) error {
+	c.rule.Name = "privileged"
+	c.rule.Validation = kyverno.Validation{
+		Message: "Privileged containers are not allowed.",
+		Pattern: containersPattern(map[string]interface{}{"=(securityContext)": map[string]interface{}{"=(privileged)": false}}),
+	}
+	return nil
+}
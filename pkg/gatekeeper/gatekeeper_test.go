@@ -0,0 +1,244 @@
+package gatekeeper
+
+import (
+	"encoding/json"
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	policyvalidate "github.com/nirmata/kyverno/pkg/engine/policy"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func decode(t *testing.T, document string) unstructured.Unstructured {
+	raw, err := yaml.ToJSON([]byte(document))
+	assert.NilError(t, err)
+	resource := unstructured.Unstructured{}
+	assert.NilError(t, resource.UnmarshalJSON(raw))
+	return resource
+}
+
+// convert returns the policy of the constraint as it is loaded from its JSON
+func convert(t *testing.T, constraint string) (kyverno.ClusterPolicy, []string) {
+	converted, warnings, err := Convert(decode(t, constraint))
+	assert.NilError(t, err)
+	raw, err := json.Marshal(converted)
+	assert.NilError(t, err)
+	var policy kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal(raw, &policy))
+	assert.NilError(t, policyvalidate.Validate(policy))
+	return policy, warnings
+}
+
+// validate returns the status of the rule of the policy on the resource, nil if the rule does not match it
+func validate(t *testing.T, policy kyverno.ClusterPolicy, document string) *bool {
+	resource := decode(t, document)
+	raw, err := resource.MarshalJSON()
+	assert.NilError(t, err)
+	ctx := context.NewContext()
+	assert.NilError(t, ctx.AddResource(raw))
+	rules := engine.Validate(engine.PolicyContext{Policy: policy, NewResource: resource, Context: ctx}).PolicyResponse.Rules
+	if len(rules) == 0 {
+		return nil
+	}
+	return &rules[0].Success
+}
+
+func status(success *bool) string {
+	switch {
+	case success == nil:
+		return "skip"
+	case *success:
+		return "pass"
+	default:
+		return "fail"
+	}
+}
+
+func Test_ConvertAllowedRepos(t *testing.T) {
+	policy, warnings := convert(t, `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sAllowedRepos
+metadata:
+  name: repo-is-openpolicyagent
+spec:
+  match:
+    kinds:
+    - apiGroups: [""]
+      kinds: ["Pod"]
+    - apiGroups: ["apps"]
+      kinds: ["Deployment"]
+    excludedNamespaces: ["kube-system"]
+  parameters:
+    repos:
+    - "openpolicyagent/"
+    - "ghcr.io/org/"
+`)
+	assert.Equal(t, policy.Name, "repo-is-openpolicyagent")
+	assert.Equal(t, policy.Spec.ValidationFailureAction, "enforce")
+	assert.DeepEqual(t, warnings, []string{"match.kinds: the template checks the pods, the kind Deployment is not matched"})
+
+	pod := `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: default
+spec:
+  initContainers:
+  - name: init
+    image: ghcr.io/org/init:v1
+  containers:
+  - name: opa
+    image: openpolicyagent/opa:0.9.2
+`
+	assert.Equal(t, status(validate(t, policy, pod)), "pass")
+	pod = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: default
+spec:
+  containers:
+  - name: nginx
+    image: nginx
+`
+	assert.Equal(t, status(validate(t, policy, pod)), "fail")
+	// the excluded namespaces are not checked
+	pod = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: kube-system
+spec:
+  containers:
+  - name: nginx
+    image: nginx
+`
+	assert.Equal(t, status(validate(t, policy, pod)), "skip")
+}
+
+func Test_ConvertRequiredLabels(t *testing.T) {
+	policy, warnings := convert(t, `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sRequiredLabels
+metadata:
+  name: all-must-have-owner
+spec:
+  enforcementAction: dryrun
+  match:
+    kinds:
+    - apiGroups: [""]
+      kinds: ["Namespace"]
+    namespaceSelector:
+      matchLabels:
+        team: platform
+  parameters:
+    message: "All namespaces must have an owner label"
+    labels:
+    - key: owner
+      allowedRegex: "^[a-zA-Z]+.agilebank.demo$"
+    - key: team
+`)
+	assert.Equal(t, policy.Spec.ValidationFailureAction, "audit")
+	assert.Equal(t, policy.Spec.Rules[0].Validation.Message, "All namespaces must have an owner label")
+	assert.DeepEqual(t, warnings, []string{
+		"match.namespaceSelector: the namespaces are not selected by their labels",
+		"parameters.labels: the values of the label owner are not checked against the regular expression ^[a-zA-Z]+.agilebank.demo$",
+	})
+	namespace := `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: web
+  labels:
+    owner: me.agilebank.demo
+    team: ""
+`
+	assert.Equal(t, status(validate(t, policy, namespace)), "pass")
+	namespace = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: web
+  labels:
+    owner: me.agilebank.demo
+`
+	assert.Equal(t, status(validate(t, policy, namespace)), "fail")
+
+	// the labels of the first versions of the template are their keys
+	policy, _ = convert(t, `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sRequiredLabels
+metadata:
+  name: ns-must-have-gk
+spec:
+  match:
+    kinds:
+    - apiGroups: [""]
+      kinds: ["Namespace"]
+  parameters:
+    labels: ["gatekeeper"]
+`)
+	assert.Equal(t, policy.Spec.Rules[0].Validation.Message, "The labels gatekeeper are required.")
+
+	_, _, err := Convert(decode(t, `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sRequiredLabels
+metadata:
+  name: all-must-have-owner
+spec:
+  parameters:
+    labels: ["owner"]
+`))
+	assert.ErrorContains(t, err, "the kinds of the match of the constraint are required")
+}
+
+func Test_ConvertPodSecurity(t *testing.T) {
+	hostNamespace, warnings := convert(t, `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sPSPHostNamespace
+metadata:
+  name: psp-host-namespace
+spec:
+  match:
+    kinds:
+    - apiGroups: [""]
+      kinds: ["Pod"]
+`)
+	assert.Equal(t, len(warnings), 0)
+	privileged, _ := convert(t, `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sPSPPrivilegedContainer
+metadata:
+  name: psp-privileged-container
+`)
+	pod := `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  hostPID: true
+  containers:
+  - name: nginx
+    image: nginx
+    securityContext:
+      privileged: false
+`
+	assert.Equal(t, status(validate(t, hostNamespace, pod)), "fail")
+	assert.Equal(t, status(validate(t, privileged, pod)), "pass")
+
+	_, _, err := Convert(decode(t, `
+apiVersion: constraints.gatekeeper.sh/v1beta1
+kind: K8sContainerLimits
+metadata:
+  name: container-must-have-limits
+`))
+	assert.ErrorContains(t, err, "the constraints of kind K8sContainerLimits are not supported")
+}
@@ -0,0 +1,83 @@
+package generate
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/nirmata/kyverno/pkg/config"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// applyResource creates or updates the generated resource with server-side apply
+// kyverno only owns the fields set in the resource, and applying the same resource again does not modify it
+// if the cluster does not support server-side apply, the resource is created or updated
+func applyResource(client *dclient.Client, kind string, newResource *unstructured.Unstructured) error {
+	removeServerFields(newResource)
+	_, err := client.ApplyResource(kind, newResource.GetNamespace(), newResource, config.GenerateFieldManager, false)
+	if apierrors.IsUnsupportedMediaType(err) {
+		glog.V(4).Infof("server-side apply is not supported, creating or updating resource %s/%s/%s", kind, newResource.GetNamespace(), newResource.GetName())
+		return createOrUpdateResource(client, kind, newResource)
+	}
+	if apierrors.IsConflict(err) {
+		return fmt.Errorf("failed to apply resource %s/%s/%s, fields conflict with another field manager: %v", kind, newResource.GetNamespace(), newResource.GetName(), err)
+	}
+	return err
+}
+
+// removeServerFields removes the status and the metadata that is set by the server,
+// only the name, namespace, labels and annotations are kept
+func removeServerFields(obj *unstructured.Unstructured) {
+	name := obj.GetName()
+	namespace := obj.GetNamespace()
+	labels := obj.GetLabels()
+	annotations := obj.GetAnnotations()
+	delete(obj.Object, "status")
+	obj.Object["metadata"] = map[string]interface{}{}
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	if len(labels) != 0 {
+		obj.SetLabels(labels)
+	}
+	if len(annotations) != 0 {
+		obj.SetAnnotations(annotations)
+	}
+}
+
+func createOrUpdateResource(client *dclient.Client, kind string, newResource *unstructured.Unstructured) error {
+	obj, err := client.GetResource(kind, newResource.GetNamespace(), newResource.GetName())
+	if apierrors.IsNotFound(err) {
+		_, err = client.CreateResource(kind, newResource.GetNamespace(), newResource, false)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	// update the fields set in the resource, other fields are kept
+	updated := obj.DeepCopy()
+	for key, value := range newResource.Object {
+		if key == "metadata" {
+			continue
+		}
+		updated.Object[key] = value
+	}
+	labels := updated.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for key, value := range newResource.GetLabels() {
+		labels[key] = value
+	}
+	updated.SetLabels(labels)
+	annotations := updated.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for key, value := range newResource.GetAnnotations() {
+		annotations[key] = value
+	}
+	updated.SetAnnotations(annotations)
+	_, err = client.UpdateResource(kind, newResource.GetNamespace(), updated, false)
+	return err
+}
@@ -0,0 +1,136 @@
+package generate
+
+import (
+	"encoding/json"
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	"gotest.tools/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func newQuota(name string, labels map[string]interface{}, hard string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ResourceQuota",
+			"metadata": map[string]interface{}{
+				"namespace":       "team-a",
+				"name":            name,
+				"resourceVersion": "1",
+				"labels":          labels,
+			},
+			"spec":   map[string]interface{}{"hard": map[string]interface{}{"pods": hard}},
+			"status": map[string]interface{}{"used": map[string]interface{}{"pods": "1"}},
+		},
+	}
+}
+
+func newMockClient(t *testing.T, objects ...runtime.Object) *dclient.Client {
+	client, err := dclient.NewMockClient(runtime.NewScheme(), objects...)
+	assert.NilError(t, err)
+	client.SetDiscovery(dclient.NewFakeDiscoveryClient([]schema.GroupVersionResource{}))
+	return client
+}
+
+// applyReactor records the server-side apply patches and returns err
+func applyReactor(patches *[]map[string]interface{}, err error) clienttesting.ReactionFunc {
+	return func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch := action.(clienttesting.PatchAction)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		if err != nil {
+			return true, nil, err
+		}
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal(patch.GetPatch(), &obj); err != nil {
+			return true, nil, err
+		}
+		*patches = append(*patches, obj)
+		return true, &unstructured.Unstructured{Object: obj}, nil
+	}
+}
+
+func Test_applyResource(t *testing.T) {
+	var patches []map[string]interface{}
+	client := newMockClient(t)
+	client.PrependReactor("patch", "resourcequotas", applyReactor(&patches, nil))
+
+	assert.NilError(t, applyResource(client, "ResourceQuota", newQuota("quota", map[string]interface{}{"app": "nginx"}, "10")))
+	assert.Equal(t, len(patches), 1)
+	// the fields set by the server are not applied
+	_, ok := patches[0]["status"]
+	assert.Assert(t, !ok)
+	assert.DeepEqual(t, patches[0]["metadata"], map[string]interface{}{
+		"namespace": "team-a",
+		"name":      "quota",
+		"labels":    map[string]interface{}{"app": "nginx"},
+	})
+	assert.DeepEqual(t, patches[0]["spec"], map[string]interface{}{"hard": map[string]interface{}{"pods": "10"}})
+}
+
+func Test_applyResource_Conflict(t *testing.T) {
+	var patches []map[string]interface{}
+	client := newMockClient(t)
+	conflict := apierrors.NewConflict(schema.GroupResource{Resource: "resourcequotas"}, "quota", nil)
+	client.PrependReactor("patch", "resourcequotas", applyReactor(&patches, conflict))
+
+	err := applyResource(client, "ResourceQuota", newQuota("quota", nil, "10"))
+	assert.ErrorContains(t, err, "fields conflict with another field manager")
+}
+
+func Test_applyResource_Unsupported(t *testing.T) {
+	var patches []map[string]interface{}
+	client := newMockClient(t, newQuota("existing", map[string]interface{}{"team": "a"}, "5"))
+	unsupported := apierrors.NewGenericServerResponse(415, "patch", schema.GroupResource{Resource: "resourcequotas"}, "", "", 0, false)
+	client.PrependReactor("patch", "resourcequotas", applyReactor(&patches, unsupported))
+
+	// the resource is created if it does not exist
+	assert.NilError(t, applyResource(client, "ResourceQuota", newQuota("quota", nil, "10")))
+	_, err := client.GetResource("ResourceQuota", "team-a", "quota")
+	assert.NilError(t, err)
+
+	// the fields of the existing resource are updated, its other labels are kept
+	assert.NilError(t, applyResource(client, "ResourceQuota", newQuota("existing", map[string]interface{}{"app": "nginx"}, "10")))
+	existing, err := client.GetResource("ResourceQuota", "team-a", "existing")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, existing.GetLabels(), map[string]string{"team": "a", "app": "nginx"})
+	hard, _, _ := unstructured.NestedString(existing.Object, "spec", "hard", "pods")
+	assert.Equal(t, hard, "10")
+}
+
+func Test_applyRuleForNamespace_Existing(t *testing.T) {
+	var patches []map[string]interface{}
+	client := newMockClient(t, newQuota("quota", nil, "5"))
+	client.PrependReactor("patch", "resourcequotas", applyReactor(&patches, nil))
+
+	gen := kyverno.Generation{
+		ResourceSpec: kyverno.ResourceSpec{Kind: "ResourceQuota", Namespace: "team-a", Name: "quota"},
+		Data:         map[string]interface{}{"spec": map[string]interface{}{"hard": map[string]interface{}{"pods": "10"}}},
+	}
+	trigger := unstructured.Unstructured{}
+	trigger.SetKind("Namespace")
+	trigger.SetName("team-a")
+
+	// the existing resource is synced with the data of the rule
+	genResource, err := applyRuleForNamespace(client, "add-quota", "quota", gen, trigger, context.NewContext(), kyverno.Completed, false)
+	assert.NilError(t, err)
+	assert.Equal(t, genResource, gen.ResourceSpec)
+	assert.Equal(t, len(patches), 1)
+	assert.DeepEqual(t, patches[0]["spec"], map[string]interface{}{"hard": map[string]interface{}{"pods": "10"}})
+
+	// the conflicts with other field managers fail the request
+	conflictClient := newMockClient(t, newQuota("quota", nil, "5"))
+	conflict := apierrors.NewConflict(schema.GroupResource{Resource: "resourcequotas"}, "quota", nil)
+	conflictClient.PrependReactor("patch", "resourcequotas", applyReactor(&patches, conflict))
+	_, err = applyRuleForNamespace(conflictClient, "add-quota", "quota", gen, trigger, context.NewContext(), kyverno.Completed, false)
+	assert.ErrorContains(t, err, "fields conflict with another field manager")
+}
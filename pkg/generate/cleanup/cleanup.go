@@ -6,6 +6,7 @@ import (
 	"github.com/golang/glog"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/generate"
 	"k8s.io/apimachinery/pkg/api/errors"
 )
 
@@ -16,6 +17,10 @@ func (c *Controller) processGR(gr kyverno.GenerateRequest) error {
 	// 1-Corresponding policy has been deleted
 	_, err := c.pLister.Get(gr.Spec.Policy)
 	if errors.IsNotFound(err) {
+		// delete the generated resources, as per the deletion policy of the rules
+		if err := deleteGeneratedResources(c.client, gr); err != nil {
+			return err
+		}
 		glog.V(4).Infof("delete GR %s", gr.Name)
 		return c.control.Delete(gr.Name)
 	}
@@ -50,17 +55,12 @@ func ownerResourceExists(client *dclient.Client, gr kyverno.GenerateRequest) boo
 	return true
 }
 
+// deleteGeneratedResources applies the deletion policy of the generate rules on the resources generated for the GR
 func deleteGeneratedResources(client *dclient.Client, gr kyverno.GenerateRequest) error {
 	for _, genResource := range gr.Status.GeneratedResources {
-		err := client.DeleteResource(genResource.Kind, genResource.Namespace, genResource.Name, false)
-		if errors.IsNotFound(err) {
-			glog.V(4).Infof("resource %s/%s/%s not found, will no delete", genResource.Kind, genResource.Namespace, genResource.Name)
-			continue
-		}
-		if err != nil {
+		if err := generate.ReleaseGeneratedResource(client, genResource); err != nil {
 			return err
 		}
-
 	}
 	return nil
 }
@@ -0,0 +1,72 @@
+package cleanup
+
+import (
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/generate"
+	"gotest.tools/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func newResourceQuota(namespace, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ResourceQuota",
+			"metadata": map[string]interface{}{
+				"namespace": namespace,
+				"name":      name,
+				"labels": map[string]interface{}{
+					generate.PolicyNameLabel: "add-quotas",
+				},
+			},
+		},
+	}
+}
+
+func Test_deleteGeneratedResources(t *testing.T) {
+	client, err := dclient.NewMockClient(runtime.NewScheme(),
+		newResourceQuota("team-a", "deleted"),
+		newResourceQuota("team-a", "orphaned"),
+		newResourceQuota("team-a", "labeled"),
+	)
+	assert.NilError(t, err)
+	client.SetDiscovery(dclient.NewFakeDiscoveryClient([]schema.GroupVersionResource{}))
+
+	genResource := func(name string, deletionPolicy kyverno.DeletionPolicy) kyverno.GeneratedResource {
+		return kyverno.GeneratedResource{
+			ResourceSpec:   kyverno.ResourceSpec{Kind: "ResourceQuota", Namespace: "team-a", Name: name},
+			Rule:           "sync",
+			DeletionPolicy: deletionPolicy,
+		}
+	}
+	gr := kyverno.GenerateRequest{}
+	gr.Spec.Policy = "add-quotas"
+	gr.Status.GeneratedResources = []kyverno.GeneratedResource{
+		genResource("deleted", ""),
+		genResource("orphaned", kyverno.Orphan),
+		genResource("labeled", kyverno.OrphanAndLabel),
+		// the resources already deleted are skipped
+		genResource("missing", kyverno.Delete),
+	}
+	assert.NilError(t, deleteGeneratedResources(client, gr))
+
+	_, err = client.GetResource("ResourceQuota", "team-a", "deleted")
+	assert.Assert(t, apierrors.IsNotFound(err))
+
+	orphaned, err := client.GetResource("ResourceQuota", "team-a", "orphaned")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, orphaned.GetLabels(), map[string]string{generate.PolicyNameLabel: "add-quotas"})
+
+	labeled, err := client.GetResource("ResourceQuota", "team-a", "labeled")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, labeled.GetLabels(), map[string]string{
+		generate.PolicyNameLabel: "add-quotas",
+		generate.OrphanedLabel:   "true",
+	})
+}
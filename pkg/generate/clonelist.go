@@ -0,0 +1,100 @@
+package generate
+
+import (
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// getCloneListSources returns the resources in the cloneList namespace
+// that are of one of the kinds and match the selector
+func getCloneListSources(client *dclient.Client, cloneList kyverno.CloneList) ([]unstructured.Unstructured, error) {
+	var sources []unstructured.Unstructured
+	for _, kind := range cloneList.Kinds {
+		list, err := client.ListResource(kind, cloneList.Namespace, cloneList.Selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			// items of a list do not always have the kind set
+			item.SetKind(kind)
+			sources = append(sources, item)
+		}
+	}
+	return sources, nil
+}
+
+// applyCloneList clones each source resource into the namespace of the generate rule
+// the cloned resources are updated when the source resources change
+func applyCloneList(client *dclient.Client, policyName, ruleName string, gen kyverno.Generation, sources []unstructured.Unstructured, resource unstructured.Unstructured, ctx context.EvalInterface, state kyverno.GenerateRequestState, processExisting bool) ([]kyverno.ResourceSpec, error) {
+	var genResources []kyverno.ResourceSpec
+	for _, source := range sources {
+		if source.GetNamespace() == gen.Namespace {
+			// the source resource is in the target namespace
+			continue
+		}
+		// clone each source as a single resource
+		cloneGen := gen
+		cloneGen.Kind = source.GetKind()
+		cloneGen.Name = source.GetName()
+		cloneGen.Clone = kyverno.CloneFrom{Namespace: source.GetNamespace(), Name: source.GetName()}
+		cloneGen.CloneList = nil
+		genResource, err := applyRuleForNamespace(client, policyName, ruleName, cloneGen, resource, ctx, state, processExisting)
+		if err != nil {
+			return nil, err
+		}
+		if genResource == (kyverno.ResourceSpec{}) {
+			continue
+		}
+		if err := syncClone(client, policyName, genResource, source); err != nil {
+			return nil, err
+		}
+		genResources = append(genResources, genResource)
+	}
+	return genResources, nil
+}
+
+// syncClone applies the content of the source resource on the cloned resource
+// the resource is not modified if the source resource did not change
+func syncClone(client *dclient.Client, policyName string, genResource kyverno.ResourceSpec, source unstructured.Unstructured) error {
+	obj, err := client.GetResource(genResource.Kind, genResource.Namespace, genResource.Name)
+	if err != nil {
+		return err
+	}
+	if obj.GetLabels()[PolicyNameLabel] != policyName {
+		// the resource was not generated by the policy, leave it as is
+		return nil
+	}
+	clone := source.DeepCopy()
+	clone.SetNamespace(genResource.Namespace)
+	// the tracking labels of the cloned resource are kept
+	labels := clone.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for _, key := range trackingLabelKeys {
+		if value, ok := obj.GetLabels()[key]; ok {
+			labels[key] = value
+		}
+	}
+	clone.SetLabels(labels)
+	glog.V(4).Infof("syncing cloned resource %s/%s/%s from %s/%s", genResource.Kind, genResource.Namespace, genResource.Name, source.GetNamespace(), source.GetName())
+	return applyResource(client, genResource.Kind, clone)
+}
+
+// deleteStaleClones releases the resources cloned by the rule whose source resource
+// no longer exists or no longer matches the cloneList, as per the deletion policy of the rule
+func deleteStaleClones(client *dclient.Client, ruleName string, prevGenResources []kyverno.GeneratedResource, genResources []kyverno.GeneratedResource) error {
+	for _, prev := range prevGenResources {
+		if prev.Rule != ruleName || containsResourceSpec(genResources, prev.ResourceSpec) {
+			continue
+		}
+		glog.V(4).Infof("releasing cloned resource %s/%s/%s as the source no longer matches the cloneList", prev.Kind, prev.Namespace, prev.Name)
+		if err := ReleaseGeneratedResource(client, prev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package generate
+
+import (
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	"gotest.tools/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func newSource(kind, namespace, name string, labels map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"namespace": namespace,
+				"name":      name,
+				"labels":    labels,
+			},
+			"data": map[string]interface{}{"key": name},
+		},
+	}
+}
+
+func newCloneList(selector map[string]string) kyverno.CloneList {
+	return kyverno.CloneList{
+		Namespace: "default",
+		Kinds:     []string{"ResourceQuota", "ServiceAccount"},
+		Selector:  &metav1.LabelSelector{MatchLabels: selector},
+	}
+}
+
+func newCloneListPolicy(cloneList kyverno.CloneList) kyverno.ClusterPolicy {
+	return kyverno.ClusterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "sync-config"},
+		Spec: kyverno.Spec{
+			Rules: []kyverno.Rule{{
+				Name:       "sync",
+				Generation: kyverno.Generation{CloneList: &cloneList},
+			}},
+		},
+	}
+}
+
+func Test_getCloneListSources(t *testing.T) {
+	client := newMockClient(t,
+		newSource("ResourceQuota", "default", "synced", map[string]interface{}{"sync": "true"}),
+		newSource("ResourceQuota", "default", "local", nil),
+		newSource("ServiceAccount", "default", "token", map[string]interface{}{"sync": "true"}),
+		newSource("ResourceQuota", "other", "synced", map[string]interface{}{"sync": "true"}),
+	)
+
+	sources, err := getCloneListSources(client, newCloneList(map[string]string{"sync": "true"}))
+	assert.NilError(t, err)
+	var names []string
+	for _, source := range sources {
+		names = append(names, source.GetKind()+"/"+source.GetNamespace()+"/"+source.GetName())
+	}
+	// only the resources of the kinds in the namespace that match the selector are selected
+	assert.DeepEqual(t, names, []string{"ResourceQuota/default/synced", "ServiceAccount/default/token"})
+}
+
+func Test_applyCloneList(t *testing.T) {
+	var patches []map[string]interface{}
+	source := newSource("ResourceQuota", "default", "synced", map[string]interface{}{"sync": "true"})
+	existing := newSource("ResourceQuota", "team-a", "synced", map[string]interface{}{PolicyNameLabel: "sync-config", RuleNameLabel: "sync"})
+	client := newMockClient(t, source, existing)
+	client.PrependReactor("patch", "resourcequotas", applyReactor(&patches, nil))
+
+	trigger := unstructured.Unstructured{}
+	trigger.SetKind("Namespace")
+	trigger.SetName("team-a")
+	gen := kyverno.Generation{
+		ResourceSpec: kyverno.ResourceSpec{Namespace: "team-a"},
+		CloneList:    &kyverno.CloneList{Namespace: "default", Kinds: []string{"ResourceQuota"}},
+	}
+	sources := []unstructured.Unstructured{*source, *newSource("ResourceQuota", "team-a", "local", nil)}
+
+	genResources, err := applyCloneList(client, "sync-config", "sync", gen, sources, trigger, context.NewContext(), kyverno.Completed, false)
+	assert.NilError(t, err)
+	// the sources in the target namespace are not cloned
+	assert.DeepEqual(t, genResources, []kyverno.ResourceSpec{{Kind: "ResourceQuota", Namespace: "team-a", Name: "synced"}})
+	// the clone is created and synced with the source
+	assert.Equal(t, len(patches), 2)
+	for _, patch := range patches {
+		assert.DeepEqual(t, patch["data"], map[string]interface{}{"key": "synced"})
+	}
+	// the tracking labels are kept on the synced clone
+	labels := patches[1]["metadata"].(map[string]interface{})["labels"].(map[string]interface{})
+	assert.Equal(t, labels["sync"], "true")
+	assert.Equal(t, labels[PolicyNameLabel], "sync-config")
+	assert.Equal(t, labels[RuleNameLabel], "sync")
+}
+
+func Test_syncClone_NotGenerated(t *testing.T) {
+	var patches []map[string]interface{}
+	source := newSource("ResourceQuota", "default", "synced", nil)
+	client := newMockClient(t, source, newSource("ResourceQuota", "team-a", "synced", nil))
+	client.PrependReactor("patch", "resourcequotas", applyReactor(&patches, nil))
+
+	// the resources not generated by the policy are left as is
+	genResource := kyverno.ResourceSpec{Kind: "ResourceQuota", Namespace: "team-a", Name: "synced"}
+	assert.NilError(t, syncClone(client, "sync-config", genResource, *source))
+	assert.Equal(t, len(patches), 0)
+}
+
+func Test_deleteStaleClones(t *testing.T) {
+	client := newMockClient(t,
+		newSource("ResourceQuota", "team-a", "synced", nil),
+		newSource("ResourceQuota", "team-a", "stale", nil),
+		newSource("ResourceQuota", "team-a", "other-rule", nil),
+	)
+	clone := func(name, rule string) kyverno.GeneratedResource {
+		return kyverno.GeneratedResource{
+			ResourceSpec: kyverno.ResourceSpec{Kind: "ResourceQuota", Namespace: "team-a", Name: name},
+			Rule:         rule,
+		}
+	}
+	prevGenResources := []kyverno.GeneratedResource{clone("synced", "sync"), clone("stale", "sync"), clone("other-rule", "other"), clone("deleted", "sync")}
+	genResources := []kyverno.GeneratedResource{clone("synced", "sync")}
+
+	assert.NilError(t, deleteStaleClones(client, "sync", prevGenResources, genResources))
+	// the clones whose source no longer matches are deleted
+	_, err := client.GetResource("ResourceQuota", "team-a", "stale")
+	assert.Assert(t, apierrors.IsNotFound(err))
+	// the clones still matching and the resources of other rules are kept
+	_, err = client.GetResource("ResourceQuota", "team-a", "synced")
+	assert.NilError(t, err)
+	_, err = client.GetResource("ResourceQuota", "team-a", "other-rule")
+	assert.NilError(t, err)
+}
+
+func Test_clonesSource(t *testing.T) {
+	policy := newCloneListPolicy(newCloneList(map[string]string{"sync": "true"}))
+	assert.Assert(t, clonesSource(policy, newSource("ResourceQuota", "default", "synced", map[string]interface{}{"sync": "true"})))
+	assert.Assert(t, !clonesSource(policy, newSource("ResourceQuota", "default", "local", nil)))
+	assert.Assert(t, !clonesSource(policy, newSource("ResourceQuota", "other", "synced", map[string]interface{}{"sync": "true"})))
+	assert.Assert(t, !clonesSource(policy, newSource("Service", "default", "synced", map[string]interface{}{"sync": "true"})))
+
+	// a namespace with variables matches all namespaces
+	cloneList := newCloneList(nil)
+	cloneList.Namespace = "{{request.object.metadata.name}}"
+	assert.Assert(t, clonesSource(newCloneListPolicy(cloneList), newSource("ServiceAccount", "other", "token", nil)))
+}
+
+func Test_watchCloneList(t *testing.T) {
+	c := Controller{
+		client:             newMockClient(t),
+		dynamicInformer:    dynamicinformer.NewDynamicSharedInformerFactory(fake.NewSimpleDynamicClient(runtime.NewScheme()), 0),
+		cloneListInformers: map[schema.GroupVersionResource]bool{},
+	}
+	policy := newCloneListPolicy(newCloneList(nil))
+	policy.Spec.Rules[0].Generation.CloneList.Kinds = append(policy.Spec.Rules[0].Generation.CloneList.Kinds, "Unknown")
+
+	// each kind is watched once, the unknown kinds are skipped
+	c.watchCloneList(policy)
+	c.watchCloneList(policy)
+	assert.DeepEqual(t, c.cloneListInformers, map[schema.GroupVersionResource]bool{
+		{Version: "v1", Resource: "resourcequotas"}:  true,
+		{Version: "v1", Resource: "serviceaccounts"}: true,
+	})
+}
@@ -2,6 +2,9 @@ package generate
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -10,9 +13,15 @@ import (
 	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
 	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
 	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/engine"
 	"github.com/nirmata/kyverno/pkg/event"
+	"github.com/nirmata/kyverno/pkg/externaldata"
 	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"github.com/nirmata/kyverno/pkg/registry"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic/dynamicinformer"
@@ -57,6 +66,20 @@ type Controller struct {
 	//TODO: list of generic informers
 	// only support Namespaces for re-evalutation on resource updates
 	nsInformer informers.GenericInformer
+	// cloneListMu protects cloneListInformers and stopCh
+	cloneListMu sync.Mutex
+	// cloneListInformers are the resources watched for the source resources of cloneList rules
+	cloneListInformers map[schema.GroupVersionResource]bool
+	// stopCh is set when the controller is started, to start the informers registered afterwards
+	stopCh <-chan struct{}
+	// configMapResolver gets the ConfigMaps referenced in the rule context
+	configMapResolver engine.ConfigMapResolver
+	// registryClient fetches the image data referenced in the rule context
+	registryClient registry.Interface
+	// serviceClient calls the external services referenced in the rule context
+	serviceClient externaldata.Interface
+	// globalContext caches the data of the global context entries
+	globalContext *engine.GlobalContext
 }
 
 //NewController returns an instance of the Generate-Request Controller
@@ -68,6 +91,10 @@ func NewController(
 	eventGen event.Interface,
 	pvGenerator policyviolation.GeneratorInterface,
 	dynamicInformer dynamicinformer.DynamicSharedInformerFactory,
+	configMapResolver engine.ConfigMapResolver,
+	registryClient registry.Interface,
+	serviceClient externaldata.Interface,
+	globalContext *engine.GlobalContext,
 ) *Controller {
 	c := Controller{
 		client:        client,
@@ -76,13 +103,19 @@ func NewController(
 		pvGenerator:   pvGenerator,
 		//TODO: do the math for worst case back off and make sure cleanup runs after that
 		// as we dont want a deleted GR to be re-queue
-		queue:           workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(1, 30), "generate-request"),
-		dynamicInformer: dynamicInformer,
+		queue:              workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(1, 30), "generate-request"),
+		dynamicInformer:    dynamicInformer,
+		configMapResolver:  configMapResolver,
+		registryClient:     registryClient,
+		serviceClient:      serviceClient,
+		globalContext:      globalContext,
+		cloneListInformers: map[schema.GroupVersionResource]bool{},
 	}
 	c.statusControl = StatusControl{client: kyvernoclient}
 
 	pInformer.Informer().AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
-		UpdateFunc: c.updatePolicy, // We only handle updates to policy
+		AddFunc:    c.addPolicy,
+		UpdateFunc: c.updatePolicy,
 		// Deletion of policy will be handled by cleanup controller
 	}, 2*time.Minute)
 
@@ -106,13 +139,211 @@ func NewController(
 	nsInformer := dynamicInformer.ForResource(client.DiscoveryClient.GetGVRFromKind("Namespace"))
 	c.nsInformer = nsInformer
 	c.nsInformer.Informer().AddEventHandlerWithResyncPeriod(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.addNamespace,
 		UpdateFunc: c.updateGenericResource,
 	}, 2*time.Minute)
 	return &c
 }
 
+// addNamespace re-evaluates the GRs of policies that generate into the namespace,
+// so that a namespace created after the trigger also receives the generated resources
+func (c *Controller) addNamespace(obj interface{}) {
+	ns := obj.(*unstructured.Unstructured)
+	c.enqueueFanOutGRs(ns)
+}
+
+func (c *Controller) enqueueFanOutGRs(ns *unstructured.Unstructured) {
+	policies, err := c.pLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list policies: %v", err)
+		return
+	}
+	for _, p := range policies {
+		if !generatesIntoNamespace(*p, ns) {
+			continue
+		}
+		grs, err := c.grLister.GetGenerateRequestsForClusterPolicy(p.Name)
+		if err != nil {
+			glog.Errorf("failed to Generate Requests for policy %s: %v", p.Name, err)
+			continue
+		}
+		for _, gr := range grs {
+			c.enqueueGR(gr)
+		}
+	}
+}
+
+// generatesIntoNamespace checks if any generate rule of the policy targets the namespace
+// through namespaces or namespaceSelector
+func generatesIntoNamespace(policy kyverno.ClusterPolicy, ns *unstructured.Unstructured) bool {
+	for _, rule := range policy.Spec.Rules {
+		if !rule.HasGenerate() {
+			continue
+		}
+		for _, name := range rule.Generation.Namespaces {
+			if name == ns.GetName() {
+				return true
+			}
+		}
+		if rule.Generation.NamespaceSelector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(rule.Generation.NamespaceSelector)
+		if err != nil {
+			glog.V(4).Infof("invalid namespaceSelector in policy %s rule %s: %v", policy.Name, rule.Name, err)
+			continue
+		}
+		if selector.Matches(labels.Set(ns.GetLabels())) {
+			return true
+		}
+	}
+	return false
+}
+
+// watchCloneList registers the informers of the kinds cloned by the cloneList rules of the policy,
+// the informers are kept when the policy is deleted as other policies may clone the same kinds
+func (c *Controller) watchCloneList(policy kyverno.ClusterPolicy) {
+	c.cloneListMu.Lock()
+	defer c.cloneListMu.Unlock()
+	registered := false
+	for _, rule := range policy.Spec.Rules {
+		if rule.Generation.CloneList == nil {
+			continue
+		}
+		for _, kind := range rule.Generation.CloneList.Kinds {
+			gvr := c.client.DiscoveryClient.GetGVRFromKind(kind)
+			if gvr.Resource == "" {
+				glog.V(4).Infof("failed to watch kind %s of policy %s rule %s: resource not found", kind, policy.Name, rule.Name)
+				continue
+			}
+			if c.cloneListInformers[gvr] {
+				continue
+			}
+			glog.V(4).Infof("watching %s for the cloneList rules", gvr.String())
+			c.dynamicInformer.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+				AddFunc:    c.addCloneListSource,
+				UpdateFunc: c.updateCloneListSource,
+				DeleteFunc: c.deleteCloneListSource,
+			})
+			c.cloneListInformers[gvr] = true
+			registered = true
+		}
+	}
+	if registered && c.stopCh != nil {
+		// Start only starts the informers that are not running yet
+		c.dynamicInformer.Start(c.stopCh)
+	}
+}
+
+func (c *Controller) addCloneListSource(obj interface{}) {
+	c.enqueueCloneListGRs(obj.(*unstructured.Unstructured))
+}
+
+func (c *Controller) updateCloneListSource(old, cur interface{}) {
+	oldR := old.(*unstructured.Unstructured)
+	curR := cur.(*unstructured.Unstructured)
+	if oldR.GetResourceVersion() == curR.GetResourceVersion() {
+		// Periodic resync will send update events for all known resources.
+		return
+	}
+	// the old resource is checked as well, the resource might no longer match the selector
+	c.enqueueCloneListGRs(oldR, curR)
+}
+
+func (c *Controller) deleteCloneListSource(obj interface{}) {
+	source, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			glog.Info(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		source, ok = tombstone.Obj.(*unstructured.Unstructured)
+		if !ok {
+			glog.Info(fmt.Errorf("Tombstone contained object that is not a resource %#v", obj))
+			return
+		}
+	}
+	c.enqueueCloneListGRs(source)
+}
+
+// enqueueCloneListGRs re-evaluates the GRs of policies with cloneList rules matching the source resources,
+// to keep the cloned resources in sync with the source resources
+func (c *Controller) enqueueCloneListGRs(sources ...*unstructured.Unstructured) {
+	policies, err := c.pLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list policies: %v", err)
+		return
+	}
+	for _, p := range policies {
+		if !clonesAny(*p, sources) {
+			continue
+		}
+		grs, err := c.grLister.GetGenerateRequestsForClusterPolicy(p.Name)
+		if err != nil {
+			glog.Errorf("failed to Generate Requests for policy %s: %v", p.Name, err)
+			continue
+		}
+		for _, gr := range grs {
+			c.enqueueGR(gr)
+		}
+	}
+}
+
+func clonesAny(policy kyverno.ClusterPolicy, sources []*unstructured.Unstructured) bool {
+	for _, source := range sources {
+		if clonesSource(policy, source) {
+			return true
+		}
+	}
+	return false
+}
+
+// clonesSource checks if any cloneList rule of the policy selects the source resource
+// a namespace with variables is resolved per request, it is considered to match all namespaces
+func clonesSource(policy kyverno.ClusterPolicy, source *unstructured.Unstructured) bool {
+	for _, rule := range policy.Spec.Rules {
+		cloneList := rule.Generation.CloneList
+		if cloneList == nil {
+			continue
+		}
+		if cloneList.Namespace != source.GetNamespace() && !strings.Contains(cloneList.Namespace, "{{") {
+			continue
+		}
+		if !containsString(cloneList.Kinds, source.GetKind()) {
+			continue
+		}
+		if cloneList.Selector == nil {
+			return true
+		}
+		selector, err := metav1.LabelSelectorAsSelector(cloneList.Selector)
+		if err != nil {
+			glog.V(4).Infof("invalid cloneList selector in policy %s rule %s: %v", policy.Name, rule.Name, err)
+			continue
+		}
+		if selector.Matches(labels.Set(source.GetLabels())) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Controller) updateGenericResource(old, cur interface{}) {
 	curR := cur.(*unstructured.Unstructured)
+	oldR := old.(*unstructured.Unstructured)
+	if !reflect.DeepEqual(oldR.GetLabels(), curR.GetLabels()) {
+		// labels updated, the namespace might now match a namespaceSelector
+		c.enqueueFanOutGRs(curR)
+	}
 
 	grs, err := c.grLister.GetGenerateRequestsForResource(curR.GetKind(), curR.GetNamespace(), curR.GetName())
 	if err != nil {
@@ -144,6 +375,7 @@ func (c *Controller) updatePolicy(old, cur interface{}) {
 		return
 	}
 	glog.V(4).Infof("Updating Policy %s", oldP.Name)
+	c.watchCloneList(*curP)
 	// get the list of GR for the current Policy version
 	grs, err := c.grLister.GetGenerateRequestsForClusterPolicy(curP.Name)
 	if err != nil {
@@ -156,6 +388,11 @@ func (c *Controller) updatePolicy(old, cur interface{}) {
 	}
 }
 
+func (c *Controller) addPolicy(obj interface{}) {
+	p := obj.(*kyverno.ClusterPolicy)
+	c.watchCloneList(*p)
+}
+
 func (c *Controller) addGR(obj interface{}) {
 	gr := obj.(*kyverno.GenerateRequest)
 	c.enqueueGR(gr)
@@ -208,6 +445,12 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
 		glog.Error("generate-policy controller: failed to sync informer cache")
 		return
 	}
+	c.cloneListMu.Lock()
+	c.stopCh = stopCh
+	c.cloneListMu.Unlock()
+	// start the informers of the cloneList rules registered before the controller is started
+	c.dynamicInformer.Start(stopCh)
+
 	for i := 0; i < workers; i++ {
 		go wait.Until(c.worker, time.Second, stopCh)
 	}
@@ -268,3 +511,8 @@ func (c *Controller) syncGenerateRequest(key string) error {
 	}
 	return c.processGR(gr)
 }
+
+//Len returns the number of the queued generate requests
+func (c *Controller) Len() int {
+	return c.queue.Len()
+}
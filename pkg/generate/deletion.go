@@ -0,0 +1,53 @@
+package generate
+
+import (
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ReleaseGeneratedResource applies the deletion policy of the generate rule on the generated resource,
+// it is called when the policy or the trigger resource is deleted
+// - Delete: the resource is deleted (default)
+// - Orphan: the resource is left as is
+// - OrphanAndLabel: the resource is left and labeled as orphaned
+func ReleaseGeneratedResource(client *dclient.Client, genResource kyverno.GeneratedResource) error {
+	switch genResource.DeletionPolicy {
+	case kyverno.Orphan:
+		glog.V(4).Infof("resource %s/%s/%s is orphaned by rule %s, will not delete", genResource.Kind, genResource.Namespace, genResource.Name, genResource.Rule)
+		return nil
+	case kyverno.OrphanAndLabel:
+		return labelOrphaned(client, genResource)
+	default:
+		err := client.DeleteResource(genResource.Kind, genResource.Namespace, genResource.Name, false)
+		if apierrors.IsNotFound(err) {
+			glog.V(4).Infof("resource %s/%s/%s not found, will not delete", genResource.Kind, genResource.Namespace, genResource.Name)
+			return nil
+		}
+		return err
+	}
+}
+
+func labelOrphaned(client *dclient.Client, genResource kyverno.GeneratedResource) error {
+	obj, err := client.GetResource(genResource.Kind, genResource.Namespace, genResource.Name)
+	if apierrors.IsNotFound(err) {
+		glog.V(4).Infof("resource %s/%s/%s not found, will not label", genResource.Kind, genResource.Namespace, genResource.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	if labels[OrphanedLabel] == "true" {
+		return nil
+	}
+	labels[OrphanedLabel] = "true"
+	obj.SetLabels(labels)
+	glog.V(4).Infof("labeling resource %s/%s/%s as orphaned", genResource.Kind, genResource.Namespace, genResource.Name)
+	_, err = client.UpdateResource(genResource.Kind, genResource.Namespace, obj, false)
+	return err
+}
@@ -47,20 +47,3 @@ func (e *NotFound) Error() string {
 func NewNotFound(kind, namespace, name string) *NotFound {
 	return &NotFound{kind: kind, namespace: namespace, name: name}
 }
-
-//ConfigNotFound stores the config information
-type ConfigNotFound struct {
-	config    interface{}
-	kind      string
-	namespace string
-	name      string
-}
-
-func (e *ConfigNotFound) Error() string {
-	return fmt.Sprintf("configuration %v, not present in resource %s/%s/%s", e.config, e.kind, e.namespace, e.name)
-}
-
-//NewConfigNotFound returns a new NewConfigNotFound error
-func NewConfigNotFound(config interface{}, kind, namespace, name string) *ConfigNotFound {
-	return &ConfigNotFound{config: config, kind: kind, namespace: namespace, name: name}
-}
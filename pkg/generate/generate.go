@@ -1,18 +1,18 @@
 package generate
 
 import (
-	"errors"
+	"encoding/json"
 	"fmt"
-	"reflect"
 
 	"github.com/golang/glog"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	dclient "github.com/nirmata/kyverno/pkg/dclient"
 	"github.com/nirmata/kyverno/pkg/engine"
 	"github.com/nirmata/kyverno/pkg/engine/context"
-	"github.com/nirmata/kyverno/pkg/engine/validate"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
 	"github.com/nirmata/kyverno/pkg/engine/variables"
 	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"k8s.io/api/admission/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -21,7 +21,7 @@ import (
 func (c *Controller) processGR(gr *kyverno.GenerateRequest) error {
 	var err error
 	var resource *unstructured.Unstructured
-	var genResources []kyverno.ResourceSpec
+	var genResources []kyverno.GeneratedResource
 	// 1 - Check if the resource exists
 	resource, err = getResource(c.client, gr.Spec.Resource)
 	if err != nil {
@@ -51,7 +51,7 @@ func (c *Controller) processGR(gr *kyverno.GenerateRequest) error {
 	return updateStatus(c.statusControl, *gr, err, genResources)
 }
 
-func (c *Controller) applyGenerate(resource unstructured.Unstructured, gr kyverno.GenerateRequest) ([]kyverno.ResourceSpec, error) {
+func (c *Controller) applyGenerate(resource unstructured.Unstructured, gr kyverno.GenerateRequest) ([]kyverno.GeneratedResource, error) {
 	// Get the list of rules to be applied
 	// get policy
 	policy, err := c.pLister.Get(gr.Spec.Policy)
@@ -76,6 +76,12 @@ func (c *Controller) applyGenerate(resource unstructured.Unstructured, gr kyvern
 		glog.Infof("Failed to load userInfo in context: %v", err)
 		return nil, err
 	}
+	// the generate rules are applied on the created resources
+	err = ctx.AddRequestInfo(string(v1beta1.Create), resource.GetNamespace())
+	if err != nil {
+		glog.Infof("Failed to load request info in context: %v", err)
+		return nil, err
+	}
 	err = ctx.AddSA(gr.Spec.Context.UserRequestInfo.AdmissionUserInfo.Username)
 	if err != nil {
 		glog.Infof("Failed to load serviceAccount in context: %v", err)
@@ -83,10 +89,16 @@ func (c *Controller) applyGenerate(resource unstructured.Unstructured, gr kyvern
 	}
 
 	policyContext := engine.PolicyContext{
-		NewResource:   resource,
-		Policy:        *policy,
-		Context:       ctx,
-		AdmissionInfo: gr.Spec.Context.UserRequestInfo,
+		NewResource:         resource,
+		Policy:              *policy,
+		Context:             ctx,
+		AdmissionInfo:       gr.Spec.Context.UserRequestInfo,
+		Client:              c.client,
+		ConfigMapResolver:   c.configMapResolver,
+		ImageRegistryClient: c.registryClient,
+		ServiceClient:       c.serviceClient,
+		GlobalContext:       c.globalContext,
+		Log:                 enginelog.Glog,
 	}
 
 	// check if the policy still applies to the resource
@@ -104,10 +116,10 @@ func (c *Controller) applyGenerate(resource unstructured.Unstructured, gr kyvern
 	}
 
 	// Apply the generate rule on resource
-	return applyGeneratePolicy(c.client, policyContext, gr.Status.State)
+	return applyGeneratePolicy(c.client, policyContext, gr.Status.State, gr.Status.GeneratedResources)
 }
 
-func updateStatus(statusControl StatusControlInterface, gr kyverno.GenerateRequest, err error, genResources []kyverno.ResourceSpec) error {
+func updateStatus(statusControl StatusControlInterface, gr kyverno.GenerateRequest, err error, genResources []kyverno.GeneratedResource) error {
 	if err != nil {
 		return statusControl.Failed(gr, err.Error(), genResources)
 	}
@@ -116,16 +128,20 @@ func updateStatus(statusControl StatusControlInterface, gr kyverno.GenerateReque
 	return statusControl.Success(gr, genResources)
 }
 
-func applyGeneratePolicy(client *dclient.Client, policyContext engine.PolicyContext, state kyverno.GenerateRequestState) ([]kyverno.ResourceSpec, error) {
+func applyGeneratePolicy(client *dclient.Client, policyContext engine.PolicyContext, state kyverno.GenerateRequestState, prevGenResources []kyverno.GeneratedResource) ([]kyverno.GeneratedResource, error) {
 	// List of generatedResources
-	var genResources []kyverno.ResourceSpec
+	var genResources []kyverno.GeneratedResource
 	// Get the response as the actions to be performed on the resource
 	// - - substitute values
 	policy := policyContext.Policy
 	resource := policyContext.NewResource
 	ctx := policyContext.Context
 	// To manage existing resources, we compare the creation time for the default resiruce to be generated and policy creation time
+	// with generateExisting, the resources are generated for the existing resources as well
 	processExisting := func() bool {
+		if policy.Spec.GenerateExisting {
+			return false
+		}
 		rcreationTime := resource.GetCreationTimestamp()
 		pcreationTime := policy.GetCreationTimestamp()
 		return rcreationTime.Before(&pcreationTime)
@@ -135,31 +151,89 @@ func applyGeneratePolicy(client *dclient.Client, policyContext engine.PolicyCont
 		if !rule.HasGenerate() {
 			continue
 		}
-		genResource, err := applyRule(client, rule, resource, ctx, state, processExisting)
+		genResource, err := applyRule(client, policy.Name, rule, resource, ctx, state, prevGenResources, processExisting)
 		if err != nil {
 			return nil, err
 		}
-		genResources = append(genResources, genResource)
+		genResources = append(genResources, genResource...)
 	}
 
 	return genResources, nil
 }
 
-func applyRule(client *dclient.Client, rule kyverno.Rule, resource unstructured.Unstructured, ctx context.EvalInterface, state kyverno.GenerateRequestState, processExisting bool) (kyverno.ResourceSpec, error) {
-	var rdata map[string]interface{}
-	var err error
-	var noGenResource kyverno.ResourceSpec
-
+func applyRule(client *dclient.Client, policyName string, rule kyverno.Rule, resource unstructured.Unstructured, ctx context.EvalInterface, state kyverno.GenerateRequestState, prevGenResources []kyverno.GeneratedResource, processExisting bool) ([]kyverno.GeneratedResource, error) {
 	if invalidPaths := variables.ValidateVariables(ctx, rule.Generation.ResourceSpec); len(invalidPaths) != 0 {
-		return noGenResource, NewViolation(rule.Name, fmt.Errorf("path not present in generate resource spec: %s", invalidPaths))
+		return nil, NewViolation(rule.Name, fmt.Errorf("path not present in generate resource spec: %s", invalidPaths))
 	}
 
 	// variable substitution
 	// - name
 	// - namespace
+	// - namespaces
 	// - clone.name
 	// - clone.namespace
 	gen := variableSubsitutionForAttributes(rule.Generation, ctx)
+
+	// the resource is generated in every target namespace
+	namespaces, err := getTargetNamespaces(client, gen)
+	if err != nil {
+		return nil, err
+	}
+
+	// the resources to be cloned with cloneList
+	var sources []unstructured.Unstructured
+	if gen.CloneList != nil {
+		if sources, err = getCloneListSources(client, *gen.CloneList); err != nil {
+			return nil, err
+		}
+	}
+
+	var genResources []kyverno.GeneratedResource
+	for _, namespace := range namespaces {
+		nsGen := gen
+		nsGen.Namespace = namespace
+		nsState := state
+		if isFanOut(gen) && !containsResourceSpec(prevGenResources, kyverno.ResourceSpec{Kind: nsGen.Kind, Namespace: nsGen.Namespace, Name: nsGen.Name}) {
+			// the namespace was not a target when the request was processed before,
+			// process it as a new request
+			nsState = ""
+		}
+		var nsGenResources []kyverno.ResourceSpec
+		if gen.CloneList != nil {
+			if nsGenResources, err = applyCloneList(client, policyName, rule.Name, nsGen, sources, resource, ctx, nsState, processExisting); err != nil {
+				return nil, err
+			}
+		} else {
+			genResource, err := applyRuleForNamespace(client, policyName, rule.Name, nsGen, resource, ctx, nsState, processExisting)
+			if err != nil {
+				return nil, err
+			}
+			if genResource != (kyverno.ResourceSpec{}) {
+				nsGenResources = append(nsGenResources, genResource)
+			}
+		}
+		for _, genResource := range nsGenResources {
+			genResources = append(genResources, kyverno.GeneratedResource{
+				ResourceSpec:   genResource,
+				Rule:           rule.Name,
+				DeletionPolicy: gen.DeletionPolicy,
+			})
+		}
+	}
+	if gen.CloneList != nil {
+		// keep the cloned resources in sync with the sources
+		if err := deleteStaleClones(client, rule.Name, prevGenResources, genResources); err != nil {
+			return nil, err
+		}
+	}
+	return genResources, nil
+}
+
+func applyRuleForNamespace(client *dclient.Client, policyName, ruleName string, gen kyverno.Generation, resource unstructured.Unstructured, ctx context.EvalInterface, state kyverno.GenerateRequestState, processExisting bool) (kyverno.ResourceSpec, error) {
+	var rdata map[string]interface{}
+	var err error
+	var noGenResource kyverno.ResourceSpec
+
 	// Resource to be generated
 	newGenResource := kyverno.ResourceSpec{
 		Kind:      gen.Kind,
@@ -169,10 +243,10 @@ func applyRule(client *dclient.Client, rule kyverno.Rule, resource unstructured.
 
 	// DATA
 	if gen.Data != nil {
-		if rdata, err = handleData(rule.Name, gen, client, resource, ctx, state); err != nil {
+		if rdata, err = handleData(ruleName, gen, client, resource, ctx, state); err != nil {
 			glog.V(4).Info(err)
 			switch e := err.(type) {
-			case *ParseFailed, *NotFound, *ConfigNotFound:
+			case *ParseFailed, *NotFound:
 				// handled errors
 			case *Violation:
 				// create policy violation
@@ -183,13 +257,12 @@ func applyRule(client *dclient.Client, rule kyverno.Rule, resource unstructured.
 			}
 		}
 		if rdata == nil {
-			// existing resource contains the configuration
 			return newGenResource, nil
 		}
 	}
 	// CLONE
 	if gen.Clone != (kyverno.CloneFrom{}) {
-		if rdata, err = handleClone(rule.Name, gen, client, resource, ctx, state); err != nil {
+		if rdata, err = handleClone(ruleName, gen, client, resource, ctx, state); err != nil {
 			glog.V(4).Info(err)
 			switch e := err.(type) {
 			case *NotFound:
@@ -200,35 +273,88 @@ func applyRule(client *dclient.Client, rule kyverno.Rule, resource unstructured.
 				return noGenResource, e
 			}
 		}
-		if rdata == nil {
-			// resource already exists
-			return newGenResource, nil
-		}
 	}
 	if processExisting {
 		// handle existing resources
 		// policy was generated after the resource
-		// we do not create new resource
-		return noGenResource, err
+		// we do not create new resource, the existing resource is synced
+		_, err := client.GetResource(gen.Kind, gen.Namespace, gen.Name)
+		if apierrors.IsNotFound(err) {
+			return noGenResource, nil
+		}
+		if err != nil {
+			return noGenResource, err
+		}
 	}
-	// Create the generate resource
+	// Create or update the generate resource
 	newResource := &unstructured.Unstructured{}
 	newResource.SetUnstructuredContent(rdata)
 	newResource.SetName(gen.Name)
 	newResource.SetNamespace(gen.Namespace)
 	// Reset resource version
 	newResource.SetResourceVersion("")
+	// track the policy, rule and trigger resource that generated the resource
+	addTrackingLabels(newResource, policyName, ruleName, resource)
 
-	glog.V(4).Infof("creating resource %v", newResource)
-	_, err = client.CreateResource(gen.Kind, gen.Namespace, newResource, false)
-	if err != nil {
+	glog.V(4).Infof("applying resource %v", newResource)
+	if err = applyResource(client, gen.Kind, newResource); err != nil {
 		glog.Info(err)
 		return noGenResource, err
 	}
-	glog.V(4).Infof("created new resource %s %s %s ", gen.Kind, gen.Namespace, gen.Name)
+	glog.V(4).Infof("applied resource %s %s %s ", gen.Kind, gen.Namespace, gen.Name)
 	return newGenResource, nil
 }
 
+// getTargetNamespaces returns the namespaces the resource is to be generated in
+// - namespace: a single namespace (default)
+// - namespaces: a list of namespaces
+// - namespaceSelector: all namespaces matching the label selector
+// namespaces and namespaceSelector can be combined, the union of both is returned
+func getTargetNamespaces(client *dclient.Client, gen kyverno.Generation) ([]string, error) {
+	if !isFanOut(gen) {
+		return []string{gen.Namespace}, nil
+	}
+	var namespaces []string
+	namespaces = append(namespaces, gen.Namespaces...)
+	if gen.NamespaceSelector != nil {
+		nsList, err := client.ListResource("Namespace", "", gen.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespaces for selector %v: %v", gen.NamespaceSelector, err)
+		}
+		for _, ns := range nsList.Items {
+			namespaces = append(namespaces, ns.GetName())
+		}
+	}
+	return uniqueStrings(namespaces), nil
+}
+
+// isFanOut returns true if the resource is generated in multiple namespaces
+func isFanOut(gen kyverno.Generation) bool {
+	return len(gen.Namespaces) != 0 || gen.NamespaceSelector != nil
+}
+
+func containsResourceSpec(genResources []kyverno.GeneratedResource, spec kyverno.ResourceSpec) bool {
+	for _, r := range genResources {
+		if r.ResourceSpec == spec {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueStrings(list []string) []string {
+	var unique []string
+	seen := map[string]bool{}
+	for _, s := range list {
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		unique = append(unique, s)
+	}
+	return unique
+}
+
 func variableSubsitutionForAttributes(gen kyverno.Generation, ctx context.EvalInterface) kyverno.Generation {
 	// Name
 	name := gen.Name
@@ -244,6 +370,24 @@ func variableSubsitutionForAttributes(gen kyverno.Generation, ctx context.EvalIn
 		gen.Namespace = newNamespace
 	}
 
+	// Namespaces
+	var namespaces []string
+	for _, ns := range gen.Namespaces {
+		if newNs, ok := variables.SubstituteVariables(ctx, ns).(string); ok {
+			namespaces = append(namespaces, newNs)
+		}
+	}
+	gen.Namespaces = namespaces
+
+	// CloneList
+	if gen.CloneList != nil {
+		cloneList := gen.CloneList.DeepCopy()
+		if newNamespace, ok := variables.SubstituteVariables(ctx, cloneList.Namespace).(string); ok {
+			cloneList.Namespace = newNamespace
+		}
+		gen.CloneList = cloneList
+	}
+
 	if gen.Clone != (kyverno.CloneFrom{}) {
 		// Clone
 		cloneName := gen.Clone.Name
@@ -266,10 +410,16 @@ func handleData(ruleName string, generateRule kyverno.Generation, client *dclien
 		return nil, NewViolation(ruleName, fmt.Errorf("path not present in generate data: %s", invalidPaths))
 	}
 
-	newData := variables.SubstituteVariables(ctx, generateRule.Data)
+	// variables are substituted in-place, use a copy of the data
+	// as the policy is shared with the informer cache
+	data, err := copyData(generateRule.Data)
+	if err != nil {
+		return nil, NewParseFailed(generateRule.Data, err)
+	}
+	newData := variables.SubstituteVariables(ctx, data)
 
 	// check if resource exists
-	obj, err := client.GetResource(generateRule.Kind, generateRule.Namespace, generateRule.Name)
+	_, err = client.GetResource(generateRule.Kind, generateRule.Namespace, generateRule.Name)
 	glog.V(4).Info(err)
 	if apierrors.IsNotFound(err) {
 		glog.V(4).Info(string(state))
@@ -293,18 +443,24 @@ func handleData(ruleName string, generateRule kyverno.Generation, client *dclien
 		//something wrong while fetching resource
 		return nil, err
 	}
-	// Resource exists; verfiy the content of the resource
-	ok, err := checkResource(ctx, newData, obj)
+	// Resource exists; the data is applied again to sync the resource
+	rdata, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&newData)
+	if err != nil {
+		return nil, NewParseFailed(newData, err)
+	}
+	return rdata, nil
+}
+
+func copyData(data interface{}) (interface{}, error) {
+	raw, err := json.Marshal(data)
 	if err != nil {
-		//something wrong with configuration
-		glog.V(4).Info(err)
 		return nil, err
 	}
-	if !ok {
-		return nil, NewConfigNotFound(newData, generateRule.Kind, generateRule.Namespace, generateRule.Name)
+	var dataCopy interface{}
+	if err := json.Unmarshal(raw, &dataCopy); err != nil {
+		return nil, err
 	}
-	// Existing resource does contain the required
-	return nil, nil
+	return dataCopy, nil
 }
 
 func handleClone(ruleName string, generateRule kyverno.Generation, client *dclient.Client, resource unstructured.Unstructured, ctx context.EvalInterface, state kyverno.GenerateRequestState) (map[string]interface{}, error) {
@@ -312,18 +468,7 @@ func handleClone(ruleName string, generateRule kyverno.Generation, client *dclie
 		return nil, NewViolation(ruleName, fmt.Errorf("path not present in generate clone: %s", invalidPaths))
 	}
 
-	// check if resource exists
-	_, err := client.GetResource(generateRule.Kind, generateRule.Namespace, generateRule.Name)
-	if err == nil {
-		// resource exists
-		return nil, nil
-	}
-	if !apierrors.IsNotFound(err) {
-		//something wrong while fetching resource
-		return nil, err
-	}
-
-	// get reference clone resource
+	// get reference clone resource, it is applied on the existing resource to sync it
 	obj, err := client.GetResource(generateRule.Kind, generateRule.Clone.Namespace, generateRule.Clone.Name)
 	if apierrors.IsNotFound(err) {
 		return nil, NewNotFound(generateRule.Kind, generateRule.Clone.Namespace, generateRule.Clone.Name)
@@ -335,16 +480,6 @@ func handleClone(ruleName string, generateRule kyverno.Generation, client *dclie
 	return obj.UnstructuredContent(), nil
 }
 
-func checkResource(ctx context.EvalInterface, newResourceSpec interface{}, resource *unstructured.Unstructured) (bool, error) {
-	// check if the resource spec if a subset of the resource
-	path, err := validate.ValidateResourceWithPattern(ctx, resource.Object, newResourceSpec)
-	if !reflect.DeepEqual(err, validate.ValidationError{}) {
-		glog.V(4).Infof("config not a subset of resource. failed at path %s: %v", path, err)
-		return false, errors.New(err.ErrorMsg)
-	}
-	return true, nil
-}
-
 func generatePV(gr kyverno.GenerateRequest, resource unstructured.Unstructured, err *Violation) policyviolation.Info {
 
 	info := policyviolation.Info{
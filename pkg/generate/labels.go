@@ -0,0 +1,49 @@
+package generate
+
+import (
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// labels added to the generated resources to track the policy, rule and trigger resource
+const (
+	// PolicyNameLabel is the name of the policy that generated the resource
+	PolicyNameLabel = "generate.kyverno.io/policy-name"
+	// RuleNameLabel is the name of the rule that generated the resource
+	RuleNameLabel = "generate.kyverno.io/rule-name"
+	// TriggerKindLabel is the kind of the resource that triggered the generate rule
+	TriggerKindLabel = "generate.kyverno.io/trigger-kind"
+	// TriggerNamespaceLabel is the namespace of the resource that triggered the generate rule
+	TriggerNamespaceLabel = "generate.kyverno.io/trigger-namespace"
+	// TriggerNameLabel is the name of the resource that triggered the generate rule
+	TriggerNameLabel = "generate.kyverno.io/trigger-name"
+	// OrphanedLabel is set on the generated resources orphaned with the OrphanAndLabel deletion policy
+	OrphanedLabel = "generate.kyverno.io/orphaned"
+)
+
+var trackingLabelKeys = []string{PolicyNameLabel, RuleNameLabel, TriggerKindLabel, TriggerNamespaceLabel, TriggerNameLabel}
+
+// addTrackingLabels labels the generated resource with the policy, rule and trigger resource
+// values that are not valid label values are skipped
+func addTrackingLabels(newResource *unstructured.Unstructured, policy, rule string, trigger unstructured.Unstructured) {
+	labels := newResource.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	trackingLabels := map[string]string{
+		PolicyNameLabel:       policy,
+		RuleNameLabel:         rule,
+		TriggerKindLabel:      trigger.GetKind(),
+		TriggerNamespaceLabel: trigger.GetNamespace(),
+		TriggerNameLabel:      trigger.GetName(),
+	}
+	for key, value := range trackingLabels {
+		if errs := validation.IsValidLabelValue(value); len(errs) != 0 {
+			glog.V(4).Infof("skipping label %s on generated resource %s/%s: %v", key, newResource.GetNamespace(), newResource.GetName(), errs)
+			continue
+		}
+		labels[key] = value
+	}
+	newResource.SetLabels(labels)
+}
@@ -0,0 +1,46 @@
+package generate
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_addTrackingLabels(t *testing.T) {
+	newResource := unstructured.Unstructured{}
+	newResource.SetLabels(map[string]string{"app": "nginx"})
+	trigger := unstructured.Unstructured{}
+	trigger.SetKind("Namespace")
+	trigger.SetName("team-a")
+
+	addTrackingLabels(&newResource, "add-networkpolicy", "default-deny", trigger)
+	assert.DeepEqual(t, newResource.GetLabels(), map[string]string{
+		"app":                                   "nginx",
+		"generate.kyverno.io/policy-name":       "add-networkpolicy",
+		"generate.kyverno.io/rule-name":         "default-deny",
+		"generate.kyverno.io/trigger-kind":      "Namespace",
+		"generate.kyverno.io/trigger-namespace": "",
+		"generate.kyverno.io/trigger-name":      "team-a",
+	})
+	for _, key := range trackingLabelKeys {
+		assert.Assert(t, strings.HasPrefix(key, "generate.kyverno.io/"), key)
+	}
+}
+
+func Test_addTrackingLabels_InvalidValue(t *testing.T) {
+	newResource := unstructured.Unstructured{}
+	trigger := unstructured.Unstructured{}
+	trigger.SetKind("ConfigMap")
+	trigger.SetNamespace("default")
+	trigger.SetName(strings.Repeat("a", 64))
+
+	// the names longer than 63 characters are not valid label values
+	addTrackingLabels(&newResource, "sync-configmap", "clone", trigger)
+	labels := newResource.GetLabels()
+	_, ok := labels[TriggerNameLabel]
+	assert.Assert(t, !ok)
+	assert.Equal(t, labels[TriggerNamespaceLabel], "default")
+	assert.Equal(t, labels[PolicyNameLabel], "sync-configmap")
+}
@@ -8,8 +8,8 @@ import (
 
 //StatusControlInterface provides interface to update status subresource
 type StatusControlInterface interface {
-	Failed(gr kyverno.GenerateRequest, message string, genResources []kyverno.ResourceSpec) error
-	Success(gr kyverno.GenerateRequest, genResources []kyverno.ResourceSpec) error
+	Failed(gr kyverno.GenerateRequest, message string, genResources []kyverno.GeneratedResource) error
+	Success(gr kyverno.GenerateRequest, genResources []kyverno.GeneratedResource) error
 }
 
 // StatusControl is default implementaation of GRStatusControlInterface
@@ -18,7 +18,7 @@ type StatusControl struct {
 }
 
 //Failed sets gr status.state to failed with message
-func (sc StatusControl) Failed(gr kyverno.GenerateRequest, message string, genResources []kyverno.ResourceSpec) error {
+func (sc StatusControl) Failed(gr kyverno.GenerateRequest, message string, genResources []kyverno.GeneratedResource) error {
 	gr.Status.State = kyverno.Failed
 	gr.Status.Message = message
 	// Update Generated Resources
@@ -33,7 +33,7 @@ func (sc StatusControl) Failed(gr kyverno.GenerateRequest, message string, genRe
 }
 
 // Success sets the gr status.state to completed and clears message
-func (sc StatusControl) Success(gr kyverno.GenerateRequest, genResources []kyverno.ResourceSpec) error {
+func (sc StatusControl) Success(gr kyverno.GenerateRequest, genResources []kyverno.GeneratedResource) error {
 	gr.Status.State = kyverno.Completed
 	gr.Status.Message = ""
 	// Update Generated Resources
@@ -0,0 +1,467 @@
+package git
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// maxPackSize is the maximum size of the fetched packs, the files of a repository are kept in memory
+const maxPackSize = 64 << 20
+
+// sshTimeout is the timeout of the SSH connections
+const sshTimeout = 10 * time.Second
+
+// Auth are the credentials of a repository, the username and the password or the token for HTTPS,
+// the private key and the known hosts for SSH
+type Auth struct {
+	Username string
+	Password string
+	// PrivateKey is the PEM encoded private key of the SSH user
+	PrivateKey []byte
+	// KnownHosts are the public keys of the SSH servers, in the format of the known_hosts files
+	KnownHosts []byte
+}
+
+// Client fetches the last commit of the branches of the Git repositories, with the Git protocol over HTTPS or SSH.
+// Only the files of the commit are fetched, as a shallow clone with a depth of 1.
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient returns a Git client, the HTTPS repositories are fetched with the HTTP client
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Minute}
+	}
+	return &Client{httpClient: httpClient}
+}
+
+// session is a connection to the upload-pack service of a repository
+type session interface {
+	// advertisement returns the reader of the references advertised by the service
+	advertisement() (io.Reader, error)
+	// uploadPack sends the request of the pack and returns the reader of the response
+	uploadPack(request []byte) (io.Reader, error)
+	Close() error
+}
+
+// Fetch returns the last commit of the branch of the repository, of the default branch if the branch is empty.
+// The repository URL is https://host/path, ssh://[user@]host[:port]/path or [user@]host:path for SSH.
+func (c *Client) Fetch(repository, branch string, auth Auth) (*Commit, error) {
+	s, err := c.connect(repository, auth)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	adv, err := s.advertisement()
+	if err != nil {
+		return nil, err
+	}
+	refs, capabilities, err := readAdvertisement(adv)
+	if err != nil {
+		return nil, fmt.Errorf("invalid references of %s: %v", repository, err)
+	}
+	hash, err := resolveBranch(refs, branch)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", repository, err)
+	}
+	request, err := uploadRequest(hash, capabilities)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", repository, err)
+	}
+	resp, err := s.uploadPack(request)
+	if err != nil {
+		return nil, err
+	}
+	pack, err := readUploadResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", repository, err)
+	}
+	objects, err := readPack(pack)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", repository, err)
+	}
+	return newCommit(hash, objects)
+}
+
+// FetchFiles returns the hash of the last commit of the branch and the files of the directory per relative path
+func (c *Client) FetchFiles(repository, branch, dir string, auth Auth) (string, map[string][]byte, error) {
+	commit, err := c.Fetch(repository, branch, auth)
+	if err != nil {
+		return "", nil, err
+	}
+	files, err := commit.Files(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	return commit.Hash, files, nil
+}
+
+func (c *Client) connect(repository string, auth Auth) (session, error) {
+	if strings.HasPrefix(repository, "https://") || strings.HasPrefix(repository, "http://") {
+		return &httpSession{client: c.httpClient, url: strings.TrimSuffix(repository, "/"), auth: auth}, nil
+	}
+	user, host, path, err := parseSSHURL(repository)
+	if err != nil {
+		return nil, err
+	}
+	return dialSSH(user, host, path, auth)
+}
+
+// readAdvertisement returns the hashes of the advertised references per name, and the capabilities of the service
+func readAdvertisement(r io.Reader) (map[string]string, map[string]bool, error) {
+	refs := map[string]string{}
+	capabilities := map[string]bool{}
+	for first := true; ; first = false {
+		line, err := readPktLine(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		if line == nil {
+			break
+		}
+		if bytes.HasPrefix(line, []byte("ERR ")) {
+			return nil, nil, fmt.Errorf("%s", line[4:])
+		}
+		if first {
+			if i := bytes.IndexByte(line, 0); i != -1 {
+				for _, capability := range strings.Fields(string(line[i+1:])) {
+					capabilities[strings.SplitN(capability, "=", 2)[0]] = true
+				}
+				line = line[:i]
+			}
+		}
+		fields := strings.Fields(string(line))
+		if len(fields) != 2 {
+			return nil, nil, fmt.Errorf("invalid reference %q", line)
+		}
+		refs[fields[1]] = fields[0]
+	}
+	return refs, capabilities, nil
+}
+
+func resolveBranch(refs map[string]string, branch string) (string, error) {
+	name := "HEAD"
+	if branch != "" {
+		name = "refs/heads/" + strings.TrimPrefix(branch, "refs/heads/")
+	}
+	hash, ok := refs[name]
+	if !ok {
+		if _, empty := refs["capabilities^{}"]; empty {
+			return "", fmt.Errorf("the repository is empty")
+		}
+		return "", fmt.Errorf("branch %s not found", strings.TrimPrefix(name, "refs/heads/"))
+	}
+	return hash, nil
+}
+
+// uploadRequest returns the request of the pack of the commit, without its parents
+func uploadRequest(hash string, capabilities map[string]bool) ([]byte, error) {
+	if !capabilities["shallow"] {
+		return nil, fmt.Errorf("the server does not support shallow fetches")
+	}
+	requested := []string{"shallow", "no-progress", "agent=kyverno"}
+	if capabilities["ofs-delta"] {
+		requested = append(requested, "ofs-delta")
+	}
+	var request bytes.Buffer
+	request.Write(pktLine(fmt.Sprintf("want %s %s\n", hash, strings.Join(requested, " "))))
+	request.Write(pktLine("deepen 1\n"))
+	request.Write(flushPkt)
+	request.Write(pktLine("done\n"))
+	return request.Bytes(), nil
+}
+
+// readUploadResponse returns the pack of the response, after the shallow commits and the NAK
+func readUploadResponse(r io.Reader) ([]byte, error) {
+	for {
+		line, err := readPktLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if line == nil {
+			break
+		}
+		if bytes.HasPrefix(line, []byte("ERR ")) {
+			return nil, fmt.Errorf("%s", line[4:])
+		}
+		if !bytes.HasPrefix(line, []byte("shallow ")) && !bytes.HasPrefix(line, []byte("unshallow ")) {
+			return nil, fmt.Errorf("unexpected response %q", line)
+		}
+	}
+	line, err := readPktLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(line, []byte("NAK")) {
+		return nil, fmt.Errorf("unexpected response %q", line)
+	}
+	pack, err := ioutil.ReadAll(io.LimitReader(r, maxPackSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(pack) > maxPackSize {
+		return nil, fmt.Errorf("the pack exceeds %d bytes", maxPackSize)
+	}
+	return pack, nil
+}
+
+// httpSession fetches the pack with the smart HTTP protocol
+type httpSession struct {
+	client *http.Client
+	url    string
+	auth   Auth
+	body   io.ReadCloser
+}
+
+func (s *httpSession) advertisement() (io.Reader, error) {
+	req, err := http.NewRequest(http.MethodGet, s.url+"/info/refs?service=git-upload-pack", nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := s.do(req, "application/x-git-upload-pack-advertisement")
+	if err != nil {
+		return nil, err
+	}
+	// the references follow the service announcement
+	line, err := readPktLine(body)
+	if err != nil {
+		return nil, err
+	}
+	if string(line) != "# service=git-upload-pack" {
+		return nil, fmt.Errorf("invalid service announcement %q of %s", line, s.url)
+	}
+	if line, err = readPktLine(body); err != nil || line != nil {
+		return nil, fmt.Errorf("invalid service announcement of %s", s.url)
+	}
+	return body, nil
+}
+
+func (s *httpSession) uploadPack(request []byte) (io.Reader, error) {
+	req, err := http.NewRequest(http.MethodPost, s.url+"/git-upload-pack", bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-git-upload-pack-request")
+	return s.do(req, "application/x-git-upload-pack-result")
+}
+
+func (s *httpSession) do(req *http.Request, contentType string) (io.Reader, error) {
+	if s.auth.Username != "" || s.auth.Password != "" {
+		req.SetBasicAuth(s.auth.Username, s.auth.Password)
+	}
+	req.Header.Set("Accept", contentType)
+	req.Header.Set("User-Agent", "git/kyverno")
+	if s.body != nil {
+		s.body.Close()
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	s.body = resp.Body
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to %s %s: %s", req.Method, req.URL.Path, resp.Status)
+	}
+	// the dumb HTTP servers return the references as a file
+	if strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0]) != contentType {
+		return nil, fmt.Errorf("%s does not support the smart HTTP protocol, its content type is %q", s.url, resp.Header.Get("Content-Type"))
+	}
+	return resp.Body, nil
+}
+
+func (s *httpSession) Close() error {
+	if s.body != nil {
+		return s.body.Close()
+	}
+	return nil
+}
+
+// parseSSHURL returns the user, the host with its port and the path of an SSH repository
+func parseSSHURL(repository string) (string, string, string, error) {
+	user, host, path := "git", "", ""
+	if strings.HasPrefix(repository, "ssh://") {
+		u, err := url.Parse(repository)
+		if err != nil {
+			return "", "", "", err
+		}
+		if u.User != nil {
+			user = u.User.Username()
+		}
+		host, path = u.Host, u.Path
+	} else {
+		// scp-like syntax, the path is relative to the home directory of the user
+		i := strings.Index(repository, ":")
+		if i == -1 || strings.Contains(repository[:i], "/") {
+			return "", "", "", fmt.Errorf("invalid repository %s, the URL must start with https:// or ssh://, or be [user@]host:path", repository)
+		}
+		host, path = repository[:i], repository[i+1:]
+		if j := strings.Index(host, "@"); j != -1 {
+			user, host = host[:j], host[j+1:]
+		}
+	}
+	if host == "" || path == "" || strings.Contains(path, "'") {
+		return "", "", "", fmt.Errorf("invalid repository %s", repository)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "22")
+	}
+	return user, host, path, nil
+}
+
+// sshSession runs git-upload-pack on the SSH server
+type sshSession struct {
+	client  *ssh.Client
+	session *ssh.Session
+	stdin   io.WriteCloser
+	stdout  io.Reader
+}
+
+func dialSSH(user, host, path string, auth Auth) (*sshSession, error) {
+	if len(auth.PrivateKey) == 0 {
+		return nil, fmt.Errorf("the private key of the SSH repository %s:%s is not set", host, path)
+	}
+	signer, err := ssh.ParsePrivateKey(auth.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+	hostKeyCallback, err := knownHostsCallback(auth.KnownHosts)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ssh.Dial("tcp", host, &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sshTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s := &sshSession{client: client}
+	if s.session, err = client.NewSession(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if s.stdin, err = s.session.StdinPipe(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if s.stdout, err = s.session.StdoutPipe(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if err := s.session.Start(fmt.Sprintf("git-upload-pack '%s'", path)); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *sshSession) advertisement() (io.Reader, error) {
+	return s.stdout, nil
+}
+
+func (s *sshSession) uploadPack(request []byte) (io.Reader, error) {
+	if _, err := s.stdin.Write(request); err != nil {
+		return nil, err
+	}
+	if err := s.stdin.Close(); err != nil {
+		return nil, err
+	}
+	return s.stdout, nil
+}
+
+func (s *sshSession) Close() error {
+	if s.session != nil {
+		s.session.Close()
+	}
+	return s.client.Close()
+}
+
+// knownHostsCallback verifies the key of the SSH servers with the known hosts,
+// the hosts are matched by name, or by their hash, the other patterns are not supported
+func knownHostsCallback(knownHosts []byte) (ssh.HostKeyCallback, error) {
+	type entry struct {
+		marker string
+		hosts  []string
+		key    ssh.PublicKey
+	}
+	var entries []entry
+	for rest := knownHosts; len(bytes.TrimSpace(rest)) != 0; {
+		marker, hosts, key, _, next, err := ssh.ParseKnownHosts(rest)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid known hosts: %v", err)
+		}
+		entries = append(entries, entry{marker: marker, hosts: hosts, key: key})
+		rest = next
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("the known hosts of the SSH repository are not set")
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		host, port, err := net.SplitHostPort(hostname)
+		if err != nil {
+			return err
+		}
+		if port != "22" {
+			host = "[" + host + "]:" + port
+		}
+		var known bool
+		for _, e := range entries {
+			if !matchHost(e.hosts, host) {
+				continue
+			}
+			if bytes.Equal(e.key.Marshal(), key.Marshal()) {
+				if e.marker == "@revoked" {
+					return fmt.Errorf("the key of %s is revoked", hostname)
+				}
+				if e.marker == "" {
+					known = true
+				}
+			}
+		}
+		if !known {
+			return fmt.Errorf("the key of %s is not in the known hosts", hostname)
+		}
+		return nil
+	}, nil
+}
+
+// matchHost checks if the host is one of the hosts of a known_hosts line, the hashed hosts are |1|<salt>|<hash>
+func matchHost(hosts []string, host string) bool {
+	for _, h := range hosts {
+		if h == host {
+			return true
+		}
+		parts := strings.Split(h, "|")
+		if len(parts) != 4 || parts[1] != "1" {
+			continue
+		}
+		salt, err := base64.StdEncoding.DecodeString(parts[2])
+		if err != nil {
+			continue
+		}
+		mac := hmac.New(sha1.New, salt)
+		mac.Write([]byte(host))
+		if base64.StdEncoding.EncodeToString(mac.Sum(nil)) == parts[3] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"gotest.tools/assert"
+)
+
+// newRepository creates a repository with two commits on the branch main and one on the branch dev,
+// the files are similar so the pack has deltas
+func newRepository(t *testing.T, root string) (string, string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := filepath.Join(root, "policies.git")
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		assert.NilError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		assert.NilError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	assert.NilError(t, os.MkdirAll(dir, 0755))
+	run("init", "-q", "-b", "main")
+	content := strings.Repeat("the same line of a large file\n", 200)
+	write("README.md", "policies\n")
+	write("policies/a.yaml", content+"a\n")
+	write("policies/b.yaml", content)
+	run("add", ".")
+	run("commit", "-q", "-m", "first")
+	write("policies/pod/c.yaml", content+"c\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "second")
+	main := run("rev-parse", "HEAD")
+	run("checkout", "-q", "-b", "dev")
+	write("policies/a.yaml", "dev\n")
+	run("commit", "-q", "-am", "dev")
+	dev := run("rev-parse", "HEAD")
+	run("checkout", "-q", "main")
+	return main, dev
+}
+
+func Test_FetchHTTP(t *testing.T) {
+	root, err := ioutil.TempDir("", "git")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+	main, dev := newRepository(t, root)
+	execPath, err := exec.Command("git", "--exec-path").Output()
+	assert.NilError(t, err)
+	server := httptest.NewTLSServer(&cgi.Handler{
+		Path: filepath.Join(strings.TrimSpace(string(execPath)), "git-http-backend"),
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	})
+	defer server.Close()
+	client := NewClient(server.Client())
+
+	commit, err := client.Fetch(server.URL+"/policies.git", "", Auth{})
+	assert.NilError(t, err)
+	assert.Equal(t, commit.Hash, main)
+	files, err := commit.Files("policies")
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 3)
+	assert.Equal(t, string(files["pod/c.yaml"]), strings.Repeat("the same line of a large file\n", 200)+"c\n")
+
+	commit, err = client.Fetch(server.URL+"/policies.git", "dev", Auth{})
+	assert.NilError(t, err)
+	assert.Equal(t, commit.Hash, dev)
+	files, err = commit.Files("/policies/")
+	assert.NilError(t, err)
+	assert.Equal(t, string(files["a.yaml"]), "dev\n")
+	files, err = commit.Files("")
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 4)
+	_, err = commit.Files("missing")
+	assert.ErrorContains(t, err, "directory missing not found")
+
+	_, err = client.Fetch(server.URL+"/policies.git", "missing", Auth{})
+	assert.ErrorContains(t, err, "branch missing not found")
+	_, err = client.Fetch(server.URL+"/missing.git", "", Auth{})
+	assert.ErrorContains(t, err, "404 Not Found")
+}
+
+func Test_FetchSSH(t *testing.T) {
+	root, err := ioutil.TempDir("", "git")
+	assert.NilError(t, err)
+	defer os.RemoveAll(root)
+	main, _ := newRepository(t, root)
+
+	userKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	userSigner, err := ssh.NewSignerFromKey(userKey)
+	assert.NilError(t, err)
+	hostSigner := newSigner(t)
+	addr := serveSSH(t, root, hostSigner, userSigner.PublicKey())
+
+	der, err := x509.MarshalECPrivateKey(userKey)
+	assert.NilError(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	host, port, _ := net.SplitHostPort(addr)
+	knownHosts := fmt.Sprintf("[%s]:%s %s", host, port, ssh.MarshalAuthorizedKey(hostSigner.PublicKey()))
+	client := NewClient(nil)
+
+	commit, err := client.Fetch(fmt.Sprintf("ssh://git@%s/policies.git", addr), "main", Auth{PrivateKey: privateKey, KnownHosts: []byte(knownHosts)})
+	assert.NilError(t, err)
+	assert.Equal(t, commit.Hash, main)
+	files, err := commit.Files("policies")
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 3)
+
+	// the key of the server must be known
+	otherHosts := fmt.Sprintf("[%s]:%s %s", host, port, ssh.MarshalAuthorizedKey(newSigner(t).PublicKey()))
+	_, err = client.Fetch(fmt.Sprintf("ssh://git@%s/policies.git", addr), "main", Auth{PrivateKey: privateKey, KnownHosts: []byte(otherHosts)})
+	assert.ErrorContains(t, err, "is not in the known hosts")
+	_, err = client.Fetch(fmt.Sprintf("ssh://git@%s/policies.git", addr), "main", Auth{PrivateKey: privateKey})
+	assert.ErrorContains(t, err, "the known hosts of the SSH repository are not set")
+}
+
+func Test_ParseSSHURL(t *testing.T) {
+	testCases := []struct {
+		url, user, host, path string
+	}{
+		{url: "git@github.com:org/policies.git", user: "git", host: "github.com:22", path: "org/policies.git"},
+		{url: "github.com:org/policies.git", user: "git", host: "github.com:22", path: "org/policies.git"},
+		{url: "ssh://deploy@git.example.com:2222/org/policies", user: "deploy", host: "git.example.com:2222", path: "/org/policies"},
+	}
+	for _, tc := range testCases {
+		user, host, path, err := parseSSHURL(tc.url)
+		assert.NilError(t, err)
+		assert.Equal(t, user, tc.user)
+		assert.Equal(t, host, tc.host)
+		assert.Equal(t, path, tc.path)
+	}
+	_, _, _, err := parseSSHURL("org/policies")
+	assert.ErrorContains(t, err, "invalid repository")
+}
+
+func newSigner(t *testing.T) ssh.Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	assert.NilError(t, err)
+	return signer
+}
+
+// serveSSH serves git-upload-pack for the repositories of the root directory to the user key, and returns the address of the server
+func serveSSH(t *testing.T, root string, hostSigner ssh.Signer, userKey ssh.PublicKey) string {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(userKey.Marshal()) {
+				return nil, fmt.Errorf("unknown key")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostSigner)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go handleSSH(root, conn, config)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func handleSSH(root string, conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer channel.Close()
+			for req := range requests {
+				if req.Type != "exec" || len(req.Payload) < 4 {
+					req.Reply(false, nil)
+					continue
+				}
+				command := string(req.Payload[4:])
+				path := strings.TrimSuffix(strings.TrimPrefix(command, "git-upload-pack '"), "'")
+				req.Reply(true, nil)
+				cmd := exec.Command("git-upload-pack", filepath.Join(root, path))
+				cmd.Stdout = channel
+				// the command does not wait for the end of the input, as sshd
+				stdin, _ := cmd.StdinPipe()
+				go io.Copy(stdin, channel)
+				status := make([]byte, 4)
+				if err := cmd.Run(); err != nil {
+					binary.BigEndian.PutUint32(status, 1)
+				}
+				channel.SendRequest("exit-status", false, status)
+				return
+			}
+		}()
+	}
+}
+
+// newPack returns a pack with a blob of the declared size
+func newPack(declaredSize int, data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("PACK")
+	binary.Write(&buf, binary.BigEndian, uint32(2))
+	binary.Write(&buf, binary.BigEndian, uint32(1))
+	// the type and the 4 low bits of the size, then 7 bits per byte
+	b := byte(objectBlob<<4) | byte(declaredSize&0x0f)
+	for size := declaredSize >> 4; size > 0; size >>= 7 {
+		buf.WriteByte(b | 0x80)
+		b = byte(size & 0x7f)
+	}
+	buf.WriteByte(b)
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	checksum := sha1.Sum(buf.Bytes())
+	buf.Write(checksum[:])
+	return buf.Bytes()
+}
+
+func Test_ReadPackObjectSize(t *testing.T) {
+	data := []byte("policies\n")
+	objects, err := readPack(newPack(len(data), data))
+	assert.NilError(t, err)
+	assert.Equal(t, len(objects), 1)
+
+	// the data larger than the declared size is not read
+	_, err = readPack(newPack(4, bytes.Repeat(data, 1000)))
+	assert.ErrorContains(t, err, "invalid object size 5, expected 4")
+
+	_, err = readPack(newPack(maxObjectSize+1, data))
+	assert.ErrorContains(t, err, fmt.Sprintf("the object exceeds %d bytes", maxObjectSize))
+}
@@ -0,0 +1,258 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// types of the objects of a pack, the deltas are resolved to the type of their base
+const (
+	objectCommit   = 1
+	objectTree     = 2
+	objectBlob     = 3
+	objectTag      = 4
+	objectOfsDelta = 6
+	objectRefDelta = 7
+)
+
+// maxObjectSize is the maximum size of an object, maxUnpackedSize the maximum size of the objects of a pack,
+// the sizes are checked before the objects are decompressed
+const (
+	maxObjectSize   = maxPackSize
+	maxUnpackedSize = 4 * maxPackSize
+)
+
+var objectTypeNames = map[int]string{
+	objectCommit: "commit",
+	objectTree:   "tree",
+	objectBlob:   "blob",
+	objectTag:    "tag",
+}
+
+type object struct {
+	kind int
+	data []byte
+}
+
+// packEntry is an object of a pack before the resolution of the deltas
+type packEntry struct {
+	kind int
+	data []byte
+	// baseOffset is the offset of the base of an ofs-delta, baseHash the hash of the base of a ref-delta
+	baseOffset int
+	baseHash   string
+}
+
+// readPack returns the objects of the pack per hash, the deltas are resolved
+func readPack(pack []byte) (map[string]object, error) {
+	if len(pack) < 32 || !bytes.Equal(pack[:4], []byte("PACK")) {
+		return nil, fmt.Errorf("invalid pack header")
+	}
+	if version := binary.BigEndian.Uint32(pack[4:8]); version != 2 && version != 3 {
+		return nil, fmt.Errorf("unsupported pack version %d", version)
+	}
+	checksum := sha1.Sum(pack[:len(pack)-20])
+	if !bytes.Equal(checksum[:], pack[len(pack)-20:]) {
+		return nil, fmt.Errorf("invalid pack checksum")
+	}
+	count := int(binary.BigEndian.Uint32(pack[8:12]))
+	entries := map[int]*packEntry{}
+	var offsets []int
+	r := bytes.NewReader(pack[:len(pack)-20])
+	r.Seek(12, io.SeekStart)
+	unpacked := 0
+	for i := 0; i < count; i++ {
+		offset := int(r.Size()) - r.Len()
+		entry, err := readPackEntry(r, offset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object at offset %d of the pack: %v", offset, err)
+		}
+		if unpacked += len(entry.data); unpacked > maxUnpackedSize {
+			return nil, fmt.Errorf("the objects of the pack exceed %d bytes", maxUnpackedSize)
+		}
+		entries[offset] = entry
+		offsets = append(offsets, offset)
+	}
+
+	objects := map[string]object{}
+	hashes := map[int]string{}
+	// the bases of the deltas are resolved first, the ref-deltas can reference an object after them in the pack
+	for resolved := true; resolved; {
+		resolved = false
+		for _, offset := range offsets {
+			entry := entries[offset]
+			if _, ok := hashes[offset]; ok {
+				continue
+			}
+			var base object
+			switch entry.kind {
+			case objectOfsDelta:
+				hash, ok := hashes[entry.baseOffset]
+				if !ok {
+					continue
+				}
+				base = objects[hash]
+			case objectRefDelta:
+				var ok bool
+				if base, ok = objects[entry.baseHash]; !ok {
+					continue
+				}
+			default:
+				base = object{kind: entry.kind}
+			}
+			obj := object{kind: base.kind, data: entry.data}
+			if entry.kind == objectOfsDelta || entry.kind == objectRefDelta {
+				data, err := applyDelta(base.data, entry.data)
+				if err != nil {
+					return nil, fmt.Errorf("invalid delta at offset %d of the pack: %v", offset, err)
+				}
+				if unpacked += len(data); unpacked > maxUnpackedSize {
+					return nil, fmt.Errorf("the objects of the pack exceed %d bytes", maxUnpackedSize)
+				}
+				obj.data = data
+			}
+			hash := hashObject(obj)
+			objects[hash] = obj
+			hashes[offset] = hash
+			resolved = true
+		}
+	}
+	if len(hashes) != len(offsets) {
+		return nil, fmt.Errorf("the base of %d deltas of the pack are missing", len(offsets)-len(hashes))
+	}
+	return objects, nil
+}
+
+func readPackEntry(r *bytes.Reader, offset int) (*packEntry, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	entry := &packEntry{kind: int(b>>4) & 7}
+	size := int(b & 0x0f)
+	for shift := uint(4); b&0x80 != 0; shift += 7 {
+		if b, err = r.ReadByte(); err != nil {
+			return nil, err
+		}
+		size |= int(b&0x7f) << shift
+		if size < 0 || size > maxObjectSize {
+			return nil, fmt.Errorf("the object exceeds %d bytes", maxObjectSize)
+		}
+	}
+	switch entry.kind {
+	case objectCommit, objectTree, objectBlob, objectTag:
+	case objectOfsDelta:
+		// the offset is relative to the offset of the delta, with a +1 on each continuation byte
+		if b, err = r.ReadByte(); err != nil {
+			return nil, err
+		}
+		relative := int(b & 0x7f)
+		for b&0x80 != 0 {
+			if b, err = r.ReadByte(); err != nil {
+				return nil, err
+			}
+			relative = ((relative + 1) << 7) | int(b&0x7f)
+		}
+		if relative <= 0 || relative > offset {
+			return nil, fmt.Errorf("invalid delta base offset %d", relative)
+		}
+		entry.baseOffset = offset - relative
+	case objectRefDelta:
+		hash := make([]byte, 20)
+		if _, err := io.ReadFull(r, hash); err != nil {
+			return nil, err
+		}
+		entry.baseHash = hex.EncodeToString(hash)
+	default:
+		return nil, fmt.Errorf("invalid object type %d", entry.kind)
+	}
+	// the bytes reader is a byte reader, so the decompressor does not read after the end of the object
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	// the data is limited by the size of the object, the objects larger than their size are rejected
+	if entry.data, err = ioutil.ReadAll(io.LimitReader(zr, int64(size)+1)); err != nil {
+		return nil, err
+	}
+	if len(entry.data) != size {
+		return nil, fmt.Errorf("invalid object size %d, expected %d", len(entry.data), size)
+	}
+	return entry, nil
+}
+
+// hashObject returns the SHA-1 of the object, its identifier in the repository
+func hashObject(obj object) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %d\x00", objectTypeNames[obj.kind], len(obj.data))
+	h.Write(obj.data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// applyDelta returns the object of the delta instructions applied to the base
+func applyDelta(base, delta []byte) ([]byte, error) {
+	r := bytes.NewReader(delta)
+	baseSize, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if int(baseSize) != len(base) {
+		return nil, fmt.Errorf("invalid base size %d, expected %d", len(base), baseSize)
+	}
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxObjectSize {
+		return nil, fmt.Errorf("the delta result exceeds %d bytes", maxObjectSize)
+	}
+	result := make([]byte, 0, size)
+	for r.Len() > 0 {
+		op, _ := r.ReadByte()
+		if op&0x80 == 0 {
+			// insert the next op bytes of the delta
+			if op == 0 {
+				return nil, fmt.Errorf("invalid delta instruction 0")
+			}
+			data := make([]byte, op)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, err
+			}
+			result = append(result, data...)
+			continue
+		}
+		// copy the bytes of the base, the bits of the instruction select the bytes of the offset and the size
+		var copyOffset, copySize int
+		for i := uint(0); i < 7; i++ {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			if i < 4 {
+				copyOffset |= int(b) << (8 * i)
+			} else {
+				copySize |= int(b) << (8 * (i - 4))
+			}
+		}
+		if copySize == 0 {
+			copySize = 0x10000
+		}
+		if copyOffset+copySize > len(base) {
+			return nil, fmt.Errorf("invalid delta copy of %d bytes at %d, the base has %d bytes", copySize, copyOffset, len(base))
+		}
+		result = append(result, base[copyOffset:copyOffset+copySize]...)
+	}
+	if len(result) != int(size) {
+		return nil, fmt.Errorf("invalid delta result size %d, expected %d", len(result), size)
+	}
+	return result, nil
+}
@@ -0,0 +1,42 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxPktLen is the maximum length of a pkt-line, with its 4 bytes length
+const maxPktLen = 65520
+
+// flushPkt ends a section of the messages of the protocol
+var flushPkt = []byte("0000")
+
+// pktLine encodes the data as a pkt-line, prefixed with its length in hexadecimal
+func pktLine(data string) []byte {
+	return []byte(fmt.Sprintf("%04x%s", len(data)+4, data))
+}
+
+// readPktLine returns the data of the next pkt-line, nil for a flush-pkt
+func readPktLine(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	length, err := strconv.ParseUint(string(header[:]), 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pkt-line length %q", header)
+	}
+	if length == 0 {
+		return nil, nil
+	}
+	if length < 4 || length > maxPktLen {
+		return nil, fmt.Errorf("invalid pkt-line length %d", length)
+	}
+	data := make([]byte, length-4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(data, []byte("\n")), nil
+}
@@ -0,0 +1,112 @@
+package git
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// modes of the entries of the trees, the symbolic links and the submodules are skipped
+const (
+	modeTree = "40000"
+)
+
+// Commit is a commit fetched from a repository, with the objects of its tree
+type Commit struct {
+	// Hash is the hash of the commit
+	Hash    string
+	tree    string
+	objects map[string]object
+}
+
+func newCommit(hash string, objects map[string]object) (*Commit, error) {
+	obj, ok := objects[hash]
+	if !ok || obj.kind != objectCommit {
+		return nil, fmt.Errorf("commit %s not found in the pack", hash)
+	}
+	header := obj.data
+	if i := bytes.Index(header, []byte("\n\n")); i != -1 {
+		header = header[:i]
+	}
+	for _, line := range strings.Split(string(header), "\n") {
+		if strings.HasPrefix(line, "tree ") {
+			return &Commit{Hash: hash, tree: strings.TrimPrefix(line, "tree "), objects: objects}, nil
+		}
+	}
+	return nil, fmt.Errorf("commit %s has no tree", hash)
+}
+
+// Files returns the content of the files of the directory and its subdirectories per path relative to the directory,
+// all the files of the repository if the directory is empty
+func (c *Commit) Files(dir string) (map[string][]byte, error) {
+	tree := c.tree
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+	if dir != "" {
+		for _, name := range strings.Split(dir, "/") {
+			entries, err := c.readTree(tree)
+			if err != nil {
+				return nil, err
+			}
+			entry, ok := entries[name]
+			if !ok || entry.mode != modeTree {
+				return nil, fmt.Errorf("directory %s not found in commit %s", dir, c.Hash)
+			}
+			tree = entry.hash
+		}
+	}
+	files := map[string][]byte{}
+	if err := c.walk(tree, "", files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+type treeEntry struct {
+	mode string
+	hash string
+}
+
+func (c *Commit) walk(tree, prefix string, files map[string][]byte) error {
+	entries, err := c.readTree(tree)
+	if err != nil {
+		return err
+	}
+	for name, entry := range entries {
+		switch {
+		case entry.mode == modeTree:
+			if err := c.walk(entry.hash, prefix+name+"/", files); err != nil {
+				return err
+			}
+		case strings.HasPrefix(entry.mode, "100"):
+			obj, ok := c.objects[entry.hash]
+			if !ok || obj.kind != objectBlob {
+				return fmt.Errorf("blob %s of %s not found in the pack", entry.hash, prefix+name)
+			}
+			files[prefix+name] = obj.data
+		}
+	}
+	return nil
+}
+
+// readTree returns the entries of the tree per name
+func (c *Commit) readTree(hash string) (map[string]treeEntry, error) {
+	obj, ok := c.objects[hash]
+	if !ok || obj.kind != objectTree {
+		return nil, fmt.Errorf("tree %s not found in the pack", hash)
+	}
+	entries := map[string]treeEntry{}
+	// each entry is <mode> <name>\0<20 bytes hash>
+	data := obj.data
+	for len(data) > 0 {
+		space := bytes.IndexByte(data, ' ')
+		null := bytes.IndexByte(data, 0)
+		if space == -1 || null < space || len(data) < null+21 {
+			return nil, fmt.Errorf("invalid tree %s", hash)
+		}
+		entries[string(data[space+1:null])] = treeEntry{mode: string(data[:space]), hash: hex.EncodeToString(data[null+1 : null+21])}
+		data = data[null+21:]
+	}
+	return entries, nil
+}
@@ -0,0 +1,108 @@
+package informers
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// dynamicSharedInformerFactory is the dynamic informer factory of client-go
+// with informers stripping the metadata of the cached objects
+type dynamicSharedInformerFactory struct {
+	client        dynamic.Interface
+	defaultResync time.Duration
+
+	lock      sync.Mutex
+	informers map[schema.GroupVersionResource]kubeinformers.GenericInformer
+	// startedInformers is used for tracking which informers have been started
+	startedInformers map[schema.GroupVersionResource]bool
+}
+
+// NewDynamicSharedInformerFactory returns a dynamic informer factory whose informers strip the metadata of the cached objects
+func NewDynamicSharedInformerFactory(client dynamic.Interface, defaultResync time.Duration) dynamicinformer.DynamicSharedInformerFactory {
+	return &dynamicSharedInformerFactory{
+		client:           client,
+		defaultResync:    defaultResync,
+		informers:        map[schema.GroupVersionResource]kubeinformers.GenericInformer{},
+		startedInformers: map[schema.GroupVersionResource]bool{},
+	}
+}
+
+func (f *dynamicSharedInformerFactory) ForResource(gvr schema.GroupVersionResource) kubeinformers.GenericInformer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if informer, exists := f.informers[gvr]; exists {
+		return informer
+	}
+	informer := &genericInformer{
+		gvr: gvr,
+		informer: newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return f.client.Resource(gvr).Namespace(metav1.NamespaceAll).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return f.client.Resource(gvr).Namespace(metav1.NamespaceAll).Watch(options)
+			},
+		}, &unstructured.Unstructured{}, f.defaultResync),
+	}
+	f.informers[gvr] = informer
+	return informer
+}
+
+// Start initializes all requested informers.
+func (f *dynamicSharedInformerFactory) Start(stopCh <-chan struct{}) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for gvr, informer := range f.informers {
+		if !f.startedInformers[gvr] {
+			go informer.Informer().Run(stopCh)
+			f.startedInformers[gvr] = true
+		}
+	}
+}
+
+// WaitForCacheSync waits for all started informers' cache were synced.
+func (f *dynamicSharedInformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[schema.GroupVersionResource]bool {
+	informers := func() map[schema.GroupVersionResource]cache.SharedIndexInformer {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		informers := map[schema.GroupVersionResource]cache.SharedIndexInformer{}
+		for gvr, informer := range f.informers {
+			if f.startedInformers[gvr] {
+				informers[gvr] = informer.Informer()
+			}
+		}
+		return informers
+	}()
+
+	res := map[schema.GroupVersionResource]bool{}
+	for gvr, informer := range informers {
+		res[gvr] = cache.WaitForCacheSync(stopCh, informer.HasSynced)
+	}
+	return res
+}
+
+type genericInformer struct {
+	informer cache.SharedIndexInformer
+	gvr      schema.GroupVersionResource
+}
+
+func (i *genericInformer) Informer() cache.SharedIndexInformer {
+	return i.informer
+}
+
+func (i *genericInformer) Lister() cache.GenericLister {
+	return cache.NewGenericLister(i.informer.GetIndexer(), i.gvr.GroupResource())
+}
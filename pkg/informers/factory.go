@@ -0,0 +1,148 @@
+package informers
+
+import (
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/client/clientset/versioned"
+	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions"
+	admregapi "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// TransformKubeInformers registers the informers of the Kubernetes kinds cached by kyverno in the factory,
+// they strip the metadata of the cached objects. It must be called before the informers are used.
+func TransformKubeInformers(factory kubeinformers.SharedInformerFactory) {
+	factory.InformerFor(&corev1.Namespace{}, func(client kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().Namespaces().List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.CoreV1().Namespaces().Watch(options)
+			},
+		}, &corev1.Namespace{}, resyncPeriod)
+	})
+	factory.InformerFor(&corev1.ConfigMap{}, func(client kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().ConfigMaps(metav1.NamespaceAll).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.CoreV1().ConfigMaps(metav1.NamespaceAll).Watch(options)
+			},
+		}, &corev1.ConfigMap{}, resyncPeriod)
+	})
+	factory.InformerFor(&rbacv1.RoleBinding{}, func(client kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.RbacV1().RoleBindings(metav1.NamespaceAll).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.RbacV1().RoleBindings(metav1.NamespaceAll).Watch(options)
+			},
+		}, &rbacv1.RoleBinding{}, resyncPeriod)
+	})
+	factory.InformerFor(&rbacv1.ClusterRoleBinding{}, func(client kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.RbacV1().ClusterRoleBindings().List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.RbacV1().ClusterRoleBindings().Watch(options)
+			},
+		}, &rbacv1.ClusterRoleBinding{}, resyncPeriod)
+	})
+	factory.InformerFor(&admregapi.MutatingWebhookConfiguration{}, func(client kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Watch(options)
+			},
+		}, &admregapi.MutatingWebhookConfiguration{}, resyncPeriod)
+	})
+}
+
+// TransformKyvernoInformers registers the informers of the kyverno kinds in the factory,
+// they strip the metadata of the cached objects. It must be called before the informers are used.
+func TransformKyvernoInformers(factory kyvernoinformer.SharedInformerFactory) {
+	factory.InformerFor(&kyverno.ClusterPolicy{}, func(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.KyvernoV1().ClusterPolicies().List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.KyvernoV1().ClusterPolicies().Watch(options)
+			},
+		}, &kyverno.ClusterPolicy{}, resyncPeriod)
+	})
+	factory.InformerFor(&kyverno.Policy{}, func(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.KyvernoV1().Policies(metav1.NamespaceAll).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.KyvernoV1().Policies(metav1.NamespaceAll).Watch(options)
+			},
+		}, &kyverno.Policy{}, resyncPeriod)
+	})
+	factory.InformerFor(&kyverno.ClusterPolicyViolation{}, func(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.KyvernoV1().ClusterPolicyViolations().List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.KyvernoV1().ClusterPolicyViolations().Watch(options)
+			},
+		}, &kyverno.ClusterPolicyViolation{}, resyncPeriod)
+	})
+	factory.InformerFor(&kyverno.PolicyViolation{}, func(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.KyvernoV1().PolicyViolations(metav1.NamespaceAll).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.KyvernoV1().PolicyViolations(metav1.NamespaceAll).Watch(options)
+			},
+		}, &kyverno.PolicyViolation{}, resyncPeriod)
+	})
+	factory.InformerFor(&kyverno.GenerateRequest{}, func(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.KyvernoV1().GenerateRequests(metav1.NamespaceAll).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.KyvernoV1().GenerateRequests(metav1.NamespaceAll).Watch(options)
+			},
+		}, &kyverno.GenerateRequest{}, resyncPeriod)
+	})
+	factory.InformerFor(&kyverno.KyvernoConfig{}, func(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.KyvernoV1().KyvernoConfigs().List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.KyvernoV1().KyvernoConfigs().Watch(options)
+			},
+		}, &kyverno.KyvernoConfig{}, resyncPeriod)
+	})
+	factory.InformerFor(&kyverno.ReportRequest{}, func(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		return newInformer(&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.KyvernoV1().ReportRequests(metav1.NamespaceAll).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.KyvernoV1().ReportRequests(metav1.NamespaceAll).Watch(options)
+			},
+		}, &kyverno.ReportRequest{}, resyncPeriod)
+	})
+}
@@ -0,0 +1,76 @@
+package informers
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// LastAppliedConfigAnnotation is the annotation of kubectl apply with the last applied configuration of the object
+const LastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// StripMetadata removes the metadata the controllers do not use from the objects cached by the informers,
+// the managed fields and the last applied configuration, a copy of the whole object
+func StripMetadata(obj interface{}) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	accessor.SetManagedFields(nil)
+	annotations := accessor.GetAnnotations()
+	if _, ok := annotations[LastAppliedConfigAnnotation]; !ok {
+		return
+	}
+	stripped := make(map[string]string, len(annotations)-1)
+	for k, v := range annotations {
+		if k != LastAppliedConfigAnnotation {
+			stripped[k] = v
+		}
+	}
+	if len(stripped) == 0 {
+		stripped = nil
+	}
+	accessor.SetAnnotations(stripped)
+}
+
+// transformListWatch strips the metadata of the objects listed and watched, before they are cached
+func transformListWatch(lw *cache.ListWatch) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			list, err := lw.ListFunc(options)
+			if err != nil {
+				return nil, err
+			}
+			err = meta.EachListItem(list, func(obj runtime.Object) error {
+				StripMetadata(obj)
+				return nil
+			})
+			return list, err
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.WatchFunc(options)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+				StripMetadata(event.Object)
+				return event, true
+			}), nil
+		},
+	}
+}
+
+// newInformer returns an informer caching the objects without their stripped metadata,
+// with the namespace index of the generated informers
+func newInformer(lw *cache.ListWatch, obj runtime.Object, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		transformListWatch(lw),
+		obj,
+		resyncPeriod,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+}
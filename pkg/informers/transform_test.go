@@ -0,0 +1,53 @@
+package informers
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func Test_StripMetadata(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl"}})
+	obj.SetAnnotations(map[string]string{LastAppliedConfigAnnotation: "{}", "owner": "team-a"})
+	StripMetadata(obj)
+	assert.Assert(t, obj.GetManagedFields() == nil)
+	assert.DeepEqual(t, obj.GetAnnotations(), map[string]string{"owner": "team-a"})
+
+	obj.SetAnnotations(map[string]string{LastAppliedConfigAnnotation: "{}"})
+	StripMetadata(obj)
+	assert.Assert(t, obj.GetAnnotations() == nil)
+}
+
+func Test_TransformKubeInformers(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:          "prod",
+			Labels:        map[string]string{"env": "prod"},
+			Annotations:   map[string]string{LastAppliedConfigAnnotation: `{"metadata":{"name":"prod"}}`},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+	})
+	factory := kubeinformers.NewSharedInformerFactory(client, time.Minute)
+	TransformKubeInformers(factory)
+	nsInformer := factory.Core().V1().Namespaces()
+	informer := nsInformer.Informer()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	assert.Assert(t, cache.WaitForCacheSync(stopCh, informer.HasSynced))
+
+	ns, err := nsInformer.Lister().Get("prod")
+	assert.NilError(t, err)
+	assert.Equal(t, ns.Labels["env"], "prod")
+	assert.Assert(t, ns.Annotations == nil)
+	assert.Assert(t, ns.ManagedFields == nil)
+}
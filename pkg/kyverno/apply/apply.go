@@ -1,251 +1,367 @@
 package apply
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/golang/glog"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
-	"github.com/nirmata/kyverno/pkg/engine"
+	"github.com/nirmata/kyverno/pkg/engine/sarif"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"github.com/nirmata/kyverno/pkg/version"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
-	yaml "k8s.io/apimachinery/pkg/util/yaml"
 	memory "k8s.io/client-go/discovery/cached/memory"
 	dynamic "k8s.io/client-go/dynamic"
 	kubernetes "k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/restmapper"
 )
 
 const (
-	applyExample = `  # Apply a policy to the resource.
-  kyverno apply @policy.yaml @resource.yaml
+	applyExample = `  # Apply the policies to the resources, without a cluster.
+  kyverno apply policy.yaml --resource resource.yaml
+  kyverno apply policy.yaml policyDir/ --resource resourceDir/ --resource other.yaml
+  helm template chart/ | kyverno apply policyDir/ --resource -
+
+  # Set the values of the variables of the rules, from a file and from flags.
+  kyverno apply policy.yaml --resource resource.yaml --values values.yaml --set request.operation=UPDATE
+
+  # Fill in the default values of the resources with a server-side dry run, in the cluster of a kubeconfig context.
+  kyverno apply policy.yaml --resource resource.yaml --kubeconfig=$PATH_TO_KUBECONFIG_FILE
+  kyverno apply policy.yaml --resource resource.yaml --context=staging --namespace=prod
+
+  # Review the changes of each mutation rule, as diffs and as JSON patches in a directory.
+  kyverno apply policy.yaml --resource resource.yaml --diff --patch-output=patches/
+
+  # Audit the resources of the cluster matched by the kinds of the policies, in a namespace and with a label selector.
+  kyverno apply policyDir/ --cluster
+  kyverno apply policyDir/ --cluster --namespace prod --selector app=web
+
+  # The legacy form, with a policy and a resource file or directory.
   kyverno apply @policy.yaml @resourceDir/
-  kyverno apply @policy.yaml @resource.yaml --kubeconfig=$PATH_TO_KUBECONFIG_FILE`
 
-	defaultYamlSeparator = "---"
+  # Report the results as JUnit, e.g. for the test reports of a CI pipeline.
+  kyverno apply policyDir/ --resource resourceDir/ --output=junit > kyverno.xml
+
+  # Report the failed rules as SARIF, e.g. for a code scanning dashboard.
+  kyverno apply policyDir/ --resource resourceDir/ --output=sarif > kyverno.sarif`
+
+	// stdinPath is the resource path of the standard input
+	stdinPath = "-"
+)
+
+// output formats of apply, along with the json and junit reports
+const (
+	// outputResources is the YAML of the mutated resources, the results are written to the standard error
+	outputResources = "yaml"
+	outputSARIF     = "sarif"
 )
 
 // NewCmdApply returns the apply command for kyverno
 func NewCmdApply(in io.Reader, out, errout io.Writer) *cobra.Command {
-	var kubeconfig string
+	var outputFormat, valuesFile, patchOutput, selector string
+	configFlags := &common.ConfigFlags{}
+	var resourcePaths, setValues []string
+	var diff, cluster bool
 	cmd := &cobra.Command{
-		Use:     "apply",
-		Short:   "Apply policy on the resource(s)",
+		Use:     "apply <policy file or directory>... --resource <resource file or directory>...",
+		Short:   "Apply policies on the resource(s)",
 		Example: applyExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			policy, resources := complete(kubeconfig, args)
-			output := applyPolicy(policy, resources)
-			fmt.Printf("%v\n", output)
+			if err := common.ValidateOutput(outputFormat, outputResources, common.OutputJSON, outputSARIF, common.OutputJUnit); err != nil {
+				glog.Errorf("%v\n", err)
+				os.Exit(common.ExitError)
+			}
+			var policies []kyverno.ClusterPolicy
+			var resources []*resourceInfo
+			if cluster {
+				policies, resources = completeCluster(configFlags, args, resourcePaths, selector)
+			} else {
+				// the resources are evaluated offline, unless a flag of the cluster is set
+				var dryRunFlags *common.ConfigFlags
+				if configFlags.Changed(cmd.Flags()) {
+					dryRunFlags = configFlags
+				}
+				policies, resources = complete(in, dryRunFlags, args, resourcePaths)
+			}
+			values, err := loadValues(valuesFile, setValues)
+			if err != nil {
+				glog.Errorf("Failed to load the values: %v\n", err)
+				os.Exit(common.ExitError)
+			}
+			output, results, patches := applyPolicies(policies, resources, values)
+			if patchOutput != "" {
+				if err := writePatches(patchOutput, patches); err != nil {
+					glog.Errorf("Failed to write the patches: %v\n", err)
+					os.Exit(common.ExitError)
+				}
+			}
+			r := newReport(results)
+			switch outputFormat {
+			case outputSARIF:
+				err = sarif.Write(out, sarif.NewLog(policies, results, version.BuildVersion))
+			case common.OutputJSON:
+				err = common.WriteReport(out, outputFormat, r)
+			case common.OutputJUnit:
+				err = writeJUnit(out, r)
+			default:
+				if diff {
+					printDiffs(out, patches)
+				} else {
+					fmt.Fprint(out, output)
+				}
+				printResults(errout, r)
+			}
+			if err != nil {
+				glog.Errorf("Failed to write the %s output: %v\n", outputFormat, err)
+				os.Exit(common.ExitError)
+			}
+			if code := r.exitCode(); code != common.ExitSuccess {
+				os.Exit(code)
+			}
 		},
 	}
 
-	cmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "path to kubeconfig file")
+	cmd.Flags().StringArrayVarP(&resourcePaths, "resource", "r", nil, "resource file or directory, - for the standard input, can be repeated")
+	cmd.Flags().StringVarP(&valuesFile, "values", "f", "", "file of the values of the variables, global and per resource, and of the user info of the request")
+	cmd.Flags().StringArrayVar(&setValues, "set", nil, "value of a variable, [<resource>:]<path>=<value>, e.g. request.operation=UPDATE or Pod/default/nginx:request.namespace=default, can be repeated")
+	cmd.Flags().BoolVar(&cluster, "cluster", false, "apply the policies on the resources of the cluster of the kinds matched by their rules, instead of the resources of files")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "label selector of the resources of the cluster with --cluster, e.g. app=web")
+	// the cluster flags fill in the default values of the resources with a server-side dry run,
+	// or select the cluster and the namespace of the resources with --cluster
+	configFlags.AddFlags(cmd.Flags())
+	cmd.Flags().BoolVar(&diff, "diff", false, "print the unified diff of the changes of each mutation rule instead of the mutated resources, with the yaml output")
+	cmd.Flags().StringVar(&patchOutput, "patch-output", "", "directory to write the JSON patch and the diff of each mutation rule, in <kind>-<namespace>-<name>/<policy>-<rule>.json and .diff")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "yaml", "output format: yaml for the mutated resources and the results on the standard error, json or junit for the results, or sarif for the failed rules")
 	return cmd
 }
 
-func complete(kubeconfig string, args []string) (*kyverno.ClusterPolicy, []*resourceInfo) {
-	policyDir, resourceDir, err := validateDir(args)
+func complete(in io.Reader, configFlags *common.ConfigFlags, args, resourcePaths []string) ([]kyverno.ClusterPolicy, []*resourceInfo) {
+	policyPaths, resourcePaths, err := parsePaths(args, resourcePaths)
 	if err != nil {
 		glog.Errorf("Failed to parse file path, err: %v\n", err)
-		os.Exit(1)
+		os.Exit(common.ExitError)
 	}
 
-	// extract policy
-	policy, err := extractPolicy(policyDir)
+	// extract policies
+	policies, err := common.LoadPolicies(policyPaths)
 	if err != nil {
 		glog.Errorf("Failed to extract policy: %v\n", err)
-		os.Exit(1)
+		os.Exit(common.ExitError)
 	}
 
 	// extract rawResource
-	resources, err := extractResource(resourceDir, kubeconfig)
+	resources, err := extractResources(in, resourcePaths, configFlags)
 	if err != nil {
 		glog.Errorf("Failed to parse resource: %v", err)
-		os.Exit(1)
+		os.Exit(common.ExitError)
 	}
 
-	return policy, resources
+	return policies, resources
 }
 
-func applyPolicy(policy *kyverno.ClusterPolicy, resources []*resourceInfo) (output string) {
-	for _, resource := range resources {
-		patchedDocument, err := applyPolicyOnRaw(policy, resource.rawResource, resource.gvk)
-		if err != nil {
-			glog.Errorf("Error applying policy on resource %s, err: %v\n", resource.gvk.Kind, err)
-			continue
-		}
+// completeCluster returns the policies of the files of the arguments and the resources of the cluster matched by their kinds
+func completeCluster(configFlags *common.ConfigFlags, args, resourcePaths []string, selector string) ([]kyverno.ClusterPolicy, []*resourceInfo) {
+	if len(resourcePaths) > 0 {
+		glog.Errorf("Failed to parse file path, err: set either --resource or --cluster\n")
+		os.Exit(common.ExitError)
+	}
+	if len(args) == 0 {
+		glog.Errorf("Failed to parse file path, err: missing policy manifest\n")
+		os.Exit(common.ExitError)
+	}
+	var policyPaths []string
+	for _, arg := range args {
+		policyPaths = append(policyPaths, strings.TrimPrefix(arg, "@"))
+	}
 
-		out, err := prettyPrint(patchedDocument)
-		if err != nil {
-			glog.Errorf("JSON parse error: %v\n", err)
-			continue
-		}
+	policies, err := common.LoadPolicies(policyPaths)
+	if err != nil {
+		glog.Errorf("Failed to extract policy: %v\n", err)
+		os.Exit(common.ExitError)
+	}
 
-		output = output + fmt.Sprintf("---\n%s", string(out))
+	resources, err := loadClusterResources(configFlags, policies, selector)
+	if err != nil {
+		glog.Errorf("Failed to list the resources of the cluster: %v\n", err)
+		os.Exit(common.ExitError)
 	}
-	return
+	return policies, resources
 }
 
-func applyPolicyOnRaw(policy *kyverno.ClusterPolicy, rawResource []byte, gvk *metav1.GroupVersionKind) ([]byte, error) {
-	patchedResource := rawResource
-	var err error
-
-	rname := engine.ParseNameFromObject(rawResource)
-	rns := engine.ParseNamespaceFromObject(rawResource)
-	resource, err := ConvertToUnstructured(rawResource)
-	if err != nil {
-		return nil, err
+// parsePaths returns the paths of the policies and of the resources,
+// the arguments of the legacy form @policy @resource are the policy and the resource
+func parsePaths(args, resourcePaths []string) ([]string, []string, error) {
+	if len(resourcePaths) == 0 && len(args) == 2 && strings.HasPrefix(args[0], "@") && strings.HasPrefix(args[1], "@") {
+		return []string{args[0][1:]}, []string{args[1][1:]}, nil
 	}
-	//TODO check if the kind information is present resource
-	// Process Mutation
-	engineResponse := engine.Mutate(engine.PolicyContext{Policy: *policy, NewResource: *resource})
-	if !engineResponse.IsSuccesful() {
-		glog.Infof("Failed to apply policy %s on resource %s/%s", policy.Name, rname, rns)
-		for _, r := range engineResponse.PolicyResponse.Rules {
-			glog.Warning(r.Message)
-		}
-	} else if len(engineResponse.PolicyResponse.Rules) > 0 {
-		glog.Infof("Mutation from policy %s has applied successfully to %s %s/%s", policy.Name, gvk.Kind, rname, rns)
 
-		// Process Validation
-		engineResponse := engine.Validate(engine.PolicyContext{Policy: *policy, NewResource: *resource})
+	var policyPaths []string
+	for _, arg := range args {
+		policyPaths = append(policyPaths, strings.TrimPrefix(arg, "@"))
+	}
+	if len(policyPaths) == 0 {
+		return nil, nil, fmt.Errorf("missing policy manifest")
+	}
+	if len(resourcePaths) == 0 {
+		return nil, nil, fmt.Errorf("missing resource manifest, set --resource")
+	}
+	return policyPaths, resourcePaths, nil
+}
 
-		if !engineResponse.IsSuccesful() {
-			glog.Infof("Failed to apply policy %s on resource %s/%s", policy.Name, rname, rns)
-			for _, r := range engineResponse.PolicyResponse.Rules {
-				glog.Warning(r.Message)
-			}
-			return patchedResource, fmt.Errorf("policy %s on resource %s/%s not satisfied", policy.Name, rname, rns)
-		} else if len(engineResponse.PolicyResponse.Rules) > 0 {
-			glog.Infof("Validation from policy %s has applied successfully to %s %s/%s", policy.Name, gvk.Kind, rname, rns)
+// loadValues returns the values of the file, overridden by the values set by the flags
+func loadValues(file string, assignments []string) (common.Values, error) {
+	values := common.Values{}
+	if file != "" {
+		var err error
+		if values, err = common.LoadValues(file); err != nil {
+			return values, err
+		}
+	}
+	for _, assignment := range assignments {
+		if err := values.Set(assignment); err != nil {
+			return values, err
 		}
 	}
-	return patchedResource, nil
+	return values, nil
 }
 
-func extractPolicy(fileDir string) (*kyverno.ClusterPolicy, error) {
-	policy := &kyverno.ClusterPolicy{}
+// applyPolicies returns the mutated resources as YAML documents, the engine responses of the resources,
+// and the changes of the resources by each mutation rule
+func applyPolicies(policies []kyverno.ClusterPolicy, resources []*resourceInfo, values common.Values) (output string, results []sarif.ResourceResult, patches []rulePatch) {
+	for _, resource := range resources {
+		patchedResource, engineResponses := common.ApplyPolicies(policies, resource.resource, values)
+		for _, engineResponse := range engineResponses {
+			results = append(results, sarif.ResourceResult{File: resource.file, Line: resource.line, EngineResponse: engineResponse})
+		}
 
-	file, err := loadFile(fileDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load file: %v", err)
-	}
+		resourcePatches, err := mutationPatches(resource.resource, engineResponses)
+		if err != nil {
+			glog.Errorf("Failed to compute the changes of resource %s, err: %v\n", common.ResourceKey(resource.resource), err)
+		}
+		patches = append(patches, resourcePatches...)
 
-	policyBytes, err := yaml.ToJSON(file)
-	if err != nil {
-		return nil, err
-	}
+		patchedDocument, err := patchedResource.MarshalJSON()
+		if err != nil {
+			glog.Errorf("Failed to marshal resource %s, err: %v\n", common.ResourceKey(patchedResource), err)
+			continue
+		}
 
-	if err := json.Unmarshal(policyBytes, policy); err != nil {
-		return nil, fmt.Errorf("failed to decode policy %s, err: %v", policy.Name, err)
-	}
+		out, err := prettyPrint(patchedDocument)
+		if err != nil {
+			glog.Errorf("JSON parse error: %v\n", err)
+			continue
+		}
 
-	if policy.TypeMeta.Kind != "ClusterPolicy" {
-		return nil, fmt.Errorf("failed to parse policy")
+		output = output + fmt.Sprintf("---\n%s", string(out))
 	}
-
-	return policy, nil
+	return
 }
 
 type resourceInfo struct {
-	rawResource []byte
-	gvk         *metav1.GroupVersionKind
+	resource unstructured.Unstructured
+	// file and line of the resource manifest
+	file string
+	line int
 }
 
-func extractResource(fileDir, kubeconfig string) ([]*resourceInfo, error) {
-	var files []string
+// extractResources returns the resources of the files, of the files of the directories, and of the standard input,
+// with their default values filled in by a dry run in the cluster of the config flags if they are set
+func extractResources(in io.Reader, paths []string, configFlags *common.ConfigFlags) ([]*resourceInfo, error) {
 	var resources []*resourceInfo
+	for _, path := range paths {
+		if path == stdinPath {
+			data, err := ioutil.ReadAll(in)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the standard input: %v", err)
+			}
+			resources = append(resources, decodeResources("<stdin>", data, configFlags)...)
+			continue
+		}
 
-	// check if applied on multiple resources
-	isDir, err := isDir(fileDir)
-	if err != nil {
-		return nil, err
-	}
-
-	if isDir {
-		files, err = scanDir(fileDir)
+		files, err := common.ExpandPaths([]string{path})
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		files = []string{fileDir}
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				glog.Warningf("Error while loading file: %v\n", err)
+				continue
+			}
+			resources = append(resources, decodeResources(file, data, configFlags)...)
+		}
 	}
+	return resources, nil
+}
 
-	for _, dir := range files {
-		data, err := loadFile(dir)
+// decodeResources returns the resources of the YAML documents of the file, the empty documents are skipped
+func decodeResources(file string, data []byte, configFlags *common.ConfigFlags) []*resourceInfo {
+	var resources []*resourceInfo
+	for _, document := range common.SplitDocuments(data) {
+		startLine := document.StartLine()
+		resource, err := common.DecodeResource(document.Data)
 		if err != nil {
-			glog.Warningf("Error while loading file: %v\n", err)
+			glog.Warningf("Error while decoding YAML object at %s:%d, err: %v\n", file, startLine, err)
+			continue
+		}
+		if resource == nil {
 			continue
 		}
 
-		dd := bytes.Split(data, []byte(defaultYamlSeparator))
-
-		for _, d := range dd {
-			decode := scheme.Codecs.UniversalDeserializer().Decode
-			obj, gvk, err := decode([]byte(d), nil, nil)
-			if err != nil {
-				glog.Warningf("Error while decoding YAML object, err: %s\n", err)
-				continue
-			}
-
-			actualObj, err := convertToActualObject(kubeconfig, gvk, obj)
+		if configFlags != nil {
+			actualObj, err := convertToActualObject(configFlags, resource)
 			if err != nil {
-				glog.V(3).Infof("Failed to convert resource %s to actual k8s object: %v\n", gvk.Kind, err)
+				glog.V(3).Infof("Failed to convert resource %s to actual k8s object: %v\n", resource.GetKind(), err)
 				glog.V(3).Infof("Apply policy on raw resource.\n")
+			} else {
+				resource = actualObj
 			}
-
-			raw, err := json.Marshal(actualObj)
-			if err != nil {
-				glog.Warningf("Error while marshalling manifest, err: %v\n", err)
-				continue
-			}
-
-			gvkInfo := &metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}
-			resources = append(resources, &resourceInfo{rawResource: raw, gvk: gvkInfo})
 		}
-	}
 
-	return resources, err
+		resources = append(resources, &resourceInfo{resource: *resource, file: file, line: startLine})
+	}
+	return resources
 }
 
-func convertToActualObject(kubeconfig string, gvk *schema.GroupVersionKind, obj runtime.Object) (interface{}, error) {
-	clientConfig, err := createClientConfig(kubeconfig)
+// convertToActualObject returns the resource with the default values filled in by a server-side dry run
+func convertToActualObject(configFlags *common.ConfigFlags, resource *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	clientConfig, err := configFlags.ToRESTConfig()
 	if err != nil {
-		return obj, err
+		return nil, err
 	}
 
 	dynamicClient, err := dynamic.NewForConfig(clientConfig)
 	if err != nil {
-		return obj, err
+		return nil, err
 	}
 
 	kclient, err := kubernetes.NewForConfig(clientConfig)
 	if err != nil {
-		return obj, err
-	}
-
-	asUnstructured := &unstructured.Unstructured{}
-	if err := scheme.Scheme.Convert(obj, asUnstructured, nil); err != nil {
-		return obj, err
+		return nil, err
 	}
 
+	gvk := resource.GroupVersionKind()
 	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(kclient.Discovery()))
 	mapping, err := mapper.RESTMapping(schema.GroupKind{Group: gvk.Group, Kind: gvk.Kind}, gvk.Version)
 	if err != nil {
-		return obj, err
+		return nil, err
 	}
 
-	actualObj, err := dynamicClient.Resource(mapping.Resource).Namespace("default").Create(asUnstructured, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
-	if err != nil {
-		return obj, err
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return dynamicClient.Resource(mapping.Resource).Create(resource, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
 	}
-
-	return actualObj, nil
+	namespace := resource.GetNamespace()
+	if namespace == "" {
+		if namespace, err = configFlags.ToNamespace(); err != nil {
+			return nil, err
+		}
+	}
+	return dynamicClient.Resource(mapping.Resource).Namespace(namespace).Create(resource, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
 }
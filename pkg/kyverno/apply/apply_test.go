@@ -0,0 +1,221 @@
+package apply
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	policyvalidate "github.com/nirmata/kyverno/pkg/engine/policy"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"gotest.tools/assert"
+)
+
+const testPolicies = `
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: add-label
+spec:
+  rules:
+  - name: add-team
+    match:
+      resources:
+        kinds:
+        - ConfigMap
+    mutate:
+      overlay:
+        metadata:
+          labels:
+            +(team): platform
+---
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: require-labels
+spec:
+  rules:
+  - name: check-team
+    match:
+      resources:
+        kinds:
+        - ConfigMap
+    validate:
+      message: "label team is required"
+      pattern:
+        metadata:
+          labels:
+            team: "?*"
+  - name: check-app
+    match:
+      resources:
+        kinds:
+        - ConfigMap
+    validate:
+      message: "label app is required"
+      pattern:
+        metadata:
+          labels:
+            app: "?*"
+`
+
+const testResources = `# the configuration of the application
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: default
+  labels:
+    app: web
+---
+
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: custom
+`
+
+func Test_ParsePaths(t *testing.T) {
+	policies, resources, err := parsePaths([]string{"@policy.yaml", "@resources/"}, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policies, []string{"policy.yaml"})
+	assert.DeepEqual(t, resources, []string{"resources/"})
+
+	policies, resources, err = parsePaths([]string{"policy.yaml", "policies/"}, []string{"-", "resource.yaml"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policies, []string{"policy.yaml", "policies/"})
+	assert.DeepEqual(t, resources, []string{"-", "resource.yaml"})
+
+	_, _, err = parsePaths([]string{"policy.yaml"}, nil)
+	assert.ErrorContains(t, err, "missing resource")
+	_, _, err = parsePaths(nil, []string{"resource.yaml"})
+	assert.ErrorContains(t, err, "missing policy")
+}
+
+func Test_ExtractResourcesFromStdin(t *testing.T) {
+	resources, err := extractResources(strings.NewReader(testResources), []string{stdinPath}, nil)
+	assert.NilError(t, err)
+	// the empty documents are skipped, the custom resources are kept
+	assert.Equal(t, len(resources), 2)
+	assert.Equal(t, resources[0].resource.GetName(), "app")
+	assert.Equal(t, resources[0].file, "<stdin>")
+	assert.Equal(t, resources[0].line, 3)
+	assert.Equal(t, resources[1].resource.GetKind(), "Widget")
+	assert.Equal(t, resources[1].line, 13)
+}
+
+func Test_ApplyPolicies(t *testing.T) {
+	policies, err := common.DecodePolicies([]byte(testPolicies))
+	assert.NilError(t, err)
+	assert.Equal(t, len(policies), 2)
+	resources := decodeResources("resources.yaml", []byte(testResources), nil)
+
+	output, results, _ := applyPolicies(policies, resources, common.Values{})
+	// the resource is validated after its mutation
+	assert.Assert(t, strings.Contains(output, "team: platform"), output)
+
+	r := newReport(results)
+	assert.Equal(t, r.exitCode(), common.ExitSuccess)
+	var out bytes.Buffer
+	printResults(&out, r)
+	assert.Assert(t, strings.Contains(out.String(), "resources.yaml:3: pass: require-labels/check-team ConfigMap/default/app"), out.String())
+	assert.Assert(t, strings.HasSuffix(out.String(), "3 passed, 0 failed, 0 errors\n"), out.String())
+
+	// without the label app, the rule check-app fails
+	resources[0].resource.SetLabels(nil)
+	_, results, _ = applyPolicies(policies, resources, common.Values{})
+	r = newReport(results)
+	assert.Equal(t, r.exitCode(), common.ExitFailure)
+	assert.DeepEqual(t, r.Summary, summary{Pass: 2, Fail: 1})
+	out.Reset()
+	printResults(&out, r)
+	assert.Assert(t, strings.Contains(out.String(), "fail: require-labels/check-app ConfigMap/default/app"), out.String())
+
+	out.Reset()
+	assert.NilError(t, common.WriteReport(&out, common.OutputYAML, r))
+	assert.Assert(t, strings.HasPrefix(out.String(), "results:\n- policy: add-label\n  rule: add-team\n"), out.String())
+	assert.Assert(t, strings.HasSuffix(out.String(), "summary:\n  pass: 2\n  fail: 1\n  error: 0\n"), out.String())
+
+	out.Reset()
+	assert.NilError(t, writeJUnit(&out, r))
+	assert.Assert(t, strings.Contains(out.String(), `<testsuites tests="3" failures="1" errors="0">`), out.String())
+	assert.Assert(t, strings.Contains(out.String(), `<testsuite name="resources.yaml" tests="3" failures="1" errors="0" skipped="0">`), out.String())
+	assert.Assert(t, strings.Contains(out.String(), `<testcase name="require-labels/check-app ConfigMap/default/app" classname="require-labels">`), out.String())
+}
+
+func Test_ReportEngineErrors(t *testing.T) {
+	policies, err := common.DecodePolicies([]byte(`apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: require-env
+spec:
+  rules:
+  - name: check-env
+    context:
+    - name: settings
+      configMap:
+        name: settings
+        namespace: kyverno
+    match:
+      resources:
+        kinds: [ConfigMap]
+    validate:
+      pattern:
+        metadata:
+          labels:
+            env: "{{settings.data.env}}"
+`))
+	assert.NilError(t, err)
+	resources := decodeResources("resources.yaml", []byte(testResources), nil)
+
+	// the ConfigMaps of the context are not available offline, the rule is an error rather than a failure
+	_, results, _ := applyPolicies(policies, resources, common.Values{})
+	r := newReport(results)
+	assert.DeepEqual(t, r.Summary, summary{Error: 1})
+	assert.Equal(t, r.Results[0].Status, common.StatusError)
+	assert.Equal(t, r.exitCode(), common.ExitError)
+}
+
+func Test_ApplyPolicies_RequestValues(t *testing.T) {
+	// the policy of the values example of the documentation
+	policies, err := common.DecodePolicies([]byte(`apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: freeze-prod
+spec:
+  background: false
+  rules:
+  - name: no-updates
+    match:
+      resources:
+        kinds: [ConfigMap]
+    preconditions:
+    - key: "{{request.operation}}"
+      operator: Equal
+      value: UPDATE
+    validate:
+      message: "{{request.operation}} is not allowed in {{request.namespace}}"
+      pattern:
+        metadata:
+          labels:
+            frozen: "false"
+`))
+	assert.NilError(t, err)
+	assert.NilError(t, policyvalidate.Validate(policies[0]))
+	resources := decodeResources("resources.yaml", []byte(testResources), nil)
+
+	values := common.Values{}
+	assert.NilError(t, values.Set("request.operation=UPDATE"))
+	assert.NilError(t, values.Set("ConfigMap/default/app:request.namespace=prod"))
+	_, results, _ := applyPolicies(policies, resources, values)
+	r := newReport(results)
+	assert.DeepEqual(t, r.Summary, summary{Fail: 1})
+	var out bytes.Buffer
+	printResults(&out, r)
+	assert.Assert(t, strings.Contains(out.String(), "UPDATE is not allowed in prod"), out.String())
+
+	// without the values, the preconditions are not met
+	_, results, _ = applyPolicies(policies, resources, common.Values{})
+	assert.Equal(t, newReport(results).Summary.Fail, 0)
+}
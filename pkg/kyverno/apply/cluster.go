@@ -0,0 +1,102 @@
+package apply
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// clusterFile is the file of the resources of the cluster in the results
+const clusterFile = "<cluster>"
+
+// resourceLister lists the resources of a kind page by page, as the client of the cluster
+type resourceLister interface {
+	ListResourcePages(kind string, namespace string, lselector *metav1.LabelSelector, fselector fields.Selector, fn func(*unstructured.UnstructuredList) error) error
+}
+
+// loadClusterResources returns the resources of the cluster of the config flags matched by the kinds of the policies,
+// in the namespace of the flags if it is set and matching the label selector
+func loadClusterResources(configFlags *common.ConfigFlags, policies []kyverno.ClusterPolicy, selector string) ([]*resourceInfo, error) {
+	labelSelector, err := metav1.ParseToLabelSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %s: %v", selector, err)
+	}
+	clientConfig, err := configFlags.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	// the command is shorter than the refresh of the discovered resources
+	client, err := dclient.NewClient(clientConfig, time.Hour, dclient.DefaultRetry, stopCh)
+	if err != nil {
+		return nil, err
+	}
+	return listResources(client, policyKinds(policies), configFlags.Namespace, labelSelector)
+}
+
+// policyKinds returns the kinds matched by the rules of the policies, sorted
+func policyKinds(policies []kyverno.ClusterPolicy) []string {
+	set := map[string]bool{}
+	for _, policy := range policies {
+		for _, rule := range policy.Spec.Rules {
+			for _, kind := range rule.MatchResources.Kinds {
+				set[kind] = true
+			}
+		}
+	}
+	var kinds []string
+	for kind := range set {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// listResources returns the resources of the kinds, in the namespace if it is not empty, sorted by kind, namespace and name.
+// In a namespace, the kinds not found are skipped, as the cluster-scoped kinds.
+func listResources(lister resourceLister, kinds []string, namespace string, selector *metav1.LabelSelector) ([]*resourceInfo, error) {
+	var resources []*resourceInfo
+	// uids of the resources already listed
+	listed := map[types.UID]bool{}
+	for _, kind := range kinds {
+		err := lister.ListResourcePages(kind, namespace, selector, nil, func(list *unstructured.UnstructuredList) error {
+			for _, item := range list.Items {
+				if listed[item.GetUID()] {
+					continue
+				}
+				listed[item.GetUID()] = true
+				resources = append(resources, &resourceInfo{resource: item, file: clusterFile})
+			}
+			return nil
+		})
+		if err != nil {
+			if namespace != "" && errors.IsNotFound(err) {
+				glog.V(3).Infof("skipping kind %s, not found in namespace %s: %v", kind, namespace, err)
+				continue
+			}
+			return nil, fmt.Errorf("failed to list the resources of kind %s: %v", kind, err)
+		}
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		a, b := resources[i].resource, resources[j].resource
+		if a.GetKind() != b.GetKind() {
+			return a.GetKind() < b.GetKind()
+		}
+		if a.GetNamespace() != b.GetNamespace() {
+			return a.GetNamespace() < b.GetNamespace()
+		}
+		return a.GetName() < b.GetName()
+	})
+	return resources, nil
+}
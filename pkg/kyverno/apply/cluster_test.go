@@ -0,0 +1,89 @@
+package apply
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// fakeLister lists the resources of the kinds, the cluster-scoped kinds are not found in a namespace
+type fakeLister struct {
+	resources     []unstructured.Unstructured
+	clusterScoped map[string]bool
+	// namespaces and selectors of the lists
+	namespaces []string
+	selectors  []*metav1.LabelSelector
+}
+
+func (l *fakeLister) ListResourcePages(kind string, namespace string, lselector *metav1.LabelSelector, fselector fields.Selector, fn func(*unstructured.UnstructuredList) error) error {
+	l.namespaces = append(l.namespaces, namespace)
+	l.selectors = append(l.selectors, lselector)
+	if namespace != "" && l.clusterScoped[kind] {
+		return errors.NewNotFound(schema.GroupResource{Resource: kind}, "")
+	}
+	list := &unstructured.UnstructuredList{}
+	for _, resource := range l.resources {
+		if resource.GetKind() == kind && (namespace == "" || resource.GetNamespace() == namespace) {
+			list.Items = append(list.Items, resource)
+		}
+	}
+	return fn(list)
+}
+
+func newResource(kind, namespace, name string, labels map[string]string) unstructured.Unstructured {
+	resource := unstructured.Unstructured{}
+	resource.SetAPIVersion("v1")
+	resource.SetKind(kind)
+	resource.SetNamespace(namespace)
+	resource.SetName(name)
+	resource.SetLabels(labels)
+	resource.SetUID(types.UID(kind + "/" + namespace + "/" + name))
+	return resource
+}
+
+func Test_ListClusterResources(t *testing.T) {
+	policies, err := common.DecodePolicies([]byte(testPolicies))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policyKinds(policies), []string{"ConfigMap"})
+
+	lister := &fakeLister{
+		resources: []unstructured.Unstructured{
+			newResource("ConfigMap", "prod", "web", map[string]string{"app": "web"}),
+			newResource("ConfigMap", "default", "db", nil),
+			newResource("Namespace", "", "prod", nil),
+		},
+		clusterScoped: map[string]bool{"Namespace": true},
+	}
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+	resources, err := listResources(lister, []string{"ConfigMap", "Namespace"}, "", selector)
+	assert.NilError(t, err)
+	// the resources are sorted by kind, namespace and name
+	var keys []string
+	for _, resource := range resources {
+		keys = append(keys, common.ResourceKey(resource.resource))
+		assert.Equal(t, resource.file, clusterFile)
+	}
+	assert.DeepEqual(t, keys, []string{"ConfigMap/default/db", "ConfigMap/prod/web", "Namespace/prod"})
+	assert.Equal(t, lister.selectors[0], selector)
+
+	// in a namespace, the cluster-scoped kinds are skipped
+	resources, err = listResources(lister, []string{"ConfigMap", "Namespace"}, "prod", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(resources), 1)
+	assert.Equal(t, common.ResourceKey(resources[0].resource), "ConfigMap/prod/web")
+
+	// the results of the resources of the cluster have no line
+	_, results, _ := applyPolicies(policies, resources, common.Values{})
+	var out bytes.Buffer
+	printResults(&out, newReport(results))
+	assert.Assert(t, strings.HasPrefix(out.String(), "<cluster>: pass: add-label/add-team ConfigMap/prod/web"), out.String())
+}
@@ -0,0 +1,250 @@
+package apply
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/engine/utils"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// diffContext is the count of the unchanged lines around the changes of a diff
+const diffContext = 3
+
+// rulePatch is the change of a resource by a mutation rule
+type rulePatch struct {
+	Policy string
+	Rule   string
+	// Resource is the key of the resource, kind/namespace/name
+	Resource string
+	// Patch is the JSON patch of the rule
+	Patch []byte
+	// Diff is the unified diff of the YAML of the resource before and after the rule
+	Diff string
+}
+
+// mutationPatches returns the changes of the resource by each mutation rule that patches it,
+// the patches of the rules are applied in order from the original resource
+func mutationPatches(resource unstructured.Unstructured, engineResponses []response.EngineResponse) ([]rulePatch, error) {
+	key := common.ResourceKey(resource)
+	before, err := resource.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var patches []rulePatch
+	for _, engineResponse := range engineResponses {
+		for _, rule := range engineResponse.PolicyResponse.Rules {
+			if rule.Type != utils.Mutation.String() || !rule.Success || len(rule.Patches) == 0 {
+				continue
+			}
+			after, err := utils.ApplyPatches(before, rule.Patches)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply the patches of rule %s/%s on %s: %v", engineResponse.PolicyResponse.Policy, rule.Name, key, err)
+			}
+			patch, err := indentJSON(utils.JoinPatches(rule.Patches))
+			if err != nil {
+				return nil, err
+			}
+			beforeYAML, err := prettyPrint(before)
+			if err != nil {
+				return nil, err
+			}
+			afterYAML, err := prettyPrint(after)
+			if err != nil {
+				return nil, err
+			}
+			ruleID := engineResponse.PolicyResponse.Policy + "/" + rule.Name
+			patches = append(patches, rulePatch{
+				Policy:   engineResponse.PolicyResponse.Policy,
+				Rule:     rule.Name,
+				Resource: key,
+				Patch:    patch,
+				Diff:     unifiedDiff(key, key+" ("+ruleID+")", splitLines(string(beforeYAML)), splitLines(string(afterYAML))),
+			})
+			before = after
+		}
+	}
+	return patches, nil
+}
+
+func indentJSON(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteString("\n")
+	return out.Bytes(), nil
+}
+
+// writePatches writes the JSON patch and the diff of each rule in the directory,
+// as <resource>/<policy>-<rule>.json and .diff with the resource <kind>-<namespace>-<name>
+func writePatches(dir string, patches []rulePatch) error {
+	for _, patch := range patches {
+		resourceDir := filepath.Join(dir, strings.ToLower(strings.Replace(patch.Resource, "/", "-", -1)))
+		if err := os.MkdirAll(resourceDir, 0755); err != nil {
+			return err
+		}
+		name := filepath.Join(resourceDir, patch.Policy+"-"+patch.Rule)
+		if err := ioutil.WriteFile(name+".json", patch.Patch, 0644); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(name+".diff", []byte(patch.Diff), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffOp is a line of a diff, ' ' if the line is unchanged, '-' if it is removed and '+' if it is added
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns the lines of the diff of a and b, from their longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// unifiedDiff returns the unified diff of the lines, empty if they are equal
+func unifiedDiff(fromName, toName string, a, b []string) string {
+	ops := diffLines(a, b)
+	var out strings.Builder
+	// the hunks are the changed lines and their context, the hunks closer than twice the context are merged
+	for start := 0; start < len(ops); {
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for k := first; k < len(ops) && k-last <= 2*diffContext; k++ {
+			if ops[k].kind != ' ' {
+				last = k
+			}
+		}
+		from := first - diffContext
+		if from < start {
+			from = start
+		}
+		if from < 0 {
+			from = 0
+		}
+		to := last + diffContext + 1
+		if to > len(ops) {
+			to = len(ops)
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		writeHunk(&out, ops, from, to)
+		start = to
+	}
+	return out.String()
+}
+
+// writeHunk writes the hunk of the lines of the ops from from to to
+func writeHunk(out *strings.Builder, ops []diffOp, from, to int) {
+	// the lines of a and b before the hunk
+	aLine, bLine := 0, 0
+	for _, op := range ops[:from] {
+		if op.kind != '+' {
+			aLine++
+		}
+		if op.kind != '-' {
+			bLine++
+		}
+	}
+	var aCount, bCount int
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			aCount++
+		}
+		if op.kind != '-' {
+			bCount++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+	for _, op := range ops[from:to] {
+		out.WriteByte(op.kind)
+		out.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange returns the range of a hunk, the start is the line before the hunk if it is empty
+func hunkRange(before, count int) string {
+	start := before + 1
+	if count == 0 {
+		start = before
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// printDiffs writes the diffs of the rules, in the order of the resources and of the rules
+func printDiffs(w io.Writer, patches []rulePatch) {
+	for _, patch := range patches {
+		fmt.Fprint(w, patch.Diff)
+	}
+}
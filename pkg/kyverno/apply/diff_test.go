@@ -0,0 +1,70 @@
+package apply
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"gotest.tools/assert"
+)
+
+func Test_UnifiedDiff(t *testing.T) {
+	a := splitLines("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n")
+	b := splitLines("a\nb\nc\nd\nE\nf\ng\nh\ni\nj\nk\nl\nm\nn\no\n")
+	expected := `--- from
++++ to
+@@ -2,7 +2,7 @@
+ b
+ c
+ d
+-e
++E
+ f
+ g
+ h
+@@ -12,3 +12,4 @@
+ l
+ m
+ n
++o
+`
+	assert.Equal(t, unifiedDiff("from", "to", a, b), expected)
+
+	// the hunks closer than twice the context are merged
+	b = splitLines("a\nb\nc\nd\nE\nf\ng\nh\ni\nJ\nk\nl\nm\nn\n")
+	assert.Equal(t, strings.Count(unifiedDiff("from", "to", a, b), "@@ "), 1)
+
+	assert.Equal(t, unifiedDiff("from", "to", a, a), "")
+	assert.Equal(t, unifiedDiff("from", "to", nil, splitLines("a\n")), "--- from\n+++ to\n@@ -0,0 +1 @@\n+a\n")
+}
+
+func Test_MutationPatches(t *testing.T) {
+	policies, err := common.DecodePolicies([]byte(testPolicies))
+	assert.NilError(t, err)
+	resources := decodeResources("resources.yaml", []byte(testResources), nil)
+
+	_, _, patches := applyPolicies(policies, resources, common.Values{})
+	// only the rule add-team changes a resource
+	assert.Equal(t, len(patches), 1)
+	patch := patches[0]
+	assert.Equal(t, patch.Policy, "add-label")
+	assert.Equal(t, patch.Rule, "add-team")
+	assert.Equal(t, patch.Resource, "ConfigMap/default/app")
+	assert.Assert(t, strings.Contains(string(patch.Patch), `"path": "/metadata/labels/team"`), string(patch.Patch))
+	assert.Assert(t, strings.HasPrefix(patch.Diff, "--- ConfigMap/default/app\n+++ ConfigMap/default/app (add-label/add-team)\n"), patch.Diff)
+	assert.Assert(t, strings.Contains(patch.Diff, "\n+    team: platform\n"), patch.Diff)
+
+	dir, err := ioutil.TempDir("", "kyverno-patches")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NilError(t, writePatches(dir, patches))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "configmap-default-app", "add-label-add-team.json"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), string(patch.Patch))
+	data, err = ioutil.ReadFile(filepath.Join(dir, "configmap-default-app", "add-label-add-team.diff"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), patch.Diff)
+}
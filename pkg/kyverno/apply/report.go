@@ -0,0 +1,109 @@
+package apply
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nirmata/kyverno/pkg/engine/sarif"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+)
+
+// report is the report of the results of the rules on the resources, for the json and yaml outputs
+type report struct {
+	Results []result `json:"results"`
+	Summary summary  `json:"summary"`
+}
+
+// result is the result of a rule on a resource
+type result struct {
+	Policy string `json:"policy"`
+	Rule   string `json:"rule"`
+	// Resource is the key of the resource, kind/namespace/name
+	Resource string `json:"resource"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	// Status is pass, fail, or error if the rule could not be applied
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type summary struct {
+	Pass  int `json:"pass"`
+	Fail  int `json:"fail"`
+	Error int `json:"error"`
+}
+
+// newReport returns the report of the results of the applied rules
+func newReport(results []sarif.ResourceResult) report {
+	r := report{Results: []result{}}
+	for _, resourceResult := range results {
+		policyResponse := resourceResult.EngineResponse.PolicyResponse
+		for _, rule := range policyResponse.Rules {
+			status := common.RuleStatus(rule)
+			switch status {
+			case common.StatusPass:
+				r.Summary.Pass++
+			case common.StatusFail:
+				r.Summary.Fail++
+			case common.StatusError:
+				r.Summary.Error++
+			}
+			r.Results = append(r.Results, result{
+				Policy:   policyResponse.Policy,
+				Rule:     rule.Name,
+				Resource: common.SpecKey(policyResponse.Resource),
+				File:     resourceResult.File,
+				Line:     resourceResult.Line,
+				Status:   status,
+				Message:  rule.Message,
+			})
+		}
+	}
+	return r
+}
+
+// exitCode returns the exit code of the report, the errors take precedence over the failures
+func (r report) exitCode() int {
+	switch {
+	case r.Summary.Error > 0:
+		return common.ExitError
+	case r.Summary.Fail > 0:
+		return common.ExitFailure
+	default:
+		return common.ExitSuccess
+	}
+}
+
+// printResults writes the result of each applied rule and a summary
+func printResults(w io.Writer, r report) {
+	for _, result := range r.Results {
+		// the resources of the cluster have no line
+		location := result.File
+		if result.Line > 0 {
+			location = fmt.Sprintf("%s:%d", result.File, result.Line)
+		}
+		fmt.Fprintf(w, "%s: %s: %s/%s %s", location, result.Status, result.Policy, result.Rule, result.Resource)
+		if result.Message != "" {
+			fmt.Fprintf(w, ": %s", result.Message)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%d passed, %d failed, %d errors\n", r.Summary.Pass, r.Summary.Fail, r.Summary.Error)
+}
+
+// writeJUnit writes the results as a JUnit report, with a test suite for each file of the resources
+func writeJUnit(w io.Writer, r report) error {
+	var suites []common.JUnitTestSuite
+	index := map[string]int{}
+	for _, result := range r.Results {
+		i, ok := index[result.File]
+		if !ok {
+			i = len(suites)
+			index[result.File] = i
+			suites = append(suites, common.JUnitTestSuite{Name: result.File})
+		}
+		name := fmt.Sprintf("%s/%s %s", result.Policy, result.Rule, result.Resource)
+		suites[i].Cases = append(suites[i].Cases, common.NewJUnitTestCase(name, result.Policy, result.Status, result.Message))
+	}
+	return common.WriteJUnit(w, suites)
+}
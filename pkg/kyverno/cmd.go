@@ -3,8 +3,17 @@ package cmd
 import (
 	"io"
 	"os"
+	"regexp"
 
 	"github.com/nirmata/kyverno/pkg/kyverno/apply"
+	"github.com/nirmata/kyverno/pkg/kyverno/create"
+	"github.com/nirmata/kyverno/pkg/kyverno/export"
+	"github.com/nirmata/kyverno/pkg/kyverno/jp"
+	"github.com/nirmata/kyverno/pkg/kyverno/migrate"
+	"github.com/nirmata/kyverno/pkg/kyverno/oci"
+	"github.com/nirmata/kyverno/pkg/kyverno/scan"
+	"github.com/nirmata/kyverno/pkg/kyverno/test"
+	"github.com/nirmata/kyverno/pkg/kyverno/validate"
 	"github.com/nirmata/kyverno/pkg/kyverno/version"
 	"github.com/spf13/cobra"
 )
@@ -14,6 +23,30 @@ func NewDefaultKyvernoCommand() *cobra.Command {
 	return NewKyvernoCommand(os.Stdin, os.Stdout, os.Stderr)
 }
 
+// PluginPrefix is the prefix of the name of the kubectl plugins, kubectl runs kubectl-kyverno as kubectl kyverno
+const PluginPrefix = "kubectl-"
+
+// pluginExample matches the kyverno commands of the examples, at the start of a line or after a pipe
+var pluginExample = regexp.MustCompile(`(?m)(^ *|\| )kyverno `)
+
+// NewDefaultKubectlPluginCommand ...
+func NewDefaultKubectlPluginCommand() *cobra.Command {
+	return NewKubectlPluginCommand(os.Stdin, os.Stdout, os.Stderr, os.Args[1:])
+}
+
+// NewKubectlPluginCommand returns the kyverno command as the subcommand of kubectl, for its usage and examples,
+// the arguments are the arguments of the plugin
+func NewKubectlPluginCommand(in io.Reader, out, errout io.Writer, args []string) *cobra.Command {
+	cmds := NewKyvernoCommand(in, out, errout)
+	for _, cmd := range cmds.Commands() {
+		cmd.Example = pluginExample.ReplaceAllString(cmd.Example, "${1}kubectl kyverno ")
+	}
+	kubectl := &cobra.Command{Use: "kubectl"}
+	kubectl.AddCommand(cmds)
+	kubectl.SetArgs(append([]string{cmds.Name()}, args...))
+	return kubectl
+}
+
 // NewKyvernoCommand returns the new kynerno command
 func NewKyvernoCommand(in io.Reader, out, errout io.Writer) *cobra.Command {
 	cmds := &cobra.Command{
@@ -22,6 +55,14 @@ func NewKyvernoCommand(in io.Reader, out, errout io.Writer) *cobra.Command {
 	}
 
 	cmds.AddCommand(apply.NewCmdApply(in, out, errout))
+	cmds.AddCommand(scan.NewCmdScan(out))
+	cmds.AddCommand(validate.NewCmdValidate(out))
+	cmds.AddCommand(test.NewCmdTest(out))
+	cmds.AddCommand(jp.NewCmdJp(in, out))
+	cmds.AddCommand(create.NewCmdCreate(out))
+	cmds.AddCommand(migrate.NewCmdMigrate(out))
+	cmds.AddCommand(export.NewCmdExport(out))
+	cmds.AddCommand(oci.NewCmdOCI(out))
 	cmds.AddCommand(version.NewCmdVersion(out))
 	return cmds
 }
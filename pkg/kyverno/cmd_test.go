@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func Test_KubectlPluginCommand(t *testing.T) {
+	var out bytes.Buffer
+	cmd := NewKubectlPluginCommand(strings.NewReader(""), &out, &out, []string{"version"})
+	executed, err := cmd.ExecuteC()
+	assert.NilError(t, err)
+	assert.Equal(t, executed.CommandPath(), "kubectl kyverno version")
+
+	apply, _, err := cmd.Find([]string{"kyverno", "apply"})
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(apply.Example, "\n  helm template chart/ | kubectl kyverno apply policyDir/ --resource -\n"), apply.Example)
+	assert.Assert(t, strings.Contains(apply.Example, "\n  kubectl kyverno apply policy.yaml --resource resource.yaml\n"), apply.Example)
+}
@@ -0,0 +1,150 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const yamlSeparator = "---"
+
+// ExpandPaths returns the files of the paths, the YAML and JSON files of the directories are walked recursively
+func ExpandPaths(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		var dirFiles []string
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && isManifest(file) {
+				dirFiles = append(dirFiles, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %v", path, err)
+		}
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
+
+func isManifest(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// LoadPolicies returns the cluster policies of the YAML or JSON files, and of the files of the directories
+func LoadPolicies(paths []string) ([]kyverno.ClusterPolicy, error) {
+	files, err := ExpandPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+	var policies []kyverno.ClusterPolicy
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		filePolicies, err := DecodePolicies(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		policies = append(policies, filePolicies...)
+	}
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("no ClusterPolicy in %s", strings.Join(paths, ", "))
+	}
+	return policies, nil
+}
+
+// DecodePolicies returns the cluster policies of the YAML or JSON documents, the empty documents are skipped
+func DecodePolicies(data []byte) ([]kyverno.ClusterPolicy, error) {
+	var policies []kyverno.ClusterPolicy
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		policy := kyverno.ClusterPolicy{}
+		if err := decoder.Decode(&policy); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode the policies: %v", err)
+		}
+		if policy.Kind == "" && policy.Name == "" {
+			// empty document
+			continue
+		}
+		if policy.Kind != "ClusterPolicy" {
+			return nil, fmt.Errorf("%s %s is not a ClusterPolicy", policy.Kind, policy.Name)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// Document is a YAML document of a file
+type Document struct {
+	// Data is the content of the document, from the end of its separator
+	Data []byte
+	// Line is the line of the start of the data in the file, starting at 1
+	Line int
+}
+
+// SplitDocuments returns the YAML documents of the file, split on the --- separators
+func SplitDocuments(data []byte) []Document {
+	dd := bytes.Split(data, []byte(yamlSeparator))
+	documents := make([]Document, 0, len(dd))
+	line := 1
+	for i, d := range dd {
+		if i > 0 {
+			line += bytes.Count(dd[i-1], []byte("\n"))
+		}
+		documents = append(documents, Document{Data: d, Line: line})
+	}
+	return documents
+}
+
+// StartLine returns the line of the first non-blank line of the document
+func (d Document) StartLine() int {
+	return d.Line + bytes.Count(d.Data[:len(d.Data)-len(bytes.TrimLeft(d.Data, " \t\r\n"))], []byte("\n"))
+}
+
+// DecodeResource returns the resource of the YAML document, nil if the document is empty.
+// The resources of any kind are decoded, including the custom resources.
+func DecodeResource(document []byte) (*unstructured.Unstructured, error) {
+	raw, err := yaml.ToJSON(document)
+	if err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	resource := &unstructured.Unstructured{}
+	if err := resource.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
@@ -0,0 +1,105 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ApplyPolicies returns the mutated resource and the engine responses of the mutation and the validation.
+// As in the admission webhook, the resource is mutated by all the policies before it is validated.
+// The values of the resource are added to the context of the variables of the rules.
+func ApplyPolicies(policies []kyverno.ClusterPolicy, resource unstructured.Unstructured, values Values) (unstructured.Unstructured, []response.EngineResponse) {
+	resourceValues := values.ForResource(resource)
+	var admissionInfo kyverno.RequestInfo
+	if values.UserInfo != nil {
+		admissionInfo = *values.UserInfo
+	}
+	var engineResponses []response.EngineResponse
+	// Process Mutation
+	for _, policy := range policies {
+		policy = withValues(policy, resourceValues)
+		engineResponse := engine.Mutate(engine.PolicyContext{Policy: policy, NewResource: resource, AdmissionInfo: admissionInfo, Context: newContext(resource, resourceValues, values.UserInfo), Log: enginelog.Glog})
+		engineResponses = append(engineResponses, engineResponse)
+		if !engineResponse.IsSuccesful() {
+			glog.Infof("Failed to apply policy %s on resource %s", policy.Name, ResourceKey(resource))
+			for _, r := range engineResponse.PolicyResponse.Rules {
+				glog.Warning(r.Message)
+			}
+			continue
+		}
+		if len(engineResponse.PolicyResponse.Rules) > 0 && engineResponse.PatchedResource.Object != nil {
+			glog.Infof("Mutation from policy %s has applied successfully to %s", policy.Name, ResourceKey(resource))
+			resource = engineResponse.PatchedResource
+		}
+	}
+
+	// Process Validation
+	for _, policy := range policies {
+		policy = withValues(policy, resourceValues)
+		engineResponse := engine.Validate(engine.PolicyContext{Policy: policy, NewResource: resource, AdmissionInfo: admissionInfo, Context: newContext(resource, resourceValues, values.UserInfo), Log: enginelog.Glog})
+		engineResponses = append(engineResponses, engineResponse)
+		if !engineResponse.IsSuccesful() {
+			glog.Infof("Policy %s on resource %s not satisfied", policy.Name, ResourceKey(resource))
+		} else if len(engineResponse.PolicyResponse.Rules) > 0 {
+			glog.Infof("Validation from policy %s has applied successfully to %s", policy.Name, ResourceKey(resource))
+		}
+	}
+	return resource, engineResponses
+}
+
+// newContext returns the context of the variables of the rules, with the resource as request.object,
+// the user info of the request if it is set, and the values, which override the other variables
+func newContext(resource unstructured.Unstructured, values map[string]interface{}, userInfo *kyverno.RequestInfo) *context.Context {
+	ctx := context.NewContext()
+	raw, err := resource.MarshalJSON()
+	if err != nil {
+		glog.Warningf("Failed to marshal resource %s: %v", ResourceKey(resource), err)
+		return ctx
+	}
+	if err := ctx.AddResource(raw); err != nil {
+		glog.Warningf("Failed to add resource %s to the context: %v", ResourceKey(resource), err)
+	}
+	if userInfo != nil {
+		if err := ctx.AddUserInfo(*userInfo); err != nil {
+			glog.Warningf("Failed to add the user info to the context: %v", err)
+		}
+		if err := ctx.AddSA(userInfo.AdmissionUserInfo.Username); err != nil {
+			glog.Warningf("Failed to add the service account to the context: %v", err)
+		}
+	}
+	if len(values) == 0 {
+		return ctx
+	}
+	if err := addValues(ctx, values); err != nil {
+		glog.Warningf("Failed to add the values of resource %s to the context: %v", ResourceKey(resource), err)
+	}
+	return ctx
+}
+
+func addValues(ctx *context.Context, values map[string]interface{}) error {
+	raw, err := valuesJSON(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the values: %v", err)
+	}
+	return ctx.AddJSON(raw)
+}
+
+// ResourceKey returns the key of the resource, kind/namespace/name or kind/name
+func ResourceKey(resource unstructured.Unstructured) string {
+	return SpecKey(response.ResourceSpec{Kind: resource.GetKind(), Namespace: resource.GetNamespace(), Name: resource.GetName()})
+}
+
+// SpecKey returns the key of the resource of an engine response, kind/namespace/name or kind/name
+func SpecKey(spec response.ResourceSpec) string {
+	if spec.Namespace == "" {
+		return spec.Kind + "/" + spec.Name
+	}
+	return spec.Kind + "/" + spec.Namespace + "/" + spec.Name
+}
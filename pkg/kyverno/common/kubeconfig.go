@@ -0,0 +1,46 @@
+package common
+
+import (
+	"github.com/spf13/pflag"
+	rest "k8s.io/client-go/rest"
+	clientcmd "k8s.io/client-go/tools/clientcmd"
+)
+
+// ConfigFlags are the kubectl flags of the cluster connection,
+// the kubeconfig files of $KUBECONFIG or ~/.kube/config are loaded if --kubeconfig is not set
+type ConfigFlags struct {
+	Kubeconfig string
+	Context    string
+	Namespace  string
+}
+
+// AddFlags adds the --kubeconfig, --context and -n/--namespace flags
+func (f *ConfigFlags) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&f.Kubeconfig, "kubeconfig", "", "path to the kubeconfig file, $KUBECONFIG or ~/.kube/config if not set")
+	flags.StringVar(&f.Context, "context", "", "name of the kubeconfig context to use, the current context if not set")
+	flags.StringVarP(&f.Namespace, "namespace", "n", "", "namespace of the request, the namespace of the context if not set")
+}
+
+// Changed returns true if one of the flags is set on the command line
+func (f *ConfigFlags) Changed(flags *pflag.FlagSet) bool {
+	return flags.Changed("kubeconfig") || flags.Changed("context") || flags.Changed("namespace")
+}
+
+func (f *ConfigFlags) clientConfig() clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = f.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: f.Context}
+	overrides.Context.Namespace = f.Namespace
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+// ToRESTConfig returns the config of the client of the context
+func (f *ConfigFlags) ToRESTConfig() (*rest.Config, error) {
+	return f.clientConfig().ClientConfig()
+}
+
+// ToNamespace returns the namespace of the flag, else of the context, else default
+func (f *ConfigFlags) ToNamespace() (string, error) {
+	namespace, _, err := f.clientConfig().Namespace()
+	return namespace, err
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+const testKubeconfig = `
+apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+- name: prod
+  cluster:
+    server: https://prod.example.com
+contexts:
+- name: dev
+  context:
+    cluster: dev
+- name: prod
+  context:
+    cluster: prod
+    namespace: apps
+users: []
+`
+
+func Test_ConfigFlags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kyverno-kubeconfig")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	kubeconfig := filepath.Join(dir, "config")
+	assert.NilError(t, ioutil.WriteFile(kubeconfig, []byte(testKubeconfig), 0644))
+
+	// without --kubeconfig, the files of $KUBECONFIG are loaded
+	defer os.Setenv("KUBECONFIG", os.Getenv("KUBECONFIG"))
+	os.Setenv("KUBECONFIG", kubeconfig)
+
+	flags := &ConfigFlags{}
+	config, err := flags.ToRESTConfig()
+	assert.NilError(t, err)
+	assert.Equal(t, config.Host, "https://dev.example.com")
+	namespace, err := flags.ToNamespace()
+	assert.NilError(t, err)
+	assert.Equal(t, namespace, "default")
+
+	flags = &ConfigFlags{Context: "prod"}
+	config, err = flags.ToRESTConfig()
+	assert.NilError(t, err)
+	assert.Equal(t, config.Host, "https://prod.example.com")
+	namespace, err = flags.ToNamespace()
+	assert.NilError(t, err)
+	assert.Equal(t, namespace, "apps")
+
+	flags = &ConfigFlags{Kubeconfig: kubeconfig, Context: "prod", Namespace: "web"}
+	namespace, err = flags.ToNamespace()
+	assert.NilError(t, err)
+	assert.Equal(t, namespace, "web")
+
+	_, err = (&ConfigFlags{Context: "staging"}).ToRESTConfig()
+	assert.ErrorContains(t, err, "staging")
+}
@@ -0,0 +1,160 @@
+package common
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	yamlv2 "gopkg.in/yaml.v2"
+)
+
+// exit codes of the commands
+const (
+	// ExitSuccess is the exit code if the policies are satisfied
+	ExitSuccess = 0
+	// ExitFailure is the exit code if a policy fails, e.g. a rule is violated or an expected result is not met
+	ExitFailure = 1
+	// ExitError is the exit code if the command fails, e.g. a file cannot be read or a rule cannot be applied
+	ExitError = 2
+)
+
+// output formats of the reports of the commands
+const (
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputJUnit = "junit"
+)
+
+// statuses of the results of the rules
+const (
+	StatusPass  = "pass"
+	StatusFail  = "fail"
+	StatusError = "error"
+	StatusSkip  = "skip"
+)
+
+// RuleStatus returns the status of the rule response, error if the rule could not be applied
+func RuleStatus(rule response.RuleResponse) string {
+	switch {
+	case rule.Success:
+		return StatusPass
+	case rule.Error:
+		return StatusError
+	default:
+		return StatusFail
+	}
+}
+
+// ValidateOutput returns an error if the format is not one of the formats
+func ValidateOutput(format string, formats ...string) error {
+	for _, f := range formats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid output %q, must be one of %v", format, formats)
+}
+
+// WriteReport writes the report as JSON or YAML, the fields of the YAML are in the order of the JSON
+func WriteReport(w io.Writer, format string, report interface{}) error {
+	raw, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if format == OutputJSON {
+		_, err := fmt.Fprintf(w, "%s\n", raw)
+		return err
+	}
+	// a JSON document is a YAML document, the map slice keeps the order of the fields
+	var document yamlv2.MapSlice
+	if err := yamlv2.Unmarshal(raw, &document); err != nil {
+		return err
+	}
+	out, err := yamlv2.Marshal(document)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// JUnitTestSuites is a JUnit report, e.g. for the test reports of a CI pipeline
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite is a suite of test cases, e.g. of a file
+type JUnitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is a test case, it passes if it has no failure, no error and is not skipped
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitMessage `xml:"failure,omitempty"`
+	Error     *JUnitMessage `xml:"error,omitempty"`
+	Skipped   *JUnitMessage `xml:"skipped,omitempty"`
+}
+
+// JUnitMessage is the message of a failure, an error or a skipped test case
+type JUnitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// NewJUnitTestCase returns the test case with the status
+func NewJUnitTestCase(name, className, status, message string) JUnitTestCase {
+	testCase := JUnitTestCase{Name: name, ClassName: className}
+	switch status {
+	case StatusFail:
+		testCase.Failure = &JUnitMessage{Message: message}
+	case StatusError:
+		testCase.Error = &JUnitMessage{Message: message}
+	case StatusSkip:
+		testCase.Skipped = &JUnitMessage{Message: message}
+	}
+	return testCase
+}
+
+// WriteJUnit writes the JUnit report of the suites, the counts of the suites and of the report are computed from the cases
+func WriteJUnit(w io.Writer, suites []JUnitTestSuite) error {
+	report := JUnitTestSuites{Suites: suites}
+	for i := range report.Suites {
+		suite := &report.Suites[i]
+		suite.Tests, suite.Failures, suite.Errors, suite.Skipped = len(suite.Cases), 0, 0, 0
+		for _, testCase := range suite.Cases {
+			switch {
+			case testCase.Failure != nil:
+				suite.Failures++
+			case testCase.Error != nil:
+				suite.Errors++
+			case testCase.Skipped != nil:
+				suite.Skipped++
+			}
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
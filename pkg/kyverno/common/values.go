@@ -0,0 +1,158 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Values are the values of the variables of the rules, by path, e.g. request.operation or the name of a context entry
+type Values struct {
+	// Global are the values for all the resources
+	Global map[string]interface{} `json:"global,omitempty"`
+	// Resources are the values for a resource, they override the global values
+	Resources []ResourceValues `json:"resources,omitempty"`
+	// UserInfo is the user info of the request, for the match and exclude blocks and the request.userInfo variables
+	UserInfo *kyverno.RequestInfo `json:"userInfo,omitempty"`
+}
+
+// ResourceValues are the values of the variables for a resource
+type ResourceValues struct {
+	Name string `json:"name"`
+	// Kind and Namespace select the resource if several resources have the name
+	Kind      string                 `json:"kind,omitempty"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Values    map[string]interface{} `json:"values"`
+}
+
+// ForResource returns the values for the resource
+func (v Values) ForResource(resource unstructured.Unstructured) map[string]interface{} {
+	values := map[string]interface{}{}
+	for path, value := range v.Global {
+		values[path] = value
+	}
+	for _, r := range v.Resources {
+		if !MatchesResource(resource, r.Name, r.Kind, r.Namespace) {
+			continue
+		}
+		for path, value := range r.Values {
+			values[path] = value
+		}
+	}
+	return values
+}
+
+// MatchesResource returns true if the resource has the name, and the kind and the namespace if they are set
+func MatchesResource(resource unstructured.Unstructured, name, kind, namespace string) bool {
+	return resource.GetName() == name &&
+		(kind == "" || resource.GetKind() == kind) &&
+		(namespace == "" || resource.GetNamespace() == namespace)
+}
+
+// valuesJSON returns the values as a JSON object, the paths are split on the dots
+func valuesJSON(values map[string]interface{}) ([]byte, error) {
+	object := map[string]interface{}{}
+	for path, value := range values {
+		fields := strings.Split(path, ".")
+		m := object
+		for _, field := range fields[:len(fields)-1] {
+			child, ok := m[field].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				m[field] = child
+			}
+			m = child
+		}
+		m[fields[len(fields)-1]] = value
+	}
+	return json.Marshal(object)
+}
+
+// withValues returns the policy without the context entries that have a value,
+// the values are used instead of the data of the entries, e.g. of a ConfigMap
+func withValues(policy kyverno.ClusterPolicy, values map[string]interface{}) kyverno.ClusterPolicy {
+	if len(values) == 0 {
+		return policy
+	}
+	names := map[string]bool{}
+	for path := range values {
+		names[strings.Split(path, ".")[0]] = true
+	}
+	policy = *policy.DeepCopy()
+	for i, rule := range policy.Spec.Rules {
+		var entries []kyverno.ContextEntry
+		for _, entry := range rule.Context {
+			if !names[entry.Name] {
+				entries = append(entries, entry)
+			}
+		}
+		policy.Spec.Rules[i].Context = entries
+	}
+	return policy
+}
+
+// LoadValues returns the values of the YAML or JSON file, the unknown fields are errors
+func LoadValues(file string) (Values, error) {
+	values := Values{}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return values, err
+	}
+	raw, err := yaml.ToJSON(data)
+	if err != nil {
+		return values, fmt.Errorf("failed to decode the values of %s: %v", file, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&values); err != nil {
+		return values, fmt.Errorf("failed to decode the values of %s: %v", file, err)
+	}
+	return values, nil
+}
+
+// Set sets a value from an assignment [<resource>:]<path>=<value>, the resource is <name>, <kind>/<name> or
+// <kind>/<namespace>/<name>, the value is global without a resource. The value is parsed as YAML, e.g. 3 is a number.
+func (v *Values) Set(assignment string) error {
+	i := strings.Index(assignment, "=")
+	if i <= 0 {
+		return fmt.Errorf("invalid value %q, must be [<resource>:]<path>=<value>", assignment)
+	}
+	path, rawValue := assignment[:i], assignment[i+1:]
+	var value interface{} = rawValue
+	if raw, err := yaml.ToJSON([]byte(rawValue)); err == nil {
+		var parsed interface{}
+		if err := json.Unmarshal(raw, &parsed); err == nil && parsed != nil {
+			value = parsed
+		}
+	}
+
+	j := strings.Index(path, ":")
+	if j < 0 {
+		if v.Global == nil {
+			v.Global = map[string]interface{}{}
+		}
+		v.Global[path] = value
+		return nil
+	}
+	resource := ResourceValues{Values: map[string]interface{}{path[j+1:]: value}}
+	selector := strings.Split(path[:j], "/")
+	switch len(selector) {
+	case 1:
+		resource.Name = selector[0]
+	case 2:
+		resource.Kind, resource.Name = selector[0], selector[1]
+	case 3:
+		resource.Kind, resource.Namespace, resource.Name = selector[0], selector[1], selector[2]
+	}
+	if resource.Name == "" || path[j+1:] == "" || len(selector) > 3 {
+		return fmt.Errorf("invalid resource %q of value %q, must be <name>, <kind>/<name> or <kind>/<namespace>/<name>", path[:j], assignment)
+	}
+	v.Resources = append(v.Resources, resource)
+	return nil
+}
@@ -0,0 +1,142 @@
+package common
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newResource(kind, namespace, name string) unstructured.Unstructured {
+	resource := unstructured.Unstructured{}
+	resource.SetAPIVersion("v1")
+	resource.SetKind(kind)
+	resource.SetNamespace(namespace)
+	resource.SetName(name)
+	return resource
+}
+
+func Test_SetValues(t *testing.T) {
+	values := Values{}
+	assert.NilError(t, values.Set("request.operation=UPDATE"))
+	assert.NilError(t, values.Set("replicas=3"))
+	assert.NilError(t, values.Set("nginx:request.namespace=prod"))
+	assert.NilError(t, values.Set("Pod/nginx:enabled=true"))
+	assert.NilError(t, values.Set("Pod/dev/nginx:request.namespace=dev"))
+	assert.NilError(t, values.Set("selector=app=web"))
+
+	assert.DeepEqual(t, values.Global, map[string]interface{}{"request.operation": "UPDATE", "replicas": float64(3), "selector": "app=web"})
+	assert.DeepEqual(t, values.Resources, []ResourceValues{
+		{Name: "nginx", Values: map[string]interface{}{"request.namespace": "prod"}},
+		{Name: "nginx", Kind: "Pod", Values: map[string]interface{}{"enabled": true}},
+		{Name: "nginx", Kind: "Pod", Namespace: "dev", Values: map[string]interface{}{"request.namespace": "dev"}},
+	})
+
+	// the values of the resources override the global values, in their order
+	assert.DeepEqual(t, values.ForResource(newResource("Pod", "dev", "nginx")), map[string]interface{}{
+		"request.operation": "UPDATE", "replicas": float64(3), "selector": "app=web", "request.namespace": "dev", "enabled": true,
+	})
+	assert.DeepEqual(t, values.ForResource(newResource("Deployment", "prod", "nginx")), map[string]interface{}{
+		"request.operation": "UPDATE", "replicas": float64(3), "selector": "app=web", "request.namespace": "prod",
+	})
+
+	for _, invalid := range []string{"request.operation", "=UPDATE", "nginx:=UPDATE", ":key=value", "a/b/c/d:key=value"} {
+		assert.Assert(t, values.Set(invalid) != nil, invalid)
+	}
+}
+
+func Test_LoadValues(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kyverno-values")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "values.yaml")
+	assert.NilError(t, ioutil.WriteFile(file, []byte(`global:
+  request.operation: CREATE
+resources:
+- name: nginx
+  values:
+    request.namespace: prod
+userInfo:
+  clusterRoles: [cluster-admin]
+  userInfo:
+    username: alice
+`), 0644))
+	values, err := LoadValues(file)
+	assert.NilError(t, err)
+	assert.Equal(t, values.Global["request.operation"], "CREATE")
+	assert.Equal(t, values.Resources[0].Values["request.namespace"], "prod")
+	assert.DeepEqual(t, values.UserInfo.ClusterRoles, []string{"cluster-admin"})
+	assert.Equal(t, values.UserInfo.AdmissionUserInfo.Username, "alice")
+
+	assert.NilError(t, ioutil.WriteFile(file, []byte("globals: {}\n"), 0644))
+	_, err = LoadValues(file)
+	assert.ErrorContains(t, err, "unknown field")
+}
+
+func Test_ApplyPoliciesWithValues(t *testing.T) {
+	policies, err := DecodePolicies([]byte(`apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: values
+spec:
+  rules:
+  - name: check-namespace
+    context:
+    - name: settings
+      configMap:
+        name: settings
+        namespace: kyverno
+    match:
+      resources:
+        kinds: [ConfigMap]
+    validate:
+      message: "the namespace must be {{settings.data.namespace}}"
+      pattern:
+        metadata:
+          annotations:
+            namespace: "{{request.namespace}}"
+            owner: "{{settings.data.owner}}"
+  - name: check-admins
+    match:
+      resources:
+        kinds: [ConfigMap]
+      clusterRoles: [cluster-admin]
+    validate:
+      pattern:
+        metadata:
+          annotations:
+            creator: "{{request.userInfo.username}}"
+`))
+	assert.NilError(t, err)
+	resource := newResource("ConfigMap", "", "settings")
+	resource.SetAnnotations(map[string]string{"namespace": "prod", "owner": "platform", "creator": "alice"})
+
+	values := Values{}
+	assert.NilError(t, values.Set("request.namespace=prod"))
+	assert.NilError(t, values.Set("settings:settings.data.owner=platform"))
+	values.UserInfo, err = decodeRequestInfo(`{"userInfo": {"username": "bob"}}`)
+	assert.NilError(t, err)
+	_, engineResponses := ApplyPolicies(policies, resource, values)
+	rules := engineResponses[1].PolicyResponse.Rules
+	// the rule check-admins does not match the user bob
+	assert.Equal(t, len(rules), 1)
+	assert.Equal(t, rules[0].Name, "check-namespace")
+	assert.Assert(t, rules[0].Success, rules[0].Message)
+
+	values.UserInfo, err = decodeRequestInfo(`{"clusterRoles": ["cluster-admin"], "userInfo": {"username": "alice"}}`)
+	assert.NilError(t, err)
+	_, engineResponses = ApplyPolicies(policies, resource, values)
+	rules = engineResponses[1].PolicyResponse.Rules
+	assert.Equal(t, len(rules), 2)
+	assert.Assert(t, rules[1].Success, rules[1].Message)
+}
+
+func decodeRequestInfo(raw string) (*kyverno.RequestInfo, error) {
+	info := &kyverno.RequestInfo{}
+	return info, json.Unmarshal([]byte(raw), info)
+}
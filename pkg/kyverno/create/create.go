@@ -0,0 +1,231 @@
+package create
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"github.com/spf13/cobra"
+	yamlv2 "gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	memory "k8s.io/client-go/discovery/cached/memory"
+	dynamic "k8s.io/client-go/dynamic"
+	kubernetes "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+)
+
+const (
+	createPolicyExample = `  # Create a policy that requires the labels of a Deployment, and check it on the Deployment.
+  kyverno create policy --resource deployment.yaml > require-labels.yaml
+  kyverno apply require-labels.yaml --resource deployment.yaml
+
+  # Require the values of fields of a Pod of the cluster, or only their presence with --wildcard.
+  kyverno create policy --from pod/nginx -n default --field spec.containers.image --field spec.securityContext.runAsNonRoot
+  kyverno create policy --from pod/nginx -n default --field spec.containers.resources.limits --wildcard
+
+  # Create a policy that generates a ConfigMap in the new namespaces.
+  kyverno create policy --type generate --resource configmap.yaml`
+
+	typeValidate = "validate"
+	typeGenerate = "generate"
+
+	// wildcard is the pattern of a required non-empty value
+	wildcard = "?*"
+)
+
+// options of the policy to create from a resource
+type options struct {
+	Type   string
+	Name   string
+	Fields []string
+	// Wildcard replaces the values of the fields by a wildcard in the pattern
+	Wildcard bool
+}
+
+// NewCmdCreate returns the create command, it scaffolds the manifests of kyverno
+func NewCmdCreate(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create the skeleton of a policy from a resource",
+	}
+	cmd.AddCommand(newCmdCreatePolicy(out))
+	return cmd
+}
+
+func newCmdCreatePolicy(out io.Writer) *cobra.Command {
+	var resourceFile, from string
+	o := options{}
+	configFlags := &common.ConfigFlags{}
+	cmd := &cobra.Command{
+		Use:     "policy (--resource <file> | --from <kind>/<name>)",
+		Short:   "Create a validate or generate ClusterPolicy for the kind of a resource, with a pattern of its fields",
+		Example: createPolicyExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			var resource *unstructured.Unstructured
+			var err error
+			switch {
+			case resourceFile != "" && from != "":
+				err = fmt.Errorf("set either --resource or --from")
+			case resourceFile != "":
+				resource, err = loadResource(resourceFile)
+			case from != "":
+				resource, err = getResource(configFlags, from)
+			default:
+				err = fmt.Errorf("missing resource, set --resource or --from")
+			}
+			if err != nil {
+				glog.Errorf("Failed to load the resource: %v", err)
+				os.Exit(common.ExitError)
+			}
+			policy, err := newPolicy(resource, o)
+			if err != nil {
+				glog.Errorf("Failed to create the policy: %v", err)
+				os.Exit(common.ExitError)
+			}
+			data, err := yamlv2.Marshal(policy)
+			if err != nil {
+				glog.Errorf("Failed to write the policy: %v", err)
+				os.Exit(common.ExitError)
+			}
+			fmt.Fprintf(out, "# created from %s\n%s", common.ResourceKey(*resource), data)
+		},
+	}
+	cmd.Flags().StringVarP(&resourceFile, "resource", "r", "", "file of the resource, the first resource of the file")
+	cmd.Flags().StringVar(&from, "from", "", "<kind>/<name> of the resource of the cluster, e.g. deployment/web or deployments.apps/web")
+	cmd.Flags().StringVar(&o.Type, "type", typeValidate, "type of the rule: validate for a pattern of the fields, generate to generate the resource in the new namespaces")
+	cmd.Flags().StringVar(&o.Name, "name", "", "name of the policy, <type>-<kind> if not set")
+	cmd.Flags().StringArrayVar(&o.Fields, "field", nil, "path of a field of the resource, e.g. spec.containers.image, the lists are traversed, can be repeated, metadata.labels if not set")
+	cmd.Flags().BoolVar(&o.Wildcard, "wildcard", false, "require a non-empty value for the fields rather than their values")
+	configFlags.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// loadResource returns the first resource of the file
+func loadResource(file string) (*unstructured.Unstructured, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	for _, document := range common.SplitDocuments(data) {
+		resource, err := common.DecodeResource(document.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the document at %s:%d: %v", file, document.StartLine(), err)
+		}
+		if resource != nil {
+			return resource, nil
+		}
+	}
+	return nil, fmt.Errorf("no resource in %s", file)
+}
+
+// getResource returns the resource <kind>/<name> of the cluster, in the namespace of the config flags if it is namespaced
+func getResource(configFlags *common.ConfigFlags, from string) (*unstructured.Unstructured, error) {
+	i := strings.Index(from, "/")
+	if i <= 0 || i == len(from)-1 {
+		return nil, fmt.Errorf("invalid resource %s, expected <kind>/<name>", from)
+	}
+	clientConfig, err := configFlags.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	kclient, err := kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(kclient.Discovery()))
+	groupResource := schema.ParseGroupResource(strings.ToLower(from[:i]))
+	gvk, err := mapper.KindFor(groupResource.WithVersion(""))
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		return dynamicClient.Resource(mapping.Resource).Get(from[i+1:], metav1.GetOptions{})
+	}
+	namespace, err := configFlags.ToNamespace()
+	if err != nil {
+		return nil, err
+	}
+	return dynamicClient.Resource(mapping.Resource).Namespace(namespace).Get(from[i+1:], metav1.GetOptions{})
+}
+
+// newPolicy returns the ClusterPolicy of the resource, with a rule of the type of the options
+func newPolicy(resource *unstructured.Unstructured, o options) (yamlv2.MapSlice, error) {
+	kind := resource.GetKind()
+	if kind == "" {
+		return nil, fmt.Errorf("the resource has no kind")
+	}
+	name := o.Name
+	if name == "" {
+		name = o.Type + "-" + strings.ToLower(kind)
+	}
+
+	var rule yamlv2.MapSlice
+	switch o.Type {
+	case typeValidate:
+		fields := o.Fields
+		if len(fields) == 0 {
+			fields = []string{"metadata.labels"}
+		}
+		pattern, err := newPattern(resource.Object, fields, o.Wildcard)
+		if err != nil {
+			return nil, err
+		}
+		rule = yamlv2.MapSlice{
+			{Key: "name", Value: "check-" + strings.ToLower(kind)},
+			{Key: "match", Value: matchKinds(kind)},
+			{Key: "validate", Value: yamlv2.MapSlice{
+				{Key: "message", Value: fmt.Sprintf("The fields of the %s must match the pattern.", kind)},
+				{Key: "pattern", Value: pattern},
+			}},
+		}
+	case typeGenerate:
+		data, err := generateData(resource.Object, o.Fields)
+		if err != nil {
+			return nil, err
+		}
+		generate := yamlv2.MapSlice{
+			{Key: "kind", Value: kind},
+			{Key: "name", Value: resource.GetName()},
+			{Key: "namespace", Value: "{{request.object.metadata.name}}"},
+			{Key: "data", Value: data},
+		}
+		rule = yamlv2.MapSlice{
+			{Key: "name", Value: "generate-" + strings.ToLower(kind)},
+			{Key: "match", Value: matchKinds("Namespace")},
+			{Key: "generate", Value: generate},
+		}
+	default:
+		return nil, fmt.Errorf("invalid type %s, expected %s or %s", o.Type, typeValidate, typeGenerate)
+	}
+
+	return yamlv2.MapSlice{
+		{Key: "apiVersion", Value: "kyverno.io/v1"},
+		{Key: "kind", Value: "ClusterPolicy"},
+		{Key: "metadata", Value: yamlv2.MapSlice{{Key: "name", Value: name}}},
+		{Key: "spec", Value: yamlv2.MapSlice{
+			// the starter policies report the violations, without blocking the requests
+			{Key: "validationFailureAction", Value: "audit"},
+			{Key: "rules", Value: []interface{}{rule}},
+		}},
+	}, nil
+}
+
+func matchKinds(kind string) yamlv2.MapSlice {
+	return yamlv2.MapSlice{{Key: "resources", Value: yamlv2.MapSlice{{Key: "kinds", Value: []string{kind}}}}}
+}
@@ -0,0 +1,146 @@
+package create
+
+import (
+	"testing"
+
+	policyvalidate "github.com/nirmata/kyverno/pkg/engine/policy"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	yamlv2 "gopkg.in/yaml.v2"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testDeployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+  labels:
+    app: web
+    team: platform
+spec:
+  replicas: 2
+  template:
+    spec:
+      securityContext:
+        runAsNonRoot: true
+      containers:
+      - name: nginx
+        image: nginx:1.19
+        ports:
+        - containerPort: 80
+      - name: sidecar
+        image: envoy:1.16
+        ports:
+        - containerPort: 80
+status:
+  replicas: 2
+`
+
+// validationStatus returns the status of the rule of the validation response, the last response
+func validationStatus(responses []response.EngineResponse) string {
+	return common.RuleStatus(responses[len(responses)-1].PolicyResponse.Rules[0])
+}
+
+// createPolicy returns the policy created from the resource, decoded and validated as a ClusterPolicy
+func createPolicy(t *testing.T, resource *unstructured.Unstructured, o options) []byte {
+	policy, err := newPolicy(resource, o)
+	assert.NilError(t, err)
+	data, err := yamlv2.Marshal(policy)
+	assert.NilError(t, err)
+	policies, err := common.DecodePolicies(data)
+	assert.NilError(t, err)
+	assert.Equal(t, len(policies), 1)
+	assert.NilError(t, policyvalidate.Validate(policies[0]))
+	return data
+}
+
+func Test_CreateValidatePolicy(t *testing.T) {
+	resource, err := common.DecodeResource([]byte(testDeployment))
+	assert.NilError(t, err)
+
+	data := createPolicy(t, resource, options{
+		Type:   typeValidate,
+		Fields: []string{"metadata.labels.team", "spec.template.spec.containers.image", "spec.template.spec.containers.ports", "spec.replicas"},
+	})
+	expected := `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: validate-deployment
+spec:
+  validationFailureAction: audit
+  rules:
+  - name: check-deployment
+    match:
+      resources:
+        kinds:
+        - Deployment
+    validate:
+      message: The fields of the Deployment must match the pattern.
+      pattern:
+        metadata:
+          labels:
+            team: platform
+        spec:
+          replicas: 2
+          template:
+            spec:
+              containers:
+              - image: ?*
+                ports:
+                - containerPort: 80
+`
+	assert.Equal(t, string(data), expected)
+
+	// the policy passes on its resource, and fails if a field changes
+	policies, err := common.DecodePolicies(data)
+	assert.NilError(t, err)
+	_, responses := common.ApplyPolicies(policies, *resource, common.Values{})
+	assert.Equal(t, validationStatus(responses), common.StatusPass)
+	assert.NilError(t, unstructured.SetNestedField(resource.Object, "apps", "metadata", "labels", "team"))
+	_, responses = common.ApplyPolicies(policies, *resource, common.Values{})
+	assert.Equal(t, validationStatus(responses), common.StatusFail)
+
+	// the labels are required by default, with wildcards
+	policy, err := newPolicy(resource, options{Type: typeValidate, Name: "require-labels", Wildcard: true})
+	assert.NilError(t, err)
+	data, err = yamlv2.Marshal(policy)
+	assert.NilError(t, err)
+	policies, err = common.DecodePolicies(data)
+	assert.NilError(t, err)
+	assert.Equal(t, policies[0].Name, "require-labels")
+	assert.DeepEqual(t, policies[0].Spec.Rules[0].Validation.Pattern, map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "?*", "team": "?*"}},
+	})
+
+	_, err = newPolicy(resource, options{Type: typeValidate, Fields: []string{"spec.template.spec.volumes"}})
+	assert.ErrorContains(t, err, "field spec.template.spec.volumes: volumes not found")
+	_, err = newPolicy(resource, options{Type: "mutate"})
+	assert.ErrorContains(t, err, "invalid type mutate")
+}
+
+func Test_CreateGeneratePolicy(t *testing.T) {
+	resource, err := common.DecodeResource([]byte(testDeployment))
+	assert.NilError(t, err)
+
+	data := createPolicy(t, resource, options{Type: typeGenerate})
+	policies, err := common.DecodePolicies(data)
+	assert.NilError(t, err)
+	rule := policies[0].Spec.Rules[0]
+	assert.DeepEqual(t, rule.MatchResources.Kinds, []string{"Namespace"})
+	assert.Equal(t, rule.Generation.Kind, "Deployment")
+	assert.Equal(t, rule.Generation.Name, "web")
+	assert.Equal(t, rule.Generation.Namespace, "{{request.object.metadata.name}}")
+	// the data has the labels and the spec, without the status and the name
+	generated := rule.Generation.Data.(map[string]interface{})
+	assert.DeepEqual(t, generated["metadata"], map[string]interface{}{"labels": map[string]interface{}{"app": "web", "team": "platform"}})
+	assert.Assert(t, generated["spec"] != nil)
+	assert.Assert(t, generated["status"] == nil)
+
+	data = createPolicy(t, resource, options{Type: typeGenerate, Fields: []string{"spec.replicas"}})
+	policies, err = common.DecodePolicies(data)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policies[0].Spec.Rules[0].Generation.Data, map[string]interface{}{"spec": map[string]interface{}{"replicas": float64(2)}})
+}
@@ -0,0 +1,171 @@
+package create
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// newPattern returns the validation pattern of the fields of the resource, merged,
+// the lists are traversed and their elements are matched by a single pattern
+func newPattern(resource map[string]interface{}, fields []string, wildcards bool) (interface{}, error) {
+	var pattern interface{}
+	for _, field := range fields {
+		value, err := selectField(resource, strings.Split(field, "."))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", field, err)
+		}
+		if wildcards {
+			value = toWildcards(value)
+		}
+		pattern = merge(pattern, collapseLists(value))
+	}
+	return pattern, nil
+}
+
+// generateData returns the data of the generated resource, the fields of the resource or all its fields
+// without its kind, name, namespace, status and server-side metadata
+func generateData(resource map[string]interface{}, fields []string) (interface{}, error) {
+	if len(fields) > 0 {
+		var data interface{}
+		for _, field := range fields {
+			value, err := selectField(resource, strings.Split(field, "."))
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %v", field, err)
+			}
+			data = merge(data, value)
+		}
+		return data, nil
+	}
+	data := map[string]interface{}{}
+	for key, value := range resource {
+		switch key {
+		case "apiVersion", "kind", "status":
+		case "metadata":
+			metadata, _ := value.(map[string]interface{})
+			kept := map[string]interface{}{}
+			for _, key := range []string{"labels", "annotations"} {
+				if value, ok := metadata[key]; ok {
+					kept[key] = value
+				}
+			}
+			if len(kept) > 0 {
+				data[key] = kept
+			}
+		default:
+			data[key] = value
+		}
+	}
+	return data, nil
+}
+
+// selectField returns the value at the path in its parents, the path of the elements of the lists is traversed
+func selectField(value interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		child, ok := typed[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("%s not found", path[0])
+		}
+		selected, err := selectField(child, path[1:])
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{path[0]: selected}, nil
+	case []interface{}:
+		var elements []interface{}
+		for _, element := range typed {
+			selected, err := selectField(element, path)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, selected)
+		}
+		if len(elements) == 0 {
+			return nil, fmt.Errorf("%s not found in an empty list", path[0])
+		}
+		return elements, nil
+	default:
+		return nil, fmt.Errorf("%s not found in a value", path[0])
+	}
+}
+
+// collapseLists returns the pattern of the value, a list is matched by a single pattern of its elements,
+// the values that differ between the elements are wildcards
+func collapseLists(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		pattern := map[string]interface{}{}
+		for key, child := range typed {
+			pattern[key] = collapseLists(child)
+		}
+		return pattern
+	case []interface{}:
+		var element interface{}
+		for i, child := range typed {
+			child = collapseLists(child)
+			if i == 0 {
+				element = child
+			} else if !reflect.DeepEqual(element, child) {
+				element = merge(toWildcards(element), toWildcards(child))
+			}
+		}
+		return []interface{}{element}
+	default:
+		return value
+	}
+}
+
+// toWildcards returns the value with a wildcard for each value
+func toWildcards(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		pattern := map[string]interface{}{}
+		for key, child := range typed {
+			pattern[key] = toWildcards(child)
+		}
+		return pattern
+	case []interface{}:
+		var elements []interface{}
+		for _, child := range typed {
+			elements = append(elements, toWildcards(child))
+		}
+		return elements
+	default:
+		return wildcard
+	}
+}
+
+// merge returns the union of the maps, the lists are merged element by element and b overrides the other values
+func merge(a, b interface{}) interface{} {
+	switch typedB := b.(type) {
+	case map[string]interface{}:
+		typedA, ok := a.(map[string]interface{})
+		if !ok {
+			return b
+		}
+		merged := map[string]interface{}{}
+		for key, value := range typedA {
+			merged[key] = value
+		}
+		for key, value := range typedB {
+			merged[key] = merge(typedA[key], value)
+		}
+		return merged
+	case []interface{}:
+		typedA, ok := a.([]interface{})
+		if !ok || len(typedA) != len(typedB) {
+			return b
+		}
+		merged := make([]interface{}, len(typedB))
+		for i := range typedB {
+			merged[i] = merge(typedA[i], typedB[i])
+		}
+		return merged
+	default:
+		return b
+	}
+}
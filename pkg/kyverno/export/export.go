@@ -0,0 +1,78 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"github.com/nirmata/kyverno/pkg/vap"
+	"github.com/spf13/cobra"
+	yamlv2 "gopkg.in/yaml.v2"
+)
+
+const exportVAPExample = `  # Export the validate rules of the policies as ValidatingAdmissionPolicies, and create them in the cluster.
+  kyverno export vap policies.yaml > vap.yaml
+  kubectl apply -f vap.yaml`
+
+// NewCmdExport returns the export command, it converts the policies to the resources of other admission controllers
+func NewCmdExport(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Convert the Kyverno policies to the policies of other admission controllers",
+	}
+	cmd.AddCommand(newCmdExportVAP(out))
+	return cmd
+}
+
+func newCmdExportVAP(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "vap <file or directory>...",
+		Short:   "Convert the validate rules of the policies to ValidatingAdmissionPolicies and their bindings, with comments on the rules that cannot be converted",
+		Example: exportVAPExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				glog.Error("missing policies, set the files of the policies")
+				os.Exit(common.ExitError)
+			}
+			policies, err := common.LoadPolicies(args)
+			if err != nil {
+				glog.Errorf("Failed to load the policies: %v", err)
+				os.Exit(common.ExitError)
+			}
+			if err := writeAdmissionPolicies(out, policies); err != nil {
+				glog.Errorf("Failed to write the ValidatingAdmissionPolicies: %v", err)
+				os.Exit(common.ExitError)
+			}
+		},
+	}
+	return cmd
+}
+
+// writeAdmissionPolicies writes the ValidatingAdmissionPolicies and the bindings of the policies,
+// the validate rules that are not converted are commented
+func writeAdmissionPolicies(out io.Writer, policies []kyverno.ClusterPolicy) error {
+	var written int
+	for _, policy := range policies {
+		resources, skipped := vap.Generate(policy)
+		for _, s := range skipped {
+			fmt.Fprintf(out, "# not converted: %s\n", s)
+		}
+		for _, resource := range resources {
+			data, err := yamlv2.Marshal(resource.Object)
+			if err != nil {
+				return err
+			}
+			if written > 0 {
+				fmt.Fprintf(out, "---\n")
+			}
+			if _, err := out.Write(data); err != nil {
+				return err
+			}
+			written++
+		}
+	}
+	return nil
+}
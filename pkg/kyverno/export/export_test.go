@@ -0,0 +1,86 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"gotest.tools/assert"
+)
+
+const testPolicies = `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: disallow-latest-tag
+spec:
+  validationFailureAction: audit
+  rules:
+  - name: validate-image-tag
+    match:
+      resources:
+        kinds:
+        - Pod
+    validate:
+      message: Using a mutable image tag e.g. 'latest' is not allowed.
+      pattern:
+        spec:
+          containers:
+          - image: "!*:latest"
+  - name: validate-registry
+    match:
+      resources:
+        kinds:
+        - Pod
+    validate:
+      pattern:
+        spec:
+          containers:
+          - image: "{{registry}}/*"
+`
+
+func Test_ExportVAP(t *testing.T) {
+	policies, err := common.DecodePolicies([]byte(testPolicies))
+	assert.NilError(t, err)
+	var out bytes.Buffer
+	assert.NilError(t, writeAdmissionPolicies(&out, policies))
+	assert.Equal(t, out.String(), `# not converted: disallow-latest-tag/validate-registry: container.image: the variables and the references are not supported
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  annotations:
+    policies.kyverno.io/source-rule: disallow-latest-tag/validate-image-tag
+  labels:
+    app.kubernetes.io/managed-by: kyverno
+  name: disallow-latest-tag-validate-image-tag
+spec:
+  failurePolicy: Fail
+  matchConstraints:
+    resourceRules:
+    - apiGroups:
+      - ""
+      apiVersions:
+      - v1
+      operations:
+      - CREATE
+      - UPDATE
+      resources:
+      - pods
+  validations:
+  - expression: has(object.spec) && has(object.spec.containers) && object.spec.containers.all(container,
+      has(container.image) && !(container.image.matches('^.*:latest$')))
+    message: Using a mutable image tag e.g. 'latest' is not allowed.
+---
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata:
+  annotations:
+    policies.kyverno.io/source-rule: disallow-latest-tag/validate-image-tag
+  labels:
+    app.kubernetes.io/managed-by: kyverno
+  name: disallow-latest-tag-validate-image-tag-binding
+spec:
+  policyName: disallow-latest-tag-validate-image-tag
+  validationActions:
+  - Audit
+`)
+}
@@ -0,0 +1,145 @@
+package jp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/golang/glog"
+	"github.com/nirmata/kyverno/pkg/engine/jmespath"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	jpExample = `  # Evaluate an expression on a resource, as the variables of the rules.
+  kyverno jp "spec.containers[].image" --input pod.yaml
+  kubectl get pod nginx -o yaml | kyverno jp "metadata.labels.app"
+
+  # Use the functions added by kyverno, and print the strings without quotes.
+  kyverno jp -u "split(metadata.name, '-')[0]" --input pod.yaml
+
+  # List the functions, the functions added by kyverno are marked.
+  kyverno jp --list-functions`
+
+	// stdinPath is the input path of the standard input
+	stdinPath = "-"
+)
+
+// NewCmdJp returns the jp command, it evaluates a JMESPath expression on the documents of a file,
+// with the functions of the policies
+func NewCmdJp(in io.Reader, out io.Writer) *cobra.Command {
+	var input string
+	var unquoted, ast, listFunctions bool
+	cmd := &cobra.Command{
+		Use:     "jp <expression>",
+		Short:   "Evaluate a JMESPath expression on a JSON or YAML document, with the functions of the policies",
+		Example: jpExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if listFunctions {
+				printFunctions(out)
+				return
+			}
+			if len(args) != 1 {
+				glog.Errorf("expected one expression, got %d arguments", len(args))
+				os.Exit(common.ExitError)
+			}
+			expression := trimVariable(args[0])
+			if ast {
+				node, err := jmespath.Parse(expression)
+				if err != nil {
+					glog.Errorf("Failed to parse %s: %v", expression, err)
+					os.Exit(common.ExitError)
+				}
+				fmt.Fprint(out, node)
+				return
+			}
+			data, err := readInput(in, input)
+			if err != nil {
+				glog.Errorf("Failed to read the input: %v", err)
+				os.Exit(common.ExitError)
+			}
+			if err := evaluate(out, expression, data, unquoted); err != nil {
+				glog.Errorf("%v", err)
+				os.Exit(common.ExitError)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&input, "input", "i", stdinPath, "JSON or YAML file of the documents, - for the standard input")
+	cmd.Flags().BoolVarP(&unquoted, "unquoted", "u", false, "print the strings without quotes")
+	cmd.Flags().BoolVar(&ast, "ast", false, "print the syntax tree of the expression, without evaluating it")
+	cmd.Flags().BoolVar(&listFunctions, "list-functions", false, "list the signatures of the functions")
+	return cmd
+}
+
+// trimVariable returns the expression of a variable of a policy, {{ expression }}
+func trimVariable(expression string) string {
+	trimmed := strings.TrimSpace(expression)
+	if strings.HasPrefix(trimmed, "{{") && strings.HasSuffix(trimmed, "}}") {
+		return strings.TrimSpace(trimmed[2 : len(trimmed)-2])
+	}
+	return expression
+}
+
+func readInput(in io.Reader, path string) ([]byte, error) {
+	if path == stdinPath {
+		return ioutil.ReadAll(in)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// evaluate writes the result of the expression on each document of the data, as JSON,
+// the empty documents are skipped
+func evaluate(out io.Writer, expression string, data []byte, unquoted bool) error {
+	query, err := jmespath.Compile(expression)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", expression, err)
+	}
+	for _, document := range common.SplitDocuments(data) {
+		raw, err := yaml.ToJSON(document.Data)
+		if err != nil {
+			return fmt.Errorf("failed to decode the document at line %d: %v", document.StartLine(), err)
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return fmt.Errorf("failed to decode the document at line %d: %v", document.StartLine(), err)
+		}
+		result, err := query.Search(value)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate %s on the document at line %d: %v", expression, document.StartLine(), err)
+		}
+		if s, ok := result.(string); ok && unquoted {
+			fmt.Fprintln(out, s)
+			continue
+		}
+		output, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(output))
+	}
+	return nil
+}
+
+// printFunctions writes the signatures of the functions, the functions added by kyverno are marked
+func printFunctions(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	for _, function := range jmespath.Functions() {
+		origin := "jmespath"
+		if function.Custom {
+			origin = "kyverno"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", function.Signature, origin)
+	}
+	w.Flush()
+}
@@ -0,0 +1,53 @@
+package jp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+const testDocuments = `# the pods
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nginx-web
+spec:
+  containers:
+  - name: nginx
+    image: nginx:1.19
+---
+{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "redis-cache"}, "spec": {"containers": [{"name": "redis", "image": "redis:6"}]}}
+---
+`
+
+func Test_Evaluate(t *testing.T) {
+	var out bytes.Buffer
+	assert.NilError(t, evaluate(&out, "spec.containers[].image", []byte(testDocuments), false))
+	assert.Equal(t, out.String(), "[\n  \"nginx:1.19\"\n]\n[\n  \"redis:6\"\n]\n")
+
+	// the functions added by kyverno, with the strings unquoted
+	out.Reset()
+	assert.NilError(t, evaluate(&out, trimVariable("{{ split(metadata.name, '-')[0] }}"), []byte(testDocuments), true))
+	assert.Equal(t, out.String(), "nginx\nredis\n")
+
+	err := evaluate(&out, "metadata.[", []byte(testDocuments), false)
+	assert.ErrorContains(t, err, "failed to parse metadata.[")
+	err = evaluate(&out, "split(metadata.name)", []byte(testDocuments), false)
+	assert.ErrorContains(t, err, "document at line 1")
+}
+
+func Test_PrintFunctions(t *testing.T) {
+	var out bytes.Buffer
+	printFunctions(&out)
+	assert.Assert(t, strings.Contains(out.String(), "\nsplit(string, string)  "), out.String())
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if strings.HasPrefix(line, "split(") {
+			assert.Assert(t, strings.HasSuffix(line, "kyverno"), line)
+		}
+		if strings.HasPrefix(line, "length(") {
+			assert.Assert(t, strings.HasSuffix(line, "jmespath"), line)
+		}
+	}
+}
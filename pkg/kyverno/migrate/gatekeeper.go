@@ -0,0 +1,84 @@
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/golang/glog"
+	"github.com/nirmata/kyverno/pkg/gatekeeper"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const migrateGatekeeperExample = `  # Convert the constraints of the cluster, with their templates to report the templates that are not supported.
+  kubectl get constrainttemplates -o yaml > templates.yaml
+  kubectl get constraints -o yaml > constraints.yaml
+  kyverno migrate gatekeeper templates.yaml constraints.yaml > policies.yaml`
+
+func newCmdMigrateGatekeeper(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "gatekeeper <file or directory>...",
+		Short:   "Convert the Gatekeeper constraints of the templates of the Gatekeeper library to ClusterPolicies",
+		Example: migrateGatekeeperExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				glog.Error("missing constraints, set the files of the constraints")
+				os.Exit(common.ExitError)
+			}
+			resources, err := loadResources(args)
+			if err != nil {
+				glog.Errorf("Failed to load the constraints: %v", err)
+				os.Exit(common.ExitError)
+			}
+			if err := writeConstraintPolicies(out, resources); err != nil {
+				glog.Errorf("Failed to convert the constraints: %v", err)
+				os.Exit(common.ExitError)
+			}
+		},
+	}
+	return cmd
+}
+
+// writeConstraintPolicies writes the ClusterPolicies of the constraints of the resources, the templates and the constraints
+// that are not supported are commented, the resources of the other groups are skipped
+func writeConstraintPolicies(out io.Writer, resources []unstructured.Unstructured) error {
+	var constraints []unstructured.Unstructured
+	var found bool
+	for _, resource := range resources {
+		switch resource.GroupVersionKind().Group {
+		case gatekeeper.TemplatesGroup:
+			found = true
+			kind, _, _ := unstructured.NestedString(resource.Object, "spec", "crd", "spec", "names", "kind")
+			if !gatekeeper.Supported(kind) {
+				fmt.Fprintf(out, "# not converted: ConstraintTemplate %s, the Rego of the template cannot be converted\n", resource.GetName())
+			}
+		case gatekeeper.ConstraintsGroup:
+			found = true
+			constraints = append(constraints, resource)
+		default:
+			glog.V(3).Infof("skipping %s %s, not a Gatekeeper constraint", resource.GetKind(), resource.GetName())
+		}
+	}
+	if !found {
+		return fmt.Errorf("no Gatekeeper constraint or template in the files")
+	}
+	var written int
+	for _, constraint := range constraints {
+		source := fmt.Sprintf("%s %s", constraint.GetKind(), constraint.GetName())
+		policy, warnings, err := gatekeeper.Convert(constraint)
+		if err != nil {
+			fmt.Fprintf(out, "# not converted: %s, %v\n", source, err)
+			continue
+		}
+		if written > 0 {
+			fmt.Fprintf(out, "---\n")
+		}
+		if err := writePolicy(out, policy, source, warnings); err != nil {
+			return err
+		}
+		written++
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package migrate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"gotest.tools/assert"
+)
+
+const testTemplates = `apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8srequiredlabels
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sRequiredLabels
+  targets:
+  - target: admission.k8s.gatekeeper.sh
+    rego: |
+      package k8srequiredlabels
+---
+apiVersion: templates.gatekeeper.sh/v1beta1
+kind: ConstraintTemplate
+metadata:
+  name: k8scontainerlimits
+spec:
+  crd:
+    spec:
+      names:
+        kind: K8sContainerLimits
+  targets:
+  - target: admission.k8s.gatekeeper.sh
+    rego: |
+      package k8scontainerlimits
+`
+
+// testConstraints is the output of kubectl get constraints -o yaml
+const testConstraints = `apiVersion: v1
+kind: List
+items:
+- apiVersion: constraints.gatekeeper.sh/v1beta1
+  kind: K8sContainerLimits
+  metadata:
+    name: container-must-have-limits
+  spec:
+    match:
+      kinds:
+      - apiGroups: [""]
+        kinds: ["Pod"]
+    parameters:
+      cpu: "200m"
+      memory: "1Gi"
+- apiVersion: constraints.gatekeeper.sh/v1beta1
+  kind: K8sRequiredLabels
+  metadata:
+    name: all-must-have-owner
+  spec:
+    match:
+      kinds:
+      - apiGroups: [""]
+        kinds: ["Namespace"]
+    parameters:
+      labels:
+      - key: owner
+        allowedRegex: "^[a-z]+$"
+`
+
+func Test_MigrateGatekeeper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gatekeeper")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "templates.yaml"), []byte(testTemplates), 0644))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "constraints.yaml"), []byte(testConstraints), 0644))
+
+	resources, err := loadResources([]string{dir})
+	assert.NilError(t, err)
+	assert.Equal(t, len(resources), 4)
+	var out bytes.Buffer
+	assert.NilError(t, writeConstraintPolicies(&out, resources))
+	assert.Equal(t, out.String(), `# not converted: ConstraintTemplate k8scontainerlimits, the Rego of the template cannot be converted
+# not converted: K8sContainerLimits container-must-have-limits, the constraints of kind K8sContainerLimits are not supported, the Rego of the template cannot be converted
+# converted from K8sRequiredLabels all-must-have-owner
+# not converted: parameters.labels: the values of the label owner are not checked against the regular expression ^[a-z]+$
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: all-must-have-owner
+spec:
+  validationFailureAction: enforce
+  rules:
+  - name: required-labels
+    match:
+      resources:
+        kinds:
+        - Namespace
+    validate:
+      message: The labels owner are required.
+      pattern:
+        metadata:
+          labels:
+            owner: '*'
+`)
+	policies, err := common.DecodePolicies(out.Bytes())
+	assert.NilError(t, err)
+	assert.Equal(t, len(policies), 1)
+
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "sa.yaml"), []byte("apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: web\n"), 0644))
+	resources, err = loadResources([]string{filepath.Join(dir, "sa.yaml")})
+	assert.NilError(t, err)
+	assert.ErrorContains(t, writeConstraintPolicies(&out, resources), "no Gatekeeper constraint or template")
+}
@@ -0,0 +1,288 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"github.com/nirmata/kyverno/pkg/psp"
+	"github.com/spf13/cobra"
+	yamlv2 "gopkg.in/yaml.v2"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetes "k8s.io/client-go/kubernetes"
+)
+
+const (
+	migratePSPExample = `  # Convert the PodSecurityPolicies of the files, and audit the pods of the cluster with the policies.
+  kyverno migrate psp psp.yaml > policies.yaml
+  kyverno apply policies.yaml --cluster
+
+  # Convert the PodSecurityPolicies of the cluster to enforced policies.
+  kyverno migrate psp --cluster --validation-failure-action enforce > policies.yaml`
+
+	actionAudit   = "audit"
+	actionEnforce = "enforce"
+)
+
+// NewCmdMigrate returns the migrate command, it converts the resources of other admission controllers to policies
+func NewCmdMigrate(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Convert the policies of other admission controllers to Kyverno policies",
+	}
+	cmd.AddCommand(newCmdMigratePSP(out))
+	cmd.AddCommand(newCmdMigrateGatekeeper(out))
+	return cmd
+}
+
+func newCmdMigratePSP(out io.Writer) *cobra.Command {
+	var cluster bool
+	var action string
+	configFlags := &common.ConfigFlags{}
+	cmd := &cobra.Command{
+		Use:     "psp (<file or directory>... | --cluster)",
+		Short:   "Convert PodSecurityPolicies to ClusterPolicies, with comments on the settings that cannot be converted",
+		Example: migratePSPExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if action != actionAudit && action != actionEnforce {
+				glog.Errorf("invalid validation failure action %s, expected %s or %s", action, actionAudit, actionEnforce)
+				os.Exit(common.ExitError)
+			}
+			var psps []policyv1beta1.PodSecurityPolicy
+			var err error
+			switch {
+			case cluster && len(args) != 0:
+				err = fmt.Errorf("set either files or --cluster")
+			case cluster:
+				psps, err = listClusterPSPs(configFlags)
+			case len(args) != 0:
+				psps, err = loadPSPs(args)
+			default:
+				err = fmt.Errorf("missing PodSecurityPolicies, set files or --cluster")
+			}
+			if err != nil {
+				glog.Errorf("Failed to load the PodSecurityPolicies: %v", err)
+				os.Exit(common.ExitError)
+			}
+			if err := writePolicies(out, psps, action); err != nil {
+				glog.Errorf("Failed to write the policies: %v", err)
+				os.Exit(common.ExitError)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&cluster, "cluster", false, "convert the PodSecurityPolicies of the cluster instead of the files")
+	cmd.Flags().StringVar(&action, "validation-failure-action", actionAudit, "validation failure action of the policies, audit to report the pods that are not allowed or enforce to reject them")
+	configFlags.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// loadResources returns the resources of the files and of the YAML and JSON files of the directories,
+// the items of the lists are returned, e.g. the output of kubectl get -o yaml
+func loadResources(paths []string) ([]unstructured.Unstructured, error) {
+	files, err := common.ExpandPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+	var resources []unstructured.Unstructured
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, document := range common.SplitDocuments(data) {
+			resource, err := common.DecodeResource(document.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode the document at %s:%d: %v", file, document.StartLine(), err)
+			}
+			if resource == nil {
+				continue
+			}
+			if !resource.IsList() {
+				resources = append(resources, *resource)
+				continue
+			}
+			list, err := resource.ToList()
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode the list at %s:%d: %v", file, document.StartLine(), err)
+			}
+			resources = append(resources, list.Items...)
+		}
+	}
+	return resources, nil
+}
+
+// loadPSPs returns the PodSecurityPolicies of the files, the resources of the other kinds are skipped
+func loadPSPs(paths []string) ([]policyv1beta1.PodSecurityPolicy, error) {
+	resources, err := loadResources(paths)
+	if err != nil {
+		return nil, err
+	}
+	var psps []policyv1beta1.PodSecurityPolicy
+	for _, resource := range resources {
+		// the PodSecurityPolicies of extensions/v1beta1 have the same fields
+		gvk := resource.GroupVersionKind()
+		if gvk.Kind != "PodSecurityPolicy" || (gvk.Group != "policy" && gvk.Group != "extensions") {
+			glog.V(3).Infof("skipping %s %s, not a PodSecurityPolicy", gvk.Kind, resource.GetName())
+			continue
+		}
+		var psp policyv1beta1.PodSecurityPolicy
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(resource.Object, &psp); err != nil {
+			return nil, fmt.Errorf("failed to decode PodSecurityPolicy %s: %v", resource.GetName(), err)
+		}
+		psps = append(psps, psp)
+	}
+	if len(psps) == 0 {
+		return nil, fmt.Errorf("no PodSecurityPolicy in %v", paths)
+	}
+	return psps, nil
+}
+
+func listClusterPSPs(configFlags *common.ConfigFlags) ([]policyv1beta1.PodSecurityPolicy, error) {
+	clientConfig, err := configFlags.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	kclient, err := kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	return listPSPs(kclient)
+}
+
+// listPSPs returns the PodSecurityPolicies of the cluster
+func listPSPs(kclient kubernetes.Interface) ([]policyv1beta1.PodSecurityPolicy, error) {
+	list, err := kclient.PolicyV1beta1().PodSecurityPolicies().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, fmt.Errorf("no PodSecurityPolicy in the cluster")
+	}
+	return list.Items, nil
+}
+
+// writePolicies writes the ClusterPolicies of the PodSecurityPolicies, the settings that are not converted are commented
+func writePolicies(out io.Writer, psps []policyv1beta1.PodSecurityPolicy, action string) error {
+	if len(psps) > 1 {
+		// the admission of a PSP allows the pods allowed by one of the PSPs the user or the service account can use
+		fmt.Fprintf(out, "# the pods must be allowed by all the policies, the RBAC permissions to use the PodSecurityPolicies do not apply\n")
+	}
+	for i, p := range psps {
+		policy, warnings := psp.Convert(p)
+		policy.Spec.ValidationFailureAction = action
+		if i > 0 {
+			fmt.Fprintf(out, "---\n")
+		}
+		if err := writePolicy(out, policy, "PodSecurityPolicy "+p.Name, warnings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePolicy writes the policy converted from the source, with the warnings of the settings that are not converted as comments
+func writePolicy(out io.Writer, policy *kyverno.ClusterPolicy, source string, warnings []string) error {
+	data, err := policyYAML(policy)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "# converted from %s\n", source)
+	for _, warning := range warnings {
+		fmt.Fprintf(out, "# not converted: %s\n", warning)
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// policyYAML returns the YAML of the policy, without its status and its empty fields,
+// the fields are in the order of the JSON, after the apiVersion and the kind
+func policyYAML(policy *kyverno.ClusterPolicy) ([]byte, error) {
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+	var document yamlv2.MapSlice
+	if err := yamlv2.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+	var fields yamlv2.MapSlice
+	for _, item := range document {
+		switch item.Key {
+		case "status":
+		case "spec":
+			// the action is before the rules, as in the policies of the create command
+			fields = append(fields, yamlv2.MapItem{Key: item.Key, Value: reorder(item.Value.(yamlv2.MapSlice), "validationFailureAction")})
+		default:
+			fields = append(fields, item)
+		}
+	}
+	return yamlv2.Marshal(prune(reorder(fields, "apiVersion", "kind")))
+}
+
+// reorder moves the fields of the keys first, in the order of the keys
+func reorder(fields yamlv2.MapSlice, keys ...string) yamlv2.MapSlice {
+	var first, others yamlv2.MapSlice
+	for _, key := range keys {
+		for _, item := range fields {
+			if item.Key == key {
+				first = append(first, item)
+			}
+		}
+	}
+	for _, item := range fields {
+		if !containsKey(keys, item.Key) {
+			others = append(others, item)
+		}
+	}
+	return append(first, others...)
+}
+
+func containsKey(keys []string, key interface{}) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// prune removes the null values and the empty strings, maps and lists, the false and zero values are kept
+func prune(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case yamlv2.MapSlice:
+		var pruned yamlv2.MapSlice
+		for _, item := range typed {
+			if v := prune(item.Value); v != nil {
+				pruned = append(pruned, yamlv2.MapItem{Key: item.Key, Value: v})
+			}
+		}
+		if len(pruned) == 0 {
+			return nil
+		}
+		return pruned
+	case []interface{}:
+		var pruned []interface{}
+		for _, element := range typed {
+			if v := prune(element); v != nil {
+				pruned = append(pruned, v)
+			}
+		}
+		if len(pruned) == 0 {
+			return nil
+		}
+		return pruned
+	case string:
+		if typed == "" {
+			return nil
+		}
+	}
+	return value
+}
@@ -0,0 +1,164 @@
+package migrate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	policyvalidate "github.com/nirmata/kyverno/pkg/engine/policy"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"gotest.tools/assert"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+const testPSPs = `apiVersion: policy/v1beta1
+kind: PodSecurityPolicy
+metadata:
+  name: baseline
+spec:
+  hostNetwork: true
+  hostPID: true
+  hostIPC: true
+  hostPorts:
+  - min: 0
+    max: 65535
+  volumes:
+  - '*'
+  allowedCapabilities:
+  - '*'
+  runAsUser:
+    rule: RunAsAny
+  seLinux:
+    rule: RunAsAny
+  supplementalGroups:
+    rule: RunAsAny
+  fsGroup:
+    rule: RunAsAny
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+---
+apiVersion: extensions/v1beta1
+kind: PodSecurityPolicy
+metadata:
+  name: non-root
+spec:
+  privileged: true
+  hostNetwork: true
+  hostPID: true
+  hostIPC: true
+  hostPorts:
+  - min: 0
+    max: 65535
+  volumes:
+  - '*'
+  allowedCapabilities:
+  - '*'
+  requiredDropCapabilities:
+  - NET_RAW
+  runAsUser:
+    rule: MustRunAsNonRoot
+  seLinux:
+    rule: RunAsAny
+  supplementalGroups:
+    rule: RunAsAny
+  fsGroup:
+    rule: RunAsAny
+`
+
+const testPod = `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: nginx
+    securityContext:
+      privileged: true
+`
+
+func Test_MigratePSPs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "psp")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "psp.yaml"), []byte(testPSPs), 0644))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("policies"), 0644))
+
+	psps, err := loadPSPs([]string{dir})
+	assert.NilError(t, err)
+	assert.Equal(t, len(psps), 2)
+	assert.Equal(t, psps[1].Spec.RunAsUser.Rule, policyv1beta1.RunAsUserStrategyMustRunAsNonRoot)
+
+	var out bytes.Buffer
+	assert.NilError(t, writePolicies(&out, psps, actionEnforce))
+	assert.Assert(t, strings.HasPrefix(out.String(), `# the pods must be allowed by all the policies, the RBAC permissions to use the PodSecurityPolicies do not apply
+# converted from PodSecurityPolicy baseline
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: psp-baseline
+spec:
+  validationFailureAction: enforce
+  rules:
+  - name: privileged
+    match:
+      resources:
+        kinds:
+        - Pod
+    validate:
+      message: Privileged containers are not allowed.
+      pattern:
+        spec:
+          =(initContainers):
+          - =(securityContext):
+              =(privileged): false
+          containers:
+          - =(securityContext):
+              =(privileged): false
+`), out.String())
+	assert.Assert(t, strings.Contains(out.String(), "---\n# converted from PodSecurityPolicy non-root\n# not converted: requiredDropCapabilities: the drop of the capabilities [NET_RAW] is not checked\n"), out.String())
+
+	policies, err := common.DecodePolicies(out.Bytes())
+	assert.NilError(t, err)
+	assert.Equal(t, len(policies), 2)
+	for _, policy := range policies {
+		assert.NilError(t, policyvalidate.Validate(policy))
+		assert.Equal(t, policy.Spec.ValidationFailureAction, actionEnforce)
+	}
+	pod, err := common.DecodeResource([]byte(testPod))
+	assert.NilError(t, err)
+	_, responses := common.ApplyPolicies(policies, *pod, common.Values{})
+	var statuses []string
+	for _, response := range responses {
+		for _, rule := range response.PolicyResponse.Rules {
+			statuses = append(statuses, response.PolicyResponse.Policy+"/"+rule.Name+": "+common.RuleStatus(rule))
+		}
+	}
+	assert.DeepEqual(t, statuses, []string{
+		"psp-baseline/privileged: fail",
+		"psp-baseline/proc-mount: pass",
+		"psp-non-root/run-as-non-root: fail",
+		"psp-non-root/proc-mount: pass",
+	})
+
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "sa.yaml"), []byte("apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: web\n"), 0644))
+	_, err = loadPSPs([]string{filepath.Join(dir, "sa.yaml")})
+	assert.ErrorContains(t, err, "no PodSecurityPolicy")
+}
+
+func Test_ListPSPs(t *testing.T) {
+	_, err := listPSPs(kubefake.NewSimpleClientset())
+	assert.ErrorContains(t, err, "no PodSecurityPolicy in the cluster")
+	psps, err := listPSPs(kubefake.NewSimpleClientset(&policyv1beta1.PodSecurityPolicy{ObjectMeta: metav1.ObjectMeta{Name: "restricted"}}))
+	assert.NilError(t, err)
+	assert.Equal(t, len(psps), 1)
+	assert.Equal(t, psps[0].Name, "restricted")
+}
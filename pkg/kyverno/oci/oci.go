@@ -0,0 +1,194 @@
+package oci
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/glog"
+	"github.com/nirmata/kyverno/pkg/cosign"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"github.com/nirmata/kyverno/pkg/policybundle"
+	"github.com/nirmata/kyverno/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+const (
+	pushExample = `  # Push the policies of the files and directories as a bundle, with the credentials of the docker config.
+  kyverno oci push policies/ --image ghcr.io/org/policies:v1
+
+  # Sign the bundle as an image, so it is verified when pulled with the key.
+  cosign sign --key cosign.key ghcr.io/org/policies:v1`
+
+	pullExample = `  # Pull the policies of a bundle and apply them.
+  kyverno oci pull --image ghcr.io/org/policies:v1 | kubectl apply -f -
+
+  # Verify the cosign signature of the bundle and save its policies in a directory.
+  kyverno oci pull --image ghcr.io/org/policies:v1 --key cosign.pub --output policies/`
+
+	// bundleFile is the file of the policies pulled in a directory
+	bundleFile = "policies.yaml"
+)
+
+// NewCmdOCI returns the oci command, it pushes and pulls the policy bundles of the OCI registries
+func NewCmdOCI(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "oci",
+		Short: "Push and pull the policy bundles of the OCI registries",
+	}
+	cmd.AddCommand(newCmdPush(out))
+	cmd.AddCommand(newCmdPull(out))
+	return cmd
+}
+
+func newCmdPush(out io.Writer) *cobra.Command {
+	var image, dockerConfig string
+	cmd := &cobra.Command{
+		Use:     "push <policy files or directories> --image <image>",
+		Short:   "Push the cluster policies of the files as a bundle to an OCI registry",
+		Example: pushExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if image == "" {
+				glog.Error("missing image, set --image")
+				os.Exit(common.ExitError)
+			}
+			policies, count, err := bundlePolicies(args)
+			if err != nil {
+				glog.Errorf("Failed to load the policies: %v", err)
+				os.Exit(common.ExitError)
+			}
+			client := registry.NewClient(nil, registry.NewFileKeychain(dockerConfig), nil)
+			client.SetLogger(enginelog.Glog)
+			digest, err := policybundle.Push(client, image, policies, nil)
+			if err != nil {
+				glog.Errorf("Failed to push %s: %v", image, err)
+				os.Exit(common.ExitError)
+			}
+			fmt.Fprintf(out, "Pushed %d policies to %s@%s\n", count, image, digest)
+		},
+	}
+	cmd.Flags().StringVarP(&image, "image", "i", "", "Image of the bundle, with a tag")
+	cmd.Flags().StringVar(&dockerConfig, "docker-config", defaultDockerConfig(), "Docker config with the credentials of the registry")
+	return cmd
+}
+
+func newCmdPull(out io.Writer) *cobra.Command {
+	var image, dockerConfig, keyFile, output string
+	cmd := &cobra.Command{
+		Use:     "pull --image <image>",
+		Short:   "Pull the cluster policies of a bundle of an OCI registry, and verify its cosign signature",
+		Example: pullExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if image == "" {
+				glog.Error("missing image, set --image")
+				os.Exit(common.ExitError)
+			}
+			var key []byte
+			if keyFile != "" {
+				var err error
+				if key, err = ioutil.ReadFile(keyFile); err != nil {
+					glog.Errorf("Failed to read the key: %v", err)
+					os.Exit(common.ExitError)
+				}
+			}
+			client := registry.NewClient(nil, registry.NewFileKeychain(dockerConfig), nil)
+			client.SetLogger(enginelog.Glog)
+			verifier := cosign.NewVerifier(client)
+			verifier.SetLogger(enginelog.Glog)
+			if err := pull(client, verifier, image, string(key), output, out); err != nil {
+				glog.Errorf("Failed to pull %s: %v", image, err)
+				os.Exit(common.ExitError)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&image, "image", "i", "", "Image of the bundle, with a tag or a digest")
+	cmd.Flags().StringVar(&keyFile, "key", "", "Public key of the cosign signature of the bundle, the signature is not verified if not set")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Directory of the pulled policies, written to the standard output if not set")
+	cmd.Flags().StringVar(&dockerConfig, "docker-config", defaultDockerConfig(), "Docker config with the credentials of the registry")
+	return cmd
+}
+
+// defaultDockerConfig returns the docker config of the docker CLI
+func defaultDockerConfig() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// bundlePolicies returns the YAML documents of the files with cluster policies and the number of policies,
+// the documents are kept as written, with their comments
+func bundlePolicies(paths []string) ([]byte, int, error) {
+	if len(paths) == 0 {
+		return nil, 0, fmt.Errorf("no policy files")
+	}
+	files, err := common.ExpandPaths(paths)
+	if err != nil {
+		return nil, 0, err
+	}
+	var bundle bytes.Buffer
+	var count int
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, 0, err
+		}
+		policies, err := common.DecodePolicies(data)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %v", file, err)
+		}
+		if len(policies) == 0 {
+			continue
+		}
+		count += len(policies)
+		bundle.WriteString("---\n")
+		bundle.Write(data)
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			bundle.WriteString("\n")
+		}
+	}
+	if count == 0 {
+		return nil, 0, fmt.Errorf("no ClusterPolicy in the files")
+	}
+	return bundle.Bytes(), count, nil
+}
+
+// pull writes the policies of the bundle in the directory, or to out if the directory is not set,
+// the signature of the bundle is verified with the key if set
+func pull(fetcher policybundle.Fetcher, verifier cosign.Interface, image, key, dir string, out io.Writer) error {
+	verified := image
+	if key != "" {
+		var err error
+		if verified, err = policybundle.Verify(verifier, image, key, nil); err != nil {
+			return err
+		}
+	}
+	data, digest, err := policybundle.Pull(fetcher, verified, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := policybundle.Decode(data); err != nil {
+		return err
+	}
+	if dir == "" {
+		_, err := out.Write(data)
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file := filepath.Join(dir, bundleFile)
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Pulled the policies of %s (%s) to %s\n", image, digest, file)
+	return nil
+}
@@ -0,0 +1,96 @@
+package oci
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nirmata/kyverno/pkg/policybundle"
+	"github.com/nirmata/kyverno/pkg/registry"
+	"gotest.tools/assert"
+)
+
+// memoryRegistry stores the blobs and the tagged manifests of the bundles
+type memoryRegistry struct {
+	blobs     map[string][]byte
+	manifests map[string][]byte
+}
+
+func (r *memoryRegistry) PushBlob(image string, data []byte, keychain registry.Keychain) (string, error) {
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	r.blobs[digest] = data
+	return digest, nil
+}
+
+func (r *memoryRegistry) PushManifest(image, mediaType string, manifest []byte, keychain registry.Keychain) (string, error) {
+	r.manifests[image] = manifest
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(manifest)), nil
+}
+
+func (r *memoryRegistry) FetchManifest(image string, keychain registry.Keychain) ([]byte, string, error) {
+	manifest, ok := r.manifests[image]
+	if !ok {
+		return nil, "", fmt.Errorf("manifest %s not found", image)
+	}
+	return manifest, fmt.Sprintf("sha256:%x", sha256.Sum256(manifest)), nil
+}
+
+func (r *memoryRegistry) FetchBlob(image, digest string, keychain registry.Keychain) ([]byte, error) {
+	return r.blobs[digest], nil
+}
+
+const policy = `# require the app label
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: require-labels
+spec:
+  rules:
+  - name: check-labels
+    match:
+      resources:
+        kinds:
+        - Pod
+    validate:
+      pattern:
+        metadata:
+          labels:
+            app: "?*"`
+
+func Test_PushPull(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oci")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NilError(t, os.MkdirAll(filepath.Join(dir, "policies"), 0755))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "policies", "a.yaml"), []byte(policy), 0644))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "policies", "b.yaml"), []byte(policy+"\n---\n"+policy), 0644))
+
+	// the documents are kept with their comments
+	data, count, err := bundlePolicies([]string{filepath.Join(dir, "policies")})
+	assert.NilError(t, err)
+	assert.Equal(t, count, 3)
+	assert.Equal(t, string(data), "---\n"+policy+"\n---\n"+policy+"\n---\n"+policy+"\n")
+
+	reg := &memoryRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	digest, err := policybundle.Push(reg, "ghcr.io/org/policies:v1", data, nil)
+	assert.NilError(t, err)
+
+	var out bytes.Buffer
+	assert.NilError(t, pull(reg, nil, "ghcr.io/org/policies:v1", "", "", &out))
+	assert.Equal(t, out.String(), string(data))
+
+	out.Reset()
+	assert.NilError(t, pull(reg, nil, "ghcr.io/org/policies:v1", "", filepath.Join(dir, "pulled"), &out))
+	assert.Equal(t, out.String(), fmt.Sprintf("Pulled the policies of ghcr.io/org/policies:v1 (%s) to %s\n", digest, filepath.Join(dir, "pulled", "policies.yaml")))
+	pulled, err := ioutil.ReadFile(filepath.Join(dir, "pulled", "policies.yaml"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(pulled), string(data))
+
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "configmap.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"), 0644))
+	_, _, err = bundlePolicies([]string{filepath.Join(dir, "configmap.yaml")})
+	assert.ErrorContains(t, err, "ConfigMap test is not a ClusterPolicy")
+}
@@ -0,0 +1,57 @@
+package scan
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nirmata/kyverno/pkg/config"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"github.com/nirmata/kyverno/pkg/policy"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+)
+
+// noFilter does not filter any resource
+type noFilter struct{}
+
+func (noFilter) ToFilter(kind, namespace, name string) bool {
+	return false
+}
+
+// scanClusters applies the cluster policies of the file on the existing resources of the clusters of the contexts,
+// read-only, and writes the violations of each cluster. It returns the count of the violations.
+func scanClusters(out io.Writer, kubeconfig, file string, contexts []string, stopCh <-chan struct{}) (int, error) {
+	policies, err := common.LoadPolicies([]string{file})
+	if err != nil {
+		return 0, err
+	}
+	configs, err := config.CreateContextConfigs(kubeconfig, contexts)
+	if err != nil {
+		return 0, err
+	}
+	violations := 0
+	for _, c := range configs {
+		// the scan is shorter than the refresh of the discovered resources
+		client, err := dclient.NewClient(c.Config, time.Hour, dclient.DefaultRetry, stopCh)
+		if err != nil {
+			return violations, fmt.Errorf("failed to create the client of cluster %s: %v", c.Name, err)
+		}
+		infos := policyviolation.GeneratePVsFromEngineResponse(policy.ScanCluster(client, policies, noFilter{}, nil, nil))
+		fmt.Fprintf(out, "Cluster %s: %d violations\n", c.Name, len(infos))
+		for _, info := range infos {
+			for _, rule := range info.Rules {
+				fmt.Fprintf(out, "  %s/%s %s %s: %s\n", info.PolicyName, rule.Name, info.Resource.GetKind(), resourceName(info.Resource.GetNamespace(), info.Resource.GetName()), rule.Message)
+			}
+		}
+		violations += len(infos)
+	}
+	return violations, nil
+}
+
+func resourceName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
@@ -0,0 +1,116 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+	kyvernoclient "github.com/nirmata/kyverno/pkg/client/clientset/versioned"
+	"github.com/nirmata/kyverno/pkg/config"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const scanExample = `  # Scan the existing resources with a policy.
+  kyverno scan --policy require-labels
+
+  # Scan the existing resources of a namespace with all the policies.
+  kyverno scan --namespace default
+
+  # Scan the existing resources of the clusters of kubeconfig contexts with the policies of a file, read-only.
+  kyverno scan -f policies.yaml --context prod --context staging`
+
+// NewCmdScan returns the scan command, it requests a background scan to kyverno
+// by setting the scan annotation on the policy or the namespace
+func NewCmdScan(out io.Writer) *cobra.Command {
+	var kubeconfig, policy, namespace, file string
+	var contexts []string
+	cmd := &cobra.Command{
+		Use:     "scan",
+		Short:   "Scan the existing resources of the cluster with a policy, or of a namespace with all the policies",
+		Example: scanExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if file != "" {
+				// the policies are applied by the command, kyverno is not required in the clusters
+				stopCh := make(chan struct{})
+				defer close(stopCh)
+				violations, err := scanClusters(out, kubeconfig, file, contexts, stopCh)
+				if err != nil {
+					glog.Errorf("Failed to scan the clusters: %v\n", err)
+					os.Exit(1)
+				}
+				if violations != 0 {
+					os.Exit(1)
+				}
+				return
+			}
+			if (policy == "") == (namespace == "") {
+				glog.Errorf("Either --policy, --namespace or --file must be set\n")
+				os.Exit(1)
+			}
+			if err := requestScan(kubeconfig, policy, namespace); err != nil {
+				glog.Errorf("Failed to request the scan: %v\n", err)
+				os.Exit(1)
+			}
+			if policy != "" {
+				fmt.Fprintf(out, "Scan of policy %s requested\n", policy)
+				return
+			}
+			fmt.Fprintf(out, "Scan of namespace %s requested\n", namespace)
+		},
+	}
+
+	cmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "path to kubeconfig file")
+	cmd.Flags().StringVar(&policy, "policy", "", "name of the ClusterPolicy to scan the existing resources with")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "", "namespace of the existing resources to scan with all the policies")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "file of the ClusterPolicies to apply on the existing resources of the clusters of the contexts, the command exits with 1 on violations")
+	cmd.Flags().StringSliceVar(&contexts, "context", nil, "kubeconfig context of a cluster to scan with --file, all the contexts of the kubeconfig if not set")
+	return cmd
+}
+
+// requestScan sets the scan annotation of the policy or of the namespace to the current time
+func requestScan(kubeconfig, policy, namespace string) error {
+	clientConfig, err := createClientConfig(kubeconfig)
+	if err != nil {
+		return err
+	}
+	patch, err := scanPatch(time.Now())
+	if err != nil {
+		return err
+	}
+	if policy != "" {
+		client, err := kyvernoclient.NewForConfig(clientConfig)
+		if err != nil {
+			return err
+		}
+		_, err = client.KyvernoV1().ClusterPolicies().Patch(policy, types.MergePatchType, patch)
+		return err
+	}
+	client, err := kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		return err
+	}
+	_, err = client.CoreV1().Namespaces().Patch(namespace, types.MergePatchType, patch)
+	return err
+}
+
+// scanPatch returns the merge patch of the scan annotation, its value changes on every request
+func scanPatch(now time.Time) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{config.ScanAnnotation: now.UTC().Format(time.RFC3339Nano)},
+		},
+	})
+}
+
+func createClientConfig(kubeconfig string) (*rest.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
+}
@@ -0,0 +1,462 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/golang/glog"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/engine/utils"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	testExample = `  # Run the tests of the kyverno-test.yaml files of a directory.
+  kyverno test policies/
+
+  # Run a test file.
+  kyverno test policies/require-labels/kyverno-test.yaml`
+
+	// TestFile is the name of the test files in the directories
+	TestFile = "kyverno-test.yaml"
+)
+
+// Test declares the policies and the resources of a test, the values of the variables and the expected results.
+// The files are relative to the directory of the test file.
+type Test struct {
+	Name      string        `json:"name"`
+	Policies  []string      `json:"policies"`
+	Resources []string      `json:"resources"`
+	Values    common.Values `json:"values,omitempty"`
+	Results   []Result      `json:"results"`
+}
+
+// Result is the expected result of a rule on a resource
+type Result struct {
+	Policy string `json:"policy"`
+	Rule   string `json:"rule"`
+	// Resource is the name of the resource, Kind and Namespace select the resource if several resources have the name
+	Resource  string `json:"resource"`
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// Result is pass, fail, or skip if the rule does not apply to the resource
+	Result string `json:"result"`
+	// PatchedResource is the file of the resource mutated by the policy
+	PatchedResource string `json:"patchedResource,omitempty"`
+}
+
+// outcome is the outcome of the assertion of an expected result
+type outcome struct {
+	Result `json:",inline"`
+	// ResourceKey is the key of the resource, kind/namespace/name
+	ResourceKey string `json:"resourceKey"`
+	// Actual is the result of the rule on the resource
+	Actual  string `json:"actual"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// report is the report of the tests, for the json and yaml outputs
+type report struct {
+	Tests   []testReport `json:"tests"`
+	Summary summary      `json:"summary"`
+}
+
+// testReport is the report of a test file, with the error of the test if it cannot run
+type testReport struct {
+	Name     string    `json:"name,omitempty"`
+	File     string    `json:"file"`
+	Error    string    `json:"error,omitempty"`
+	Outcomes []outcome `json:"outcomes,omitempty"`
+}
+
+type summary struct {
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+	Errors int `json:"errors"`
+}
+
+// outputTable is the table of the outcomes of each test
+const outputTable = "table"
+
+// NewCmdTest returns the test command, it runs the tests of the kyverno-test.yaml files
+func NewCmdTest(out io.Writer) *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:     "test <test file or directory>...",
+		Short:   "Run the tests declared in kyverno-test.yaml files, and report the outcome of the expected results",
+		Example: testExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := common.ValidateOutput(outputFormat, outputTable, common.OutputJSON, common.OutputYAML, common.OutputJUnit); err != nil {
+				glog.Errorf("%v", err)
+				os.Exit(common.ExitError)
+			}
+			if len(args) == 0 {
+				glog.Errorf("missing test file or directory")
+				os.Exit(common.ExitError)
+			}
+			files, err := testFiles(args)
+			if err != nil {
+				glog.Errorf("Failed to list the test files: %v", err)
+				os.Exit(common.ExitError)
+			}
+			if len(files) == 0 {
+				glog.Errorf("No %s in %v", TestFile, args)
+				os.Exit(common.ExitError)
+			}
+			r := runTests(files)
+			switch outputFormat {
+			case common.OutputJSON, common.OutputYAML:
+				err = common.WriteReport(out, outputFormat, r)
+			case common.OutputJUnit:
+				err = writeJUnit(out, r)
+			default:
+				printReport(out, r)
+			}
+			if err != nil {
+				glog.Errorf("Failed to write the %s output: %v", outputFormat, err)
+				os.Exit(common.ExitError)
+			}
+			if code := r.exitCode(); code != common.ExitSuccess {
+				os.Exit(code)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", outputTable, "output format: table for the outcomes of each test, json, yaml or junit")
+	return cmd
+}
+
+// runTests runs the tests of the files, a test that cannot run is an error
+func runTests(files []string) report {
+	r := report{Tests: []testReport{}}
+	for _, file := range files {
+		test := testReport{File: file}
+		name, outcomes, err := runTestFile(file)
+		test.Name = name
+		if err != nil {
+			test.Error = err.Error()
+			r.Summary.Errors++
+		}
+		for _, o := range outcomes {
+			if o.Success {
+				r.Summary.Passed++
+			} else {
+				r.Summary.Failed++
+			}
+		}
+		test.Outcomes = outcomes
+		r.Tests = append(r.Tests, test)
+	}
+	return r
+}
+
+// exitCode returns the exit code of the report, the errors take precedence over the failures
+func (r report) exitCode() int {
+	switch {
+	case r.Summary.Errors > 0:
+		return common.ExitError
+	case r.Summary.Failed > 0:
+		return common.ExitFailure
+	default:
+		return common.ExitSuccess
+	}
+}
+
+// testFiles returns the test files, and the kyverno-test.yaml files of the directories
+func testFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && info.Name() == TestFile {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// loadTest returns the test of the file, the unknown fields are errors
+func loadTest(file string) (*Test, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := yaml.ToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	test := &Test{}
+	if err := decoder.Decode(test); err != nil {
+		return nil, fmt.Errorf("failed to decode the test: %v", err)
+	}
+	if len(test.Policies) == 0 || len(test.Resources) == 0 {
+		return nil, fmt.Errorf("the test has no policies or no resources")
+	}
+	for i, result := range test.Results {
+		switch result.Result {
+		case common.StatusPass, common.StatusFail, common.StatusError, common.StatusSkip:
+		default:
+			return nil, fmt.Errorf("results[%d]: invalid result %q, must be %s, %s, %s or %s", i, result.Result, common.StatusPass, common.StatusFail, common.StatusError, common.StatusSkip)
+		}
+	}
+	return test, nil
+}
+
+// runTestFile runs the test of the file, and returns its name and the outcomes of its expected results
+func runTestFile(file string) (string, []outcome, error) {
+	test, err := loadTest(file)
+	if err != nil {
+		return "", nil, err
+	}
+	dir := filepath.Dir(file)
+	policies, err := common.LoadPolicies(relativeTo(dir, test.Policies))
+	if err != nil {
+		return test.Name, nil, err
+	}
+	resources, err := loadResources(relativeTo(dir, test.Resources))
+	if err != nil {
+		return test.Name, nil, err
+	}
+
+	var runs []resourceRun
+	for _, resource := range resources {
+		_, engineResponses := common.ApplyPolicies(policies, resource, test.Values)
+		runs = append(runs, resourceRun{resource: resource, engineResponses: engineResponses})
+	}
+
+	var outcomes []outcome
+	for _, result := range test.Results {
+		outcomes = append(outcomes, assertResult(dir, result, runs)...)
+	}
+	return test.Name, outcomes, nil
+}
+
+// resourceRun is a resource and the engine responses of the policies of the test
+type resourceRun struct {
+	resource        unstructured.Unstructured
+	engineResponses []response.EngineResponse
+}
+
+// assertResult returns the outcome of the expected result on each resource it selects
+func assertResult(dir string, result Result, runs []resourceRun) []outcome {
+	var outcomes []outcome
+	for _, run := range runs {
+		if !common.MatchesResource(run.resource, result.Resource, result.Kind, result.Namespace) {
+			continue
+		}
+		o := outcome{Result: result, ResourceKey: common.ResourceKey(run.resource), Actual: ruleResult(run.engineResponses, result.Policy, result.Rule)}
+		o.Success = o.Actual == result.Result
+		if o.Success && result.PatchedResource != "" {
+			if err := comparePatchedResource(filepath.Join(dir, result.PatchedResource), run, result.Policy); err != nil {
+				o.Success = false
+				o.Message = err.Error()
+			}
+		}
+		outcomes = append(outcomes, o)
+	}
+	if len(outcomes) == 0 {
+		key := common.SpecKey(response.ResourceSpec{Kind: result.Kind, Namespace: result.Namespace, Name: result.Resource})
+		outcomes = append(outcomes, outcome{Result: result, ResourceKey: key, Message: "resource not found"})
+	}
+	return outcomes
+}
+
+// ruleResult returns the status of the rule of the policy if it applies to the resource, skip otherwise
+func ruleResult(engineResponses []response.EngineResponse, policy, rule string) string {
+	for _, engineResponse := range engineResponses {
+		if engineResponse.PolicyResponse.Policy != policy {
+			continue
+		}
+		for _, r := range engineResponse.PolicyResponse.Rules {
+			if r.Name == rule {
+				return common.RuleStatus(r)
+			}
+		}
+	}
+	return common.StatusSkip
+}
+
+// comparePatchedResource compares the resource mutated by the policy with the resource of the file
+func comparePatchedResource(file string, run resourceRun, policy string) error {
+	expected, err := loadResources([]string{file})
+	if err != nil {
+		return err
+	}
+	var expectedResource *unstructured.Unstructured
+	for i := range expected {
+		if common.MatchesResource(expected[i], run.resource.GetName(), run.resource.GetKind(), run.resource.GetNamespace()) {
+			expectedResource = &expected[i]
+			break
+		}
+	}
+	if expectedResource == nil {
+		return fmt.Errorf("no patched resource %s in %s", common.ResourceKey(run.resource), file)
+	}
+
+	// the resource is not patched if the policy has no mutation
+	patched := run.resource
+	for _, engineResponse := range run.engineResponses {
+		if engineResponse.PolicyResponse.Policy == policy && engineResponse.PatchedResource.Object != nil && isMutation(engineResponse) {
+			patched = engineResponse.PatchedResource
+			break
+		}
+	}
+	equal, err := equalJSON(patched.Object, expectedResource.Object)
+	if err != nil {
+		return err
+	}
+	if !equal {
+		return fmt.Errorf("the patched resource differs from %s", file)
+	}
+	return nil
+}
+
+func isMutation(engineResponse response.EngineResponse) bool {
+	for _, r := range engineResponse.PolicyResponse.Rules {
+		if r.Type == utils.Mutation.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// equalJSON compares the objects once marshaled, the numbers of the objects can have different types
+func equalJSON(a, b interface{}) (bool, error) {
+	var normalized [2]interface{}
+	for i, object := range []interface{}{a, b} {
+		raw, err := json.Marshal(object)
+		if err != nil {
+			return false, err
+		}
+		if err := json.Unmarshal(raw, &normalized[i]); err != nil {
+			return false, err
+		}
+	}
+	return reflect.DeepEqual(normalized[0], normalized[1]), nil
+}
+
+// loadResources returns the resources of the files, and of the files of the directories
+func loadResources(paths []string) ([]unstructured.Unstructured, error) {
+	files, err := common.ExpandPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+	var resources []unstructured.Unstructured
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, document := range common.SplitDocuments(data) {
+			resource, err := common.DecodeResource(document.Data)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", file, document.StartLine(), err)
+			}
+			if resource != nil {
+				resources = append(resources, *resource)
+			}
+		}
+	}
+	return resources, nil
+}
+
+func relativeTo(dir string, paths []string) []string {
+	var joined []string
+	for _, path := range paths {
+		if filepath.IsAbs(path) {
+			joined = append(joined, path)
+			continue
+		}
+		joined = append(joined, filepath.Join(dir, path))
+	}
+	return joined
+}
+
+// printReport writes the table of the outcomes of each test, and a summary
+func printReport(out io.Writer, r report) {
+	for _, test := range r.Tests {
+		if test.Error != "" {
+			fmt.Fprintf(out, "Test %s: error: %s\n\n", test.File, test.Error)
+			continue
+		}
+		printOutcomes(out, test.File, test.Outcomes)
+	}
+	fmt.Fprintf(out, "%d passed, %d failed, %d errors\n", r.Summary.Passed, r.Summary.Failed, r.Summary.Errors)
+}
+
+// printOutcomes writes the table of the outcomes of the test
+func printOutcomes(out io.Writer, file string, outcomes []outcome) {
+	fmt.Fprintf(out, "Test %s:\n", file)
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tPOLICY\tRULE\tRESOURCE\tEXPECTED\tACTUAL\tOUTCOME")
+	for i, o := range outcomes {
+		status := "Pass"
+		if !o.Success {
+			status = "Fail"
+		}
+		if o.Message != "" {
+			status = fmt.Sprintf("%s: %s", status, o.Message)
+		}
+		actual := o.Actual
+		if actual == "" {
+			actual = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", i+1, o.Policy, o.Rule, o.ResourceKey, o.Result.Result, actual, status)
+	}
+	w.Flush()
+	fmt.Fprintln(out)
+}
+
+// writeJUnit writes the outcomes as a JUnit report, with a test suite for each test and a test case for each outcome
+func writeJUnit(w io.Writer, r report) error {
+	var suites []common.JUnitTestSuite
+	for _, test := range r.Tests {
+		suite := common.JUnitTestSuite{Name: test.File}
+		if test.Error != "" {
+			suite.Cases = append(suite.Cases, common.NewJUnitTestCase(test.File, test.File, common.StatusError, test.Error))
+		}
+		for _, o := range test.Outcomes {
+			name := fmt.Sprintf("%s/%s %s", o.Policy, o.Rule, o.ResourceKey)
+			status, message := common.StatusPass, ""
+			if !o.Success {
+				status = common.StatusFail
+				message = fmt.Sprintf("expected %s, actual %s", o.Result.Result, o.Actual)
+				if o.Message != "" {
+					message = o.Message
+				}
+			}
+			suite.Cases = append(suite.Cases, common.NewJUnitTestCase(name, test.Name, status, message))
+		}
+		suites = append(suites, suite)
+	}
+	return common.WriteJUnit(w, suites)
+}
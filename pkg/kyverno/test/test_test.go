@@ -0,0 +1,201 @@
+package test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"gotest.tools/assert"
+)
+
+var fixtures = map[string]string{
+	"policies.yaml": `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: add-team
+spec:
+  rules:
+  - name: add-team
+    match:
+      resources:
+        kinds: [ConfigMap]
+    mutate:
+      overlay:
+        metadata:
+          labels:
+            +(team): platform
+---
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: require-env
+spec:
+  rules:
+  - name: check-env
+    context:
+    - name: settings
+      configMap:
+        name: settings
+        namespace: kyverno
+    match:
+      resources:
+        kinds: [ConfigMap]
+    validate:
+      message: "label env must be {{settings.data.env}}"
+      pattern:
+        metadata:
+          labels:
+            env: "{{settings.data.env}}"
+  - name: check-pods
+    match:
+      resources:
+        kinds: [Pod]
+    validate:
+      pattern:
+        metadata:
+          name: "?*"
+`,
+	"resources.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: prod
+  namespace: default
+  labels:
+    env: prod
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: dev
+  namespace: default
+  labels:
+    env: dev
+`,
+	"patched.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: prod
+  namespace: default
+  labels:
+    env: prod
+    team: platform
+`,
+	TestFile: `name: require-env
+policies:
+- policies.yaml
+resources:
+- resources.yaml
+values:
+  global:
+    settings.data.env: prod
+results:
+- policy: add-team
+  rule: add-team
+  resource: prod
+  result: pass
+  patchedResource: patched.yaml
+- policy: require-env
+  rule: check-env
+  resource: prod
+  kind: ConfigMap
+  result: pass
+- policy: require-env
+  rule: check-env
+  resource: dev
+  result: fail
+- policy: require-env
+  rule: check-pods
+  resource: dev
+  result: skip
+`,
+}
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "kyverno-test")
+	assert.NilError(t, err)
+	for name, content := range files {
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func Test_RunTestFile(t *testing.T) {
+	dir := writeTestFiles(t, fixtures)
+	defer os.RemoveAll(dir)
+
+	files, err := testFiles([]string{dir})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, files, []string{filepath.Join(dir, TestFile)})
+
+	name, outcomes, err := runTestFile(files[0])
+	assert.NilError(t, err)
+	assert.Equal(t, name, "require-env")
+	assert.Equal(t, len(outcomes), 4)
+	for _, o := range outcomes {
+		assert.Assert(t, o.Success, "%s/%s %s: expected %s, actual %s: %s", o.Policy, o.Rule, o.ResourceKey, o.Result.Result, o.Actual, o.Message)
+	}
+
+	r := runTests(files)
+	assert.DeepEqual(t, r.Summary, summary{Passed: 4})
+	assert.Equal(t, r.exitCode(), common.ExitSuccess)
+	var out bytes.Buffer
+	printReport(&out, r)
+	assert.Assert(t, strings.Contains(out.String(), "3  require-env  check-env   ConfigMap/default/dev   fail      fail    Pass"), out.String())
+}
+
+func Test_RunTestFileFailures(t *testing.T) {
+	files := map[string]string{}
+	for name, content := range fixtures {
+		files[name] = content
+	}
+	// without the value of the context entry, the ConfigMap is not available offline
+	files[TestFile] = strings.Replace(files[TestFile], "    settings.data.env: prod\n", "    request.operation: CREATE\n", 1)
+	files["patched.yaml"] = strings.Replace(files["patched.yaml"], "team: platform", "team: apps", 1)
+	files[TestFile] += `- policy: require-env
+  rule: check-env
+  resource: missing
+  result: pass
+`
+	dir := writeTestFiles(t, files)
+	defer os.RemoveAll(dir)
+
+	_, outcomes, err := runTestFile(filepath.Join(dir, TestFile))
+	assert.NilError(t, err)
+	assert.Equal(t, len(outcomes), 5)
+	assert.Assert(t, !outcomes[0].Success)
+	assert.Assert(t, strings.Contains(outcomes[0].Message, "patched resource differs"), outcomes[0].Message)
+	// the rule cannot load its context
+	assert.Equal(t, outcomes[1].Actual, common.StatusError)
+	assert.Assert(t, !outcomes[1].Success)
+	assert.Equal(t, outcomes[2].Actual, common.StatusError)
+	assert.Assert(t, !outcomes[2].Success)
+	assert.Assert(t, outcomes[3].Success)
+	assert.Assert(t, !outcomes[4].Success)
+	assert.Equal(t, outcomes[4].Message, "resource not found")
+
+	r := runTests([]string{filepath.Join(dir, TestFile), filepath.Join(dir, "missing.yaml")})
+	assert.DeepEqual(t, r.Summary, summary{Passed: 1, Failed: 4, Errors: 1})
+	assert.Equal(t, r.exitCode(), common.ExitError)
+	var out bytes.Buffer
+	assert.NilError(t, writeJUnit(&out, r))
+	assert.Assert(t, strings.Contains(out.String(), `<testsuite name="`+filepath.Join(dir, TestFile)+`" tests="5" failures="4" errors="0" skipped="0">`), out.String())
+	assert.Assert(t, strings.Contains(out.String(), `<failure message="expected pass, actual error"></failure>`), out.String())
+	assert.Assert(t, strings.Contains(out.String(), `tests="1" failures="0" errors="1"`), out.String())
+}
+
+func Test_LoadTestErrors(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"unknown.yaml": "policies: [p.yaml]\nresources: [r.yaml]\nresult: []\n",
+		"invalid.yaml": "policies: [p.yaml]\nresources: [r.yaml]\nresults:\n- policy: p\n  rule: r\n  resource: r\n  result: warn\n",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := loadTest(filepath.Join(dir, "unknown.yaml"))
+	assert.ErrorContains(t, err, "unknown field")
+	_, err = loadTest(filepath.Join(dir, "invalid.yaml"))
+	assert.ErrorContains(t, err, `invalid result "warn"`)
+}
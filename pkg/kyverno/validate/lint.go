@@ -0,0 +1,249 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	policyvalidate "github.com/nirmata/kyverno/pkg/engine/policy"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// lintError is an error of a policy, at a line of its file
+type lintError struct {
+	Line    int    `json:"line"`
+	Policy  string `json:"-"`
+	Message string `json:"message"`
+}
+
+func (e lintError) String() string {
+	if e.Policy == "" {
+		return fmt.Sprintf("%d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("%d: %s: %s", e.Line, e.Policy, e.Message)
+}
+
+// lintResult is the result of the lint of a policy of a file
+type lintResult struct {
+	File string `json:"file"`
+	// Line is the line of the start of the policy
+	Line   int         `json:"line"`
+	Policy string      `json:"policy,omitempty"`
+	Valid  bool        `json:"valid"`
+	Errors []lintError `json:"errors,omitempty"`
+}
+
+// lintFile returns the results of the policies of the file, the empty documents are skipped
+func lintFile(file string, data []byte) []lintResult {
+	var results []lintResult
+	for _, document := range common.SplitDocuments(data) {
+		errs, empty := lintDocument(document)
+		if empty {
+			continue
+		}
+		result := lintResult{File: file, Line: document.StartLine(), Valid: len(errs) == 0, Errors: errs}
+		if resource, err := common.DecodeResource(document.Data); err == nil && resource != nil {
+			result.Policy = resource.GetName()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// yamlErrorLine matches the line of the errors of the YAML parser, relative to the document
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// lintDocument returns the errors of the policy of the document, checked in the order of the admission of the policy:
+// the decoding of the YAML, the fields and their type against the schema of the CRD, then the checks of the
+// policy validation webhook, i.e. the rule types, the anchors of the patterns, the operators and the variables
+func lintDocument(document common.Document) (errs []lintError, empty bool) {
+	locator := newLineLocator(document)
+	raw, err := yaml.ToJSON(document.Data)
+	if err != nil {
+		line := document.StartLine()
+		message := err.Error()
+		if m := yamlErrorLine.FindStringSubmatch(message); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			line = document.Line + n - 1
+			message = "yaml: " + message[len(m[0]):]
+		}
+		return []lintError{{Line: line, Message: message}}, false
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return []lintError{{Line: document.StartLine(), Message: "the document is not an object"}}, false
+	}
+	if len(object) == 0 {
+		return nil, true
+	}
+
+	policy := kyverno.ClusterPolicy{}
+	name := nestedString(object, "metadata", "name")
+	kind := nestedString(object, "kind")
+	if kind != "ClusterPolicy" && kind != "Policy" {
+		return []lintError{{Line: locator.line([]string{"kind"}), Policy: name, Message: fmt.Sprintf("kind %q is not ClusterPolicy or Policy", kind)}}, false
+	}
+	if name == "" {
+		errs = append(errs, lintError{Line: locator.line([]string{"metadata"}), Message: "metadata.name is required"})
+	}
+	for _, path := range unknownFields(object, reflect.TypeOf(policy), nil) {
+		errs = append(errs, lintError{Line: locator.line(path), Policy: name, Message: fmt.Sprintf("unknown field %s", strings.Join(path, "."))})
+	}
+	if len(errs) > 0 {
+		return errs, false
+	}
+
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		line := document.StartLine()
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+			line = locator.line(strings.Split(typeErr.Field, "."))
+		}
+		return []lintError{{Line: line, Policy: name, Message: err.Error()}}, false
+	}
+
+	if err := policyvalidate.Validate(policy); err != nil {
+		path, message := splitValidationError(err)
+		line := document.StartLine()
+		if len(path) > 0 {
+			line = locator.line(path)
+		}
+		return []lintError{{Line: line, Policy: name, Message: message}}, false
+	}
+	return nil, false
+}
+
+// nestedString returns the string of the nested field of the object, empty if it is not a string
+func nestedString(object map[string]interface{}, fields ...string) string {
+	var value interface{} = object
+	for _, field := range fields {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = m[field]
+	}
+	s, _ := value.(string)
+	return s
+}
+
+// validationErrorPath matches the separators of the paths of the errors of the policy validation,
+// e.g. spec.rules[0].validate.pattern/metadata/labels/(app).
+var validationErrorPath = regexp.MustCompile(`[./\[\]]+`)
+
+// splitValidationError returns the path and the message of an error of the policy validation,
+// the path is empty if the error has no path
+func splitValidationError(err error) ([]string, string) {
+	message := err.Error()
+	if !strings.HasPrefix(message, "path: ") {
+		return nil, message
+	}
+	i := strings.Index(message[len("path: "):], ": ")
+	if i < 0 {
+		return nil, message
+	}
+	rawPath := message[len("path: ") : len("path: ")+i]
+	var path []string
+	for _, segment := range validationErrorPath.Split(rawPath, -1) {
+		if segment != "" {
+			path = append(path, segment)
+		}
+	}
+	return path, message[len("path: "):]
+}
+
+var jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// unknownFields returns the paths of the fields of the value that are not fields of the type,
+// the fields of the interface types, e.g. the patterns, and of the types with a custom decoding are not checked
+func unknownFields(value interface{}, t reflect.Type, path []string) [][]string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+		return nil
+	}
+	var unknown [][]string
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		fields := jsonFields(t)
+		for _, key := range sortedKeys(m) {
+			fieldPath := append(append([]string{}, path...), key)
+			fieldType, ok := fields[key]
+			if !ok {
+				unknown = append(unknown, fieldPath)
+				continue
+			}
+			unknown = append(unknown, unknownFields(m[key], fieldType, fieldPath)...)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			unknown = append(unknown, unknownFields(item, t.Elem(), append(append([]string{}, path...), strconv.Itoa(i)))...)
+		}
+	case reflect.Map:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for _, key := range sortedKeys(m) {
+			unknown = append(unknown, unknownFields(m[key], t.Elem(), append(append([]string{}, path...), key))...)
+		}
+	}
+	return unknown
+}
+
+// jsonFields returns the types of the JSON fields of the struct, including the fields of the embedded structs
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		inline := len(tag) > 1 && tag[1] == "inline"
+		if (f.Anonymous && name == "") || inline {
+			embedded := f.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for k, v := range jsonFields(embedded) {
+					fields[k] = v
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
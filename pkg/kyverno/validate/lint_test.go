@@ -0,0 +1,203 @@
+package validate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+const validPolicy = `# a valid policy
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: require-labels
+spec:
+  rules:
+  - name: check-app
+    match:
+      resources:
+        kinds: [Pod]
+    validate:
+      message: "label app is required"
+      pattern:
+        metadata:
+          labels:
+            app: "?*"
+`
+
+func Test_LintValidPolicy(t *testing.T) {
+	results := lintFile("policy.yaml", []byte(validPolicy+"---\n"))
+	assert.DeepEqual(t, results, []lintResult{{File: "policy.yaml", Line: 1, Policy: "require-labels", Valid: true}})
+}
+
+func Test_LintErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   string
+		expected lintError
+	}{
+		{
+			name: "unknown field",
+			policy: `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: p
+spec:
+  rules:
+  - name: r
+    match:
+      resources:
+        kinds: [Pod]
+    validate:
+      mesage: "typo"
+      pattern:
+        metadata:
+          name: "?*"
+`,
+			expected: lintError{Line: 12, Policy: "p", Message: "unknown field spec.rules.0.validate.mesage"},
+		},
+		{
+			name: "unsupported anchor",
+			policy: `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: p
+spec:
+  rules:
+  - name: r
+    match:
+      resources:
+        kinds:
+        - Pod
+    validate:
+      pattern:
+        metadata:
+          labels:
+            +(app): "?*"
+`,
+			expected: lintError{Line: 16, Policy: "p"},
+		},
+		{
+			name: "several rule types",
+			policy: `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: p
+spec:
+  rules:
+  - name: first
+    match:
+      resources:
+        kinds: [Pod]
+    validate:
+      pattern:
+        metadata:
+          name: "?*"
+  -
+    name: second
+    match:
+      resources:
+        kinds: [Pod]
+    mutate:
+      overlay:
+        metadata:
+          labels:
+            +(app): web
+    validate:
+      pattern:
+        metadata:
+          name: "?*"
+`,
+			expected: lintError{Line: 15, Policy: "p"},
+		},
+		{
+			name: "unknown variable",
+			policy: `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: p
+spec:
+  rules:
+  - name: r
+    match:
+      resources:
+        kinds: [Pod]
+    validate:
+      message: "{{request.objct.metadata.name}} is invalid"
+      pattern:
+        metadata:
+          name: "?*"
+`,
+			expected: lintError{Line: 12, Policy: "p"},
+		},
+		{
+			name: "wrong type",
+			policy: `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: p
+spec:
+  validationFailureAction: audit
+  rules:
+  - name: r
+    match:
+      resources:
+        kinds: Pod
+`,
+			expected: lintError{Line: 11, Policy: "p"},
+		},
+		{
+			name: "yaml syntax",
+			policy: `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: p
+ spec:
+`,
+			// the parser reports the error at the end of the block mapping
+			expected: lintError{Line: 4},
+		},
+		{
+			name: "not a policy",
+			policy: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: p
+`,
+			expected: lintError{Line: 2, Policy: "p", Message: `kind "ConfigMap" is not ClusterPolicy or Policy`},
+		},
+	}
+	for _, tc := range testCases {
+		// the policy is the second document, after a valid policy
+		results := lintFile("policy.yaml", []byte(validPolicy+"---\n"+tc.policy))
+		assert.Equal(t, len(results), 2, tc.name)
+		assert.Assert(t, results[0].Valid, tc.name)
+		assert.Assert(t, !results[1].Valid, tc.name)
+		errs := results[1].Errors
+		assert.Equal(t, len(errs), 1, tc.name)
+		// the lines of the valid policy and of the separator
+		assert.Equal(t, errs[0].Line, tc.expected.Line+18, "%s: %s", tc.name, errs[0])
+		assert.Equal(t, errs[0].Policy, tc.expected.Policy, tc.name)
+		if tc.expected.Message != "" {
+			assert.Equal(t, errs[0].Message, tc.expected.Message, tc.name)
+		}
+	}
+}
+
+func Test_WriteJUnit(t *testing.T) {
+	results := lintFile("policy.yaml", []byte(validPolicy+"---\n"+`apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: invalid
+spec:
+  rules:
+  - name: r
+    unknown: true
+`))
+	var out bytes.Buffer
+	assert.NilError(t, writeJUnit(&out, report{Results: results}))
+	assert.Assert(t, strings.Contains(out.String(), `<testsuite name="policy.yaml" tests="2" failures="1" errors="0" skipped="0">`), out.String())
+	assert.Assert(t, strings.Contains(out.String(), `<failure message="26: unknown field spec.rules.0.unknown"></failure>`), out.String())
+}
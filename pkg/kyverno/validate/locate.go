@@ -0,0 +1,115 @@
+package validate
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+)
+
+// lineLocator finds the line of a field of a YAML document from its path, for the block style of the policies.
+// The fields of the flow style, e.g. kinds: [Pod], are located at the line of their parent.
+type lineLocator struct {
+	lines []yamlLine
+	// first is the line of the first line of the document in the file
+	first int
+	start int
+}
+
+type yamlLine struct {
+	indent int
+	// text is the content of the line after its indentation, empty for the blank lines and the comments
+	text string
+}
+
+func newLineLocator(document common.Document) lineLocator {
+	var lines []yamlLine
+	for _, line := range strings.Split(string(document.Data), "\n") {
+		text := strings.TrimLeft(line, " ")
+		indent := len(line) - len(text)
+		text = strings.TrimRight(text, " \t\r")
+		if strings.HasPrefix(text, "#") {
+			text = ""
+		}
+		lines = append(lines, yamlLine{indent: indent, text: text})
+	}
+	return lineLocator{lines: lines, first: document.Line, start: document.StartLine()}
+}
+
+// line returns the line of the field of the path in the file, or of its closest parent found,
+// the segments of the path are the keys of the maps and the indexes of the lists
+func (l lineLocator) line(path []string) int {
+	lines := make([]yamlLine, len(l.lines))
+	copy(lines, l.lines)
+	found := -1
+	// the lines of the current block are after from, with an indentation greater than parent
+	from, parent := 0, -1
+	for _, segment := range path {
+		index, err := strconv.Atoi(segment)
+		isIndex := err == nil
+		matched := false
+		count := 0
+		child := -1
+		for i := from; i < len(lines) && !matched; i++ {
+			line := lines[i]
+			if line.text == "" {
+				continue
+			}
+			isItem := line.text == "-" || strings.HasPrefix(line.text, "- ")
+			// the items of a list can have the indentation of the key of the list
+			if line.indent < parent || (line.indent == parent && !(isIndex && isItem)) {
+				break
+			}
+			if child == -1 {
+				child = line.indent
+			}
+			if line.indent != child {
+				continue
+			}
+			if isIndex {
+				if !isItem {
+					continue
+				}
+				if count == index {
+					matched = true
+					found = i
+					// the fields of the item are indented after the dash
+					lines[i] = yamlLine{indent: line.indent + 2, text: strings.TrimLeft(line.text[1:], " ")}
+					from, parent = i, line.indent
+				}
+				count++
+				continue
+			}
+			if keyOf(line.text) == segment {
+				matched = true
+				found = i
+				from, parent = i+1, line.indent
+			}
+		}
+		if !matched {
+			break
+		}
+	}
+	if found == -1 {
+		return l.start
+	}
+	return l.first + found
+}
+
+// keyOf returns the key of the line of a map, empty if the line is not a key
+func keyOf(text string) string {
+	for _, quote := range []string{`"`, `'`} {
+		if strings.HasPrefix(text, quote) {
+			end := strings.Index(text[1:], quote)
+			if end < 0 || !strings.HasPrefix(text[end+2:], ":") {
+				return ""
+			}
+			return text[1 : end+1]
+		}
+	}
+	i := strings.Index(text, ":")
+	if i < 0 || (i+1 < len(text) && text[i+1] != ' ') {
+		return ""
+	}
+	return text[:i]
+}
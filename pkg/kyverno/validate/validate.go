@@ -0,0 +1,132 @@
+package validate
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"github.com/spf13/cobra"
+)
+
+const (
+	validateExample = `  # Check the policies of files and directories, e.g. in a pre-commit hook.
+  kyverno validate policy.yaml policies/
+
+  # Report the invalid policies as JUnit, e.g. for the test reports of a CI pipeline.
+  kyverno validate policies/ --output=junit > kyverno.xml`
+
+	// outputText is the errors of the invalid policies, with their file and line
+	outputText = "text"
+)
+
+// report is the report of the lint of the policies, for the json and yaml outputs
+type report struct {
+	Results []lintResult `json:"results"`
+	Summary summary      `json:"summary"`
+}
+
+type summary struct {
+	Valid   int `json:"valid"`
+	Invalid int `json:"invalid"`
+}
+
+// NewCmdValidate returns the validate command, it checks the policies against the schema of the CRDs
+// and the checks of the policy validation webhook, without a cluster
+func NewCmdValidate(out io.Writer) *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:     "validate <policy file or directory>...",
+		Short:   "Check the policy files, and report the errors with their line",
+		Example: validateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := common.ValidateOutput(outputFormat, outputText, common.OutputJSON, common.OutputYAML, common.OutputJUnit); err != nil {
+				glog.Errorf("%v", err)
+				os.Exit(common.ExitError)
+			}
+			if len(args) == 0 {
+				glog.Errorf("missing policy manifest")
+				os.Exit(common.ExitError)
+			}
+			files, err := common.ExpandPaths(args)
+			if err != nil {
+				glog.Errorf("Failed to list the policy files: %v", err)
+				os.Exit(common.ExitError)
+			}
+			r := report{Results: []lintResult{}}
+			for _, file := range files {
+				data, err := ioutil.ReadFile(file)
+				if err != nil {
+					glog.Errorf("Failed to read %s: %v", file, err)
+					os.Exit(common.ExitError)
+				}
+				for _, result := range lintFile(file, data) {
+					if result.Valid {
+						r.Summary.Valid++
+					} else {
+						r.Summary.Invalid++
+					}
+					r.Results = append(r.Results, result)
+				}
+			}
+			switch outputFormat {
+			case common.OutputJSON, common.OutputYAML:
+				err = common.WriteReport(out, outputFormat, r)
+			case common.OutputJUnit:
+				err = writeJUnit(out, r)
+			default:
+				printErrors(out, r)
+			}
+			if err != nil {
+				glog.Errorf("Failed to write the %s output: %v", outputFormat, err)
+				os.Exit(common.ExitError)
+			}
+			if r.Summary.Invalid > 0 {
+				os.Exit(common.ExitFailure)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", outputText, "output format: text for the errors, json, yaml or junit for the results of the policies")
+	return cmd
+}
+
+// printErrors writes the errors of the invalid policies with their file and line, and a summary
+func printErrors(w io.Writer, r report) {
+	for _, result := range r.Results {
+		for _, lintErr := range result.Errors {
+			fmt.Fprintf(w, "%s:%s\n", result.File, lintErr)
+		}
+	}
+	fmt.Fprintf(w, "%d valid, %d invalid policies\n", r.Summary.Valid, r.Summary.Invalid)
+}
+
+// writeJUnit writes the results as a JUnit report, with a test suite for each file and a test case for each policy
+func writeJUnit(w io.Writer, r report) error {
+	var suites []common.JUnitTestSuite
+	index := map[string]int{}
+	for _, result := range r.Results {
+		i, ok := index[result.File]
+		if !ok {
+			i = len(suites)
+			index[result.File] = i
+			suites = append(suites, common.JUnitTestSuite{Name: result.File})
+		}
+		name := result.Policy
+		if name == "" {
+			name = fmt.Sprintf("line %d", result.Line)
+		}
+		status, message := common.StatusPass, ""
+		if !result.Valid {
+			var messages []string
+			for _, lintErr := range result.Errors {
+				messages = append(messages, fmt.Sprintf("%d: %s", lintErr.Line, lintErr.Message))
+			}
+			status, message = common.StatusFail, strings.Join(messages, "; ")
+		}
+		suites[i].Cases = append(suites[i].Cases, common.NewJUnitTestCase(name, result.File, status, message))
+	}
+	return common.WriteJUnit(w, suites)
+}
@@ -0,0 +1,97 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/nirmata/kyverno/pkg/config"
+	"github.com/nirmata/kyverno/pkg/metrics"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// Elector elects the leader of the kyverno replicas with a ConfigMap lock of the kyverno namespace,
+// the controllers run on the leader only while all the replicas serve the webhooks
+type Elector struct {
+	name    string
+	id      string
+	lock    resourcelock.Interface
+	run     func(stopCh <-chan struct{})
+	leading int32
+}
+
+// NewElector returns an elector for the replica id with the lock of the ConfigMap name, run starts the controllers
+// of the leader until the stop channel is closed
+func NewElector(kubeClient kubernetes.Interface, name, id string, run func(stopCh <-chan struct{})) (*Elector, error) {
+	lock, err := resourcelock.New(resourcelock.ConfigMapsResourceLock,
+		config.KubePolicyNamespace,
+		name,
+		kubeClient.CoreV1(),
+		kubeClient.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: id})
+	if err != nil {
+		return nil, err
+	}
+	return &Elector{name: name, id: id, lock: lock, run: run}, nil
+}
+
+// Run campaigns for the leadership until stopCh is closed, the lock is released on shutdown so another replica
+// takes over without waiting for the lease to expire. Kyverno exits if the leadership is lost, to restart the
+// controllers from a clean state.
+func (e *Elector) Run(stopCh <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	glog.Infof("Starting leader election %s of %s", e.name, e.id)
+	// RunOrDie returns when the leadership is lost or stopCh is closed
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            e.lock,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		ReleaseOnCancel: true,
+		Name:            e.name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				glog.Infof("%s is the leader, starting the controllers", e.id)
+				atomic.StoreInt32(&e.leading, 1)
+				metrics.RecordLeader(true)
+				e.run(ctx.Done())
+			},
+			OnStoppedLeading: func() {
+				select {
+				case <-stopCh:
+					if atomic.CompareAndSwapInt32(&e.leading, 1, 0) {
+						metrics.RecordLeader(false)
+						glog.Infof("%s released the leadership", e.id)
+					}
+				default:
+					glog.Fatalf("%s lost the leadership", e.id)
+				}
+			},
+			OnNewLeader: func(identity string) {
+				if identity != e.id {
+					glog.Infof("%s is the leader", identity)
+				}
+			},
+		},
+	})
+}
+
+// IsLeader returns true if the replica is the leader
+func (e *Elector) IsLeader() bool {
+	return atomic.LoadInt32(&e.leading) == 1
+}
@@ -0,0 +1,65 @@
+package leader
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// runElector runs the elector until the returned stop channel is closed, the started channel is closed
+// when the replica starts leading
+func runElector(t *testing.T, kubeClient *fake.Clientset, id string) (*Elector, chan struct{}, chan struct{}, chan struct{}) {
+	started := make(chan struct{})
+	elector, err := NewElector(kubeClient, "kyverno", id, func(stopCh <-chan struct{}) {
+		close(started)
+	})
+	assert.NilError(t, err)
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		elector.Run(stopCh)
+		close(done)
+	}()
+	return elector, stopCh, started, done
+}
+
+func Test_Elector(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+
+	first, stopFirst, firstStarted, firstDone := runElector(t, kubeClient, "kyverno-1")
+	select {
+	case <-firstStarted:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the first replica did not start leading")
+	}
+	assert.Assert(t, first.IsLeader())
+
+	// the controllers of the other replicas are not started while the lock is held
+	second, stopSecond, secondStarted, secondDone := runElector(t, kubeClient, "kyverno-2")
+	defer func() {
+		close(stopSecond)
+		<-secondDone
+	}()
+	select {
+	case <-secondStarted:
+		t.Fatal("the second replica started leading while the lock is held")
+	case <-time.After(retryPeriod + time.Second):
+	}
+	assert.Assert(t, !second.IsLeader())
+
+	// the lock is released on shutdown, the other replica takes over
+	close(stopFirst)
+	<-firstDone
+	assert.Assert(t, !first.IsLeader())
+	select {
+	case <-secondStarted:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the second replica did not take over the leadership")
+	}
+	assert.NilError(t, wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		return second.IsLeader(), nil
+	}))
+}
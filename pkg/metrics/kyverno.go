@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+)
+
+// latencyBuckets are the upper bounds in seconds of the latency histograms
+var latencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// scanBuckets are the upper bounds in seconds of the background scan histogram
+var scanBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 600}
+
+// DefaultRegistry is the registry of the kyverno metrics served at /metrics
+var DefaultRegistry = NewRegistry()
+
+var (
+	admissionRequests = DefaultRegistry.NewCounter("kyverno_admission_requests_total",
+		"Number of the admission requests of the resources, by operation, kind and result (allowed or rejected).",
+		"operation", "kind", "result")
+	admissionRequestDuration = DefaultRegistry.NewHistogram("kyverno_admission_request_duration_seconds",
+		"Latency of the admission requests of the resources, by operation and kind.",
+		latencyBuckets, "operation", "kind")
+	admissionRequestsInFlight = DefaultRegistry.NewGauge("kyverno_admission_requests_in_flight",
+		"Number of the admission requests being processed.")
+	policyResults = DefaultRegistry.NewCounter("kyverno_policy_results_total",
+		"Number of the rule results, by policy, rule, rule type, result (pass or fail) and source (admission or background).",
+		"policy", "rule", "rule_type", "result", "source")
+	leader = DefaultRegistry.NewGauge("kyverno_leader",
+		"1 if the replica is the leader running the controllers, with the leader election.")
+	ruleExecutionDuration = DefaultRegistry.NewHistogram("kyverno_policy_rule_execution_duration_seconds",
+		"Latency of the rule executions, by policy, rule and rule type.",
+		latencyBuckets, "policy", "rule", "rule_type")
+	backgroundScanDuration = DefaultRegistry.NewHistogram("kyverno_background_scan_duration_seconds",
+		"Duration of the background scans of the existing resources, by policy.",
+		scanBuckets, "policy")
+
+	// Policies is the number of the policies by rule type (mutate, validate, generate or verifyImages)
+	Policies = DefaultRegistry.NewGaugeFunc("kyverno_policies",
+		"Number of the policies with rules of the type.", "rule_type")
+	// QueueDepth is the number of the items waiting in the queues of the controllers, by queue
+	QueueDepth = DefaultRegistry.NewGaugeFunc("kyverno_queue_depth",
+		"Number of the items waiting in the queue.", "queue")
+)
+
+// RecordLeader records the leadership of the replica
+func RecordLeader(leading bool) {
+	if leading {
+		leader.Add(1)
+		return
+	}
+	leader.Add(-1)
+}
+
+// RecordBackgroundScan records the duration of a background scan of the policy
+func RecordBackgroundScan(policy string, duration time.Duration) {
+	backgroundScanDuration.Observe(duration.Seconds(), policy)
+}
+
+// sources of the policy results
+const (
+	SourceAdmission  = "admission"
+	SourceBackground = "background"
+)
+
+// AdmissionRequestStarted counts the admission request in flight, the returned function records its result
+func AdmissionRequestStarted(operation, kind string) func(allowed bool) {
+	startTime := time.Now()
+	admissionRequestsInFlight.Add(1)
+	return func(allowed bool) {
+		admissionRequestsInFlight.Add(-1)
+		result := "allowed"
+		if !allowed {
+			result = "rejected"
+		}
+		admissionRequests.Inc(operation, kind, result)
+		admissionRequestDuration.Observe(time.Since(startTime).Seconds(), operation, kind)
+	}
+}
+
+// RecordEngineResponses records the rule results and latencies of the engine responses
+func RecordEngineResponses(source string, engineResponses []response.EngineResponse) {
+	for _, er := range engineResponses {
+		for _, rule := range er.PolicyResponse.Rules {
+			result := "pass"
+			if !rule.Success {
+				result = "fail"
+			}
+			policyResults.Inc(er.PolicyResponse.Policy, rule.Name, rule.Type, result, source)
+			ruleExecutionDuration.Observe(rule.RuleStats.ProcessingTime.Seconds(), er.PolicyResponse.Policy, rule.Name, rule.Type)
+		}
+	}
+}
+
+// CountPolicies returns the number of the policies with rules of each type
+func CountPolicies(policies []*kyverno.ClusterPolicy) map[string]int {
+	counts := map[string]int{"mutate": 0, "validate": 0, "generate": 0, "verifyImages": 0}
+	for _, policy := range policies {
+		types := map[string]bool{}
+		for _, rule := range policy.Spec.Rules {
+			if rule.HasMutate() {
+				types["mutate"] = true
+			}
+			if rule.HasValidate() {
+				types["validate"] = true
+			}
+			if rule.HasGenerate() {
+				types["generate"] = true
+			}
+			if rule.HasVerifyImages() {
+				types["verifyImages"] = true
+			}
+		}
+		for t := range types {
+			counts[t]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,295 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// collector writes its samples in the Prometheus text format
+type collector interface {
+	metricName() string
+	write(w io.Writer)
+}
+
+// Registry is a set of metrics exposed in the Prometheus text format
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+	// disabled are the names of the metrics not written
+	disabled map[string]bool
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Disable sets the names of the metrics not written, the other metrics are written
+func (r *Registry) Disable(names []string) {
+	disabled := map[string]bool{}
+	for _, name := range names {
+		disabled[name] = true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.disabled = disabled
+}
+
+// Handler serves the metrics of the registry
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// Write writes the metrics of the registry in the Prometheus text format
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	var collectors []collector
+	for _, c := range r.collectors {
+		if !r.disabled[c.metricName()] {
+			collectors = append(collectors, c)
+		}
+	}
+	r.mu.Unlock()
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// vec stores the series of a metric by their label values
+type vec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	series map[string][]string
+}
+
+func newVec(name, help string, labels []string) vec {
+	return vec{name: name, help: help, labels: labels, series: map[string][]string{}}
+}
+
+// key returns the key of the label values, the missing values are empty
+func (v *vec) key(values []string) (string, []string) {
+	normalized := make([]string, len(v.labels))
+	copy(normalized, values)
+	return strings.Join(normalized, "\xff"), normalized
+}
+
+// keys returns the sorted keys of the series, the caller holds the lock
+func (v *vec) keys() []string {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *vec) metricName() string {
+	return v.name
+}
+
+func (v *vec) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, v.help, v.name, kind)
+}
+
+// Counter is a counter with labels
+type Counter struct {
+	vec
+	values map[string]float64
+}
+
+// NewCounter registers a counter with the labels
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{vec: newVec(name, help, labels), values: map[string]float64{}}
+	r.register(c)
+	return c
+}
+
+// Inc increments the counter of the label values
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds delta to the counter of the label values
+func (c *Counter) Add(delta float64, values ...string) {
+	key, normalized := c.key(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.series[key] = normalized
+	c.values[key] += delta
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w, "counter")
+	for _, key := range c.keys() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, c.series[key]), formatValue(c.values[key]))
+	}
+}
+
+// Gauge is a gauge with labels
+type Gauge struct {
+	vec
+	values map[string]float64
+}
+
+// NewGauge registers a gauge with the labels
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{vec: newVec(name, help, labels), values: map[string]float64{}}
+	r.register(g)
+	return g
+}
+
+// Add adds delta to the gauge of the label values
+func (g *Gauge) Add(delta float64, values ...string) {
+	key, normalized := g.key(values)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.series[key] = normalized
+	g.values[key] += delta
+}
+
+func (g *Gauge) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w, "gauge")
+	for _, key := range g.keys() {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labels, g.series[key]), formatValue(g.values[key]))
+	}
+}
+
+// GaugeFunc is a gauge of a label whose values are computed on each scrape
+type GaugeFunc struct {
+	name  string
+	help  string
+	label string
+	mu    sync.Mutex
+	fns   map[string]func() float64
+}
+
+// NewGaugeFunc registers a gauge of the label, the values of the label are added with Set
+func (r *Registry) NewGaugeFunc(name, help, label string) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, label: label, fns: map[string]func() float64{}}
+	r.register(g)
+	return g
+}
+
+// Set sets the function computing the gauge of the label value
+func (g *GaugeFunc) Set(value string, fn func() float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.fns[value] = fn
+}
+
+func (g *GaugeFunc) metricName() string {
+	return g.name
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+	values := make([]string, 0, len(g.fns))
+	for value := range g.fns {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels([]string{g.label}, []string{value}), formatValue(g.fns[value]()))
+	}
+}
+
+// Histogram is a histogram with labels
+type Histogram struct {
+	vec
+	buckets []float64
+	counts  map[string][]uint64
+	sums    map[string]float64
+	totals  map[string]uint64
+}
+
+// NewHistogram registers a histogram with the upper bounds of the buckets and the labels
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		vec:     newVec(name, help, labels),
+		buckets: buckets,
+		counts:  map[string][]uint64{},
+		sums:    map[string]float64{},
+		totals:  map[string]uint64{},
+	}
+	r.register(h)
+	return h
+}
+
+// Observe adds the value to the histogram of the label values
+func (h *Histogram) Observe(value float64, values ...string) {
+	key, normalized := h.key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.series[key] = normalized
+	counts, ok := h.counts[key]
+	if !ok {
+		counts = make([]uint64, len(h.buckets))
+		h.counts[key] = counts
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			counts[i]++
+		}
+	}
+	h.sums[key] += value
+	h.totals[key]++
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, "histogram")
+	labels := append(append([]string{}, h.labels...), "le")
+	for _, key := range h.keys() {
+		values := h.series[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(labels, append(append([]string{}, values...), formatValue(bound))), h.counts[key][i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(labels, append(append([]string{}, values...), "+Inf")), h.totals[key])
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, values), formatValue(h.sums[key]))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, values), h.totals[key])
+	}
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + labelValueEscaper.Replace(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"gotest.tools/assert"
+)
+
+func Test_Registry(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounter("test_requests_total", "Number of the requests.", "kind", "result")
+	counter.Inc("Pod", "allowed")
+	counter.Inc("Pod", "allowed")
+	counter.Inc("Deployment", "rejected")
+	histogram := registry.NewHistogram("test_duration_seconds", "Latency of the requests.", []float64{0.1, 1}, "kind")
+	histogram.Observe(0.05, "Pod")
+	histogram.Observe(0.5, "Pod")
+	histogram.Observe(5, "Pod")
+	gauge := registry.NewGaugeFunc("test_queue_depth", "Number of the queued items.", "queue")
+	gauge.Set("event", func() float64 { return 3 })
+
+	var buf bytes.Buffer
+	registry.Write(&buf)
+	assert.Equal(t, buf.String(), `# HELP test_requests_total Number of the requests.
+# TYPE test_requests_total counter
+test_requests_total{kind="Deployment",result="rejected"} 1
+test_requests_total{kind="Pod",result="allowed"} 2
+# HELP test_duration_seconds Latency of the requests.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{kind="Pod",le="0.1"} 1
+test_duration_seconds_bucket{kind="Pod",le="1"} 2
+test_duration_seconds_bucket{kind="Pod",le="+Inf"} 3
+test_duration_seconds_sum{kind="Pod"} 5.55
+test_duration_seconds_count{kind="Pod"} 3
+# HELP test_queue_depth Number of the queued items.
+# TYPE test_queue_depth gauge
+test_queue_depth{queue="event"} 3
+`)
+
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Assert(t, strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+	assert.Equal(t, recorder.Body.String(), buf.String())
+
+	// the disabled metrics are not written
+	registry.Disable([]string{"test_requests_total", "test_duration_seconds"})
+	buf.Reset()
+	registry.Write(&buf)
+	assert.Equal(t, buf.String(), `# HELP test_queue_depth Number of the queued items.
+# TYPE test_queue_depth gauge
+test_queue_depth{queue="event"} 3
+`)
+}
+
+func Test_RecordEngineResponses(t *testing.T) {
+	RecordEngineResponses(SourceBackground, []response.EngineResponse{{
+		PolicyResponse: response.PolicyResponse{
+			Policy: "require-labels",
+			Rules: []response.RuleResponse{
+				{Name: "check-app", Type: "Validation", Success: false, RuleStats: response.RuleStats{ProcessingTime: 2 * time.Millisecond}},
+			},
+		},
+	}})
+	done := AdmissionRequestStarted("CREATE", "Pod")
+	done(false)
+
+	var buf bytes.Buffer
+	DefaultRegistry.Write(&buf)
+	out := buf.String()
+	assert.Assert(t, strings.Contains(out, `kyverno_policy_results_total{policy="require-labels",rule="check-app",rule_type="Validation",result="fail",source="background"} 1`))
+	assert.Assert(t, strings.Contains(out, `kyverno_policy_rule_execution_duration_seconds_bucket{policy="require-labels",rule="check-app",rule_type="Validation",le="0.005"} 1`))
+	assert.Assert(t, strings.Contains(out, `kyverno_admission_requests_total{operation="CREATE",kind="Pod",result="rejected"} 1`))
+	assert.Assert(t, strings.Contains(out, "kyverno_admission_requests_in_flight 0"))
+}
+
+func Test_CountPolicies(t *testing.T) {
+	policies := []*kyverno.ClusterPolicy{
+		{Spec: kyverno.Spec{Rules: []kyverno.Rule{
+			{Name: "check-app", Validation: kyverno.Validation{Pattern: map[string]interface{}{"metadata": "?*"}}},
+			{Name: "check-team", Validation: kyverno.Validation{Pattern: map[string]interface{}{"metadata": "?*"}}},
+		}}},
+		{Spec: kyverno.Spec{Rules: []kyverno.Rule{
+			{Name: "verify", VerifyImages: []kyverno.ImageVerification{{Image: "ghcr.io/*"}}},
+		}}},
+	}
+	assert.DeepEqual(t, CountPolicies(policies), map[string]int{"mutate": 0, "validate": 1, "generate": 0, "verifyImages": 1})
+}
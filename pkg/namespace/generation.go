@@ -9,6 +9,7 @@ import (
 	client "github.com/nirmata/kyverno/pkg/dclient"
 	"github.com/nirmata/kyverno/pkg/engine"
 	"github.com/nirmata/kyverno/pkg/engine/context"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
 	"github.com/nirmata/kyverno/pkg/engine/response"
 	policyctr "github.com/nirmata/kyverno/pkg/policy"
 	"github.com/nirmata/kyverno/pkg/policystore"
@@ -119,7 +120,7 @@ func (nsc *NamespaceController) processNamespace(namespace corev1.Namespace) []r
 
 func generateRuleExists(policy *kyverno.ClusterPolicy) bool {
 	for _, rule := range policy.Spec.Rules {
-		if rule.Generation != (kyverno.Generation{}) {
+		if rule.HasGenerate() {
 			return true
 		}
 	}
@@ -143,7 +144,7 @@ func (nsc *NamespaceController) processPolicy(policy *kyverno.ClusterPolicy) {
 		}
 		ns := unstructured.Unstructured{Object: unstr}
 		for _, rule := range policy.Spec.Rules {
-			if rule.Generation == (kyverno.Generation{}) {
+			if !rule.HasGenerate() {
 				continue
 			}
 			ok := engine.MatchesResourceDescription(ns, rule)
@@ -165,14 +166,14 @@ func (nsc *NamespaceController) processPolicy(policy *kyverno.ClusterPolicy) {
 func listpolicies(ns unstructured.Unstructured, pMetaStore policystore.LookupInterface) []kyverno.ClusterPolicy {
 	var filteredpolicies []kyverno.ClusterPolicy
 	glog.V(4).Infof("listing policies for namespace %s", ns.GetName())
-	policies, err := pMetaStore.LookUp(ns.GetKind(), ns.GetNamespace())
+	policies, err := pMetaStore.LookUp(ns.GroupVersionKind(), ns.GetNamespace())
 	if err != nil {
 		glog.Errorf("failed to get list policies: %v", err)
 		return nil
 	}
 	for _, policy := range policies {
 		for _, rule := range policy.Spec.Rules {
-			if rule.Generation == (kyverno.Generation{}) {
+			if !rule.HasGenerate() {
 				continue
 			}
 			ok := engine.MatchesResourceDescription(ns, rule)
@@ -232,6 +233,7 @@ func applyPolicy(client *client.Client, resource unstructured.Unstructured, p ky
 		Policy:      p,
 		Client:      client,
 		Context:     ctx,
+		Log:         enginelog.Glog,
 	}
 	engineResponse := engine.Generate(policyContext)
 	// gather stats
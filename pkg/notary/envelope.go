@@ -0,0 +1,258 @@
+package notary
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha512" // registers the SHA-384 and SHA-512 hashes
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// content type of the signed payload, signing scheme and header parameters of the notary JWS envelopes
+const (
+	payloadContentType  = "application/vnd.cncf.notary.payload.v1+json"
+	signingSchemeX509   = "notary.x509"
+	headerSigningScheme = "io.cncf.notary.signingScheme"
+	headerSigningTime   = "io.cncf.notary.signingTime"
+	headerExpiry        = "io.cncf.notary.expiry"
+)
+
+// jwsEnvelope is the flattened JSON serialization of a JWS, the unprotected header has the certificate chain
+type jwsEnvelope struct {
+	Payload   string `json:"payload"`
+	Protected string `json:"protected"`
+	Header    struct {
+		CertChain [][]byte `json:"x5c"`
+	} `json:"header"`
+	Signature string `json:"signature"`
+}
+
+type protectedHeader struct {
+	Algorithm     string     `json:"alg"`
+	ContentType   string     `json:"cty"`
+	Critical      []string   `json:"crit"`
+	SigningScheme string     `json:"io.cncf.notary.signingScheme"`
+	SigningTime   *time.Time `json:"io.cncf.notary.signingTime"`
+	Expiry        *time.Time `json:"io.cncf.notary.expiry"`
+}
+
+// signedPayload is the descriptor of the signed manifest
+type signedPayload struct {
+	TargetArtifact struct {
+		MediaType string `json:"mediaType"`
+		Digest    string `json:"digest"`
+		Size      int64  `json:"size"`
+	} `json:"targetArtifact"`
+}
+
+// verifyEnvelope verifies the JWS envelope as the notation trust policy does
+// - integrity: the signature of the leaf certificate is valid and the payload is for the digest, always enforced
+// - authenticity: the certificate chain is issued by the trust store and the leaf is a trusted identity, logged at the audit level
+// - expiry: the signature and the certificate chain are not expired, enforced at the strict level
+func verifyEnvelope(raw []byte, digest string, roots *x509.CertPool, identities []map[string]string, level string, now time.Time) error {
+	var env jwsEnvelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return fmt.Errorf("failed to decode the signature envelope: %v", err)
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(env.Protected)
+	if err != nil {
+		return fmt.Errorf("invalid protected header: %v", err)
+	}
+	var header protectedHeader
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return fmt.Errorf("invalid protected header: %v", err)
+	}
+	if err := checkHeader(header); err != nil {
+		return err
+	}
+	if len(env.Header.CertChain) == 0 {
+		return fmt.Errorf("the signature envelope has no certificate chain")
+	}
+	chain := make([]*x509.Certificate, 0, len(env.Header.CertChain))
+	for _, der := range env.Header.CertChain {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return fmt.Errorf("invalid certificate chain: %v", err)
+		}
+		chain = append(chain, cert)
+	}
+	leaf := chain[0]
+
+	// integrity
+	sig, err := base64.RawURLEncoding.DecodeString(env.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	if err := verifyJWS(header.Algorithm, leaf.PublicKey, []byte(env.Protected+"."+env.Payload), sig); err != nil {
+		return err
+	}
+	rawPayload, err := base64.RawURLEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return fmt.Errorf("invalid payload: %v", err)
+	}
+	var p signedPayload
+	if err := json.Unmarshal(rawPayload, &p); err != nil {
+		return fmt.Errorf("failed to decode the payload: %v", err)
+	}
+	if p.TargetArtifact.Digest != digest {
+		return fmt.Errorf("the signature is for the digest %s", p.TargetArtifact.Digest)
+	}
+
+	// authenticity, the chain is verified at the signing time and its expiry is checked below
+	signingTime := now
+	if header.SigningTime != nil {
+		signingTime = *header.SigningTime
+	}
+	err = verifyChain(chain, roots, signingTime)
+	if err == nil {
+		err = checkIdentity(leaf.Subject, identities)
+	}
+	if err != nil {
+		if level != LevelAudit {
+			return err
+		}
+		glog.V(2).Infof("signature authenticity check failed, ignored at the audit level: %v", err)
+	}
+
+	// expiry
+	err = nil
+	if header.Expiry != nil && !now.Before(*header.Expiry) {
+		err = fmt.Errorf("the signature expired at %s", header.Expiry.Format(time.RFC3339))
+	}
+	for _, cert := range chain {
+		if err == nil && (now.Before(cert.NotBefore) || now.After(cert.NotAfter)) {
+			err = fmt.Errorf("the certificate %s is not valid at %s", cert.Subject.String(), now.Format(time.RFC3339))
+		}
+	}
+	if err != nil {
+		if level == LevelStrict {
+			return err
+		}
+		glog.V(2).Infof("signature expiry check failed, ignored at the %s level: %v", level, err)
+	}
+	return nil
+}
+
+// checkHeader checks the envelope is a notary.x509 signature of a notary payload, with no unknown critical parameter
+func checkHeader(header protectedHeader) error {
+	if header.ContentType != payloadContentType {
+		return fmt.Errorf("unsupported payload content type %s", header.ContentType)
+	}
+	if header.SigningScheme != signingSchemeX509 {
+		return fmt.Errorf("unsupported signing scheme %s", header.SigningScheme)
+	}
+	for _, param := range header.Critical {
+		switch param {
+		case headerSigningScheme, headerSigningTime, headerExpiry:
+		default:
+			return fmt.Errorf("unsupported critical header parameter %s", param)
+		}
+	}
+	return nil
+}
+
+// verifyChain checks the leaf is a code signing certificate issued by the trust store
+func verifyChain(chain []*x509.Certificate, roots *x509.CertPool, at time.Time) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return fmt.Errorf("invalid certificate chain: %v", err)
+	}
+	return nil
+}
+
+// checkIdentity checks the subject has the attributes of one of the trusted identities
+func checkIdentity(subject pkix.Name, identities []map[string]string) error {
+	values := map[string][]string{
+		"C":  subject.Country,
+		"ST": subject.Province,
+		"L":  subject.Locality,
+		"O":  subject.Organization,
+		"OU": subject.OrganizationalUnit,
+		"CN": {subject.CommonName},
+	}
+	for _, identity := range identities {
+		matched := true
+		for key, value := range identity {
+			if !contains(values[key], value) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return nil
+		}
+	}
+	return fmt.Errorf("the certificate subject %s is not a trusted identity", subject.String())
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyJWS verifies the JWS signature of the signing input with the RSASSA-PSS or ECDSA algorithms of notary
+func verifyJWS(algorithm string, publicKey crypto.PublicKey, input, sig []byte) error {
+	var hash crypto.Hash
+	var curve elliptic.Curve
+	switch algorithm {
+	case "PS256", "ES256":
+		hash, curve = crypto.SHA256, elliptic.P256()
+	case "PS384", "ES384":
+		hash, curve = crypto.SHA384, elliptic.P384()
+	case "PS512", "ES512":
+		hash, curve = crypto.SHA512, elliptic.P521()
+	default:
+		return fmt.Errorf("unsupported signature algorithm %s", algorithm)
+	}
+	h := hash.New()
+	h.Write(input)
+	hashed := h.Sum(nil)
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if algorithm[0] != 'P' {
+			return fmt.Errorf("the algorithm %s does not match the RSA key", algorithm)
+		}
+		if err := rsa.VerifyPSS(key, hash, hashed, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}); err != nil {
+			return fmt.Errorf("invalid signature")
+		}
+	case *ecdsa.PublicKey:
+		if algorithm[0] != 'E' || key.Curve != curve {
+			return fmt.Errorf("the algorithm %s does not match the ECDSA key", algorithm)
+		}
+		// the signature is the concatenation of r and s
+		size := (curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return fmt.Errorf("invalid signature")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, hashed, r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package notary
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/nirmata/kyverno/pkg/cosign"
+	"github.com/nirmata/kyverno/pkg/registry"
+)
+
+// artifact type of the signature manifests and media type of the JWS signature envelopes
+const (
+	signatureArtifactType = "application/vnd.cncf.notary.signature"
+	jwsMediaType          = "application/jose+json"
+)
+
+// signature verification levels of the trust policy
+// - strict: the signature must be valid, signed by a trusted identity and not expired
+// - permissive: the signature must be valid and signed by a trusted identity, the expiry is logged
+// - audit: the signature must be valid, the identity and the expiry are logged
+const (
+	LevelStrict     = "strict"
+	LevelPermissive = "permissive"
+	LevelAudit      = "audit"
+)
+
+// verifiedTTL is the duration a verified image digest is cached
+const verifiedTTL = time.Hour
+
+// Interface verifies the Notary v2 signatures of the images
+type Interface interface {
+	// Verify checks the image is signed as the trust policy requires and returns the digest of the image
+	Verify(image string, opts Options) (string, error)
+}
+
+// Options is the trust policy of the signatures
+type Options struct {
+	// TrustStore are the PEM encoded certificates of the signing authorities
+	TrustStore string
+	// TrustedIdentities are the subjects of the signing certificates, e.g. "x509.subject: C=US, O=Corp", "*" for any
+	TrustedIdentities []string
+	// Level is the signature verification level, strict if empty
+	Level string
+	// Keychain are the credentials of the registries, e.g. of the image pull secrets of the pod
+	// it does not change the verification, so it is not part of the cache key
+	Keychain registry.Keychain `json:"-"`
+}
+
+// Registry fetches the manifests, the blobs and the referrers of the images
+type Registry interface {
+	FetchManifest(image string, keychain registry.Keychain) ([]byte, string, error)
+	FetchBlob(image, digest string, keychain registry.Keychain) ([]byte, error)
+	FetchReferrers(image, artifactType string, keychain registry.Keychain) ([]registry.Referrer, error)
+}
+
+// Verifier verifies the Notary v2 signatures stored in the registry of the image,
+// the signatures are the referrers of the image manifest with the notary signature artifact type
+type Verifier struct {
+	registry Registry
+	mu       sync.Mutex
+	// verified are the expiration times of the verified digests per repository and options
+	verified map[string]time.Time
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewVerifier returns a verifier that fetches the signatures from the registry
+func NewVerifier(registry Registry) *Verifier {
+	return &Verifier{
+		registry: registry,
+		verified: map[string]time.Time{},
+		now:      time.Now,
+	}
+}
+
+type manifest struct {
+	Layers []struct {
+		MediaType string `json:"mediaType"`
+		Digest    string `json:"digest"`
+	} `json:"layers"`
+}
+
+// Verify checks one of the signatures of the image is valid for the trust policy, the tags are resolved to digests
+// the verified digests are cached so the signatures are fetched once per digest
+func (v *Verifier) Verify(image string, opts Options) (string, error) {
+	roots, err := cosign.ParseCertificates(opts.TrustStore)
+	if err != nil {
+		return "", fmt.Errorf("invalid trust store: %v", err)
+	}
+	identities, err := ParseTrustedIdentities(opts.TrustedIdentities)
+	if err != nil {
+		return "", err
+	}
+	level, err := verificationLevel(opts.Level)
+	if err != nil {
+		return "", err
+	}
+	ref, err := registry.ParseImageReference(image)
+	if err != nil {
+		return "", err
+	}
+	digest := ref.Digest
+	if digest == "" {
+		if _, digest, err = v.registry.FetchManifest(image, opts.Keychain); err != nil {
+			return "", fmt.Errorf("failed to resolve the digest of %s: %v", image, err)
+		}
+	}
+	cacheKey, err := verificationKey(ref, digest, opts)
+	if err != nil {
+		return "", err
+	}
+	if v.isVerified(cacheKey) {
+		glog.V(4).Infof("using the cached verification of %s@%s", ref.Repository, digest)
+		return digest, nil
+	}
+	subject := registry.ImageReference{Registry: ref.Registry, Repository: ref.Repository, Digest: digest}
+	referrers, err := v.registry.FetchReferrers(subject.String(), signatureArtifactType, opts.Keychain)
+	if err != nil {
+		return "", fmt.Errorf("no signatures found for %s@%s: %v", ref.Repository, digest, err)
+	}
+	var errs []string
+	for _, referrer := range referrers {
+		signature := registry.ImageReference{Registry: ref.Registry, Repository: ref.Repository, Digest: referrer.Digest}
+		err := v.verifySignature(signature.String(), digest, roots, identities, level, opts.Keychain)
+		if err == nil {
+			v.mu.Lock()
+			v.verified[cacheKey] = v.now().Add(verifiedTTL)
+			v.mu.Unlock()
+			return digest, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	if len(errs) == 0 {
+		return "", fmt.Errorf("no signatures found for %s@%s", ref.Repository, digest)
+	}
+	return "", fmt.Errorf("no valid signature for %s@%s: %s", ref.Repository, digest, strings.Join(errs, "; "))
+}
+
+// verifySignature checks the envelope of the signature manifest is valid for the digest
+func (v *Verifier) verifySignature(signature, digest string, roots *x509.CertPool, identities []map[string]string, level string, keychain registry.Keychain) error {
+	body, _, err := v.registry.FetchManifest(signature, keychain)
+	if err != nil {
+		return err
+	}
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return fmt.Errorf("failed to decode the signature manifest %s: %v", signature, err)
+	}
+	if len(m.Layers) != 1 {
+		return fmt.Errorf("the signature manifest %s must have one envelope", signature)
+	}
+	if m.Layers[0].MediaType != jwsMediaType {
+		return fmt.Errorf("unsupported signature envelope %s", m.Layers[0].MediaType)
+	}
+	raw, err := v.registry.FetchBlob(signature, m.Layers[0].Digest, keychain)
+	if err != nil {
+		return err
+	}
+	return verifyEnvelope(raw, digest, roots, identities, level, v.now())
+}
+
+// verificationLevel returns the level, strict by default
+func verificationLevel(level string) (string, error) {
+	switch level {
+	case "":
+		return LevelStrict, nil
+	case LevelStrict, LevelPermissive, LevelAudit:
+		return level, nil
+	default:
+		return "", fmt.Errorf("invalid signature verification level %s, must be one of %s, %s, %s", level, LevelStrict, LevelPermissive, LevelAudit)
+	}
+}
+
+// verificationKey identifies the verification of the digest with the options
+func verificationKey(ref registry.ImageReference, digest string, opts Options) (string, error) {
+	rawOpts, err := json.Marshal(opts)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s@%s/%x", ref.Registry, ref.Repository, digest, sha256.Sum256(rawOpts)), nil
+}
+
+func (v *Verifier) isVerified(cacheKey string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	expires, ok := v.verified[cacheKey]
+	if !ok {
+		return false
+	}
+	if !v.now().Before(expires) {
+		delete(v.verified, cacheKey)
+		return false
+	}
+	return true
+}
+
+// ParseTrustedIdentities parses the trusted identities into the attributes the certificate subjects must have,
+// the identities are "*", any subject, or "x509.subject: <distinguished name>", e.g. "x509.subject: C=US, O=Corp, CN=release"
+func ParseTrustedIdentities(identities []string) ([]map[string]string, error) {
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("trusted identities are required")
+	}
+	parsed := make([]map[string]string, 0, len(identities))
+	for _, identity := range identities {
+		if identity == "*" {
+			parsed = append(parsed, map[string]string{})
+			continue
+		}
+		if !strings.HasPrefix(identity, "x509.subject:") {
+			return nil, fmt.Errorf("invalid trusted identity %q, must be * or x509.subject: <distinguished name>", identity)
+		}
+		attributes, err := parseDistinguishedName(strings.TrimPrefix(identity, "x509.subject:"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted identity %q: %v", identity, err)
+		}
+		parsed = append(parsed, attributes)
+	}
+	return parsed, nil
+}
+
+// parseDistinguishedName parses the comma separated attributes of the distinguished name
+func parseDistinguishedName(dn string) (map[string]string, error) {
+	attributes := map[string]string{}
+	for _, attribute := range strings.Split(dn, ",") {
+		kv := strings.SplitN(attribute, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || strings.TrimSpace(kv[1]) == "" {
+			return nil, fmt.Errorf("invalid attribute %q", strings.TrimSpace(attribute))
+		}
+		key := strings.ToUpper(strings.TrimSpace(kv[0]))
+		if _, ok := attributes[key]; ok {
+			return nil, fmt.Errorf("duplicate attribute %s", key)
+		}
+		attributes[key] = strings.TrimSpace(kv[1])
+	}
+	return attributes, nil
+}
@@ -0,0 +1,240 @@
+package notary
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/nirmata/kyverno/pkg/registry"
+	"gotest.tools/assert"
+)
+
+// fakeRegistry serves the manifests, the blobs and the referrers by reference
+type fakeRegistry struct {
+	digests   map[string]string
+	manifests map[string]string
+	blobs     map[string][]byte
+	referrers map[string][]registry.Referrer
+	fetches   int
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		digests:   map[string]string{},
+		manifests: map[string]string{},
+		blobs:     map[string][]byte{},
+		referrers: map[string][]registry.Referrer{},
+	}
+}
+
+func (r *fakeRegistry) FetchManifest(image string, keychain registry.Keychain) ([]byte, string, error) {
+	r.fetches++
+	if digest, ok := r.digests[image]; ok {
+		return nil, digest, nil
+	}
+	if m, ok := r.manifests[image]; ok {
+		return []byte(m), "", nil
+	}
+	return nil, "", fmt.Errorf("manifest %s not found", image)
+}
+
+func (r *fakeRegistry) FetchBlob(image, digest string, keychain registry.Keychain) ([]byte, error) {
+	r.fetches++
+	if blob, ok := r.blobs[digest]; ok {
+		return blob, nil
+	}
+	return nil, fmt.Errorf("blob %s not found", digest)
+}
+
+func (r *fakeRegistry) FetchReferrers(image, artifactType string, keychain registry.Keychain) ([]registry.Referrer, error) {
+	r.fetches++
+	return r.referrers[image], nil
+}
+
+// authority issues code signing certificates
+type authority struct {
+	cert  *x509.Certificate
+	key   *ecdsa.PrivateKey
+	store string
+}
+
+func newAuthority(t *testing.T) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "corp ca"},
+		NotBefore:             time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NilError(t, err)
+	return &authority{cert: cert, key: key, store: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}
+}
+
+// issue returns the DER certificate of the key for the subject
+func (a *authority) issue(t *testing.T, key crypto.Signer, subject pkix.Name, notAfter time.Time) []byte {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      subject,
+		NotBefore:    time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, key.Public(), a.key)
+	assert.NilError(t, err)
+	return der
+}
+
+// sign adds the JWS signature of the digest as a referrer of the image
+func (r *fakeRegistry) sign(t *testing.T, repository, digest string, key crypto.Signer, chain [][]byte, expiry *time.Time) {
+	header := map[string]interface{}{
+		"cty":                          payloadContentType,
+		"crit":                         []string{headerSigningScheme},
+		"io.cncf.notary.signingScheme": signingSchemeX509,
+		"io.cncf.notary.signingTime":   time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if expiry != nil {
+		header["io.cncf.notary.expiry"] = *expiry
+		header["crit"] = []string{headerSigningScheme, headerExpiry}
+	}
+	var sign func(hash []byte) []byte
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		header["alg"] = "PS256"
+		sign = func(hash []byte) []byte {
+			sig, err := rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, hash, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+			assert.NilError(t, err)
+			return sig
+		}
+	case *ecdsa.PrivateKey:
+		header["alg"] = "ES256"
+		sign = func(hash []byte) []byte {
+			r, s, err := ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), hash)
+			assert.NilError(t, err)
+			sig := make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+			return sig
+		}
+	}
+	rawHeader, err := json.Marshal(header)
+	assert.NilError(t, err)
+	payload := fmt.Sprintf(`{"targetArtifact": {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "%s", "size": 100}}`, digest)
+	protected := base64.RawURLEncoding.EncodeToString(rawHeader)
+	encodedPayload := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	hash := sha256.Sum256([]byte(protected + "." + encodedPayload))
+	env, err := json.Marshal(map[string]interface{}{
+		"payload":   encodedPayload,
+		"protected": protected,
+		"header":    map[string]interface{}{"x5c": chain},
+		"signature": base64.RawURLEncoding.EncodeToString(sign(hash[:])),
+	})
+	assert.NilError(t, err)
+	envDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(env))
+	r.blobs[envDigest] = env
+	m := fmt.Sprintf(`{"artifactType": "%s", "layers": [{"mediaType": "%s", "digest": "%s"}]}`, signatureArtifactType, jwsMediaType, envDigest)
+	manifestDigest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(m)))
+	r.manifests[repository+"@"+manifestDigest] = m
+	r.referrers[repository+"@"+digest] = append(r.referrers[repository+"@"+digest], registry.Referrer{ArtifactType: signatureArtifactType, Digest: manifestDigest})
+}
+
+func Test_Verify(t *testing.T) {
+	ca := newAuthority(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NilError(t, err)
+	release := pkix.Name{Country: []string{"US"}, Organization: []string{"Corp"}, CommonName: "release"}
+	notAfter := time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC)
+	expired := time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	reg := newFakeRegistry()
+	reg.digests["registry.corp.com/app:v1"] = "sha256:aaaa"
+	reg.sign(t, "registry.corp.com/app", "sha256:aaaa", ecKey, [][]byte{ca.issue(t, ecKey, release, notAfter)}, nil)
+	reg.sign(t, "registry.corp.com/rsa", "sha256:bbbb", rsaKey, [][]byte{ca.issue(t, rsaKey, release, notAfter)}, nil)
+	reg.sign(t, "registry.corp.com/expired", "sha256:cccc", ecKey, [][]byte{ca.issue(t, ecKey, release, notAfter)}, &expired)
+	reg.sign(t, "registry.corp.com/dev", "sha256:dddd", ecKey, [][]byte{ca.issue(t, ecKey, pkix.Name{Organization: []string{"Corp"}, CommonName: "dev"}, notAfter)}, nil)
+
+	verifier := NewVerifier(reg)
+	verifier.now = func() time.Time { return time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC) }
+	opts := Options{TrustStore: ca.store, TrustedIdentities: []string{"x509.subject: C=US, O=Corp, CN=release"}}
+
+	digest, err := verifier.Verify("registry.corp.com/app:v1", opts)
+	assert.NilError(t, err)
+	assert.Equal(t, digest, "sha256:aaaa")
+
+	// the verified digests are cached
+	fetches := reg.fetches
+	_, err = verifier.Verify("registry.corp.com/app@sha256:aaaa", opts)
+	assert.NilError(t, err)
+	assert.Equal(t, reg.fetches, fetches)
+
+	_, err = verifier.Verify("registry.corp.com/rsa@sha256:bbbb", opts)
+	assert.NilError(t, err)
+
+	_, err = verifier.Verify("registry.corp.com/dev@sha256:dddd", opts)
+	assert.ErrorContains(t, err, "is not a trusted identity")
+	anyIdentity := opts
+	anyIdentity.TrustedIdentities = []string{"*"}
+	_, err = verifier.Verify("registry.corp.com/dev@sha256:dddd", anyIdentity)
+	assert.NilError(t, err)
+
+	// the expiry is only enforced at the strict level
+	_, err = verifier.Verify("registry.corp.com/expired@sha256:cccc", opts)
+	assert.ErrorContains(t, err, "the signature expired at 2021-07-01T00:00:00Z")
+	permissive := opts
+	permissive.Level = LevelPermissive
+	_, err = verifier.Verify("registry.corp.com/expired@sha256:cccc", permissive)
+	assert.NilError(t, err)
+
+	// the authenticity is only logged at the audit level
+	untrusted := opts
+	untrusted.TrustStore = newAuthority(t).store
+	_, err = verifier.Verify("registry.corp.com/rsa@sha256:bbbb", untrusted)
+	assert.ErrorContains(t, err, "invalid certificate chain")
+	untrusted.Level = LevelAudit
+	_, err = verifier.Verify("registry.corp.com/rsa@sha256:bbbb", untrusted)
+	assert.NilError(t, err)
+
+	// the signature of another digest is not valid
+	reg.referrers["registry.corp.com/app@sha256:eeee"] = reg.referrers["registry.corp.com/app@sha256:aaaa"]
+	_, err = verifier.Verify("registry.corp.com/app@sha256:eeee", opts)
+	assert.ErrorContains(t, err, "the signature is for the digest sha256:aaaa")
+
+	_, err = verifier.Verify("registry.corp.com/unsigned@sha256:ffff", opts)
+	assert.ErrorContains(t, err, "no signatures found for unsigned@sha256:ffff")
+}
+
+func Test_ParseTrustedIdentities(t *testing.T) {
+	identities, err := ParseTrustedIdentities([]string{"*", "x509.subject: C=US, o=Corp"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, identities, []map[string]string{{}, {"C": "US", "O": "Corp"}})
+
+	_, err = ParseTrustedIdentities(nil)
+	assert.ErrorContains(t, err, "trusted identities are required")
+	_, err = ParseTrustedIdentities([]string{"CN=release"})
+	assert.ErrorContains(t, err, "must be * or x509.subject")
+	_, err = ParseTrustedIdentities([]string{"x509.subject: C=US, C=FR"})
+	assert.ErrorContains(t, err, "duplicate attribute C")
+	_, err = ParseTrustedIdentities([]string{"x509.subject: C"})
+	assert.ErrorContains(t, err, "invalid attribute")
+}
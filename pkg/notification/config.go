@@ -0,0 +1,93 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// defaults of the config
+const (
+	defaultBatchSize     = 50
+	defaultBatchInterval = 10 * time.Second
+	defaultMaxRetries    = 5
+	defaultTimeout       = 10 * time.Second
+)
+
+// Config configures the sinks of the notifications and their batching
+type Config struct {
+	// BatchSize is the maximum count of notifications sent at once
+	BatchSize int `json:"batchSize,omitempty"`
+	// BatchInterval is the maximum delay of a notification before its batch is sent
+	BatchInterval metav1.Duration `json:"batchInterval,omitempty"`
+	// MaxRetries is the count of the retries of a failed batch, with an exponential backoff
+	MaxRetries *int         `json:"maxRetries,omitempty"`
+	Sinks      []SinkConfig `json:"sinks"`
+}
+
+// SinkConfig configures a sink, exactly one of webhook, slack and syslog is set
+type SinkConfig struct {
+	Name    string         `json:"name"`
+	Webhook *WebhookConfig `json:"webhook,omitempty"`
+	Slack   *SlackConfig   `json:"slack,omitempty"`
+	Syslog  *SyslogConfig  `json:"syslog,omitempty"`
+}
+
+// LoadConfig reads the YAML or JSON config file, e.g. mounted from a Secret
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read the notification config %s: %v", path, err)
+	}
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), len(data)).Decode(&config); err != nil {
+		return config, fmt.Errorf("failed to decode the notification config %s: %v", path, err)
+	}
+	return config, nil
+}
+
+func (c Config) batchSize() int {
+	if c.BatchSize <= 0 {
+		return defaultBatchSize
+	}
+	return c.BatchSize
+}
+
+func (c Config) batchInterval() time.Duration {
+	if c.BatchInterval.Duration <= 0 {
+		return defaultBatchInterval
+	}
+	return c.BatchInterval.Duration
+}
+
+func (c Config) maxRetries() int {
+	if c.MaxRetries == nil {
+		return defaultMaxRetries
+	}
+	return *c.MaxRetries
+}
+
+// newSink returns the sink of the config
+func (c SinkConfig) newSink() (Sink, error) {
+	count := 0
+	for _, set := range []bool{c.Webhook != nil, c.Slack != nil, c.Syslog != nil} {
+		if set {
+			count++
+		}
+	}
+	if count != 1 {
+		return nil, fmt.Errorf("sink %q must have exactly one of webhook, slack and syslog", c.Name)
+	}
+	switch {
+	case c.Webhook != nil:
+		return newWebhookSink(*c.Webhook)
+	case c.Slack != nil:
+		return newSlackSink(*c.Slack)
+	default:
+		return newSyslogSink(*c.Syslog)
+	}
+}
@@ -0,0 +1,311 @@
+package notification
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"github.com/nirmata/kyverno/pkg/utils"
+)
+
+const (
+	// queueSize is the count of notifications waiting to be batched, the next notifications are dropped
+	queueSize = 1000
+	// sinkQueueSize is the count of batches waiting to be sent to a sink, the next batches are dropped
+	sinkQueueSize = 10
+	// retryInterval is the delay of the first retry of a batch, it doubles until maxRetryInterval
+	retryInterval    = time.Second
+	maxRetryInterval = time.Minute
+	// violationTTL is the time the violations already sent are remembered, a violation still reported afterwards is sent again,
+	// so that the violations of the deleted resources and policies are forgotten
+	violationTTL = 24 * time.Hour
+	// maxViolations is the count of the violations remembered, the oldest ones are forgotten first
+	maxViolations = 10000
+)
+
+// Notifier batches the violations and the blocked admission requests, and sends the batches to the sinks
+type Notifier struct {
+	queue         chan Notification
+	batchSize     int
+	batchInterval time.Duration
+	workers       []*sinkWorker
+	// flushed is closed once the queued notifications are dispatched on shutdown
+	flushed chan struct{}
+	running utils.Workers
+	mu      sync.Mutex
+	// violations are the failed rules of the violations already sent, by policy and resource
+	violations map[string]sentViolation
+	now        func() time.Time
+}
+
+// sentViolation is the signature of the failed rules of a violation already sent
+type sentViolation struct {
+	signature string
+	expires   time.Time
+}
+
+// sinkWorker sends the batches to a sink, so that a slow sink does not delay the others
+type sinkWorker struct {
+	name          string
+	sink          Sink
+	batches       chan []Notification
+	maxRetries    int
+	retryInterval time.Duration
+}
+
+// NewNotifier returns a notifier sending the notifications to the sinks of the config
+func NewNotifier(config Config) (*Notifier, error) {
+	sinks := map[string]Sink{}
+	for _, sc := range config.Sinks {
+		if _, ok := sinks[sc.Name]; ok || sc.Name == "" {
+			return nil, fmt.Errorf("sink names must be unique and not empty, got %q", sc.Name)
+		}
+		sink, err := sc.newSink()
+		if err != nil {
+			return nil, err
+		}
+		sinks[sc.Name] = sink
+	}
+	return newNotifier(config, sinks), nil
+}
+
+func newNotifier(config Config, sinks map[string]Sink) *Notifier {
+	n := &Notifier{
+		queue:         make(chan Notification, queueSize),
+		batchSize:     config.batchSize(),
+		batchInterval: config.batchInterval(),
+		violations:    map[string]sentViolation{},
+		now:           time.Now,
+		flushed:       make(chan struct{}),
+	}
+	for name, sink := range sinks {
+		n.workers = append(n.workers, &sinkWorker{
+			name:          name,
+			sink:          sink,
+			batches:       make(chan []Notification, sinkQueueSize),
+			maxRetries:    config.maxRetries(),
+			retryInterval: retryInterval,
+		})
+	}
+	return n
+}
+
+// Add forwards the new and the changed violations, the violations without rules are compliant resources
+func (n *Notifier) Add(infos ...policyviolation.Info) {
+	for _, info := range infos {
+		key := strings.Join([]string{info.PolicyName, info.Resource.GetKind(), info.Resource.GetNamespace(), info.Resource.GetName()}, "/")
+		var rules []Rule
+		var signature []string
+		for _, rule := range info.Rules {
+			rules = append(rules, Rule{Name: rule.Name, Type: rule.Type, Message: rule.Message})
+			signature = append(signature, rule.Name+"="+rule.Message)
+		}
+		sort.Strings(signature)
+		if !n.changed(key, strings.Join(signature, "\n")) {
+			continue
+		}
+		n.enqueue(Notification{
+			Reason:   ReasonViolation,
+			Policy:   info.PolicyName,
+			Resource: Resource{Kind: info.Resource.GetKind(), Namespace: info.Resource.GetNamespace(), Name: info.Resource.GetName()},
+			Rules:    rules,
+			Time:     time.Now(),
+		})
+	}
+}
+
+// changed records the failed rules of the violation, it returns false if they were already sent
+func (n *Notifier) changed(key, signature string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if signature == "" {
+		delete(n.violations, key)
+		return false
+	}
+	now := n.now()
+	if sent, ok := n.violations[key]; ok && sent.signature == signature && now.Before(sent.expires) {
+		return false
+	}
+	if _, ok := n.violations[key]; !ok && len(n.violations) >= maxViolations {
+		n.evict(now)
+	}
+	n.violations[key] = sentViolation{signature: signature, expires: now.Add(violationTTL)}
+	return true
+}
+
+// evict removes the expired violations, or the oldest violation if none expired
+func (n *Notifier) evict(now time.Time) {
+	var oldest string
+	var oldestExpires time.Time
+	for key, sent := range n.violations {
+		if !now.Before(sent.expires) {
+			delete(n.violations, key)
+			continue
+		}
+		if oldest == "" || sent.expires.Before(oldestExpires) {
+			oldest, oldestExpires = key, sent.expires
+		}
+	}
+	if len(n.violations) >= maxViolations {
+		delete(n.violations, oldest)
+	}
+}
+
+// Blocked forwards the failed rules of the engine responses of a rejected admission request
+func (n *Notifier) Blocked(engineResponses []response.EngineResponse) {
+	for _, er := range engineResponses {
+		var rules []Rule
+		for _, rule := range er.PolicyResponse.Rules {
+			if !rule.Success {
+				rules = append(rules, Rule{Name: rule.Name, Type: rule.Type, Message: rule.Message})
+			}
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		resource := er.PolicyResponse.Resource
+		n.enqueue(Notification{
+			Reason:   ReasonBlocked,
+			Policy:   er.PolicyResponse.Policy,
+			Resource: Resource{Kind: resource.Kind, Namespace: resource.Namespace, Name: resource.Name},
+			Rules:    rules,
+			Time:     time.Now(),
+		})
+	}
+}
+
+// enqueue queues the notification without blocking the admission requests
+func (n *Notifier) enqueue(notification Notification) {
+	if len(n.workers) == 0 {
+		return
+	}
+	select {
+	case n.queue <- notification:
+	default:
+		glog.Warningf("notification queue is full, dropping the notification of policy %s", notification.Policy)
+	}
+}
+
+// Len returns the number of the notifications waiting to be batched
+func (n *Notifier) Len() int {
+	return len(n.queue)
+}
+
+// Run batches the notifications until stopCh is closed, the queued notifications are then dispatched
+func (n *Notifier) Run(stopCh <-chan struct{}) {
+	glog.Infof("starting the notifier with %d sinks", len(n.workers))
+	defer glog.Info("shutting down the notifier")
+	for _, w := range n.workers {
+		w := w
+		n.running.Go(func() { w.run(stopCh, n.flushed) }, stopCh)
+	}
+	ticker := time.NewTicker(n.batchInterval)
+	defer ticker.Stop()
+	var batch []Notification
+	for {
+		select {
+		case notification := <-n.queue:
+			batch = append(batch, notification)
+			if len(batch) >= n.batchSize {
+				n.dispatch(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			if len(batch) != 0 {
+				n.dispatch(batch)
+				batch = nil
+			}
+		case <-stopCh:
+			n.flush(batch)
+			return
+		}
+	}
+}
+
+// flush dispatches the batch and the queued notifications
+func (n *Notifier) flush(batch []Notification) {
+	defer close(n.flushed)
+	for {
+		select {
+		case notification := <-n.queue:
+			batch = append(batch, notification)
+			if len(batch) >= n.batchSize {
+				n.dispatch(batch)
+				batch = nil
+			}
+		default:
+			if len(batch) != 0 {
+				n.dispatch(batch)
+			}
+			return
+		}
+	}
+}
+
+// Drain waits for the sinks to be sent the notifications queued on shutdown, once stopCh is closed,
+// it returns false if they are not sent before the timeout. The failed batches are not retried.
+func (n *Notifier) Drain(timeout time.Duration) bool {
+	return n.running.Wait(timeout)
+}
+
+// dispatch queues the batch for each sink
+func (n *Notifier) dispatch(batch []Notification) {
+	for _, w := range n.workers {
+		select {
+		case w.batches <- batch:
+		default:
+			glog.Warningf("queue of sink %s is full, dropping %d notifications", w.name, len(batch))
+		}
+	}
+}
+
+// run sends the batches until stopCh is closed, and then the batches queued until flushed is closed
+func (w *sinkWorker) run(stopCh, flushed <-chan struct{}) {
+	for {
+		select {
+		case batch := <-w.batches:
+			w.send(batch, stopCh)
+		case <-stopCh:
+			<-flushed
+			for {
+				select {
+				case batch := <-w.batches:
+					w.send(batch, stopCh)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// send sends the batch, and retries the failures with an exponential backoff
+func (w *sinkWorker) send(batch []Notification, stopCh <-chan struct{}) {
+	delay := w.retryInterval
+	for attempt := 0; ; attempt++ {
+		err := w.sink.Send(batch)
+		if err == nil {
+			glog.V(4).Infof("sent %d notifications to sink %s", len(batch), w.name)
+			return
+		}
+		if _, ok := err.(permanentError); ok || attempt >= w.maxRetries {
+			glog.Errorf("failed to send %d notifications to sink %s, dropping them: %v", len(batch), w.name, err)
+			return
+		}
+		glog.V(2).Infof("failed to send %d notifications to sink %s, retrying in %v: %v", len(batch), w.name, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-stopCh:
+			return
+		}
+		delay *= 2
+		if delay > maxRetryInterval {
+			delay = maxRetryInterval
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fakeSink records the batches, the first sends fail with err
+type fakeSink struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	attempts int
+	batches  [][]Notification
+}
+
+func (s *fakeSink) Send(notifications []Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	if s.attempts <= s.failures {
+		return s.err
+	}
+	s.batches = append(s.batches, notifications)
+	return nil
+}
+
+func newInfo(policy, name string, rules ...kyverno.ViolatedRule) policyviolation.Info {
+	resource := unstructured.Unstructured{}
+	resource.SetKind("Pod")
+	resource.SetNamespace("default")
+	resource.SetName(name)
+	return policyviolation.Info{PolicyName: policy, Resource: resource, Rules: rules}
+}
+
+func Test_Add_Deduplicates(t *testing.T) {
+	n := newNotifier(Config{}, map[string]Sink{"fake": &fakeSink{}})
+	rule := kyverno.ViolatedRule{Name: "validate-image-tag", Type: "Validation", Message: "image tag latest is not allowed"}
+	n.Add(newInfo("disallow-latest-tag", "nginx", rule))
+	// the background scans report the same violation
+	n.Add(newInfo("disallow-latest-tag", "nginx", rule))
+	assert.Equal(t, n.Len(), 1)
+
+	// the resource is compliant, then violates the policy again
+	n.Add(newInfo("disallow-latest-tag", "nginx"))
+	n.Add(newInfo("disallow-latest-tag", "nginx", rule))
+	assert.Equal(t, n.Len(), 2)
+
+	// the message of the rule changed
+	rule.Message = "image tag is required"
+	n.Add(newInfo("disallow-latest-tag", "nginx", rule))
+	assert.Equal(t, n.Len(), 3)
+
+	notification := <-n.queue
+	assert.Equal(t, notification.Reason, ReasonViolation)
+	assert.Equal(t, notification.Policy, "disallow-latest-tag")
+	assert.DeepEqual(t, notification.Resource, Resource{Kind: "Pod", Namespace: "default", Name: "nginx"})
+	assert.Equal(t, len(notification.Rules), 1)
+}
+
+func Test_Add_Expires(t *testing.T) {
+	n := newNotifier(Config{}, map[string]Sink{"fake": &fakeSink{}})
+	now := time.Now()
+	n.now = func() time.Time { return now }
+	rule := kyverno.ViolatedRule{Name: "validate-image-tag", Type: "Validation", Message: "image tag latest is not allowed"}
+	n.Add(newInfo("disallow-latest-tag", "nginx", rule))
+	n.Add(newInfo("disallow-latest-tag", "nginx", rule))
+	assert.Equal(t, n.Len(), 1)
+
+	// the violation is sent again once forgotten
+	now = now.Add(violationTTL)
+	n.Add(newInfo("disallow-latest-tag", "nginx", rule))
+	assert.Equal(t, n.Len(), 2)
+}
+
+func Test_Add_Bounded(t *testing.T) {
+	n := newNotifier(Config{}, nil)
+	now := time.Now()
+	n.now = func() time.Time { return now }
+	rule := kyverno.ViolatedRule{Name: "validate-image-tag", Message: "image tag latest is not allowed"}
+	for i := 0; i < maxViolations+10; i++ {
+		now = now.Add(time.Millisecond)
+		n.Add(newInfo("disallow-latest-tag", fmt.Sprintf("nginx-%d", i), rule))
+	}
+	assert.Equal(t, len(n.violations), maxViolations)
+	// the oldest violations are forgotten first
+	_, ok := n.violations["disallow-latest-tag/Pod/default/nginx-0"]
+	assert.Assert(t, !ok)
+	_, ok = n.violations[fmt.Sprintf("disallow-latest-tag/Pod/default/nginx-%d", maxViolations+9)]
+	assert.Assert(t, ok)
+
+	// the expired violations are removed when the map is full
+	now = now.Add(violationTTL)
+	n.Add(newInfo("disallow-latest-tag", "nginx", rule))
+	assert.Equal(t, len(n.violations), 1)
+}
+
+func Test_Add_WithoutSinks(t *testing.T) {
+	n := newNotifier(Config{}, nil)
+	n.Add(newInfo("disallow-latest-tag", "nginx", kyverno.ViolatedRule{Name: "validate-image-tag"}))
+	assert.Equal(t, n.Len(), 0)
+}
+
+func Test_Blocked(t *testing.T) {
+	n := newNotifier(Config{}, map[string]Sink{"fake": &fakeSink{}})
+	er := response.EngineResponse{}
+	er.PolicyResponse.Policy = "disallow-latest-tag"
+	er.PolicyResponse.Resource = response.ResourceSpec{Kind: "Pod", Namespace: "default"}
+	er.PolicyResponse.Rules = []response.RuleResponse{
+		{Name: "validate-image-tag", Type: "Validation", Message: "image tag latest is not allowed", Success: false},
+		{Name: "validate-labels", Type: "Validation", Success: true},
+	}
+	passed := response.EngineResponse{}
+	passed.PolicyResponse.Rules = []response.RuleResponse{{Name: "validate-labels", Success: true}}
+
+	n.Blocked([]response.EngineResponse{er, passed})
+	assert.Equal(t, n.Len(), 1)
+	notification := <-n.queue
+	assert.Equal(t, notification.Reason, ReasonBlocked)
+	assert.DeepEqual(t, notification.Rules, []Rule{{Name: "validate-image-tag", Type: "Validation", Message: "image tag latest is not allowed"}})
+}
+
+func Test_Run_Batches(t *testing.T) {
+	sink := &fakeSink{}
+	n := newNotifier(Config{BatchSize: 2, BatchInterval: metav1.Duration{Duration: 50 * time.Millisecond}}, map[string]Sink{"fake": sink})
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go n.Run(stopCh)
+
+	for _, name := range []string{"nginx", "redis", "mysql"} {
+		n.Add(newInfo("disallow-latest-tag", name, kyverno.ViolatedRule{Name: "validate-image-tag"}))
+	}
+	// the full batch is sent at once, the last notification on the batch interval
+	assert.Assert(t, waitFor(func() bool { return len(sink.sent()) == 2 }))
+	batches := sink.sent()
+	assert.Equal(t, len(batches[0]), 2)
+	assert.Equal(t, len(batches[1]), 1)
+}
+
+func Test_Drain_SendsQueued(t *testing.T) {
+	sink := &fakeSink{}
+	n := newNotifier(Config{BatchInterval: metav1.Duration{Duration: time.Hour}}, map[string]Sink{"fake": sink})
+	stopCh := make(chan struct{})
+	go n.Run(stopCh)
+
+	n.Add(newInfo("disallow-latest-tag", "nginx", kyverno.ViolatedRule{Name: "validate-image-tag"}))
+	assert.Assert(t, waitFor(func() bool { return n.Len() == 0 }))
+	// the batch is sent on shutdown, before the batch interval
+	close(stopCh)
+	assert.Assert(t, n.Drain(time.Second))
+	batches := sink.sent()
+	assert.Equal(t, len(batches), 1)
+	assert.Equal(t, batches[0][0].Resource.Name, "nginx")
+}
+
+func Test_send_Retries(t *testing.T) {
+	sink := &fakeSink{failures: 2, err: errors.New("connection refused")}
+	w := &sinkWorker{name: "fake", sink: sink, maxRetries: 2, retryInterval: time.Millisecond}
+	w.send([]Notification{{Policy: "disallow-latest-tag"}}, make(chan struct{}))
+	assert.Equal(t, sink.attempts, 3)
+	assert.Equal(t, len(sink.batches), 1)
+
+	// the batch is dropped after the retries
+	sink = &fakeSink{failures: 5, err: errors.New("connection refused")}
+	w.sink = sink
+	w.send([]Notification{{Policy: "disallow-latest-tag"}}, make(chan struct{}))
+	assert.Equal(t, sink.attempts, 3)
+	assert.Equal(t, len(sink.batches), 0)
+
+	// the rejected payloads are not retried
+	sink = &fakeSink{failures: 5, err: permanentError{errors.New("400 Bad Request")}}
+	w.sink = sink
+	w.send([]Notification{{Policy: "disallow-latest-tag"}}, make(chan struct{}))
+	assert.Equal(t, sink.attempts, 1)
+}
+
+func (s *fakeSink) sent() [][]Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]Notification{}, s.batches...)
+}
+
+func waitFor(condition func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return false
+}
+
+func Test_LoadConfig(t *testing.T) {
+	file, err := ioutil.TempFile("", "notifications")
+	assert.NilError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`
+batchSize: 20
+batchInterval: 30s
+maxRetries: 0
+sinks:
+- name: security
+  slack:
+    url: https://hooks.slack.com/services/T0/B0/X
+- name: siem
+  syslog:
+    address: siem.corp.com:6514
+    network: tls
+`)
+	assert.NilError(t, err)
+	file.Close()
+
+	config, err := LoadConfig(file.Name())
+	assert.NilError(t, err)
+	assert.Equal(t, config.batchSize(), 20)
+	assert.Equal(t, config.batchInterval(), 30*time.Second)
+	assert.Equal(t, config.maxRetries(), 0)
+	n, err := NewNotifier(config)
+	assert.NilError(t, err)
+	assert.Equal(t, len(n.workers), 2)
+
+	config.Sinks = append(config.Sinks, SinkConfig{Name: "siem", Slack: &SlackConfig{URL: "https://hooks.slack.com/services/T0/B0/Y"}})
+	_, err = NewNotifier(config)
+	assert.ErrorContains(t, err, "unique")
+	_, err = NewNotifier(Config{Sinks: []SinkConfig{{Name: "none"}}})
+	assert.ErrorContains(t, err, "exactly one")
+}
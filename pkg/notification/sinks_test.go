@@ -0,0 +1,150 @@
+package notification
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+var testNotifications = []Notification{{
+	Reason:   ReasonBlocked,
+	Policy:   "disallow-latest-tag",
+	Resource: Resource{Kind: "Pod", Namespace: "default", Name: "nginx"},
+	Rules:    []Rule{{Name: "validate-image-tag", Type: "Validation", Message: `image tag "latest" is not allowed`}},
+	Time:     time.Date(2020, 3, 2, 10, 15, 0, 0, time.UTC),
+}}
+
+// recorder is an endpoint recording the requests, it responds with status
+func recorder(t *testing.T, status int, requests *[]*http.Request, bodies *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NilError(t, err)
+		*requests = append(*requests, r)
+		*bodies = append(*bodies, string(body))
+		w.WriteHeader(status)
+	}))
+}
+
+func Test_webhookSink_Template(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	server := recorder(t, http.StatusOK, &requests, &bodies)
+	defer server.Close()
+
+	sink, err := newWebhookSink(WebhookConfig{
+		URL:      server.URL,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		Template: `{"alerts": [{{range $i, $n := .Notifications}}{{if $i}},{{end}}{"title": {{json $n.Policy}}, "reason": "{{$n.Reason}}", "rules": {{json $n.Rules}}}{{end}}]}`,
+	})
+	assert.NilError(t, err)
+	assert.NilError(t, sink.Send(testNotifications))
+
+	assert.Equal(t, len(requests), 1)
+	assert.Equal(t, requests[0].Header.Get("Authorization"), "Bearer token")
+	assert.Equal(t, requests[0].Header.Get("Content-Type"), "application/json")
+	var payload struct {
+		Alerts []struct {
+			Title  string `json:"title"`
+			Reason string `json:"reason"`
+			Rules  []Rule `json:"rules"`
+		} `json:"alerts"`
+	}
+	assert.NilError(t, json.Unmarshal([]byte(bodies[0]), &payload))
+	assert.Equal(t, payload.Alerts[0].Title, "disallow-latest-tag")
+	assert.Equal(t, payload.Alerts[0].Reason, ReasonBlocked)
+	assert.Equal(t, payload.Alerts[0].Rules[0].Message, `image tag "latest" is not allowed`)
+}
+
+func Test_webhookSink_Errors(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	rejecting := recorder(t, http.StatusBadRequest, &requests, &bodies)
+	defer rejecting.Close()
+	failing := recorder(t, http.StatusServiceUnavailable, &requests, &bodies)
+	defer failing.Close()
+
+	sink, err := newWebhookSink(WebhookConfig{URL: rejecting.URL})
+	assert.NilError(t, err)
+	err = sink.Send(testNotifications)
+	_, permanent := err.(permanentError)
+	assert.Assert(t, permanent)
+
+	sink, err = newWebhookSink(WebhookConfig{URL: failing.URL})
+	assert.NilError(t, err)
+	err = sink.Send(testNotifications)
+	_, permanent = err.(permanentError)
+	assert.Assert(t, err != nil && !permanent)
+
+	_, err = newWebhookSink(WebhookConfig{URL: "hooks.corp.com/kyverno"})
+	assert.ErrorContains(t, err, "invalid URL")
+	_, err = newWebhookSink(WebhookConfig{URL: "https://hooks.corp.com", Template: "{{.Notifications"})
+	assert.ErrorContains(t, err, "invalid template")
+}
+
+func Test_slackSink(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	server := recorder(t, http.StatusOK, &requests, &bodies)
+	defer server.Close()
+
+	sink, err := newSlackSink(SlackConfig{URL: server.URL, Channel: "#security"})
+	assert.NilError(t, err)
+	assert.NilError(t, sink.Send(testNotifications))
+	var message map[string]string
+	assert.NilError(t, json.Unmarshal([]byte(bodies[0]), &message))
+	assert.Equal(t, message["channel"], "#security")
+	assert.Equal(t, message["text"], "*Pod* `default/nginx` was blocked by policy `disallow-latest-tag`\n"+
+		"• `validate-image-tag`: image tag \"latest\" is not allowed")
+}
+
+func Test_syslogSink_Format(t *testing.T) {
+	sink, err := newSyslogSink(SyslogConfig{Address: "siem.corp.com:514", Facility: "local4"})
+	assert.NilError(t, err)
+	s := sink.(*syslogSink)
+	s.hostname = "kyverno-7d9c"
+	message := s.format(testNotifications[0])
+	// local4 (20) * 8 + warning (4)
+	assert.Assert(t, strings.HasPrefix(message, "<164>1 2020-03-02T10:15:00.000000Z kyverno-7d9c kyverno "), message)
+	assert.Assert(t, strings.Contains(message, ` RequestBlocked [kyverno@32473 policy="disallow-latest-tag" rules="validate-image-tag" kind="Pod" namespace="default" name="nginx"] `), message)
+	assert.Assert(t, strings.HasSuffix(message, `Pod default/nginx blocked by policy disallow-latest-tag: validate-image-tag: image tag "latest" is not allowed`), message)
+
+	assert.Equal(t, escapeParam(`a"b\c]`), `a\"b\\c\]`)
+	_, err = newSyslogSink(SyslogConfig{Address: "siem.corp.com:514", Facility: "local9"})
+	assert.ErrorContains(t, err, "invalid syslog facility")
+	_, err = newSyslogSink(SyslogConfig{Address: "siem.corp.com"})
+	assert.ErrorContains(t, err, "invalid syslog address")
+}
+
+func Test_syslogSink_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer listener.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := bufio.NewReader(conn).ReadString('\x00')
+		received <- data
+	}()
+
+	sink, err := newSyslogSink(SyslogConfig{Address: listener.Addr().String(), Network: "tcp"})
+	assert.NilError(t, err)
+	assert.NilError(t, sink.Send(testNotifications))
+	data := <-received
+	// octet counting framing
+	parts := strings.SplitN(data, " ", 2)
+	assert.Equal(t, parts[0], strconv.Itoa(len(parts[1])))
+	assert.Assert(t, strings.HasPrefix(parts[1], "<132>1 "))
+}
@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SlackConfig configures a sink posting the notifications to a Slack incoming webhook
+type SlackConfig struct {
+	// URL of the incoming webhook, https://hooks.slack.com/services/...
+	URL string `json:"url"`
+	// Channel overrides the channel of the incoming webhook
+	Channel string `json:"channel,omitempty"`
+	// Username overrides the name of the incoming webhook
+	Username string          `json:"username,omitempty"`
+	Timeout  metav1.Duration `json:"timeout,omitempty"`
+}
+
+type slackSink struct {
+	config SlackConfig
+	client *http.Client
+}
+
+func newSlackSink(config SlackConfig) (Sink, error) {
+	client, err := newHTTPClient(config.URL, "", config.Timeout.Duration)
+	if err != nil {
+		return nil, err
+	}
+	return &slackSink{config: config, client: client}, nil
+}
+
+func (s *slackSink) Send(notifications []Notification) error {
+	message := map[string]string{"text": slackText(notifications)}
+	if s.config.Channel != "" {
+		message["channel"] = s.config.Channel
+	}
+	if s.config.Username != "" {
+		message["username"] = s.config.Username
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return permanentError{err}
+	}
+	return post(s.client, s.config.URL, "application/json", nil, bytes.NewReader(payload))
+}
+
+// slackText returns the message of the notifications, one line per failed rule
+func slackText(notifications []Notification) string {
+	var lines []string
+	for _, n := range notifications {
+		status := "violates"
+		if n.Reason == ReasonBlocked {
+			status = "was blocked by"
+		}
+		lines = append(lines, fmt.Sprintf("*%s* `%s` %s policy `%s`", n.Resource.Kind, resourceName(n.Resource), status, n.Policy))
+		for _, rule := range n.Rules {
+			lines = append(lines, fmt.Sprintf("• `%s`: %s", rule.Name, rule.Message))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// resourceName returns namespace/name, or name for the cluster-wide resources
+func resourceName(r Resource) string {
+	name := r.Name
+	if name == "" {
+		name = "<generated>"
+	}
+	if r.Namespace == "" {
+		return name
+	}
+	return r.Namespace + "/" + name
+}
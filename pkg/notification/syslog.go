@@ -0,0 +1,163 @@
+package notification
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// sdID is the SD-ID of the structured data of the syslog messages, 32473 is the enterprise number reserved for documentation
+const sdID = "kyverno@32473"
+
+// syslog severities of the notifications
+const (
+	severityWarning = 4
+	severityNotice  = 5
+)
+
+// facilities are the syslog facilities by name
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "daemon": 3, "auth": 4, "syslog": 5, "authpriv": 10,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// SyslogConfig configures a sink sending the notifications as RFC 5424 syslog messages
+type SyslogConfig struct {
+	// Address of the syslog server, host:port
+	Address string `json:"address"`
+	// Network is udp (default), tcp or tls, the tcp and tls messages are framed with their length (RFC 6587)
+	Network string `json:"network,omitempty"`
+	// Facility is the name of the facility, local0 by default
+	Facility string `json:"facility,omitempty"`
+	// AppName is kyverno by default
+	AppName string `json:"appName,omitempty"`
+	// CABundle are the PEM encoded CA certificates of the tls server, the system roots are used by default
+	CABundle string          `json:"caBundle,omitempty"`
+	Timeout  metav1.Duration `json:"timeout,omitempty"`
+}
+
+type syslogSink struct {
+	address   string
+	network   string
+	facility  int
+	appName   string
+	hostname  string
+	tlsConfig *tls.Config
+	timeout   time.Duration
+}
+
+func newSyslogSink(config SyslogConfig) (Sink, error) {
+	if _, _, err := net.SplitHostPort(config.Address); err != nil {
+		return nil, fmt.Errorf("invalid syslog address %q: %v", config.Address, err)
+	}
+	sink := &syslogSink{address: config.Address, network: config.Network, appName: config.AppName, timeout: config.Timeout.Duration}
+	switch sink.network {
+	case "":
+		sink.network = "udp"
+	case "udp", "tcp":
+	case "tls":
+		sink.tlsConfig = &tls.Config{}
+		if config.CABundle != "" {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM([]byte(config.CABundle)) {
+				return nil, fmt.Errorf("invalid CA bundle of syslog server %s", config.Address)
+			}
+			sink.tlsConfig.RootCAs = pool
+		}
+	default:
+		return nil, fmt.Errorf("invalid syslog network %q, must be udp, tcp or tls", config.Network)
+	}
+	facility, ok := facilities[config.Facility]
+	if config.Facility == "" {
+		facility, ok = facilities["local0"], true
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid syslog facility %q", config.Facility)
+	}
+	sink.facility = facility
+	if sink.appName == "" {
+		sink.appName = "kyverno"
+	}
+	if sink.timeout <= 0 {
+		sink.timeout = defaultTimeout
+	}
+	sink.hostname, _ = os.Hostname()
+	return sink, nil
+}
+
+func (s *syslogSink) Send(notifications []Notification) error {
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: s.timeout}
+	if s.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.address, s.tlsConfig)
+	} else {
+		conn, err = dialer.Dial(s.network, s.address)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	for _, n := range notifications {
+		message := s.format(n)
+		if s.network != "udp" {
+			// octet counting framing
+			message = fmt.Sprintf("%d %s", len(message), message)
+		}
+		if _, err := conn.Write([]byte(message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// format returns the RFC 5424 message of the notification:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [STRUCTURED-DATA] MSG
+func (s *syslogSink) format(n Notification) string {
+	severity := severityNotice
+	if n.Reason == ReasonBlocked {
+		severity = severityWarning
+	}
+	var rules, messages []string
+	for _, rule := range n.Rules {
+		rules = append(rules, rule.Name)
+		messages = append(messages, rule.Name+": "+rule.Message)
+	}
+	sd := fmt.Sprintf(`[%s policy="%s" rules="%s" kind="%s" namespace="%s" name="%s"]`, sdID,
+		escapeParam(n.Policy), escapeParam(strings.Join(rules, ",")), escapeParam(n.Resource.Kind),
+		escapeParam(n.Resource.Namespace), escapeParam(n.Resource.Name))
+	msg := fmt.Sprintf("%s %s %s policy %s: %s", n.Resource.Kind, resourceName(n.Resource), reasonText(n.Reason), n.Policy, strings.Join(messages, "; "))
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		s.facility*8+severity,
+		n.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		headerField(s.hostname), headerField(s.appName), os.Getpid(), n.Reason, sd, msg)
+}
+
+func reasonText(reason string) string {
+	if reason == ReasonBlocked {
+		return "blocked by"
+	}
+	return "violates"
+}
+
+var paramEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// escapeParam escapes the characters of a PARAM-VALUE: '"', '\' and ']'
+func escapeParam(value string) string {
+	return paramEscaper.Replace(value)
+}
+
+// headerField returns the NILVALUE for the empty fields, and removes the spaces of the field
+func headerField(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return strings.Replace(value, " ", "_", -1)
+}
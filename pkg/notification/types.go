@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/nirmata/kyverno/pkg/engine/response"
+)
+
+// reasons of the notifications, the same as the reasons of the events
+const (
+	// ReasonViolation is a new or changed policy violation of a resource
+	ReasonViolation = "PolicyViolation"
+	// ReasonBlocked is an admission request rejected by the enforced policies
+	ReasonBlocked = "RequestBlocked"
+)
+
+// Notification is a policy violation or a blocked admission request forwarded to the sinks
+type Notification struct {
+	// Reason is PolicyViolation or RequestBlocked
+	Reason   string    `json:"reason"`
+	Policy   string    `json:"policy"`
+	Resource Resource  `json:"resource"`
+	Rules    []Rule    `json:"rules"`
+	Time     time.Time `json:"time"`
+}
+
+// Resource is the resource of the notification
+type Resource struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	// Name is empty for the blocked creations of the resources with a generated name
+	Name string `json:"name,omitempty"`
+}
+
+// Rule is a failed rule of the policy
+type Rule struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// Sink sends the batches of notifications to an external system
+type Sink interface {
+	Send(notifications []Notification) error
+}
+
+// Interface forwards the blocked admission requests to the sinks,
+// the violations are forwarded as a policyviolation.GeneratorInterface
+type Interface interface {
+	// Blocked forwards the failed rules of the engine responses of a rejected admission request
+	Blocked(engineResponses []response.EngineResponse)
+}
+
+// permanentError is an error of a sink that is not retried, e.g. a rejected payload
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
@@ -0,0 +1,136 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WebhookConfig configures a sink posting the batches of notifications to an HTTPS endpoint
+type WebhookConfig struct {
+	URL string `json:"url"`
+	// Headers are added to the requests, e.g. Authorization
+	Headers map[string]string `json:"headers,omitempty"`
+	// Template is the Go template of the payload, executed with .Notifications,
+	// the default payload is {"notifications": [...]}
+	Template string `json:"template,omitempty"`
+	// ContentType of the payload, application/json by default
+	ContentType string `json:"contentType,omitempty"`
+	// CABundle are the PEM encoded CA certificates of the endpoint, the system roots are used by default
+	CABundle string          `json:"caBundle,omitempty"`
+	Timeout  metav1.Duration `json:"timeout,omitempty"`
+}
+
+// templateData is the data of the payload templates
+type templateData struct {
+	Notifications []Notification
+}
+
+// templateFuncs are the functions of the payload templates
+var templateFuncs = template.FuncMap{
+	// json encodes the value, e.g. {{ json .Notifications }} or {{ json .Policy }} for a quoted string
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": strings.Join,
+}
+
+type webhookSink struct {
+	url         string
+	headers     map[string]string
+	template    *template.Template
+	contentType string
+	client      *http.Client
+}
+
+func newWebhookSink(config WebhookConfig) (Sink, error) {
+	client, err := newHTTPClient(config.URL, config.CABundle, config.Timeout.Duration)
+	if err != nil {
+		return nil, err
+	}
+	sink := &webhookSink{url: config.URL, headers: config.Headers, contentType: config.ContentType, client: client}
+	if sink.contentType == "" {
+		sink.contentType = "application/json"
+	}
+	if config.Template != "" {
+		sink.template, err = template.New("payload").Funcs(templateFuncs).Option("missingkey=error").Parse(config.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template of webhook %s: %v", config.URL, err)
+		}
+	}
+	return sink, nil
+}
+
+func (s *webhookSink) Send(notifications []Notification) error {
+	var payload bytes.Buffer
+	data := templateData{Notifications: notifications}
+	if s.template != nil {
+		if err := s.template.Execute(&payload, data); err != nil {
+			return permanentError{fmt.Errorf("failed to execute the template of webhook %s: %v", s.url, err)}
+		}
+	} else if err := json.NewEncoder(&payload).Encode(map[string]interface{}{"notifications": notifications}); err != nil {
+		return permanentError{err}
+	}
+	return post(s.client, s.url, s.contentType, s.headers, &payload)
+}
+
+// newHTTPClient returns the client of the endpoint, with the CA certificates if set
+func newHTTPClient(endpoint, caBundle string, timeout time.Duration) (*http.Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q, must be an https URL", endpoint)
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	if caBundle != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caBundle)) {
+			return nil, fmt.Errorf("invalid CA bundle of %s", endpoint)
+		}
+		client.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		}
+	}
+	return client, nil
+}
+
+// post sends the payload, the client errors other than 429 Too Many Requests are not retried
+func post(client *http.Client, endpoint, contentType string, headers map[string]string, payload io.Reader) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, payload)
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return permanentError{err}
+	}
+	return err
+}
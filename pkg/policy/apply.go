@@ -10,16 +10,20 @@ import (
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/golang/glog"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	client "github.com/nirmata/kyverno/pkg/dclient"
 	"github.com/nirmata/kyverno/pkg/engine"
 	"github.com/nirmata/kyverno/pkg/engine/context"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
 	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/externaldata"
+	"github.com/nirmata/kyverno/pkg/registry"
 	"github.com/nirmata/kyverno/pkg/utils"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // applyPolicy applies policy on a resource
 //TODO: generation rules
-func applyPolicy(policy kyverno.ClusterPolicy, resource unstructured.Unstructured, policyStatus PolicyStatusInterface) (responses []response.EngineResponse) {
+func applyPolicy(policy kyverno.ClusterPolicy, resource unstructured.Unstructured, policyStatus PolicyStatusInterface, client *client.Client, configMapResolver engine.ConfigMapResolver, registryClient registry.Interface, serviceClient externaldata.Interface, globalContext *engine.GlobalContext, exceptions []kyverno.PolicyException) (responses []response.EngineResponse) {
 	startTime := time.Now()
 	var policyStats []PolicyStat
 	glog.V(4).Infof("Started apply policy %s on resource %s/%s/%s (%v)", policy.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), startTime)
@@ -64,9 +68,11 @@ func applyPolicy(policy kyverno.ClusterPolicy, resource unstructured.Unstructure
 	// build context
 	ctx := context.NewContext()
 	ctx.AddResource(transformResource(resource))
+	// the existing resources have no operation
+	ctx.AddRequestInfo("", resource.GetNamespace())
 
 	//MUTATION
-	engineResponse, err = mutation(policy, resource, policyStatus, ctx)
+	engineResponse, err = mutation(policy, resource, policyStatus, ctx, client, configMapResolver, registryClient, serviceClient, globalContext, exceptions)
 	engineResponses = append(engineResponses, engineResponse)
 	if err != nil {
 		glog.Errorf("unable to process mutation rules: %v", err)
@@ -76,7 +82,7 @@ func applyPolicy(policy kyverno.ClusterPolicy, resource unstructured.Unstructure
 	sendStat(false)
 
 	//VALIDATION
-	engineResponse = engine.Validate(engine.PolicyContext{Policy: policy, Context: ctx, NewResource: resource})
+	engineResponse = engine.Validate(engine.PolicyContext{Policy: policy, Context: ctx, NewResource: resource, Client: client, ConfigMapResolver: configMapResolver, ImageRegistryClient: registryClient, ServiceClient: serviceClient, GlobalContext: globalContext, Exceptions: exceptions, Log: enginelog.Glog})
 	engineResponses = append(engineResponses, engineResponse)
 	// gather stats
 	gatherStat(policy.Name, engineResponse.PolicyResponse)
@@ -86,9 +92,9 @@ func applyPolicy(policy kyverno.ClusterPolicy, resource unstructured.Unstructure
 	//TODO: GENERATION
 	return engineResponses
 }
-func mutation(policy kyverno.ClusterPolicy, resource unstructured.Unstructured, policyStatus PolicyStatusInterface, ctx context.EvalInterface) (response.EngineResponse, error) {
+func mutation(policy kyverno.ClusterPolicy, resource unstructured.Unstructured, policyStatus PolicyStatusInterface, ctx context.EvalInterface, client *client.Client, configMapResolver engine.ConfigMapResolver, registryClient registry.Interface, serviceClient externaldata.Interface, globalContext *engine.GlobalContext, exceptions []kyverno.PolicyException) (response.EngineResponse, error) {
 
-	engineResponse := engine.Mutate(engine.PolicyContext{Policy: policy, NewResource: resource, Context: ctx})
+	engineResponse := engine.Mutate(engine.PolicyContext{Policy: policy, NewResource: resource, Context: ctx, Client: client, ConfigMapResolver: configMapResolver, ImageRegistryClient: registryClient, ServiceClient: serviceClient, GlobalContext: globalContext, Exceptions: exceptions, Log: enginelog.Glog})
 	if !engineResponse.IsSuccesful() {
 		glog.V(4).Infof("mutation had errors reporting them")
 		return engineResponse, nil
@@ -3,6 +3,7 @@ package policy
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/golang/glog"
@@ -13,29 +14,42 @@ import (
 	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
 	"github.com/nirmata/kyverno/pkg/config"
 	client "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/engine"
 	"github.com/nirmata/kyverno/pkg/engine/policy"
 	"github.com/nirmata/kyverno/pkg/event"
+	"github.com/nirmata/kyverno/pkg/externaldata"
+	"github.com/nirmata/kyverno/pkg/policyexception"
 	"github.com/nirmata/kyverno/pkg/policystore"
 	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"github.com/nirmata/kyverno/pkg/registry"
 	"github.com/nirmata/kyverno/pkg/webhookconfig"
+	"github.com/nirmata/kyverno/pkg/webhooks/generate"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	corev1informer "k8s.io/client-go/informers/core/v1"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 )
 
 const (
 	// maxRetries is the number of times a Policy will be retried before it is dropped out of the queue.
-	// With the current rate-limiter in use (5ms*2^(maxRetries-1)) the following numbers represent the times
+	// With the default rate-limiter (5ms*2^(maxRetries-1)) the following numbers represent the times
 	// a deployment is going to be requeued:
 	//
 	// 5ms, 10ms, 20ms, 40ms, 80ms, 160ms, 320ms, 640ms, 1.3s, 2.6s, 5.1s, 10.2s, 20.4s, 41s, 82s
 	maxRetries = 15
+	// statusSyncInterval is the interval of the updates of the execution statistics in the policy status,
+	// the statistics change on every admission request so they are not written on each change
+	// each replica adds the statistics of the requests it served since its previous update
+	statusSyncInterval = time.Minute
 )
 
 // PolicyController is responsible for synchronizing Policy objects stored
@@ -52,14 +66,26 @@ type PolicyController struct {
 	pvControl PVControlInterface
 	// Policys that need to be synced
 	queue workqueue.RateLimitingInterface
+	// background scans of the existing resources, keyed by policy/namespace
+	scanQueue workqueue.RateLimitingInterface
+	// checks of the conditions of the policies matching the kinds of the new CRDs
+	conditionsQueue workqueue.RateLimitingInterface
+	// interval of the background scans of all the policies, disabled if 0, read and set atomically
+	scanInterval int64
+	// signals the changes of the scan interval
+	scanIntervalChanged chan struct{}
 	// pLister can list/get policy from the shared informer's store
 	pLister kyvernolister.ClusterPolicyLister
+	// nspLister can list/get namespaced policy from the shared informer's store
+	nspLister kyvernolister.PolicyLister
 	// pvLister can list/get policy violation from the shared informer's store
 	cpvLister kyvernolister.ClusterPolicyViolationLister
 	// nspvLister can list/get namespaced policy violation from the shared informer's store
 	nspvLister kyvernolister.PolicyViolationLister
 	// pListerSynced returns true if the Policy store has been synced at least once
 	pListerSynced cache.InformerSynced
+	// nspListerSynced returns true if the namespaced Policy store has been synced at least once
+	nspListerSynced cache.InformerSynced
 	// pvListerSynced returns true if the Policy store has been synced at least once
 	cpvListerSynced cache.InformerSynced
 	// pvListerSynced returns true if the Policy Violation store has been synced at least once
@@ -76,19 +102,49 @@ type PolicyController struct {
 	pvGenerator policyviolation.GeneratorInterface
 	// resourceWebhookWatcher queues the webhook creation request, creates the webhook
 	resourceWebhookWatcher *webhookconfig.ResourceWebhookRegister
+	// grLister can list/get generate request from the shared informer's store
+	grLister kyvernolister.GenerateRequestNamespaceLister
+	// grListerSynced returns true if the Generate Request store has been synced at least once
+	grListerSynced cache.InformerSynced
+	// nsListerSynced returns true if the Namespace store has been synced at least once
+	nsListerSynced cache.InformerSynced
+	// grGenerator creates the generate requests for the existing resources
+	grGenerator generate.GenerateRequests
+	// configMapResolver gets the ConfigMaps referenced in the rule context
+	configMapResolver engine.ConfigMapResolver
+	// registryClient fetches the image data referenced in the rule context
+	registryClient registry.Interface
+	// serviceClient calls the external services referenced in the rule context
+	serviceClient externaldata.Interface
+	// globalContext caches the data of the global context entries
+	globalContext *engine.GlobalContext
+	// exceptionStore looks up the policy exceptions of the namespace of the resources
+	exceptionStore policyexception.LookupInterface
 }
 
 // NewPolicyController create a new PolicyController
 func NewPolicyController(kyvernoClient *kyvernoclient.Clientset,
 	client *client.Client,
 	pInformer kyvernoinformer.ClusterPolicyInformer,
+	nspInformer kyvernoinformer.PolicyInformer,
 	cpvInformer kyvernoinformer.ClusterPolicyViolationInformer,
 	nspvInformer kyvernoinformer.PolicyViolationInformer,
+	grInformer kyvernoinformer.GenerateRequestInformer,
+	nsInformer corev1informer.NamespaceInformer,
+	dynamicInformer dynamicinformer.DynamicSharedInformerFactory,
 	configHandler config.Interface,
 	eventGen event.Interface,
 	pvGenerator policyviolation.GeneratorInterface,
 	pMetaStore policystore.UpdateInterface,
-	resourceWebhookWatcher *webhookconfig.ResourceWebhookRegister) (*PolicyController, error) {
+	resourceWebhookWatcher *webhookconfig.ResourceWebhookRegister,
+	grGenerator generate.GenerateRequests,
+	configMapResolver engine.ConfigMapResolver,
+	registryClient registry.Interface,
+	serviceClient externaldata.Interface,
+	globalContext *engine.GlobalContext,
+	exceptionStore policyexception.LookupInterface,
+	rateLimiter workqueue.RateLimiter,
+	scanInterval time.Duration) (*PolicyController, error) {
 	// Event broad caster
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(glog.Infof)
@@ -103,11 +159,21 @@ func NewPolicyController(kyvernoClient *kyvernoclient.Clientset,
 		kyvernoClient:          kyvernoClient,
 		eventGen:               eventGen,
 		eventRecorder:          eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "policy_controller"}),
-		queue:                  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "policy"),
+		queue:                  workqueue.NewNamedRateLimitingQueue(rateLimiter, "policy"),
+		scanQueue:              workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), scanQueueName),
+		conditionsQueue:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), conditionsQueueName),
+		scanInterval:           int64(scanInterval),
+		scanIntervalChanged:    make(chan struct{}, 1),
 		configHandler:          configHandler,
 		pMetaStore:             pMetaStore,
 		pvGenerator:            pvGenerator,
 		resourceWebhookWatcher: resourceWebhookWatcher,
+		grGenerator:            grGenerator,
+		configMapResolver:      configMapResolver,
+		registryClient:         registryClient,
+		serviceClient:          serviceClient,
+		globalContext:          globalContext,
+		exceptionStore:         exceptionStore,
 	}
 
 	pc.pvControl = RealPVControl{Client: kyvernoClient, Recorder: pc.eventRecorder}
@@ -118,6 +184,12 @@ func NewPolicyController(kyvernoClient *kyvernoclient.Clientset,
 		DeleteFunc: pc.deletePolicy,
 	})
 
+	nspInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    pc.addNamespacedPolicy,
+		UpdateFunc: pc.updateNamespacedPolicy,
+		DeleteFunc: pc.deleteNamespacedPolicy,
+	})
+
 	cpvInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    pc.addClusterPolicyViolation,
 		UpdateFunc: pc.updateClusterPolicyViolation,
@@ -130,16 +202,37 @@ func NewPolicyController(kyvernoClient *kyvernoclient.Clientset,
 		DeleteFunc: pc.deleteNamespacedPolicyViolation,
 	})
 
+	grInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    pc.addGR,
+		UpdateFunc: pc.updateGR,
+	})
+
+	nsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: pc.updateNamespace,
+	})
+
+	// the policies matching the kinds of a new CRD are checked again
+	crdInformer := dynamicInformer.ForResource(client.DiscoveryClient.GetGVRFromKind("CustomResourceDefinition"))
+	crdInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    pc.addCRD,
+		UpdateFunc: pc.updateCRD,
+	})
+
 	pc.enqueuePolicy = pc.enqueue
 	pc.syncHandler = pc.syncPolicy
 
 	pc.pLister = pInformer.Lister()
+	pc.nspLister = nspInformer.Lister()
 	pc.cpvLister = cpvInformer.Lister()
 	pc.nspvLister = nspvInformer.Lister()
+	pc.grLister = grInformer.Lister().GenerateRequests(config.KubePolicyNamespace)
 
 	pc.pListerSynced = pInformer.Informer().HasSynced
+	pc.nspListerSynced = nspInformer.Informer().HasSynced
 	pc.cpvListerSynced = cpvInformer.Informer().HasSynced
 	pc.nspvListerSynced = nspvInformer.Informer().HasSynced
+	pc.grListerSynced = grInformer.Informer().HasSynced
+	pc.nsListerSynced = nsInformer.Informer().HasSynced
 	// resource manager
 	// rebuild after 300 seconds/ 5 mins
 	//TODO: pass the time in seconds instead of converting it internally
@@ -159,22 +252,8 @@ func (pc *PolicyController) addPolicy(obj interface{}) {
 	// register with policy meta-store
 	pc.pMetaStore.Register(*p)
 
-	// TODO: code might seem vague, awaiting resolution of issue https://github.com/nirmata/kyverno/issues/598
-	if p.Spec.Background == nil {
-		// if userInfo is not defined in policy we process the policy
-		if err := policy.ContainsUserInfo(*p); err != nil {
-			return
-		}
-	} else {
-		if !*p.Spec.Background {
-			return
-		}
-		// If userInfo is used then skip the policy
-		// ideally this should be handled by background flag only
-		if err := policy.ContainsUserInfo(*p); err != nil {
-			// contains userInfo used in policy
-			return
-		}
+	if !canBackgroundProcess(*p) && !p.Spec.GenerateExisting {
+		return
 	}
 
 	glog.V(4).Infof("Adding Policy %s", p.Name)
@@ -192,27 +271,42 @@ func (pc *PolicyController) updatePolicy(old, cur interface{}) {
 	}
 	pc.pMetaStore.Register(*curP)
 
-	// Only process policies that are enabled for "background" execution
-	// policy.spec.background -> "True"
-	// TODO: code might seem vague, awaiting resolution of issue https://github.com/nirmata/kyverno/issues/598
-	if curP.Spec.Background == nil {
+	if scanRequested(oldP.GetAnnotations(), curP.GetAnnotations()) {
+		pc.ScanPolicy(curP.Name)
+	}
+	// ignore the updates of the status and the periodic resyncs, the existing resources are scanned
+	// every scan interval. The resyncs still apply the generate rules on the existing resources.
+	if reflect.DeepEqual(oldP.Spec, curP.Spec) && (oldP.ResourceVersion != curP.ResourceVersion || !curP.Spec.GenerateExisting) {
+		return
+	}
+	if !canBackgroundProcess(*curP) && !curP.Spec.GenerateExisting {
+		return
+	}
+	glog.V(4).Infof("Updating Policy %s", oldP.Name)
+	pc.enqueuePolicy(curP)
+}
+
+// canBackgroundProcess checks if the policy is enabled for "background" execution
+// policy.spec.background -> "True"
+// TODO: code might seem vague, awaiting resolution of issue https://github.com/nirmata/kyverno/issues/598
+func canBackgroundProcess(p kyverno.ClusterPolicy) bool {
+	if p.Spec.Background == nil {
 		// if userInfo is not defined in policy we process the policy
-		if err := policy.ContainsUserInfo(*curP); err != nil {
-			return
+		if err := policy.ContainsUserInfo(p); err != nil {
+			return false
 		}
 	} else {
-		if !*curP.Spec.Background {
-			return
+		if !*p.Spec.Background {
+			return false
 		}
 		// If userInfo is used then skip the policy
 		// ideally this should be handled by background flag only
-		if err := policy.ContainsUserInfo(*curP); err != nil {
+		if err := policy.ContainsUserInfo(p); err != nil {
 			// contains userInfo used in policy
-			return
+			return false
 		}
 	}
-	glog.V(4).Infof("Updating Policy %s", oldP.Name)
-	pc.enqueuePolicy(curP)
+	return true
 }
 
 func (pc *PolicyController) deletePolicy(obj interface{}) {
@@ -239,6 +333,35 @@ func (pc *PolicyController) deletePolicy(obj interface{}) {
 	pc.enqueuePolicy(p)
 }
 
+// addGR updates the status of the policy of the generate request, with the progress of generateExisting
+// only the status is synced, the existing resources are not listed again
+func (pc *PolicyController) addGR(obj interface{}) {
+	gr := obj.(*kyverno.GenerateRequest)
+	pc.enqueueGenerateExistingPolicy(gr.Spec.Policy)
+}
+
+func (pc *PolicyController) updateGR(old, cur interface{}) {
+	oldGr := old.(*kyverno.GenerateRequest)
+	curGr := cur.(*kyverno.GenerateRequest)
+	if oldGr.Status.State == curGr.Status.State {
+		return
+	}
+	pc.enqueueGenerateExistingPolicy(curGr.Spec.Policy)
+}
+
+func (pc *PolicyController) enqueueGenerateExistingPolicy(policyName string) {
+	p, err := pc.pLister.Get(policyName)
+	if err != nil {
+		glog.V(4).Infof("policy %s not found: %v", policyName, err)
+		return
+	}
+	if !p.Spec.GenerateExisting {
+		return
+	}
+	// the status updates of the generate requests of a policy are batched by the queue
+	pc.conditionsQueue.Add(p.Name)
+}
+
 func (pc *PolicyController) enqueue(policy *kyverno.ClusterPolicy) {
 	key, err := cache.MetaNamespaceKeyFunc(policy)
 	if err != nil {
@@ -253,22 +376,56 @@ func (pc *PolicyController) Run(workers int, stopCh <-chan struct{}) {
 
 	defer utilruntime.HandleCrash()
 	defer pc.queue.ShutDown()
+	defer pc.scanQueue.ShutDown()
+	defer pc.conditionsQueue.ShutDown()
 
 	glog.Info("Starting policy controller")
 	defer glog.Info("Shutting down policy controller")
 
-	if !cache.WaitForCacheSync(stopCh, pc.pListerSynced, pc.cpvListerSynced, pc.nspvListerSynced) {
+	if !cache.WaitForCacheSync(stopCh, pc.pListerSynced, pc.nspListerSynced, pc.cpvListerSynced, pc.nspvListerSynced, pc.grListerSynced, pc.nsListerSynced) {
 		glog.Error("failed to sync informer cache")
 		return
 	}
 
 	for i := 0; i < workers; i++ {
 		go wait.Until(pc.worker, time.Second, stopCh)
+		go wait.Until(pc.scanWorker, time.Second, stopCh)
 	}
-	// policy status aggregator
+	go wait.Until(pc.conditionsWorker, time.Second, stopCh)
+	go pc.runScans(stopCh)
+	go wait.Until(pc.syncStatus, statusSyncInterval, stopCh)
+	<-stopCh
+}
+
+// RunStatusAggregator aggregates the statistics sent by the webhooks, it runs on every replica
+// as the webhooks wait for the statistics to be received. Each replica adds its statistics to the policy status,
+// so the statistics of the requests served by all the replicas are kept across the leader changes
+func (pc *PolicyController) RunStatusAggregator(stopCh <-chan struct{}) {
+	go wait.Until(pc.syncStats, statusSyncInterval, stopCh)
 	//TODO: workers required for aggergation
 	pc.statusAggregator.Run(1, stopCh)
-	<-stopCh
+}
+
+// syncStats adds the statistics aggregated since the previous sync to the status of the policies
+func (pc *PolicyController) syncStats() {
+	for name, stats := range pc.statusAggregator.TakePolicyStats() {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			p, err := pc.kyvernoClient.KyvernoV1().ClusterPolicies().Get(name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			newPolicy := p.DeepCopy()
+			newPolicy.Status = addStats(p.Status, stats)
+			_, err = pc.kyvernoClient.KyvernoV1().ClusterPolicies().UpdateStatus(newPolicy)
+			return err
+		})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			glog.Errorf("failed to update the statistics of policy %s: %v", name, err)
+		}
+	}
 }
 
 // worker runs a worker thread that just dequeues items, processes them, and marks them done.
@@ -358,9 +515,15 @@ func (pc *PolicyController) syncPolicy(key string) error {
 	}
 
 	// process policies on existing resources
-	engineResponses := pc.processExistingResources(*policy)
-	// report errors
-	pc.cleanupAndReport(engineResponses)
+	if canBackgroundProcess(*policy) {
+		engineResponses := pc.processExistingResources(*policy, "", false)
+		// report errors
+		pc.cleanupAndReport(engineResponses)
+	}
+	// apply generate rules on existing resources
+	if policy.Spec.GenerateExisting {
+		pc.processExistingGenerate(*policy)
+	}
 	// sync active
 	return pc.syncStatusOnly(policy, cpvList, nspvList)
 }
@@ -391,35 +554,193 @@ func (pc *PolicyController) deleteNamespacedPolicyViolations(policy string) erro
 	return nil
 }
 
-//syncStatusOnly updates the policy status subresource
+// syncStatus updates the status of the policies with the execution statistics
+func (pc *PolicyController) syncStatus() {
+	policies, err := pc.pLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list policies: %v", err)
+		return
+	}
+	for _, p := range policies {
+		if err := pc.syncClusterPolicyStatus(p); err != nil {
+			glog.Errorf("failed to update status of policy %s: %v", p.Name, err)
+		}
+	}
+	nsPolicies, err := pc.nspLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list namespaced policies: %v", err)
+		return
+	}
+	for _, p := range nsPolicies {
+		if err := pc.syncNamespacedPolicyStatus(p); err != nil {
+			glog.Errorf("failed to update status of policy %s/%s: %v", p.Namespace, p.Name, err)
+		}
+	}
+}
+
+// syncClusterPolicyStatus updates the status of the policy with the count of its policy violations
+func (pc *PolicyController) syncClusterPolicyStatus(p *kyverno.ClusterPolicy) error {
+	cpvList, err := pc.getClusterPolicyViolationForPolicy(p.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list cluster policy violations: %v", err)
+	}
+	nspvList, err := pc.getNamespacedPolicyViolationForPolicy(p.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list namespaced policy violations: %v", err)
+	}
+	return pc.syncStatusOnly(p, cpvList, nspvList)
+}
+
+//syncStatusOnly updates the policy status subresource,
+//the execution statistics are kept, they are added by each replica with syncStats
 func (pc *PolicyController) syncStatusOnly(p *kyverno.ClusterPolicy, pvList []*kyverno.ClusterPolicyViolation, nspvList []*kyverno.PolicyViolation) error {
-	newStatus := pc.calculateStatus(p.Name, pvList, nspvList)
+	newStatus := pc.calculateStatus(p, pvList, nspvList)
+	copyExecutionStats(&newStatus, p.Status)
+	if p.Spec.GenerateExisting {
+		newStatus.GenerateExisting = pc.calculateGenerateExistingStatus(p.Name)
+	}
+	newStatus.Conditions = pc.policyConditions(p, p.Status.Conditions)
+	pc.recordUnknownKinds(p, p.Status.Conditions, newStatus.Conditions)
 	if reflect.DeepEqual(newStatus, p.Status) {
 		// no update to status
 		return nil
 	}
 	// update status
-	newPolicy := p
+	newPolicy := p.DeepCopy()
 	newPolicy.Status = newStatus
 	_, err := pc.kyvernoClient.KyvernoV1().ClusterPolicies().UpdateStatus(newPolicy)
 	return err
 }
 
-func (pc *PolicyController) calculateStatus(policyName string, pvList []*kyverno.ClusterPolicyViolation, nspvList []*kyverno.PolicyViolation) kyverno.PolicyStatus {
+// copyExecutionStats sets the execution statistics of the status to the ones of the current status
+func copyExecutionStats(status *kyverno.PolicyStatus, current kyverno.PolicyStatus) {
+	status.RulesAppliedCount = current.RulesAppliedCount
+	status.ResourcesBlockedCount = current.ResourcesBlockedCount
+	status.AvgExecutionTimeMutation = current.AvgExecutionTimeMutation
+	status.AvgExecutionTimeValidation = current.AvgExecutionTimeValidation
+	status.AvgExecutionTimeGeneration = current.AvgExecutionTimeGeneration
+	status.Rules = current.Rules
+}
+
+// countRules returns the count of the rules of the policy per type
+func countRules(p *kyverno.ClusterPolicy) kyverno.RuleCountStatus {
+	count := kyverno.RuleCountStatus{Total: len(p.Spec.Rules)}
+	for _, rule := range p.Spec.Rules {
+		if rule.HasMutate() {
+			count.Mutate++
+		}
+		if rule.HasValidate() {
+			count.Validate++
+		}
+		if rule.HasGenerate() {
+			count.Generate++
+		}
+		if rule.HasVerifyImages() {
+			count.VerifyImages++
+		}
+	}
+	return count
+}
+
+// policyConditions returns the conditions of the policy updated with its current state
+func (pc *PolicyController) policyConditions(p *kyverno.ClusterPolicy, conditions []kyverno.PolicyCondition) []kyverno.PolicyCondition {
+	unknownKinds, uncoveredKinds := pc.checkKinds(p)
+	rulesValidated := rulesValidatedCondition(policy.Validate(*p))
+	webhookConfigured := webhookConfiguredCondition(pc.resourceWebhookWatcher.IsResourceWebhookRegistered(), uncoveredKinds)
+	conditions = setCondition(conditions, rulesValidated)
+	conditions = setCondition(conditions, webhookConfigured)
+	conditions = setCondition(conditions, kindsResolvedCondition(unknownKinds))
+	return setCondition(conditions, readyCondition(rulesValidated, webhookConfigured))
+}
+
+// rulesValidatedCondition returns the RulesValidated condition of the policy for the error of its validation
+func rulesValidatedCondition(err error) kyverno.PolicyCondition {
+	if err != nil {
+		return kyverno.PolicyCondition{
+			Type:    kyverno.PolicyRulesValidated,
+			Status:  v1.ConditionFalse,
+			Reason:  "ValidationFailed",
+			Message: err.Error(),
+		}
+	}
+	return kyverno.PolicyCondition{
+		Type:    kyverno.PolicyRulesValidated,
+		Status:  v1.ConditionTrue,
+		Reason:  "Succeeded",
+		Message: "the rules of the policy are valid",
+	}
+}
+
+// webhookConfiguredCondition returns the WebhookConfigured condition of the policy, the requests of its kinds
+// are forwarded to the webhook server once the resource webhook is registered
+func webhookConfiguredCondition(webhookRegistered bool, uncoveredKinds []string) kyverno.PolicyCondition {
+	if !webhookRegistered {
+		return kyverno.PolicyCondition{
+			Type:    kyverno.PolicyWebhookConfigured,
+			Status:  v1.ConditionFalse,
+			Reason:  "WebhookNotRegistered",
+			Message: "the resource webhook configuration is not registered",
+		}
+	}
+	if len(uncoveredKinds) != 0 {
+		return kyverno.PolicyCondition{
+			Type:    kyverno.PolicyWebhookConfigured,
+			Status:  v1.ConditionFalse,
+			Reason:  "KindsNotCovered",
+			Message: fmt.Sprintf("the resource webhook configuration does not cover the kinds %s", strings.Join(uncoveredKinds, ", ")),
+		}
+	}
+	return kyverno.PolicyCondition{
+		Type:    kyverno.PolicyWebhookConfigured,
+		Status:  v1.ConditionTrue,
+		Reason:  "Succeeded",
+		Message: "the resource webhook configuration covers the kinds of the policy",
+	}
+}
+
+// readyCondition returns the Ready condition of the policy, the policies are enforced once their rules are
+// validated and the webhook is configured, otherwise it has the reason of the first False condition
+func readyCondition(conditions ...kyverno.PolicyCondition) kyverno.PolicyCondition {
+	for _, c := range conditions {
+		if c.Status != v1.ConditionTrue {
+			return kyverno.PolicyCondition{
+				Type:    kyverno.PolicyReady,
+				Status:  v1.ConditionFalse,
+				Reason:  c.Reason,
+				Message: c.Message,
+			}
+		}
+	}
+	return kyverno.PolicyCondition{
+		Type:    kyverno.PolicyReady,
+		Status:  v1.ConditionTrue,
+		Reason:  "Succeeded",
+		Message: "the policy is enforced by the admission webhooks",
+	}
+}
+
+// setCondition returns the conditions with the condition replacing the one of its type,
+// the transition time changes only with the status of the condition
+func setCondition(conditions []kyverno.PolicyCondition, condition kyverno.PolicyCondition) []kyverno.PolicyCondition {
+	newConditions := []kyverno.PolicyCondition{}
+	condition.LastTransitionTime = metav1.Now()
+	for _, c := range conditions {
+		if c.Type != condition.Type {
+			newConditions = append(newConditions, c)
+			continue
+		}
+		if c.Status == condition.Status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+	}
+	return append(newConditions, condition)
+}
+
+func (pc *PolicyController) calculateStatus(p *kyverno.ClusterPolicy, pvList []*kyverno.ClusterPolicyViolation, nspvList []*kyverno.PolicyViolation) kyverno.PolicyStatus {
 	violationCount := len(pvList) + len(nspvList)
 	status := kyverno.PolicyStatus{
 		ViolationCount: violationCount,
-	}
-	// get stats
-	stats := pc.statusAggregator.GetPolicyStats(policyName)
-	if !reflect.DeepEqual(stats, (PolicyStatInfo{})) {
-		status.RulesAppliedCount = stats.RulesAppliedCount
-		status.ResourcesBlockedCount = stats.ResourceBlocked
-		status.AvgExecutionTimeMutation = stats.MutationExecutionTime.String()
-		status.AvgExecutionTimeValidation = stats.ValidationExecutionTime.String()
-		status.AvgExecutionTimeGeneration = stats.GenerationExecutionTime.String()
-		// update rule stats
-		status.Rules = convertRules(stats.Rules)
+		RuleCount:      countRules(p),
 	}
 	return status
 }
@@ -470,8 +791,23 @@ func convertRules(rules []RuleStatinfo) []kyverno.RuleStats {
 			AppliedCount:   r.RuleAppliedCount,
 			ViolationCount: r.RulesFailedCount,
 			MutationCount:  r.MutationCount,
+			ExecutionCount: r.ExecutionCount,
+		}
+		if len(r.executionTimes) != 0 {
+			stat.P95ExecutionTime = r.percentile(0.95).String()
+			stat.P99ExecutionTime = r.percentile(0.99).String()
 		}
 		stats = append(stats, stat)
 	}
 	return stats
 }
+
+//Len returns the number of the queued policies
+func (pc *PolicyController) Len() int {
+	return pc.queue.Len()
+}
+
+//ScanLen returns the number of the queued background scans
+func (pc *PolicyController) ScanLen() int {
+	return pc.scanQueue.Len()
+}
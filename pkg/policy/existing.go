@@ -2,6 +2,7 @@ package policy
 
 import (
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,45 +13,52 @@ import (
 	client "github.com/nirmata/kyverno/pkg/dclient"
 	"github.com/nirmata/kyverno/pkg/engine"
 	"github.com/nirmata/kyverno/pkg/engine/response"
+	engineutils "github.com/nirmata/kyverno/pkg/engine/utils"
 	"github.com/nirmata/kyverno/pkg/utils"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 )
 
-func (pc *PolicyController) processExistingResources(policy kyverno.ClusterPolicy) []response.EngineResponse {
+// processExistingResources applies the policy on the existing resources, of the namespace if not empty,
+// the resources already processed with the same versions are skipped unless rescan is set
+func (pc *PolicyController) processExistingResources(policy kyverno.ClusterPolicy, namespace string, rescan bool) []response.EngineResponse {
 	// Parse through all the resources
 	// drops the cache after configured rebuild time
 	pc.rm.Drop()
 	var engineResponses []response.EngineResponse
-	// get resource that are satisfy the resource description defined in the rules
-	resourceMap := listResources(pc.client, policy, pc.configHandler)
-	for _, resource := range resourceMap {
+	// get resource that are satisfy the resource description defined in the rules, page by page
+	listResources(pc.client, policy, pc.configHandler, namespace, func(resource unstructured.Unstructured) {
 		// pre-processing, check if the policy and resource version has been processed before
-		if !pc.rm.ProcessResource(policy.Name, policy.ResourceVersion, resource.GetKind(), resource.GetNamespace(), resource.GetName(), resource.GetResourceVersion()) {
+		if !rescan && !pc.rm.ProcessResource(policy.Name, policy.ResourceVersion, resource.GetKind(), resource.GetNamespace(), resource.GetName(), resource.GetResourceVersion()) {
 			glog.V(4).Infof("policy %s with resource version %s already processed on resource %s/%s/%s with resource version %s", policy.Name, policy.ResourceVersion, resource.GetKind(), resource.GetNamespace(), resource.GetName(), resource.GetResourceVersion())
-			continue
+			return
 		}
 
 		// skip reporting violation on pod which has annotation pod-policies.kyverno.io/autogen-applied
 		if skipPodApplication(resource) {
-			continue
+			return
 		}
 
 		// apply the policy on each
 		glog.V(4).Infof("apply policy %s with resource version %s on resource %s/%s/%s with resource version %s", policy.Name, policy.ResourceVersion, resource.GetKind(), resource.GetNamespace(), resource.GetName(), resource.GetResourceVersion())
-		engineResponse := applyPolicy(policy, resource, pc.statusAggregator)
+		engineResponse := applyPolicy(policy, resource, pc.statusAggregator, pc.client, pc.configMapResolver, pc.registryClient, pc.serviceClient, pc.globalContext, pc.exceptionStore.LookUp(resource.GetNamespace()))
 		// get engine response for mutation & validation independently
 		engineResponses = append(engineResponses, engineResponse...)
 		// post-processing, register the resource as processed
 		pc.rm.RegisterResource(policy.GetName(), policy.GetResourceVersion(), resource.GetKind(), resource.GetNamespace(), resource.GetName(), resource.GetResourceVersion())
-	}
+	})
 	return engineResponses
 }
 
-func listResources(client *client.Client, policy kyverno.ClusterPolicy, configHandler config.Interface) map[string]unstructured.Unstructured {
-	// key uid
-	resourceMap := map[string]unstructured.Unstructured{}
+// listResources calls fn on the resources matched by the rules of the policy, in the namespace if not empty,
+// the resources are listed page by page and fn is called once per resource matched by several rules
+func listResources(client *client.Client, policy kyverno.ClusterPolicy, configHandler config.Interface, namespace string, fn func(unstructured.Unstructured)) {
+	// uids of the resources already processed
+	processed := map[types.UID]bool{}
 
 	for _, rule := range policy.Spec.Rules {
 		// resources that match
@@ -66,7 +74,12 @@ func listResources(client *client.Client, policy kyverno.ClusterPolicy, configHa
 				glog.V(4).Infof("skipping processing policy %s rule %s for kind Namespace", policy.Name, rule.Name)
 				continue
 			}
-			if len(rule.MatchResources.Namespaces) > 0 {
+			if namespace != "" {
+				if len(rule.MatchResources.Namespaces) > 0 && !utils.ContainsString(rule.MatchResources.Namespaces, namespace) {
+					continue
+				}
+				namespaces = []string{namespace}
+			} else if len(rule.MatchResources.Namespaces) > 0 {
 				namespaces = append(namespaces, rule.MatchResources.Namespaces...)
 				glog.V(4).Infof("namespaces specified for inclusion: %v", rule.MatchResources.Namespaces)
 			} else {
@@ -77,50 +90,79 @@ func listResources(client *client.Client, policy kyverno.ClusterPolicy, configHa
 
 			// get resources in the namespaces
 			for _, ns := range namespaces {
-				rMap := getResourcesPerNamespace(k, client, ns, rule, configHandler)
-				mergeresources(resourceMap, rMap)
+				getResourcesPerNamespace(k, client, ns, rule, configHandler, func(resourceMap map[string]unstructured.Unstructured) {
+					for _, r := range resourceMap {
+						if processed[r.GetUID()] {
+							continue
+						}
+						processed[r.GetUID()] = true
+						fn(r)
+					}
+				})
 			}
 
 		}
 	}
-	return resourceMap
 }
 
-func getResourcesPerNamespace(kind string, client *client.Client, namespace string, rule kyverno.Rule, configHandler config.Interface) map[string]unstructured.Unstructured {
-	resourceMap := map[string]unstructured.Unstructured{}
+// getResourcesPerNamespace lists the resources of the kind in the namespace matched by the rule page by page,
+// and calls fn with the matched resources of each page
+func getResourcesPerNamespace(kind string, client *client.Client, namespace string, rule kyverno.Rule, configHandler config.Interface, fn func(map[string]unstructured.Unstructured)) {
 	// merge include and exclude label selector values
 	ls := rule.MatchResources.Selector
 	//	ls := mergeLabelSectors(rule.MatchResources.Selector, rule.ExcludeResources.Selector)
 	// list resources
-	glog.V(4).Infof("get resources for kind %s, namespace %s, selector %v", kind, namespace, rule.MatchResources.Selector)
-	list, err := client.ListResource(kind, namespace, ls)
-	if err != nil {
-		glog.Infof("unable to get resources: err %v", err)
-		return nil
-	}
-	// filter based on name
-	for _, r := range list.Items {
-		// match name
-		if rule.MatchResources.Name != "" {
-			if !wildcard.Match(rule.MatchResources.Name, r.GetName()) {
-				glog.V(4).Infof("skipping resource %s/%s due to include condition name=%s mistatch", r.GetNamespace(), r.GetName(), rule.MatchResources.Name)
+	// the names are filtered by the API server if they are not wildcards
+	fs := nameSelector(rule)
+	glog.V(4).Infof("get resources for kind %s, namespace %s, selector %v, field selector %v", kind, namespace, rule.MatchResources.Selector, fs)
+	err := client.ListResourcePages(kind, namespace, ls, fs, func(list *unstructured.UnstructuredList) error {
+		resourceMap := map[string]unstructured.Unstructured{}
+		// filter based on name
+		for _, r := range list.Items {
+			// match name
+			if rule.MatchResources.Name != "" {
+				if !wildcard.Match(rule.MatchResources.Name, r.GetName()) {
+					glog.V(4).Infof("skipping resource %s/%s due to include condition name=%s mistatch", r.GetNamespace(), r.GetName(), rule.MatchResources.Name)
+					continue
+				}
+			}
+			// Skip the filtered resources
+			if configHandler.ToFilter(r.GetKind(), r.GetNamespace(), r.GetName()) {
 				continue
 			}
-		}
-		// Skip the filtered resources
-		if configHandler.ToFilter(r.GetKind(), r.GetNamespace(), r.GetName()) {
-			continue
+
+			//TODO check if the group version kind is present or not
+			resourceMap[string(r.GetUID())] = r
 		}
 
-		//TODO check if the group version kind is present or not
-		resourceMap[string(r.GetUID())] = r
+		// exclude the resources
+		// skip resources to be filtered
+		excludeResources(resourceMap, rule.ExcludeResources.ResourceDescription, configHandler)
+		fn(resourceMap)
+		return nil
+	})
+	if err != nil {
+		glog.Infof("unable to get resources: err %v", err)
 	}
+}
 
-	// exclude the resources
-	// skip resources to be filtered
-	excludeResources(resourceMap, rule.ExcludeResources.ResourceDescription, configHandler)
-	//	glog.V(4).Infof("resource map: %v", resourceMap)
-	return resourceMap
+// nameSelector returns the field selector of the names of the resources matched and excluded by the rule,
+// the names with wildcards are not in the selector and are matched by the client, as are the excluded names
+// combined with other exclude conditions
+func nameSelector(rule kyverno.Rule) fields.Selector {
+	var selectors []fields.Selector
+	if name := rule.MatchResources.Name; name != "" && !hasWildcard(name) {
+		selectors = append(selectors, fields.OneTermEqualSelector("metadata.name", name))
+	}
+	exclude := rule.ExcludeResources.ResourceDescription
+	if name := exclude.Name; name != "" && !hasWildcard(name) && reflect.DeepEqual(exclude, kyverno.ResourceDescription{Name: name}) {
+		selectors = append(selectors, fields.OneTermNotEqualSelector("metadata.name", name))
+	}
+	return fields.AndSelectors(selectors...)
+}
+
+func hasWildcard(name string) bool {
+	return strings.ContainsAny(name, "*?")
 }
 
 func excludeResources(included map[string]unstructured.Unstructured, exclude kyverno.ResourceDescription, configHandler config.Interface) {
@@ -163,21 +205,21 @@ func excludeResources(included map[string]unstructured.Unstructured, exclude kyv
 		return Process
 	}
 
-	findKind := func(kind string, kinds []string) bool {
+	findKind := func(gvk schema.GroupVersionKind, kinds []string) bool {
 		for _, k := range kinds {
-			if k == kind {
+			if engineutils.MatchesKind(k, gvk) {
 				return true
 			}
 		}
 		return false
 	}
 
-	excludeKind := func(kind string) Condition {
+	excludeKind := func(gvk schema.GroupVersionKind) Condition {
 		if len(exclude.Kinds) == 0 {
 			return NotEvaluate
 		}
 
-		if findKind(kind, exclude.Kinds) {
+		if findKind(gvk, exclude.Kinds) {
 			return Skip
 		}
 
@@ -200,7 +242,7 @@ func excludeResources(included map[string]unstructured.Unstructured, exclude kyv
 		if ret := excludeSelector(resource.GetLabels()); ret != NotEvaluate {
 			excludeEval = append(excludeEval, ret)
 		}
-		if ret := excludeKind(resource.GetKind()); ret != NotEvaluate {
+		if ret := excludeKind(resource.GroupVersionKind()); ret != NotEvaluate {
 			excludeEval = append(excludeEval, ret)
 		}
 		// exclude the filtered resources
@@ -237,13 +279,6 @@ const (
 	Skip Condition = 2
 )
 
-// merge b into a map
-func mergeresources(a, b map[string]unstructured.Unstructured) {
-	for k, v := range b {
-		a[k] = v
-	}
-}
-
 func getAllNamespaces(client *client.Client) []string {
 	var namespaces []string
 	// get all namespaces
@@ -0,0 +1,29 @@
+package policy
+
+import (
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_nameSelector(t *testing.T) {
+	rule := func(match, exclude kyverno.ResourceDescription) kyverno.Rule {
+		return kyverno.Rule{
+			MatchResources:   kyverno.MatchResources{ResourceDescription: match},
+			ExcludeResources: kyverno.ExcludeResources{ResourceDescription: exclude},
+		}
+	}
+	pods := kyverno.ResourceDescription{Kinds: []string{"Pod"}}
+
+	assert.Equal(t, nameSelector(rule(pods, kyverno.ResourceDescription{})).String(), "")
+	// the names with wildcards are matched by the client
+	assert.Equal(t, nameSelector(rule(kyverno.ResourceDescription{Kinds: []string{"Pod"}, Name: "nginx-*"}, kyverno.ResourceDescription{})).String(), "")
+	assert.Equal(t, nameSelector(rule(kyverno.ResourceDescription{Kinds: []string{"Pod"}, Name: "nginx"}, kyverno.ResourceDescription{})).String(), "metadata.name=nginx")
+	assert.Equal(t, nameSelector(rule(pods, kyverno.ResourceDescription{Name: "coredns"})).String(), "metadata.name!=coredns")
+	assert.Equal(t, nameSelector(rule(kyverno.ResourceDescription{Kinds: []string{"Pod"}, Name: "nginx"}, kyverno.ResourceDescription{Name: "coredns"})).String(), "metadata.name=nginx,metadata.name!=coredns")
+	// the excluded name combined with other conditions is matched by the client
+	assert.Equal(t, nameSelector(rule(pods, kyverno.ResourceDescription{Name: "coredns", Namespaces: []string{"kube-system"}})).String(), "")
+	assert.Equal(t, nameSelector(rule(pods, kyverno.ResourceDescription{Name: "coredns", Selector: &metav1.LabelSelector{}})).String(), "")
+}
@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/config"
+	client "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/engine"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// processExistingGenerate creates generate requests for the existing resources the generate rules apply on,
+// the generate requests are processed by the generate controller
+// a generate request is not created if one exists for the policy and the resource
+func (pc *PolicyController) processExistingGenerate(policy kyverno.ClusterPolicy) {
+	listGenerateTriggers(pc.client, policy, pc.configHandler, func(resource unstructured.Unstructured) {
+		if pc.hasGenerateRequest(policy.Name, resource) {
+			return
+		}
+		// build context
+		ctx := context.NewContext()
+		ctx.AddResource(transformResource(resource))
+		policyContext := engine.PolicyContext{
+			NewResource:         resource,
+			Policy:              policy,
+			Context:             ctx,
+			Client:              pc.client,
+			ConfigMapResolver:   pc.configMapResolver,
+			ImageRegistryClient: pc.registryClient,
+			ServiceClient:       pc.serviceClient,
+			GlobalContext:       pc.globalContext,
+			Log:                 enginelog.Glog,
+		}
+		// check if the generate rules apply on the resource
+		engineResponse := engine.Generate(policyContext)
+		if len(engineResponse.PolicyResponse.Rules) == 0 {
+			return
+		}
+		glog.V(4).Infof("creating generate request for policy %s on existing resource %s/%s/%s", policy.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName())
+		gr := kyverno.GenerateRequestSpec{
+			Policy: policy.Name,
+			Resource: kyverno.ResourceSpec{
+				Kind:      resource.GetKind(),
+				Namespace: resource.GetNamespace(),
+				Name:      resource.GetName(),
+			},
+		}
+		if err := pc.grGenerator.Create(gr); err != nil {
+			glog.Errorf("failed to create generate request for policy %s on resource %s/%s/%s: %v", policy.Name, resource.GetKind(), resource.GetNamespace(), resource.GetName(), err)
+		}
+	})
+}
+
+func (pc *PolicyController) hasGenerateRequest(policy string, resource unstructured.Unstructured) bool {
+	grs, err := pc.grLister.GetGenerateRequestsForResource(resource.GetKind(), resource.GetNamespace(), resource.GetName())
+	if err != nil {
+		glog.Errorf("failed to list generate requests for resource %s/%s/%s: %v", resource.GetKind(), resource.GetNamespace(), resource.GetName(), err)
+		// do not create duplicate generate requests
+		return true
+	}
+	for _, gr := range grs {
+		if gr.Spec.Policy == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// listGenerateTriggers calls fn on the existing resources of the kinds matched by the generate rules,
+// the resources are listed page by page and fn is called once per resource matched by several rules
+// the resources filtered by the configuration are skipped
+func listGenerateTriggers(client *client.Client, policy kyverno.ClusterPolicy, configHandler config.Interface, fn func(unstructured.Unstructured)) {
+	// uids of the resources already processed
+	processed := map[types.UID]bool{}
+	list := func(kind, namespace string, rule kyverno.Rule) {
+		err := client.ListResourcePages(kind, namespace, rule.MatchResources.Selector, nameSelector(rule), func(list *unstructured.UnstructuredList) error {
+			for _, r := range list.Items {
+				if processed[r.GetUID()] || configHandler.ToFilter(r.GetKind(), r.GetNamespace(), r.GetName()) {
+					continue
+				}
+				processed[r.GetUID()] = true
+				fn(r)
+			}
+			return nil
+		})
+		if err != nil {
+			glog.Infof("unable to get resources: err %v", err)
+		}
+	}
+	for _, rule := range policy.Spec.Rules {
+		if !rule.HasGenerate() {
+			continue
+		}
+		for _, k := range rule.MatchResources.Kinds {
+			namespaced, err := client.DiscoveryClient.IsNamespaced(k)
+			if err != nil {
+				glog.Infof("unable to get the scope of kind %s: %v", k, err)
+				continue
+			}
+			if !namespaced {
+				list(k, "", rule)
+				continue
+			}
+			var namespaces []string
+			if len(rule.MatchResources.Namespaces) > 0 {
+				namespaces = append(namespaces, rule.MatchResources.Namespaces...)
+			} else {
+				namespaces = getAllNamespaces(client)
+			}
+			for _, ns := range namespaces {
+				list(k, ns, rule)
+			}
+		}
+	}
+}
+
+// calculateGenerateExistingStatus returns the progress of the generate requests of the policy
+func (pc *PolicyController) calculateGenerateExistingStatus(policyName string) *kyverno.GenerateExistingStatus {
+	grs, err := pc.grLister.GetGenerateRequestsForClusterPolicy(policyName)
+	if err != nil {
+		glog.Errorf("failed to list generate requests for policy %s: %v", policyName, err)
+		return nil
+	}
+	status := kyverno.GenerateExistingStatus{
+		Total: len(grs),
+	}
+	for _, gr := range grs {
+		switch gr.Status.State {
+		case kyverno.Completed:
+			status.Completed++
+		case kyverno.Failed:
+			status.Failed++
+		default:
+			status.Pending++
+		}
+	}
+	return &status
+}
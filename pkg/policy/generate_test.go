@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/config"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// fakeGenerateRequests records the generate requests
+type fakeGenerateRequests struct {
+	specs []kyverno.GenerateRequestSpec
+}
+
+func (f *fakeGenerateRequests) Create(gr kyverno.GenerateRequestSpec) error {
+	f.specs = append(f.specs, gr)
+	return nil
+}
+
+// fakeFilter filters the resources of the namespace
+type fakeFilter string
+
+func (f fakeFilter) ToFilter(kind, namespace, name string) bool {
+	return namespace == string(f)
+}
+
+var _ config.Interface = fakeFilter("")
+
+func newObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetUID(types.UID(namespace + "/" + name))
+	return obj
+}
+
+func Test_processExistingGenerate(t *testing.T) {
+	// the namespaces are listed with the typed client
+	scheme := runtime.NewScheme()
+	assert.NilError(t, v1.AddToScheme(scheme))
+	client, err := dclient.NewMockClient(scheme,
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", UID: "default"}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "kube-system"}},
+		newObject("cert-manager.io/v1", "ClusterIssuer", "", "letsencrypt"),
+		newObject("v1", "ServiceAccount", "default", "builder"),
+		newObject("v1", "ServiceAccount", "kube-system", "coredns"),
+	)
+	assert.NilError(t, err)
+	client.SetDiscovery(dclient.NewFakeDiscoveryClient([]schema.GroupVersionResource{
+		{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"},
+	}))
+	grs := &fakeGenerateRequests{}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	// the service account default/builder has a generate request already
+	indexer.Add(&kyverno.GenerateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kyverno", Name: "gr-builder"},
+		Spec: kyverno.GenerateRequestSpec{
+			Policy:   "generate-existing",
+			Resource: kyverno.ResourceSpec{Kind: "ServiceAccount", Namespace: "default", Name: "builder"},
+		},
+	})
+	pc := &PolicyController{
+		client:        client,
+		configHandler: fakeFilter("kube-system"),
+		grLister:      kyvernolister.NewGenerateRequestLister(indexer).GenerateRequests("kyverno"),
+		grGenerator:   grs,
+	}
+	generate := func(name string, kinds ...string) kyverno.Rule {
+		return kyverno.Rule{
+			Name:           name,
+			MatchResources: kyverno.MatchResources{ResourceDescription: kyverno.ResourceDescription{Kinds: kinds}},
+			Generation: kyverno.Generation{
+				ResourceSpec: kyverno.ResourceSpec{Kind: "ConfigMap", Namespace: "default", Name: "{{request.object.metadata.name}}"},
+				Data:         map[string]interface{}{"data": map[string]interface{}{"a": "b"}},
+			},
+		}
+	}
+	policy := kyverno.ClusterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "generate-existing"},
+		Spec: kyverno.Spec{
+			GenerateExisting: true,
+			Rules: []kyverno.Rule{
+				generate("namespaces", "Namespace"),
+				generate("issuers", "ClusterIssuer"),
+				generate("serviceaccounts", "ServiceAccount"),
+			},
+		},
+	}
+	pc.processExistingGenerate(policy)
+
+	// the cluster-scoped resources are listed once, the resources of the filtered namespace kube-system
+	// and the resources with a generate request are skipped
+	var resources []kyverno.ResourceSpec
+	for _, gr := range grs.specs {
+		assert.Equal(t, gr.Policy, "generate-existing")
+		resources = append(resources, gr.Resource)
+	}
+	assert.DeepEqual(t, resources, []kyverno.ResourceSpec{
+		{Kind: "Namespace", Name: "default"},
+		{Kind: "Namespace", Name: "kube-system"},
+		{Kind: "ClusterIssuer", Name: "letsencrypt"},
+	})
+}
@@ -0,0 +1,204 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	conditionsQueueName = "policy-conditions"
+	// discoveryResyncDelay is the delay of the checks of the kinds of a new CRD,
+	// the cache of the registered resources is invalidated every 10 seconds
+	discoveryResyncDelay = 15 * time.Second
+)
+
+// checkKinds returns the kinds matched by the policy which are not served by the API server,
+// and the known kinds which are not forwarded to the webhook server
+func (pc *PolicyController) checkKinds(p *kyverno.ClusterPolicy) (unknown, uncovered []string) {
+	checked := map[string]bool{}
+	for _, rule := range p.Spec.Rules {
+		for _, kind := range rule.MatchResources.Kinds {
+			if checked[kind] || strings.Contains(kind, "*") {
+				continue
+			}
+			checked[kind] = true
+			gvr := pc.client.DiscoveryClient.GetGVRFromKind(kind)
+			if gvr.Empty() {
+				unknown = append(unknown, kind)
+				continue
+			}
+			if !pc.resourceWebhookWatcher.IsResourceCovered(gvr) {
+				uncovered = append(uncovered, kind)
+			}
+		}
+	}
+	return unknown, uncovered
+}
+
+// kindsResolvedCondition returns the KindsResolved condition of the policy, the rules matching
+// unknown kinds never apply, e.g. for a typo in a kind or a CRD not installed yet
+func kindsResolvedCondition(unknownKinds []string) kyverno.PolicyCondition {
+	if len(unknownKinds) != 0 {
+		return kyverno.PolicyCondition{
+			Type:    kyverno.PolicyKindsResolved,
+			Status:  v1.ConditionFalse,
+			Reason:  "UnknownKinds",
+			Message: fmt.Sprintf("the kinds %s are not served by the API server", strings.Join(unknownKinds, ", ")),
+		}
+	}
+	return kyverno.PolicyCondition{
+		Type:    kyverno.PolicyKindsResolved,
+		Status:  v1.ConditionTrue,
+		Reason:  "Succeeded",
+		Message: "the kinds of the policy are served by the API server",
+	}
+}
+
+// recordUnknownKinds records a warning event on the policy when its kinds are no longer resolved
+func (pc *PolicyController) recordUnknownKinds(obj runtime.Object, old, cur []kyverno.PolicyCondition) {
+	condition := getCondition(cur, kyverno.PolicyKindsResolved)
+	if condition == nil || condition.Status != v1.ConditionFalse {
+		return
+	}
+	if previous := getCondition(old, kyverno.PolicyKindsResolved); previous != nil && previous.Status == v1.ConditionFalse && previous.Message == condition.Message {
+		return
+	}
+	pc.eventRecorder.Event(obj, v1.EventTypeWarning, condition.Reason, condition.Message)
+}
+
+func getCondition(conditions []kyverno.PolicyCondition, conditionType string) *kyverno.PolicyCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+func (pc *PolicyController) addCRD(obj interface{}) {
+	pc.checkCRDKind(obj.(*unstructured.Unstructured))
+}
+
+func (pc *PolicyController) updateCRD(old, cur interface{}) {
+	pc.checkCRDKind(cur.(*unstructured.Unstructured))
+}
+
+// checkCRDKind queues the checks of the policies matching the kind of the CRD once it is established,
+// if their kinds are not resolved
+func (pc *PolicyController) checkCRDKind(crd *unstructured.Unstructured) {
+	if !isEstablished(crd) {
+		return
+	}
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	policies, err := pc.pLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list policies: %v", err)
+		return
+	}
+	for _, p := range policies {
+		if matchesUnresolvedKind(p.Spec, p.Status, kind) {
+			pc.conditionsQueue.AddAfter(p.Name, discoveryResyncDelay)
+		}
+	}
+	nsPolicies, err := pc.nspLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list namespaced policies: %v", err)
+		return
+	}
+	for _, p := range nsPolicies {
+		if matchesUnresolvedKind(p.Spec, p.Status, kind) {
+			pc.conditionsQueue.AddAfter(p.Namespace+"/"+p.Name, discoveryResyncDelay)
+		}
+	}
+}
+
+func isEstablished(crd *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == "Established" && condition["status"] == string(v1.ConditionTrue) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesUnresolvedKind returns true if the policy matches the kind and its kinds are not resolved
+func matchesUnresolvedKind(spec kyverno.Spec, status kyverno.PolicyStatus, kind string) bool {
+	condition := getCondition(status.Conditions, kyverno.PolicyKindsResolved)
+	if condition == nil || condition.Status == v1.ConditionTrue {
+		return false
+	}
+	for _, rule := range spec.Rules {
+		for _, k := range rule.MatchResources.Kinds {
+			if k == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (pc *PolicyController) conditionsWorker() {
+	for pc.processNextConditions() {
+	}
+}
+
+func (pc *PolicyController) processNextConditions() bool {
+	key, quit := pc.conditionsQueue.Get()
+	if quit {
+		return false
+	}
+	defer pc.conditionsQueue.Done(key)
+	err := pc.syncConditions(key.(string))
+	if err == nil {
+		pc.conditionsQueue.Forget(key)
+		return true
+	}
+	if pc.conditionsQueue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error syncing conditions of policy %v: %v", key, err)
+		pc.conditionsQueue.AddRateLimited(key)
+		return true
+	}
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping policy %q out of the conditions queue: %v", key, err)
+	pc.conditionsQueue.Forget(key)
+	return true
+}
+
+// syncConditions updates the conditions of the policy, the key is namespace/name for the namespaced policies
+func (pc *PolicyController) syncConditions(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	if namespace != "" {
+		p, err := pc.nspLister.Policies(namespace).Get(name)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return pc.syncNamespacedPolicyStatus(p)
+	}
+	p, err := pc.pLister.Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return pc.syncClusterPolicyStatus(p)
+}
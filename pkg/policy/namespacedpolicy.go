@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// The namespaced policies are only applied on the admission requests of their namespace,
+// they are registered in the policy meta-store and are not processed in the background,
+// their status only has the conditions updated on the status sync interval
+
+func (pc *PolicyController) addNamespacedPolicy(obj interface{}) {
+	p := obj.(*kyverno.Policy)
+	glog.V(4).Infof("Adding namespaced Policy %s/%s", p.Namespace, p.Name)
+	pc.pMetaStore.Register(*p.ToClusterPolicy())
+	pc.resourceWebhookWatcher.RegisterResourceWebhook()
+}
+
+func (pc *PolicyController) updateNamespacedPolicy(old, cur interface{}) {
+	oldP := old.(*kyverno.Policy)
+	curP := cur.(*kyverno.Policy)
+	if reflect.DeepEqual(oldP.Spec, curP.Spec) {
+		return
+	}
+	glog.V(4).Infof("Updating namespaced Policy %s/%s", curP.Namespace, curP.Name)
+	if err := pc.pMetaStore.UnRegister(*oldP.ToClusterPolicy()); err != nil {
+		glog.Infof("Failed to unregister policy %s/%s", oldP.Namespace, oldP.Name)
+	}
+	pc.pMetaStore.Register(*curP.ToClusterPolicy())
+	pc.resourceWebhookWatcher.RegisterResourceWebhook()
+}
+
+func (pc *PolicyController) deleteNamespacedPolicy(obj interface{}) {
+	p, ok := obj.(*kyverno.Policy)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			glog.Info(fmt.Errorf("Couldn't get object from tombstone %#v", obj))
+			return
+		}
+		p, ok = tombstone.Obj.(*kyverno.Policy)
+		if !ok {
+			glog.Info(fmt.Errorf("Tombstone contained object that is not a namespaced Policy %#v", obj))
+			return
+		}
+	}
+	glog.V(4).Infof("Deleting namespaced Policy %s/%s", p.Namespace, p.Name)
+	if err := pc.pMetaStore.UnRegister(*p.ToClusterPolicy()); err != nil {
+		glog.Infof("failed to unregister policy %s/%s", p.Namespace, p.Name)
+	}
+	// the webhook of the policies with the Fail failure policy is removed with the last of them
+	pc.resourceWebhookWatcher.RegisterResourceWebhook()
+}
+
+// syncNamespacedPolicyStatus updates the conditions of the namespaced policy
+func (pc *PolicyController) syncNamespacedPolicyStatus(p *kyverno.Policy) error {
+	conditions := pc.policyConditions(p.ToClusterPolicy(), p.Status.Conditions)
+	if reflect.DeepEqual(conditions, p.Status.Conditions) {
+		return nil
+	}
+	pc.recordUnknownKinds(p, p.Status.Conditions, conditions)
+	newPolicy := p.DeepCopy()
+	newPolicy.Status.Conditions = conditions
+	_, err := pc.kyvernoClient.KyvernoV1().Policies(p.Namespace).UpdateStatus(newPolicy)
+	return err
+}
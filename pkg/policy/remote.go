@@ -0,0 +1,155 @@
+package policy
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
+	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/config"
+	client "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/engine"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/externaldata"
+	"github.com/nirmata/kyverno/pkg/policyreport"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"github.com/nirmata/kyverno/pkg/ratelimit"
+	"github.com/nirmata/kyverno/pkg/registry"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+// RemoteCluster is a cluster whose existing resources are scanned from the management cluster
+type RemoteCluster struct {
+	Name   string
+	Client *client.Client
+}
+
+// ScanCluster applies the policies on the existing resources of the cluster of the client and returns the engine responses
+// of the mutation and the validation rules. The cluster is only read: the mutations not already applied are reported as
+// failed rules, the generate rules are not applied. The policies not applied in the background are skipped.
+func ScanCluster(client *client.Client, policies []kyverno.ClusterPolicy, configHandler config.Interface, registryClient registry.Interface, serviceClient externaldata.Interface) []response.EngineResponse {
+	// the ConfigMaps of the context entries are read from the scanned cluster
+	configMapResolver := clientConfigMapResolver{client: client}
+	var engineResponses []response.EngineResponse
+	for _, policy := range policies {
+		if !canBackgroundProcess(policy) {
+			glog.V(4).Infof("skipping policy %s, not applied in the background", policy.Name)
+			continue
+		}
+		listResources(client, policy, configHandler, "", func(resource unstructured.Unstructured) {
+			// skip reporting violation on pod which has annotation pod-policies.kyverno.io/autogen-applied
+			if skipPodApplication(resource) {
+				return
+			}
+			ctx := context.NewContext()
+			ctx.AddResource(transformResource(resource))
+			ctx.AddRequestInfo("", resource.GetNamespace())
+			engineResponse, err := mutation(policy, resource, nil, ctx, client, configMapResolver, registryClient, serviceClient, nil, nil)
+			if err != nil {
+				glog.Errorf("unable to process mutation rules: %v", err)
+			}
+			engineResponses = append(engineResponses, engineResponse)
+			engineResponses = append(engineResponses, engine.Validate(engine.PolicyContext{Policy: policy, Context: ctx, NewResource: resource, Client: client, ConfigMapResolver: configMapResolver, ImageRegistryClient: registryClient, ServiceClient: serviceClient, Log: enginelog.Glog}))
+		})
+	}
+	return engineResponses
+}
+
+// clientConfigMapResolver resolves the ConfigMaps of the context entries with the client of a remote cluster
+type clientConfigMapResolver struct {
+	client *client.Client
+}
+
+func (r clientConfigMapResolver) Get(namespace, name string) (*v1.ConfigMap, error) {
+	obj, err := r.client.GetResource("ConfigMap", namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	cm := &v1.ConfigMap{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, cm); err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+// RemoteScanner scans periodically the existing resources of the remote clusters with the cluster policies,
+// and saves the violations of each cluster in its ClusterPolicyReport in the management cluster
+type RemoteScanner struct {
+	clusters []RemoteCluster
+	// client of the management cluster
+	client         *client.Client
+	pLister        kyvernolister.ClusterPolicyLister
+	pSynced        cache.InformerSynced
+	configHandler  config.Interface
+	registryClient registry.Interface
+	serviceClient  externaldata.Interface
+	// maximum number of results of the chunks of the reports
+	maxResults int
+	limiter    *ratelimit.Limiter
+	interval   time.Duration
+}
+
+// NewRemoteScanner returns a scanner of the remote clusters, scanned every interval
+func NewRemoteScanner(clusters []RemoteCluster,
+	client *client.Client,
+	pInformer kyvernoinformer.ClusterPolicyInformer,
+	configHandler config.Interface,
+	registryClient registry.Interface,
+	serviceClient externaldata.Interface,
+	maxResults int,
+	limiter *ratelimit.Limiter,
+	interval time.Duration) *RemoteScanner {
+	return &RemoteScanner{
+		clusters:       clusters,
+		client:         client,
+		pLister:        pInformer.Lister(),
+		pSynced:        pInformer.Informer().HasSynced,
+		configHandler:  configHandler,
+		registryClient: registryClient,
+		serviceClient:  serviceClient,
+		maxResults:     maxResults,
+		limiter:        limiter,
+		interval:       interval,
+	}
+}
+
+// Run scans the remote clusters every interval until stopCh is closed
+func (rs *RemoteScanner) Run(stopCh <-chan struct{}) {
+	glog.Infof("Starting remote cluster scanner, %d clusters", len(rs.clusters))
+	defer glog.Info("Shutting down remote cluster scanner")
+	if !cache.WaitForCacheSync(stopCh, rs.pSynced) {
+		glog.Error("remote cluster scanner: failed to sync informer cache")
+		return
+	}
+	wait.Until(rs.scan, rs.interval, stopCh)
+}
+
+func (rs *RemoteScanner) scan() {
+	list, err := rs.pLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list policies: %v", err)
+		return
+	}
+	var policies []kyverno.ClusterPolicy
+	for _, policy := range list {
+		policies = append(policies, *policy.DeepCopy())
+	}
+	for _, cluster := range rs.clusters {
+		startTime := time.Now()
+		engineResponses := ScanCluster(cluster.Client, policies, rs.configHandler, rs.registryClient, rs.serviceClient)
+		infos := policyviolation.GeneratePVsFromEngineResponse(engineResponses)
+		if err := policyreport.SaveClusterReport(rs.client, cluster.Name, infos, rs.maxResults, rs.limiter); err != nil {
+			glog.Errorf("failed to save the policy report of cluster %s: %v", cluster.Name, err)
+			continue
+		}
+		glog.V(2).Infof("Scanned cluster %s in %v: %d violations", cluster.Name, time.Since(startTime), len(infos))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/nirmata/kyverno/pkg/engine/response"
 	"github.com/nirmata/kyverno/pkg/event"
+	"github.com/nirmata/kyverno/pkg/metrics"
 	"github.com/nirmata/kyverno/pkg/policyviolation"
 )
 
@@ -13,6 +14,7 @@ import (
 // - has violation -> report
 // - no violation -> cleanup policy violations
 func (pc *PolicyController) cleanupAndReport(engineResponses []response.EngineResponse) {
+	metrics.RecordEngineResponses(metrics.SourceBackground, engineResponses)
 	// generate Events
 	eventInfos := generateEvents(engineResponses)
 	pc.eventGen.Add(eventInfos...)
@@ -26,8 +28,16 @@ func (pc *PolicyController) cleanupAndReport(engineResponses []response.EngineRe
 }
 
 func (pc *PolicyController) cleanUp(ers []response.EngineResponse) {
+	// the mutation and the validation responses of a policy are separate,
+	// the resource is compliant only if none of them failed
+	failed := map[string]bool{}
 	for _, er := range ers {
 		if !er.IsSuccesful() {
+			failed[er.PolicyResponse.Policy+"/"+er.PolicyResponse.Resource.GetKey()] = true
+		}
+	}
+	for _, er := range ers {
+		if !er.IsSuccesful() || failed[er.PolicyResponse.Policy+"/"+er.PolicyResponse.Resource.GetKey()] {
 			continue
 		}
 		if len(er.PolicyResponse.Rules) == 0 {
@@ -35,6 +45,8 @@ func (pc *PolicyController) cleanUp(ers []response.EngineResponse) {
 		}
 		// clean up after the policy has been corrected
 		pc.cleanUpPolicyViolation(er.PolicyResponse)
+		// the policy reports remove the results of the compliant resource
+		pc.pvGenerator.Add(policyviolation.Info{PolicyName: er.PolicyResponse.Policy, Resource: er.PatchedResource})
 	}
 }
 
@@ -0,0 +1,168 @@
+package policy
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/nirmata/kyverno/pkg/config"
+	"github.com/nirmata/kyverno/pkg/metrics"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+const scanQueueName = "background-scan"
+
+// scanKey returns the key of the scan of the policy, limited to the namespace if not empty
+func scanKey(policy, namespace string) string {
+	return policy + "/" + namespace
+}
+
+// parseScanKey returns the policy and the namespace of the scan key
+func parseScanKey(key string) (policy, namespace string) {
+	i := strings.Index(key, "/")
+	if i < 0 {
+		return key, ""
+	}
+	return key[:i], key[i+1:]
+}
+
+// scanRequested returns true if the scan annotation was set or changed
+func scanRequested(old, cur map[string]string) bool {
+	value := cur[config.ScanAnnotation]
+	return value != "" && value != old[config.ScanAnnotation]
+}
+
+// ScanPolicy queues a scan of the policy on all the existing resources
+func (pc *PolicyController) ScanPolicy(name string) {
+	glog.V(4).Infof("Queue background scan of policy %s", name)
+	pc.scanQueue.Add(scanKey(name, ""))
+}
+
+// ScanNamespace queues the scans of the background policies on the existing resources of the namespace
+func (pc *PolicyController) ScanNamespace(namespace string) {
+	policies, err := pc.pLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list policies: %v", err)
+		return
+	}
+	glog.V(4).Infof("Queue background scan of namespace %s", namespace)
+	for _, policy := range policies {
+		if canBackgroundProcess(*policy) {
+			pc.scanQueue.Add(scanKey(policy.Name, namespace))
+		}
+	}
+}
+
+// SetScanInterval sets the interval of the background scans of all the policies, 0 disables them.
+// The next scan runs after the new interval.
+func (pc *PolicyController) SetScanInterval(interval time.Duration) {
+	if time.Duration(atomic.SwapInt64(&pc.scanInterval, int64(interval))) == interval {
+		return
+	}
+	glog.Infof("Background scan interval set to %v", interval)
+	select {
+	case pc.scanIntervalChanged <- struct{}{}:
+	default:
+	}
+}
+
+// runScans queues the scans of all the background policies every scan interval, the policies
+// are processed when they are added so the first scan runs after an interval
+func (pc *PolicyController) runScans(stopCh <-chan struct{}) {
+	for {
+		var next <-chan time.Time
+		var timer *time.Timer
+		if interval := time.Duration(atomic.LoadInt64(&pc.scanInterval)); interval > 0 {
+			timer = time.NewTimer(interval)
+			next = timer.C
+		}
+		select {
+		case <-next:
+			pc.scanAll()
+		case <-pc.scanIntervalChanged:
+		case <-stopCh:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+	}
+}
+
+func (pc *PolicyController) scanAll() {
+	policies, err := pc.pLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list policies: %v", err)
+		return
+	}
+	for _, policy := range policies {
+		if canBackgroundProcess(*policy) {
+			pc.ScanPolicy(policy.Name)
+		}
+	}
+}
+
+func (pc *PolicyController) updateNamespace(old, cur interface{}) {
+	oldNs := old.(*v1.Namespace)
+	curNs := cur.(*v1.Namespace)
+	if scanRequested(oldNs.GetAnnotations(), curNs.GetAnnotations()) {
+		pc.ScanNamespace(curNs.Name)
+	}
+}
+
+func (pc *PolicyController) scanWorker() {
+	for pc.processNextScan() {
+	}
+}
+
+func (pc *PolicyController) processNextScan() bool {
+	key, quit := pc.scanQueue.Get()
+	if quit {
+		return false
+	}
+	defer pc.scanQueue.Done(key)
+	err := pc.syncScan(key.(string))
+	if err == nil {
+		pc.scanQueue.Forget(key)
+		return true
+	}
+	if pc.scanQueue.NumRequeues(key) < maxRetries {
+		glog.V(2).Infof("Error scanning %v: %v", key, err)
+		pc.scanQueue.AddRateLimited(key)
+		return true
+	}
+	utilruntime.HandleError(err)
+	glog.V(2).Infof("Dropping scan %q out of the queue: %v", key, err)
+	pc.scanQueue.Forget(key)
+	return true
+}
+
+// syncScan applies the policy on the existing resources again, and reports the results
+func (pc *PolicyController) syncScan(key string) error {
+	name, namespace := parseScanKey(key)
+	policy, err := pc.pLister.Get(name)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !canBackgroundProcess(*policy) {
+		glog.V(4).Infof("skipping background scan of policy %s, background processing is disabled", name)
+		return nil
+	}
+	startTime := time.Now()
+	engineResponses := pc.processExistingResources(*policy, namespace, true)
+	pc.cleanupAndReport(engineResponses)
+	metrics.RecordBackgroundScan(name, time.Since(startTime))
+	glog.V(2).Infof("Scanned policy %s on namespace %q in %v: %d responses", name, namespace, time.Since(startTime), len(engineResponses))
+	return nil
+}
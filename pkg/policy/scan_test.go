@@ -0,0 +1,58 @@
+package policy
+
+import (
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/config"
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func Test_scanKey(t *testing.T) {
+	policy, namespace := parseScanKey(scanKey("require-labels", "default"))
+	assert.Equal(t, policy, "require-labels")
+	assert.Equal(t, namespace, "default")
+	policy, namespace = parseScanKey(scanKey("require-labels", ""))
+	assert.Equal(t, policy, "require-labels")
+	assert.Equal(t, namespace, "")
+}
+
+func Test_scanRequested(t *testing.T) {
+	assert.Assert(t, !scanRequested(nil, nil))
+	assert.Assert(t, scanRequested(nil, map[string]string{config.ScanAnnotation: "1"}))
+	assert.Assert(t, !scanRequested(map[string]string{config.ScanAnnotation: "1"}, map[string]string{config.ScanAnnotation: "1"}))
+	assert.Assert(t, scanRequested(map[string]string{config.ScanAnnotation: "1"}, map[string]string{config.ScanAnnotation: "2"}))
+	// removing the annotation does not trigger a scan
+	assert.Assert(t, !scanRequested(map[string]string{config.ScanAnnotation: "1"}, nil))
+}
+
+func Test_updateNamespace(t *testing.T) {
+	background := false
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	assert.NilError(t, indexer.Add(&kyverno.ClusterPolicy{ObjectMeta: metav1.ObjectMeta{Name: "require-labels"}}))
+	assert.NilError(t, indexer.Add(&kyverno.ClusterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "generate-quota"},
+		Spec:       kyverno.Spec{Background: &background},
+	}))
+	pc := &PolicyController{
+		pLister:   kyvernolister.NewClusterPolicyLister(indexer),
+		scanQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), scanQueueName),
+	}
+
+	old := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	pc.updateNamespace(old, old)
+	assert.Equal(t, pc.ScanLen(), 0)
+
+	// only the background policies scan the namespace
+	cur := old.DeepCopy()
+	cur.SetAnnotations(map[string]string{config.ScanAnnotation: "2026-10-15T10:00:00Z"})
+	pc.updateNamespace(old, cur)
+	assert.Equal(t, pc.ScanLen(), 1)
+	key, _ := pc.scanQueue.Get()
+	assert.Equal(t, key, scanKey("require-labels", "default"))
+}
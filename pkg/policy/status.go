@@ -1,15 +1,21 @@
 package policy
 
 import (
+	"math"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	kyvernoclient "github.com/nirmata/kyverno/pkg/client/clientset/versioned"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
+// maxExecutionTimes is the count of the recent execution times of a rule kept to compute the percentiles
+const maxExecutionTimes = 1000
+
 //PolicyStatusAggregator stores information abt aggregation
 type PolicyStatusAggregator struct {
 	// time since we start aggregating the stats
@@ -75,7 +81,9 @@ func (psa *PolicyStatusAggregator) aggregate(ps PolicyStat) {
 
 	info, ok := psa.policyData[ps.PolicyName]
 	if !ok {
-		psa.policyData[ps.PolicyName] = ps.Stats
+		info = ps.Stats
+		info.Rules = aggregateRules(nil, ps.Stats.Rules)
+		psa.policyData[ps.PolicyName] = info
 		glog.V(4).Infof("added stats for policy %s", ps.PolicyName)
 		return
 	}
@@ -110,34 +118,67 @@ func (psa *PolicyStatusAggregator) aggregate(ps PolicyStat) {
 	glog.V(4).Infof("updated stats for policy %s", ps.PolicyName)
 }
 
+// aggregateRules adds the executions of the update to the stats of the rules,
+// the execution time of the aggregated rules is the average of their executions
 func aggregateRules(old []RuleStatinfo, update []RuleStatinfo) []RuleStatinfo {
 	var zeroDuration time.Duration
-	searchRule := func(list []RuleStatinfo, key string) *RuleStatinfo {
-		for _, v := range list {
+	searchRule := func(list []RuleStatinfo, key string) int {
+		for i, v := range list {
 			if v.RuleName == key {
-				return &v
+				return i
 			}
 		}
-		return nil
+		return -1
 	}
-	newRules := []RuleStatinfo{}
-	// search for new rules in old rules and update it
+	newRules := append([]RuleStatinfo{}, old...)
 	for _, updateR := range update {
-		if updateR.ExecutionTime != zeroDuration {
-			if rule := searchRule(old, updateR.RuleName); rule != nil {
-				rule.ExecutionTime = (rule.ExecutionTime + updateR.ExecutionTime) / 2
-				rule.RuleAppliedCount = rule.RuleAppliedCount + updateR.RuleAppliedCount
-				rule.RulesFailedCount = rule.RulesFailedCount + updateR.RulesFailedCount
-				rule.MutationCount = rule.MutationCount + updateR.MutationCount
-				newRules = append(newRules, *rule)
-			} else {
-				newRules = append(newRules, updateR)
-			}
+		if updateR.ExecutionTime == zeroDuration {
+			continue
+		}
+		// the rule stats sent by the webhooks and the controllers are single executions
+		if updateR.ExecutionCount == 0 {
+			updateR.ExecutionCount = 1
 		}
+		i := searchRule(newRules, updateR.RuleName)
+		if i == -1 {
+			updateR.executionTimes = []time.Duration{updateR.ExecutionTime}
+			newRules = append(newRules, updateR)
+			continue
+		}
+		rule := newRules[i]
+		count := rule.ExecutionCount + updateR.ExecutionCount
+		rule.ExecutionTime = (rule.ExecutionTime*time.Duration(rule.ExecutionCount) + updateR.ExecutionTime*time.Duration(updateR.ExecutionCount)) / time.Duration(count)
+		rule.ExecutionCount = count
+		rule.RuleAppliedCount = rule.RuleAppliedCount + updateR.RuleAppliedCount
+		rule.RulesFailedCount = rule.RulesFailedCount + updateR.RulesFailedCount
+		rule.MutationCount = rule.MutationCount + updateR.MutationCount
+		// keep the recent execution times, the slice is not shared with the previous stats
+		executionTimes := append([]time.Duration{}, rule.executionTimes...)
+		executionTimes = append(executionTimes, updateR.ExecutionTime)
+		if len(executionTimes) > maxExecutionTimes {
+			executionTimes = executionTimes[len(executionTimes)-maxExecutionTimes:]
+		}
+		rule.executionTimes = executionTimes
+		newRules[i] = rule
 	}
 	return newRules
 }
 
+// percentile returns the p-th percentile of the recent execution times of the rule
+func (rs RuleStatinfo) percentile(p float64) time.Duration {
+	if len(rs.executionTimes) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, rs.executionTimes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	// nearest rank
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
 //GetPolicyStats returns the policy stats
 func (psa *PolicyStatusAggregator) GetPolicyStats(policyName string) PolicyStatInfo {
 	func() {
@@ -152,6 +193,67 @@ func (psa *PolicyStatusAggregator) GetPolicyStats(policyName string) PolicyStatI
 	return psa.policyData[policyName]
 }
 
+// TakePolicyStats returns the stats of the policies aggregated since the previous call, and resets them
+func (psa *PolicyStatusAggregator) TakePolicyStats() map[string]PolicyStatInfo {
+	psa.mux.Lock()
+	defer psa.mux.Unlock()
+	stats := psa.policyData
+	psa.policyData = map[string]PolicyStatInfo{}
+	return stats
+}
+
+// addStats returns the status with the stats added, the counts are summed and the average execution times
+// are weighted by the execution counts. The percentiles are the ones of the recent executions of the stats
+func addStats(status kyverno.PolicyStatus, stats PolicyStatInfo) kyverno.PolicyStatus {
+	status.RulesAppliedCount += stats.RulesAppliedCount
+	status.ResourcesBlockedCount += stats.ResourceBlocked
+	status.AvgExecutionTimeMutation = averageDuration(status.AvgExecutionTimeMutation, stats.MutationExecutionTime)
+	status.AvgExecutionTimeValidation = averageDuration(status.AvgExecutionTimeValidation, stats.ValidationExecutionTime)
+	status.AvgExecutionTimeGeneration = averageDuration(status.AvgExecutionTimeGeneration, stats.GenerationExecutionTime)
+	rules := append([]kyverno.RuleStats{}, status.Rules...)
+	for _, update := range convertRules(stats.Rules) {
+		i := -1
+		for j := range rules {
+			if rules[j].Name == update.Name {
+				i = j
+				break
+			}
+		}
+		if i == -1 {
+			rules = append(rules, update)
+			continue
+		}
+		rule := rules[i]
+		current, _ := time.ParseDuration(rule.ExecutionTime)
+		updateTime, _ := time.ParseDuration(update.ExecutionTime)
+		if count := rule.ExecutionCount + update.ExecutionCount; count != 0 {
+			rule.ExecutionTime = ((current*time.Duration(rule.ExecutionCount) + updateTime*time.Duration(update.ExecutionCount)) / time.Duration(count)).String()
+			rule.ExecutionCount = count
+		}
+		rule.AppliedCount += update.AppliedCount
+		rule.ViolationCount += update.ViolationCount
+		rule.MutationCount += update.MutationCount
+		if update.P95ExecutionTime != "" {
+			rule.P95ExecutionTime = update.P95ExecutionTime
+			rule.P99ExecutionTime = update.P99ExecutionTime
+		}
+		rules[i] = rule
+	}
+	status.Rules = rules
+	return status
+}
+
+// averageDuration returns the average of the duration of the status and of the update, as the aggregator does
+func averageDuration(current string, update time.Duration) string {
+	if update == 0 {
+		return current
+	}
+	if d, err := time.ParseDuration(current); err == nil && d != 0 {
+		update = (d + update) / 2
+	}
+	return update.String()
+}
+
 //RemovePolicyStats rmves policy stats records
 func (psa *PolicyStatusAggregator) RemovePolicyStats(policyName string) {
 	func() {
@@ -195,6 +297,9 @@ type RuleStatinfo struct {
 	RuleAppliedCount int
 	RulesFailedCount int
 	MutationCount    int
+	ExecutionCount   int
+	// recent execution times, at most maxExecutionTimes
+	executionTimes []time.Duration
 }
 
 //SendStat sends the stat information for aggregation
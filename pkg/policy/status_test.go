@@ -0,0 +1,159 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_aggregateRules(t *testing.T) {
+	var rules []RuleStatinfo
+	for i := 1; i <= 100; i++ {
+		update := RuleStatinfo{RuleName: "check-image", ExecutionTime: time.Duration(i) * time.Millisecond, RuleAppliedCount: 1}
+		if i%10 == 0 {
+			update.RuleAppliedCount, update.RulesFailedCount = 0, 1
+		}
+		rules = aggregateRules(rules, []RuleStatinfo{update})
+	}
+	rules = aggregateRules(rules, []RuleStatinfo{{RuleName: "check-labels", ExecutionTime: time.Millisecond, RuleAppliedCount: 1}})
+
+	assert.Equal(t, len(rules), 2)
+	rule := rules[0]
+	assert.Equal(t, rule.RuleName, "check-image")
+	assert.Equal(t, rule.ExecutionCount, 100)
+	assert.Equal(t, rule.RuleAppliedCount, 90)
+	assert.Equal(t, rule.RulesFailedCount, 10)
+	assert.Equal(t, rule.ExecutionTime, 50500*time.Microsecond)
+	assert.Equal(t, rule.percentile(0.95), 95*time.Millisecond)
+	assert.Equal(t, rule.percentile(0.99), 99*time.Millisecond)
+	assert.Equal(t, rules[1].ExecutionCount, 1)
+}
+
+func Test_aggregateRules_MaxExecutionTimes(t *testing.T) {
+	var rules []RuleStatinfo
+	for i := 0; i < maxExecutionTimes+10; i++ {
+		rules = aggregateRules(rules, []RuleStatinfo{{RuleName: "check-image", ExecutionTime: time.Millisecond}})
+	}
+	assert.Equal(t, rules[0].ExecutionCount, maxExecutionTimes+10)
+	assert.Equal(t, len(rules[0].executionTimes), maxExecutionTimes)
+}
+
+func Test_TakePolicyStats(t *testing.T) {
+	psa := NewPolicyStatAggregator(nil)
+	psa.aggregate(PolicyStat{PolicyName: "check-labels", Stats: PolicyStatInfo{
+		RulesAppliedCount: 1,
+		Rules:             []RuleStatinfo{{RuleName: "check-app", ExecutionTime: time.Millisecond, RuleAppliedCount: 1}},
+	}})
+	stats := psa.TakePolicyStats()
+	assert.Equal(t, stats["check-labels"].RulesAppliedCount, 1)
+	// the stats are added once to the status
+	assert.Equal(t, len(psa.TakePolicyStats()), 0)
+}
+
+func Test_addStats(t *testing.T) {
+	// the status written by the other replicas
+	status := kyverno.PolicyStatus{
+		ViolationCount:           2,
+		RulesAppliedCount:        10,
+		ResourcesBlockedCount:    1,
+		AvgExecutionTimeMutation: "2ms",
+		Rules: []kyverno.RuleStats{
+			{Name: "check-app", ExecutionTime: "1ms", AppliedCount: 3, ViolationCount: 1, ExecutionCount: 4, P95ExecutionTime: "2ms", P99ExecutionTime: "2ms"},
+		},
+	}
+	stats := PolicyStatInfo{
+		RulesAppliedCount:       5,
+		ResourceBlocked:         2,
+		MutationExecutionTime:   4 * time.Millisecond,
+		ValidationExecutionTime: time.Millisecond,
+		Rules: aggregateRules(nil, []RuleStatinfo{
+			{RuleName: "check-app", ExecutionTime: 5 * time.Millisecond, RuleAppliedCount: 1},
+			{RuleName: "check-owner", ExecutionTime: time.Millisecond, RulesFailedCount: 1},
+		}),
+	}
+	status = addStats(status, stats)
+	assert.Equal(t, status.ViolationCount, 2)
+	assert.Equal(t, status.RulesAppliedCount, 15)
+	assert.Equal(t, status.ResourcesBlockedCount, 3)
+	assert.Equal(t, status.AvgExecutionTimeMutation, "3ms")
+	assert.Equal(t, status.AvgExecutionTimeValidation, "1ms")
+	assert.Equal(t, status.AvgExecutionTimeGeneration, "")
+	assert.DeepEqual(t, status.Rules, []kyverno.RuleStats{
+		{Name: "check-app", ExecutionTime: "1.8ms", AppliedCount: 4, ViolationCount: 1, ExecutionCount: 5, P95ExecutionTime: "5ms", P99ExecutionTime: "5ms"},
+		{Name: "check-owner", ExecutionTime: "1ms", ViolationCount: 1, ExecutionCount: 1, P95ExecutionTime: "1ms", P99ExecutionTime: "1ms"},
+	})
+}
+
+func Test_countRules(t *testing.T) {
+	p := &kyverno.ClusterPolicy{Spec: kyverno.Spec{Rules: []kyverno.Rule{
+		{Name: "mutate", Mutation: kyverno.Mutation{Overlay: map[string]interface{}{"a": "b"}}},
+		{Name: "validate", Validation: kyverno.Validation{Pattern: map[string]interface{}{"a": "b"}}},
+		{Name: "validate-2", Validation: kyverno.Validation{Pattern: map[string]interface{}{"a": "c"}}},
+	}}}
+	assert.DeepEqual(t, countRules(p), kyverno.RuleCountStatus{Total: 3, Mutate: 1, Validate: 2})
+}
+
+func Test_setCondition(t *testing.T) {
+	transition := metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	conditions := []kyverno.PolicyCondition{{Type: kyverno.PolicyReady, Status: v1.ConditionTrue, LastTransitionTime: transition}}
+
+	// the transition time is kept while the status does not change
+	updated := setCondition(conditions, readyCondition(rulesValidatedCondition(nil), webhookConfiguredCondition(true, nil)))
+	assert.Equal(t, len(updated), 1)
+	assert.Equal(t, updated[0].Reason, "Succeeded")
+	assert.Equal(t, updated[0].LastTransitionTime, transition)
+
+	updated = setCondition(conditions, readyCondition(rulesValidatedCondition(nil), webhookConfiguredCondition(false, nil)))
+	assert.Equal(t, len(updated), 1)
+	assert.Equal(t, updated[0].Status, v1.ConditionFalse)
+	assert.Assert(t, updated[0].LastTransitionTime.After(transition.Time))
+}
+
+func Test_readyCondition(t *testing.T) {
+	// the Ready condition has the reason of the first False condition
+	ready := readyCondition(rulesValidatedCondition(nil), webhookConfiguredCondition(true, []string{"Pod", "Service"}))
+	assert.Equal(t, ready.Type, kyverno.PolicyReady)
+	assert.Equal(t, ready.Status, v1.ConditionFalse)
+	assert.Equal(t, ready.Reason, "KindsNotCovered")
+	assert.Equal(t, ready.Message, "the resource webhook configuration does not cover the kinds Pod, Service")
+
+	ready = readyCondition(rulesValidatedCondition(errors.New("duplicate rule name")), webhookConfiguredCondition(false, nil))
+	assert.Equal(t, ready.Reason, "ValidationFailed")
+	assert.Equal(t, ready.Message, "duplicate rule name")
+
+	ready = readyCondition(rulesValidatedCondition(nil), webhookConfiguredCondition(true, nil))
+	assert.Equal(t, ready.Status, v1.ConditionTrue)
+
+	// the conditions keep their order when they are updated
+	conditions := setCondition(nil, rulesValidatedCondition(nil))
+	conditions = setCondition(conditions, webhookConfiguredCondition(true, nil))
+	conditions = setCondition(conditions, ready)
+	conditions = setCondition(conditions, rulesValidatedCondition(nil))
+	conditions = setCondition(conditions, webhookConfiguredCondition(true, nil))
+	conditions = setCondition(conditions, ready)
+	assert.Equal(t, conditions[0].Type, kyverno.PolicyRulesValidated)
+	assert.Equal(t, conditions[1].Type, kyverno.PolicyWebhookConfigured)
+	assert.Equal(t, conditions[2].Type, kyverno.PolicyReady)
+}
+
+func Test_kindsResolvedCondition(t *testing.T) {
+	condition := kindsResolvedCondition([]string{"Foo", "Bar"})
+	assert.Equal(t, condition.Status, v1.ConditionFalse)
+	assert.Equal(t, condition.Reason, "UnknownKinds")
+	assert.Equal(t, condition.Message, "the kinds Foo, Bar are not served by the API server")
+	assert.Equal(t, kindsResolvedCondition(nil).Status, v1.ConditionTrue)
+
+	// the policies are checked again on the new CRDs of their unknown kinds
+	spec := kyverno.Spec{Rules: []kyverno.Rule{{MatchResources: kyverno.MatchResources{ResourceDescription: kyverno.ResourceDescription{Kinds: []string{"Pod", "Foo"}}}}}}
+	status := kyverno.PolicyStatus{Conditions: setCondition(nil, condition)}
+	assert.Assert(t, matchesUnresolvedKind(spec, status, "Foo"))
+	assert.Assert(t, !matchesUnresolvedKind(spec, status, "Baz"))
+	status.Conditions = setCondition(status.Conditions, kindsResolvedCondition(nil))
+	assert.Assert(t, !matchesUnresolvedKind(spec, status, "Foo"))
+	assert.Assert(t, !matchesUnresolvedKind(spec, kyverno.PolicyStatus{}, "Foo"))
+}
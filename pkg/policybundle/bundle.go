@@ -0,0 +1,172 @@
+package policybundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/cosign"
+	"github.com/nirmata/kyverno/pkg/registry"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// media types of the manifest of a policy bundle, the config identifies the artifact
+// and the single layer has the YAML documents of the policies
+const (
+	manifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ConfigMediaType   = "application/vnd.kyverno.policy.config.v1+json"
+	LayerMediaType    = "application/vnd.kyverno.policy.layer.v1+yaml"
+)
+
+// titleAnnotation is the file name of the layer, used when the layer is pulled as a file
+const titleAnnotation = "org.opencontainers.image.title"
+
+// layerTitle is the file name of the policies of a bundle
+const layerTitle = "policies.yaml"
+
+// Pusher pushes the blobs and the manifests to the registries
+type Pusher interface {
+	PushBlob(image string, data []byte, keychain registry.Keychain) (string, error)
+	PushManifest(image, mediaType string, manifest []byte, keychain registry.Keychain) (string, error)
+}
+
+// Fetcher fetches the manifests and the blobs from the registries
+type Fetcher interface {
+	FetchManifest(image string, keychain registry.Keychain) ([]byte, string, error)
+	FetchBlob(image, digest string, keychain registry.Keychain) ([]byte, error)
+}
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int               `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        descriptor   `json:"config"`
+	Layers        []descriptor `json:"layers"`
+}
+
+// Push pushes the YAML documents of the policies as a bundle with the tag or the digest of the image and returns the digest of the bundle,
+// the bundle can be signed with cosign as any image
+func Push(pusher Pusher, image string, policies []byte, keychain registry.Keychain) (string, error) {
+	if _, err := Decode(policies); err != nil {
+		return "", err
+	}
+	config := []byte("{}")
+	configDigest, err := pusher.PushBlob(image, config, keychain)
+	if err != nil {
+		return "", fmt.Errorf("failed to push the config of %s: %v", image, err)
+	}
+	layerDigest, err := pusher.PushBlob(image, policies, keychain)
+	if err != nil {
+		return "", fmt.Errorf("failed to push the policies of %s: %v", image, err)
+	}
+	data, err := json.Marshal(manifest{
+		SchemaVersion: 2,
+		MediaType:     manifestMediaType,
+		Config:        descriptor{MediaType: ConfigMediaType, Digest: configDigest, Size: len(config)},
+		Layers: []descriptor{{
+			MediaType:   LayerMediaType,
+			Digest:      layerDigest,
+			Size:        len(policies),
+			Annotations: map[string]string{titleAnnotation: layerTitle},
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return pusher.PushManifest(image, manifestMediaType, data, keychain)
+}
+
+// Pull returns the YAML documents of the policies of the bundle and the digest of the bundle
+func Pull(fetcher Fetcher, image string, keychain registry.Keychain) ([]byte, string, error) {
+	data, digest, err := fetcher.FetchManifest(image, keychain)
+	if err != nil {
+		return nil, "", err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, "", fmt.Errorf("failed to decode the manifest of %s: %v", image, err)
+	}
+	if m.Config.MediaType != ConfigMediaType {
+		return nil, "", fmt.Errorf("%s is not a policy bundle, the media type of its config is %q", image, m.Config.MediaType)
+	}
+	if len(m.Layers) != 1 || m.Layers[0].MediaType != LayerMediaType {
+		return nil, "", fmt.Errorf("invalid policy bundle %s, it must have a single layer of media type %s", image, LayerMediaType)
+	}
+	policies, err := fetcher.FetchBlob(image, m.Layers[0].Digest, keychain)
+	if err != nil {
+		return nil, "", err
+	}
+	return policies, digest, nil
+}
+
+// Verify verifies the cosign signature of the bundle with the key and returns the image with the verified digest,
+// so the tag cannot be moved between the verification and the pull
+func Verify(verifier cosign.Interface, image, key string, keychain registry.Keychain) (string, error) {
+	digest, err := verifier.Verify(image, cosign.Options{Key: key, Keychain: keychain})
+	if err != nil {
+		return "", fmt.Errorf("failed to verify the signature of %s: %v", image, err)
+	}
+	ref, err := registry.ParseImageReference(image)
+	if err != nil {
+		return "", err
+	}
+	ref.Tag = ""
+	ref.Digest = digest
+	return ref.String(), nil
+}
+
+// Fetch returns the policies of the bundle and its digest, the signature of the bundle is verified with the key if set
+func Fetch(fetcher Fetcher, verifier cosign.Interface, image, key string, keychain registry.Keychain) ([]kyverno.ClusterPolicy, string, error) {
+	if key != "" {
+		var err error
+		if image, err = Verify(verifier, image, key, keychain); err != nil {
+			return nil, "", err
+		}
+	}
+	data, digest, err := Pull(fetcher, image, keychain)
+	if err != nil {
+		return nil, "", err
+	}
+	policies, err := Decode(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %v", image, err)
+	}
+	return policies, digest, nil
+}
+
+// Decode returns the cluster policies of the YAML documents of a bundle, the empty documents are skipped
+func Decode(data []byte) ([]kyverno.ClusterPolicy, error) {
+	var policies []kyverno.ClusterPolicy
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		policy := kyverno.ClusterPolicy{}
+		if err := decoder.Decode(&policy); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode the policies: %v", err)
+		}
+		if policy.Kind == "" && policy.Name == "" {
+			continue
+		}
+		if policy.Kind != "ClusterPolicy" {
+			return nil, fmt.Errorf("%s %s is not a ClusterPolicy", policy.Kind, policy.Name)
+		}
+		if policy.Name == "" {
+			return nil, fmt.Errorf("the name of a ClusterPolicy is not set")
+		}
+		policies = append(policies, policy)
+	}
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("no ClusterPolicy in the bundle")
+	}
+	return policies, nil
+}
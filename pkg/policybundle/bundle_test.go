@@ -0,0 +1,165 @@
+package policybundle
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nirmata/kyverno/pkg/cosign"
+	"github.com/nirmata/kyverno/pkg/registry"
+	"gotest.tools/assert"
+)
+
+// memoryRegistry stores the blobs and the manifests of a single repository
+type memoryRegistry struct {
+	blobs     map[string][]byte
+	manifests map[string][]byte
+}
+
+func newMemoryRegistry() *memoryRegistry {
+	return &memoryRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+}
+
+func (r *memoryRegistry) PushBlob(image string, data []byte, keychain registry.Keychain) (string, error) {
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	r.blobs[digest] = data
+	return digest, nil
+}
+
+func (r *memoryRegistry) PushManifest(image, mediaType string, manifest []byte, keychain registry.Keychain) (string, error) {
+	ref, err := registry.ParseImageReference(image)
+	if err != nil {
+		return "", err
+	}
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(manifest))
+	r.manifests[ref.Identifier()] = manifest
+	r.manifests[digest] = manifest
+	return digest, nil
+}
+
+func (r *memoryRegistry) FetchManifest(image string, keychain registry.Keychain) ([]byte, string, error) {
+	ref, err := registry.ParseImageReference(image)
+	if err != nil {
+		return nil, "", err
+	}
+	manifest, ok := r.manifests[ref.Identifier()]
+	if !ok {
+		return nil, "", fmt.Errorf("manifest %s not found", ref.Identifier())
+	}
+	return manifest, fmt.Sprintf("sha256:%x", sha256.Sum256(manifest)), nil
+}
+
+func (r *memoryRegistry) FetchBlob(image, digest string, keychain registry.Keychain) ([]byte, error) {
+	blob, ok := r.blobs[digest]
+	if !ok {
+		return nil, fmt.Errorf("blob %s not found", digest)
+	}
+	return blob, nil
+}
+
+// fakeVerifier verifies the signatures made with its key, the digest is the one of the signed image
+type fakeVerifier struct {
+	key    string
+	digest string
+	images []string
+}
+
+func (v *fakeVerifier) Verify(image string, opts cosign.Options) (string, error) {
+	v.images = append(v.images, image)
+	if opts.Key != v.key {
+		return "", fmt.Errorf("invalid signature")
+	}
+	return v.digest, nil
+}
+
+func (v *fakeVerifier) FetchAttestations(image string, opts cosign.Options) ([]cosign.Statement, error) {
+	return nil, nil
+}
+
+const policies = `
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: require-labels
+spec:
+  rules:
+  - name: check-labels
+    match:
+      resources:
+        kinds:
+        - Pod
+    validate:
+      pattern:
+        metadata:
+          labels:
+            app: "?*"
+---
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: disallow-latest
+spec:
+  rules:
+  - name: check-tag
+    match:
+      resources:
+        kinds:
+        - Pod
+    validate:
+      pattern:
+        spec:
+          containers:
+          - image: "!*:latest"
+`
+
+func Test_PushPull(t *testing.T) {
+	reg := newMemoryRegistry()
+	digest, err := Push(reg, "ghcr.io/org/policies:v1", []byte(policies), nil)
+	assert.NilError(t, err)
+
+	data, pulledDigest, err := Pull(reg, "ghcr.io/org/policies:v1", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), policies)
+	assert.Equal(t, pulledDigest, digest)
+
+	// the layer is named so it can be pulled as a file with the other OCI clients
+	manifest := string(reg.manifests["v1"])
+	assert.Assert(t, strings.Contains(manifest, `"org.opencontainers.image.title":"policies.yaml"`), manifest)
+	assert.Assert(t, strings.Contains(manifest, ConfigMediaType), manifest)
+
+	_, err = Push(reg, "ghcr.io/org/policies:v2", []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"), nil)
+	assert.ErrorContains(t, err, "ConfigMap test is not a ClusterPolicy")
+
+	// the images that are not bundles are rejected
+	_, err = reg.PushManifest("ghcr.io/org/policies:image", manifestMediaType, []byte(`{"config": {"mediaType": "application/vnd.oci.image.config.v1+json"}}`), nil)
+	assert.NilError(t, err)
+	_, _, err = Pull(reg, "ghcr.io/org/policies:image", nil)
+	assert.ErrorContains(t, err, "is not a policy bundle")
+}
+
+func Test_Fetch(t *testing.T) {
+	reg := newMemoryRegistry()
+	digest, err := Push(reg, "ghcr.io/org/policies:v1", []byte(policies), nil)
+	assert.NilError(t, err)
+
+	loaded, loadedDigest, err := Fetch(reg, nil, "ghcr.io/org/policies:v1", "", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, loadedDigest, digest)
+	assert.Equal(t, len(loaded), 2)
+	assert.Equal(t, loaded[0].Name, "require-labels")
+	assert.Equal(t, loaded[1].Name, "disallow-latest")
+
+	// the verified digest is pulled, not the tag
+	verifier := &fakeVerifier{key: "key", digest: digest}
+	_, err = Push(reg, "ghcr.io/org/policies:v1", []byte(policies+"---\n"), nil)
+	assert.NilError(t, err)
+	loaded, loadedDigest, err = Fetch(reg, verifier, "ghcr.io/org/policies:v1", "key", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, loadedDigest, digest)
+	assert.Equal(t, len(loaded), 2)
+	assert.DeepEqual(t, verifier.images, []string{"ghcr.io/org/policies:v1"})
+
+	_, _, err = Fetch(reg, verifier, "ghcr.io/org/policies:v1", "other", nil)
+	assert.ErrorContains(t, err, "failed to verify the signature of ghcr.io/org/policies:v1")
+}
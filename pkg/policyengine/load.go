@@ -0,0 +1,48 @@
+package policyengine
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	policyvalidate "github.com/nirmata/kyverno/pkg/engine/policy"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// LoadPolicies returns the ClusterPolicies and the namespaced Policies of the YAML or JSON documents,
+// an error if a document is not a policy or if a policy is not valid. The empty documents are skipped.
+func LoadPolicies(data []byte) ([]kyverno.ClusterPolicy, error) {
+	var policies []kyverno.ClusterPolicy
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		// the Policies have the fields of the ClusterPolicies, with a namespace
+		policy := kyverno.ClusterPolicy{}
+		if err := decoder.Decode(&policy); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode the policies: %v", err)
+		}
+		if policy.Kind == "" && policy.Name == "" {
+			continue
+		}
+		switch policy.Kind {
+		case "ClusterPolicy":
+			if policy.Namespace != "" {
+				return nil, fmt.Errorf("ClusterPolicy %s: the cluster policies have no namespace", policy.Name)
+			}
+		case "Policy":
+			if policy.Namespace == "" {
+				return nil, fmt.Errorf("Policy %s: the namespace of the policy is required", policy.Name)
+			}
+		default:
+			return nil, fmt.Errorf("%s %s is not a ClusterPolicy or a Policy", policy.Kind, policy.Name)
+		}
+		if err := policyvalidate.Validate(policy); err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %v", policy.Kind, policy.Name, err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
@@ -0,0 +1,287 @@
+// Package policyengine is the API of the policy engine for the Go programs that embed it: load the policies,
+// then evaluate the resources with an Engine. The engine has no global state, its logs are discarded unless
+// a logger is set in the options.
+package policyengine
+
+import (
+	"encoding/json"
+	"fmt"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/cosign"
+	"github.com/nirmata/kyverno/pkg/engine"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/engine/utils"
+	"github.com/nirmata/kyverno/pkg/externaldata"
+	"github.com/nirmata/kyverno/pkg/notary"
+	"github.com/nirmata/kyverno/pkg/registry"
+	"k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Status is the status of the result of a rule
+type Status string
+
+// statuses of the results of the rules
+const (
+	StatusPass  Status = "pass"
+	StatusFail  Status = "fail"
+	StatusError Status = "error"
+)
+
+// Options are the clients of the rules, the rules that need a client that is not set fail with an error
+type Options struct {
+	// Client - used by the API calls of the rule context
+	Client engine.Client
+	// ConfigMapResolver - used to load ConfigMaps in the rule context
+	ConfigMapResolver engine.ConfigMapResolver
+	// RegistryClient - used to load image data in the rule context
+	RegistryClient registry.Interface
+	// ServiceClient - used to call the external services in the rule context
+	ServiceClient externaldata.Interface
+	// GlobalContext - the context entries shared by all policies
+	GlobalContext *engine.GlobalContext
+	// ImageVerifier - used to verify the image signatures, the verifyImages rules are applied if it or NotaryVerifier is set
+	ImageVerifier cosign.Interface
+	// NotaryVerifier - used to verify the Notary v2 signatures of the verifyImages rules
+	NotaryVerifier notary.Interface
+	// Exceptions - the PolicyExceptions exempting resources from rules, whatever their namespace
+	Exceptions []kyverno.PolicyException
+	// Log - the logger of the engine, the logs are discarded if it is not set
+	Log log.Logger
+}
+
+// Engine applies policies on resources
+type Engine struct {
+	policies []kyverno.ClusterPolicy
+	options  Options
+}
+
+// New returns the engine of the policies
+func New(policies []kyverno.ClusterPolicy, options Options) *Engine {
+	return &Engine{policies: policies, options: options}
+}
+
+// Request is a resource to evaluate
+type Request struct {
+	// Resource is the resource the policies are applied on
+	Resource unstructured.Unstructured
+	// OldResource is the resource before the update, the resource is evaluated as created if it is not set
+	OldResource unstructured.Unstructured
+	// UserInfo is the user of the request, matched by the subjects and the roles of the rules
+	UserInfo kyverno.RequestInfo
+	// Variables are added to the context of the rules, with the resource and the user info
+	Variables map[string]interface{}
+}
+
+// Result is the result of the evaluation of a resource
+type Result struct {
+	// Resource is the resource mutated by the policies
+	Resource unstructured.Unstructured `json:"-"`
+	// Patches are the JSON patches of the mutate rules and of the verifyImages rules
+	Patches []json.RawMessage `json:"patches,omitempty"`
+	// Policies are the results of the policies applied on the resource
+	Policies []PolicyResult `json:"policies"`
+}
+
+// PolicyResult is the result of a policy
+type PolicyResult struct {
+	Name                    string       `json:"name"`
+	Namespace               string       `json:"namespace,omitempty"`
+	ValidationFailureAction string       `json:"validationFailureAction"`
+	Rules                   []RuleResult `json:"rules"`
+}
+
+// RuleResult is the result of a rule of a policy
+type RuleResult struct {
+	Name string `json:"name"`
+	// Type is the type of the rule: Mutation, Validation or ImageVerify
+	Type    string            `json:"type"`
+	Status  Status            `json:"status"`
+	Message string            `json:"message"`
+	Patches []json.RawMessage `json:"patches,omitempty"`
+}
+
+// Blocked returns true if the rule fails in a policy whose validationFailureAction is enforce,
+// the admission webhook would reject the request
+func (r PolicyResult) Blocked(rule RuleResult) bool {
+	return rule.Status != StatusPass && rule.Type != utils.Mutation.String() && r.ValidationFailureAction == "enforce"
+}
+
+// Allowed returns true if no rule of an enforced policy fails
+func (r *Result) Allowed() bool {
+	for _, policy := range r.Policies {
+		for _, rule := range policy.Rules {
+			if policy.Blocked(rule) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Message returns the messages of the rules that block the request, as the admission webhook
+func (r *Result) Message() string {
+	var message string
+	for _, policy := range r.Policies {
+		for _, rule := range policy.Rules {
+			if !policy.Blocked(rule) {
+				continue
+			}
+			if message != "" {
+				message += "; "
+			}
+			message += fmt.Sprintf("policy %s: rule %s (%s): %s", policy.Name, rule.Name, rule.Type, rule.Message)
+		}
+	}
+	return message
+}
+
+// Evaluate applies the policies on the resource as the admission webhook does: the resource is mutated by all
+// the policies, then its images are verified and it is validated
+func (e *Engine) Evaluate(request Request) (*Result, error) {
+	if request.Resource.Object == nil {
+		return nil, fmt.Errorf("the resource is required")
+	}
+	resource := request.Resource
+	policyContext := engine.PolicyContext{
+		OldResource:         request.OldResource,
+		AdmissionInfo:       request.UserInfo,
+		Client:              e.options.Client,
+		ConfigMapResolver:   e.options.ConfigMapResolver,
+		ImageRegistryClient: e.options.RegistryClient,
+		ServiceClient:       e.options.ServiceClient,
+		GlobalContext:       e.options.GlobalContext,
+		ImageVerifier:       e.options.ImageVerifier,
+		NotaryVerifier:      e.options.NotaryVerifier,
+		Exceptions:          e.options.Exceptions,
+		Log:                 e.options.Log,
+	}
+	var engineResponses []response.EngineResponse
+	var patches [][]byte
+	// apply the policies on the resource patched by the previous ones
+	apply := func(apply func(engine.PolicyContext) response.EngineResponse, patch bool) error {
+		for _, policy := range e.policies {
+			request.Resource = resource
+			ctx, err := NewContext(request)
+			if err != nil {
+				return err
+			}
+			policyContext.Policy = policy
+			policyContext.NewResource = resource
+			policyContext.Context = ctx
+			engineResponse := apply(policyContext)
+			engineResponses = append(engineResponses, engineResponse)
+			if !patch {
+				continue
+			}
+			if !engineResponse.IsSuccesful() {
+				e.options.Log.V(4).Infof("Failed to apply policy %s on resource %s/%s", policy.Name, resource.GetNamespace(), resource.GetName())
+				continue
+			}
+			if policyPatches := engineResponse.GetPatches(); len(policyPatches) != 0 {
+				patches = append(patches, policyPatches...)
+				resource = engineResponse.PatchedResource
+			}
+		}
+		return nil
+	}
+	if err := apply(engine.Mutate, true); err != nil {
+		return nil, err
+	}
+	if e.options.ImageVerifier != nil || e.options.NotaryVerifier != nil {
+		if err := apply(engine.VerifyImages, true); err != nil {
+			return nil, err
+		}
+	}
+	if err := apply(engine.Validate, false); err != nil {
+		return nil, err
+	}
+	return newResult(resource, patches, engineResponses), nil
+}
+
+// NewContext returns the context of the variables of the rules, with the resource, the user info and the variables of the request
+func NewContext(request Request) (*context.Context, error) {
+	raw, err := request.Resource.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource: %v", err)
+	}
+	ctx := context.NewContext()
+	if err := ctx.AddResource(raw); err != nil {
+		return nil, fmt.Errorf("failed to add the resource to the context: %v", err)
+	}
+	if err := ctx.AddUserInfo(request.UserInfo); err != nil {
+		return nil, fmt.Errorf("failed to add the user info to the context: %v", err)
+	}
+	operation := v1beta1.Create
+	if len(request.OldResource.Object) != 0 {
+		operation = v1beta1.Update
+	}
+	if err := ctx.AddRequestInfo(string(operation), request.Resource.GetNamespace()); err != nil {
+		return nil, fmt.Errorf("failed to add the request info to the context: %v", err)
+	}
+	if err := ctx.AddSA(request.UserInfo.AdmissionUserInfo.Username); err != nil {
+		return nil, fmt.Errorf("failed to add the service account to the context: %v", err)
+	}
+	if len(request.Variables) != 0 {
+		raw, err := json.Marshal(request.Variables)
+		if err != nil {
+			return nil, fmt.Errorf("invalid variables: %v", err)
+		}
+		if err := ctx.AddJSON(raw); err != nil {
+			return nil, fmt.Errorf("failed to add the variables to the context: %v", err)
+		}
+	}
+	return ctx, nil
+}
+
+// newResult returns the result of the engine responses, per policy
+func newResult(resource unstructured.Unstructured, patches [][]byte, engineResponses []response.EngineResponse) *Result {
+	result := &Result{Resource: resource, Policies: []PolicyResult{}}
+	for _, patch := range patches {
+		result.Patches = append(result.Patches, json.RawMessage(patch))
+	}
+	policies := map[string]int{}
+	for _, engineResponse := range engineResponses {
+		policyResponse := engineResponse.PolicyResponse
+		if len(policyResponse.Rules) == 0 {
+			continue
+		}
+		key := policyResponse.PolicyNamespace + "/" + policyResponse.Policy
+		i, ok := policies[key]
+		if !ok {
+			i = len(result.Policies)
+			policies[key] = i
+			result.Policies = append(result.Policies, PolicyResult{
+				Name:      policyResponse.Policy,
+				Namespace: policyResponse.PolicyNamespace,
+			})
+		}
+		if policyResponse.ValidationFailureAction != "" {
+			// not set by the mutation
+			result.Policies[i].ValidationFailureAction = policyResponse.ValidationFailureAction
+		}
+		for _, rule := range policyResponse.Rules {
+			ruleResult := RuleResult{Name: rule.Name, Type: rule.Type, Status: ruleStatus(rule), Message: rule.Message}
+			for _, patch := range rule.Patches {
+				ruleResult.Patches = append(ruleResult.Patches, json.RawMessage(patch))
+			}
+			result.Policies[i].Rules = append(result.Policies[i].Rules, ruleResult)
+		}
+	}
+	return result
+}
+
+// ruleStatus returns the status of the rule response, error if the rule could not be applied
+func ruleStatus(rule response.RuleResponse) Status {
+	switch {
+	case rule.Success:
+		return StatusPass
+	case rule.Error:
+		return StatusError
+	default:
+		return StatusFail
+	}
+}
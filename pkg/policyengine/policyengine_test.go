@@ -0,0 +1,122 @@
+package policyengine
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testPolicies = `
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: add-labels
+spec:
+  rules:
+  - name: add-team
+    match:
+      resources:
+        kinds:
+        - Pod
+    mutate:
+      overlay:
+        metadata:
+          labels:
+            +(team): web
+---
+apiVersion: kyverno.io/v1
+kind: Policy
+metadata:
+  name: require-labels
+  namespace: default
+spec:
+  validationFailureAction: enforce
+  rules:
+  - name: check-team
+    match:
+      resources:
+        kinds:
+        - Pod
+    validate:
+      message: The label team is required.
+      pattern:
+        metadata:
+          labels:
+            team: "?*"
+  - name: check-app
+    match:
+      resources:
+        kinds:
+        - Pod
+    validate:
+      message: The label app is required.
+      pattern:
+        metadata:
+          labels:
+            app: "?*"
+`
+
+func newPod(labels map[string]interface{}) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default", "labels": labels},
+		"spec":       map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "nginx", "image": "nginx"}}},
+	}}
+}
+
+func Test_LoadPolicies(t *testing.T) {
+	policies, err := LoadPolicies([]byte(testPolicies))
+	assert.NilError(t, err)
+	assert.Equal(t, len(policies), 2)
+	assert.Equal(t, policies[0].Name, "add-labels")
+	assert.Equal(t, policies[1].Namespace, "default")
+
+	_, err = LoadPolicies([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n"))
+	assert.ErrorContains(t, err, "is not a ClusterPolicy or a Policy")
+	_, err = LoadPolicies([]byte("apiVersion: kyverno.io/v1\nkind: Policy\nmetadata:\n  name: policy\n"))
+	assert.ErrorContains(t, err, "the namespace of the policy is required")
+}
+
+func Test_Evaluate(t *testing.T) {
+	policies, err := LoadPolicies([]byte(testPolicies))
+	assert.NilError(t, err)
+	engine := New(policies, Options{})
+
+	result, err := engine.Evaluate(Request{Resource: newPod(map[string]interface{}{"app": "web"})})
+	assert.NilError(t, err)
+	assert.Equal(t, result.Allowed(), true, result.Message())
+	assert.Equal(t, len(result.Patches), 1)
+	assert.DeepEqual(t, result.Resource.GetLabels(), map[string]string{"app": "web", "team": "web"})
+	assert.Equal(t, len(result.Policies), 2)
+	assert.Equal(t, result.Policies[0].Rules[0].Status, StatusPass)
+	assert.Equal(t, result.Policies[1].Namespace, "default")
+	assert.Equal(t, result.Policies[1].ValidationFailureAction, "enforce")
+	assert.Equal(t, len(result.Policies[1].Rules), 2)
+
+	result, err = engine.Evaluate(Request{Resource: newPod(map[string]interface{}{})})
+	assert.NilError(t, err)
+	assert.Equal(t, result.Allowed(), false)
+	assert.Equal(t, result.Policies[1].Rules[1].Status, StatusFail)
+	assert.Assert(t, strings.HasPrefix(result.Message(), "policy require-labels: rule check-app (Validation): "), result.Message())
+	assert.Assert(t, strings.Contains(result.Message(), "The label app is required."), result.Message())
+
+	_, err = engine.Evaluate(Request{})
+	assert.ErrorContains(t, err, "the resource is required")
+}
+
+func Test_NewContext(t *testing.T) {
+	ctx, err := NewContext(Request{
+		Resource:  newPod(map[string]interface{}{"app": "web"}),
+		Variables: map[string]interface{}{"request": map[string]interface{}{"operation": "CREATE"}},
+	})
+	assert.NilError(t, err)
+	name, err := ctx.Query("request.object.metadata.name")
+	assert.NilError(t, err)
+	assert.Equal(t, name, "web")
+	operation, err := ctx.Query("request.operation")
+	assert.NilError(t, err)
+	assert.Equal(t, operation, "CREATE")
+}
@@ -0,0 +1,71 @@
+package policyexception
+
+import (
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
+	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+//LookupInterface provides api to lookup the policy exceptions
+type LookupInterface interface {
+	// LookUp returns the exceptions that may exempt the resources of the namespace, empty for the cluster-wide resources
+	LookUp(namespace string) []kyverno.PolicyException
+}
+
+//Store looks up the policy exceptions in the informer cache.
+// The exceptions of a namespace apply to the resources of the namespace, the exceptions of the kyverno namespace
+// apply to the resources of all the namespaces and to the cluster-wide resources, so the users allowed to create
+// the exceptions of a namespace cannot exempt the resources of the other namespaces.
+type Store struct {
+	// list the policy exceptions
+	lister kyvernolister.PolicyExceptionLister
+	// returns true if the policy exception store has been synced at least once
+	synced cache.InformerSynced
+	// the namespace of kyverno, its exceptions apply to all the namespaces
+	kyvernoNamespace string
+	log              log.Logger
+}
+
+// NewStore returns a new policy exception store
+func NewStore(informer kyvernoinformer.PolicyExceptionInformer, kyvernoNamespace string) *Store {
+	return &Store{
+		lister:           informer.Lister(),
+		synced:           informer.Informer().HasSynced,
+		kyvernoNamespace: kyvernoNamespace,
+	}
+}
+
+//SetLogger sets the logger of the store, the logs are discarded by default
+func (s *Store) SetLogger(logger log.Logger) {
+	s.log = logger
+}
+
+//Run checks syncing
+func (s *Store) Run(stopCh <-chan struct{}) {
+	if !cache.WaitForCacheSync(stopCh, s.synced) {
+		s.log.Error("policy exception store: failed to sync informer cache")
+	}
+}
+
+//LookUp returns the exceptions of the namespace and of the kyverno namespace
+func (s *Store) LookUp(namespace string) []kyverno.PolicyException {
+	var exceptions []kyverno.PolicyException
+	add := func(namespace string) {
+		list, err := s.lister.PolicyExceptions(namespace).List(labels.Everything())
+		if err != nil {
+			s.log.Errorf("failed to list the policy exceptions of namespace %s: %v", namespace, err)
+			return
+		}
+		for _, exception := range list {
+			exceptions = append(exceptions, *exception)
+		}
+	}
+	add(s.kyvernoNamespace)
+	if namespace != "" && namespace != s.kyvernoNamespace {
+		add(namespace)
+	}
+	return exceptions
+}
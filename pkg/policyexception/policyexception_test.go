@@ -0,0 +1,37 @@
+package policyexception
+
+import (
+	"sort"
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/client/clientset/versioned/fake"
+	kyvernoinformers "github.com/nirmata/kyverno/pkg/client/informers/externalversions"
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_LookUp(t *testing.T) {
+	exception := func(namespace, name string) *kyverno.PolicyException {
+		return &kyverno.PolicyException{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+	client := fake.NewSimpleClientset(exception("kyverno", "cluster-wide"), exception("team-a", "legacy"), exception("team-b", "other"))
+	factory := kyvernoinformers.NewSharedInformerFactory(client, 0)
+	store := NewStore(factory.Kyverno().V1().PolicyExceptions(), "kyverno")
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	store.Run(stopCh)
+
+	names := func(namespace string) []string {
+		var names []string
+		for _, exception := range store.LookUp(namespace) {
+			names = append(names, exception.Namespace+"/"+exception.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	assert.DeepEqual(t, names("team-a"), []string{"kyverno/cluster-wide", "team-a/legacy"})
+	assert.DeepEqual(t, names(""), []string{"kyverno/cluster-wide"})
+	assert.DeepEqual(t, names("kyverno"), []string{"kyverno/cluster-wide"})
+}
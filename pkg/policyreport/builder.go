@@ -0,0 +1,217 @@
+package policyreport
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// clusterReportName is the name of the ClusterPolicyReport
+const clusterReportName = "clusterpolicyreport"
+
+// timestampRefresh is the minimum interval of the updates of the timestamps of the results reported again
+const timestampRefresh = policyviolation.CleanupInterval
+
+// reportKind returns the kind of the report of the namespace, the cluster-wide resources are in the ClusterPolicyReport
+func reportKind(namespace string) string {
+	if namespace == "" {
+		return clusterPolicyReportKind
+	}
+	return policyReportKind
+}
+
+// reportName returns the name of the report of the namespace
+func reportName(namespace string) string {
+	if namespace == "" {
+		return clusterReportName
+	}
+	return "policyreport-ns-" + namespace
+}
+
+// chunkName returns the name of a chunk of the report of the namespace, the first chunk is the report
+func chunkName(namespace string, index int) string {
+	if index == 0 {
+		return reportName(namespace)
+	}
+	return reportName(namespace) + "-" + strconv.Itoa(index)
+}
+
+// chunkIndex returns the index of the chunk of the report of the namespace, false if the name is not a chunk
+func chunkIndex(namespace, name string) (int, bool) {
+	if name == reportName(namespace) {
+		return 0, true
+	}
+	suffix := strings.TrimPrefix(name, reportName(namespace)+"-")
+	if suffix == name {
+		return 0, false
+	}
+	index, err := strconv.Atoi(suffix)
+	if err != nil || index <= 0 || strconv.Itoa(index) != suffix {
+		return 0, false
+	}
+	return index, true
+}
+
+// newReport returns an empty report of the namespace
+func newReport(namespace string) PolicyReport {
+	return newChunk(namespace, 0)
+}
+
+// newChunk returns an empty chunk of the report of the namespace
+func newChunk(namespace string, index int) PolicyReport {
+	report := PolicyReport{}
+	report.APIVersion = apiVersion
+	report.Kind = reportKind(namespace)
+	report.SetName(chunkName(namespace, index))
+	report.SetNamespace(namespace)
+	report.SetLabels(map[string]string{"app.kubernetes.io/managed-by": "kyverno"})
+	return report
+}
+
+// mergeResults replaces the results of the policy for the resource by the failed rules of the violation,
+// it returns false if the results did not change
+func mergeResults(report *PolicyReport, info policyviolation.Info) bool {
+	chunks, changed := mergeChunks([]PolicyReport{*report}, info, 0)
+	*report = chunks[0]
+	return len(changed) != 0
+}
+
+// mergeChunks replaces the results of the policy for the resource in the chunks of a report, sorted by index.
+// The results stay in the chunk of the resource if it has room for them, else they are moved to the first chunk
+// with less than maxResults results, or to a new chunk; maxResults 0 is unlimited.
+// It returns the chunks and the indexes of the changed chunks.
+func mergeChunks(chunks []PolicyReport, info policyviolation.Info, maxResults int) ([]PolicyReport, map[int]bool) {
+	resource := v1.ObjectReference{
+		APIVersion: info.Resource.GetAPIVersion(),
+		Kind:       info.Resource.GetKind(),
+		Namespace:  info.Resource.GetNamespace(),
+		Name:       info.Resource.GetName(),
+		UID:        info.Resource.GetUID(),
+	}
+	chunks = append([]PolicyReport{}, chunks...)
+	home := -1
+	var previous []PolicyReportResult
+	results := make([][]PolicyReportResult, len(chunks))
+	for i := range chunks {
+		results[i] = make([]PolicyReportResult, 0, len(chunks[i].Results))
+		for _, result := range chunks[i].Results {
+			if result.Policy == info.PolicyName && len(result.Resources) == 1 && sameResource(result.Resources[0], resource) {
+				if home < 0 {
+					home = i
+				}
+				previous = append(previous, result)
+				continue
+			}
+			results[i] = append(results[i], result)
+		}
+	}
+
+	reported := newResults(info, resource, previous, time.Now())
+	if len(reported) == len(previous) && (len(reported) == 0 || reflect.DeepEqual(reported, previous)) {
+		return chunks, nil
+	}
+	if len(reported) != 0 {
+		target := -1
+		fits := func(i int) bool { return maxResults <= 0 || len(results[i])+len(reported) <= maxResults }
+		if home >= 0 && fits(home) {
+			target = home
+		} else {
+			for i := range chunks {
+				if fits(i) {
+					target = i
+					break
+				}
+			}
+		}
+		if target < 0 {
+			chunks = append(chunks, newChunk(resource.Namespace, nextChunkIndex(chunks)))
+			results = append(results, nil)
+			target = len(chunks) - 1
+		}
+		results[target] = append(results[target], reported...)
+	}
+
+	changed := map[int]bool{}
+	for i := range chunks {
+		if len(results[i]) == len(chunks[i].Results) && (len(results[i]) == 0 || reflect.DeepEqual(results[i], chunks[i].Results)) {
+			continue
+		}
+		chunks[i].Results = results[i]
+		chunks[i].Summary = summarize(results[i])
+		changed[i] = true
+	}
+	return chunks, changed
+}
+
+// newResults returns the results of the failed rules of the violation. The timestamps of the results
+// reported again are refreshed every timestampRefresh, to not update the reports on every background scan.
+func newResults(info policyviolation.Info, resource v1.ObjectReference, previous []PolicyReportResult, now time.Time) []PolicyReportResult {
+	var results []PolicyReportResult
+	for _, rule := range info.Rules {
+		result := PolicyReportResult{
+			Policy:    info.PolicyName,
+			Rule:      rule.Name,
+			Message:   rule.Message,
+			Status:    StatusFail,
+			Scored:    true,
+			Resources: []v1.ObjectReference{resource},
+			Data:      map[string]string{"type": rule.Type},
+			Timestamp: metav1.NewTime(now),
+		}
+		for _, p := range previous {
+			if p.Rule != rule.Name || now.Sub(p.Timestamp.Time) >= timestampRefresh {
+				continue
+			}
+			seen := result
+			seen.Timestamp = p.Timestamp
+			if reflect.DeepEqual(seen, p) {
+				result = seen
+			}
+			break
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// nextChunkIndex returns the index of a new chunk of the report
+func nextChunkIndex(chunks []PolicyReport) int {
+	next := 0
+	for _, chunk := range chunks {
+		if index, ok := chunkIndex(chunk.Namespace, chunk.Name); ok && index >= next {
+			next = index + 1
+		}
+	}
+	return next
+}
+
+// sameResource compares the kind, the namespace and the name of the resources,
+// the UID is not set in the admission requests of the resources being created
+func sameResource(a, b v1.ObjectReference) bool {
+	return a.Kind == b.Kind && a.Namespace == b.Namespace && a.Name == b.Name
+}
+
+// summarize counts the results per status
+func summarize(results []PolicyReportResult) PolicyReportSummary {
+	var summary PolicyReportSummary
+	for _, result := range results {
+		switch result.Status {
+		case StatusPass:
+			summary.Pass++
+		case StatusFail:
+			summary.Fail++
+		case StatusWarn:
+			summary.Warn++
+		case StatusError:
+			summary.Error++
+		case StatusSkip:
+			summary.Skip++
+		}
+	}
+	return summary
+}
@@ -0,0 +1,137 @@
+package policyreport
+
+import (
+	"testing"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newResource(kind, namespace, name string) unstructured.Unstructured {
+	resource := unstructured.Unstructured{}
+	resource.SetAPIVersion("v1")
+	resource.SetKind(kind)
+	resource.SetNamespace(namespace)
+	resource.SetName(name)
+	return resource
+}
+
+func Test_mergeResults(t *testing.T) {
+	report := newReport("test")
+	assert.Equal(t, report.Kind, "PolicyReport")
+	assert.Equal(t, report.Name, "policyreport-ns-test")
+
+	pod := newResource("Pod", "test", "nginx")
+	mergeResults(&report, policyviolation.Info{
+		PolicyName: "require-labels",
+		Resource:   pod,
+		Rules: []kyverno.ViolatedRule{
+			{Name: "check-app", Type: "Validation", Message: "label app is required"},
+			{Name: "check-team", Type: "Validation", Message: "label team is required"},
+		},
+	})
+	mergeResults(&report, policyviolation.Info{
+		PolicyName: "require-labels",
+		Resource:   newResource("Pod", "test", "redis"),
+		Rules:      []kyverno.ViolatedRule{{Name: "check-app", Type: "Validation", Message: "label app is required"}},
+	})
+	assert.Equal(t, len(report.Results), 3)
+	assert.DeepEqual(t, report.Summary, PolicyReportSummary{Fail: 3})
+
+	// the new violation of the policy replaces the results of the resource
+	mergeResults(&report, policyviolation.Info{
+		PolicyName: "require-labels",
+		Resource:   pod,
+		Rules:      []kyverno.ViolatedRule{{Name: "check-team", Type: "Validation", Message: "label team is required"}},
+	})
+	assert.Equal(t, len(report.Results), 2)
+	assert.DeepEqual(t, report.Summary, PolicyReportSummary{Fail: 2})
+	result := report.Results[1]
+	assert.Equal(t, result.Policy, "require-labels")
+	assert.Equal(t, result.Rule, "check-team")
+	assert.Equal(t, result.Status, StatusFail)
+	assert.Equal(t, result.Resources[0].Name, "nginx")
+	assert.Equal(t, result.Data["type"], "Validation")
+
+	// the compliant resource has no results
+	assert.Assert(t, mergeResults(&report, policyviolation.Info{PolicyName: "require-labels", Resource: pod}))
+	assert.Equal(t, len(report.Results), 1)
+	assert.Assert(t, !mergeResults(&report, policyviolation.Info{PolicyName: "require-labels", Resource: pod}))
+}
+
+func Test_newReport_Cluster(t *testing.T) {
+	report := newReport("")
+	assert.Equal(t, report.Kind, "ClusterPolicyReport")
+	assert.Equal(t, report.Name, "clusterpolicyreport")
+	assert.Equal(t, report.APIVersion, "wgpolicyk8s.io/v1alpha1")
+}
+
+func Test_chunkIndex(t *testing.T) {
+	assert.Equal(t, chunkName("test", 0), "policyreport-ns-test")
+	assert.Equal(t, chunkName("test", 2), "policyreport-ns-test-2")
+	assert.Equal(t, chunkName("", 1), "clusterpolicyreport-1")
+	for name, index := range map[string]int{"policyreport-ns-test": 0, "policyreport-ns-test-2": 2, "policyreport-ns-test-12": 12} {
+		i, ok := chunkIndex("test", name)
+		assert.Assert(t, ok, name)
+		assert.Equal(t, i, index)
+	}
+	for _, name := range []string{"policyreport-ns-test-a", "policyreport-ns-test-0", "policyreport-ns-test-01", "policyreport-ns-tests", "cis-benchmark"} {
+		_, ok := chunkIndex("test", name)
+		assert.Assert(t, !ok, name)
+	}
+}
+
+func Test_mergeChunks(t *testing.T) {
+	rule := kyverno.ViolatedRule{Name: "check-app", Type: "Validation", Message: "label app is required"}
+	chunks := []PolicyReport{newReport("test")}
+	var changed map[int]bool
+	for _, name := range []string{"nginx", "redis", "mysql"} {
+		chunks, changed = mergeChunks(chunks, policyviolation.Info{PolicyName: "require-labels", Resource: newResource("Pod", "test", name), Rules: []kyverno.ViolatedRule{rule}}, 2)
+	}
+	// the third result is added to a new chunk
+	assert.DeepEqual(t, changed, map[int]bool{1: true})
+	assert.Equal(t, len(chunks), 2)
+	assert.Equal(t, chunks[1].Name, "policyreport-ns-test-1")
+	assert.Equal(t, chunks[1].Results[0].Resources[0].Name, "mysql")
+	assert.DeepEqual(t, chunks[1].Summary, PolicyReportSummary{Fail: 1})
+
+	// the results reported again do not change
+	_, changed = mergeChunks(chunks, policyviolation.Info{PolicyName: "require-labels", Resource: newResource("Pod", "test", "nginx"), Rules: []kyverno.ViolatedRule{rule}}, 2)
+	assert.Equal(t, len(changed), 0)
+
+	// the results of the resource stay in their chunk if it has room, else they move to the first chunk with room
+	chunks, changed = mergeChunks(chunks, policyviolation.Info{PolicyName: "require-labels", Resource: newResource("Pod", "test", "nginx"),
+		Rules: []kyverno.ViolatedRule{rule, {Name: "check-team", Type: "Validation", Message: "label team is required"}}}, 2)
+	assert.DeepEqual(t, changed, map[int]bool{0: true, 2: true})
+	assert.Equal(t, len(chunks[0].Results), 1)
+	assert.Equal(t, chunks[2].Name, "policyreport-ns-test-2")
+	assert.Equal(t, len(chunks[2].Results), 2)
+
+	// the compliant resource is removed from its chunk
+	chunks, changed = mergeChunks(chunks, policyviolation.Info{PolicyName: "require-labels", Resource: newResource("Pod", "test", "redis")}, 2)
+	assert.DeepEqual(t, changed, map[int]bool{0: true})
+	assert.Equal(t, len(chunks[0].Results), 0)
+}
+
+func Test_newResults_Timestamp(t *testing.T) {
+	now := time.Now()
+	info := policyviolation.Info{PolicyName: "require-labels", Resource: newResource("Pod", "test", "nginx"),
+		Rules: []kyverno.ViolatedRule{{Name: "check-app", Type: "Validation", Message: "label app is required"}}}
+	resource := v1.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "test", Name: "nginx"}
+	previous := newResults(info, resource, nil, now.Add(-time.Minute))
+	assert.Equal(t, previous[0].Timestamp.Time, now.Add(-time.Minute))
+
+	// the timestamp is kept until the refresh
+	assert.DeepEqual(t, newResults(info, resource, previous, now), previous)
+	results := newResults(info, resource, previous, now.Add(timestampRefresh))
+	assert.Equal(t, results[0].Timestamp.Time, now.Add(timestampRefresh))
+
+	// the changed results are updated
+	info.Rules[0].Message = "label app must be set"
+	results = newResults(info, resource, previous, now)
+	assert.Equal(t, results[0].Timestamp.Time, now)
+}
@@ -0,0 +1,197 @@
+package policyreport
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
+	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+//CleanupController removes the stale results of the policy reports, the results of the deleted resources,
+// policies and rules, and the results above the retention limits. The empty chunks of the reports are deleted.
+// The results of the compliant resources are removed by the background scans of the policy controller.
+type CleanupController struct {
+	dclient   *dclient.Client
+	retention policyviolation.Retention
+	pLister   kyvernolister.ClusterPolicyLister
+	pSynced   cache.InformerSynced
+}
+
+//NewCleanupController returns a new instance of the policy report cleanup controller
+func NewCleanupController(dclient *dclient.Client, pInformer kyvernoinformer.ClusterPolicyInformer, retention policyviolation.Retention) *CleanupController {
+	return &CleanupController{
+		dclient:   dclient,
+		retention: retention,
+		pLister:   pInformer.Lister(),
+		pSynced:   pInformer.Informer().HasSynced,
+	}
+}
+
+// Run scans the policy reports every policyviolation.CleanupInterval
+func (c *CleanupController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	glog.Info("Starting policy report cleanup controller")
+	defer glog.Info("Shutting down policy report cleanup controller")
+
+	if !cache.WaitForCacheSync(stopCh, c.pSynced) {
+		glog.Error("policy report cleanup controller: failed to sync informer cache")
+		return
+	}
+	wait.Until(c.cleanup, policyviolation.CleanupInterval, stopCh)
+}
+
+func (c *CleanupController) cleanup() {
+	for _, kind := range []string{policyReportKind, clusterPolicyReportKind} {
+		list, err := c.dclient.ListResource(kind, "", nil)
+		if err != nil {
+			glog.Errorf("failed to list %s: %v", kind, err)
+			continue
+		}
+		// the chunks of the reports per namespace
+		namespaces := map[string][]PolicyReport{}
+		for _, obj := range list.Items {
+			if _, ok := chunkIndex(obj.GetNamespace(), obj.GetName()); !ok {
+				continue
+			}
+			report := PolicyReport{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &report); err != nil {
+				glog.Errorf("failed to convert %s %s/%s: %v", kind, obj.GetNamespace(), obj.GetName(), err)
+				continue
+			}
+			namespaces[report.Namespace] = append(namespaces[report.Namespace], report)
+		}
+		for namespace, chunks := range namespaces {
+			changed := map[int]bool{}
+			for i := range chunks {
+				if removeStaleResults(&chunks[i], c.getPolicy, c.resourceExists) {
+					glog.V(4).Infof("cleanup stale results of %s %s/%s", kind, namespace, chunks[i].Name)
+					changed[i] = true
+				}
+			}
+			for i := range applyRetention(chunks, c.retention, time.Now()) {
+				glog.V(4).Infof("cleanup the results of %s %s/%s above the retention", kind, namespace, chunks[i].Name)
+				changed[i] = true
+			}
+			// the cleanup writes are not limited, they run once per cleanup interval
+			if err := saveChunks(c.dclient, chunks, changed, nil); err != nil {
+				glog.Errorf("failed to update %s of namespace %q: %v", kind, namespace, err)
+			}
+		}
+	}
+}
+
+func (c *CleanupController) getPolicy(name string) (*kyverno.ClusterPolicy, error) {
+	return c.pLister.Get(name)
+}
+
+func (c *CleanupController) resourceExists(kind, namespace, name string) (bool, error) {
+	if _, err := c.dclient.GetResource(kind, namespace, name); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// removeStaleResults removes the results of the deleted policies, rules and resources,
+// it returns false if the results did not change. The results are kept if the lookups fail.
+func removeStaleResults(report *PolicyReport,
+	getPolicy func(name string) (*kyverno.ClusterPolicy, error),
+	resourceExists func(kind, namespace, name string) (bool, error)) bool {
+	policies := map[string]*kyverno.ClusterPolicy{}
+	resources := map[string]bool{}
+	var results []PolicyReportResult
+	for _, result := range report.Results {
+		policy, ok := policies[result.Policy]
+		if !ok {
+			p, err := getPolicy(result.Policy)
+			if err != nil && !apierrors.IsNotFound(err) {
+				glog.V(4).Infof("failed to get policy %s: %v", result.Policy, err)
+				results = append(results, result)
+				continue
+			}
+			if err == nil {
+				policy = p
+			}
+			policies[result.Policy] = policy
+		}
+		if policy == nil || len(policyviolation.FilterRules(policy, []kyverno.ViolatedRule{{Name: result.Rule}})) == 0 {
+			continue
+		}
+		stale := false
+		for _, resource := range result.Resources {
+			key := resource.Kind + "/" + resource.Namespace + "/" + resource.Name
+			exists, ok := resources[key]
+			if !ok {
+				var err error
+				if exists, err = resourceExists(resource.Kind, resource.Namespace, resource.Name); err != nil {
+					glog.V(4).Infof("failed to get resource %s: %v", key, err)
+					exists = true
+				}
+				resources[key] = exists
+			}
+			if !exists {
+				stale = true
+			}
+		}
+		if stale {
+			continue
+		}
+		results = append(results, result)
+	}
+	if len(results) == len(report.Results) {
+		return false
+	}
+	report.Results = results
+	report.Summary = summarize(results)
+	return true
+}
+
+// applyRetention removes the results of the chunks of a report above the retention limits,
+// it returns the indexes of the changed chunks
+func applyRetention(chunks []PolicyReport, retention policyviolation.Retention, now time.Time) map[int]bool {
+	changed := map[int]bool{}
+	if !retention.Enabled() {
+		return changed
+	}
+	type position struct{ chunk, result int }
+	var positions []position
+	var lastSeen []time.Time
+	for i, chunk := range chunks {
+		for j, result := range chunk.Results {
+			positions = append(positions, position{i, j})
+			// the results reported before the timestamps are kept until they are reported again
+			timestamp := result.Timestamp.Time
+			if timestamp.IsZero() {
+				timestamp = now
+			}
+			lastSeen = append(lastSeen, timestamp)
+		}
+	}
+	expired := map[position]bool{}
+	for _, i := range retention.Expired(lastSeen, now) {
+		expired[positions[i]] = true
+		changed[positions[i].chunk] = true
+	}
+	for i := range changed {
+		var results []PolicyReportResult
+		for j, result := range chunks[i].Results {
+			if !expired[position{i, j}] {
+				results = append(results, result)
+			}
+		}
+		chunks[i].Results = results
+		chunks[i].Summary = summarize(results)
+	}
+	return changed
+}
@@ -0,0 +1,69 @@
+package policyreport
+
+import (
+	"testing"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"gotest.tools/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_removeStaleResults(t *testing.T) {
+	report := newReport("test")
+	for _, info := range []policyviolation.Info{
+		{PolicyName: "require-labels", Resource: newResource("Pod", "test", "nginx"), Rules: []kyverno.ViolatedRule{{Name: "check-app"}, {Name: "check-team"}}},
+		{PolicyName: "require-labels", Resource: newResource("Pod", "test", "deleted"), Rules: []kyverno.ViolatedRule{{Name: "check-app"}}},
+		{PolicyName: "deleted-policy", Resource: newResource("Pod", "test", "nginx"), Rules: []kyverno.ViolatedRule{{Name: "check-app"}}},
+	} {
+		mergeResults(&report, info)
+	}
+	assert.Equal(t, report.Summary.Fail, 4)
+
+	getPolicy := func(name string) (*kyverno.ClusterPolicy, error) {
+		if name != "require-labels" {
+			return nil, apierrors.NewNotFound(schema.GroupResource{Group: "kyverno.io", Resource: "clusterpolicies"}, name)
+		}
+		// the rule check-team is deleted
+		return &kyverno.ClusterPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       kyverno.Spec{Rules: []kyverno.Rule{{Name: "check-app"}}},
+		}, nil
+	}
+	resourceExists := func(kind, namespace, name string) (bool, error) {
+		return name != "deleted", nil
+	}
+	assert.Assert(t, removeStaleResults(&report, getPolicy, resourceExists))
+	assert.Equal(t, len(report.Results), 1)
+	assert.Equal(t, report.Results[0].Rule, "check-app")
+	assert.Equal(t, report.Results[0].Resources[0].Name, "nginx")
+	assert.DeepEqual(t, report.Summary, PolicyReportSummary{Fail: 1})
+
+	assert.Assert(t, !removeStaleResults(&report, getPolicy, resourceExists))
+}
+
+func Test_applyRetention(t *testing.T) {
+	now := time.Now()
+	chunks := []PolicyReport{newReport("test"), newChunk("test", 1)}
+	chunks[0].Results = []PolicyReportResult{
+		{Policy: "require-labels", Rule: "check-app", Status: StatusFail, Timestamp: metav1.NewTime(now.Add(-48 * time.Hour))},
+		{Policy: "require-labels", Rule: "check-team", Status: StatusFail, Timestamp: metav1.NewTime(now.Add(-time.Hour))},
+	}
+	chunks[1].Results = []PolicyReportResult{
+		{Policy: "disallow-latest-tag", Rule: "validate-image-tag", Status: StatusFail, Timestamp: metav1.NewTime(now.Add(-2 * time.Hour))},
+		// reported before the timestamps
+		{Policy: "disallow-latest-tag", Rule: "require-image-tag", Status: StatusFail},
+	}
+	assert.Equal(t, len(applyRetention(chunks, policyviolation.Retention{}, now)), 0)
+
+	changed := applyRetention(chunks, policyviolation.Retention{MaxAge: 24 * time.Hour, MaxPerNamespace: 2}, now)
+	assert.DeepEqual(t, changed, map[int]bool{0: true, 1: true})
+	assert.Equal(t, len(chunks[0].Results), 1)
+	assert.Equal(t, chunks[0].Results[0].Rule, "check-team")
+	assert.DeepEqual(t, chunks[0].Summary, PolicyReportSummary{Fail: 1})
+	assert.Equal(t, len(chunks[1].Results), 1)
+	assert.Equal(t, chunks[1].Results[0].Rule, "require-image-tag")
+}
@@ -0,0 +1,279 @@
+package policyreport
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"github.com/nirmata/kyverno/pkg/ratelimit"
+	"github.com/nirmata/kyverno/pkg/utils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const workQueueName = "policy-report-controller"
+const workQueueRetryLimit = 3
+
+// Generator aggregates the policy violations in the PolicyReports of the namespaces and the ClusterPolicyReport,
+// the reports are split in chunks of maxResults results to stay below the size limit of the objects.
+// The violations are queued per namespace, the pending violations of a namespace are merged in a single write.
+type Generator struct {
+	dclient    *dclient.Client
+	maxResults int
+	limiter    *ratelimit.Limiter
+	queue      workqueue.RateLimitingInterface
+	dataStore  *dataStore
+	workers    utils.Workers
+}
+
+// dataStore holds the pending violations per namespace, the latest violation of a policy for a resource replaces the pending one
+type dataStore struct {
+	data map[string]map[string]policyviolation.Info
+	mu   sync.Mutex
+}
+
+func (ds *dataStore) add(namespace string, info policyviolation.Info) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.data[namespace] == nil {
+		ds.data[namespace] = map[string]policyviolation.Info{}
+	}
+	ds.data[namespace][infoKey(info)] = info
+}
+
+// restore adds back the violations of a failed write, unless newer violations are pending
+func (ds *dataStore) restore(namespace string, infos []policyviolation.Info) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if ds.data[namespace] == nil {
+		ds.data[namespace] = map[string]policyviolation.Info{}
+	}
+	for _, info := range infos {
+		if _, ok := ds.data[namespace][infoKey(info)]; !ok {
+			ds.data[namespace][infoKey(info)] = info
+		}
+	}
+}
+
+// take removes and returns the pending violations of the namespace, sorted by key
+func (ds *dataStore) take(namespace string) []policyviolation.Info {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	pending := ds.data[namespace]
+	delete(ds.data, namespace)
+	keys := make([]string, 0, len(pending))
+	for key := range pending {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	infos := make([]policyviolation.Info, 0, len(keys))
+	for _, key := range keys {
+		infos = append(infos, pending[key])
+	}
+	return infos
+}
+
+func (ds *dataStore) delete(namespace string) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	delete(ds.data, namespace)
+}
+
+// NewGenerator returns a new instance of policy report generator, maxResults is the maximum count of results of a report, 0 is unlimited.
+// The writes of the reports are limited by limiter.
+func NewGenerator(dclient *dclient.Client, maxResults int, limiter *ratelimit.Limiter) *Generator {
+	gen := Generator{
+		dclient:    dclient,
+		maxResults: maxResults,
+		limiter:    limiter,
+		queue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), workQueueName),
+		dataStore:  &dataStore{data: map[string]map[string]policyviolation.Info{}},
+	}
+	return &gen
+}
+
+// Add queues the policy violations to be reported
+func (gen *Generator) Add(infos ...policyviolation.Info) {
+	for _, info := range infos {
+		namespace := info.Resource.GetNamespace()
+		gen.dataStore.add(namespace, info)
+		gen.queue.Add(namespace)
+		glog.V(3).Infof("Added policy report result: %s", infoKey(info))
+	}
+}
+
+// infoKey identifies the policy and the resource, the latest violation replaces the queued one
+func infoKey(info policyviolation.Info) string {
+	return info.PolicyName + "/" + info.Resource.GetKind() + "/" + info.Resource.GetNamespace() + "/" + info.Resource.GetName()
+}
+
+// Run starts the workers
+func (gen *Generator) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	glog.Info("Start policy report generator")
+	defer glog.Info("Shutting down policy report generator")
+
+	for i := 0; i < workers; i++ {
+		gen.workers.Go(gen.runWorker, stopCh)
+	}
+	<-stopCh
+}
+
+// Drain stops queueing the policy violations and writes the reports of the queued ones,
+// it returns false if they are not written before the timeout
+func (gen *Generator) Drain(timeout time.Duration) bool {
+	gen.queue.ShutDown()
+	return gen.workers.Wait(timeout)
+}
+
+func (gen *Generator) runWorker() {
+	for gen.processNextWorkitem() {
+	}
+}
+
+func (gen *Generator) handleErr(err error, key interface{}) {
+	if err == nil {
+		gen.queue.Forget(key)
+		return
+	}
+
+	if gen.queue.NumRequeues(key) < workQueueRetryLimit {
+		glog.V(4).Infof("Error syncing policy report %v: %v", key, err)
+		gen.queue.AddRateLimited(key)
+		return
+	}
+	gen.queue.Forget(key)
+	glog.Error(err)
+	if namespace, ok := key.(string); ok {
+		gen.dataStore.delete(namespace)
+	}
+	glog.Warningf("Dropping the key out of the queue: %v", err)
+}
+
+func (gen *Generator) processNextWorkitem() bool {
+	obj, shutdown := gen.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer gen.queue.Done(obj)
+
+	namespace, ok := obj.(string)
+	if !ok {
+		gen.queue.Forget(obj)
+		glog.Warningf("Expecting type string but got %v\n", obj)
+		return true
+	}
+	infos := gen.dataStore.take(namespace)
+	if len(infos) == 0 {
+		// the violations were written with a previous batch
+		gen.queue.Forget(obj)
+		return true
+	}
+	err := gen.syncHandler(namespace, infos)
+	if err != nil {
+		gen.dataStore.restore(namespace, infos)
+	}
+	gen.handleErr(err, obj)
+	return true
+}
+
+// syncHandler merges the pending violations of the namespace in the chunks of its report and saves the changed chunks,
+// the report is created if it does not exist
+func (gen *Generator) syncHandler(namespace string, infos []policyviolation.Info) error {
+	chunks, err := listChunks(gen.dclient, namespace)
+	if err != nil {
+		return err
+	}
+	if len(chunks) == 0 {
+		if !hasViolations(infos) {
+			// compliant resources, nothing to report
+			return nil
+		}
+		chunks = []PolicyReport{newReport(namespace)}
+	}
+	changed := map[int]bool{}
+	for _, info := range infos {
+		var merged map[int]bool
+		chunks, merged = mergeChunks(chunks, info, gen.maxResults)
+		for i := range merged {
+			changed[i] = true
+		}
+	}
+	glog.V(4).Infof("Merged %d policy report results of namespace %q", len(infos), namespace)
+	return saveChunks(gen.dclient, chunks, changed, gen.limiter)
+}
+
+func hasViolations(infos []policyviolation.Info) bool {
+	for _, info := range infos {
+		if len(info.Rules) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// listChunks returns the chunks of the report of the namespace, sorted by index
+func listChunks(client *dclient.Client, namespace string) ([]PolicyReport, error) {
+	kind := reportKind(namespace)
+	list, err := client.ListResource(kind, namespace, nil)
+	if err != nil {
+		return nil, err
+	}
+	var chunks []PolicyReport
+	for _, obj := range list.Items {
+		if _, ok := chunkIndex(namespace, obj.GetName()); !ok {
+			continue
+		}
+		report := PolicyReport{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &report); err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, report)
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		a, _ := chunkIndex(namespace, chunks[i].Name)
+		b, _ := chunkIndex(namespace, chunks[j].Name)
+		return a < b
+	})
+	return chunks, nil
+}
+
+// saveChunks creates the new chunks and updates the changed chunks, the empty chunks are deleted but the first one.
+// Every write waits for the limiter.
+func saveChunks(client *dclient.Client, chunks []PolicyReport, changed map[int]bool, limiter *ratelimit.Limiter) error {
+	for i, chunk := range chunks {
+		if !changed[i] {
+			continue
+		}
+		limiter.Wait()
+		kind := reportKind(chunk.Namespace)
+		switch {
+		case chunk.ResourceVersion == "":
+			if _, err := client.CreateResource(kind, chunk.Namespace, chunk, false); err != nil {
+				return err
+			}
+			glog.V(3).Infof("Created %s %s/%s", kind, chunk.Namespace, chunk.Name)
+		case len(chunk.Results) == 0 && chunk.Name != reportName(chunk.Namespace):
+			if err := client.DeleteResource(kind, chunk.Namespace, chunk.Name, false); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			glog.V(3).Infof("Deleted empty %s %s/%s", kind, chunk.Namespace, chunk.Name)
+		default:
+			if _, err := client.UpdateResource(kind, chunk.Namespace, chunk, false); err != nil {
+				return err
+			}
+			glog.V(3).Infof("Updated %s %s/%s", kind, chunk.Namespace, chunk.Name)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of the namespaces with queued policy report results
+func (gen *Generator) Len() int {
+	return gen.queue.Len()
+}
@@ -0,0 +1,44 @@
+package policyreport
+
+import (
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"gotest.tools/assert"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func Test_Generator_Add_BatchesNamespace(t *testing.T) {
+	gen := &Generator{
+		queue:     workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), workQueueName),
+		dataStore: &dataStore{data: map[string]map[string]policyviolation.Info{}},
+	}
+	defer gen.queue.ShutDown()
+
+	rule := kyverno.ViolatedRule{Name: "check-app", Type: "Validation", Message: "label app is required"}
+	gen.Add(policyviolation.Info{PolicyName: "require-labels", Resource: newResource("Pod", "test", "nginx"), Rules: []kyverno.ViolatedRule{rule}})
+	gen.Add(policyviolation.Info{PolicyName: "require-labels", Resource: newResource("Pod", "test", "redis"), Rules: []kyverno.ViolatedRule{rule}})
+	// the latest violation of the resource replaces the pending one
+	gen.Add(policyviolation.Info{PolicyName: "require-labels", Resource: newResource("Pod", "test", "nginx")})
+	gen.Add(policyviolation.Info{PolicyName: "require-labels", Resource: newResource("Namespace", "", "test"), Rules: []kyverno.ViolatedRule{rule}})
+	assert.Equal(t, gen.Len(), 2)
+
+	infos := gen.dataStore.take("test")
+	assert.Equal(t, len(infos), 2)
+	assert.Equal(t, infos[0].Resource.GetName(), "nginx")
+	assert.Equal(t, len(infos[0].Rules), 0)
+	assert.Equal(t, infos[1].Resource.GetName(), "redis")
+	assert.Equal(t, len(gen.dataStore.take("test")), 0)
+
+	// a failed write is restored, unless a newer violation is pending
+	gen.Add(policyviolation.Info{PolicyName: "require-labels", Resource: newResource("Pod", "test", "redis")})
+	gen.dataStore.restore("test", infos)
+	restored := gen.dataStore.take("test")
+	assert.Equal(t, len(restored), 2)
+	assert.Equal(t, len(restored[0].Rules), 0)
+	assert.Equal(t, restored[1].Resource.GetName(), "redis")
+	assert.Equal(t, len(restored[1].Rules), 0)
+
+	assert.Assert(t, hasViolations(gen.dataStore.take("")))
+}
@@ -0,0 +1,170 @@
+package policyreport
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"github.com/nirmata/kyverno/pkg/ratelimit"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ClusterLabel is the label of the ClusterPolicyReports of the remote clusters, set to the name of the cluster
+const ClusterLabel = "kyverno.io/cluster"
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// clusterName returns the name of the cluster usable in the names and the labels of the reports,
+// e.g. arn:aws:eks:us-east-1:1234:cluster/prod becomes arn-aws-eks-us-east-1-1234-cluster-prod
+func clusterName(cluster string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(cluster), "-")
+	if len(name) > 63 {
+		name = name[len(name)-63:]
+	}
+	return strings.Trim(name, "-")
+}
+
+// remoteReportName returns the name of a chunk of the ClusterPolicyReport of the remote cluster, the first chunk is the report
+func remoteReportName(cluster string, index int) string {
+	name := clusterReportName + "-cluster-" + clusterName(cluster)
+	if index == 0 {
+		return name
+	}
+	return name + "-" + strconv.Itoa(index)
+}
+
+// newRemoteChunk returns an empty chunk of the ClusterPolicyReport of the remote cluster
+func newRemoteChunk(cluster string, index int) PolicyReport {
+	report := newChunk("", 0)
+	report.SetName(remoteReportName(cluster, index))
+	report.Labels[ClusterLabel] = clusterName(cluster)
+	return report
+}
+
+// SaveClusterReport replaces the results of the ClusterPolicyReport of the remote cluster, in the management cluster,
+// by the violations of the last scan of the cluster. The report is split in chunks of maxResults results, 0 is unlimited,
+// and is kept without results when the cluster is compliant. Every write waits for the limiter.
+func SaveClusterReport(client *dclient.Client, cluster string, infos []policyviolation.Info, maxResults int, limiter *ratelimit.Limiter) error {
+	existing, err := listRemoteChunks(client, cluster)
+	if err != nil {
+		return err
+	}
+	chunks := buildRemoteChunks(cluster, existing, infos, maxResults, time.Now())
+
+	for i, chunk := range chunks {
+		previous, ok := existing[chunk.Name]
+		switch {
+		case !ok:
+			limiter.Wait()
+			if _, err := client.CreateResource(clusterPolicyReportKind, "", chunk, false); err != nil {
+				return err
+			}
+			glog.V(3).Infof("Created %s %s of cluster %s", clusterPolicyReportKind, chunk.Name, cluster)
+		case !reflect.DeepEqual(previous.Results, chunk.Results) || i == 0 && !reflect.DeepEqual(previous.Labels, chunk.Labels):
+			limiter.Wait()
+			chunk.ResourceVersion = previous.ResourceVersion
+			if _, err := client.UpdateResource(clusterPolicyReportKind, "", chunk, false); err != nil {
+				return err
+			}
+			glog.V(3).Infof("Updated %s %s of cluster %s", clusterPolicyReportKind, chunk.Name, cluster)
+		}
+		delete(existing, chunk.Name)
+	}
+	// the chunks not needed anymore
+	for name := range existing {
+		limiter.Wait()
+		if err := client.DeleteResource(clusterPolicyReportKind, "", name, false); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		glog.V(3).Infof("Deleted %s %s of cluster %s", clusterPolicyReportKind, name, cluster)
+	}
+	return nil
+}
+
+// listRemoteChunks returns the chunks of the ClusterPolicyReport of the remote cluster by name
+func listRemoteChunks(client *dclient.Client, cluster string) (map[string]PolicyReport, error) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{ClusterLabel: clusterName(cluster)}}
+	list, err := client.ListResource(clusterPolicyReportKind, "", selector)
+	if err != nil {
+		return nil, err
+	}
+	chunks := map[string]PolicyReport{}
+	for _, obj := range list.Items {
+		report := PolicyReport{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &report); err != nil {
+			return nil, err
+		}
+		chunks[report.Name] = report
+	}
+	return chunks, nil
+}
+
+// buildRemoteChunks returns the chunks of the report of the violations, the timestamps of the results
+// reported in the existing chunks are kept until they are refreshed
+func buildRemoteChunks(cluster string, existing map[string]PolicyReport, infos []policyviolation.Info, maxResults int, now time.Time) []PolicyReport {
+	// the previous results of the policies per resource
+	previous := map[string][]PolicyReportResult{}
+	for _, chunk := range existing {
+		for _, result := range chunk.Results {
+			if len(result.Resources) == 1 {
+				key := resultKey(result.Policy, result.Resources[0])
+				previous[key] = append(previous[key], result)
+			}
+		}
+	}
+	var results []PolicyReportResult
+	for _, info := range infos {
+		resource := v1.ObjectReference{
+			APIVersion: info.Resource.GetAPIVersion(),
+			Kind:       info.Resource.GetKind(),
+			Namespace:  info.Resource.GetNamespace(),
+			Name:       info.Resource.GetName(),
+			UID:        info.Resource.GetUID(),
+		}
+		results = append(results, newResults(info, resource, previous[resultKey(info.PolicyName, resource)], now)...)
+	}
+	// stable order of the results, the chunks do not change if the results do not change
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].Resources[0], results[j].Resources[0]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if results[i].Policy != results[j].Policy {
+			return results[i].Policy < results[j].Policy
+		}
+		return results[i].Rule < results[j].Rule
+	})
+
+	chunks := []PolicyReport{newRemoteChunk(cluster, 0)}
+	for _, result := range results {
+		last := &chunks[len(chunks)-1]
+		if maxResults > 0 && len(last.Results) >= maxResults {
+			chunks = append(chunks, newRemoteChunk(cluster, len(chunks)))
+			last = &chunks[len(chunks)-1]
+		}
+		last.Results = append(last.Results, result)
+	}
+	for i := range chunks {
+		chunks[i].Summary = summarize(chunks[i].Results)
+	}
+	return chunks
+}
+
+func resultKey(policy string, resource v1.ObjectReference) string {
+	return strings.Join([]string{policy, resource.Kind, resource.Namespace, resource.Name}, "/")
+}
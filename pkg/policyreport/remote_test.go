@@ -0,0 +1,64 @@
+package policyreport
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"gotest.tools/assert"
+)
+
+func Test_clusterName(t *testing.T) {
+	assert.Equal(t, clusterName("prod"), "prod")
+	assert.Equal(t, clusterName("arn:aws:eks:us-east-1:1234:cluster/Prod"), "arn-aws-eks-us-east-1-1234-cluster-prod")
+	assert.Equal(t, clusterName("admin@kind-dev"), "admin-kind-dev")
+	assert.Assert(t, len(clusterName("gke_"+strings.Repeat("project", 20)+"_prod")) <= 63)
+	assert.Equal(t, remoteReportName("prod", 0), "clusterpolicyreport-cluster-prod")
+	assert.Equal(t, remoteReportName("prod", 2), "clusterpolicyreport-cluster-prod-2")
+
+	// the reports of the remote clusters are not chunks of the ClusterPolicyReport of the management cluster
+	_, ok := chunkIndex("", remoteReportName("prod", 0))
+	assert.Assert(t, !ok)
+	_, ok = chunkIndex("", remoteReportName("prod", 1))
+	assert.Assert(t, !ok)
+}
+
+func Test_buildRemoteChunks(t *testing.T) {
+	now := time.Now()
+	violation := func(name string) policyviolation.Info {
+		return policyviolation.Info{
+			PolicyName: "require-labels",
+			Resource:   newResource("Pod", "test", name),
+			Rules:      []kyverno.ViolatedRule{{Name: "check-app", Type: "Validation", Message: "label app is required"}},
+		}
+	}
+	infos := []policyviolation.Info{violation("redis"), violation("nginx"), violation("mysql")}
+
+	chunks := buildRemoteChunks("prod", nil, infos, 2, now)
+	assert.Equal(t, len(chunks), 2)
+	assert.Equal(t, chunks[0].Name, "clusterpolicyreport-cluster-prod")
+	assert.Equal(t, chunks[0].Kind, "ClusterPolicyReport")
+	assert.Equal(t, chunks[0].Labels[ClusterLabel], "prod")
+	assert.Equal(t, chunks[1].Name, "clusterpolicyreport-cluster-prod-1")
+	assert.Equal(t, chunks[1].Labels[ClusterLabel], "prod")
+	// sorted by resource
+	assert.Equal(t, chunks[0].Results[0].Resources[0].Name, "mysql")
+	assert.Equal(t, chunks[0].Results[1].Resources[0].Name, "nginx")
+	assert.Equal(t, chunks[1].Results[0].Resources[0].Name, "redis")
+	assert.DeepEqual(t, chunks[0].Summary, PolicyReportSummary{Fail: 2})
+	assert.DeepEqual(t, chunks[1].Summary, PolicyReportSummary{Fail: 1})
+
+	// the timestamps of the results reported again are kept, the chunks do not change
+	existing := map[string]PolicyReport{chunks[0].Name: chunks[0], chunks[1].Name: chunks[1]}
+	again := buildRemoteChunks("prod", existing, infos, 2, now.Add(time.Minute))
+	assert.DeepEqual(t, again[0].Results, chunks[0].Results)
+	assert.DeepEqual(t, again[1].Results, chunks[1].Results)
+
+	// the compliant cluster has an empty report
+	chunks = buildRemoteChunks("prod", existing, nil, 2, now)
+	assert.Equal(t, len(chunks), 1)
+	assert.Equal(t, len(chunks[0].Results), 0)
+	assert.DeepEqual(t, chunks[0].Summary, PolicyReportSummary{})
+}
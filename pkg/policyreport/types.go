@@ -0,0 +1,58 @@
+package policyreport
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// API version and kinds of the policy reports of the Kubernetes Policy WG, wgpolicyk8s.io
+const (
+	apiVersion              = "wgpolicyk8s.io/v1alpha1"
+	policyReportKind        = "PolicyReport"
+	clusterPolicyReportKind = "ClusterPolicyReport"
+)
+
+// statuses of the results
+const (
+	StatusPass  = "pass"
+	StatusFail  = "fail"
+	StatusWarn  = "warn"
+	StatusError = "error"
+	StatusSkip  = "skip"
+)
+
+// PolicyReport is the report of the policy results of the resources of a namespace,
+// or of the cluster-wide resources for a ClusterPolicyReport
+type PolicyReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// Summary is the count of the results per status
+	Summary PolicyReportSummary `json:"summary"`
+	// Results are the results of the rules per resource
+	Results []PolicyReportResult `json:"results,omitempty"`
+}
+
+// PolicyReportSummary is the count of the results per status
+type PolicyReportSummary struct {
+	Pass  int `json:"pass"`
+	Fail  int `json:"fail"`
+	Warn  int `json:"warn"`
+	Error int `json:"error"`
+	Skip  int `json:"skip"`
+}
+
+// PolicyReportResult is the result of a rule of a policy for the resources
+type PolicyReportResult struct {
+	Policy  string `json:"policy"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Status is pass, fail, warn, error or skip
+	Status string `json:"status"`
+	// Scored is false for the informational results
+	Scored    bool                 `json:"scored"`
+	Resources []v1.ObjectReference `json:"resources,omitempty"`
+	// Data are the properties of the result, e.g. the rule type
+	Data map[string]string `json:"data,omitempty"`
+	// Timestamp is the last time the result was reported, refreshed every timestampRefresh
+	Timestamp metav1.Time `json:"timestamp,omitempty"`
+}
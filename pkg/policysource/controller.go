@@ -0,0 +1,292 @@
+package policysource
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	kyvernoclient "github.com/nirmata/kyverno/pkg/client/clientset/versioned"
+	"github.com/nirmata/kyverno/pkg/config"
+	"github.com/nirmata/kyverno/pkg/cosign"
+	"github.com/nirmata/kyverno/pkg/git"
+	"github.com/nirmata/kyverno/pkg/policybundle"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// SourceLabel is the label of the cluster policies loaded from a policy source, its value is the name of the source
+	SourceLabel = "policies.kyverno.io/source"
+	// RevisionAnnotation is the revision of the source of the cluster policies loaded from a policy source,
+	// the digest of the bundle or the commit of the repository
+	RevisionAnnotation = "policies.kyverno.io/source-revision"
+)
+
+// defaultInterval is the interval of the checks of the sources without an interval
+const defaultInterval = 5 * time.Minute
+
+// syncPeriod is the period of the checks of the sources whose interval has elapsed
+const syncPeriod = 30 * time.Second
+
+// Sources returns the policy sources of the configuration
+type Sources interface {
+	PolicySources() []kyverno.PolicySource
+}
+
+// Repositories fetches the files of the Git repositories
+type Repositories interface {
+	FetchFiles(repository, branch, dir string, auth git.Auth) (string, map[string][]byte, error)
+}
+
+// syncState is the last check of a source
+type syncState struct {
+	source   kyverno.PolicySource
+	revision string
+	checked  time.Time
+}
+
+// Controller loads the cluster policies of the policy sources in the cluster, from the bundles of the OCI registries
+// and the directories of the Git repositories. The policies of a source are created and updated when the revision of
+// the source changes, and the policies removed from the source are deleted. The policies not loaded from the source are
+// never overwritten, and the policies of a source removed from the configuration are kept. The result of the last sync
+// of each source is reported in the status of the KyvernoConfig.
+type Controller struct {
+	pclient      kyvernoclient.Interface
+	kubeClient   kubernetes.Interface
+	sources      Sources
+	fetcher      policybundle.Fetcher
+	verifier     cosign.Interface
+	repositories Repositories
+	// synced are the last checks of the sources per name, status the results of their last sync,
+	// reported the statuses in the KyvernoConfig, only accessed by the sync loop
+	synced   map[string]syncState
+	status   map[string]kyverno.PolicySourceStatus
+	reported []kyverno.PolicySourceStatus
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewController returns a controller of the policy sources, the bundles are fetched with the registry client
+// and their signatures are verified with the verifier, the repositories with the credentials of the Secrets of the kyverno namespace
+func NewController(pclient kyvernoclient.Interface, kubeClient kubernetes.Interface, sources Sources, fetcher policybundle.Fetcher, verifier cosign.Interface, repositories Repositories) *Controller {
+	return &Controller{
+		pclient:      pclient,
+		kubeClient:   kubeClient,
+		sources:      sources,
+		fetcher:      fetcher,
+		verifier:     verifier,
+		repositories: repositories,
+		synced:       map[string]syncState{},
+		status:       map[string]kyverno.PolicySourceStatus{},
+		now:          time.Now,
+	}
+}
+
+// Run checks the policy sources until stopCh is closed
+func (c *Controller) Run(stopCh <-chan struct{}) {
+	glog.Info("Starting policy source controller")
+	defer glog.Info("Shutting down policy source controller")
+	wait.Until(c.sync, syncPeriod, stopCh)
+}
+
+func (c *Controller) sync() {
+	sources := c.sources.PolicySources()
+	names := map[string]bool{}
+	for _, source := range sources {
+		names[source.Name] = true
+		state, ok := c.synced[source.Name]
+		unchanged := ok && reflect.DeepEqual(state.source, source)
+		if unchanged && c.now().Before(state.checked.Add(interval(source))) {
+			continue
+		}
+		policies, revision, err := c.fetch(source)
+		if err != nil {
+			glog.Errorf("failed to fetch the policies of source %s: %v", source.Name, err)
+			// the source is checked again after its interval
+			c.synced[source.Name] = syncState{source: source, revision: state.revision, checked: c.now()}
+			c.setError(source.Name, err)
+			continue
+		}
+		if unchanged && revision == state.revision {
+			c.synced[source.Name] = syncState{source: source, revision: revision, checked: c.now()}
+			c.setSynced(source.Name, revision, policies)
+			continue
+		}
+		if err := c.apply(source.Name, policies, revision); err != nil {
+			glog.Errorf("failed to load the policies of source %s: %v", source.Name, err)
+			// the revision is not recorded, so the policies are loaded again at the next check
+			c.synced[source.Name] = syncState{source: source, checked: c.now()}
+			c.setError(source.Name, err)
+			continue
+		}
+		glog.V(2).Infof("Loaded %d policies of source %s at revision %s", len(policies), source.Name, revision)
+		c.synced[source.Name] = syncState{source: source, revision: revision, checked: c.now()}
+		c.setSynced(source.Name, revision, policies)
+	}
+	for name := range c.synced {
+		if !names[name] {
+			delete(c.synced, name)
+			delete(c.status, name)
+		}
+	}
+	c.reportStatus(sources)
+}
+
+func interval(source kyverno.PolicySource) time.Duration {
+	if source.Interval != nil {
+		return source.Interval.Duration
+	}
+	return defaultInterval
+}
+
+// fetch returns the policies of the source and its revision
+func (c *Controller) fetch(source kyverno.PolicySource) ([]kyverno.ClusterPolicy, string, error) {
+	if source.OCI != nil {
+		return policybundle.Fetch(c.fetcher, c.verifier, source.OCI.Image, source.OCI.Key, nil)
+	}
+	auth, err := c.gitAuth(source.Git.SecretName)
+	if err != nil {
+		return nil, "", err
+	}
+	commit, files, err := c.repositories.FetchFiles(source.Git.URL, source.Git.Branch, source.Git.Path, auth)
+	if err != nil {
+		return nil, "", err
+	}
+	policies, err := decodeFiles(files)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s@%s: %v", source.Git.URL, commit, err)
+	}
+	return policies, commit, nil
+}
+
+// gitAuth returns the credentials of the Secret of the kyverno namespace, none if the name is empty
+func (c *Controller) gitAuth(secretName string) (git.Auth, error) {
+	if secretName == "" {
+		return git.Auth{}, nil
+	}
+	secret, err := c.kubeClient.CoreV1().Secrets(config.KubePolicyNamespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return git.Auth{}, fmt.Errorf("failed to get the credentials of the repository: %v", err)
+	}
+	return git.Auth{
+		Username:   string(secret.Data["username"]),
+		Password:   string(secret.Data["password"]),
+		PrivateKey: secret.Data["identity"],
+		KnownHosts: secret.Data["known_hosts"],
+	}, nil
+}
+
+// apply creates or updates the policies of the source and deletes its policies not in the source.
+// The policies are read from the API server, not from the informer cache, so the policies created at the previous check are found.
+func (c *Controller) apply(name string, policies []kyverno.ClusterPolicy, revision string) error {
+	client := c.pclient.KyvernoV1().ClusterPolicies()
+	loaded := map[string]bool{}
+	var failed []string
+	for _, policy := range policies {
+		policy := policy.DeepCopy()
+		loaded[policy.Name] = true
+		if policy.Labels == nil {
+			policy.Labels = map[string]string{}
+		}
+		policy.Labels[SourceLabel] = name
+		if policy.Annotations == nil {
+			policy.Annotations = map[string]string{}
+		}
+		policy.Annotations[RevisionAnnotation] = revision
+		policy.Status = kyverno.PolicyStatus{}
+
+		existing, err := client.Get(policy.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			if _, err := client.Create(policy); err != nil {
+				glog.Errorf("failed to create policy %s of source %s: %v", policy.Name, name, err)
+				failed = append(failed, policy.Name)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if existing.Labels[SourceLabel] != name {
+			glog.Errorf("policy %s of source %s is not loaded, a policy with the same name is not loaded from the source", policy.Name, name)
+			failed = append(failed, policy.Name)
+			continue
+		}
+		if existing.Annotations[RevisionAnnotation] == revision && reflect.DeepEqual(existing.Spec, policy.Spec) {
+			continue
+		}
+		policy.ResourceVersion = existing.ResourceVersion
+		if _, err := client.Update(policy); err != nil {
+			glog.Errorf("failed to update policy %s of source %s: %v", policy.Name, name, err)
+			failed = append(failed, policy.Name)
+		}
+	}
+
+	list, err := client.List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", SourceLabel, name)})
+	if err != nil {
+		return err
+	}
+	for _, policy := range list.Items {
+		if loaded[policy.Name] {
+			continue
+		}
+		if err := client.Delete(policy.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			glog.Errorf("failed to delete policy %s removed from source %s: %v", policy.Name, name, err)
+			failed = append(failed, policy.Name)
+			continue
+		}
+		glog.V(2).Infof("Deleted policy %s removed from source %s", policy.Name, name)
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("failed to load the policies %v", failed)
+	}
+	return nil
+}
+
+func (c *Controller) setSynced(name, revision string, policies []kyverno.ClusterPolicy) {
+	var names []string
+	for _, policy := range policies {
+		names = append(names, policy.Name)
+	}
+	sort.Strings(names)
+	now := metav1.NewTime(c.now())
+	c.status[name] = kyverno.PolicySourceStatus{Name: name, Revision: revision, Policies: names, LastSyncTime: &now}
+}
+
+// setError keeps the revision and the policies of the last successful sync
+func (c *Controller) setError(name string, err error) {
+	status := c.status[name]
+	status.Name = name
+	status.Error = err.Error()
+	c.status[name] = status
+}
+
+// reportStatus updates the status of the KyvernoConfig if the results of the syncs changed
+func (c *Controller) reportStatus(sources []kyverno.PolicySource) {
+	var statuses []kyverno.PolicySourceStatus
+	for _, source := range sources {
+		if status, ok := c.status[source.Name]; ok {
+			statuses = append(statuses, status)
+		}
+	}
+	if reflect.DeepEqual(statuses, c.reported) {
+		return
+	}
+	kc, err := c.pclient.KyvernoV1().KyvernoConfigs().Get(config.KyvernoConfigName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			glog.Errorf("failed to get KyvernoConfig %s: %v", config.KyvernoConfigName, err)
+		}
+		return
+	}
+	kc.Status.PolicySources = statuses
+	if _, err := c.pclient.KyvernoV1().KyvernoConfigs().UpdateStatus(kc); err != nil {
+		glog.Errorf("failed to update the status of KyvernoConfig %s: %v", config.KyvernoConfigName, err)
+		return
+	}
+	c.reported = statuses
+}
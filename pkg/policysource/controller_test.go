@@ -0,0 +1,189 @@
+package policysource
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/client/clientset/versioned/fake"
+	"github.com/nirmata/kyverno/pkg/config"
+	"github.com/nirmata/kyverno/pkg/git"
+	"github.com/nirmata/kyverno/pkg/policybundle"
+	"github.com/nirmata/kyverno/pkg/registry"
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+// memoryRegistry stores the blobs and the tagged manifests of the bundles
+type memoryRegistry struct {
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	fetches   int
+}
+
+func (r *memoryRegistry) PushBlob(image string, data []byte, keychain registry.Keychain) (string, error) {
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	r.blobs[digest] = data
+	return digest, nil
+}
+
+func (r *memoryRegistry) PushManifest(image, mediaType string, manifest []byte, keychain registry.Keychain) (string, error) {
+	r.manifests[image] = manifest
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(manifest)), nil
+}
+
+func (r *memoryRegistry) FetchManifest(image string, keychain registry.Keychain) ([]byte, string, error) {
+	r.fetches++
+	manifest, ok := r.manifests[image]
+	if !ok {
+		return nil, "", fmt.Errorf("manifest %s not found", image)
+	}
+	return manifest, fmt.Sprintf("sha256:%x", sha256.Sum256(manifest)), nil
+}
+
+func (r *memoryRegistry) FetchBlob(image, digest string, keychain registry.Keychain) ([]byte, error) {
+	return r.blobs[digest], nil
+}
+
+type staticSources []kyverno.PolicySource
+
+func (s staticSources) PolicySources() []kyverno.PolicySource {
+	return s
+}
+
+func bundle(names ...string) []byte {
+	var data string
+	for _, name := range names {
+		data += fmt.Sprintf(`---
+apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: %s
+spec:
+  rules:
+  - name: check-labels
+    match:
+      resources:
+        kinds:
+        - Pod
+    validate:
+      pattern:
+        metadata:
+          labels:
+            app: "?*"
+`, name)
+	}
+	return []byte(data)
+}
+
+func policyNames(t *testing.T, c *Controller) map[string]string {
+	list, err := c.pclient.KyvernoV1().ClusterPolicies().List(metav1.ListOptions{})
+	assert.NilError(t, err)
+	names := map[string]string{}
+	for _, policy := range list.Items {
+		names[policy.Name] = policy.Labels[SourceLabel]
+	}
+	return names
+}
+
+func Test_Sync(t *testing.T) {
+	reg := &memoryRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	_, err := policybundle.Push(reg, "ghcr.io/org/baseline:v1", bundle("require-labels", "disallow-latest"), nil)
+	assert.NilError(t, err)
+	// the policies not loaded from the source are never overwritten
+	manual := &kyverno.ClusterPolicy{ObjectMeta: metav1.ObjectMeta{Name: "manual"}}
+	sources := staticSources{{Name: "baseline", OCI: &kyverno.OCISource{Image: "ghcr.io/org/baseline:v1"}}}
+	c := NewController(fake.NewSimpleClientset(manual), kubefake.NewSimpleClientset(), sources, reg, nil, nil)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.sync()
+	assert.DeepEqual(t, policyNames(t, c), map[string]string{"require-labels": "baseline", "disallow-latest": "baseline", "manual": ""})
+	policy, err := c.pclient.KyvernoV1().ClusterPolicies().Get("require-labels", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, policy.Annotations[RevisionAnnotation], c.synced["baseline"].revision)
+
+	// the bundle is checked again once the interval has elapsed
+	_, err = policybundle.Push(reg, "ghcr.io/org/baseline:v1", bundle("require-labels", "manual"), nil)
+	assert.NilError(t, err)
+	c.sync()
+	assert.Equal(t, reg.fetches, 1)
+	now = now.Add(defaultInterval)
+	c.sync()
+	assert.Equal(t, reg.fetches, 2)
+	// disallow-latest is removed from the bundle, manual is not overwritten
+	assert.DeepEqual(t, policyNames(t, c), map[string]string{"require-labels": "baseline", "manual": ""})
+
+	// the policies of a removed source are kept
+	c.sources = staticSources{}
+	c.sync()
+	assert.Equal(t, len(c.synced), 0)
+	assert.DeepEqual(t, policyNames(t, c), map[string]string{"require-labels": "baseline", "manual": ""})
+}
+
+// fakeRepositories returns the files of the repository, with the credentials of the Secret
+type fakeRepositories struct {
+	commit string
+	files  map[string][]byte
+	auth   git.Auth
+}
+
+func (r *fakeRepositories) FetchFiles(repository, branch, dir string, auth git.Auth) (string, map[string][]byte, error) {
+	if auth.Password != r.auth.Password {
+		return "", nil, fmt.Errorf("authentication required")
+	}
+	return r.commit, r.files, nil
+}
+
+func Test_SyncGit(t *testing.T) {
+	repositories := &fakeRepositories{
+		commit: "a1b2c3",
+		files: map[string][]byte{
+			"labels.yaml":        bundle("require-labels"),
+			"kustomization.yaml": []byte("apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\nresources:\n- labels.yaml\n"),
+			"README.md":          []byte("policies"),
+		},
+		auth: git.Auth{Password: "token"},
+	}
+	kc := &kyverno.KyvernoConfig{ObjectMeta: metav1.ObjectMeta{Name: config.KyvernoConfigName}}
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "git-credentials", Namespace: config.KubePolicyNamespace}, Data: map[string][]byte{"username": []byte("git"), "password": []byte("token")}}
+	sources := staticSources{{Name: "git", Git: &kyverno.GitSource{URL: "https://github.com/org/policies", Path: "baseline"}}}
+	c := NewController(fake.NewSimpleClientset(kc), kubefake.NewSimpleClientset(secret), sources, nil, nil, repositories)
+
+	// the error of the sync is reported in the status
+	c.sync()
+	assert.DeepEqual(t, policyNames(t, c), map[string]string{})
+	status := configStatus(t, c)
+	assert.Equal(t, len(status), 1)
+	assert.Equal(t, status[0].Error, "authentication required")
+
+	c.sources = staticSources{{Name: "git", Git: &kyverno.GitSource{URL: "https://github.com/org/policies", Path: "baseline", SecretName: "git-credentials"}}}
+	c.sync()
+	assert.DeepEqual(t, policyNames(t, c), map[string]string{"require-labels": "git"})
+	status = configStatus(t, c)
+	assert.Equal(t, len(status), 1)
+	assert.Equal(t, status[0].Revision, "a1b2c3")
+	assert.DeepEqual(t, status[0].Policies, []string{"require-labels"})
+	assert.Equal(t, status[0].Error, "")
+	assert.Assert(t, status[0].LastSyncTime != nil)
+
+	// the policies are kept if the directory has no policy
+	repositories.commit = "d4e5f6"
+	repositories.files = map[string][]byte{"README.md": []byte("policies")}
+	c.now = func() time.Time { return time.Now().Add(defaultInterval) }
+	c.sync()
+	assert.DeepEqual(t, policyNames(t, c), map[string]string{"require-labels": "git"})
+	status = configStatus(t, c)
+	assert.Equal(t, status[0].Revision, "a1b2c3")
+	assert.Equal(t, status[0].Error, "https://github.com/org/policies@d4e5f6: no ClusterPolicy in the directory")
+}
+
+func configStatus(t *testing.T, c *Controller) []kyverno.PolicySourceStatus {
+	kc, err := c.pclient.KyvernoV1().KyvernoConfigs().Get(config.KyvernoConfigName, metav1.GetOptions{})
+	assert.NilError(t, err)
+	return kc.Status.PolicySources
+}
@@ -0,0 +1,61 @@
+package policysource
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// manifestExtensions are the extensions of the files of the policies in the repositories
+var manifestExtensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+// decodeFiles returns the cluster policies of the YAML and JSON files of a repository directory,
+// the documents of the other kinds are skipped, e.g. a kustomization.yaml
+func decodeFiles(files map[string][]byte) ([]kyverno.ClusterPolicy, error) {
+	var paths []string
+	for p := range files {
+		if manifestExtensions[path.Ext(p)] {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	var policies []kyverno.ClusterPolicy
+	names := map[string]string{}
+	for _, p := range paths {
+		decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(files[p]), 4096)
+		for {
+			policy := kyverno.ClusterPolicy{}
+			if err := decoder.Decode(&policy); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return nil, fmt.Errorf("%s: failed to decode the policies: %v", p, err)
+			}
+			if policy.Kind != "ClusterPolicy" {
+				if policy.Kind != "" {
+					glog.V(4).Infof("skipping %s %s of %s, not a ClusterPolicy", policy.Kind, policy.Name, p)
+				}
+				continue
+			}
+			if policy.Name == "" {
+				return nil, fmt.Errorf("%s: the name of a ClusterPolicy is not set", p)
+			}
+			if other, ok := names[policy.Name]; ok {
+				return nil, fmt.Errorf("%s: ClusterPolicy %s is also defined in %s", p, policy.Name, other)
+			}
+			names[policy.Name] = p
+			policies = append(policies, policy)
+		}
+	}
+	// an empty directory is more likely a wrong path than the removal of all the policies
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("no ClusterPolicy in the directory")
+	}
+	return policies, nil
+}
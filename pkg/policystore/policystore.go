@@ -1,27 +1,45 @@
 package policystore
 
 import (
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/golang/glog"
+	"github.com/minio/minio/pkg/wildcard"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
 	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine/utils"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/cache"
 )
 
-type policyMap map[string]interface{}
+// allNamespaces is the namespace key of the rules matching all the namespaces
+const allNamespaces = "*"
+
+// ruleSet is the set of the names of the candidate rules of a policy
+type ruleSet map[string]interface{}
+type policyMap map[string]ruleSet
 type namespaceMap map[string]policyMap
-type kindMap map[string]namespaceMap
+type kindMap map[schema.GroupVersionKind]namespaceMap
 
 //PolicyStore Store the meta-data information to faster lookup policies
+// the rules are indexed by the group, the version and the kind, and the namespaces they match,
+// the group and the version are * for the kinds of the rules without an API version, e.g. Pod.
+// A namespace key is either a namespace, a namespace pattern or * for the rules matching all the namespaces.
+// The namespaced policies are stored with their namespace/name key
 type PolicyStore struct {
-	data map[string]namespaceMap
+	data kindMap
 	mu   sync.RWMutex
 	// list/get cluster policy
 	pLister kyvernolister.ClusterPolicyLister
 	// returns true if the cluster policy store has been synced at least once
 	pSynched cache.InformerSynced
+	// list/get namespaced policy
+	nspLister kyvernolister.PolicyLister
+	// returns true if the namespaced policy store has been synced at least once
+	nspSynched cache.InformerSynced
 }
 
 //UpdateInterface provides api to update policies
@@ -34,23 +52,27 @@ type UpdateInterface interface {
 
 //LookupInterface provides api to lookup policies
 type LookupInterface interface {
-	// Lookup based on kind and namespaces
-	LookUp(kind, namespace string) ([]kyverno.ClusterPolicy, error)
+	// Lookup based on group, version, kind and namespaces
+	LookUp(gvk schema.GroupVersionKind, namespace string) ([]kyverno.ClusterPolicy, error)
+	// LookUpRules returns the policies with only their rules matching the group, the version, the kind and the namespace
+	LookUpRules(gvk schema.GroupVersionKind, namespace string) ([]kyverno.ClusterPolicy, error)
 }
 
 // NewPolicyStore returns a new policy store
-func NewPolicyStore(pInformer kyvernoinformer.ClusterPolicyInformer) *PolicyStore {
+func NewPolicyStore(pInformer kyvernoinformer.ClusterPolicyInformer, nspInformer kyvernoinformer.PolicyInformer) *PolicyStore {
 	ps := PolicyStore{
-		data:     make(kindMap),
-		pLister:  pInformer.Lister(),
-		pSynched: pInformer.Informer().HasSynced,
+		data:       make(kindMap),
+		pLister:    pInformer.Lister(),
+		pSynched:   pInformer.Informer().HasSynced,
+		nspLister:  nspInformer.Lister(),
+		nspSynched: nspInformer.Informer().HasSynced,
 	}
 	return &ps
 }
 
 //Run checks syncing
 func (ps *PolicyStore) Run(stopCh <-chan struct{}) {
-	if !cache.WaitForCacheSync(stopCh, ps.pSynched) {
+	if !cache.WaitForCacheSync(stopCh, ps.pSynched, ps.nspSynched) {
 		glog.Error("policy meta store: failed to sync informer cache")
 	}
 }
@@ -58,36 +80,34 @@ func (ps *PolicyStore) Run(stopCh <-chan struct{}) {
 //Register a new policy
 func (ps *PolicyStore) Register(policy kyverno.ClusterPolicy) {
 	glog.V(4).Infof("adding resources %s", policy.Name)
+	key := policyKey(policy)
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
-	var pmap policyMap
 	// add an entry for each rule in policy
 	for _, rule := range policy.Spec.Rules {
 		//		rule.MatchResources.Kinds - List - mandatory - atleast on entry
 		for _, kind := range rule.MatchResources.Kinds {
-			kindMap := ps.addKind(kind)
+			kindMap := ps.addKind(utils.ParseKind(kind))
 			// namespaces
 			if len(rule.MatchResources.Namespaces) == 0 {
 				// all namespaces - *
-				pmap = addNamespace(kindMap, "*")
-			} else {
-				for _, ns := range rule.MatchResources.Namespaces {
-					pmap = addNamespace(kindMap, ns)
-				}
+				addRuleElement(addNamespace(kindMap, allNamespaces), key, rule.Name)
+				continue
+			}
+			for _, ns := range rule.MatchResources.Namespaces {
+				addRuleElement(addNamespace(kindMap, ns), key, rule.Name)
 			}
-			// add policy to the pmap
-			addPolicyElement(pmap, policy.Name)
 		}
 	}
 }
 
 //LookUp look up the resources
-func (ps *PolicyStore) LookUp(kind, namespace string) ([]kyverno.ClusterPolicy, error) {
+func (ps *PolicyStore) LookUp(gvk schema.GroupVersionKind, namespace string) ([]kyverno.ClusterPolicy, error) {
 	ret := []kyverno.ClusterPolicy{}
 	// lookup meta-store
-	policyNames := ps.lookUp(kind, namespace)
-	for _, policyName := range policyNames {
-		policy, err := ps.pLister.Get(policyName)
+	candidates := ps.lookUp(gvk, namespace)
+	for _, key := range sortedPolicies(candidates) {
+		policy, err := ps.get(key)
 		if err != nil {
 			return nil, err
 		}
@@ -96,91 +116,138 @@ func (ps *PolicyStore) LookUp(kind, namespace string) ([]kyverno.ClusterPolicy,
 	return ret, nil
 }
 
+// LookUpRules returns the policies with the rules matching the group, the version, the kind and the namespace,
+// the other rules are removed so the admission requests only evaluate the candidate rules, sorted by policy
+func (ps *PolicyStore) LookUpRules(gvk schema.GroupVersionKind, namespace string) ([]kyverno.ClusterPolicy, error) {
+	ret := []kyverno.ClusterPolicy{}
+	candidates := ps.lookUp(gvk, namespace)
+	for _, key := range sortedPolicies(candidates) {
+		policy, err := ps.get(key)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, filterRules(*policy, candidates[key]))
+	}
+	return ret, nil
+}
+
 //UnRegister Remove policy information
 func (ps *PolicyStore) UnRegister(policy kyverno.ClusterPolicy) error {
+	key := policyKey(policy)
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
 	for _, rule := range policy.Spec.Rules {
 		for _, kind := range rule.MatchResources.Kinds {
+			gvk := utils.ParseKind(kind)
 			// get kind Map
-			kindMap := ps.getKind(kind)
+			kindMap := ps.data[gvk]
 			if kindMap == nil {
 				// kind does not exist
-				return nil
+				continue
 			}
 			if len(rule.MatchResources.Namespaces) == 0 {
-				namespace := "*"
-				pmap := getNamespace(kindMap, namespace)
-				// remove element
-				delete(pmap, policy.Name)
+				removePolicyElement(kindMap, allNamespaces, key)
 			} else {
 				for _, ns := range rule.MatchResources.Namespaces {
-					pmap := getNamespace(kindMap, ns)
-					// remove element
-					delete(pmap, policy.Name)
+					removePolicyElement(kindMap, ns, key)
 				}
 			}
+			if len(kindMap) == 0 {
+				delete(ps.data, gvk)
+			}
 		}
 	}
 	return nil
 }
 
-//LookUp lookups up the policies for kind and namespace
-// returns a list of <policy, rule> that statisfy the filters
-func (ps *PolicyStore) lookUp(kind, namespace string) []string {
+// get returns the cluster policy of the key, the namespaced policies are
+// converted to the cluster policies matching their namespace only
+func (ps *PolicyStore) get(key string) (*kyverno.ClusterPolicy, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		return ps.pLister.Get(name)
+	}
+	policy, err := ps.nspLister.Policies(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return policy.ToClusterPolicy(), nil
+}
+
+// policyKey returns the store key of the policy, namespace/name for the namespaced policies
+func policyKey(policy kyverno.ClusterPolicy) string {
+	if policy.Namespace == "" {
+		return policy.Name
+	}
+	return policy.Namespace + "/" + policy.Name
+}
+
+//lookUp lookups up the policies for group, version, kind and namespace
+// returns the candidate rules of each policy matching the group, the version, the kind and the namespace
+func (ps *PolicyStore) lookUp(gvk schema.GroupVersionKind, namespace string) policyMap {
 	ps.mu.RLock()
 	defer ps.mu.RUnlock()
-	var policyMap policyMap
-	var ret []string
-	// kind
-	kindMap := ps.getKind(kind)
-	if kindMap == nil {
-		return []string{}
-	}
-	// get namespace specific policies
-	policyMap = kindMap[namespace]
-	ret = append(ret, transform(policyMap)...)
-	// get policies on all namespaces
-	policyMap = kindMap["*"]
-	ret = append(ret, transform(policyMap)...)
-	return unique(ret)
-}
-
-func unique(intSlice []string) []string {
-	keys := make(map[string]bool)
-	list := []string{}
-	for _, entry := range intSlice {
-		if _, value := keys[entry]; !value {
-			keys[entry] = true
-			list = append(list, entry)
+	ret := policyMap{}
+	// the rules of the kind with the API version of the resource, and without API version
+	anyGroupVersion := schema.GroupVersionKind{Group: utils.AnyGroupVersion, Version: utils.AnyGroupVersion, Kind: gvk.Kind}
+	for _, kindMap := range []namespaceMap{ps.data[gvk], ps.data[anyGroupVersion]} {
+		for key, pmap := range kindMap {
+			if !matchNamespace(key, namespace) {
+				continue
+			}
+			for policy, rules := range pmap {
+				for rule := range rules {
+					addRuleElement(ret, policy, rule)
+				}
+			}
 		}
 	}
-	return list
+	return ret
 }
 
-// generates a copy
-func transform(pmap policyMap) []string {
-	ret := []string{}
+// matchNamespace returns true if the namespace key of the rules matches the namespace,
+// the namespace patterns are matched like the engine matches the rules
+func matchNamespace(key, namespace string) bool {
+	if key == allNamespaces || key == namespace {
+		return true
+	}
+	return strings.ContainsAny(key, "*?") && wildcard.Match(key, namespace)
+}
+
+// filterRules returns a copy of the policy with only the rules of the set, in their order
+func filterRules(policy kyverno.ClusterPolicy, rules ruleSet) kyverno.ClusterPolicy {
+	filtered := make([]kyverno.Rule, 0, len(rules))
+	for _, rule := range policy.Spec.Rules {
+		if _, ok := rules[rule.Name]; ok {
+			filtered = append(filtered, rule)
+		}
+	}
+	policy.Spec.Rules = filtered
+	return policy
+}
+
+func sortedPolicies(pmap policyMap) []string {
+	ret := make([]string, 0, len(pmap))
 	for k := range pmap {
 		ret = append(ret, k)
 	}
+	sort.Strings(ret)
 	return ret
 }
 
-func (ps *PolicyStore) addKind(kind string) namespaceMap {
-	val, ok := ps.data[kind]
+func (ps *PolicyStore) addKind(gvk schema.GroupVersionKind) namespaceMap {
+	val, ok := ps.data[gvk]
 	if ok {
 		return val
 	}
-	ps.data[kind] = make(namespaceMap)
-	return ps.data[kind]
-}
-
-func (ps *PolicyStore) getKind(kind string) namespaceMap {
-	return ps.data[kind]
+	ps.data[gvk] = make(namespaceMap)
+	return ps.data[gvk]
 }
 
-func addNamespace(kindMap map[string]policyMap, namespace string) policyMap {
+func addNamespace(kindMap namespaceMap, namespace string) policyMap {
 	val, ok := kindMap[namespace]
 	if ok {
 		return val
@@ -189,14 +256,24 @@ func addNamespace(kindMap map[string]policyMap, namespace string) policyMap {
 	return kindMap[namespace]
 }
 
-func getNamespace(kindMap map[string]policyMap, namespace string) policyMap {
-	return kindMap[namespace]
-}
-
-func addPolicyElement(pmap policyMap, name string) {
+func addRuleElement(pmap policyMap, policy, rule string) {
 	var emptyInterface interface{}
 
-	if _, ok := pmap[name]; !ok {
-		pmap[name] = emptyInterface
+	rules, ok := pmap[policy]
+	if !ok {
+		rules = make(ruleSet)
+		pmap[policy] = rules
+	}
+	rules[rule] = emptyInterface
+}
+
+func removePolicyElement(kindMap namespaceMap, namespace, policy string) {
+	pmap := kindMap[namespace]
+	if pmap == nil {
+		return
+	}
+	delete(pmap, policy)
+	if len(pmap) == 0 {
+		delete(kindMap, namespace)
 	}
 }
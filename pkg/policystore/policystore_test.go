@@ -12,10 +12,16 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
 	cache "k8s.io/client-go/tools/cache"
 )
 
+var (
+	podGVK     = schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	serviceGVK = schema.GroupVersionKind{Version: "v1", Kind: "Service"}
+)
+
 func Test_Operations(t *testing.T) {
 	rawPolicy1 := []byte(`
 	{
@@ -211,7 +217,7 @@ func Test_Operations(t *testing.T) {
 	// Mock Lister
 	client := fake.NewSimpleClientset(polices...)
 	fakeInformer := &FakeInformer{client: client}
-	store := NewPolicyStore(fakeInformer)
+	store := NewPolicyStore(fakeInformer, newFakePolicyInformer())
 	// Test Operations
 	// Add
 	store.Register(policy1)
@@ -220,7 +226,7 @@ func Test_Operations(t *testing.T) {
 	// Add
 	store.Register(policy3)
 	// Lookup
-	retPolicies, err = store.LookUp("Pod", "")
+	retPolicies, err = store.LookUp(podGVK, "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -234,7 +240,7 @@ func Test_Operations(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	retPolicies, err = store.LookUp("Pod", "")
+	retPolicies, err = store.LookUp(podGVK, "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -244,7 +250,7 @@ func Test_Operations(t *testing.T) {
 	}
 	// Add
 	store.Register(policy1)
-	retPolicies, err = store.LookUp("Pod", "")
+	retPolicies, err = store.LookUp(podGVK, "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -254,7 +260,7 @@ func Test_Operations(t *testing.T) {
 		t.Error("not matching")
 	}
 
-	retPolicies, err = store.LookUp("Service", "")
+	retPolicies, err = store.LookUp(serviceGVK, "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -278,6 +284,23 @@ func (fi *FakeInformer) Lister() listerv1.ClusterPolicyLister {
 	return fl
 }
 
+type FakePolicyInformer struct {
+	indexer cache.Indexer
+}
+
+func newFakePolicyInformer() *FakePolicyInformer {
+	return &FakePolicyInformer{indexer: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})}
+}
+
+func (fi *FakePolicyInformer) Informer() cache.SharedIndexInformer {
+	fsi := &FakeSharedInformer{}
+	return fsi
+}
+
+func (fi *FakePolicyInformer) Lister() listerv1.PolicyLister {
+	return listerv1.NewPolicyLister(fi.indexer)
+}
+
 type FakeLister struct {
 	client *fake.Clientset
 }
@@ -338,3 +361,188 @@ func (fsi *FakeSharedInformer) Run(stopCh <-chan struct{}) {
 func (fsi *FakeSharedInformer) LastSyncResourceVersion() string {
 	return ""
 }
+
+func newRule(name string, kinds []string, namespaces ...string) kyverno.Rule {
+	rule := kyverno.Rule{Name: name}
+	rule.MatchResources.Kinds = kinds
+	rule.MatchResources.Namespaces = namespaces
+	return rule
+}
+
+func Test_LookUpRules(t *testing.T) {
+	policy1 := kyverno.ClusterPolicy{
+		ObjectMeta: v1.ObjectMeta{Name: "policy1"},
+		Spec: kyverno.Spec{Rules: []kyverno.Rule{
+			newRule("pods", []string{"Pod"}),
+			newRule("deployments", []string{"Deployment"}),
+			newRule("prod-pods", []string{"Pod"}, "prod-*"),
+		}},
+	}
+	policy2 := kyverno.ClusterPolicy{
+		ObjectMeta: v1.ObjectMeta{Name: "policy2"},
+		Spec: kyverno.Spec{Rules: []kyverno.Rule{
+			newRule("test-pods", []string{"Pod", "Service"}, "test"),
+		}},
+	}
+	client := fake.NewSimpleClientset(&policy1, &policy2)
+	store := NewPolicyStore(&FakeInformer{client: client}, newFakePolicyInformer())
+	store.Register(policy1)
+	store.Register(policy2)
+
+	ruleNames := func(policies []kyverno.ClusterPolicy) map[string][]string {
+		names := map[string][]string{}
+		for _, policy := range policies {
+			for _, rule := range policy.Spec.Rules {
+				names[policy.Name] = append(names[policy.Name], rule.Name)
+			}
+		}
+		return names
+	}
+
+	policies, err := store.LookUpRules(podGVK, "prod-eu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the namespace patterns are matched, the rules keep their order
+	if !reflect.DeepEqual(ruleNames(policies), map[string][]string{"policy1": {"pods", "prod-pods"}}) {
+		t.Errorf("unexpected rules %v", ruleNames(policies))
+	}
+
+	policies, err = store.LookUpRules(podGVK, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ruleNames(policies), map[string][]string{"policy1": {"pods"}, "policy2": {"test-pods"}}) {
+		t.Errorf("unexpected rules %v", ruleNames(policies))
+	}
+	// the policies of the lister are not modified
+	if len(policies[0].Spec.Rules) != 1 {
+		t.Errorf("unexpected rules %v", policies[0].Spec.Rules)
+	}
+	policy, _ := store.pLister.Get("policy1")
+	if len(policy.Spec.Rules) != 3 {
+		t.Errorf("unexpected rules %v", policy.Spec.Rules)
+	}
+
+	// the unregistered policies are removed from all the kinds
+	if err := store.UnRegister(policy2); err != nil {
+		t.Fatal(err)
+	}
+	policies, err = store.LookUpRules(serviceGVK, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 0 {
+		t.Errorf("unexpected policies %v", ruleNames(policies))
+	}
+}
+
+func Test_LookUpRules_GroupVersionKind(t *testing.T) {
+	policy := kyverno.ClusterPolicy{
+		ObjectMeta: v1.ObjectMeta{Name: "policy"},
+		Spec: kyverno.Spec{Rules: []kyverno.Rule{
+			newRule("ingresses", []string{"Ingress"}),
+			newRule("networking-ingresses", []string{"networking.k8s.io/v1beta1/Ingress"}),
+			newRule("pods", []string{"v1/Pod"}),
+		}},
+	}
+	client := fake.NewSimpleClientset(&policy)
+	store := NewPolicyStore(&FakeInformer{client: client}, newFakePolicyInformer())
+	store.Register(policy)
+
+	ruleNames := func(gvk schema.GroupVersionKind) []string {
+		policies, err := store.LookUpRules(gvk, "default")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, policy := range policies {
+			for _, rule := range policy.Spec.Rules {
+				names = append(names, rule.Name)
+			}
+		}
+		return names
+	}
+	// the kinds without an API version match all the groups and versions
+	if names := ruleNames(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}); !reflect.DeepEqual(names, []string{"ingresses", "networking-ingresses"}) {
+		t.Errorf("unexpected rules %v", names)
+	}
+	if names := ruleNames(schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}); !reflect.DeepEqual(names, []string{"ingresses"}) {
+		t.Errorf("unexpected rules %v", names)
+	}
+	if names := ruleNames(podGVK); !reflect.DeepEqual(names, []string{"pods"}) {
+		t.Errorf("unexpected rules %v", names)
+	}
+	if names := ruleNames(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Pod"}); len(names) != 0 {
+		t.Errorf("unexpected rules %v", names)
+	}
+
+	if err := store.UnRegister(policy); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.data) != 0 {
+		t.Errorf("unexpected kinds %v", store.data)
+	}
+}
+
+func Test_LookUpNamespacedPolicy(t *testing.T) {
+	clusterPolicy := kyverno.ClusterPolicy{
+		ObjectMeta: v1.ObjectMeta{Name: "policy"},
+		Spec: kyverno.Spec{Rules: []kyverno.Rule{
+			newRule("pods", []string{"Pod"}),
+		}},
+	}
+	policy := kyverno.Policy{
+		ObjectMeta: v1.ObjectMeta{Name: "policy", Namespace: "team-a"},
+		Spec: kyverno.Spec{Rules: []kyverno.Rule{
+			newRule("pods", []string{"Pod"}, "*"),
+		}},
+	}
+	client := fake.NewSimpleClientset(&clusterPolicy)
+	nspInformer := newFakePolicyInformer()
+	if err := nspInformer.indexer.Add(&policy); err != nil {
+		t.Fatal(err)
+	}
+	store := NewPolicyStore(&FakeInformer{client: client}, nspInformer)
+	store.Register(clusterPolicy)
+	store.Register(*policy.ToClusterPolicy())
+
+	names := func(policies []kyverno.ClusterPolicy) []string {
+		var ret []string
+		for _, policy := range policies {
+			ret = append(ret, policy.Namespace+"/"+policy.Name)
+		}
+		return ret
+	}
+
+	// the namespaced policy only applies to its namespace, even if its rules match all the namespaces
+	policies, err := store.LookUpRules(podGVK, "team-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(policies), []string{"/policy"}) {
+		t.Errorf("unexpected policies %v", names(policies))
+	}
+	policies, err = store.LookUpRules(podGVK, "team-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(policies), []string{"/policy", "team-a/policy"}) {
+		t.Errorf("unexpected policies %v", names(policies))
+	}
+	if !reflect.DeepEqual(policies[1].Spec.Rules[0].MatchResources.Namespaces, []string{"team-a"}) {
+		t.Errorf("unexpected namespaces %v", policies[1].Spec.Rules[0].MatchResources.Namespaces)
+	}
+
+	// unregistering the namespaced policy keeps the cluster policy of the same name
+	if err := store.UnRegister(*policy.ToClusterPolicy()); err != nil {
+		t.Fatal(err)
+	}
+	policies, err = store.LookUpRules(podGVK, "team-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names(policies), []string{"/policy"}) {
+		t.Errorf("unexpected policies %v", names(policies))
+	}
+}
@@ -0,0 +1,221 @@
+package policyviolation
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	kyvernoclient "github.com/nirmata/kyverno/pkg/client/clientset/versioned"
+	kyvernov1 "github.com/nirmata/kyverno/pkg/client/clientset/versioned/typed/kyverno/v1"
+	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
+	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+// CleanupInterval is the interval of the scans of the stale policy violations
+const CleanupInterval = 10 * time.Minute
+
+//CleanupController removes the stale policy violations, the violations of the deleted resources
+// and the violated rules of the deleted policies and rules, and the violations above the retention limits.
+// The violations of the compliant resources are deleted by the background scans of the policy controller.
+type CleanupController struct {
+	dclient          *dclient.Client
+	retention        Retention
+	kyvernoInterface kyvernov1.KyvernoV1Interface
+	pLister          kyvernolister.ClusterPolicyLister
+	cpvLister        kyvernolister.ClusterPolicyViolationLister
+	nspvLister       kyvernolister.PolicyViolationLister
+	pSynced          cache.InformerSynced
+	cpvSynced        cache.InformerSynced
+	nspvSynced       cache.InformerSynced
+}
+
+//NewCleanupController returns a new instance of the policy violation cleanup controller
+func NewCleanupController(client *kyvernoclient.Clientset,
+	dclient *dclient.Client,
+	pInformer kyvernoinformer.ClusterPolicyInformer,
+	pvInformer kyvernoinformer.ClusterPolicyViolationInformer,
+	nspvInformer kyvernoinformer.PolicyViolationInformer,
+	retention Retention) *CleanupController {
+	return &CleanupController{
+		dclient:          dclient,
+		retention:        retention,
+		kyvernoInterface: client.KyvernoV1(),
+		pLister:          pInformer.Lister(),
+		cpvLister:        pvInformer.Lister(),
+		nspvLister:       nspvInformer.Lister(),
+		pSynced:          pInformer.Informer().HasSynced,
+		cpvSynced:        pvInformer.Informer().HasSynced,
+		nspvSynced:       nspvInformer.Informer().HasSynced,
+	}
+}
+
+// Run scans the policy violations every CleanupInterval
+func (c *CleanupController) Run(stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	glog.Info("Starting policy violation cleanup controller")
+	defer glog.Info("Shutting down policy violation cleanup controller")
+
+	if !cache.WaitForCacheSync(stopCh, c.pSynced, c.cpvSynced, c.nspvSynced) {
+		glog.Error("policy violation cleanup controller: failed to sync informer cache")
+		return
+	}
+	wait.Until(c.cleanup, CleanupInterval, stopCh)
+}
+
+func (c *CleanupController) cleanup() {
+	cpvs, err := c.cpvLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list cluster policy violations: %v", err)
+		return
+	}
+	for _, cpv := range cpvs {
+		rules, err := c.activeRules(cpv.Spec)
+		if err != nil {
+			glog.V(4).Infof("failed to check cluster policy violation %s: %v", cpv.Name, err)
+			continue
+		}
+		if len(rules) == 0 {
+			glog.V(4).Infof("cleanup stale cluster policy violation %s on %s", cpv.Name, cpv.Spec.ResourceSpec.ToKey())
+			if err := c.kyvernoInterface.ClusterPolicyViolations().Delete(cpv.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				glog.Errorf("failed to delete cluster policy violation %s: %v", cpv.Name, err)
+			}
+			continue
+		}
+		if reflect.DeepEqual(rules, cpv.Spec.ViolatedRules) {
+			continue
+		}
+		newPv := cpv.DeepCopy()
+		newPv.Spec.ViolatedRules = rules
+		if _, err := c.kyvernoInterface.ClusterPolicyViolations().Update(newPv); err != nil {
+			glog.Errorf("failed to remove the stale rules of cluster policy violation %s: %v", cpv.Name, err)
+		}
+	}
+
+	nspvs, err := c.nspvLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list namespaced policy violations: %v", err)
+		return
+	}
+	for _, nspv := range nspvs {
+		rules, err := c.activeRules(nspv.Spec)
+		if err != nil {
+			glog.V(4).Infof("failed to check policy violation %s/%s: %v", nspv.Namespace, nspv.Name, err)
+			continue
+		}
+		if len(rules) == 0 {
+			glog.V(4).Infof("cleanup stale policy violation %s/%s on %s", nspv.Namespace, nspv.Name, nspv.Spec.ResourceSpec.ToKey())
+			if err := c.kyvernoInterface.PolicyViolations(nspv.Namespace).Delete(nspv.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				glog.Errorf("failed to delete policy violation %s/%s: %v", nspv.Namespace, nspv.Name, err)
+			}
+			continue
+		}
+		if reflect.DeepEqual(rules, nspv.Spec.ViolatedRules) {
+			continue
+		}
+		newPv := nspv.DeepCopy()
+		newPv.Spec.ViolatedRules = rules
+		if _, err := c.kyvernoInterface.PolicyViolations(nspv.Namespace).Update(newPv); err != nil {
+			glog.Errorf("failed to remove the stale rules of policy violation %s/%s: %v", nspv.Namespace, nspv.Name, err)
+		}
+	}
+
+	if c.retention.Enabled() {
+		c.applyRetention()
+	}
+}
+
+// applyRetention deletes the policy violations above the retention limits, the stale violations
+// deleted by the cleanup may still be listed, they are ignored
+func (c *CleanupController) applyRetention() {
+	now := time.Now()
+	cpvs, err := c.cpvLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list cluster policy violations: %v", err)
+		return
+	}
+	var lastSeen []time.Time
+	for _, cpv := range cpvs {
+		lastSeen = append(lastSeen, reportedAt(cpv.Status, cpv.CreationTimestamp))
+	}
+	for _, i := range c.retention.Expired(lastSeen, now) {
+		glog.V(4).Infof("cluster policy violation %s on %s exceeds the retention", cpvs[i].Name, cpvs[i].Spec.ResourceSpec.ToKey())
+		if err := c.kyvernoInterface.ClusterPolicyViolations().Delete(cpvs[i].Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			glog.Errorf("failed to delete cluster policy violation %s: %v", cpvs[i].Name, err)
+		}
+	}
+
+	nspvs, err := c.nspvLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list namespaced policy violations: %v", err)
+		return
+	}
+	namespaces := map[string][]*kyverno.PolicyViolation{}
+	for _, nspv := range nspvs {
+		namespaces[nspv.Namespace] = append(namespaces[nspv.Namespace], nspv)
+	}
+	for namespace, pvs := range namespaces {
+		lastSeen := make([]time.Time, 0, len(pvs))
+		for _, pv := range pvs {
+			lastSeen = append(lastSeen, reportedAt(pv.Status, pv.CreationTimestamp))
+		}
+		for _, i := range c.retention.Expired(lastSeen, now) {
+			glog.V(4).Infof("policy violation %s/%s on %s exceeds the retention", namespace, pvs[i].Name, pvs[i].Spec.ResourceSpec.ToKey())
+			if err := c.kyvernoInterface.PolicyViolations(namespace).Delete(pvs[i].Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				glog.Errorf("failed to delete policy violation %s/%s: %v", namespace, pvs[i].Name, err)
+			}
+		}
+	}
+}
+
+// reportedAt returns the last time the violation was reported, its creation if the status is not set
+func reportedAt(status kyverno.PolicyViolationStatus, created metav1.Time) time.Time {
+	if !status.LastSeen.IsZero() {
+		return status.LastSeen.Time
+	}
+	return created.Time
+}
+
+// activeRules returns the violated rules that still exist, none if the policy or the resource is deleted
+func (c *CleanupController) activeRules(spec kyverno.PolicyViolationSpec) ([]kyverno.ViolatedRule, error) {
+	policy, err := c.pLister.Get(spec.Policy)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	rules := FilterRules(policy, spec.ViolatedRules)
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	if _, err := c.dclient.GetResource(spec.Kind, spec.Namespace, spec.Name); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rules, nil
+}
+
+//FilterRules returns the violated rules that are rules of the policy
+func FilterRules(policy *kyverno.ClusterPolicy, rules []kyverno.ViolatedRule) []kyverno.ViolatedRule {
+	names := map[string]bool{}
+	for _, rule := range policy.Spec.Rules {
+		names[rule.Name] = true
+	}
+	var filtered []kyverno.ViolatedRule
+	for _, rule := range rules {
+		if names[rule.Name] {
+			filtered = append(filtered, rule)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,18 @@
+package policyviolation
+
+import (
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"gotest.tools/assert"
+)
+
+func Test_FilterRules(t *testing.T) {
+	policy := &kyverno.ClusterPolicy{Spec: kyverno.Spec{Rules: []kyverno.Rule{{Name: "check-app"}, {Name: "check-probes"}}}}
+	rules := []kyverno.ViolatedRule{
+		{Name: "check-app", Type: "Validation", Message: "label app is required"},
+		{Name: "check-team", Type: "Validation", Message: "label team is required"},
+	}
+	assert.DeepEqual(t, FilterRules(policy, rules), []kyverno.ViolatedRule{{Name: "check-app", Type: "Validation", Message: "label app is required"}})
+	assert.Equal(t, len(FilterRules(policy, rules[1:])), 0)
+}
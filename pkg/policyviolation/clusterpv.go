@@ -9,6 +9,7 @@ import (
 	kyvernov1 "github.com/nirmata/kyverno/pkg/client/clientset/versioned/typed/kyverno/v1"
 	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
 	client "github.com/nirmata/kyverno/pkg/dclient"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -65,15 +66,40 @@ func (cpv *clusterPV) getExisting(newPv kyverno.ClusterPolicyViolation) (*kyvern
 		return nil, err
 	}
 
+	if len(pvs) == 0 {
+		// the cache might not have the violation created by the previous request yet
+		list, err := cpv.kyvernoInterface.ClusterPolicyViolations().List(metav1.ListOptions{LabelSelector: ls.String()})
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			pvs = append(pvs, &list.Items[i])
+		}
+	}
+
+	var existing *kyverno.ClusterPolicyViolation
 	for _, pv := range pvs {
 		// find a policy on same resource and policy combination
-		if pv.Spec.Policy == newPv.Spec.Policy &&
-			pv.Spec.ResourceSpec.Kind == newPv.Spec.ResourceSpec.Kind &&
-			pv.Spec.ResourceSpec.Name == newPv.Spec.ResourceSpec.Name {
-			return pv, nil
+		if pv.Spec.Policy != newPv.Spec.Policy ||
+			pv.Spec.ResourceSpec.Kind != newPv.Spec.ResourceSpec.Kind ||
+			pv.Spec.ResourceSpec.Name != newPv.Spec.ResourceSpec.Name {
+			continue
+		}
+		if existing == nil {
+			existing = pv
+			continue
+		}
+		// keep the oldest violation, delete the duplicates
+		duplicate := pv
+		if pv.CreationTimestamp.Before(&existing.CreationTimestamp) {
+			existing, duplicate = pv, existing
+		}
+		glog.V(4).Infof("deleting duplicate cluster policy violation %s of %s", duplicate.Name, existing.Name)
+		if err := cpv.kyvernoInterface.ClusterPolicyViolations().Delete(duplicate.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			glog.V(4).Infof("failed to delete duplicate cluster policy violation %s: %v", duplicate.Name, err)
 		}
 	}
-	return nil, nil
+	return existing, nil
 }
 
 func (cpv *clusterPV) createPV(newPv *kyverno.ClusterPolicyViolation) error {
@@ -88,11 +114,16 @@ func (cpv *clusterPV) createPV(newPv *kyverno.ClusterPolicyViolation) error {
 	newPv.SetOwnerReferences([]metav1.OwnerReference{ownerRef})
 
 	// create resource
-	_, err = cpv.kyvernoInterface.ClusterPolicyViolations().Create(newPv)
+	created, err := cpv.kyvernoInterface.ClusterPolicyViolations().Create(newPv)
 	if err != nil {
 		glog.V(4).Infof("failed to create Cluster Policy Violation: %v", err)
 		return err
 	}
+	// the status is a subresource, it is not set on creation
+	created.Status = observedStatus(kyverno.PolicyViolationStatus{}, true)
+	if _, err := cpv.kyvernoInterface.ClusterPolicyViolations().UpdateStatus(created); err != nil {
+		glog.V(4).Infof("failed to update the status of policy violation %s: %v", created.Name, err)
+	}
 	glog.Infof("policy violation created for resource %v", newPv.Spec.ResourceSpec)
 	return nil
 }
@@ -101,7 +132,13 @@ func (cpv *clusterPV) updatePV(newPv, oldPv *kyverno.ClusterPolicyViolation) err
 	var err error
 	// check if there is any update
 	if reflect.DeepEqual(newPv.Spec, oldPv.Spec) {
-		glog.V(4).Infof("policy violation spec %v did not change so not updating it", newPv.Spec)
+		// count the repeated violation
+		seen := oldPv.DeepCopy()
+		seen.Status = observedStatus(oldPv.Status, false)
+		if _, err := cpv.kyvernoInterface.ClusterPolicyViolations().UpdateStatus(seen); err != nil {
+			return fmt.Errorf("failed to update the status of policy violation %s: %v", oldPv.Name, err)
+		}
+		glog.V(4).Infof("policy violation spec %v did not change, seen %d times", newPv.Spec, seen.Status.Count)
 		return nil
 	}
 	// set name
@@ -109,11 +146,15 @@ func (cpv *clusterPV) updatePV(newPv, oldPv *kyverno.ClusterPolicyViolation) err
 	newPv.SetResourceVersion(oldPv.ResourceVersion)
 
 	// update resource
-	_, err = cpv.kyvernoInterface.ClusterPolicyViolations().Update(newPv)
+	updated, err := cpv.kyvernoInterface.ClusterPolicyViolations().Update(newPv)
 	if err != nil {
 		return fmt.Errorf("failed to update cluster policy violation: %v", err)
 	}
 	glog.Infof("cluster policy violation updated for resource %v", newPv.Spec.ResourceSpec)
+	updated.Status = observedStatus(oldPv.Status, true)
+	if _, err := cpv.kyvernoInterface.ClusterPolicyViolations().UpdateStatus(updated); err != nil {
+		glog.V(4).Infof("failed to update the status of policy violation %s: %v", updated.Name, err)
+	}
 
 	return nil
 }
@@ -70,3 +70,14 @@ func converLabelToSelector(labelMap map[string]string) (labels.Selector, error)
 
 	return policyViolationSelector, nil
 }
+
+// observedStatus returns the status of a violation reported again, changed is true if the violated rules changed
+func observedStatus(status kyverno.PolicyViolationStatus, changed bool) kyverno.PolicyViolationStatus {
+	now := metav1.Now()
+	status.Count++
+	status.LastSeen = now
+	if changed || status.LastUpdateTime.IsZero() {
+		status.LastUpdateTime = now
+	}
+	return status
+}
@@ -0,0 +1,27 @@
+package policyviolation
+
+import (
+	"testing"
+	"time"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"gotest.tools/assert"
+)
+
+func Test_observedStatus(t *testing.T) {
+	created := observedStatus(kyverno.PolicyViolationStatus{}, true)
+	assert.Equal(t, created.Count, 1)
+	assert.Assert(t, !created.LastSeen.IsZero())
+	assert.Equal(t, created.LastUpdateTime, created.LastSeen)
+
+	// the repeated violation is counted, the rules did not change
+	created.LastUpdateTime.Time = created.LastUpdateTime.Add(-time.Hour)
+	seen := observedStatus(created, false)
+	assert.Equal(t, seen.Count, 2)
+	assert.Equal(t, seen.LastUpdateTime, created.LastUpdateTime)
+	assert.Assert(t, seen.LastSeen.After(seen.LastUpdateTime.Time))
+
+	updated := observedStatus(seen, true)
+	assert.Equal(t, updated.Count, 3)
+	assert.Equal(t, updated.LastUpdateTime, updated.LastSeen)
+}
@@ -16,9 +16,10 @@ import (
 	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
 
 	dclient "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/ratelimit"
+	"github.com/nirmata/kyverno/pkg/utils"
 	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 )
@@ -38,8 +39,11 @@ type Generator struct {
 	pvSynced cache.InformerSynced
 	// returns true if the namespaced cluster policy store has been synced at at least once
 	nspvSynced cache.InformerSynced
-	queue      workqueue.RateLimitingInterface
-	dataStore  *dataStore
+	// limits the writes of the policy violations
+	limiter   *ratelimit.Limiter
+	queue     workqueue.RateLimitingInterface
+	dataStore *dataStore
+	workers   utils.Workers
 }
 
 //NewDataStore returns an instance of data store
@@ -74,7 +78,7 @@ func (ds *dataStore) delete(keyHash string) {
 	delete(ds.data, keyHash)
 }
 
-//Info is a request to create PV
+//Info is a request to create PV, a request without rules reports a compliant resource
 type Info struct {
 	PolicyName string
 	Resource   unstructured.Unstructured
@@ -103,7 +107,8 @@ type GeneratorInterface interface {
 func NewPVGenerator(client *kyvernoclient.Clientset,
 	dclient *dclient.Client,
 	pvInformer kyvernoinformer.ClusterPolicyViolationInformer,
-	nspvInformer kyvernoinformer.PolicyViolationInformer) *Generator {
+	nspvInformer kyvernoinformer.PolicyViolationInformer,
+	limiter *ratelimit.Limiter) *Generator {
 	gen := Generator{
 		kyvernoInterface: client.KyvernoV1(),
 		dclient:          dclient,
@@ -111,6 +116,7 @@ func NewPVGenerator(client *kyvernoclient.Clientset,
 		pvSynced:         pvInformer.Informer().HasSynced,
 		nspvLister:       nspvInformer.Lister(),
 		nspvSynced:       nspvInformer.Informer().HasSynced,
+		limiter:          limiter,
 		queue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), workQueueName),
 		dataStore:        newDataStore(),
 	}
@@ -129,6 +135,10 @@ func (gen *Generator) enqueue(info Info) {
 //Add queues a policy violation create request
 func (gen *Generator) Add(infos ...Info) {
 	for _, info := range infos {
+		if len(info.Rules) == 0 {
+			// a compliant resource, its violation is deleted by the policy controller
+			continue
+		}
 		gen.enqueue(info)
 		glog.V(3).Infof("Added policy violation: %s", info.toKey())
 	}
@@ -145,11 +155,18 @@ func (gen *Generator) Run(workers int, stopCh <-chan struct{}) {
 	}
 
 	for i := 0; i < workers; i++ {
-		go wait.Until(gen.runWorker, time.Second, stopCh)
+		gen.workers.Go(gen.runWorker, stopCh)
 	}
 	<-stopCh
 }
 
+// Drain stops queueing the policy violations and writes the queued ones,
+// it returns false if they are not written before the timeout
+func (gen *Generator) Drain(timeout time.Duration) bool {
+	gen.queue.ShutDown()
+	return gen.workers.Wait(timeout)
+}
+
 func (gen *Generator) runWorker() {
 	for gen.processNextWorkitem() {
 	}
@@ -229,6 +246,7 @@ func (gen *Generator) syncHandler(info Info) error {
 	pv := builder.generate(info)
 
 	// Create Policy Violations
+	gen.limiter.Wait()
 	glog.V(3).Infof("Creating policy violation: %s", info.toKey())
 	if err := handler.create(pv); err != nil {
 		failure = true
@@ -249,3 +267,23 @@ func (gen *Generator) syncHandler(info Info) error {
 type pvGenerator interface {
 	create(policyViolation kyverno.PolicyViolationTemplate) error
 }
+
+// multiGenerator forwards the policy violations to several generators
+type multiGenerator []GeneratorInterface
+
+// NewMultiGenerator returns a generator that forwards the policy violations to the generators
+func NewMultiGenerator(generators ...GeneratorInterface) GeneratorInterface {
+	return multiGenerator(generators)
+}
+
+//Add forwards the policy violations to the generators
+func (m multiGenerator) Add(infos ...Info) {
+	for _, gen := range m {
+		gen.Add(infos...)
+	}
+}
+
+//Len returns the number of the queued policy violations
+func (gen *Generator) Len() int {
+	return gen.queue.Len()
+}
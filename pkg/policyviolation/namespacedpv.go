@@ -9,6 +9,7 @@ import (
 	kyvernov1 "github.com/nirmata/kyverno/pkg/client/clientset/versioned/typed/kyverno/v1"
 	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
 	client "github.com/nirmata/kyverno/pkg/dclient"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -64,15 +65,40 @@ func (nspv *namespacedPV) getExisting(newPv kyverno.PolicyViolation) (*kyverno.P
 		return nil, err
 	}
 
+	if len(pvs) == 0 {
+		// the cache might not have the violation created by the previous request yet
+		list, err := nspv.kyvernoInterface.PolicyViolations(newPv.GetNamespace()).List(metav1.ListOptions{LabelSelector: ls.String()})
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			pvs = append(pvs, &list.Items[i])
+		}
+	}
+
+	var existing *kyverno.PolicyViolation
 	for _, pv := range pvs {
 		// find a policy on same resource and policy combination
-		if pv.Spec.Policy == newPv.Spec.Policy &&
-			pv.Spec.ResourceSpec.Kind == newPv.Spec.ResourceSpec.Kind &&
-			pv.Spec.ResourceSpec.Name == newPv.Spec.ResourceSpec.Name {
-			return pv, nil
+		if pv.Spec.Policy != newPv.Spec.Policy ||
+			pv.Spec.ResourceSpec.Kind != newPv.Spec.ResourceSpec.Kind ||
+			pv.Spec.ResourceSpec.Name != newPv.Spec.ResourceSpec.Name {
+			continue
+		}
+		if existing == nil {
+			existing = pv
+			continue
+		}
+		// keep the oldest violation, delete the duplicates
+		duplicate := pv
+		if pv.CreationTimestamp.Before(&existing.CreationTimestamp) {
+			existing, duplicate = pv, existing
+		}
+		glog.V(4).Infof("deleting duplicate namespaced policy violation %s of %s", duplicate.Name, existing.Name)
+		if err := nspv.kyvernoInterface.PolicyViolations(newPv.GetNamespace()).Delete(duplicate.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			glog.V(4).Infof("failed to delete duplicate namespaced policy violation %s: %v", duplicate.Name, err)
 		}
 	}
-	return nil, nil
+	return existing, nil
 }
 
 func (nspv *namespacedPV) createPV(newPv *kyverno.PolicyViolation) error {
@@ -87,11 +113,16 @@ func (nspv *namespacedPV) createPV(newPv *kyverno.PolicyViolation) error {
 	newPv.SetOwnerReferences([]metav1.OwnerReference{ownerRef})
 
 	// create resource
-	_, err = nspv.kyvernoInterface.PolicyViolations(newPv.GetNamespace()).Create(newPv)
+	created, err := nspv.kyvernoInterface.PolicyViolations(newPv.GetNamespace()).Create(newPv)
 	if err != nil {
 		glog.V(4).Infof("failed to create Cluster Policy Violation: %v", err)
 		return err
 	}
+	// the status is a subresource, it is not set on creation
+	created.Status = observedStatus(kyverno.PolicyViolationStatus{}, true)
+	if _, err := nspv.kyvernoInterface.PolicyViolations(newPv.GetNamespace()).UpdateStatus(created); err != nil {
+		glog.V(4).Infof("failed to update the status of policy violation %s: %v", created.Name, err)
+	}
 	glog.Infof("policy violation created for resource %v", newPv.Spec.ResourceSpec)
 	return nil
 }
@@ -100,17 +131,27 @@ func (nspv *namespacedPV) updatePV(newPv, oldPv *kyverno.PolicyViolation) error
 	var err error
 	// check if there is any update
 	if reflect.DeepEqual(newPv.Spec, oldPv.Spec) {
-		glog.V(4).Infof("policy violation spec %v did not change so not updating it", newPv.Spec)
+		// count the repeated violation
+		seen := oldPv.DeepCopy()
+		seen.Status = observedStatus(oldPv.Status, false)
+		if _, err := nspv.kyvernoInterface.PolicyViolations(newPv.GetNamespace()).UpdateStatus(seen); err != nil {
+			return fmt.Errorf("failed to update the status of policy violation %s: %v", oldPv.Name, err)
+		}
+		glog.V(4).Infof("policy violation spec %v did not change, seen %d times", newPv.Spec, seen.Status.Count)
 		return nil
 	}
 	// set name
 	newPv.SetName(oldPv.Name)
 	newPv.SetResourceVersion(oldPv.ResourceVersion)
 	// update resource
-	_, err = nspv.kyvernoInterface.PolicyViolations(newPv.GetNamespace()).Update(newPv)
+	updated, err := nspv.kyvernoInterface.PolicyViolations(newPv.GetNamespace()).Update(newPv)
 	if err != nil {
 		return fmt.Errorf("failed to update namespaced polciy violation: %v", err)
 	}
 	glog.Infof("namespaced policy violation updated for resource %v", newPv.Spec.ResourceSpec)
+	updated.Status = observedStatus(oldPv.Status, true)
+	if _, err := nspv.kyvernoInterface.PolicyViolations(newPv.GetNamespace()).UpdateStatus(updated); err != nil {
+		glog.V(4).Infof("failed to update the status of policy violation %s: %v", updated.Name, err)
+	}
 	return nil
 }
@@ -0,0 +1,41 @@
+package policyviolation
+
+import (
+	"sort"
+	"time"
+)
+
+// Retention limits the policy violations, and the results of the policy reports, kept per namespace.
+// The cluster-wide violations are limited together.
+type Retention struct {
+	// MaxAge removes the violations not reported since, disabled if 0
+	MaxAge time.Duration
+	// MaxPerNamespace removes the least recently reported violations above the count, disabled if 0
+	MaxPerNamespace int
+}
+
+// Enabled returns true if a limit is set
+func (r Retention) Enabled() bool {
+	return r.MaxAge > 0 || r.MaxPerNamespace > 0
+}
+
+// Expired returns the indexes of the violations of a namespace to remove, given the times they were last reported
+func (r Retention) Expired(lastSeen []time.Time, now time.Time) []int {
+	var expired, kept []int
+	for i, t := range lastSeen {
+		if r.MaxAge > 0 && now.Sub(t) > r.MaxAge {
+			expired = append(expired, i)
+			continue
+		}
+		kept = append(kept, i)
+	}
+	if r.MaxPerNamespace > 0 && len(kept) > r.MaxPerNamespace {
+		// the oldest are removed first
+		sort.SliceStable(kept, func(a, b int) bool {
+			return lastSeen[kept[a]].Before(lastSeen[kept[b]])
+		})
+		expired = append(expired, kept[:len(kept)-r.MaxPerNamespace]...)
+	}
+	sort.Ints(expired)
+	return expired
+}
@@ -0,0 +1,26 @@
+package policyviolation
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func Test_Retention_Expired(t *testing.T) {
+	now := time.Date(2020, 3, 2, 10, 0, 0, 0, time.UTC)
+	lastSeen := []time.Time{
+		now.Add(-time.Hour),
+		now.Add(-48 * time.Hour),
+		now.Add(-2 * time.Hour),
+		now.Add(-time.Minute),
+	}
+	assert.Assert(t, !Retention{}.Enabled())
+	assert.Equal(t, len(Retention{}.Expired(lastSeen, now)), 0)
+
+	assert.DeepEqual(t, Retention{MaxAge: 24 * time.Hour}.Expired(lastSeen, now), []int{1})
+	// the least recently reported are removed first
+	assert.DeepEqual(t, Retention{MaxPerNamespace: 2}.Expired(lastSeen, now), []int{1, 2})
+	assert.DeepEqual(t, Retention{MaxAge: 24 * time.Hour, MaxPerNamespace: 2}.Expired(lastSeen, now), []int{1, 2})
+	assert.DeepEqual(t, Retention{MaxAge: 24 * time.Hour, MaxPerNamespace: 3}.Expired(lastSeen, now), []int{1})
+}
@@ -0,0 +1,576 @@
+package psp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// annotations of the seccomp and AppArmor profiles of the PodSecurityPolicies and of the pods
+const (
+	SeccompAllowedProfilesAnnotation  = "seccomp.security.alpha.kubernetes.io/allowedProfileNames"
+	SeccompDefaultProfileAnnotation   = "seccomp.security.alpha.kubernetes.io/defaultProfileName"
+	SeccompPodAnnotation              = "seccomp.security.alpha.kubernetes.io/pod"
+	AppArmorAllowedProfilesAnnotation = "apparmor.security.beta.kubernetes.io/allowedProfileNames"
+	AppArmorDefaultProfileAnnotation  = "apparmor.security.beta.kubernetes.io/defaultProfileName"
+)
+
+// PolicyPrefix is the prefix of the names of the converted cluster policies
+const PolicyPrefix = "psp-"
+
+// the highest ports and IDs, the ranges up to them have no maximum
+const (
+	maxPort = 65535
+	maxID   = math.MaxUint32
+)
+
+// volumeFields are the fields of the volume sources of the volume types of the PodSecurityPolicies
+var volumeFields = map[policyv1beta1.FSType]string{
+	policyv1beta1.AzureFile:             "azureFile",
+	policyv1beta1.Flocker:               "flocker",
+	policyv1beta1.FlexVolume:            "flexVolume",
+	policyv1beta1.HostPath:              "hostPath",
+	policyv1beta1.EmptyDir:              "emptyDir",
+	policyv1beta1.GCEPersistentDisk:     "gcePersistentDisk",
+	policyv1beta1.AWSElasticBlockStore:  "awsElasticBlockStore",
+	policyv1beta1.GitRepo:               "gitRepo",
+	policyv1beta1.Secret:                "secret",
+	policyv1beta1.NFS:                   "nfs",
+	policyv1beta1.ISCSI:                 "iscsi",
+	policyv1beta1.Glusterfs:             "glusterfs",
+	policyv1beta1.PersistentVolumeClaim: "persistentVolumeClaim",
+	policyv1beta1.RBD:                   "rbd",
+	policyv1beta1.Cinder:                "cinder",
+	policyv1beta1.CephFS:                "cephfs",
+	policyv1beta1.DownwardAPI:           "downwardAPI",
+	policyv1beta1.FC:                    "fc",
+	policyv1beta1.ConfigMap:             "configMap",
+	policyv1beta1.VsphereVolume:         "vsphereVolume",
+	policyv1beta1.Quobyte:               "quobyte",
+	policyv1beta1.AzureDisk:             "azureDisk",
+	policyv1beta1.PhotonPersistentDisk:  "photonPersistentDisk",
+	policyv1beta1.StorageOS:             "storageos",
+	policyv1beta1.Projected:             "projected",
+	policyv1beta1.PortworxVolume:        "portworxVolume",
+	policyv1beta1.ScaleIO:               "scaleIO",
+	policyv1beta1.CSI:                   "csi",
+}
+
+// converter accumulates the rules of a PodSecurityPolicy
+type converter struct {
+	psp      policyv1beta1.PodSecurityPolicy
+	rules    []kyverno.Rule
+	warnings []string
+	// the defaults of the PSP are set by a mutate rule, in the security context of the pod,
+	// in the security context of the containers and in the annotations of the pod
+	podDefaults         map[string]interface{}
+	containerDefaults   map[string]interface{}
+	annotationsDefaults map[string]interface{}
+}
+
+// Convert returns the ClusterPolicy of the PodSecurityPolicy. Its validate rules check the pods as the admission of the PSP,
+// and its mutate rule sets the defaults of the PSP. The settings of the PSP that the patterns of the rules cannot express
+// are returned as warnings, they are not enforced by the policy.
+func Convert(psp policyv1beta1.PodSecurityPolicy) (*kyverno.ClusterPolicy, []string) {
+	c := &converter{
+		psp:                 psp,
+		podDefaults:         map[string]interface{}{},
+		containerDefaults:   map[string]interface{}{},
+		annotationsDefaults: map[string]interface{}{},
+	}
+	c.convertPrivileged()
+	c.convertHostNamespaces()
+	c.convertHostPorts()
+	c.convertVolumes()
+	c.convertCapabilities()
+	c.convertRunAsUser()
+	c.convertRunAsGroup()
+	c.convertSupplementalGroups()
+	c.convertFSGroup()
+	c.convertSELinux()
+	c.convertReadOnlyRootFilesystem()
+	c.convertPrivilegeEscalation()
+	c.convertSysctls()
+	c.convertProcMount()
+	c.convertProfiles()
+
+	var rules []kyverno.Rule
+	if overlay := c.defaultsOverlay(); overlay != nil {
+		rules = append(rules, kyverno.Rule{
+			Name:           "defaults",
+			MatchResources: matchPods(),
+			Mutation:       kyverno.Mutation{Overlay: overlay},
+		})
+	}
+	rules = append(rules, c.rules...)
+	if len(rules) == 0 {
+		c.warn("the PodSecurityPolicy allows all the pods, the policy has no rule")
+	}
+	policy := &kyverno.ClusterPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "kyverno.io/v1", Kind: "ClusterPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: PolicyPrefix + psp.Name},
+		Spec:       kyverno.Spec{Rules: rules},
+	}
+	return policy, c.warnings
+}
+
+func (c *converter) warn(format string, args ...interface{}) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+func matchPods() kyverno.MatchResources {
+	return kyverno.MatchResources{ResourceDescription: kyverno.ResourceDescription{Kinds: []string{"Pod"}}}
+}
+
+// validate adds a validate rule for each pattern, the pods must match all of them
+func (c *converter) validate(name, message string, patterns ...map[string]interface{}) {
+	for i, pattern := range patterns {
+		rule := kyverno.Rule{
+			Name:           name,
+			MatchResources: matchPods(),
+			Validation:     kyverno.Validation{Message: message, Pattern: pattern},
+		}
+		if i > 0 {
+			rule.Name = fmt.Sprintf("%s-%d", name, i+1)
+		}
+		c.rules = append(c.rules, rule)
+	}
+}
+
+// optional returns the fields with equality anchors, they are only checked if they are set
+func optional(fields map[string]interface{}) map[string]interface{} {
+	anchored := map[string]interface{}{}
+	for key, value := range fields {
+		anchored["=("+key+")"] = value
+	}
+	return anchored
+}
+
+// podSpecPattern returns the pattern of the fields of the spec of the pods
+func podSpecPattern(fields map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"spec": optional(fields)}
+}
+
+// containersPattern returns the pattern of the fields of the containers and of the init containers
+func containersPattern(fields map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers":        []interface{}{fields},
+			"=(initContainers)": []interface{}{fields},
+		},
+	}
+}
+
+// securityContextPattern returns the pattern of the fields of the security context of the pod and of the containers, if they are set
+func securityContextPattern(fields map[string]interface{}) map[string]interface{} {
+	pattern := containersPattern(map[string]interface{}{"=(securityContext)": optional(fields)})
+	pattern["spec"].(map[string]interface{})["=(securityContext)"] = optional(fields)
+	return pattern
+}
+
+// containerSecurityContextPattern returns the pattern of the fields of the security context of the containers, if they are set
+func containerSecurityContextPattern(fields map[string]interface{}) map[string]interface{} {
+	return containersPattern(map[string]interface{}{"=(securityContext)": optional(fields)})
+}
+
+func (c *converter) convertPrivileged() {
+	if c.psp.Spec.Privileged {
+		return
+	}
+	c.validate("privileged", "Privileged containers are not allowed.",
+		containerSecurityContextPattern(map[string]interface{}{"privileged": false}))
+}
+
+func (c *converter) convertHostNamespaces() {
+	fields := map[string]interface{}{}
+	if !c.psp.Spec.HostNetwork {
+		fields["hostNetwork"] = false
+	}
+	if !c.psp.Spec.HostPID {
+		fields["hostPID"] = false
+	}
+	if !c.psp.Spec.HostIPC {
+		fields["hostIPC"] = false
+	}
+	if len(fields) == 0 {
+		return
+	}
+	c.validate("host-namespaces", "Sharing the host namespaces is not allowed.", podSpecPattern(fields))
+}
+
+func (c *converter) convertHostPorts() {
+	if len(c.psp.Spec.HostPorts) == 0 {
+		c.validate("host-ports", "Host ports are not allowed.", containersPattern(map[string]interface{}{
+			"=(ports)": []interface{}{map[string]interface{}{"X(hostPort)": "null"}},
+		}))
+		return
+	}
+	var ranges []idRange
+	for _, r := range c.psp.Spec.HostPorts {
+		ranges = append(ranges, idRange{int64(r.Min), int64(r.Max)})
+	}
+	values, ok := rangePatterns(ranges, maxPort)
+	if !ok {
+		c.warn("hostPorts: several ranges of host ports %s cannot be converted, the host ports are not checked", formatRanges(ranges))
+		return
+	}
+	var patterns []map[string]interface{}
+	for _, value := range values {
+		patterns = append(patterns, containersPattern(map[string]interface{}{
+			"=(ports)": []interface{}{map[string]interface{}{"=(hostPort)": value}},
+		}))
+	}
+	c.validate("host-ports", fmt.Sprintf("The host ports must be in %s.", formatRanges(ranges)), patterns...)
+}
+
+func (c *converter) convertVolumes() {
+	allowed := map[policyv1beta1.FSType]bool{}
+	for _, volume := range c.psp.Spec.Volumes {
+		allowed[volume] = true
+	}
+	fields := map[string]interface{}{}
+	if !allowed[policyv1beta1.All] {
+		for volume, field := range volumeFields {
+			if !allowed[volume] {
+				fields["X("+field+")"] = "null"
+			}
+		}
+	}
+	var names []string
+	if allowed[policyv1beta1.All] || allowed[policyv1beta1.HostPath] {
+		for _, path := range c.psp.Spec.AllowedHostPaths {
+			names = append(names, strings.TrimSuffix(path.PathPrefix, "/")+"*")
+			if path.ReadOnly {
+				c.warn("allowedHostPaths: the read-only mounts of the host path %s are not checked", path.PathPrefix)
+			}
+		}
+		if len(names) != 0 {
+			fields["=(hostPath)"] = map[string]interface{}{"path": strings.Join(names, " | ")}
+		}
+	}
+	if allowed[policyv1beta1.All] || allowed[policyv1beta1.FlexVolume] {
+		if drivers := c.psp.Spec.AllowedFlexVolumes; len(drivers) != 0 {
+			var names []string
+			for _, driver := range drivers {
+				names = append(names, driver.Driver)
+			}
+			fields["=(flexVolume)"] = map[string]interface{}{"driver": strings.Join(names, " | ")}
+		}
+	}
+	if allowed[policyv1beta1.All] || allowed[policyv1beta1.CSI] {
+		if drivers := c.psp.Spec.AllowedCSIDrivers; len(drivers) != 0 {
+			var names []string
+			for _, driver := range drivers {
+				names = append(names, driver.Name)
+			}
+			fields["=(csi)"] = map[string]interface{}{"driver": strings.Join(names, " | ")}
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	message := "The volume type is not allowed."
+	if len(c.psp.Spec.Volumes) != 0 {
+		var types []string
+		for _, volume := range c.psp.Spec.Volumes {
+			types = append(types, string(volume))
+		}
+		message = fmt.Sprintf("The volume types must be in %s.", strings.Join(types, ", "))
+	}
+	c.validate("volumes", message, podSpecPattern(map[string]interface{}{"volumes": []interface{}{fields}}))
+}
+
+func (c *converter) convertCapabilities() {
+	spec := c.psp.Spec
+	if len(spec.DefaultAddCapabilities) != 0 {
+		c.warn("defaultAddCapabilities: the capabilities %v are not added to the containers", spec.DefaultAddCapabilities)
+	}
+	if len(spec.RequiredDropCapabilities) != 0 {
+		c.warn("requiredDropCapabilities: the drop of the capabilities %v is not checked", spec.RequiredDropCapabilities)
+	}
+	for _, capability := range spec.AllowedCapabilities {
+		if capability == "*" {
+			return
+		}
+	}
+	if len(spec.AllowedCapabilities) != 0 || len(spec.DefaultAddCapabilities) != 0 {
+		c.warn("allowedCapabilities: the added capabilities are not checked against %v", append(spec.AllowedCapabilities, spec.DefaultAddCapabilities...))
+		return
+	}
+	c.validate("capabilities", "Adding capabilities is not allowed.", containerSecurityContextPattern(map[string]interface{}{
+		"capabilities": map[string]interface{}{"X(add)": "null"},
+	}))
+}
+
+func (c *converter) convertRunAsUser() {
+	runAsUser := c.psp.Spec.RunAsUser
+	switch runAsUser.Rule {
+	case policyv1beta1.RunAsUserStrategyMustRunAs:
+		c.convertIDs("runAsUser", "run-as-user", "user", runAsUser.Ranges, true)
+	case policyv1beta1.RunAsUserStrategyMustRunAsNonRoot:
+		// the pod runs as non root, or all its containers
+		nonRoot := map[string]interface{}{"runAsNonRoot": true}
+		c.rules = append(c.rules, kyverno.Rule{
+			Name:           "run-as-non-root",
+			MatchResources: matchPods(),
+			Validation: kyverno.Validation{
+				Message: "Running as root is not allowed, set runAsNonRoot to true.",
+				AnyPattern: []interface{}{
+					map[string]interface{}{"spec": map[string]interface{}{
+						"securityContext":   nonRoot,
+						"containers":        []interface{}{map[string]interface{}{"=(securityContext)": optional(nonRoot)}},
+						"=(initContainers)": []interface{}{map[string]interface{}{"=(securityContext)": optional(nonRoot)}},
+					}},
+					map[string]interface{}{"spec": map[string]interface{}{
+						"containers":        []interface{}{map[string]interface{}{"securityContext": nonRoot}},
+						"=(initContainers)": []interface{}{map[string]interface{}{"securityContext": nonRoot}},
+					}},
+				},
+			},
+		})
+	}
+}
+
+func (c *converter) convertRunAsGroup() {
+	runAsGroup := c.psp.Spec.RunAsGroup
+	if runAsGroup == nil {
+		return
+	}
+	switch runAsGroup.Rule {
+	case policyv1beta1.RunAsGroupStrategyMustRunAs:
+		c.convertIDs("runAsGroup", "run-as-group", "group", runAsGroup.Ranges, true)
+	case policyv1beta1.RunAsGroupStrategyMayRunAs:
+		c.convertIDs("runAsGroup", "run-as-group", "group", runAsGroup.Ranges, false)
+	}
+}
+
+func (c *converter) convertSupplementalGroups() {
+	groups := c.psp.Spec.SupplementalGroups
+	if groups.Rule == policyv1beta1.SupplementalGroupsStrategyMustRunAs || groups.Rule == policyv1beta1.SupplementalGroupsStrategyMayRunAs {
+		c.warn("supplementalGroups: the supplemental groups are not checked against %s", formatRanges(idRanges(groups.Ranges)))
+	}
+}
+
+func (c *converter) convertFSGroup() {
+	fsGroup := c.psp.Spec.FSGroup
+	ranges := idRanges(fsGroup.Ranges)
+	switch fsGroup.Rule {
+	case policyv1beta1.FSGroupStrategyMustRunAs, policyv1beta1.FSGroupStrategyMayRunAs:
+		if fsGroup.Rule == policyv1beta1.FSGroupStrategyMustRunAs && len(ranges) != 0 {
+			c.podDefaults["+(fsGroup)"] = ranges[0].min
+		}
+		values, ok := rangePatterns(ranges, maxID)
+		if !ok {
+			c.warn("fsGroup: several ranges of groups %s cannot be converted, the fsGroup is not checked", formatRanges(ranges))
+			return
+		}
+		var patterns []map[string]interface{}
+		for _, value := range values {
+			patterns = append(patterns, podSpecPattern(map[string]interface{}{
+				"securityContext": optional(map[string]interface{}{"fsGroup": value}),
+			}))
+		}
+		c.validate("fs-group", fmt.Sprintf("The fsGroup must be in %s.", formatRanges(ranges)), patterns...)
+	}
+}
+
+// convertIDs checks the user or group IDs of the field of the security contexts,
+// the first ID of the ranges is set in the security context of the pod if it is a default
+func (c *converter) convertIDs(field, name, kind string, psp []policyv1beta1.IDRange, setDefault bool) {
+	ranges := idRanges(psp)
+	if setDefault && len(ranges) != 0 {
+		c.podDefaults["+("+field+")"] = ranges[0].min
+	}
+	values, ok := rangePatterns(ranges, maxID)
+	if !ok {
+		c.warn("%s: several ranges of %s IDs %s cannot be converted, the %s IDs are not checked", field, kind, formatRanges(ranges), kind)
+		return
+	}
+	var patterns []map[string]interface{}
+	for _, value := range values {
+		patterns = append(patterns, securityContextPattern(map[string]interface{}{field: value}))
+	}
+	c.validate(name, fmt.Sprintf("The %s IDs must be in %s.", kind, formatRanges(ranges)), patterns...)
+}
+
+func (c *converter) convertSELinux() {
+	seLinux := c.psp.Spec.SELinux
+	if seLinux.Rule != policyv1beta1.SELinuxStrategyMustRunAs || seLinux.SELinuxOptions == nil {
+		return
+	}
+	options := map[string]interface{}{}
+	if seLinux.SELinuxOptions.User != "" {
+		options["user"] = seLinux.SELinuxOptions.User
+	}
+	if seLinux.SELinuxOptions.Role != "" {
+		options["role"] = seLinux.SELinuxOptions.Role
+	}
+	if seLinux.SELinuxOptions.Type != "" {
+		options["type"] = seLinux.SELinuxOptions.Type
+	}
+	if seLinux.SELinuxOptions.Level != "" {
+		options["level"] = seLinux.SELinuxOptions.Level
+	}
+	if len(options) == 0 {
+		return
+	}
+	c.podDefaults["+(seLinuxOptions)"] = options
+	c.validate("se-linux", "The SELinux options are not allowed.", securityContextPattern(map[string]interface{}{"seLinuxOptions": optional(options)}))
+}
+
+func (c *converter) convertReadOnlyRootFilesystem() {
+	if !c.psp.Spec.ReadOnlyRootFilesystem {
+		return
+	}
+	c.containerDefaults["+(readOnlyRootFilesystem)"] = true
+	c.validate("read-only-root-filesystem", "The root filesystem of the containers must be read-only.",
+		containerSecurityContextPattern(map[string]interface{}{"readOnlyRootFilesystem": true}))
+}
+
+func (c *converter) convertPrivilegeEscalation() {
+	spec := c.psp.Spec
+	if spec.DefaultAllowPrivilegeEscalation != nil {
+		c.containerDefaults["+(allowPrivilegeEscalation)"] = *spec.DefaultAllowPrivilegeEscalation
+	}
+	if spec.AllowPrivilegeEscalation == nil || *spec.AllowPrivilegeEscalation {
+		return
+	}
+	c.containerDefaults["+(allowPrivilegeEscalation)"] = false
+	c.validate("privilege-escalation", "Privilege escalation is not allowed.",
+		containerSecurityContextPattern(map[string]interface{}{"allowPrivilegeEscalation": false}))
+}
+
+func (c *converter) convertSysctls() {
+	spec := c.psp.Spec
+	if len(spec.ForbiddenSysctls) == 1 && spec.ForbiddenSysctls[0] == "*" {
+		c.validate("sysctls", "Sysctls are not allowed.", podSpecPattern(map[string]interface{}{
+			"securityContext": map[string]interface{}{"X(sysctls)": "null"},
+		}))
+		return
+	}
+	if len(spec.ForbiddenSysctls) != 0 {
+		c.warn("forbiddenSysctls: the sysctls are not checked against %v", spec.ForbiddenSysctls)
+	}
+	if len(spec.AllowedUnsafeSysctls) != 0 {
+		c.warn("allowedUnsafeSysctls: the sysctls are not checked against %v", spec.AllowedUnsafeSysctls)
+	}
+}
+
+func (c *converter) convertProcMount() {
+	types := c.psp.Spec.AllowedProcMountTypes
+	if len(types) == 0 {
+		types = []v1.ProcMountType{v1.DefaultProcMount}
+	}
+	var names []string
+	for _, t := range types {
+		names = append(names, string(t))
+	}
+	c.validate("proc-mount", fmt.Sprintf("The proc mount types must be in %s.", strings.Join(names, ", ")),
+		containerSecurityContextPattern(map[string]interface{}{"procMount": strings.Join(names, " | ")}))
+}
+
+func (c *converter) convertProfiles() {
+	annotations := c.psp.Annotations
+	if profile, ok := annotations[SeccompDefaultProfileAnnotation]; ok {
+		c.annotationsDefaults["+("+SeccompPodAnnotation+")"] = profile
+	}
+	if allowed, ok := annotations[SeccompAllowedProfilesAnnotation]; ok && allowed != "*" {
+		var profiles []string
+		for _, profile := range strings.Split(allowed, ",") {
+			profiles = append(profiles, strings.TrimSpace(profile))
+		}
+		c.validate("seccomp", fmt.Sprintf("The seccomp profiles must be in %s.", strings.Join(profiles, ", ")), map[string]interface{}{
+			"metadata": optional(map[string]interface{}{
+				"annotations": optional(map[string]interface{}{SeccompPodAnnotation: strings.Join(profiles, " | ")}),
+			}),
+		})
+		c.warn("%s: the seccomp profiles of the annotations of the containers are not checked", SeccompAllowedProfilesAnnotation)
+	}
+	for _, annotation := range []string{AppArmorAllowedProfilesAnnotation, AppArmorDefaultProfileAnnotation} {
+		if _, ok := annotations[annotation]; ok {
+			c.warn("%s: the AppArmor profiles of the annotations of the containers are not checked", annotation)
+		}
+	}
+}
+
+// defaultsOverlay returns the overlay of the defaults, nil if the PSP has no default
+func (c *converter) defaultsOverlay() map[string]interface{} {
+	overlay := map[string]interface{}{}
+	spec := map[string]interface{}{}
+	if len(c.podDefaults) != 0 {
+		spec["securityContext"] = c.podDefaults
+	}
+	if len(c.containerDefaults) != 0 {
+		// the init containers are not mutated, an overlay of the init containers adds them to the pods without init containers
+		spec["containers"] = []interface{}{map[string]interface{}{"securityContext": c.containerDefaults}}
+	}
+	if len(spec) != 0 {
+		overlay["spec"] = spec
+	}
+	if len(c.annotationsDefaults) != 0 {
+		overlay["metadata"] = map[string]interface{}{"annotations": c.annotationsDefaults}
+	}
+	if len(overlay) == 0 {
+		return nil
+	}
+	return overlay
+}
+
+// idRange is a range of IDs or of ports, with its bounds
+type idRange struct {
+	min, max int64
+}
+
+func idRanges(ranges []policyv1beta1.IDRange) []idRange {
+	var converted []idRange
+	for _, r := range ranges {
+		converted = append(converted, idRange{r.Min, r.Max})
+	}
+	return converted
+}
+
+// rangePatterns returns the patterns of the values of the ranges, the values must match all the patterns.
+// The ranges of a single value are alternatives of a pattern, a range of several values is checked with a pattern
+// of its minimum and a pattern of its maximum, so it cannot be an alternative of other ranges.
+// The bounds from 0 and up to the highest value are not checked.
+func rangePatterns(ranges []idRange, highest int64) ([]string, bool) {
+	if len(ranges) == 0 {
+		return nil, true
+	}
+	if len(ranges) == 1 && ranges[0].min != ranges[0].max {
+		var patterns []string
+		if ranges[0].min > 0 {
+			patterns = append(patterns, fmt.Sprintf(">=%d", ranges[0].min))
+		}
+		if ranges[0].max < highest {
+			patterns = append(patterns, fmt.Sprintf("<=%d", ranges[0].max))
+		}
+		return patterns, true
+	}
+	var values []string
+	for _, r := range ranges {
+		if r.min != r.max {
+			return nil, false
+		}
+		values = append(values, strconv.FormatInt(r.min, 10))
+	}
+	return []string{strings.Join(values, " | ")}, true
+}
+
+func formatRanges(ranges []idRange) string {
+	var formatted []string
+	for _, r := range ranges {
+		if r.min == r.max {
+			formatted = append(formatted, strconv.FormatInt(r.min, 10))
+		} else {
+			formatted = append(formatted, fmt.Sprintf("%d-%d", r.min, r.max))
+		}
+	}
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
@@ -0,0 +1,238 @@
+package psp
+
+import (
+	"encoding/json"
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/engine"
+	"github.com/nirmata/kyverno/pkg/engine/context"
+	policyvalidate "github.com/nirmata/kyverno/pkg/engine/policy"
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// restricted is the restricted PodSecurityPolicy of the examples of Kubernetes
+func restricted() policyv1beta1.PodSecurityPolicy {
+	allowPrivilegeEscalation := false
+	return policyv1beta1.PodSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "restricted",
+			Annotations: map[string]string{
+				SeccompAllowedProfilesAnnotation:  "docker/default,runtime/default",
+				SeccompDefaultProfileAnnotation:   "runtime/default",
+				AppArmorAllowedProfilesAnnotation: "runtime/default",
+			},
+		},
+		Spec: policyv1beta1.PodSecurityPolicySpec{
+			AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+			RequiredDropCapabilities: []v1.Capability{"ALL"},
+			Volumes:                  []policyv1beta1.FSType{"configMap", "emptyDir", "projected", "secret", "downwardAPI", "persistentVolumeClaim"},
+			RunAsUser:                policyv1beta1.RunAsUserStrategyOptions{Rule: policyv1beta1.RunAsUserStrategyMustRunAsNonRoot},
+			SELinux:                  policyv1beta1.SELinuxStrategyOptions{Rule: policyv1beta1.SELinuxStrategyRunAsAny},
+			SupplementalGroups: policyv1beta1.SupplementalGroupsStrategyOptions{
+				Rule:   policyv1beta1.SupplementalGroupsStrategyMustRunAs,
+				Ranges: []policyv1beta1.IDRange{{Min: 1, Max: 65535}},
+			},
+			FSGroup: policyv1beta1.FSGroupStrategyOptions{
+				Rule:   policyv1beta1.FSGroupStrategyMustRunAs,
+				Ranges: []policyv1beta1.IDRange{{Min: 1, Max: 65535}},
+			},
+		},
+	}
+}
+
+// convert returns the policy of the PSP as it is loaded from its JSON
+func convert(t *testing.T, psp policyv1beta1.PodSecurityPolicy) (kyverno.ClusterPolicy, []string) {
+	converted, warnings := Convert(psp)
+	raw, err := json.Marshal(converted)
+	assert.NilError(t, err)
+	var policy kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal(raw, &policy))
+	return policy, warnings
+}
+
+// apply mutates the pod with the policy, and returns the mutated pod and the rules it does not match
+func apply(t *testing.T, policy kyverno.ClusterPolicy, pod string) (unstructured.Unstructured, []string) {
+	raw, err := yaml.ToJSON([]byte(pod))
+	assert.NilError(t, err)
+	resource := unstructured.Unstructured{}
+	assert.NilError(t, resource.UnmarshalJSON(raw))
+	ctx := context.NewContext()
+	assert.NilError(t, ctx.AddResource(raw))
+
+	mutated := engine.Mutate(engine.PolicyContext{Policy: policy, NewResource: resource, Context: ctx})
+	assert.Assert(t, mutated.IsSuccesful())
+	resource = mutated.PatchedResource
+	raw, err = resource.MarshalJSON()
+	assert.NilError(t, err)
+	ctx = context.NewContext()
+	assert.NilError(t, ctx.AddResource(raw))
+	var failed []string
+	for _, rule := range engine.Validate(engine.PolicyContext{Policy: policy, NewResource: resource, Context: ctx}).PolicyResponse.Rules {
+		if !rule.Success {
+			failed = append(failed, rule.Name)
+		}
+	}
+	return resource, failed
+}
+
+func Test_ConvertRestricted(t *testing.T) {
+	policy, warnings := convert(t, restricted())
+	assert.NilError(t, policyvalidate.Validate(policy))
+	assert.Equal(t, policy.Name, "psp-restricted")
+	var names []string
+	for _, rule := range policy.Spec.Rules {
+		names = append(names, rule.Name)
+	}
+	assert.DeepEqual(t, names, []string{"defaults", "privileged", "host-namespaces", "host-ports", "volumes", "capabilities",
+		"run-as-non-root", "fs-group", "fs-group-2", "privilege-escalation", "proc-mount", "seccomp"})
+	assert.DeepEqual(t, warnings, []string{
+		"requiredDropCapabilities: the drop of the capabilities [ALL] is not checked",
+		"supplementalGroups: the supplemental groups are not checked against [1-65535]",
+		"seccomp.security.alpha.kubernetes.io/allowedProfileNames: the seccomp profiles of the annotations of the containers are not checked",
+		"apparmor.security.beta.kubernetes.io/allowedProfileNames: the AppArmor profiles of the annotations of the containers are not checked",
+	})
+
+	// the defaults of the PSP are set
+	pod, failed := apply(t, policy, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  securityContext:
+    runAsNonRoot: true
+  volumes:
+  - name: config
+    configMap:
+      name: web
+  containers:
+  - name: web
+    image: nginx
+    ports:
+    - containerPort: 80
+`)
+	assert.Equal(t, len(failed), 0, failed)
+	fsGroup, _, _ := unstructured.NestedInt64(pod.Object, "spec", "securityContext", "fsGroup")
+	assert.Equal(t, fsGroup, int64(1))
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+	allowPrivilegeEscalation, _, _ := unstructured.NestedBool(containers[0].(map[string]interface{}), "securityContext", "allowPrivilegeEscalation")
+	assert.Equal(t, allowPrivilegeEscalation, false)
+	assert.Equal(t, pod.GetAnnotations()[SeccompPodAnnotation], "runtime/default")
+
+	_, failed = apply(t, policy, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: privileged
+  annotations:
+    seccomp.security.alpha.kubernetes.io/pod: unconfined
+spec:
+  hostNetwork: true
+  securityContext:
+    fsGroup: 0
+  volumes:
+  - name: host
+    hostPath:
+      path: /var/run/docker.sock
+  initContainers:
+  - name: init
+    image: busybox
+    securityContext:
+      privileged: true
+      runAsNonRoot: true
+  containers:
+  - name: web
+    image: nginx
+    ports:
+    - containerPort: 80
+      hostPort: 80
+    securityContext:
+      allowPrivilegeEscalation: true
+      capabilities:
+        add:
+        - NET_ADMIN
+`)
+	assert.DeepEqual(t, failed, []string{"privileged", "host-namespaces", "host-ports", "volumes", "capabilities",
+		"run-as-non-root", "fs-group", "privilege-escalation", "seccomp"})
+}
+
+func Test_ConvertRanges(t *testing.T) {
+	psp := policyv1beta1.PodSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "ranges"},
+		Spec: policyv1beta1.PodSecurityPolicySpec{
+			Privileged:             true,
+			HostNetwork:            true,
+			HostPID:                true,
+			HostIPC:                true,
+			HostPorts:              []policyv1beta1.HostPortRange{{Min: 80, Max: 80}, {Min: 443, Max: 443}},
+			Volumes:                []policyv1beta1.FSType{"*"},
+			AllowedHostPaths:       []policyv1beta1.AllowedHostPath{{PathPrefix: "/var/log/", ReadOnly: true}},
+			AllowedCapabilities:    []v1.Capability{"*"},
+			RunAsUser:              policyv1beta1.RunAsUserStrategyOptions{Rule: policyv1beta1.RunAsUserStrategyMustRunAs, Ranges: []policyv1beta1.IDRange{{Min: 1000, Max: 2000}}},
+			RunAsGroup:             &policyv1beta1.RunAsGroupStrategyOptions{Rule: policyv1beta1.RunAsGroupStrategyMayRunAs, Ranges: []policyv1beta1.IDRange{{Min: 1, Max: 10}, {Min: 20, Max: 30}}},
+			ReadOnlyRootFilesystem: true,
+			ForbiddenSysctls:       []string{"*"},
+			AllowedProcMountTypes:  []v1.ProcMountType{v1.DefaultProcMount, v1.UnmaskedProcMount},
+		},
+	}
+	policy, warnings := convert(t, psp)
+	assert.NilError(t, policyvalidate.Validate(policy))
+	assert.DeepEqual(t, warnings, []string{
+		"allowedHostPaths: the read-only mounts of the host path /var/log/ are not checked",
+		"runAsGroup: several ranges of group IDs [1-10, 20-30] cannot be converted, the group IDs are not checked",
+	})
+
+	pod, failed := apply(t, policy, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  volumes:
+  - name: logs
+    hostPath:
+      path: /var/log/web
+  containers:
+  - name: web
+    image: nginx
+    ports:
+    - containerPort: 443
+      hostPort: 443
+`)
+	assert.Equal(t, len(failed), 0, failed)
+	runAsUser, _, _ := unstructured.NestedInt64(pod.Object, "spec", "securityContext", "runAsUser")
+	assert.Equal(t, runAsUser, int64(1000))
+
+	_, failed = apply(t, policy, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  securityContext:
+    runAsUser: 1000
+    sysctls:
+    - name: net.core.somaxconn
+      value: "1024"
+  volumes:
+  - name: root
+    hostPath:
+      path: /etc
+  containers:
+  - name: web
+    image: nginx
+    ports:
+    - containerPort: 8080
+      hostPort: 8080
+    securityContext:
+      runAsUser: 3000
+      readOnlyRootFilesystem: false
+`)
+	assert.DeepEqual(t, failed, []string{"host-ports", "volumes", "run-as-user-2", "read-only-root-filesystem", "sysctls"})
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Limiter is a token bucket limiting the writes of the generators to the API server,
+// a nil Limiter does not limit the writes
+type Limiter struct {
+	limiter *rate.Limiter
+}
+
+// NewLimiter returns a limiter allowing qps writes per second on average and bursts of burst writes,
+// nil if qps is not positive
+func NewLimiter(qps float64, burst int) *Limiter {
+	if qps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{limiter: rate.NewLimiter(rate.Limit(qps), burst)}
+}
+
+// Wait blocks until a write is allowed, and returns the time waited
+func (l *Limiter) Wait() time.Duration {
+	if l == nil {
+		return 0
+	}
+	start := time.Now()
+	// the background context never expires, Wait fails only if the burst is lower than 1
+	_ = l.limiter.Wait(context.Background())
+	return time.Since(start)
+}
+
+// NewQueueRateLimiter returns the rate limiter of the retries of a workqueue: an item is retried after baseDelay
+// doubled on each failure up to maxDelay, and all the retries are limited to qps per second with bursts of burst,
+// unlimited if qps is not positive. workqueue.DefaultControllerRateLimiter uses 5ms, 1000s, 10 and 100.
+func NewQueueRateLimiter(baseDelay, maxDelay time.Duration, qps float64, burst int) workqueue.RateLimiter {
+	itemLimiter := workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay)
+	if qps <= 0 {
+		return itemLimiter
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return workqueue.NewMaxOfRateLimiter(
+		itemLimiter,
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+	)
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func Test_NewLimiter_Disabled(t *testing.T) {
+	l := NewLimiter(0, 10)
+	assert.Assert(t, l == nil)
+	assert.Equal(t, l.Wait(), time.Duration(0))
+}
+
+func Test_Limiter_Wait(t *testing.T) {
+	l := NewLimiter(20, 2)
+	start := time.Now()
+	// the burst is allowed, the next write waits for a token
+	l.Wait()
+	l.Wait()
+	assert.Assert(t, time.Since(start) < 25*time.Millisecond)
+	l.Wait()
+	assert.Assert(t, time.Since(start) >= 40*time.Millisecond)
+}
+
+func Test_NewQueueRateLimiter(t *testing.T) {
+	l := NewQueueRateLimiter(10*time.Millisecond, 50*time.Millisecond, 0, 0)
+	// the delay of an item doubles on each failure up to the max delay
+	assert.Equal(t, l.When("policy"), 10*time.Millisecond)
+	assert.Equal(t, l.When("policy"), 20*time.Millisecond)
+	assert.Equal(t, l.When("policy"), 40*time.Millisecond)
+	assert.Equal(t, l.When("policy"), 50*time.Millisecond)
+	assert.Equal(t, l.NumRequeues("policy"), 4)
+	// the delays are per item
+	assert.Equal(t, l.When("other"), 10*time.Millisecond)
+	l.Forget("policy")
+	assert.Equal(t, l.When("policy"), 10*time.Millisecond)
+
+	// the bucket delays the retries once the burst is used
+	l = NewQueueRateLimiter(time.Millisecond, time.Millisecond, 1, 2)
+	assert.Equal(t, l.When("a"), time.Millisecond)
+	assert.Equal(t, l.When("b"), time.Millisecond)
+	assert.Assert(t, l.When("c") > 500*time.Millisecond)
+}
@@ -0,0 +1,476 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nirmata/kyverno/pkg/engine/log"
+)
+
+// media types of the manifests
+const (
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// defaultPlatform is the platform selected in a multi-platform image
+const (
+	defaultOS           = "linux"
+	defaultArchitecture = "amd64"
+)
+
+// dockerHubRegistry is the host of the docker.io registry API
+const dockerHubRegistry = "registry-1.docker.io"
+
+// maxResponseSize is the maximum size of the responses of the registries, the manifests and the blobs are kept in memory
+const maxResponseSize = 64 << 20
+
+// Interface fetches the image data from the registry
+type Interface interface {
+	FetchImageData(image string) (*ImageData, error)
+}
+
+// ImageData is the data of an image fetched from its registry
+type ImageData struct {
+	// Image is the image reference
+	Image string `json:"image"`
+	// ResolvedImage is the image reference with the digest of the manifest
+	ResolvedImage string `json:"resolvedImage"`
+	Registry      string `json:"registry"`
+	Repository    string `json:"repository"`
+	Identifier    string `json:"identifier"`
+	// Manifest is the image manifest, the layers are listed in manifest.layers
+	Manifest map[string]interface{} `json:"manifest"`
+	// ConfigData is the image configuration, e.g. configData.config.User
+	ConfigData map[string]interface{} `json:"configData"`
+}
+
+// Client fetches the image data from the registries with the Docker Registry HTTP API V2,
+// the registries are accessed with the credentials of the keychain, or anonymously if it has none
+// the repositories of the registries with a mirror are fetched from the mirror
+type Client struct {
+	httpClient *http.Client
+	keychain   Keychain
+	mirrors    map[string]Mirror
+	// offline is true if the registries without mirror must not be contacted
+	offline bool
+	log     log.Logger
+}
+
+// NewClient returns a registry client, the keychain is used for all the requests and can be nil
+// the mirrors are the mirrors per registry host and can be nil
+func NewClient(httpClient *http.Client, keychain Keychain, mirrors map[string]Mirror) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Client{httpClient: httpClient, keychain: keychain, mirrors: mirrors}
+}
+
+// SetLogger sets the logger of the client, the logs are discarded by default
+func (c *Client) SetLogger(logger log.Logger) {
+	c.log = logger
+}
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Platform  *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	} `json:"platform,omitempty"`
+}
+
+// FetchImageData fetches the manifest and the configuration of the image
+// the manifest of the default platform is selected for multi-platform images
+func (c *Client) FetchImageData(image string) (*ImageData, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return nil, err
+	}
+	body, mediaType, digest, err := c.getManifest(ref, ref.Identifier(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if mediaType == mediaTypeDockerManifestList || mediaType == mediaTypeOCIIndex {
+		var index struct {
+			Manifests []descriptor `json:"manifests"`
+		}
+		if err := json.Unmarshal(body, &index); err != nil {
+			return nil, fmt.Errorf("failed to decode the manifest list of %s: %v", image, err)
+		}
+		platformDigest, err := selectPlatform(index.Manifests)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select the manifest of %s: %v", image, err)
+		}
+		if body, _, digest, err = c.getManifest(ref, platformDigest, nil); err != nil {
+			return nil, err
+		}
+	}
+	var manifest map[string]interface{}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode the manifest of %s: %v", image, err)
+	}
+	var config struct {
+		Config descriptor `json:"config"`
+	}
+	if err := json.Unmarshal(body, &config); err != nil || config.Config.Digest == "" {
+		return nil, fmt.Errorf("the manifest of %s does not reference a configuration", image)
+	}
+	configBody, err := c.get(ref, "blobs/"+config.Config.Digest, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var configData map[string]interface{}
+	if err := json.Unmarshal(configBody, &configData); err != nil {
+		return nil, fmt.Errorf("failed to decode the configuration of %s: %v", image, err)
+	}
+	resolved := ref
+	resolved.Digest = digest
+	return &ImageData{
+		Image:         image,
+		ResolvedImage: resolved.String(),
+		Registry:      ref.Registry,
+		Repository:    ref.Repository,
+		Identifier:    ref.Identifier(),
+		Manifest:      manifest,
+		ConfigData:    configData,
+	}, nil
+}
+
+// FetchManifest returns the manifest of the image and its digest, a manifest list is returned as is
+// the credentials of the keychain, if not nil, are used before those of the client
+func (c *Client) FetchManifest(image string, keychain Keychain) ([]byte, string, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return nil, "", err
+	}
+	body, _, digest, err := c.getManifest(ref, ref.Identifier(), keychain)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, digest, nil
+}
+
+// FetchBlob returns the blob of the repository of the image, the digest of the blob is verified
+func (c *Client) FetchBlob(image, digest string, keychain Keychain) ([]byte, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.get(ref, "blobs/"+digest, "", keychain)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyDigest(digest, body); err != nil {
+		return nil, fmt.Errorf("the blob %s of %s: %v", digest, image, err)
+	}
+	return body, nil
+}
+
+// Referrer is an artifact that references a manifest, e.g. a signature
+type Referrer struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType"`
+	Digest       string            `json:"digest"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// FetchReferrers returns the referrers of the artifact type of the image, the image must have a digest
+// the referrers are fetched with the referrers API, or from the index with the tag sha256-<hex> if the registry does not support it
+func (c *Client) FetchReferrers(image, artifactType string, keychain Keychain) ([]Referrer, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Digest == "" {
+		return nil, fmt.Errorf("the image %s has no digest", image)
+	}
+	body, err := c.get(ref, fmt.Sprintf("referrers/%s?artifactType=%s", ref.Digest, url.QueryEscape(artifactType)), mediaTypeOCIIndex, keychain)
+	if err != nil {
+		c.log.V(4).Infof("failed to fetch the referrers of %s with the referrers API, using the referrers tag: %v", image, err)
+		if body, _, _, err = c.getManifest(ref, strings.Replace(ref.Digest, ":", "-", 1), keychain); err != nil {
+			return nil, fmt.Errorf("failed to fetch the referrers of %s: %v", image, err)
+		}
+	}
+	var index struct {
+		Manifests []Referrer `json:"manifests"`
+	}
+	if err := json.Unmarshal(body, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode the referrers of %s: %v", image, err)
+	}
+	var referrers []Referrer
+	for _, r := range index.Manifests {
+		if r.ArtifactType == artifactType {
+			referrers = append(referrers, r)
+		}
+	}
+	return referrers, nil
+}
+
+// selectPlatform returns the digest of the manifest of the default platform
+func selectPlatform(manifests []descriptor) (string, error) {
+	for _, m := range manifests {
+		if m.Platform != nil && m.Platform.OS == defaultOS && m.Platform.Architecture == defaultArchitecture {
+			return m.Digest, nil
+		}
+	}
+	return "", fmt.Errorf("no manifest for platform %s/%s", defaultOS, defaultArchitecture)
+}
+
+// getManifest returns the manifest, its media type and its digest
+func (c *Client) getManifest(ref ImageReference, identifier string, keychain Keychain) ([]byte, string, string, error) {
+	ref, err := c.mirror(ref)
+	if err != nil {
+		return nil, "", "", err
+	}
+	accept := strings.Join([]string{mediaTypeDockerManifest, mediaTypeDockerManifestList, mediaTypeOCIManifest, mediaTypeOCIIndex}, ",")
+	req, err := c.newRequest(http.MethodGet, ref, "manifests/"+identifier, accept, nil)
+	if err != nil {
+		return nil, "", "", err
+	}
+	resp, body, err := c.do(req, ref, keychain)
+	if err != nil {
+		return nil, "", "", err
+	}
+	// the digest of the registry, and the digest of the reference, must be the digest of the manifest
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+	} else if err := verifyDigest(digest, body); err != nil {
+		return nil, "", "", fmt.Errorf("the manifest %s of %s: %v", identifier, ref.Repository, err)
+	}
+	if strings.Contains(identifier, ":") {
+		if err := verifyDigest(identifier, body); err != nil {
+			return nil, "", "", fmt.Errorf("the manifest %s of %s: %v", identifier, ref.Repository, err)
+		}
+	}
+	mediaType := resp.Header.Get("Content-Type")
+	if i := strings.Index(mediaType, ";"); i != -1 {
+		mediaType = mediaType[:i]
+	}
+	if mediaType == "" || mediaType == "application/json" {
+		// the media type can be in the manifest
+		var m struct {
+			MediaType string `json:"mediaType"`
+		}
+		if err := json.Unmarshal(body, &m); err == nil {
+			mediaType = m.MediaType
+		}
+	}
+	return body, mediaType, digest, nil
+}
+
+func (c *Client) get(ref ImageReference, path, accept string, keychain Keychain) ([]byte, error) {
+	ref, err := c.mirror(ref)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(http.MethodGet, ref, path, accept, nil)
+	if err != nil {
+		return nil, err
+	}
+	_, body, err := c.do(req, ref, keychain)
+	return body, err
+}
+
+func (c *Client) newRequest(method string, ref ImageReference, path, accept string, body []byte) (*http.Request, error) {
+	host := ref.Registry
+	if host == DefaultRegistry {
+		host = dockerHubRegistry
+	}
+	// the body of the request can be read again, to send it again with the credentials
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("https://%s/v2/%s/%s", host, ref.Repository, path), reader)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	return req, nil
+}
+
+// do sends the request, if the registry requires authentication the request is sent again
+// - with the credentials of the registry, for a basic challenge
+// - with a token requested with the credentials of the registry, or anonymously, for a bearer challenge
+func (c *Client) do(req *http.Request, ref ImageReference, keychain Keychain) (*http.Response, []byte, error) {
+	resp, body, err := c.send(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		creds := c.credentials(ref.Registry, keychain)
+		challenge := resp.Header.Get("WWW-Authenticate")
+		if strings.HasPrefix(strings.ToLower(challenge), "basic") {
+			if creds == nil {
+				return nil, nil, fmt.Errorf("failed to authenticate to registry %s: no credentials", ref.Registry)
+			}
+			req.SetBasicAuth(creds.Username, creds.Password)
+		} else {
+			token, err := c.getToken(challenge, ref, creds)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to authenticate to registry %s: %v", ref.Registry, err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, nil, err
+			}
+		}
+		if resp, body, err = c.send(req); err != nil {
+			return nil, nil, err
+		}
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, nil, fmt.Errorf("failed to %s %s: %s", strings.ToLower(req.Method), req.URL.String(), resp.Status)
+	}
+	return resp, body, nil
+}
+
+func (c *Client) send(req *http.Request) (*http.Response, []byte, error) {
+	c.log.V(4).Infof("registry request %s", req.URL.String())
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(body) > maxResponseSize {
+		return nil, nil, fmt.Errorf("the response of %s exceeds %d bytes", req.URL.String(), maxResponseSize)
+	}
+	return resp, body, nil
+}
+
+// verifyDigest checks that the sha256 or sha512 digest is the digest of the data
+func verifyDigest(digest string, data []byte) error {
+	var computed string
+	switch {
+	case strings.HasPrefix(digest, "sha256:"):
+		computed = fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	case strings.HasPrefix(digest, "sha512:"):
+		computed = fmt.Sprintf("sha512:%x", sha512.Sum512(data))
+	default:
+		return fmt.Errorf("unsupported digest %s", digest)
+	}
+	if computed != digest {
+		return fmt.Errorf("the digest %s does not match the content %s", digest, computed)
+	}
+	return nil
+}
+
+// credentials returns the credentials of the registry of the keychain, else of the keychain of the client
+func (c *Client) credentials(registry string, keychain Keychain) *Credentials {
+	for _, k := range []Keychain{keychain, c.keychain} {
+		if k == nil {
+			continue
+		}
+		creds, err := k.Resolve(registry)
+		if err != nil {
+			c.log.V(4).Infof("failed to resolve the credentials of registry %s: %v", registry, err)
+			continue
+		}
+		if creds != nil {
+			return creds
+		}
+	}
+	return nil
+}
+
+// getToken requests a token to pull the repository, with the credentials if not nil, else anonymously
+// the challenge is: Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"
+func (c *Client) getToken(challenge string, ref ImageReference, creds *Credentials) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+	params := parseChallenge(challenge[len("bearer "):])
+	realm, ok := params["realm"]
+	if !ok {
+		return "", fmt.Errorf("no realm in authentication challenge %q", challenge)
+	}
+	tokenURL, err := url.Parse(realm)
+	if err != nil {
+		return "", err
+	}
+	query := tokenURL.Query()
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+	scope, ok := params["scope"]
+	if !ok {
+		scope = fmt.Sprintf("repository:%s:pull", ref.Repository)
+	}
+	query.Set("scope", scope)
+	tokenURL.RawQuery = query.Encode()
+	req, err := http.NewRequest(http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if creds != nil {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+	resp, body, err := c.send(req)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get token: %s", resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", err
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	if token.AccessToken != "" {
+		return token.AccessToken, nil
+	}
+	return "", fmt.Errorf("empty token")
+}
+
+// parseChallenge parses the key="value" parameters of the challenge, the values can contain commas
+func parseChallenge(challenge string) map[string]string {
+	params := map[string]string{}
+	var param strings.Builder
+	quoted := false
+	addParam := func() {
+		kv := strings.SplitN(strings.TrimSpace(param.String()), "=", 2)
+		if len(kv) == 2 {
+			params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+		param.Reset()
+	}
+	for _, r := range challenge {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			addParam()
+			continue
+		}
+		param.WriteRune(r)
+	}
+	addParam()
+	return params
+}
@@ -0,0 +1,195 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// amdManifest is the manifest of the amd64 image of test/app, emptyManifest the manifest of the other repositories
+const (
+	amdManifest   = `{"config": {"digest": "sha256:config"}, "layers": [{"digest": "sha256:layer1"}]}`
+	emptyManifest = `{}`
+)
+
+var (
+	amdDigest   = testDigest(amdManifest)
+	emptyDigest = testDigest(emptyManifest)
+)
+
+func testDigest(data string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(data)))
+}
+
+// newTestRegistry serves a multi-platform image test/app:v1 that requires a token
+func newTestRegistry(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("scope") {
+		case "repository:test/app:pull":
+			fmt.Fprint(w, `{"token": "secret"}`)
+		case "repository:test/private:pull":
+			// the token of the private repository requires credentials
+			if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"access_token": "private-secret"}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	mux.HandleFunc("/v2/test/private/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer private-secret" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:test/private:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", emptyDigest)
+		fmt.Fprint(w, emptyManifest)
+	})
+	mux.HandleFunc("/v2/test/basic/", func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "password" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", emptyDigest)
+		fmt.Fprint(w, emptyManifest)
+	})
+	mux.HandleFunc("/v2/test/app/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:test/app:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch strings.TrimPrefix(r.URL.Path, "/v2/test/app/") {
+		case "manifests/v1":
+			w.Header().Set("Content-Type", mediaTypeDockerManifestList)
+			fmt.Fprintf(w, `{"manifests": [
+				{"digest": "sha256:arm", "platform": {"os": "linux", "architecture": "arm64"}},
+				{"digest": "%s", "platform": {"os": "linux", "architecture": "amd64"}}
+			]}`, amdDigest)
+		case "manifests/" + amdDigest:
+			w.Header().Set("Content-Type", mediaTypeDockerManifest)
+			w.Header().Set("Docker-Content-Digest", amdDigest)
+			fmt.Fprint(w, amdManifest)
+		case "manifests/sha256:tampered":
+			// the registry returns a manifest that is not the manifest of the digest
+			w.Header().Set("Content-Type", mediaTypeDockerManifest)
+			fmt.Fprint(w, amdManifest)
+		case "manifests/large":
+			w.Header().Set("Content-Type", mediaTypeDockerManifest)
+			w.Write(make([]byte, maxResponseSize+1))
+		case "referrers/" + amdDigest:
+			assert.Equal(t, r.URL.Query().Get("artifactType"), "application/vnd.cncf.notary.signature", nil)
+			w.Header().Set("Content-Type", mediaTypeOCIIndex)
+			fmt.Fprint(w, `{"manifests": [
+				{"artifactType": "application/vnd.cncf.notary.signature", "digest": "sha256:sig"},
+				{"artifactType": "application/spdx+json", "digest": "sha256:sbom"}
+			]}`)
+		case "manifests/sha256-arm":
+			w.Header().Set("Content-Type", mediaTypeOCIIndex)
+			fmt.Fprint(w, `{"manifests": [{"artifactType": "application/vnd.cncf.notary.signature", "digest": "sha256:armsig"}]}`)
+		case "blobs/sha256:config":
+			fmt.Fprint(w, `{"config": {"User": "root", "Labels": {"app": "test"}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server = httptest.NewTLSServer(mux)
+	return server
+}
+
+func Test_FetchImageData(t *testing.T) {
+	server := newTestRegistry(t)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	client := NewClient(server.Client(), nil, nil)
+	data, err := client.FetchImageData(host + "/test/app:v1")
+	assert.NilError(t, err)
+	assert.Equal(t, data.ResolvedImage, host+"/test/app:v1@"+amdDigest)
+	assert.Equal(t, data.Registry, host)
+	assert.Equal(t, data.Repository, "test/app")
+	assert.Equal(t, data.Identifier, "v1")
+	config := data.ConfigData["config"].(map[string]interface{})
+	assert.Equal(t, config["User"], "root")
+	layers := data.Manifest["layers"].([]interface{})
+	assert.Equal(t, layers[0].(map[string]interface{})["digest"], "sha256:layer1")
+
+	_, err = client.FetchImageData(host + "/test/app:v2")
+	assert.Assert(t, err != nil)
+}
+
+func Test_FetchReferrers(t *testing.T) {
+	server := newTestRegistry(t)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	client := NewClient(server.Client(), nil, nil)
+	referrers, err := client.FetchReferrers(host+"/test/app@"+amdDigest, "application/vnd.cncf.notary.signature", nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, referrers, []Referrer{{ArtifactType: "application/vnd.cncf.notary.signature", Digest: "sha256:sig"}})
+
+	// the referrers tag is used if the referrers API is not supported
+	referrers, err = client.FetchReferrers(host+"/test/app@sha256:arm", "application/vnd.cncf.notary.signature", nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, referrers, []Referrer{{ArtifactType: "application/vnd.cncf.notary.signature", Digest: "sha256:armsig"}})
+
+	_, err = client.FetchReferrers(host+"/test/app:v1", "application/vnd.cncf.notary.signature", nil)
+	assert.ErrorContains(t, err, "has no digest")
+}
+
+func Test_FetchManifest_Credentials(t *testing.T) {
+	server := newTestRegistry(t)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+	keychain, err := ParseDockerConfig([]byte(fmt.Sprintf(`{"auths": {"%s": {"username": "user", "password": "password"}}}`, host)))
+	assert.NilError(t, err)
+
+	anonymous := NewClient(server.Client(), nil, nil)
+	_, _, err = anonymous.FetchManifest(host+"/test/private:v1", nil)
+	assert.ErrorContains(t, err, "failed to authenticate")
+	_, _, err = anonymous.FetchManifest(host+"/test/basic:v1", nil)
+	assert.ErrorContains(t, err, "no credentials")
+
+	// the credentials of the request
+	_, digest, err := anonymous.FetchManifest(host+"/test/private:v1", keychain)
+	assert.NilError(t, err)
+	assert.Equal(t, digest, emptyDigest)
+
+	// the credentials of the client
+	client := NewClient(server.Client(), keychain, nil)
+	_, digest, err = client.FetchManifest(host+"/test/basic:v1", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, digest, emptyDigest)
+}
+
+func Test_FetchManifest_Digest(t *testing.T) {
+	server := newTestRegistry(t)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	client := NewClient(server.Client(), nil, nil)
+	_, digest, err := client.FetchManifest(host+"/test/app@"+amdDigest, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, digest, amdDigest)
+	_, _, err = client.FetchManifest(host+"/test/app@sha256:tampered", nil)
+	assert.ErrorContains(t, err, "does not match the content")
+	_, _, err = client.FetchManifest(host+"/test/app:large", nil)
+	assert.ErrorContains(t, err, fmt.Sprintf("exceeds %d bytes", maxResponseSize))
+}
+
+func Test_parseChallenge(t *testing.T) {
+	params := parseChallenge(`realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull,push"`)
+	assert.Equal(t, params["realm"], "https://auth.docker.io/token")
+	assert.Equal(t, params["service"], "registry.docker.io")
+	assert.Equal(t, params["scope"], "repository:library/nginx:pull,push")
+}
@@ -0,0 +1,303 @@
+package registry
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// usernames of the credentials exchanged for the cloud identity of kyverno
+const (
+	gcpUsername = "oauth2accesstoken"
+	acrUsername = "00000000-0000-0000-0000-000000000000"
+)
+
+// ecrRegistry matches the ECR registries, e.g. 123456789012.dkr.ecr.us-east-1.amazonaws.com
+var ecrRegistry = regexp.MustCompile(`^\d{12}\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// cloudKeychain exchanges the cloud identity of kyverno for registry credentials
+// - ECR: an authorization token of the AWS credentials of the environment or of the web identity (IRSA)
+// - GCR and Artifact Registry: an access token of the service account of the GCE metadata server (Workload Identity)
+// - ACR: a refresh token exchanged for the access token of the managed identity
+// the credentials are cached until they expire
+type cloudKeychain struct {
+	httpClient *http.Client
+	mu         sync.Mutex
+	cache      map[string]cachedCredentials
+	// endpoints and time are replaced in tests
+	ecrEndpoint    func(region string) string
+	stsEndpoint    string
+	gcpTokenURL    string
+	azureTokenURL  string
+	acrExchangeURL func(registry string) string
+	getenv         func(key string) string
+	now            func() time.Time
+}
+
+type cachedCredentials struct {
+	creds   Credentials
+	expires time.Time
+}
+
+// NewCloudKeychain returns the keychain of the ECR, GCR, Artifact Registry and ACR registries
+func NewCloudKeychain(httpClient *http.Client) Keychain {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &cloudKeychain{
+		httpClient:     httpClient,
+		cache:          map[string]cachedCredentials{},
+		ecrEndpoint:    func(region string) string { return fmt.Sprintf("https://api.ecr.%s.amazonaws.com/", region) },
+		stsEndpoint:    "https://sts.amazonaws.com/",
+		gcpTokenURL:    "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token",
+		azureTokenURL:  "http://169.254.169.254/metadata/identity/oauth2/token?api-version=2018-02-01&resource=https%3A%2F%2Fmanagement.azure.com%2F",
+		acrExchangeURL: func(registry string) string { return fmt.Sprintf("https://%s/oauth2/exchange", registry) },
+		getenv:         os.Getenv,
+		now:            time.Now,
+	}
+}
+
+func (k *cloudKeychain) Resolve(registry string) (*Credentials, error) {
+	var exchange func(string) (Credentials, time.Time, error)
+	switch {
+	case ecrRegistry.MatchString(registry):
+		exchange = k.ecrCredentials
+	case registry == "gcr.io" || strings.HasSuffix(registry, ".gcr.io") || strings.HasSuffix(registry, "-docker.pkg.dev"):
+		exchange = k.gcpCredentials
+	case strings.HasSuffix(registry, ".azurecr.io"):
+		exchange = k.acrCredentials
+	default:
+		return nil, nil
+	}
+	k.mu.Lock()
+	cached, ok := k.cache[registry]
+	k.mu.Unlock()
+	if ok && k.now().Before(cached.expires) {
+		return &cached.creds, nil
+	}
+	creds, expires, err := exchange(registry)
+	if err != nil {
+		return nil, err
+	}
+	k.mu.Lock()
+	// the credentials are renewed before they expire
+	k.cache[registry] = cachedCredentials{creds: creds, expires: expires.Add(-5 * time.Minute)}
+	k.mu.Unlock()
+	return &creds, nil
+}
+
+// ecrCredentials requests an ECR authorization token for the region of the registry
+func (k *cloudKeychain) ecrCredentials(registry string) (Credentials, time.Time, error) {
+	region := ecrRegistry.FindStringSubmatch(registry)[2]
+	aws, err := k.awsCredentials()
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, k.ecrEndpoint(region), strings.NewReader("{}"))
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken")
+	signV4(req, []byte("{}"), aws, region, "ecr", k.now())
+	var resp struct {
+		AuthorizationData []struct {
+			AuthorizationToken string  `json:"authorizationToken"`
+			ExpiresAt          float64 `json:"expiresAt"`
+		} `json:"authorizationData"`
+	}
+	if err := k.do(req, &resp); err != nil {
+		return Credentials{}, time.Time{}, fmt.Errorf("failed to get the ECR authorization token: %v", err)
+	}
+	if len(resp.AuthorizationData) == 0 {
+		return Credentials{}, time.Time{}, fmt.Errorf("no ECR authorization token")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(resp.AuthorizationData[0].AuthorizationToken)
+	if err != nil {
+		return Credentials{}, time.Time{}, fmt.Errorf("invalid ECR authorization token: %v", err)
+	}
+	userPass := strings.SplitN(string(decoded), ":", 2)
+	if len(userPass) != 2 {
+		return Credentials{}, time.Time{}, fmt.Errorf("invalid ECR authorization token")
+	}
+	return Credentials{Username: userPass[0], Password: userPass[1]}, time.Unix(int64(resp.AuthorizationData[0].ExpiresAt), 0), nil
+}
+
+type awsCredentials struct {
+	AccessKeyID     string `xml:"AccessKeyId"`
+	SecretAccessKey string `xml:"SecretAccessKey"`
+	SessionToken    string `xml:"SessionToken"`
+}
+
+// awsCredentials returns the AWS credentials of the environment variables,
+// or assumes the role of the web identity token file of IRSA
+func (k *cloudKeychain) awsCredentials() (awsCredentials, error) {
+	if id := k.getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		return awsCredentials{AccessKeyID: id, SecretAccessKey: k.getenv("AWS_SECRET_ACCESS_KEY"), SessionToken: k.getenv("AWS_SESSION_TOKEN")}, nil
+	}
+	roleARN, tokenFile := k.getenv("AWS_ROLE_ARN"), k.getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	if roleARN == "" || tokenFile == "" {
+		return awsCredentials{}, fmt.Errorf("no AWS credentials, set AWS_ACCESS_KEY_ID or AWS_ROLE_ARN and AWS_WEB_IDENTITY_TOKEN_FILE")
+	}
+	token, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	query := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {"kyverno"},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	resp, err := k.httpClient.Get(k.stsEndpoint + "?" + query.Encode())
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return awsCredentials{}, fmt.Errorf("failed to assume role %s: %s", roleARN, resp.Status)
+	}
+	var result struct {
+		Credentials awsCredentials `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to decode the credentials of role %s: %v", roleARN, err)
+	}
+	return result.Credentials, nil
+}
+
+// gcpCredentials requests an access token of the service account from the metadata server
+func (k *cloudKeychain) gcpCredentials(registry string) (Credentials, time.Time, error) {
+	req, err := http.NewRequest(http.MethodGet, k.gcpTokenURL, nil)
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := k.do(req, &token); err != nil {
+		return Credentials{}, time.Time{}, fmt.Errorf("failed to get the GCP access token: %v", err)
+	}
+	return Credentials{Username: gcpUsername, Password: token.AccessToken}, k.now().Add(time.Duration(token.ExpiresIn) * time.Second), nil
+}
+
+// acrCredentials exchanges the access token of the managed identity for a refresh token of the registry
+func (k *cloudKeychain) acrCredentials(registry string) (Credentials, time.Time, error) {
+	req, err := http.NewRequest(http.MethodGet, k.azureTokenURL, nil)
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	req.Header.Set("Metadata", "true")
+	var token struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := k.do(req, &token); err != nil {
+		return Credentials{}, time.Time{}, fmt.Errorf("failed to get the Azure access token: %v", err)
+	}
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {registry},
+		"access_token": {token.AccessToken},
+	}
+	req, err = http.NewRequest(http.MethodPost, k.acrExchangeURL(registry), strings.NewReader(form.Encode()))
+	if err != nil {
+		return Credentials{}, time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var refresh struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := k.do(req, &refresh); err != nil {
+		return Credentials{}, time.Time{}, fmt.Errorf("failed to exchange the Azure access token for registry %s: %v", registry, err)
+	}
+	expiresIn, err := token.ExpiresIn.Int64()
+	if err != nil {
+		expiresIn = int64(time.Hour / time.Second)
+	}
+	return Credentials{Username: acrUsername, Password: refresh.RefreshToken}, k.now().Add(time.Duration(expiresIn) * time.Second), nil
+}
+
+func (k *cloudKeychain) do(req *http.Request, result interface{}) error {
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL.Host, resp.Status)
+	}
+	return json.Unmarshal(body, result)
+}
+
+// signV4 signs the request with the AWS signature version 4
+func signV4(req *http.Request, body []byte, creds awsCredentials, region, service string, at time.Time) {
+	amzDate := at.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	// the query parameters are sorted, url.Values.Encode sorts by key
+	query := strings.Replace(req.URL.Query().Encode(), "+", "%20", -1)
+	canonicalRequest := strings.Join([]string{req.Method, path, query, canonicalHeaders.String(), signedHeaders, hexSHA256(body)}, "\n")
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hexSHA256([]byte(canonicalRequest))}, "\n")
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := fmt.Sprintf("%x", hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hexSHA256(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
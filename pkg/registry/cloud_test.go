@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func Test_signV4(t *testing.T) {
+	// the example of the AWS signature version 4 documentation
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	assert.NilError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, creds, "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	assert.Equal(t, req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7")
+}
+
+func newTestCloudKeychain(server *httptest.Server, env map[string]string) *cloudKeychain {
+	k := NewCloudKeychain(server.Client()).(*cloudKeychain)
+	k.ecrEndpoint = func(region string) string { return server.URL + "/ecr/" + region }
+	k.stsEndpoint = server.URL + "/sts"
+	k.gcpTokenURL = server.URL + "/gcp/token"
+	k.azureTokenURL = server.URL + "/azure/token"
+	k.acrExchangeURL = func(registry string) string { return server.URL + "/acr/" + registry }
+	k.getenv = func(key string) string { return env[key] }
+	return k
+}
+
+func Test_CloudKeychain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aws")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	assert.NilError(t, ioutil.WriteFile(tokenFile, []byte("web-identity\n"), 0600))
+
+	requests := map[string]int{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sts", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Query().Get("WebIdentityToken"), "web-identity")
+		fmt.Fprint(w, `<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+			<AccessKeyId>AKID</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>
+			</Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`)
+	})
+	mux.HandleFunc("/ecr/us-east-1", func(w http.ResponseWriter, r *http.Request) {
+		requests["ecr"]++
+		assert.Assert(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"), r.Header.Get("Authorization"))
+		assert.Equal(t, r.Header.Get("X-Amz-Security-Token"), "session")
+		fmt.Fprintf(w, `{"authorizationData": [{"authorizationToken": "%s", "expiresAt": %d}]}`,
+			base64.StdEncoding.EncodeToString([]byte("AWS:ecr-password")), time.Now().Add(12*time.Hour).Unix())
+	})
+	mux.HandleFunc("/gcp/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("Metadata-Flavor"), "Google")
+		fmt.Fprint(w, `{"access_token": "gcp-token", "expires_in": 3600}`)
+	})
+	mux.HandleFunc("/azure/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Header.Get("Metadata"), "true")
+		fmt.Fprint(w, `{"access_token": "aad-token", "expires_in": "3600"}`)
+	})
+	mux.HandleFunc("/acr/corp.azurecr.io", func(w http.ResponseWriter, r *http.Request) {
+		assert.NilError(t, r.ParseForm())
+		assert.Equal(t, r.PostForm.Get("access_token"), "aad-token")
+		assert.Equal(t, r.PostForm.Get("service"), "corp.azurecr.io")
+		fmt.Fprint(w, `{"refresh_token": "acr-token"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	keychain := newTestCloudKeychain(server, map[string]string{"AWS_ROLE_ARN": "arn:aws:iam::123456789012:role/kyverno", "AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile})
+	creds, err := keychain.Resolve("123456789012.dkr.ecr.us-east-1.amazonaws.com")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, creds, &Credentials{Username: "AWS", Password: "ecr-password"})
+	// the credentials are cached
+	_, err = keychain.Resolve("123456789012.dkr.ecr.us-east-1.amazonaws.com")
+	assert.NilError(t, err)
+	assert.Equal(t, requests["ecr"], 1)
+
+	creds, err = keychain.Resolve("us-docker.pkg.dev")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, creds, &Credentials{Username: gcpUsername, Password: "gcp-token"})
+
+	creds, err = keychain.Resolve("corp.azurecr.io")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, creds, &Credentials{Username: acrUsername, Password: "acr-token"})
+
+	creds, err = keychain.Resolve("registry.corp.com")
+	assert.NilError(t, err)
+	assert.Assert(t, creds == nil)
+
+	_, err = newTestCloudKeychain(server, nil).Resolve("123456789012.dkr.ecr.us-east-1.amazonaws.com")
+	assert.ErrorContains(t, err, "no AWS credentials")
+}
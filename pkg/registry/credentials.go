@@ -0,0 +1,178 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Credentials authenticate to a registry
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Keychain resolves the credentials of the registries
+type Keychain interface {
+	// Resolve returns the credentials of the registry host, nil if the keychain has none
+	Resolve(registry string) (*Credentials, error)
+}
+
+// multiKeychain returns the credentials of the first keychain that has credentials for the registry
+type multiKeychain []Keychain
+
+// NewMultiKeychain returns a keychain that resolves the credentials with the keychains in order
+func NewMultiKeychain(keychains ...Keychain) Keychain {
+	var m multiKeychain
+	for _, k := range keychains {
+		if k != nil {
+			m = append(m, k)
+		}
+	}
+	return m
+}
+
+// Resolve returns the errors of the keychains that failed if no keychain has credentials for the registry
+func (m multiKeychain) Resolve(registry string) (*Credentials, error) {
+	var errs []string
+	for _, k := range m {
+		creds, err := k.Resolve(registry)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if creds != nil {
+			return creds, nil
+		}
+	}
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil, nil
+}
+
+// dockerConfigKeychain are the credentials of a docker config per registry host,
+// a host can have a leading wildcard, e.g. *.corp.com
+type dockerConfigKeychain map[string]Credentials
+
+type dockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Auth is the base64 encoded username:password
+	Auth string `json:"auth"`
+}
+
+// ParseDockerConfig parses the auths of a docker config.json, or the legacy .dockercfg format without auths
+func ParseDockerConfig(data []byte) (Keychain, error) {
+	var config struct {
+		Auths map[string]dockerConfigEntry `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode the docker config: %v", err)
+	}
+	if config.Auths == nil {
+		if err := json.Unmarshal(data, &config.Auths); err != nil {
+			return nil, fmt.Errorf("failed to decode the docker config: %v", err)
+		}
+	}
+	keychain := dockerConfigKeychain{}
+	for server, entry := range config.Auths {
+		creds := Credentials{Username: entry.Username, Password: entry.Password}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth of registry %s: %v", server, err)
+			}
+			userPass := strings.SplitN(string(decoded), ":", 2)
+			if len(userPass) != 2 {
+				return nil, fmt.Errorf("invalid auth of registry %s, must be username:password", server)
+			}
+			creds = Credentials{Username: userPass[0], Password: userPass[1]}
+		}
+		keychain[normalizeServer(server)] = creds
+	}
+	return keychain, nil
+}
+
+// normalizeServer returns the host of a docker config server, e.g. https://index.docker.io/v1/ -> docker.io
+func normalizeServer(server string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	if i := strings.Index(host, "/"); i != -1 {
+		host = host[:i]
+	}
+	switch host {
+	case "index.docker.io", dockerHubRegistry:
+		return DefaultRegistry
+	}
+	return host
+}
+
+func (k dockerConfigKeychain) Resolve(registry string) (*Credentials, error) {
+	if creds, ok := k[registry]; ok {
+		return &creds, nil
+	}
+	for server, creds := range k {
+		if strings.HasPrefix(server, "*.") && strings.HasSuffix(registry, server[1:]) {
+			creds := creds
+			return &creds, nil
+		}
+	}
+	return nil, nil
+}
+
+// NewSecretKeychain returns the credentials of the image pull secrets,
+// the secrets of type kubernetes.io/dockerconfigjson and kubernetes.io/dockercfg are supported
+func NewSecretKeychain(log log.Logger, secrets []v1.Secret) Keychain {
+	var keychains []Keychain
+	for _, secret := range secrets {
+		var data []byte
+		switch secret.Type {
+		case v1.SecretTypeDockerConfigJson:
+			data = secret.Data[v1.DockerConfigJsonKey]
+		case v1.SecretTypeDockercfg:
+			data = secret.Data[v1.DockerConfigKey]
+		default:
+			log.V(4).Infof("skipping the image pull secret %s/%s of type %s", secret.Namespace, secret.Name, secret.Type)
+			continue
+		}
+		keychain, err := ParseDockerConfig(data)
+		if err != nil {
+			log.Errorf("invalid image pull secret %s/%s: %v", secret.Namespace, secret.Name, err)
+			continue
+		}
+		keychains = append(keychains, keychain)
+	}
+	return NewMultiKeychain(keychains...)
+}
+
+// fileKeychain reads the credentials from a mounted docker config, the file is read on each resolution
+// so the updates of the mounted secret are used
+type fileKeychain struct {
+	path string
+}
+
+// NewFileKeychain returns the credentials of the docker config file
+func NewFileKeychain(path string) Keychain {
+	return fileKeychain{path: path}
+}
+
+func (k fileKeychain) Resolve(registry string) (*Credentials, error) {
+	data, err := ioutil.ReadFile(k.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	keychain, err := ParseDockerConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker config %s: %v", k.path, err)
+	}
+	return keychain.Resolve(registry)
+}
@@ -0,0 +1,84 @@
+package registry
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_ParseDockerConfig(t *testing.T) {
+	// the auth is base64 of user:password
+	keychain, err := ParseDockerConfig([]byte(`{"auths": {
+		"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNzd29yZA=="},
+		"registry.corp.com": {"username": "corp", "password": "secret"},
+		"*.corp.io": {"username": "wildcard", "password": "secret"}
+	}}`))
+	assert.NilError(t, err)
+	creds, err := keychain.Resolve("docker.io")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, creds, &Credentials{Username: "user", Password: "password"})
+	creds, _ = keychain.Resolve("registry.corp.com")
+	assert.DeepEqual(t, creds, &Credentials{Username: "corp", Password: "secret"})
+	creds, _ = keychain.Resolve("eu.corp.io")
+	assert.DeepEqual(t, creds, &Credentials{Username: "wildcard", Password: "secret"})
+	creds, _ = keychain.Resolve("ghcr.io")
+	assert.Assert(t, creds == nil)
+
+	// the legacy .dockercfg format has no auths
+	keychain, err = ParseDockerConfig([]byte(`{"registry.corp.com": {"username": "corp", "password": "secret"}}`))
+	assert.NilError(t, err)
+	creds, _ = keychain.Resolve("registry.corp.com")
+	assert.DeepEqual(t, creds, &Credentials{Username: "corp", Password: "secret"})
+
+	_, err = ParseDockerConfig([]byte(`{"auths": {"registry.corp.com": {"auth": "dXNlcg=="}}}`))
+	assert.ErrorContains(t, err, "must be username:password")
+}
+
+func Test_NewSecretKeychain(t *testing.T) {
+	keychain := NewSecretKeychain(log.Discard, []v1.Secret{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "opaque"},
+			Type:       v1.SecretTypeOpaque,
+			Data:       map[string][]byte{v1.DockerConfigJsonKey: []byte(`{"auths": {"registry.corp.com": {"username": "opaque", "password": "secret"}}}`)},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "dockercfg"},
+			Type:       v1.SecretTypeDockercfg,
+			Data:       map[string][]byte{v1.DockerConfigKey: []byte(`{"ghcr.io": {"username": "ghcr", "password": "secret"}}`)},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "dockerconfigjson"},
+			Type:       v1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{v1.DockerConfigJsonKey: []byte(`{"auths": {"registry.corp.com": {"username": "corp", "password": "secret"}}}`)},
+		},
+	})
+	creds, err := keychain.Resolve("registry.corp.com")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, creds, &Credentials{Username: "corp", Password: "secret"})
+	creds, _ = keychain.Resolve("ghcr.io")
+	assert.DeepEqual(t, creds, &Credentials{Username: "ghcr", Password: "secret"})
+}
+
+func Test_FileKeychain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	keychain := NewFileKeychain(path)
+
+	creds, err := keychain.Resolve("registry.corp.com")
+	assert.NilError(t, err)
+	assert.Assert(t, creds == nil)
+
+	// the updates of the file are used
+	assert.NilError(t, ioutil.WriteFile(path, []byte(`{"auths": {"registry.corp.com": {"username": "corp", "password": "secret"}}}`), 0600))
+	creds, err = keychain.Resolve("registry.corp.com")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, creds, &Credentials{Username: "corp", Password: "secret"})
+}
@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Mirror is the registry and the repository prefix a registry is mirrored to,
+// e.g. the repository library/nginx of docker.io is mirror.corp.com/docker.io/library/nginx for the prefix docker.io
+type Mirror struct {
+	Registry string
+	Prefix   string
+}
+
+// ParseMirrors parses the comma separated mirrors of the registries, e.g. "docker.io=mirror.corp.com/docker.io,ghcr.io=mirror.corp.com"
+func ParseMirrors(mirrors string) (map[string]Mirror, error) {
+	parsed := map[string]Mirror{}
+	for _, entry := range strings.Split(mirrors, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || strings.TrimSpace(kv[1]) == "" {
+			return nil, fmt.Errorf("invalid mirror %q, must be <registry>=<mirror registry>[/<prefix>]", entry)
+		}
+		registry := normalizeServer(strings.TrimSpace(kv[0]))
+		if _, ok := parsed[registry]; ok {
+			return nil, fmt.Errorf("duplicate mirror of registry %s", registry)
+		}
+		target := strings.Trim(strings.TrimSpace(kv[1]), "/")
+		mirror := Mirror{Registry: target}
+		if i := strings.Index(target, "/"); i != -1 {
+			mirror = Mirror{Registry: target[:i], Prefix: target[i+1:]}
+		}
+		parsed[registry] = mirror
+	}
+	return parsed, nil
+}
+
+// mirror returns the reference of the repository in the mirror of its registry, the reference if the registry has no mirror,
+// an error in offline mode if the registry has no mirror and is neither a mirror nor a registry of the cluster
+func (c *Client) mirror(ref ImageReference) (ImageReference, error) {
+	m, ok := c.mirrors[ref.Registry]
+	if !ok {
+		if c.offline && !c.isLocalRegistry(ref.Registry) {
+			return ref, fmt.Errorf("registry %s has no mirror, the registries without mirror are not contacted in offline mode", ref.Registry)
+		}
+		return ref, nil
+	}
+	mirrored := ref
+	mirrored.Registry = m.Registry
+	if m.Prefix != "" {
+		mirrored.Repository = m.Prefix + "/" + ref.Repository
+	}
+	return mirrored, nil
+}
+
+// SetOffline sets the offline mode: the registries without mirror are not contacted, unless they are mirrors
+// or services of the cluster, e.g. registry.kube-system.svc:5000
+func (c *Client) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// isLocalRegistry returns true if the registry is the registry of a mirror or a service of the cluster
+func (c *Client) isLocalRegistry(registry string) bool {
+	for _, m := range c.mirrors {
+		if m.Registry == registry {
+			return true
+		}
+	}
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+	return strings.HasSuffix(host, ".svc") || strings.Contains(host, ".svc.")
+}
@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func Test_ParseMirrors(t *testing.T) {
+	mirrors, err := ParseMirrors("index.docker.io=mirror.corp.com/docker.io, ghcr.io=mirror.corp.com:5000/")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, mirrors, map[string]Mirror{
+		"docker.io": {Registry: "mirror.corp.com", Prefix: "docker.io"},
+		"ghcr.io":   {Registry: "mirror.corp.com:5000"},
+	})
+
+	mirrors, err = ParseMirrors("")
+	assert.NilError(t, err)
+	assert.Equal(t, len(mirrors), 0)
+
+	_, err = ParseMirrors("docker.io")
+	assert.ErrorContains(t, err, "invalid mirror")
+	_, err = ParseMirrors("docker.io=mirror.corp.com,registry-1.docker.io=other.corp.com")
+	assert.ErrorContains(t, err, "duplicate mirror")
+}
+
+func Test_FetchManifest_Mirror(t *testing.T) {
+	server := newTestRegistry(t)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	// ghcr.io/app is fetched from the repository test/app of the mirror
+	client := NewClient(server.Client(), nil, map[string]Mirror{"ghcr.io": {Registry: host, Prefix: "test"}})
+	_, digest, err := client.FetchManifest("ghcr.io/app@"+amdDigest, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, digest, amdDigest)
+	referrers, err := client.FetchReferrers("ghcr.io/app@"+amdDigest, "application/vnd.cncf.notary.signature", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(referrers), 1)
+
+	data, err := client.FetchImageData("ghcr.io/app:v1")
+	assert.NilError(t, err)
+	assert.Equal(t, data.ResolvedImage, "ghcr.io/app:v1@"+amdDigest)
+}
+
+func Test_FetchManifest_Offline(t *testing.T) {
+	server := newTestRegistry(t)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	client := NewClient(server.Client(), nil, map[string]Mirror{"ghcr.io": {Registry: host, Prefix: "test"}})
+	client.SetOffline(true)
+	// the mirrored registries are fetched from their mirror
+	_, digest, err := client.FetchManifest("ghcr.io/app@"+amdDigest, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, digest, amdDigest)
+	// the mirror is accessed directly
+	_, _, err = client.FetchManifest(host+"/test/app@"+amdDigest, nil)
+	assert.NilError(t, err)
+	// the registries without mirror are not contacted
+	_, err = client.FetchImageData("docker.io/nginx:latest")
+	assert.ErrorContains(t, err, "registry docker.io has no mirror")
+	_, _, err = client.FetchManifest("quay.io/app:v1", nil)
+	assert.ErrorContains(t, err, "not contacted in offline mode")
+
+	assert.Assert(t, client.isLocalRegistry("registry.kube-system.svc:5000"))
+	assert.Assert(t, client.isLocalRegistry("registry.kube-system.svc.cluster.local"))
+	assert.Assert(t, !client.isLocalRegistry("registry.corp.com"))
+}
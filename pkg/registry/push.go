@@ -0,0 +1,68 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// PushBlob uploads the blob to the repository of the image in a single request and returns its digest,
+// the blobs are pushed to the registry of the image, not to its mirror
+func (c *Client) PushBlob(image string, data []byte, keychain Keychain) (string, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return "", err
+	}
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	req, err := c.newRequest(http.MethodPost, ref, "blobs/uploads/", "", []byte{})
+	if err != nil {
+		return "", err
+	}
+	resp, _, err := c.do(req, ref, keychain)
+	if err != nil {
+		return "", err
+	}
+	// the location of the upload can be relative to the URL of the request
+	location, err := req.URL.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.Header.Get("Location") == "" {
+		return "", fmt.Errorf("invalid location of the upload of %s: %q", image, resp.Header.Get("Location"))
+	}
+	query := location.Query()
+	query.Set("digest", digest)
+	location.RawQuery = query.Encode()
+	if req, err = uploadRequest(location, data); err != nil {
+		return "", err
+	}
+	if _, _, err := c.do(req, ref, keychain); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+func uploadRequest(location *url.URL, data []byte) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPut, location.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return req, nil
+}
+
+// PushManifest uploads the manifest with the tag or the digest of the image and returns its digest
+func (c *Client) PushManifest(image, mediaType string, manifest []byte, keychain Keychain) (string, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return "", err
+	}
+	req, err := c.newRequest(http.MethodPut, ref, "manifests/"+ref.Identifier(), "", manifest)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", mediaType)
+	if _, _, err := c.do(req, ref, keychain); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(manifest)), nil
+}
@@ -0,0 +1,92 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// newPushRegistry serves the blobs and the manifests pushed to the repository test/bundle, with basic authentication
+func newPushRegistry(t *testing.T) *httptest.Server {
+	blobs := map[string][]byte{}
+	manifests := map[string][]byte{}
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "password" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v2/test/bundle/")
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NilError(t, err)
+		switch {
+		case r.Method == http.MethodPost && path == "blobs/uploads/":
+			w.Header().Set("Location", "/v2/test/bundle/blobs/uploads/1?state=a")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPut && path == "blobs/uploads/1":
+			assert.Equal(t, r.URL.Query().Get("state"), "a")
+			digest := r.URL.Query().Get("digest")
+			if fmt.Sprintf("sha256:%x", sha256.Sum256(body)) != digest {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			blobs[digest] = body
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && strings.HasPrefix(path, "manifests/"):
+			assert.Equal(t, r.Header.Get("Content-Type"), mediaTypeOCIManifest)
+			manifests[strings.TrimPrefix(path, "manifests/")] = body
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && strings.HasPrefix(path, "manifests/"):
+			manifest, ok := manifests[strings.TrimPrefix(path, "manifests/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", mediaTypeOCIManifest)
+			w.Write(manifest)
+		case r.Method == http.MethodGet && strings.HasPrefix(path, "blobs/"):
+			blob, ok := blobs[strings.TrimPrefix(path, "blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(blob)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func Test_Push(t *testing.T) {
+	server := newPushRegistry(t)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+	keychain, err := ParseDockerConfig([]byte(fmt.Sprintf(`{"auths": {"%s": {"username": "user", "password": "password"}}}`, host)))
+	assert.NilError(t, err)
+	client := NewClient(server.Client(), keychain, nil)
+
+	// the body of the requests is sent again with the credentials
+	digest, err := client.PushBlob(host+"/test/bundle:v1", []byte("policies"), nil)
+	assert.NilError(t, err)
+	assert.Equal(t, digest, fmt.Sprintf("sha256:%x", sha256.Sum256([]byte("policies"))))
+	blob, err := client.FetchBlob(host+"/test/bundle:v1", digest, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, string(blob), "policies")
+
+	manifest := []byte(fmt.Sprintf(`{"mediaType": "%s", "layers": [{"digest": "%s"}]}`, mediaTypeOCIManifest, digest))
+	manifestDigest, err := client.PushManifest(host+"/test/bundle:v1", mediaTypeOCIManifest, manifest, nil)
+	assert.NilError(t, err)
+	body, fetchedDigest, err := client.FetchManifest(host+"/test/bundle:v1", nil)
+	assert.NilError(t, err)
+	assert.Equal(t, string(body), string(manifest))
+	assert.Equal(t, fetchedDigest, manifestDigest)
+
+	_, err = NewClient(server.Client(), nil, nil).PushBlob(host+"/test/bundle:v1", []byte("policies"), nil)
+	assert.ErrorContains(t, err, "no credentials")
+}
@@ -0,0 +1,92 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultRegistry is the registry of the images that do not specify a registry
+	DefaultRegistry = "docker.io"
+	// DefaultTag is the tag of the images that do not specify a tag or a digest
+	DefaultTag = "latest"
+)
+
+// ImageReference is a parsed image reference
+// e.g. ghcr.io/kyverno/kyverno:v1.0 -> registry: ghcr.io, repository: kyverno/kyverno, tag: v1.0
+type ImageReference struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+// ParseImageReference parses the image reference, the defaults are applied as the container runtime does
+// - docker.io is the default registry
+// - the images of docker.io without a path are in library/
+// - latest is the default tag, if no digest is set
+func ParseImageReference(image string) (ImageReference, error) {
+	var ref ImageReference
+	if image == "" {
+		return ref, fmt.Errorf("image reference cannot be empty")
+	}
+	name := image
+	if i := strings.Index(name, "@"); i != -1 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !strings.Contains(ref.Digest, ":") {
+			return ref, fmt.Errorf("invalid digest in image reference %s", image)
+		}
+	}
+	// the tag is after the last ':' of the last path component, a ':' before is the registry port
+	if i := strings.LastIndex(name, ":"); i != -1 && !strings.Contains(name[i+1:], "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = DefaultTag
+	}
+	components := strings.SplitN(name, "/", 2)
+	if len(components) == 2 && isRegistry(components[0]) {
+		ref.Registry = components[0]
+		ref.Repository = components[1]
+	} else {
+		ref.Registry = DefaultRegistry
+		ref.Repository = name
+	}
+	if ref.Registry == DefaultRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if ref.Repository == "" {
+		return ref, fmt.Errorf("invalid image reference %s, the repository is empty", image)
+	}
+	if strings.ToLower(ref.Repository) != ref.Repository {
+		return ref, fmt.Errorf("invalid image reference %s, the repository must be lowercase", image)
+	}
+	return ref, nil
+}
+
+// isRegistry checks if the first component of an image name is a registry host
+func isRegistry(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost"
+}
+
+// Identifier returns the digest of the image if set, else the tag
+func (r ImageReference) Identifier() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// String returns the fully qualified image reference
+func (r ImageReference) String() string {
+	image := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		image += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		image += "@" + r.Digest
+	}
+	return image
+}
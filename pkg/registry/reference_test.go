@@ -0,0 +1,45 @@
+package registry
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func Test_ParseImageReference(t *testing.T) {
+	testCases := []struct {
+		image    string
+		expected ImageReference
+	}{
+		{"nginx", ImageReference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"nginx:1.17", ImageReference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.17"}},
+		{"nirmata/kyverno:v1.1.0", ImageReference{Registry: "docker.io", Repository: "nirmata/kyverno", Tag: "v1.1.0"}},
+		{"ghcr.io/kyverno/kyverno", ImageReference{Registry: "ghcr.io", Repository: "kyverno/kyverno", Tag: "latest"}},
+		{"localhost:5000/app:dev", ImageReference{Registry: "localhost:5000", Repository: "app", Tag: "dev"}},
+		{"localhost/app", ImageReference{Registry: "localhost", Repository: "app", Tag: "latest"}},
+		{"nginx@sha256:abc", ImageReference{Registry: "docker.io", Repository: "library/nginx", Digest: "sha256:abc"}},
+		{"gcr.io/proj/app:1.0@sha256:abc", ImageReference{Registry: "gcr.io", Repository: "proj/app", Tag: "1.0", Digest: "sha256:abc"}},
+	}
+	for _, tc := range testCases {
+		ref, err := ParseImageReference(tc.image)
+		assert.NilError(t, err, tc.image)
+		assert.DeepEqual(t, ref, tc.expected)
+	}
+
+	for _, image := range []string{"", "Nginx", "nginx@abc"} {
+		_, err := ParseImageReference(image)
+		assert.Assert(t, err != nil, image)
+	}
+}
+
+func Test_ImageReference_String(t *testing.T) {
+	ref, err := ParseImageReference("nginx")
+	assert.NilError(t, err)
+	assert.Equal(t, ref.String(), "docker.io/library/nginx:latest")
+	assert.Equal(t, ref.Identifier(), "latest")
+
+	ref, err = ParseImageReference("gcr.io/proj/app@sha256:abc")
+	assert.NilError(t, err)
+	assert.Equal(t, ref.String(), "gcr.io/proj/app@sha256:abc")
+	assert.Equal(t, ref.Identifier(), "sha256:abc")
+}
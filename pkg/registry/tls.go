@@ -0,0 +1,175 @@
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio/pkg/wildcard"
+	"github.com/nirmata/kyverno/pkg/engine/log"
+	v1 "k8s.io/api/core/v1"
+	informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// keys of the registry TLS Secret
+const (
+	// caBundleKey are the PEM encoded CA certificates trusted in addition to the system roots
+	caBundleKey = "ca.crt"
+	// insecureRegistriesKey are the comma or newline separated registry hosts whose certificates are not verified,
+	// a host can contain wildcards, e.g. *.lab.corp.com
+	insecureRegistriesKey = "insecureRegistries"
+)
+
+// TLSConfig is the transport of the registry client, it trusts the CA certificates of a Secret
+// and skips the verification of the certificates of its insecure registries,
+// the configuration is reloaded when the Secret changes
+type TLSConfig struct {
+	secretName string
+	mu         sync.RWMutex
+	// insecure are the host patterns of the insecure registries
+	insecure          []string
+	transport         *http.Transport
+	insecureTransport *http.Transport
+	secretSynced      cache.InformerSynced
+	log               log.Logger
+}
+
+// NewTLSConfig returns the transport configured by the Secret, the system roots are used until the Secret is loaded
+// the informer must watch the namespace of the Secret
+func NewTLSConfig(secretInformer informers.SecretInformer, secretName string, log log.Logger) *TLSConfig {
+	t := &TLSConfig{secretName: secretName, log: log}
+	t.setConfig(nil, nil)
+	t.secretSynced = secretInformer.Informer().HasSynced
+	secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    t.addSecret,
+		UpdateFunc: t.updateSecret,
+		DeleteFunc: t.deleteSecret,
+	})
+	return t
+}
+
+// Run waits for the Secret to be loaded
+func (t *TLSConfig) Run(stopCh <-chan struct{}) {
+	if !cache.WaitForCacheSync(stopCh, t.secretSynced) {
+		t.log.Error("registry TLS configuration: failed to sync informer cache")
+	}
+}
+
+// RoundTrip sends the request with the transport of the registry host
+func (t *TLSConfig) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	transport := t.transport
+	if matchHost(t.insecure, req.URL.Host) {
+		transport = t.insecureTransport
+	}
+	t.mu.RUnlock()
+	return transport.RoundTrip(req)
+}
+
+func (t *TLSConfig) addSecret(obj interface{}) {
+	secret := obj.(*v1.Secret)
+	if secret.Name != t.secretName {
+		return
+	}
+	t.load(secret)
+}
+
+func (t *TLSConfig) updateSecret(old, cur interface{}) {
+	secret := cur.(*v1.Secret)
+	if secret.Name != t.secretName {
+		return
+	}
+	t.load(secret)
+}
+
+func (t *TLSConfig) deleteSecret(obj interface{}) {
+	secret, ok := obj.(*v1.Secret)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			t.log.Infof("Couldn't get object from tombstone %#v", obj)
+			return
+		}
+		if secret, ok = tombstone.Obj.(*v1.Secret); !ok {
+			t.log.Infof("Tombstone contained object that is not a Secret %#v", obj)
+			return
+		}
+	}
+	if secret.Name != t.secretName {
+		return
+	}
+	t.log.Infof("registry TLS configuration: Secret %s deleted, using the system roots", secret.Name)
+	t.setConfig(nil, nil)
+}
+
+// load parses the Secret, an invalid Secret is logged and the current configuration is kept
+func (t *TLSConfig) load(secret *v1.Secret) {
+	roots, insecure, err := parseTLSSecret(t.log, secret)
+	if err != nil {
+		t.log.Errorf("registry TLS configuration: invalid Secret %s: %v", secret.Name, err)
+		return
+	}
+	t.log.Infof("registry TLS configuration: loaded Secret %s, insecure registries %v", secret.Name, insecure)
+	t.setConfig(roots, insecure)
+}
+
+// setConfig replaces the transports, the idle connections of the previous transports are closed
+// so the new certificates are used for the next requests
+func (t *TLSConfig) setConfig(roots *x509.CertPool, insecure []string) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	insecureTransport := http.DefaultTransport.(*http.Transport).Clone()
+	insecureTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	t.mu.Lock()
+	previous := []*http.Transport{t.transport, t.insecureTransport}
+	t.transport, t.insecureTransport, t.insecure = transport, insecureTransport, insecure
+	t.mu.Unlock()
+	for _, p := range previous {
+		if p != nil {
+			p.CloseIdleConnections()
+		}
+	}
+}
+
+// parseTLSSecret returns the system roots with the CA certificates of the Secret, nil if it has none, and the insecure registries
+func parseTLSSecret(log log.Logger, secret *v1.Secret) (*x509.CertPool, []string, error) {
+	var roots *x509.CertPool
+	if bundle := secret.Data[caBundleKey]; len(bundle) > 0 {
+		var err error
+		if roots, err = x509.SystemCertPool(); err != nil {
+			log.V(4).Infof("failed to load the system roots: %v", err)
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(bundle) {
+			return nil, nil, fmt.Errorf("no certificates found in %s", caBundleKey)
+		}
+	}
+	insecure := strings.FieldsFunc(string(secret.Data[insecureRegistriesKey]), func(r rune) bool {
+		return r == ',' || r == '\n' || r == ' '
+	})
+	return roots, insecure, nil
+}
+
+// matchHost checks if the host, with its port if any, matches one of the patterns, docker.io matches the host of its API
+// the patterns with a port match the host and the port, the patterns without a port match the host on any port
+func matchHost(patterns []string, host string) bool {
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	for _, pattern := range patterns {
+		target := hostname
+		if _, _, err := net.SplitHostPort(pattern); err == nil {
+			target = host
+		}
+		if wildcard.Match(pattern, target) || (pattern == DefaultRegistry && target == dockerHubRegistry) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_TLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	tlsConfig := &TLSConfig{secretName: "registry-tls"}
+	tlsConfig.setConfig(nil, nil)
+	client := &http.Client{Transport: tlsConfig}
+	get := func() error {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	// the certificate of the server is not trusted by the system roots
+	assert.ErrorContains(t, get(), "certificate")
+
+	// the Secrets with other names are ignored
+	tlsConfig.addSecret(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other"}, Data: map[string][]byte{caBundleKey: caBundle}})
+	assert.ErrorContains(t, get(), "certificate")
+
+	tlsConfig.addSecret(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "registry-tls"}, Data: map[string][]byte{caBundleKey: caBundle}})
+	assert.NilError(t, get())
+
+	// an invalid bundle keeps the current configuration
+	tlsConfig.updateSecret(nil, &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "registry-tls"}, Data: map[string][]byte{caBundleKey: []byte("invalid")}})
+	assert.NilError(t, get())
+
+	tlsConfig.updateSecret(nil, &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "registry-tls"}, Data: map[string][]byte{insecureRegistriesKey: []byte("registry.lab.corp.com,\n127.0.0.1")}})
+	assert.NilError(t, get())
+
+	tlsConfig.deleteSecret(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "registry-tls"}})
+	assert.ErrorContains(t, get(), "certificate")
+}
+
+func Test_matchHost(t *testing.T) {
+	patterns := []string{"docker.io", "*.lab.corp.com", "localhost:5000"}
+	assert.Assert(t, matchHost(patterns, "registry-1.docker.io"))
+	assert.Assert(t, matchHost(patterns, "registry.lab.corp.com"))
+	assert.Assert(t, matchHost(patterns, "localhost:5000"))
+	assert.Assert(t, !matchHost(patterns, "localhost:5001"))
+	assert.Assert(t, !matchHost(patterns, "ghcr.io"))
+	// the patterns without a port match the hosts on any port
+	assert.Assert(t, matchHost(patterns, "registry.lab.corp.com:5000"))
+	assert.Assert(t, matchHost(patterns, "registry-1.docker.io:443"))
+	assert.Assert(t, !matchHost(patterns, "ghcr.io:443"))
+	assert.Assert(t, !matchHost(patterns, "localhost"))
+}
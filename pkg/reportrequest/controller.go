@@ -0,0 +1,142 @@
+package reportrequest
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	kyvernoclient "github.com/nirmata/kyverno/pkg/client/clientset/versioned"
+	kyvernov1 "github.com/nirmata/kyverno/pkg/client/clientset/versioned/typed/kyverno/v1"
+	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
+	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/config"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const controllerQueueName = "report-request-controller"
+
+// Controller is the reports controller: it passes the violations of the ReportRequests to the generators of the
+// policy violations, the policy reports and the notifications, and deletes the processed requests
+type Controller struct {
+	kyvernoInterface kyvernov1.KyvernoV1Interface
+	// rrLister can list/get the report requests of the kyverno namespace from the shared informer's store
+	rrLister kyvernolister.ReportRequestNamespaceLister
+	// rrSynced returns true if the report request store has been synced at least once
+	rrSynced     cache.InformerSynced
+	violationGen policyviolation.GeneratorInterface
+	queue        workqueue.RateLimitingInterface
+}
+
+// NewController returns a new instance of the reports controller
+func NewController(client *kyvernoclient.Clientset,
+	rrInformer kyvernoinformer.ReportRequestInformer,
+	violationGen policyviolation.GeneratorInterface) *Controller {
+	c := &Controller{
+		kyvernoInterface: client.KyvernoV1(),
+		rrLister:         rrInformer.Lister().ReportRequests(config.KubePolicyNamespace),
+		rrSynced:         rrInformer.Informer().HasSynced,
+		violationGen:     violationGen,
+		queue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerQueueName),
+	}
+	rrInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueue,
+		UpdateFunc: func(old, cur interface{}) { c.enqueue(cur) },
+	})
+	return c
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	rr, ok := obj.(*kyverno.ReportRequest)
+	if !ok || rr.Namespace != config.KubePolicyNamespace {
+		return
+	}
+	key, err := cache.MetaNamespaceKeyFunc(rr)
+	if err != nil {
+		glog.Error(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// Run starts the workers
+func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+	glog.Info("Starting reports controller")
+	defer glog.Info("Shutting down reports controller")
+
+	if !cache.WaitForCacheSync(stopCh, c.rrSynced) {
+		glog.Error("reports controller: failed to sync informer cache")
+		return
+	}
+	for i := 0; i < workers; i++ {
+		go wait.Until(c.runWorker, time.Second, stopCh)
+	}
+	<-stopCh
+}
+
+func (c *Controller) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *Controller) processNextWorkItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+	err := c.syncHandler(key.(string))
+	if err == nil {
+		c.queue.Forget(key)
+		return true
+	}
+	if c.queue.NumRequeues(key) < workQueueRetryLimit {
+		glog.V(4).Infof("Error syncing report request %v: %v", key, err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	glog.Errorf("Dropping report request %v out of the queue: %v", key, err)
+	return true
+}
+
+// syncHandler passes the violation of the report request to the generators and deletes the request.
+// The request is kept if it was updated in the meantime, the update is processed next.
+func (c *Controller) syncHandler(key string) error {
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	rr, err := c.rrLister.Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	c.violationGen.Add(toInfo(rr.Spec))
+
+	uid := rr.UID
+	resourceVersion := rr.ResourceVersion
+	err = c.kyvernoInterface.ReportRequests(rr.Namespace).Delete(rr.Name, &metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &uid, ResourceVersion: &resourceVersion},
+	})
+	if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+		return err
+	}
+	resource := rr.Spec.Resource
+	glog.V(4).Infof("Processed report request %s of policy %s on %s/%s/%s", rr.Name, rr.Spec.Policy, resource.Kind, resource.Namespace, resource.Name)
+	return nil
+}
+
+// Len returns the number of the queued report requests
+func (c *Controller) Len() int {
+	return c.queue.Len()
+}
@@ -0,0 +1,226 @@
+package reportrequest
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	kyvernoclient "github.com/nirmata/kyverno/pkg/client/clientset/versioned"
+	kyvernov1 "github.com/nirmata/kyverno/pkg/client/clientset/versioned/typed/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/config"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"github.com/nirmata/kyverno/pkg/ratelimit"
+	"github.com/nirmata/kyverno/pkg/utils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const workQueueName = "report-request-generator"
+const workQueueRetryLimit = 3
+
+// refreshInterval is the minimum interval of the writes of an unchanged violation, the background scans report
+// the violations again on every scan, the reports controller refreshes the last time the violations were seen
+const refreshInterval = policyviolation.CleanupInterval
+
+// Generator writes the policy violations as ReportRequests processed by the reports controller,
+// the latest violation of a policy for a resource replaces its pending request
+type Generator struct {
+	kyvernoInterface kyvernov1.KyvernoV1Interface
+	limiter          *ratelimit.Limiter
+	queue            workqueue.RateLimitingInterface
+	dataStore        *dataStore
+	workers          utils.Workers
+	// the last written violations per policy and resource
+	written   map[string]written
+	writtenMu sync.Mutex
+}
+
+type written struct {
+	spec kyverno.ReportRequestSpec
+	time time.Time
+}
+
+type dataStore struct {
+	data map[string]policyviolation.Info
+	mu   sync.RWMutex
+}
+
+func (ds *dataStore) add(key string, info policyviolation.Info) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.data[key] = info
+}
+
+func (ds *dataStore) lookup(key string) policyviolation.Info {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	return ds.data[key]
+}
+
+func (ds *dataStore) has(key string) bool {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	_, ok := ds.data[key]
+	return ok
+}
+
+func (ds *dataStore) delete(key string) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	delete(ds.data, key)
+}
+
+// NewGenerator returns a new instance of report request generator, the writes of the requests are limited by limiter
+func NewGenerator(client *kyvernoclient.Clientset, limiter *ratelimit.Limiter) *Generator {
+	return &Generator{
+		kyvernoInterface: client.KyvernoV1(),
+		limiter:          limiter,
+		queue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), workQueueName),
+		dataStore:        &dataStore{data: make(map[string]policyviolation.Info)},
+		written:          map[string]written{},
+	}
+}
+
+// Add queues the policy violations to be written, the violations written recently are skipped
+func (gen *Generator) Add(infos ...policyviolation.Info) {
+	for _, info := range infos {
+		key := infoKey(info)
+		// a queued violation is replaced even if the violation was written recently
+		if !gen.dataStore.has(key) && gen.recentlyWritten(key, newReportRequest(info).Spec) {
+			continue
+		}
+		gen.dataStore.add(key, info)
+		gen.queue.Add(key)
+		glog.V(3).Infof("Added report request: %s", key)
+	}
+}
+
+// Run starts the workers
+func (gen *Generator) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	glog.Info("Start report request generator")
+	defer glog.Info("Shutting down report request generator")
+
+	for i := 0; i < workers; i++ {
+		gen.workers.Go(gen.runWorker, stopCh)
+	}
+	<-stopCh
+}
+
+// Drain stops queueing the policy violations and writes the report requests of the queued ones,
+// it returns false if they are not written before the timeout
+func (gen *Generator) Drain(timeout time.Duration) bool {
+	gen.queue.ShutDown()
+	return gen.workers.Wait(timeout)
+}
+
+func (gen *Generator) runWorker() {
+	for gen.processNextWorkitem() {
+	}
+}
+
+func (gen *Generator) handleErr(err error, key interface{}) {
+	if err == nil {
+		gen.queue.Forget(key)
+		return
+	}
+
+	if gen.queue.NumRequeues(key) < workQueueRetryLimit {
+		glog.V(4).Infof("Error syncing report request %v: %v", key, err)
+		gen.queue.AddRateLimited(key)
+		return
+	}
+	gen.queue.Forget(key)
+	glog.Error(err)
+	if k, ok := key.(string); ok {
+		gen.dataStore.delete(k)
+	}
+	glog.Warningf("Dropping the key out of the queue: %v", err)
+}
+
+func (gen *Generator) processNextWorkitem() bool {
+	obj, shutdown := gen.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer gen.queue.Done(obj)
+
+	key, ok := obj.(string)
+	if !ok {
+		gen.queue.Forget(obj)
+		glog.Warningf("Expecting type string but got %v\n", obj)
+		return true
+	}
+	info := gen.dataStore.lookup(key)
+	if reflect.DeepEqual(info, policyviolation.Info{}) {
+		gen.queue.Forget(obj)
+		glog.Warningf("Got empty key %v\n", obj)
+		return true
+	}
+	err := gen.syncHandler(info)
+	gen.handleErr(err, obj)
+	if err == nil {
+		gen.dataStore.delete(key)
+	}
+	return true
+}
+
+func (gen *Generator) recentlyWritten(key string, spec kyverno.ReportRequestSpec) bool {
+	gen.writtenMu.Lock()
+	defer gen.writtenMu.Unlock()
+	w, ok := gen.written[key]
+	return ok && time.Since(w.time) < refreshInterval && reflect.DeepEqual(w.spec, spec)
+}
+
+func (gen *Generator) setWritten(key string, spec kyverno.ReportRequestSpec) {
+	gen.writtenMu.Lock()
+	defer gen.writtenMu.Unlock()
+	gen.written[key] = written{spec: spec, time: time.Now()}
+}
+
+// syncHandler creates the report request of the violation, or replaces the spec of the pending request
+func (gen *Generator) syncHandler(info policyviolation.Info) error {
+	if err := gen.write(info); err != nil {
+		return err
+	}
+	gen.setWritten(infoKey(info), newReportRequest(info).Spec)
+	return nil
+}
+
+func (gen *Generator) write(info policyviolation.Info) error {
+	rr := newReportRequest(info)
+	requests := gen.kyvernoInterface.ReportRequests(config.KubePolicyNamespace)
+	gen.limiter.Wait()
+	_, err := requests.Create(rr)
+	if err == nil {
+		glog.V(4).Infof("Created report request %s for %s", rr.Name, infoKey(info))
+		return nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	pending, err := requests.Get(rr.Name, metav1.GetOptions{})
+	if err != nil {
+		// the pending request was processed in the meantime, the request is created on the retry
+		return err
+	}
+	if reflect.DeepEqual(pending.Spec, rr.Spec) {
+		return nil
+	}
+	updated := pending.DeepCopy()
+	updated.Spec = rr.Spec
+	if _, err := requests.Update(updated); err != nil {
+		return err
+	}
+	glog.V(4).Infof("Updated report request %s for %s", rr.Name, infoKey(info))
+	return nil
+}
+
+// Len returns the number of the queued report requests
+func (gen *Generator) Len() int {
+	return gen.queue.Len()
+}
@@ -0,0 +1,66 @@
+package reportrequest
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/config"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// infoKey identifies the policy and the resource
+func infoKey(info policyviolation.Info) string {
+	return info.PolicyName + "/" + info.Resource.GetKind() + "/" + info.Resource.GetNamespace() + "/" + info.Resource.GetName()
+}
+
+// requestName returns the name of the report request of the policy for the resource,
+// there is at most one pending request per policy and resource
+func requestName(info policyviolation.Info) string {
+	h := fnv.New64a()
+	h.Write([]byte(infoKey(info)))
+	return fmt.Sprintf("rr-%x", h.Sum64())
+}
+
+// newReportRequest returns the report request of the violation, in the kyverno namespace
+func newReportRequest(info policyviolation.Info) *kyverno.ReportRequest {
+	return &kyverno.ReportRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      requestName(info),
+			Namespace: config.KubePolicyNamespace,
+		},
+		Spec: kyverno.ReportRequestSpec{
+			Policy: info.PolicyName,
+			Resource: kyverno.ReportResource{
+				APIVersion: info.Resource.GetAPIVersion(),
+				ResourceSpec: kyverno.ResourceSpec{
+					Kind:      info.Resource.GetKind(),
+					Namespace: info.Resource.GetNamespace(),
+					Name:      info.Resource.GetName(),
+				},
+				UID: string(info.Resource.GetUID()),
+			},
+			ViolatedRules: info.Rules,
+		},
+	}
+}
+
+// toInfo returns the violation of the report request
+func toInfo(spec kyverno.ReportRequestSpec) policyviolation.Info {
+	resource := unstructured.Unstructured{}
+	resource.SetAPIVersion(spec.Resource.APIVersion)
+	resource.SetKind(spec.Resource.Kind)
+	resource.SetNamespace(spec.Resource.Namespace)
+	resource.SetName(spec.Resource.Name)
+	if spec.Resource.UID != "" {
+		resource.SetUID(types.UID(spec.Resource.UID))
+	}
+	return policyviolation.Info{
+		PolicyName: spec.Policy,
+		Resource:   resource,
+		Rules:      spec.ViolatedRules,
+	}
+}
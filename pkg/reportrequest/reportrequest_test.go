@@ -0,0 +1,120 @@
+package reportrequest
+
+import (
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/client/clientset/versioned/fake"
+	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/config"
+	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"gotest.tools/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func newInfo(name string, rules ...kyverno.ViolatedRule) policyviolation.Info {
+	resource := unstructured.Unstructured{}
+	resource.SetAPIVersion("v1")
+	resource.SetKind("Pod")
+	resource.SetNamespace("default")
+	resource.SetName(name)
+	resource.SetUID("0d4b2c1e")
+	return policyviolation.Info{PolicyName: "require-labels", Resource: resource, Rules: rules}
+}
+
+func Test_toInfo(t *testing.T) {
+	info := newInfo("nginx", kyverno.ViolatedRule{Name: "check-app", Type: "Validation", Message: "label app is required"})
+	rr := newReportRequest(info)
+	assert.Equal(t, rr.Namespace, config.KubePolicyNamespace)
+	assert.Equal(t, rr.Spec.Resource.Namespace, "default")
+	assert.DeepEqual(t, toInfo(rr.Spec), info)
+
+	// one request per policy and resource
+	assert.Equal(t, requestName(newInfo("nginx")), rr.Name)
+	assert.Assert(t, requestName(newInfo("redis")) != rr.Name)
+}
+
+func Test_Generator_syncHandler(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	gen := &Generator{kyvernoInterface: client.KyvernoV1(), written: map[string]written{}}
+	rule := kyverno.ViolatedRule{Name: "check-app", Type: "Validation", Message: "label app is required"}
+	assert.NilError(t, gen.syncHandler(newInfo("nginx", rule)))
+	// the violations written recently are skipped
+	key := infoKey(newInfo("nginx"))
+	assert.Assert(t, gen.recentlyWritten(key, newReportRequest(newInfo("nginx", rule)).Spec))
+	assert.Assert(t, !gen.recentlyWritten(key, newReportRequest(newInfo("nginx")).Spec))
+
+	// the pending request is replaced by the latest violation
+	assert.NilError(t, gen.syncHandler(newInfo("nginx")))
+	list, err := client.KyvernoV1().ReportRequests(config.KubePolicyNamespace).List(metav1.ListOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(list.Items), 1)
+	assert.Equal(t, len(list.Items[0].Spec.ViolatedRules), 0)
+}
+
+// recorder records the violations
+type recorder struct {
+	infos []policyviolation.Info
+}
+
+func (r *recorder) Add(infos ...policyviolation.Info) {
+	r.infos = append(r.infos, infos...)
+}
+
+func Test_Controller_syncHandler(t *testing.T) {
+	rr := newReportRequest(newInfo("nginx", kyverno.ViolatedRule{Name: "check-app", Type: "Validation", Message: "label app is required"}))
+	client := fake.NewSimpleClientset(rr)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	assert.NilError(t, indexer.Add(rr))
+	gen := &recorder{}
+	c := &Controller{
+		kyvernoInterface: client.KyvernoV1(),
+		rrLister:         kyvernolister.NewReportRequestLister(indexer).ReportRequests(config.KubePolicyNamespace),
+		violationGen:     gen,
+		queue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerQueueName),
+	}
+	c.enqueue(rr)
+	assert.Equal(t, c.Len(), 1)
+
+	assert.NilError(t, c.syncHandler(config.KubePolicyNamespace+"/"+rr.Name))
+	assert.Equal(t, len(gen.infos), 1)
+	assert.Equal(t, gen.infos[0].Resource.GetName(), "nginx")
+	assert.Equal(t, gen.infos[0].Rules[0].Name, "check-app")
+	_, err := client.KyvernoV1().ReportRequests(config.KubePolicyNamespace).Get(rr.Name, metav1.GetOptions{})
+	assert.Assert(t, apierrors.IsNotFound(err))
+
+	// the requests of the other namespaces are ignored
+	other := rr.DeepCopy()
+	other.Namespace = "default"
+	c.enqueue(other)
+	assert.Equal(t, c.Len(), 1)
+}
+
+func Test_Generator_Add(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	gen := &Generator{
+		kyvernoInterface: client.KyvernoV1(),
+		queue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), workQueueName),
+		dataStore:        &dataStore{data: make(map[string]policyviolation.Info)},
+		written:          map[string]written{},
+	}
+	rule := kyverno.ViolatedRule{Name: "check-app", Type: "Validation", Message: "label app is required"}
+	gen.Add(newInfo("nginx", rule))
+	assert.Assert(t, gen.processNextWorkitem())
+	// the background scan reports the same violation
+	gen.Add(newInfo("nginx", rule))
+	assert.Equal(t, gen.Len(), 0)
+
+	// the resource is compliant, then violates the policy again before the request is written
+	gen.Add(newInfo("nginx"))
+	gen.Add(newInfo("nginx", rule))
+	assert.Equal(t, gen.Len(), 1)
+	assert.Assert(t, gen.processNextWorkitem())
+	rr, err := client.KyvernoV1().ReportRequests(config.KubePolicyNamespace).Get(requestName(newInfo("nginx")), metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(rr.Spec.ViolatedRules), 1)
+}
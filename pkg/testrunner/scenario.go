@@ -13,6 +13,7 @@ import (
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	client "github.com/nirmata/kyverno/pkg/dclient"
 	"github.com/nirmata/kyverno/pkg/engine"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
 	"github.com/nirmata/kyverno/pkg/engine/response"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -130,7 +131,7 @@ func runTestCase(t *testing.T, tc scaseT) bool {
 
 	var er response.EngineResponse
 
-	er = engine.Mutate(engine.PolicyContext{Policy: *policy, NewResource: *resource})
+	er = engine.Mutate(engine.PolicyContext{Policy: *policy, NewResource: *resource, Log: enginelog.Glog})
 	t.Log("---Mutation---")
 	validateResource(t, er.PatchedResource, tc.Expected.Mutation.PatchedResource)
 	validateResponse(t, er.PolicyResponse, tc.Expected.Mutation.PolicyResponse)
@@ -140,7 +141,7 @@ func runTestCase(t *testing.T, tc scaseT) bool {
 		resource = &er.PatchedResource
 	}
 
-	er = engine.Validate(engine.PolicyContext{Policy: *policy, NewResource: *resource})
+	er = engine.Validate(engine.PolicyContext{Policy: *policy, NewResource: *resource, Log: enginelog.Glog})
 	t.Log("---Validation---")
 	validateResponse(t, er.PolicyResponse, tc.Expected.Validation.PolicyResponse)
 
@@ -158,6 +159,7 @@ func runTestCase(t *testing.T, tc scaseT) bool {
 				NewResource: *resource,
 				Policy:      *policy,
 				Client:      client,
+				Log:         enginelog.Glog,
 			}
 
 			er = engine.Generate(policyContext)
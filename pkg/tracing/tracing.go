@@ -0,0 +1,289 @@
+package tracing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// exportInterval is the interval of the exports of the ended spans
+	exportInterval = 5 * time.Second
+	// maxQueuedSpans is the number of the ended spans kept until the next export, the other spans are dropped
+	maxQueuedSpans = 10000
+	// serviceName is the service.name attribute of the exported spans
+	serviceName = "kyverno"
+)
+
+// Tracer exports the spans to an OpenTelemetry collector with OTLP over HTTP, in the JSON encoding
+type Tracer struct {
+	endpoint string
+	client   *http.Client
+	mu       sync.Mutex
+	spans    []*Span
+	dropped  int
+}
+
+// NewTracer returns a tracer exporting the spans to the OTLP HTTP endpoint of the collector, e.g. http://otel-collector:4318
+func NewTracer(endpoint string, client *http.Client) *Tracer {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	return &Tracer{endpoint: endpoint, client: client}
+}
+
+var (
+	mu            sync.RWMutex
+	defaultTracer *Tracer
+)
+
+// SetTracer sets the tracer of the traces started with StartTrace, the traces are not recorded without tracer
+func SetTracer(t *Tracer) {
+	mu.Lock()
+	defer mu.Unlock()
+	defaultTracer = t
+}
+
+// StartTrace starts the root span of a trace, it returns nil if tracing is disabled.
+// The attributes are key value pairs.
+func StartTrace(name string, attributes ...string) *Span {
+	mu.RLock()
+	t := defaultTracer
+	mu.RUnlock()
+	if t == nil {
+		return nil
+	}
+	s := newSpan(t, name, attributes)
+	s.kind = spanKindServer
+	rand.Read(s.traceID[:])
+	return s
+}
+
+// Run exports the ended spans every exportInterval, and when stopCh is closed
+func (t *Tracer) Run(stopCh <-chan struct{}) {
+	glog.Infof("exporting traces to %s", t.endpoint)
+	wait.Until(t.flush, exportInterval, stopCh)
+	t.flush()
+}
+
+func (t *Tracer) add(s *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.spans) >= maxQueuedSpans {
+		t.dropped++
+		return
+	}
+	t.spans = append(t.spans, s)
+}
+
+func (t *Tracer) flush() {
+	t.mu.Lock()
+	spans, dropped := t.spans, t.dropped
+	t.spans, t.dropped = nil, 0
+	t.mu.Unlock()
+	if dropped != 0 {
+		glog.Warningf("dropped %d spans, the export queue is full", dropped)
+	}
+	if len(spans) == 0 {
+		return
+	}
+	if err := t.export(spans); err != nil {
+		glog.Errorf("failed to export %d spans: %v", len(spans), err)
+	}
+}
+
+func (t *Tracer) export(spans []*Span) error {
+	body, err := json.Marshal(newExportRequest(spans))
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Post(t.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector %s returned %s", t.endpoint, resp.Status)
+	}
+	return nil
+}
+
+// kinds of the spans
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	spanKindClient   = 3
+)
+
+// Span is an operation of a trace. The methods of a nil span do nothing, so the callers do not check if tracing is enabled.
+type Span struct {
+	tracer     *Tracer
+	traceID    [16]byte
+	spanID     [8]byte
+	parentID   [8]byte
+	name       string
+	kind       int
+	start      time.Time
+	end        time.Time
+	mu         sync.Mutex
+	attributes []string
+	err        string
+}
+
+func newSpan(t *Tracer, name string, attributes []string) *Span {
+	s := &Span{tracer: t, name: name, kind: spanKindInternal, start: time.Now(), attributes: attributes}
+	rand.Read(s.spanID[:])
+	return s
+}
+
+// Start starts a child span
+func (s *Span) Start(name string, attributes ...string) *Span {
+	if s == nil {
+		return nil
+	}
+	child := newSpan(s.tracer, name, attributes)
+	child.traceID = s.traceID
+	child.parentID = s.spanID
+	return child
+}
+
+// StartClient starts a child span of a call to an external dependency, e.g. a registry
+func (s *Span) StartClient(name string, attributes ...string) *Span {
+	child := s.Start(name, attributes...)
+	if child != nil {
+		child.kind = spanKindClient
+	}
+	return child
+}
+
+// SetAttributes adds the key value pairs to the attributes of the span
+func (s *Span) SetAttributes(attributes ...string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attributes = append(s.attributes, attributes...)
+}
+
+// SetError sets the error status of the span
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End ends the span and queues it for export, the span is ended once
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.tracer.add(s)
+}
+
+// the OTLP JSON encoding of the spans, https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            status     `json:"status"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type status struct {
+	// Code is 0 (unset) or 2 (error)
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func newExportRequest(spans []*Span) exportRequest {
+	var otlpSpans []otlpSpan
+	for _, s := range spans {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        keyValues(s.attributes),
+		}
+		if s.parentID != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		if s.err != "" {
+			span.Status = status{Code: 2, Message: s.err}
+		}
+		s.mu.Unlock()
+		otlpSpans = append(otlpSpans, span)
+	}
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource:   resource{Attributes: keyValues([]string{"service.name", serviceName})},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: serviceName}, Spans: otlpSpans}},
+	}}}
+}
+
+func keyValues(attributes []string) []keyValue {
+	var kvs []keyValue
+	for i := 0; i+1 < len(attributes); i += 2 {
+		kvs = append(kvs, keyValue{Key: attributes[i], Value: anyValue{StringValue: attributes[i+1]}})
+	}
+	return kvs
+}
@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func Test_Export(t *testing.T) {
+	var received exportRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/v1/traces")
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NilError(t, err)
+		assert.NilError(t, json.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	tracer := NewTracer(server.URL, server.Client())
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	root := StartTrace("admission CREATE Pod", "k8s.kind", "Pod")
+	policy := root.Start("validate policy", "kyverno.policy", "disallow-latest-tag")
+	rule := policy.StartClient("rule", "kyverno.rule", "validate-image-tag")
+	rule.SetError(errors.New("image tag latest is not allowed"))
+	rule.End()
+	rule.End()
+	policy.End()
+	root.SetAttributes("allowed", "false")
+	root.End()
+	tracer.flush()
+
+	assert.Equal(t, len(received.ResourceSpans), 1)
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Equal(t, len(spans), 3)
+	ruleSpan, policySpan, rootSpan := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, rootSpan.ParentSpanID, "")
+	assert.Equal(t, rootSpan.Kind, spanKindServer)
+	assert.Equal(t, len(rootSpan.Attributes), 2)
+	assert.Equal(t, rootSpan.Attributes[1].Value.StringValue, "false")
+	assert.Equal(t, policySpan.ParentSpanID, rootSpan.SpanID)
+	assert.Equal(t, ruleSpan.ParentSpanID, policySpan.SpanID)
+	assert.Equal(t, ruleSpan.Kind, spanKindClient)
+	assert.Equal(t, policySpan.TraceID, rootSpan.TraceID)
+	assert.Equal(t, ruleSpan.TraceID, rootSpan.TraceID)
+	assert.Equal(t, ruleSpan.Status.Code, 2)
+	assert.Equal(t, ruleSpan.Status.Message, "image tag latest is not allowed")
+	assert.Equal(t, policySpan.Status.Code, 0)
+}
+
+func Test_Disabled(t *testing.T) {
+	SetTracer(nil)
+	span := StartTrace("admission CREATE Pod")
+	assert.Assert(t, span == nil)
+	child := span.Start("policy")
+	assert.Assert(t, child == nil)
+	child.SetAttributes("key", "value")
+	child.SetError(errors.New("error"))
+	child.End()
+	span.End()
+}
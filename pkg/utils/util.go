@@ -43,7 +43,8 @@ func compareString(str, name string) bool {
 
 //NewKubeClient returns a new kubernetes client
 func NewKubeClient(config *rest.Config) (kubernetes.Interface, error) {
-	kclient, err := kubernetes.NewForConfig(config)
+	// the requests and responses are encoded with protobuf
+	kclient, err := kubernetes.NewForConfig(dclient.ProtobufConfig(config))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Workers tracks the workers of a queue, to wait on shutdown for the queued items to be processed
+type Workers struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	stopped bool
+}
+
+// Go runs the worker every second until stopCh is closed, a worker returns once its queue is shut down and empty.
+// The worker runs at least once, to process the items queued before stopCh is closed. The workers are not started after Wait.
+func (w *Workers) Go(worker func(), stopCh <-chan struct{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return
+	}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		worker()
+		wait.Until(worker, time.Second, stopCh)
+	}()
+}
+
+// Wait waits for the workers to return, it returns false if they are still running after the timeout
+func (w *Workers) Wait(timeout time.Duration) bool {
+	w.mu.Lock()
+	w.stopped = true
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
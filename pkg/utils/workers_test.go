@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func Test_Workers_Wait_DrainsQueue(t *testing.T) {
+	queue := workqueue.New()
+	processed := make(chan interface{}, 10)
+	worker := func() {
+		for {
+			item, quit := queue.Get()
+			if quit {
+				return
+			}
+			processed <- item
+			queue.Done(item)
+		}
+	}
+	stopCh := make(chan struct{})
+	var workers Workers
+	workers.Go(worker, stopCh)
+	for i := 0; i < 5; i++ {
+		queue.Add(i)
+	}
+	close(stopCh)
+	queue.ShutDown()
+	// the items added after the shutdown are dropped
+	queue.Add(5)
+	assert.Assert(t, workers.Wait(time.Second))
+	assert.Equal(t, len(processed), 5)
+
+	// the workers are not started after Wait
+	workers.Go(worker, make(chan struct{}))
+	assert.Assert(t, workers.Wait(time.Millisecond))
+}
+
+func Test_Workers_Wait_Timeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	stopCh := make(chan struct{})
+	close(stopCh)
+	var workers Workers
+	workers.Go(func() { <-block }, stopCh)
+	assert.Assert(t, !workers.Wait(10*time.Millisecond))
+}
@@ -0,0 +1,247 @@
+package vap
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// identifier matches the keys that are selected as fields in CEL, the other keys are selected as map keys
+var identifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// integer matches the integer values of the patterns, the quantities and the decimals are not converted
+var integer = regexp.MustCompile(`^-?[0-9]+$`)
+
+// numericFields are the names of the integer fields of the schemas of the supported kinds, the strings of integers
+// of the patterns are compared as numbers on these fields and as strings on the other fields, e.g. the labels
+var numericFields = map[string]bool{
+	"activeDeadlineSeconds":         true,
+	"backoffLimit":                  true,
+	"completions":                   true,
+	"containerPort":                 true,
+	"defaultMode":                   true,
+	"expirationSeconds":             true,
+	"failedJobsHistoryLimit":        true,
+	"failureThreshold":              true,
+	"fsGroup":                       true,
+	"hostPort":                      true,
+	"initialDelaySeconds":           true,
+	"minReadySeconds":               true,
+	"mode":                          true,
+	"nodePort":                      true,
+	"parallelism":                   true,
+	"periodSeconds":                 true,
+	"port":                          true,
+	"priority":                      true,
+	"progressDeadlineSeconds":       true,
+	"replicas":                      true,
+	"revisionHistoryLimit":          true,
+	"runAsGroup":                    true,
+	"runAsUser":                     true,
+	"startingDeadlineSeconds":       true,
+	"successThreshold":              true,
+	"successfulJobsHistoryLimit":    true,
+	"terminationGracePeriodSeconds": true,
+	"timeoutSeconds":                true,
+	"ttlSecondsAfterFinished":       true,
+}
+
+// comparisons are the operators of the values of the patterns, the longest first
+var comparisons = []string{">=", "<=", ">", "<"}
+
+// expression returns the CEL expression of the pattern of a validate rule on the value of the CEL path,
+// an error if the pattern cannot be expressed in CEL
+func expression(path string, pattern interface{}) (string, error) {
+	switch typed := pattern.(type) {
+	case map[string]interface{}:
+		return mapExpression(path, typed)
+	case []interface{}:
+		if len(typed) != 1 {
+			return "", fmt.Errorf("%s: the lists of the patterns must have a single element", path)
+		}
+		element, ok := typed[0].(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("%s: the lists of values are not supported", path)
+		}
+		variable := elementVariable(path)
+		expr, err := mapExpression(variable, element)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s.all(%s, %s)", path, variable, expr), nil
+	case bool:
+		return fmt.Sprintf("%s == %t", path, typed), nil
+	case float64:
+		if typed != math.Trunc(typed) {
+			return "", fmt.Errorf("%s: the decimal values are not supported", path)
+		}
+		return fmt.Sprintf("%s == %d", path, int64(typed)), nil
+	case int64:
+		return fmt.Sprintf("%s == %d", path, typed), nil
+	case string:
+		return stringExpression(path, typed)
+	case nil:
+		return "", fmt.Errorf("%s: the null values are not supported", path)
+	default:
+		return "", fmt.Errorf("%s: the values of type %T are not supported", path, pattern)
+	}
+}
+
+// mapExpression returns the conjunction of the expressions of the fields of the map, in the order of the keys
+func mapExpression(path string, pattern map[string]interface{}) (string, error) {
+	var keys []string
+	for key := range pattern {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var exprs []string
+	for _, key := range keys {
+		value := pattern[key]
+		var name, anchor string
+		switch {
+		case strings.HasPrefix(key, "=(") && strings.HasSuffix(key, ")"):
+			name, anchor = key[2:len(key)-1], "="
+		case strings.HasPrefix(key, "X(") && strings.HasSuffix(key, ")"):
+			name, anchor = key[2:len(key)-1], "X"
+		case strings.HasPrefix(key, "(") || strings.HasPrefix(key, "^(") || strings.HasPrefix(key, "+("):
+			return "", fmt.Errorf("%s: the anchor %s is not supported", path, key)
+		default:
+			name = key
+		}
+		has, field := selectField(path, name)
+		switch anchor {
+		case "X":
+			exprs = append(exprs, "!"+has)
+			continue
+		case "":
+			// a wildcard requires the field, with any value
+			if value == "*" {
+				exprs = append(exprs, has)
+				continue
+			}
+		}
+		expr, err := expression(field, value)
+		if err != nil {
+			return "", err
+		}
+		if anchor == "=" {
+			exprs = append(exprs, fmt.Sprintf("(!%s || %s)", has, expr))
+		} else {
+			exprs = append(exprs, has, expr)
+		}
+	}
+	if len(exprs) == 0 {
+		return "true", nil
+	}
+	return strings.Join(exprs, " && "), nil
+}
+
+// selectField returns the expression of the presence of the field and the path of the field
+func selectField(path, name string) (string, string) {
+	if identifier.MatchString(name) {
+		return fmt.Sprintf("has(%s.%s)", path, name), path + "." + name
+	}
+	return fmt.Sprintf("%s in %s", quote(name), path), fmt.Sprintf("%s[%s]", path, quote(name))
+}
+
+// stringExpression returns the expression of the alternatives of the values of the string pattern
+func stringExpression(path, pattern string) (string, error) {
+	if strings.Contains(pattern, "{{") || strings.HasPrefix(pattern, "$(") {
+		return "", fmt.Errorf("%s: the variables and the references are not supported", path)
+	}
+	var exprs []string
+	for _, alternative := range strings.Split(pattern, "|") {
+		expr, err := valueExpression(path, strings.TrimSpace(alternative))
+		if err != nil {
+			return "", err
+		}
+		exprs = append(exprs, expr)
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return "(" + strings.Join(exprs, " || ") + ")", nil
+}
+
+// valueExpression returns the expression of a value of a pattern, with its operator
+func valueExpression(path, value string) (string, error) {
+	for _, operator := range comparisons {
+		if strings.HasPrefix(value, operator) {
+			number := value[len(operator):]
+			if !integer.MatchString(number) {
+				return "", fmt.Errorf("%s: only the integers are compared, not %s", path, number)
+			}
+			return fmt.Sprintf("%s %s %s", path, operator, number), nil
+		}
+	}
+	negate := strings.HasPrefix(value, "!")
+	if negate {
+		value = value[1:]
+	}
+	var expr string
+	switch {
+	case integer.MatchString(value) && numericFields[fieldName(path)]:
+		// the strings of integers are compared as numbers on the integer fields
+		expr = fmt.Sprintf("%s == %s", path, value)
+	case integer.MatchString(value):
+		expr = fmt.Sprintf("%s == %s", path, quote(value))
+	case len(value) > 0 && value[0] >= '0' && value[0] <= '9':
+		return "", fmt.Errorf("%s: the quantities are not supported, %s", path, value)
+	case value == "*":
+		expr = "true"
+	case value == "?*":
+		expr = fmt.Sprintf("%s != ''", path)
+	case strings.ContainsAny(value, "*?"):
+		expr = fmt.Sprintf("%s.matches(%s)", path, quote(wildcardRegex(value)))
+	default:
+		expr = fmt.Sprintf("%s == %s", path, quote(value))
+	}
+	if negate {
+		return fmt.Sprintf("!(%s)", expr), nil
+	}
+	return expr, nil
+}
+
+// wildcardRegex returns the regular expression of the wildcard pattern, * matches any string and ? any character
+func wildcardRegex(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// quote returns the CEL string literal of the value
+func quote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// fieldName returns the name of the field of the path, e.g. replicas for object.spec.replicas,
+// the keys selected with brackets are the keys of maps, e.g. of the labels, and not fields of the schemas
+func fieldName(path string) string {
+	if strings.HasSuffix(path, "]") {
+		return ""
+	}
+	return path[strings.LastIndex(path, ".")+1:]
+}
+
+// elementVariable returns the name of the variable of the elements of the list of the path, e.g. container for spec.containers
+func elementVariable(path string) string {
+	name := path[strings.LastIndexAny(path, ".[")+1:]
+	name = strings.Trim(name, "']")
+	if identifier.MatchString(name) && strings.HasSuffix(name, "s") && len(name) > 1 {
+		return strings.TrimSuffix(name, "s")
+	}
+	return "element"
+}
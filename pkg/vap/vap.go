@@ -0,0 +1,223 @@
+package vap
+
+import (
+	"fmt"
+	"strings"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// APIVersion is the API version of the generated ValidatingAdmissionPolicies and of their bindings
+const APIVersion = "admissionregistration.k8s.io/v1"
+
+// SourceAnnotation is the annotation of the generated resources with the policy and the rule they are generated from
+const SourceAnnotation = "policies.kyverno.io/source-rule"
+
+// namespaceLabel is the label set by Kubernetes on the namespaces with their name
+const namespaceLabel = "kubernetes.io/metadata.name"
+
+// resource is the group, the version and the resource of a kind
+type resource struct {
+	group, version, name string
+}
+
+// kinds are the resources of the kinds that are matched by the ValidatingAdmissionPolicies
+var kinds = map[string]resource{
+	"ConfigMap":             {"", "v1", "configmaps"},
+	"CronJob":               {"batch", "v1", "cronjobs"},
+	"DaemonSet":             {"apps", "v1", "daemonsets"},
+	"Deployment":            {"apps", "v1", "deployments"},
+	"Ingress":               {"networking.k8s.io", "v1", "ingresses"},
+	"Job":                   {"batch", "v1", "jobs"},
+	"Namespace":             {"", "v1", "namespaces"},
+	"NetworkPolicy":         {"networking.k8s.io", "v1", "networkpolicies"},
+	"PersistentVolumeClaim": {"", "v1", "persistentvolumeclaims"},
+	"Pod":                   {"", "v1", "pods"},
+	"ReplicaSet":            {"apps", "v1", "replicasets"},
+	"Role":                  {"rbac.authorization.k8s.io", "v1", "roles"},
+	"RoleBinding":           {"rbac.authorization.k8s.io", "v1", "rolebindings"},
+	"Secret":                {"", "v1", "secrets"},
+	"Service":               {"", "v1", "services"},
+	"ServiceAccount":        {"", "v1", "serviceaccounts"},
+	"StatefulSet":           {"apps", "v1", "statefulsets"},
+}
+
+// Skipped is a validate rule that is not converted
+type Skipped struct {
+	Policy string
+	Rule   string
+	Reason string
+}
+
+func (s Skipped) String() string {
+	return fmt.Sprintf("%s/%s: %s", s.Policy, s.Rule, s.Reason)
+}
+
+// Generate returns the ValidatingAdmissionPolicies and their bindings of the validate rules of the policy that are
+// expressed in CEL, a policy and a binding per rule. The validate rules that cannot be converted are returned as skipped,
+// the other rules are ignored.
+func Generate(policy kyverno.ClusterPolicy) ([]unstructured.Unstructured, []Skipped) {
+	var resources []unstructured.Unstructured
+	var skipped []Skipped
+	for _, rule := range policy.Spec.Rules {
+		if rule.Validation.Pattern == nil && len(rule.Validation.AnyPattern) == 0 {
+			continue
+		}
+		vap, err := admissionPolicy(policy, rule)
+		if err != nil {
+			skipped = append(skipped, Skipped{Policy: policy.Name, Rule: rule.Name, Reason: err.Error()})
+			continue
+		}
+		resources = append(resources, *vap, *binding(policy, vap))
+	}
+	return resources, skipped
+}
+
+// admissionPolicy returns the ValidatingAdmissionPolicy of the validate rule of the policy
+func admissionPolicy(policy kyverno.ClusterPolicy, rule kyverno.Rule) (*unstructured.Unstructured, error) {
+	if len(rule.Context) > 0 {
+		return nil, fmt.Errorf("the context of the rule is not supported")
+	}
+	if len(rule.Conditions) > 0 {
+		return nil, fmt.Errorf("the preconditions of the rule are not supported")
+	}
+	if hasUserInfo(rule.MatchResources.UserInfo) || hasUserInfo(rule.ExcludeResources.UserInfo) {
+		return nil, fmt.Errorf("the roles and the subjects of the rule are not supported")
+	}
+	constraints, err := matchConstraints(rule)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := validationExpression(rule.Validation)
+	if err != nil {
+		return nil, err
+	}
+	message := rule.Validation.Message
+	if message == "" || strings.Contains(message, "{{") {
+		// the variables of the messages are not evaluated
+		message = fmt.Sprintf("validation error: rule %s of the policy %s failed", rule.Name, policy.Name)
+	}
+	vap := newResource("ValidatingAdmissionPolicy", policy.Name+"-"+rule.Name, policy.Name+"/"+rule.Name)
+	vap.Object["spec"] = map[string]interface{}{
+		"failurePolicy":    kyverno.FailurePolicyFail,
+		"matchConstraints": constraints,
+		"validations": []interface{}{
+			map[string]interface{}{"expression": expr, "message": message},
+		},
+	}
+	return vap, nil
+}
+
+// binding returns the ValidatingAdmissionPolicyBinding of the ValidatingAdmissionPolicy, the requests are denied
+// if the policy is enforced, the other violations are audited
+func binding(policy kyverno.ClusterPolicy, vap *unstructured.Unstructured) *unstructured.Unstructured {
+	action := "Audit"
+	if policy.Spec.ValidationFailureAction == "enforce" {
+		action = "Deny"
+	}
+	b := newResource("ValidatingAdmissionPolicyBinding", vap.GetName()+"-binding", vap.GetAnnotations()[SourceAnnotation])
+	b.Object["spec"] = map[string]interface{}{
+		"policyName":        vap.GetName(),
+		"validationActions": []interface{}{action},
+	}
+	return b
+}
+
+func newResource(kind, name, source string) *unstructured.Unstructured {
+	r := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	r.SetAPIVersion(APIVersion)
+	r.SetKind(kind)
+	r.SetName(name)
+	r.SetLabels(map[string]string{"app.kubernetes.io/managed-by": "kyverno"})
+	r.SetAnnotations(map[string]string{SourceAnnotation: source})
+	return r
+}
+
+func hasUserInfo(info kyverno.UserInfo) bool {
+	return len(info.Roles) > 0 || len(info.ClusterRoles) > 0 || len(info.Subjects) > 0
+}
+
+// matchConstraints returns the resource rules and the selectors of the match and of the exclude of the rule
+func matchConstraints(rule kyverno.Rule) (map[string]interface{}, error) {
+	match := rule.MatchResources.ResourceDescription
+	exclude := rule.ExcludeResources.ResourceDescription
+	if len(match.Kinds) == 0 {
+		return nil, fmt.Errorf("the kinds of the match of the rule are required")
+	}
+	if len(exclude.Kinds) > 0 || exclude.Name != "" || exclude.Selector != nil {
+		return nil, fmt.Errorf("only the namespaces of the exclude of the rule are supported")
+	}
+	if strings.ContainsAny(match.Name, "*?") {
+		return nil, fmt.Errorf("the wildcards of the name of the match of the rule are not supported")
+	}
+	var resourceRules []interface{}
+	for _, kind := range match.Kinds {
+		r, ok := kinds[kind]
+		if !ok {
+			return nil, fmt.Errorf("the kind %s is not supported", kind)
+		}
+		resourceRule := map[string]interface{}{
+			"apiGroups":   []interface{}{r.group},
+			"apiVersions": []interface{}{r.version},
+			"operations":  []interface{}{"CREATE", "UPDATE"},
+			"resources":   []interface{}{r.name},
+		}
+		if match.Name != "" {
+			resourceRule["resourceNames"] = []interface{}{match.Name}
+		}
+		resourceRules = append(resourceRules, resourceRule)
+	}
+	constraints := map[string]interface{}{"resourceRules": resourceRules}
+
+	var expressions []interface{}
+	for _, namespaces := range []struct {
+		values   []string
+		operator string
+	}{{match.Namespaces, "In"}, {exclude.Namespaces, "NotIn"}} {
+		if len(namespaces.values) == 0 {
+			continue
+		}
+		var values []interface{}
+		for _, namespace := range namespaces.values {
+			if strings.ContainsAny(namespace, "*?") {
+				return nil, fmt.Errorf("the wildcards of the namespaces of the rule are not supported")
+			}
+			values = append(values, namespace)
+		}
+		expressions = append(expressions, map[string]interface{}{
+			"key":      namespaceLabel,
+			"operator": namespaces.operator,
+			"values":   values,
+		})
+	}
+	if len(expressions) > 0 {
+		constraints["namespaceSelector"] = map[string]interface{}{"matchExpressions": expressions}
+	}
+
+	if match.Selector != nil {
+		selector, err := runtime.DefaultUnstructuredConverter.ToUnstructured(match.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector: %v", err)
+		}
+		constraints["objectSelector"] = selector
+	}
+	return constraints, nil
+}
+
+// validationExpression returns the CEL expression of the pattern of the validation, or of the disjunction of its patterns
+func validationExpression(validation kyverno.Validation) (string, error) {
+	if validation.Pattern != nil {
+		return expression("object", validation.Pattern)
+	}
+	var exprs []string
+	for _, pattern := range validation.AnyPattern {
+		expr, err := expression("object", pattern)
+		if err != nil {
+			return "", err
+		}
+		exprs = append(exprs, "("+expr+")")
+	}
+	return strings.Join(exprs, " || "), nil
+}
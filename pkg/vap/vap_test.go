@@ -0,0 +1,195 @@
+package vap
+
+import (
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"github.com/nirmata/kyverno/pkg/kyverno/common"
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_Expression(t *testing.T) {
+	testCases := []struct {
+		pattern interface{}
+		expr    string
+		err     string
+	}{
+		{
+			pattern: map[string]interface{}{"spec": map[string]interface{}{"=(hostNetwork)": false}},
+			expr:    "has(object.spec) && (!has(object.spec.hostNetwork) || object.spec.hostNetwork == false)",
+		},
+		{
+			pattern: map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"app.kubernetes.io/name": "?*"}}},
+			expr:    "has(object.metadata) && has(object.metadata.labels) && 'app.kubernetes.io/name' in object.metadata.labels && object.metadata.labels['app.kubernetes.io/name'] != ''",
+		},
+		{
+			pattern: map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{map[string]interface{}{"image": "!*:latest", "name": "*"}}}},
+			expr:    "has(object.spec) && has(object.spec.containers) && object.spec.containers.all(container, has(container.image) && !(container.image.matches('^.*:latest$')) && has(container.name))",
+		},
+		{
+			pattern: map[string]interface{}{"spec": map[string]interface{}{"replicas": ">=2", "X(hostPID)": "null", "strategy": map[string]interface{}{"type": "Recreate | RollingUpdate"}}},
+			expr:    "has(object.spec) && !has(object.spec.hostPID) && has(object.spec.replicas) && object.spec.replicas >= 2 && has(object.spec.strategy) && has(object.spec.strategy.type) && (object.spec.strategy.type == 'Recreate' || object.spec.strategy.type == 'RollingUpdate')",
+		},
+		{
+			pattern: map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"version": "2", "team": "!100"}}},
+			expr:    "has(object.metadata) && has(object.metadata.labels) && has(object.metadata.labels.team) && !(object.metadata.labels.team == '100') && has(object.metadata.labels.version) && object.metadata.labels.version == '2'",
+		},
+		{
+			pattern: map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{"example.com/port": "8080"}}},
+			expr:    "has(object.metadata) && has(object.metadata.annotations) && 'example.com/port' in object.metadata.annotations && object.metadata.annotations['example.com/port'] == '8080'",
+		},
+		{
+			pattern: map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{map[string]interface{}{"securityContext": map[string]interface{}{"runAsUser": "1000 | 2000"}}}}},
+			expr:    "has(object.spec) && has(object.spec.containers) && object.spec.containers.all(container, has(container.securityContext) && has(container.securityContext.runAsUser) && (container.securityContext.runAsUser == 1000 || container.securityContext.runAsUser == 2000))",
+		},
+		{
+			pattern: map[string]interface{}{"spec": map[string]interface{}{"(name)": "web", "image": "nginx"}},
+			err:     "object.spec: the anchor (name) is not supported",
+		},
+		{
+			pattern: map[string]interface{}{"metadata": map[string]interface{}{"name": "{{request.object.metadata.namespace}}"}},
+			err:     "object.metadata.name: the variables and the references are not supported",
+		},
+		{
+			pattern: map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{map[string]interface{}{"resources": map[string]interface{}{"limits": map[string]interface{}{"memory": "<1Gi"}}}}}},
+			err:     "container.resources.limits.memory: only the integers are compared, not 1Gi",
+		},
+		{
+			pattern: map[string]interface{}{"spec": map[string]interface{}{"args": []interface{}{"--verbose"}}},
+			err:     "object.spec.args: the lists of values are not supported",
+		},
+	}
+	for _, tc := range testCases {
+		expr, err := expression("object", tc.pattern)
+		if tc.err != "" {
+			assert.Error(t, err, tc.err)
+			continue
+		}
+		assert.NilError(t, err)
+		assert.Equal(t, expr, tc.expr)
+	}
+}
+
+const testPolicy = `apiVersion: kyverno.io/v1
+kind: ClusterPolicy
+metadata:
+  name: pod-security
+spec:
+  validationFailureAction: enforce
+  rules:
+  - name: host-network
+    match:
+      resources:
+        kinds:
+        - Pod
+        selector:
+          matchLabels:
+            team: web
+    exclude:
+      resources:
+        namespaces:
+        - kube-system
+    validate:
+      message: Sharing the host network is not allowed.
+      pattern:
+        spec:
+          =(hostNetwork): false
+  - name: run-as-non-root
+    match:
+      resources:
+        kinds:
+        - Pod
+    validate:
+      anyPattern:
+      - spec:
+          securityContext:
+            runAsNonRoot: true
+      - spec:
+          securityContext:
+            runAsUser: ">0"
+  - name: same-namespace
+    match:
+      resources:
+        kinds:
+        - Pod
+    preconditions:
+    - key: "{{request.operation}}"
+      operator: Equal
+      value: CREATE
+    validate:
+      pattern:
+        metadata:
+          name: "web-*"
+  - name: default-label
+    match:
+      resources:
+        kinds:
+        - Pod
+    mutate:
+      overlay:
+        metadata:
+          labels:
+            +(team): web
+`
+
+func Test_Generate(t *testing.T) {
+	policies, err := common.DecodePolicies([]byte(testPolicy))
+	assert.NilError(t, err)
+	resources, skipped := Generate(policies[0])
+	assert.DeepEqual(t, skipped, []Skipped{{Policy: "pod-security", Rule: "same-namespace", Reason: "the preconditions of the rule are not supported"}})
+	assert.Equal(t, len(resources), 4)
+
+	vap := resources[0]
+	assert.Equal(t, vap.GetKind(), "ValidatingAdmissionPolicy")
+	assert.Equal(t, vap.GetName(), "pod-security-host-network")
+	assert.Equal(t, vap.GetAnnotations()[SourceAnnotation], "pod-security/host-network")
+	rules, _, _ := unstructured.NestedSlice(vap.Object, "spec", "matchConstraints", "resourceRules")
+	assert.DeepEqual(t, rules, []interface{}{map[string]interface{}{
+		"apiGroups":   []interface{}{""},
+		"apiVersions": []interface{}{"v1"},
+		"operations":  []interface{}{"CREATE", "UPDATE"},
+		"resources":   []interface{}{"pods"},
+	}})
+	namespaces, _, _ := unstructured.NestedSlice(vap.Object, "spec", "matchConstraints", "namespaceSelector", "matchExpressions")
+	assert.DeepEqual(t, namespaces, []interface{}{map[string]interface{}{
+		"key":      namespaceLabel,
+		"operator": "NotIn",
+		"values":   []interface{}{"kube-system"},
+	}})
+	labels, _, _ := unstructured.NestedStringMap(vap.Object, "spec", "matchConstraints", "objectSelector", "matchLabels")
+	assert.DeepEqual(t, labels, map[string]string{"team": "web"})
+	validations, _, _ := unstructured.NestedSlice(vap.Object, "spec", "validations")
+	assert.DeepEqual(t, validations, []interface{}{map[string]interface{}{
+		"expression": "has(object.spec) && (!has(object.spec.hostNetwork) || object.spec.hostNetwork == false)",
+		"message":    "Sharing the host network is not allowed.",
+	}})
+
+	binding := resources[1]
+	assert.Equal(t, binding.GetKind(), "ValidatingAdmissionPolicyBinding")
+	assert.Equal(t, binding.GetName(), "pod-security-host-network-binding")
+	policyName, _, _ := unstructured.NestedString(binding.Object, "spec", "policyName")
+	assert.Equal(t, policyName, "pod-security-host-network")
+	actions, _, _ := unstructured.NestedStringSlice(binding.Object, "spec", "validationActions")
+	assert.DeepEqual(t, actions, []string{"Deny"})
+
+	validations, _, _ = unstructured.NestedSlice(resources[2].Object, "spec", "validations")
+	assert.DeepEqual(t, validations, []interface{}{map[string]interface{}{
+		"expression": "(has(object.spec) && has(object.spec.securityContext) && has(object.spec.securityContext.runAsNonRoot) && object.spec.securityContext.runAsNonRoot == true) || " +
+			"(has(object.spec) && has(object.spec.securityContext) && has(object.spec.securityContext.runAsUser) && object.spec.securityContext.runAsUser > 0)",
+		"message": "validation error: rule run-as-non-root of the policy pod-security failed",
+	}})
+
+	// the violations of the audited policies are reported
+	policy := policies[0]
+	policy.Spec.ValidationFailureAction = "audit"
+	policy.Spec.Rules = []kyverno.Rule{policy.Spec.Rules[0]}
+	policy.Spec.Rules[0].MatchResources.Kinds = []string{"Pod", "CustomResource"}
+	resources, skipped = Generate(policy)
+	assert.Equal(t, len(resources), 0)
+	assert.DeepEqual(t, skipped, []Skipped{{Policy: "pod-security", Rule: "host-network", Reason: "the kind CustomResource is not supported"}})
+	policy.Spec.Rules[0].MatchResources.Kinds = []string{"Pod"}
+	resources, _ = Generate(policy)
+	actions, _, _ = unstructured.NestedStringSlice(resources[1].Object, "spec", "validationActions")
+	assert.DeepEqual(t, actions, []string{"Audit"})
+}
@@ -25,7 +25,7 @@ func (wrc *WebhookRegistrationClient) constructVerifyMutatingWebhookConfig(caDat
 				config.VerifyMutatingWebhookServicePath,
 				caData,
 				true,
-				wrc.timeoutSeconds,
+				wrc.timeout(),
 				"deployments/*",
 				"apps",
 				"v1",
@@ -48,7 +48,7 @@ func (wrc *WebhookRegistrationClient) constructDebugVerifyMutatingWebhookConfig(
 				url,
 				caData,
 				true,
-				wrc.timeoutSeconds,
+				wrc.timeout(),
 				"deployments/*",
 				"apps",
 				"v1",
@@ -24,10 +24,21 @@ func (wrc *WebhookRegistrationClient) contructPolicyValidatingWebhookConfig(caDa
 				config.PolicyValidatingWebhookServicePath,
 				caData,
 				true,
-				wrc.timeoutSeconds,
+				wrc.timeout(),
 				"clusterpolicies/*",
 				"kyverno.io",
-				"v1",
+				"*",
+				[]admregapi.OperationType{admregapi.Create, admregapi.Update},
+			),
+			generateWebhook(
+				config.NamespacedPolicyValidatingWebhookName,
+				config.NamespacedPolicyValidatingWebhookServicePath,
+				caData,
+				true,
+				wrc.timeout(),
+				"policies/*",
+				"kyverno.io",
+				"*",
 				[]admregapi.OperationType{admregapi.Create, admregapi.Update},
 			),
 		},
@@ -37,6 +48,7 @@ func (wrc *WebhookRegistrationClient) contructPolicyValidatingWebhookConfig(caDa
 func (wrc *WebhookRegistrationClient) contructDebugPolicyValidatingWebhookConfig(caData []byte) *admregapi.ValidatingWebhookConfiguration {
 	url := fmt.Sprintf("https://%s%s", wrc.serverIP, config.PolicyValidatingWebhookServicePath)
 	glog.V(4).Infof("Debug PolicyValidatingWebhookConfig is registered with url %s\n", url)
+	namespacedURL := fmt.Sprintf("https://%s%s", wrc.serverIP, config.NamespacedPolicyValidatingWebhookServicePath)
 
 	return &admregapi.ValidatingWebhookConfiguration{
 		ObjectMeta: v1.ObjectMeta{
@@ -48,10 +60,21 @@ func (wrc *WebhookRegistrationClient) contructDebugPolicyValidatingWebhookConfig
 				url,
 				caData,
 				true,
-				wrc.timeoutSeconds,
+				wrc.timeout(),
 				"clusterpolicies/*",
 				"kyverno.io",
-				"v1",
+				"*",
+				[]admregapi.OperationType{admregapi.Create, admregapi.Update},
+			),
+			generateDebugWebhook(
+				config.NamespacedPolicyValidatingWebhookName,
+				namespacedURL,
+				caData,
+				true,
+				wrc.timeout(),
+				"policies/*",
+				"kyverno.io",
+				"*",
 				[]admregapi.OperationType{admregapi.Create, admregapi.Update},
 			),
 		},
@@ -72,10 +95,21 @@ func (wrc *WebhookRegistrationClient) contructPolicyMutatingWebhookConfig(caData
 				config.PolicyMutatingWebhookServicePath,
 				caData,
 				true,
-				wrc.timeoutSeconds,
+				wrc.timeout(),
 				"clusterpolicies/*",
 				"kyverno.io",
-				"v1",
+				"*",
+				[]admregapi.OperationType{admregapi.Create, admregapi.Update},
+			),
+			generateWebhook(
+				config.NamespacedPolicyMutatingWebhookName,
+				config.PolicyMutatingWebhookServicePath,
+				caData,
+				true,
+				wrc.timeout(),
+				"policies/*",
+				"kyverno.io",
+				"*",
 				[]admregapi.OperationType{admregapi.Create, admregapi.Update},
 			),
 		},
@@ -95,10 +129,21 @@ func (wrc *WebhookRegistrationClient) contructDebugPolicyMutatingWebhookConfig(c
 				url,
 				caData,
 				true,
-				wrc.timeoutSeconds,
+				wrc.timeout(),
 				"clusterpolicies/*",
 				"kyverno.io",
-				"v1",
+				"*",
+				[]admregapi.OperationType{admregapi.Create, admregapi.Update},
+			),
+			generateDebugWebhook(
+				config.NamespacedPolicyMutatingWebhookName,
+				url,
+				caData,
+				true,
+				wrc.timeout(),
+				"policies/*",
+				"kyverno.io",
+				"*",
 				[]admregapi.OperationType{admregapi.Create, admregapi.Update},
 			),
 		},
@@ -1,8 +1,12 @@
 package webhookconfig
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/glog"
@@ -25,7 +29,8 @@ type WebhookRegistrationClient struct {
 	client       *client.Client
 	clientConfig *rest.Config
 	// serverIP should be used if running Kyverno out of clutser
-	serverIP       string
+	serverIP string
+	// timeoutSeconds is read and set atomically, it is reloaded from the KyvernoConfig
 	timeoutSeconds int32
 }
 
@@ -43,6 +48,73 @@ func NewWebhookRegistrationClient(
 	}
 }
 
+func (wrc *WebhookRegistrationClient) timeout() int32 {
+	return atomic.LoadInt32(&wrc.timeoutSeconds)
+}
+
+//SetTimeout sets the timeout of the webhook configurations, and updates the registered webhook configurations
+// if it changed
+func (wrc *WebhookRegistrationClient) SetTimeout(timeoutSeconds int32) error {
+	if atomic.SwapInt32(&wrc.timeoutSeconds, timeoutSeconds) == timeoutSeconds {
+		return nil
+	}
+	glog.Infof("Updating the timeout of the webhook configurations to %ds", timeoutSeconds)
+	configurations := map[string]string{
+		config.MutatingWebhookConfigurationName:         MutatingWebhookConfigurationKind,
+		config.PolicyMutatingWebhookConfigurationName:   MutatingWebhookConfigurationKind,
+		config.VerifyMutatingWebhookConfigurationName:   MutatingWebhookConfigurationKind,
+		config.PolicyValidatingWebhookConfigurationName: ValidatingWebhookConfigurationKind,
+	}
+	if wrc.serverIP != "" {
+		configurations = map[string]string{
+			config.MutatingWebhookConfigurationDebugName:         MutatingWebhookConfigurationKind,
+			config.PolicyMutatingWebhookConfigurationDebugName:   MutatingWebhookConfigurationKind,
+			config.VerifyMutatingWebhookConfigurationDebugName:   MutatingWebhookConfigurationKind,
+			config.PolicyValidatingWebhookConfigurationDebugName: ValidatingWebhookConfigurationKind,
+		}
+	}
+	var failed []string
+	for name, kind := range configurations {
+		if err := wrc.updateTimeout(kind, name, timeoutSeconds); err != nil {
+			glog.Errorf("failed to update the timeout of the webhook configuration %s: %v", name, err)
+			failed = append(failed, name)
+		}
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("failed to update the timeout of the webhook configurations %v", failed)
+	}
+	return nil
+}
+
+// updateTimeout sets the timeout of the webhooks of the webhook configuration, if it is registered
+func (wrc *WebhookRegistrationClient) updateTimeout(kind, name string, timeoutSeconds int32) error {
+	obj, err := wrc.client.GetResource(kind, "", name)
+	if errorsapi.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	webhooks, _ := obj.Object["webhooks"].([]interface{})
+	var patch []interface{}
+	for i := range webhooks {
+		patch = append(patch, map[string]interface{}{
+			"op":    "add",
+			"path":  fmt.Sprintf("/webhooks/%d/timeoutSeconds", i),
+			"value": timeoutSeconds,
+		})
+	}
+	if len(patch) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = wrc.client.PatchResource(kind, "", name, data)
+	return err
+}
+
 // Register creates admission webhooks configs on cluster
 func (wrc *WebhookRegistrationClient) Register() error {
 	if wrc.serverIP != "" {
@@ -75,6 +147,102 @@ func (wrc *WebhookRegistrationClient) Register() error {
 	return nil
 }
 
+// EnsureRegistered creates the missing webhook configurations, and updates the webhooks of the existing ones
+// if they differ, e.g. after an upgrade that added webhooks.
+// It is used by the leader of the replicas, the webhook configurations are shared by the replicas
+func (wrc *WebhookRegistrationClient) EnsureRegistered() error {
+	verifyConfig, err := wrc.verifyMutatingWebhookConfig()
+	if err != nil {
+		return err
+	}
+	if err := wrc.ensureWebhooks(MutatingWebhookConfigurationKind, verifyConfig.Name, *verifyConfig, verifyConfig.Webhooks); err != nil {
+		return err
+	}
+	validatingConfig, err := wrc.policyValidatingWebhookConfig()
+	if err != nil {
+		return err
+	}
+	if err := wrc.ensureWebhooks(ValidatingWebhookConfigurationKind, validatingConfig.Name, *validatingConfig, validatingConfig.Webhooks); err != nil {
+		return err
+	}
+	mutatingConfig, err := wrc.policyMutatingWebhookConfig()
+	if err != nil {
+		return err
+	}
+	if err := wrc.ensureWebhooks(MutatingWebhookConfigurationKind, mutatingConfig.Name, *mutatingConfig, mutatingConfig.Webhooks); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ensureWebhooks creates the webhook configuration, or replaces the webhooks of the existing configuration
+// if they differ from the webhooks
+func (wrc *WebhookRegistrationClient) ensureWebhooks(kind, name string, config interface{}, webhooks []admregapi.Webhook) error {
+	_, err := wrc.client.CreateResource(kind, "", config, false)
+	if err == nil {
+		glog.V(4).Infof("created %s %s", kind, name)
+		return nil
+	}
+	if !errorsapi.IsAlreadyExists(err) {
+		return err
+	}
+	obj, err := wrc.client.GetResource(kind, "", name)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(obj.Object["webhooks"])
+	if err != nil {
+		return err
+	}
+	var existing []admregapi.Webhook
+	if err := json.Unmarshal(raw, &existing); err != nil {
+		return fmt.Errorf("failed to decode the webhooks of %s %s: %v", kind, name, err)
+	}
+	if !webhooksChanged(webhooks, existing) {
+		return nil
+	}
+	glog.Infof("updating the webhooks of %s %s", kind, name)
+	patch, err := json.Marshal([]interface{}{map[string]interface{}{
+		"op":    "replace",
+		"path":  "/webhooks",
+		"value": webhooks,
+	}})
+	if err != nil {
+		return err
+	}
+	_, err = wrc.client.PatchResource(kind, "", name, patch)
+	return err
+}
+
+// webhooksChanged compares the fields of the webhooks set by kyverno, the fields defaulted by the API server are ignored
+func webhooksChanged(desired, existing []admregapi.Webhook) bool {
+	if len(desired) != len(existing) {
+		return true
+	}
+	for i := range desired {
+		d, e := desired[i], existing[i]
+		if d.Name != e.Name ||
+			!reflect.DeepEqual(d.ClientConfig, e.ClientConfig) ||
+			!reflect.DeepEqual(d.FailurePolicy, e.FailurePolicy) ||
+			!reflect.DeepEqual(d.SideEffects, e.SideEffects) ||
+			!reflect.DeepEqual(d.TimeoutSeconds, e.TimeoutSeconds) ||
+			!reflect.DeepEqual(d.AdmissionReviewVersions, e.AdmissionReviewVersions) ||
+			len(d.Rules) != len(e.Rules) {
+			return true
+		}
+		for j := range d.Rules {
+			dr, er := d.Rules[j], e.Rules[j]
+			if !reflect.DeepEqual(dr.Operations, er.Operations) ||
+				!reflect.DeepEqual(dr.APIGroups, er.APIGroups) ||
+				!reflect.DeepEqual(dr.APIVersions, er.APIVersions) ||
+				!reflect.DeepEqual(dr.Resources, er.Resources) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // RemoveWebhookConfigurations removes webhook configurations for reosurces and policy
 // called during webhook server shutdown
 func (wrc *WebhookRegistrationClient) RemoveWebhookConfigurations(cleanUp chan<- struct{}) {
@@ -87,26 +255,13 @@ func (wrc *WebhookRegistrationClient) RemoveWebhookConfigurations(cleanUp chan<-
 //CreateResourceMutatingWebhookConfiguration create a Mutatingwebhookconfiguration resource for all resource type
 // used to forward request to kyverno webhooks to apply policeis
 // Mutationg webhook is be used for Mutating & Validating purpose
-func (wrc *WebhookRegistrationClient) CreateResourceMutatingWebhookConfiguration() error {
-	var caData []byte
-	var config *admregapi.MutatingWebhookConfiguration
-
-	// read CA data from
-	// 1) secret(config)
-	// 2) kubeconfig
-	if caData = wrc.readCaData(); caData == nil {
-		return errors.New("Unable to extract CA data from configuration")
-	}
-	// if serverIP is specified we assume its debug mode
-	if wrc.serverIP != "" {
-		// debug mode
-		// clientConfig - URL
-		config = wrc.contructDebugMutatingWebhookConfig(caData)
-	} else {
-		// clientConfig - service
-		config = wrc.constructMutatingWebhookConfig(caData)
+// failClosed adds the webhook of the policies with the Fail failure policy
+func (wrc *WebhookRegistrationClient) CreateResourceMutatingWebhookConfiguration(failClosed bool) error {
+	config, err := wrc.resourceMutatingWebhookConfig(failClosed)
+	if err != nil {
+		return err
 	}
-	_, err := wrc.client.CreateResource(MutatingWebhookConfigurationKind, "", *config, false)
+	_, err = wrc.client.CreateResource(MutatingWebhookConfigurationKind, "", *config, false)
 	if errorsapi.IsAlreadyExists(err) {
 		glog.V(4).Infof("resource mutating webhook configuration %s, already exists. not creating one", config.Name)
 		return nil
@@ -118,26 +273,51 @@ func (wrc *WebhookRegistrationClient) CreateResourceMutatingWebhookConfiguration
 	return nil
 }
 
-//registerPolicyValidatingWebhookConfiguration create a Validating webhook configuration for Policy CRD
-func (wrc *WebhookRegistrationClient) createPolicyValidatingWebhookConfiguration() error {
-	var caData []byte
-	var config *admregapi.ValidatingWebhookConfiguration
+//UpdateResourceMutatingWebhookConfiguration replaces the webhooks of the resource webhook configuration,
+// when the webhook of the policies with the Fail failure policy is added or removed
+func (wrc *WebhookRegistrationClient) UpdateResourceMutatingWebhookConfiguration(failClosed bool) error {
+	config, err := wrc.resourceMutatingWebhookConfig(failClosed)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal([]interface{}{map[string]interface{}{
+		"op":    "replace",
+		"path":  "/webhooks",
+		"value": config.Webhooks,
+	}})
+	if err != nil {
+		return err
+	}
+	if _, err := wrc.client.PatchResource(MutatingWebhookConfigurationKind, "", config.Name, patch); err != nil {
+		glog.V(4).Infof("failed to update resource mutating webhook configuration %s: %v", config.Name, err)
+		return err
+	}
+	return nil
+}
 
+func (wrc *WebhookRegistrationClient) resourceMutatingWebhookConfig(failClosed bool) (*admregapi.MutatingWebhookConfiguration, error) {
 	// read CA data from
 	// 1) secret(config)
 	// 2) kubeconfig
-	if caData = wrc.readCaData(); caData == nil {
-		return errors.New("Unable to extract CA data from configuration")
+	caData := wrc.readCaData()
+	if caData == nil {
+		return nil, errors.New("Unable to extract CA data from configuration")
 	}
-
 	// if serverIP is specified we assume its debug mode
 	if wrc.serverIP != "" {
 		// debug mode
 		// clientConfig - URL
-		config = wrc.contructDebugPolicyValidatingWebhookConfig(caData)
-	} else {
-		// clientConfig - service
-		config = wrc.contructPolicyValidatingWebhookConfig(caData)
+		return wrc.contructDebugMutatingWebhookConfig(caData, failClosed), nil
+	}
+	// clientConfig - service
+	return wrc.constructMutatingWebhookConfig(caData, failClosed), nil
+}
+
+//registerPolicyValidatingWebhookConfiguration create a Validating webhook configuration for Policy CRD
+func (wrc *WebhookRegistrationClient) createPolicyValidatingWebhookConfiguration() error {
+	config, err := wrc.policyValidatingWebhookConfig()
+	if err != nil {
+		return err
 	}
 
 	// create validating webhook configuration resource
@@ -150,24 +330,24 @@ func (wrc *WebhookRegistrationClient) createPolicyValidatingWebhookConfiguration
 }
 
 func (wrc *WebhookRegistrationClient) createPolicyMutatingWebhookConfiguration() error {
-	var caData []byte
-	var config *admregapi.MutatingWebhookConfiguration
+	config, err := wrc.policyMutatingWebhookConfig()
+	if err != nil {
+		return err
+	}
 
-	// read CA data from
-	// 1) secret(config)
-	// 2) kubeconfig
-	if caData = wrc.readCaData(); caData == nil {
-		return errors.New("Unable to extract CA data from configuration")
+	// create mutating webhook configuration resource
+	if _, err := wrc.client.CreateResource(MutatingWebhookConfigurationKind, "", *config, false); err != nil {
+		return err
 	}
 
-	// if serverIP is specified we assume its debug mode
-	if wrc.serverIP != "" {
-		// debug mode
-		// clientConfig - URL
-		config = wrc.contructDebugPolicyMutatingWebhookConfig(caData)
-	} else {
-		// clientConfig - service
-		config = wrc.contructPolicyMutatingWebhookConfig(caData)
+	glog.V(4).Infof("created Mutating Webhook Configuration %s ", config.Name)
+	return nil
+}
+
+func (wrc *WebhookRegistrationClient) createVerifyMutatingWebhookConfiguration() error {
+	config, err := wrc.verifyMutatingWebhookConfig()
+	if err != nil {
+		return err
 	}
 
 	// create mutating webhook configuration resource
@@ -179,34 +359,44 @@ func (wrc *WebhookRegistrationClient) createPolicyMutatingWebhookConfiguration()
 	return nil
 }
 
-func (wrc *WebhookRegistrationClient) createVerifyMutatingWebhookConfiguration() error {
-	var caData []byte
-	var config *admregapi.MutatingWebhookConfiguration
-
+// policyValidatingWebhookConfig returns the validating webhook configuration of the policies
+func (wrc *WebhookRegistrationClient) policyValidatingWebhookConfig() (*admregapi.ValidatingWebhookConfiguration, error) {
 	// read CA data from
 	// 1) secret(config)
 	// 2) kubeconfig
-	if caData = wrc.readCaData(); caData == nil {
-		return errors.New("Unable to extract CA data from configuration")
+	caData := wrc.readCaData()
+	if caData == nil {
+		return nil, errors.New("Unable to extract CA data from configuration")
 	}
-
 	// if serverIP is specified we assume its debug mode
 	if wrc.serverIP != "" {
-		// debug mode
-		// clientConfig - URL
-		config = wrc.constructDebugVerifyMutatingWebhookConfig(caData)
-	} else {
-		// clientConfig - service
-		config = wrc.constructVerifyMutatingWebhookConfig(caData)
+		return wrc.contructDebugPolicyValidatingWebhookConfig(caData), nil
 	}
+	return wrc.contructPolicyValidatingWebhookConfig(caData), nil
+}
 
-	// create mutating webhook configuration resource
-	if _, err := wrc.client.CreateResource(MutatingWebhookConfigurationKind, "", *config, false); err != nil {
-		return err
+// policyMutatingWebhookConfig returns the mutating webhook configuration of the policies
+func (wrc *WebhookRegistrationClient) policyMutatingWebhookConfig() (*admregapi.MutatingWebhookConfiguration, error) {
+	caData := wrc.readCaData()
+	if caData == nil {
+		return nil, errors.New("Unable to extract CA data from configuration")
 	}
+	if wrc.serverIP != "" {
+		return wrc.contructDebugPolicyMutatingWebhookConfig(caData), nil
+	}
+	return wrc.contructPolicyMutatingWebhookConfig(caData), nil
+}
 
-	glog.V(4).Infof("created Mutating Webhook Configuration %s ", config.Name)
-	return nil
+// verifyMutatingWebhookConfig returns the mutating webhook configuration used to check if the admission control is enabled
+func (wrc *WebhookRegistrationClient) verifyMutatingWebhookConfig() (*admregapi.MutatingWebhookConfiguration, error) {
+	caData := wrc.readCaData()
+	if caData == nil {
+		return nil, errors.New("Unable to extract CA data from configuration")
+	}
+	if wrc.serverIP != "" {
+		return wrc.constructDebugVerifyMutatingWebhookConfig(caData), nil
+	}
+	return wrc.constructVerifyMutatingWebhookConfig(caData), nil
 }
 
 // DeregisterAll deletes webhook configs from cluster
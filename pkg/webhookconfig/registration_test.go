@@ -5,8 +5,15 @@ import (
 	"io/ioutil"
 	"testing"
 
+	dclient "github.com/nirmata/kyverno/pkg/dclient"
 	"gotest.tools/assert"
+	admregapi "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	rest "k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
 )
 
 func TestExtractCA_EmptyBundle(t *testing.T) {
@@ -62,3 +69,70 @@ func TestExtractCA_InvalidFile(t *testing.T) {
 	actual := extractCA(config)
 	assert.Assert(t, actual == nil)
 }
+
+func TestEnsureWebhooks(t *testing.T) {
+	client, err := dclient.NewMockClient(runtime.NewScheme())
+	assert.NilError(t, err)
+	client.SetDiscovery(dclient.NewFakeDiscoveryClient([]schema.GroupVersionResource{
+		{Group: "admissionregistration.k8s.io", Version: "v1beta1", Resource: "mutatingwebhookconfigurations"},
+	}))
+	var patches int
+	client.PrependReactor("patch", "mutatingwebhookconfigurations", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patches++
+		return false, nil, nil
+	})
+	wrc := &WebhookRegistrationClient{client: client}
+	config := func(webhooks ...admregapi.Webhook) admregapi.MutatingWebhookConfiguration {
+		return admregapi.MutatingWebhookConfiguration{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "admissionregistration.k8s.io/v1beta1", Kind: MutatingWebhookConfigurationKind},
+			ObjectMeta: metav1.ObjectMeta{Name: "kyverno-policy-mutating-webhook-cfg"},
+			Webhooks:   webhooks,
+		}
+	}
+	ensure := func(webhooks ...admregapi.Webhook) {
+		assert.NilError(t, wrc.ensureWebhooks(MutatingWebhookConfigurationKind, "kyverno-policy-mutating-webhook-cfg", config(webhooks...), webhooks))
+	}
+	operations := []admregapi.OperationType{admregapi.Create, admregapi.Update}
+	policies := generateWebhook("nirmata.kyverno.policy-mutating-webhook", "/policymutate", []byte("ca"), false, 10, "clusterpolicies/*", "kyverno.io", "*", operations)
+	namespacedPolicies := generateWebhook("nirmata.kyverno.namespaced-policy-mutating-webhook", "/namespacedpolicymutate", []byte("ca"), false, 10, "policies/*", "kyverno.io", "*", operations)
+
+	// the configuration is created
+	ensure(policies)
+	assert.Equal(t, patches, 0)
+	// the configuration is not updated if its webhooks did not change
+	ensure(policies)
+	assert.Equal(t, patches, 0)
+
+	// the webhooks added by an upgrade are registered
+	ensure(policies, namespacedPolicies)
+	assert.Equal(t, patches, 1)
+	obj, err := client.GetResource(MutatingWebhookConfigurationKind, "", "kyverno-policy-mutating-webhook-cfg")
+	assert.NilError(t, err)
+	webhooks, _, _ := unstructured.NestedSlice(obj.Object, "webhooks")
+	assert.Equal(t, len(webhooks), 2)
+	ensure(policies, namespacedPolicies)
+	assert.Equal(t, patches, 1)
+}
+
+func TestWebhooksChanged(t *testing.T) {
+	operations := []admregapi.OperationType{admregapi.Create, admregapi.Update}
+	webhook := generateWebhook("nirmata.kyverno.policy-mutating-webhook", "/policymutate", []byte("ca"), false, 10, "clusterpolicies/*", "kyverno.io", "*", operations)
+	assert.Assert(t, !webhooksChanged([]admregapi.Webhook{webhook}, []admregapi.Webhook{webhook}))
+
+	// the fields defaulted by the API server are ignored
+	defaulted := *webhook.DeepCopy()
+	scope := admregapi.AllScopes
+	defaulted.Rules[0].Scope = &scope
+	defaulted.NamespaceSelector = &metav1.LabelSelector{}
+	assert.Assert(t, !webhooksChanged([]admregapi.Webhook{webhook}, []admregapi.Webhook{defaulted}))
+
+	path := *webhook.DeepCopy()
+	servicePath := "/policyvalidate"
+	path.ClientConfig.Service.Path = &servicePath
+	assert.Assert(t, webhooksChanged([]admregapi.Webhook{webhook}, []admregapi.Webhook{path}))
+
+	rules := *webhook.DeepCopy()
+	rules.Rules[0].Operations = []admregapi.OperationType{admregapi.Create}
+	assert.Assert(t, webhooksChanged([]admregapi.Webhook{webhook}, []admregapi.Webhook{rules}))
+	assert.Assert(t, webhooksChanged([]admregapi.Webhook{webhook}, nil))
+}
@@ -10,11 +10,11 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func (wrc *WebhookRegistrationClient) contructDebugMutatingWebhookConfig(caData []byte) *admregapi.MutatingWebhookConfiguration {
+func (wrc *WebhookRegistrationClient) contructDebugMutatingWebhookConfig(caData []byte, failClosed bool) *admregapi.MutatingWebhookConfiguration {
 	url := fmt.Sprintf("https://%s%s", wrc.serverIP, config.MutatingWebhookServicePath)
 	glog.V(4).Infof("Debug MutatingWebhookConfig is registered with url %s\n", url)
 
-	return &admregapi.MutatingWebhookConfiguration{
+	mutatingConfig := &admregapi.MutatingWebhookConfiguration{
 		ObjectMeta: v1.ObjectMeta{
 			Name: config.MutatingWebhookConfigurationDebugName,
 		},
@@ -24,7 +24,7 @@ func (wrc *WebhookRegistrationClient) contructDebugMutatingWebhookConfig(caData
 				url,
 				caData,
 				true,
-				wrc.timeoutSeconds,
+				wrc.timeout(),
 				"*/*",
 				"*",
 				"*",
@@ -32,10 +32,25 @@ func (wrc *WebhookRegistrationClient) contructDebugMutatingWebhookConfig(caData
 			),
 		},
 	}
+	if failClosed {
+		failURL := fmt.Sprintf("https://%s%s", wrc.serverIP, config.MutatingWebhookFailServicePath)
+		mutatingConfig.Webhooks = append(mutatingConfig.Webhooks, failWebhook(generateDebugWebhook(
+			config.MutatingWebhookFailName,
+			failURL,
+			caData,
+			true,
+			wrc.timeout(),
+			"*/*",
+			"*",
+			"*",
+			[]admregapi.OperationType{admregapi.Create, admregapi.Update},
+		)))
+	}
+	return mutatingConfig
 }
 
-func (wrc *WebhookRegistrationClient) constructMutatingWebhookConfig(caData []byte) *admregapi.MutatingWebhookConfiguration {
-	return &admregapi.MutatingWebhookConfiguration{
+func (wrc *WebhookRegistrationClient) constructMutatingWebhookConfig(caData []byte, failClosed bool) *admregapi.MutatingWebhookConfiguration {
+	mutatingConfig := &admregapi.MutatingWebhookConfiguration{
 		ObjectMeta: v1.ObjectMeta{
 			Name: config.MutatingWebhookConfigurationName,
 			OwnerReferences: []v1.OwnerReference{
@@ -48,7 +63,7 @@ func (wrc *WebhookRegistrationClient) constructMutatingWebhookConfig(caData []by
 				config.MutatingWebhookServicePath,
 				caData,
 				false,
-				wrc.timeoutSeconds,
+				wrc.timeout(),
 				"*/*",
 				"*",
 				"*",
@@ -56,6 +71,37 @@ func (wrc *WebhookRegistrationClient) constructMutatingWebhookConfig(caData []by
 			),
 		},
 	}
+	if failClosed {
+		mutatingConfig.Webhooks = append(mutatingConfig.Webhooks, failWebhook(generateWebhook(
+			config.MutatingWebhookFailName,
+			config.MutatingWebhookFailServicePath,
+			caData,
+			false,
+			wrc.timeout(),
+			"*/*",
+			"*",
+			"*",
+			[]admregapi.OperationType{admregapi.Create, admregapi.Update},
+		)))
+	}
+	return mutatingConfig
+}
+
+// failWebhook sets the Fail failure policy on the webhook, the requests are rejected if the webhook server is unavailable
+func failWebhook(webhook admregapi.Webhook) admregapi.Webhook {
+	failurePolicy := admregapi.Fail
+	webhook.FailurePolicy = &failurePolicy
+	return webhook
+}
+
+// HasFailWebhook returns true if the resource webhook configuration has the webhook of the policies with the Fail failure policy
+func HasFailWebhook(mutatingConfig *admregapi.MutatingWebhookConfiguration) bool {
+	for _, webhook := range mutatingConfig.Webhooks {
+		if webhook.Name == config.MutatingWebhookFailName {
+			return true
+		}
+	}
+	return false
 }
 
 //GetResourceMutatingWebhookConfigName provi
@@ -4,8 +4,14 @@ import (
 	"time"
 
 	"github.com/golang/glog"
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	checker "github.com/nirmata/kyverno/pkg/checker"
+	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
+	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
 	"github.com/tevino/abool"
+	admregapi "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	mconfiginformer "k8s.io/client-go/informers/admissionregistration/v1beta1"
 	mconfiglister "k8s.io/client-go/listers/admissionregistration/v1beta1"
 	cache "k8s.io/client-go/tools/cache"
@@ -20,12 +26,19 @@ type ResourceWebhookRegister struct {
 	// list/get mutatingwebhookconfigurations
 	mWebhookConfigLister      mconfiglister.MutatingWebhookConfigurationLister
 	webhookRegistrationClient *WebhookRegistrationClient
+	// list the policies, the webhook of the policies with the Fail failure policy is registered if one of them exists
+	pLister   kyvernolister.ClusterPolicyLister
+	nspLister kyvernolister.PolicyLister
+	pSynced   cache.InformerSynced
+	nspSynced cache.InformerSynced
 }
 
 // NewResourceWebhookRegister returns a new instance of ResourceWebhookRegister manager
 func NewResourceWebhookRegister(
 	lastReqTime *checker.LastReqTime,
 	mconfigwebhookinformer mconfiginformer.MutatingWebhookConfigurationInformer,
+	pInformer kyvernoinformer.ClusterPolicyInformer,
+	nspInformer kyvernoinformer.PolicyInformer,
 	webhookRegistrationClient *WebhookRegistrationClient,
 ) *ResourceWebhookRegister {
 	return &ResourceWebhookRegister{
@@ -34,6 +47,10 @@ func NewResourceWebhookRegister(
 		mwebhookconfigSynced:      mconfigwebhookinformer.Informer().HasSynced,
 		mWebhookConfigLister:      mconfigwebhookinformer.Lister(),
 		webhookRegistrationClient: webhookRegistrationClient,
+		pLister:                   pInformer.Lister(),
+		nspLister:                 nspInformer.Lister(),
+		pSynced:                   pInformer.Informer().HasSynced,
+		nspSynced:                 nspInformer.Informer().HasSynced,
 	}
 }
 
@@ -45,19 +62,33 @@ func (rww *ResourceWebhookRegister) RegisterResourceWebhook() {
 		return
 	}
 
+	failClosed := rww.failClosed()
 	// check cache
 	configName := rww.webhookRegistrationClient.GetResourceMutatingWebhookConfigName()
 	// exsitence of config is all that matters; if error occurs, creates webhook anyway
 	// errors of webhook creation are handled separately
 	config, _ := rww.mWebhookConfigLister.Get(configName)
 	if config != nil {
-		glog.V(4).Info("mutating webhoook configuration already exists, skip the request")
+		if HasFailWebhook(config) == failClosed {
+			glog.V(4).Info("mutating webhoook configuration already exists, skip the request")
+			return
+		}
+		// the webhook of the policies with the Fail failure policy is added or removed
+		rww.pendingCreation.Set()
+		go func() {
+			defer rww.pendingCreation.UnSet()
+			if err := rww.webhookRegistrationClient.UpdateResourceMutatingWebhookConfiguration(failClosed); err != nil {
+				glog.Errorf("failed to update resource mutating webhook configuration: %v", err)
+				return
+			}
+			glog.V(3).Infof("Successfully updated mutating webhook configuration for resources, fail webhook: %t", failClosed)
+		}()
 		return
 	}
 
 	createWebhook := func() {
 		rww.pendingCreation.Set()
-		err := rww.webhookRegistrationClient.CreateResourceMutatingWebhookConfiguration()
+		err := rww.webhookRegistrationClient.CreateResourceMutatingWebhookConfiguration(failClosed)
 		rww.pendingCreation.UnSet()
 
 		if err != nil {
@@ -75,10 +106,94 @@ func (rww *ResourceWebhookRegister) RegisterResourceWebhook() {
 	}
 }
 
+// failClosed returns true if a policy has the Fail failure policy
+func (rww *ResourceWebhookRegister) failClosed() bool {
+	policies, err := rww.pLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list policies: %v", err)
+	}
+	for _, p := range policies {
+		if p.Spec.FailurePolicy == kyverno.FailurePolicyFail {
+			return true
+		}
+	}
+	nsPolicies, err := rww.nspLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("failed to list namespaced policies: %v", err)
+	}
+	for _, p := range nsPolicies {
+		if p.Spec.FailurePolicy == kyverno.FailurePolicyFail {
+			return true
+		}
+	}
+	return false
+}
+
+//IsFailWebhookRegistered returns true if the resource webhook configuration has the webhook
+// of the policies with the Fail failure policy
+func (rww *ResourceWebhookRegister) IsFailWebhookRegistered() bool {
+	configName := rww.webhookRegistrationClient.GetResourceMutatingWebhookConfigName()
+	config, err := rww.mWebhookConfigLister.Get(configName)
+	return err == nil && config != nil && HasFailWebhook(config)
+}
+
+//IsResourceWebhookRegistered returns true if the resource webhook configuration exists
+func (rww *ResourceWebhookRegister) IsResourceWebhookRegistered() bool {
+	configName := rww.webhookRegistrationClient.GetResourceMutatingWebhookConfigName()
+	config, err := rww.mWebhookConfigLister.Get(configName)
+	return err == nil && config != nil
+}
+
+//IsResourceCovered returns true if the resource webhook configuration exists and
+// forwards the CREATE and UPDATE requests of the resource to the webhook server
+func (rww *ResourceWebhookRegister) IsResourceCovered(gvr schema.GroupVersionResource) bool {
+	configName := rww.webhookRegistrationClient.GetResourceMutatingWebhookConfigName()
+	config, err := rww.mWebhookConfigLister.Get(configName)
+	if err != nil || config == nil {
+		return false
+	}
+	for _, webhook := range config.Webhooks {
+		for _, rule := range webhook.Rules {
+			if ruleCovers(rule, gvr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ruleCovers returns true if the rule matches the create and update operations on the resource
+func ruleCovers(rule admregapi.RuleWithOperations, gvr schema.GroupVersionResource) bool {
+	if !containsOperation(rule.Operations, admregapi.Create) || !containsOperation(rule.Operations, admregapi.Update) {
+		return false
+	}
+	return containsValue(rule.APIGroups, gvr.Group) &&
+		containsValue(rule.APIVersions, gvr.Version) &&
+		(containsValue(rule.Resources, gvr.Resource) || containsValue(rule.Resources, "*/*"))
+}
+
+func containsOperation(operations []admregapi.OperationType, operation admregapi.OperationType) bool {
+	for _, op := range operations {
+		if op == operation || op == admregapi.OperationAll {
+			return true
+		}
+	}
+	return false
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
 //Run starts the ResourceWebhookRegister manager
 func (rww *ResourceWebhookRegister) Run(stopCh <-chan struct{}) {
 	// wait for cache to populate first time
-	if !cache.WaitForCacheSync(stopCh, rww.mwebhookconfigSynced) {
+	if !cache.WaitForCacheSync(stopCh, rww.mwebhookconfigSynced, rww.pSynced, rww.nspSynced) {
 		glog.Error("configuration: failed to sync webhook informer cache")
 	}
 }
@@ -0,0 +1,34 @@
+package webhookconfig
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	admregapi "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_ruleCovers(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	rule := func(operations []admregapi.OperationType, groups, versions, resources []string) admregapi.RuleWithOperations {
+		return admregapi.RuleWithOperations{
+			Operations: operations,
+			Rule:       admregapi.Rule{APIGroups: groups, APIVersions: versions, Resources: resources},
+		}
+	}
+	createUpdate := []admregapi.OperationType{admregapi.Create, admregapi.Update}
+
+	// the rule of the resource webhook configuration covers all the resources
+	all := rule(createUpdate, []string{"*"}, []string{"*"}, []string{"*/*"})
+	assert.Assert(t, ruleCovers(all, pods))
+	assert.Assert(t, ruleCovers(all, deployments))
+
+	podsOnly := rule(createUpdate, []string{""}, []string{"v1"}, []string{"pods"})
+	assert.Assert(t, ruleCovers(podsOnly, pods))
+	assert.Assert(t, !ruleCovers(podsOnly, deployments))
+
+	// the updates must be forwarded too
+	assert.Assert(t, !ruleCovers(rule([]admregapi.OperationType{admregapi.Create}, []string{"*"}, []string{"*"}, []string{"*/*"}), pods))
+	assert.Assert(t, ruleCovers(rule([]admregapi.OperationType{admregapi.OperationAll}, []string{"*"}, []string{"*"}, []string{"*"}), pods))
+}
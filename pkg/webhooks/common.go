@@ -1,6 +1,7 @@
 package webhooks
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -111,17 +112,43 @@ func processResourceWithPatches(patch []byte, resource []byte) []byte {
 	return resource
 }
 
+// containRBACinfo checks if the roles of the requester are needed to apply the policies
 func containRBACinfo(policies []kyverno.ClusterPolicy) bool {
 	for _, policy := range policies {
 		for _, rule := range policy.Spec.Rules {
 			if len(rule.MatchResources.Roles) > 0 || len(rule.MatchResources.ClusterRoles) > 0 {
 				return true
 			}
+			if usesRBACVariables(rule) {
+				return true
+			}
 		}
 	}
 	return false
 }
 
+// usesRBACVariables checks if the rule uses the roles of the requester as variables
+// webhookPolicies returns the policies applied by the webhook, the policies with the Fail failure policy
+// are applied by the fail webhook, or by the other webhook until the fail webhook is registered
+func webhookPolicies(policies []kyverno.ClusterPolicy, failWebhook, failWebhookRegistered bool) []kyverno.ClusterPolicy {
+	var selected []kyverno.ClusterPolicy
+	for _, policy := range policies {
+		failClosed := policy.Spec.FailurePolicy == kyverno.FailurePolicyFail
+		if failClosed == failWebhook || (failClosed && !failWebhookRegistered) {
+			selected = append(selected, policy)
+		}
+	}
+	return selected
+}
+
+func usesRBACVariables(rule kyverno.Rule) bool {
+	raw, err := json.Marshal(rule)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(raw), "request.roles") || strings.Contains(string(raw), "request.clusterRoles")
+}
+
 // extracts the new and old resource as unstructured
 func extractResources(newRaw []byte, request *v1beta1.AdmissionRequest) (unstructured.Unstructured, unstructured.Unstructured, error) {
 	var emptyResource unstructured.Unstructured
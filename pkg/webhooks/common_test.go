@@ -0,0 +1,41 @@
+package webhooks
+
+import (
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"gotest.tools/assert"
+)
+
+func Test_containRBACinfo(t *testing.T) {
+	policy := func(rule kyverno.Rule) []kyverno.ClusterPolicy {
+		return []kyverno.ClusterPolicy{{Spec: kyverno.Spec{Rules: []kyverno.Rule{rule}}}}
+	}
+	assert.Assert(t, !containRBACinfo(policy(kyverno.Rule{
+		Validation: kyverno.Validation{Message: "{{request.userInfo.username}} is not allowed"},
+	})))
+	assert.Assert(t, containRBACinfo(policy(kyverno.Rule{
+		MatchResources: kyverno.MatchResources{UserInfo: kyverno.UserInfo{ClusterRoles: []string{"admin"}}},
+	})))
+	// the roles are resolved if they are used as variables
+	assert.Assert(t, containRBACinfo(policy(kyverno.Rule{
+		Validation: kyverno.Validation{Message: "roles {{request.roles}} are not allowed"},
+	})))
+	assert.Assert(t, containRBACinfo(policy(kyverno.Rule{
+		Conditions: []kyverno.Condition{{Key: "{{request.clusterRoles}}", Operator: kyverno.Equal, Value: "admin"}},
+	})))
+}
+
+func Test_webhookPolicies(t *testing.T) {
+	policies := []kyverno.ClusterPolicy{
+		{Spec: kyverno.Spec{FailurePolicy: kyverno.FailurePolicyIgnore}},
+		{Spec: kyverno.Spec{FailurePolicy: kyverno.FailurePolicyFail}},
+		{},
+	}
+	assert.Equal(t, len(webhookPolicies(policies, false, true)), 2)
+	fail := webhookPolicies(policies, true, true)
+	assert.Equal(t, len(fail), 1)
+	assert.Equal(t, fail[0].Spec.FailurePolicy, kyverno.FailurePolicyFail)
+	// the policies with the Fail failure policy are applied until the fail webhook is registered
+	assert.Equal(t, len(webhookPolicies(policies, false, false)), 3)
+}
@@ -2,14 +2,15 @@ package generate
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	backoff "github.com/cenkalti/backoff"
 	"github.com/golang/glog"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	kyvernoclient "github.com/nirmata/kyverno/pkg/client/clientset/versioned"
+	"github.com/nirmata/kyverno/pkg/utils"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 //GenerateRequests provides interface to manage generate requests
@@ -23,6 +24,10 @@ type Generator struct {
 	ch     chan kyverno.GenerateRequestSpec
 	client *kyvernoclient.Clientset
 	stopCh <-chan struct{}
+	// closed is set once the channel is closed on shutdown
+	mu      sync.RWMutex
+	closed  bool
+	workers utils.Workers
 }
 
 //NewGenerator returns a new instance of Generate-Request resource generator
@@ -38,6 +43,11 @@ func NewGenerator(client *kyvernoclient.Clientset, stopCh <-chan struct{}) *Gene
 //Create to create generate request resoruce (blocking call if channel is full)
 func (g *Generator) Create(gr kyverno.GenerateRequestSpec) error {
 	glog.V(4).Infof("create GR %v", gr)
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.closed {
+		return fmt.Errorf("shutting down gr create channel")
+	}
 	// Send to channel
 	select {
 	case g.ch <- gr:
@@ -56,11 +66,23 @@ func (g *Generator) Run(workers int) {
 		glog.V(4).Info("Shutting down generate request")
 	}()
 	for i := 0; i < workers; i++ {
-		go wait.Until(g.process, time.Second, g.stopCh)
+		g.workers.Go(g.process, g.stopCh)
 	}
 	<-g.stopCh
 }
 
+// Drain stops accepting the generate requests and creates the queued ones, it returns false if they are not
+// created before the timeout. The created generate requests are processed by the leader, even after a failover.
+func (g *Generator) Drain(timeout time.Duration) bool {
+	g.mu.Lock()
+	if !g.closed {
+		g.closed = true
+		close(g.ch)
+	}
+	g.mu.Unlock()
+	return g.workers.Wait(timeout)
+}
+
 func (g *Generator) process() {
 	for r := range g.ch {
 		glog.V(4).Infof("received generate request %v", r)
@@ -116,3 +138,8 @@ func retryCreateResource(client *kyvernoclient.Clientset, grSpec kyverno.Generat
 
 	return nil
 }
+
+//Len returns the number of the queued generate requests
+func (g *Generator) Len() int {
+	return len(g.ch)
+}
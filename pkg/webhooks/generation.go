@@ -5,6 +5,7 @@ import (
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	"github.com/nirmata/kyverno/pkg/engine"
 	"github.com/nirmata/kyverno/pkg/engine/context"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
 	"github.com/nirmata/kyverno/pkg/engine/response"
 	"github.com/nirmata/kyverno/pkg/engine/utils"
 	"github.com/nirmata/kyverno/pkg/webhooks/generate"
@@ -42,6 +43,10 @@ func (ws *WebhookServer) HandleGenerate(request *v1beta1.AdmissionRequest, polic
 	if err != nil {
 		glog.Infof("Failed to load userInfo in context:%v", err)
 	}
+	err = ctx.AddRequestInfo(string(request.Operation), request.Namespace)
+	if err != nil {
+		glog.Infof("Failed to load request info in context:%v", err)
+	}
 	// load service account in context
 	err = ctx.AddSA(userRequestInfo.AdmissionUserInfo.Username)
 	if err != nil {
@@ -49,9 +54,15 @@ func (ws *WebhookServer) HandleGenerate(request *v1beta1.AdmissionRequest, polic
 	}
 
 	policyContext := engine.PolicyContext{
-		NewResource:   *resource,
-		AdmissionInfo: userRequestInfo,
-		Context:       ctx,
+		NewResource:         *resource,
+		AdmissionInfo:       userRequestInfo,
+		Context:             ctx,
+		Client:              ws.client,
+		ConfigMapResolver:   ws.configMapResolver,
+		ImageRegistryClient: ws.registryClient,
+		ServiceClient:       ws.serviceClient,
+		GlobalContext:       ws.globalContext,
+		Log:                 enginelog.Glog,
 	}
 
 	// engine.Generate returns a list of rules that are applicable on this resource
@@ -7,10 +7,13 @@ import (
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	"github.com/nirmata/kyverno/pkg/engine"
 	"github.com/nirmata/kyverno/pkg/engine/context"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
 	"github.com/nirmata/kyverno/pkg/engine/response"
 	engineutils "github.com/nirmata/kyverno/pkg/engine/utils"
+	"github.com/nirmata/kyverno/pkg/metrics"
 	policyctr "github.com/nirmata/kyverno/pkg/policy"
 	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"github.com/nirmata/kyverno/pkg/tracing"
 	"github.com/nirmata/kyverno/pkg/utils"
 	v1beta1 "k8s.io/api/admission/v1beta1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -18,7 +21,7 @@ import (
 
 // HandleMutation handles mutating webhook admission request
 // return value: generated patches
-func (ws *WebhookServer) HandleMutation(request *v1beta1.AdmissionRequest, resource unstructured.Unstructured, policies []kyverno.ClusterPolicy, roles, clusterRoles []string) []byte {
+func (ws *WebhookServer) HandleMutation(request *v1beta1.AdmissionRequest, resource unstructured.Unstructured, policies []kyverno.ClusterPolicy, roles, clusterRoles []string, span *tracing.Span) [][]byte {
 	glog.V(4).Infof("Receive request in mutating webhook: Kind=%s, Namespace=%s Name=%s UID=%s patchOperation=%s",
 		request.Kind.Kind, request.Namespace, request.Name, request.UID, request.Operation)
 
@@ -27,6 +30,10 @@ func (ws *WebhookServer) HandleMutation(request *v1beta1.AdmissionRequest, resou
 
 	// gather stats from the engine response
 	gatherStat := func(policyName string, policyResponse response.PolicyResponse) {
+		if policyResponse.PolicyNamespace != "" {
+			// the namespaced policies have no status
+			return
+		}
 		ps := policyctr.PolicyStat{}
 		ps.PolicyName = policyName
 		ps.Stats.MutationExecutionTime = policyResponse.ProcessingTime
@@ -77,15 +84,27 @@ func (ws *WebhookServer) HandleMutation(request *v1beta1.AdmissionRequest, resou
 	if err != nil {
 		glog.Infof("Failed to load userInfo in context:%v", err)
 	}
+	err = ctx.AddRequestInfo(string(request.Operation), request.Namespace)
+	if err != nil {
+		glog.Infof("Failed to load request info in context:%v", err)
+	}
 	err = ctx.AddSA(userRequestInfo.AdmissionUserInfo.Username)
 	if err != nil {
 		glog.Infof("Failed to load service account in context:%v", err)
 	}
 
 	policyContext := engine.PolicyContext{
-		NewResource:   resource,
-		AdmissionInfo: userRequestInfo,
-		Context:       ctx,
+		NewResource:         resource,
+		AdmissionInfo:       userRequestInfo,
+		Context:             ctx,
+		Client:              ws.client,
+		ConfigMapResolver:   ws.configMapResolver,
+		ImageRegistryClient: ws.registryClient,
+		ServiceClient:       ws.serviceClient,
+		GlobalContext:       ws.globalContext,
+		Exceptions:          ws.exceptionStore.LookUp(request.Namespace),
+		Span:                span,
+		Log:                 enginelog.Glog,
 	}
 
 	for _, policy := range policies {
@@ -108,6 +127,8 @@ func (ws *WebhookServer) HandleMutation(request *v1beta1.AdmissionRequest, resou
 		policyContext.NewResource = engineResponse.PatchedResource
 	}
 
+	metrics.RecordEngineResponses(metrics.SourceAdmission, engineResponses)
+
 	// generate annotations
 	if annPatches := generateAnnotationPatches(engineResponses); annPatches != nil {
 		patches = append(patches, annPatches)
@@ -118,11 +139,11 @@ func (ws *WebhookServer) HandleMutation(request *v1beta1.AdmissionRequest, resou
 
 	// AUDIT
 	// generate violation when response fails
-	pvInfos := policyviolation.GeneratePVsFromEngineResponse(engineResponses)
+	pvInfos := policyviolation.GeneratePVsFromEngineResponse(clusterPolicyResponses(engineResponses))
 	ws.pvGenerator.Add(pvInfos...)
 
 	// ADD EVENTS
-	events := generateEvents(engineResponses, (request.Operation == v1beta1.Update))
+	events := generateEvents(engineResponses, false, (request.Operation == v1beta1.Update))
 	ws.eventGen.Add(events...)
 
 	sendStat(false)
@@ -144,5 +165,5 @@ func (ws *WebhookServer) HandleMutation(request *v1beta1.AdmissionRequest, resou
 	glog.V(4).Infof("report: %v %s/%s/%s", time.Since(reportTime), resource.GetKind(), resource.GetNamespace(), resource.GetName())
 
 	// patches holds all the successful patches, if no patch is created, it returns nil
-	return engineutils.JoinPatches(patches)
+	return patches
 }
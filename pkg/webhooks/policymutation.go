@@ -66,6 +66,12 @@ func generateJSONPatchesForDefaults(policy *kyverno.ClusterPolicy, operation v1b
 		updateMsgs = append(updateMsgs, updateMsg)
 	}
 
+	// default 'FailurePolicy'
+	if patch, updateMsg := defaultFailurePolicy(policy); patch != nil {
+		patches = append(patches, patch)
+		updateMsgs = append(updateMsgs, updateMsg)
+	}
+
 	// TODO(shuting): enable this feature on policy UPDATE
 	if operation == v1beta1.Create {
 		patch, errs := generatePodControllerRule(*policy)
@@ -108,6 +114,29 @@ func defaultBackgroundFlag(policy *kyverno.ClusterPolicy) ([]byte, string) {
 	return nil, ""
 }
 
+func defaultFailurePolicy(policy *kyverno.ClusterPolicy) ([]byte, string) {
+	// default FailurePolicy to "Ignore" if not specified, the requests are allowed if the webhook server is unavailable
+	if policy.Spec.FailurePolicy == "" {
+		jsonPatch := struct {
+			Path  string `json:"path"`
+			Op    string `json:"op"`
+			Value string `json:"value"`
+		}{
+			"/spec/failurePolicy",
+			"add",
+			kyverno.FailurePolicyIgnore,
+		}
+		patchByte, err := json.Marshal(jsonPatch)
+		if err != nil {
+			glog.Errorf("failed to set default 'FailurePolicy' to '%s' for policy %s", kyverno.FailurePolicyIgnore, policy.Name)
+			return nil, ""
+		}
+		glog.V(4).Infof("generate JSON Patch to set default 'FailurePolicy' to '%s' for policy %s", kyverno.FailurePolicyIgnore, policy.Name)
+		return patchByte, fmt.Sprintf("default 'FailurePolicy' to '%s'", kyverno.FailurePolicyIgnore)
+	}
+	return nil, ""
+}
+
 func defaultvalidationFailureAction(policy *kyverno.ClusterPolicy) ([]byte, string) {
 	// default ValidationFailureAction to "audit" if not specified
 	if policy.Spec.ValidationFailureAction == "" {
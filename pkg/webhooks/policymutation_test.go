@@ -5,9 +5,11 @@ import (
 	"reflect"
 	"testing"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	"github.com/nirmata/kyverno/pkg/engine/utils"
 	"gotest.tools/assert"
+	v1beta1 "k8s.io/api/admission/v1beta1"
 )
 
 func compareJSONAsMap(t *testing.T, expected, actual []byte) {
@@ -548,3 +550,47 @@ func TestGeneratePodControllerRule_ValidatePattern(t *testing.T) {
 	  }`)
 	compareJSONAsMap(t, p, expectedPolicy)
 }
+
+func TestGenerateJSONPatchesForDefaults(t *testing.T) {
+	policyRaw := []byte(`{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "require-labels"
+		},
+		"spec": {
+		  "rules": []
+		}
+	  }`)
+
+	var policy kyverno.ClusterPolicy
+	assert.NilError(t, json.Unmarshal(policyRaw, &policy))
+	patches, _ := generateJSONPatchesForDefaults(&policy, v1beta1.Update)
+	patch, err := jsonpatch.DecodePatch(patches)
+	assert.NilError(t, err)
+	p, err := patch.Apply(policyRaw)
+	assert.NilError(t, err)
+
+	expectedPolicy := []byte(`{
+		"apiVersion": "kyverno.io/v1",
+		"kind": "ClusterPolicy",
+		"metadata": {
+		  "name": "require-labels"
+		},
+		"spec": {
+		  "rules": [],
+		  "validationFailureAction": "audit",
+		  "background": true,
+		  "failurePolicy": "Ignore"
+		}
+	  }`)
+	compareJSONAsMap(t, p, expectedPolicy)
+
+	// the values set in the policy are kept
+	policy.Spec.FailurePolicy = kyverno.FailurePolicyFail
+	policy.Spec.ValidationFailureAction = Enforce
+	background := false
+	policy.Spec.Background = &background
+	patches, _ = generateJSONPatchesForDefaults(&policy, v1beta1.Update)
+	assert.Assert(t, patches == nil)
+}
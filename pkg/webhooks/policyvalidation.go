@@ -3,10 +3,12 @@ package webhooks
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/golang/glog"
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	policyvalidate "github.com/nirmata/kyverno/pkg/engine/policy"
+	"github.com/nirmata/kyverno/pkg/utils"
 	v1beta1 "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -18,7 +20,7 @@ func (ws *WebhookServer) handlePolicyValidation(request *v1beta1.AdmissionReques
 		Allowed: true,
 	}
 
-	//TODO: can this happen? wont this be picked by OpenAPI spec schema ?
+	// the fields are checked by the OpenAPI schema of the CRD before the validating webhooks are called
 	raw := request.Object.Raw
 	if err := json.Unmarshal(raw, &policy); err != nil {
 		glog.Errorf("Failed to unmarshal policy admission request, err %v\n", err)
@@ -27,7 +29,11 @@ func (ws *WebhookServer) handlePolicyValidation(request *v1beta1.AdmissionReques
 				Message: fmt.Sprintf("Failed to unmarshal policy admission request err %v", err),
 			}}
 	}
-	if err := policyvalidate.Validate(*policy); err != nil {
+	err := validateAuthSecrets(policy.Spec.Rules, ws.serviceSecretNamespaces)
+	if err == nil {
+		err = policyvalidate.Validate(*policy)
+	}
+	if err != nil {
 		admissionResp = &v1beta1.AdmissionResponse{
 			Allowed: false,
 			Result: &metav1.Status{
@@ -43,3 +49,83 @@ func (ws *WebhookServer) handlePolicyValidation(request *v1beta1.AdmissionReques
 	}
 	return admissionResp
 }
+
+//handleNamespacedPolicyValidation performs the validation check on namespaced policy resource,
+// the namespaced policies can not generate resources nor match the resources of other namespaces
+func (ws *WebhookServer) handleNamespacedPolicyValidation(request *v1beta1.AdmissionRequest) *v1beta1.AdmissionResponse {
+	var policy *kyverno.Policy
+	raw := request.Object.Raw
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		glog.Errorf("Failed to unmarshal namespaced policy admission request, err %v\n", err)
+		return &v1beta1.AdmissionResponse{Allowed: false,
+			Result: &metav1.Status{
+				Message: fmt.Sprintf("Failed to unmarshal namespaced policy admission request err %v", err),
+			}}
+	}
+	if err := validateNamespacedPolicy(*policy, request.Namespace, ws.serviceSecretNamespaces); err != nil {
+		return &v1beta1.AdmissionResponse{
+			Allowed: false,
+			Result: &metav1.Status{
+				Message: err.Error(),
+			},
+		}
+	}
+	ws.resourceWebhookWatcher.RegisterResourceWebhook()
+	return &v1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+}
+
+func validateNamespacedPolicy(policy kyverno.Policy, namespace string, secretNamespaces []string) error {
+	for _, rule := range policy.Spec.Rules {
+		if rule.HasGenerate() {
+			return fmt.Errorf("rule %s: generate rules are not supported in namespaced policies", rule.Name)
+		}
+		for _, ns := range rule.MatchResources.Namespaces {
+			if ns != namespace {
+				return fmt.Errorf("rule %s: namespaced policies can only match the resources of the namespace %s, found %s", rule.Name, namespace, ns)
+			}
+		}
+		if err := validateNamespacedContext(rule, namespace); err != nil {
+			return err
+		}
+	}
+	if err := validateAuthSecrets(policy.Spec.Rules, secretNamespaces); err != nil {
+		return err
+	}
+	return policyvalidate.Validate(kyverno.ClusterPolicy(policy))
+}
+
+// validateAuthSecrets checks the authorization Secrets of the service context entries are in the namespaces
+// kyverno reads the Secrets of, the other Secrets of the cluster can not be read by the policies
+func validateAuthSecrets(rules []kyverno.Rule, namespaces []string) error {
+	for _, rule := range rules {
+		for _, entry := range rule.Context {
+			if entry.Service == nil || entry.Service.AuthSecret == nil {
+				continue
+			}
+			if ns := entry.Service.AuthSecret.Namespace; !utils.ContainsString(namespaces, ns) {
+				return fmt.Errorf("rule %s: context entry %s: the authSecret must be in the namespaces %s, found %q", rule.Name, entry.Name, strings.Join(namespaces, ", "), ns)
+			}
+		}
+	}
+	return nil
+}
+
+// validateNamespacedContext checks the context entries of the rule of a namespaced policy only read the namespace of the policy:
+// the entries are loaded with the service account of kyverno, they can not read the ConfigMaps nor the Secrets of the other
+// namespaces and the API calls are rejected, as their paths can reference any resource
+func validateNamespacedContext(rule kyverno.Rule, namespace string) error {
+	for _, entry := range rule.Context {
+		if entry.ConfigMap != nil && entry.ConfigMap.Namespace != namespace {
+			return fmt.Errorf("rule %s: context entry %s: namespaced policies can only read the ConfigMaps of the namespace %s, found %q", rule.Name, entry.Name, namespace, entry.ConfigMap.Namespace)
+		}
+		if entry.APICall != nil {
+			return fmt.Errorf("rule %s: context entry %s: API calls are not supported in namespaced policies", rule.Name, entry.Name)
+		}
+		if entry.Service != nil && entry.Service.AuthSecret != nil && entry.Service.AuthSecret.Namespace != namespace {
+			return fmt.Errorf("rule %s: context entry %s: namespaced policies can only read the Secrets of the namespace %s, found %q", rule.Name, entry.Name, namespace, entry.Service.AuthSecret.Namespace)
+		}
+	}
+	return nil
+}
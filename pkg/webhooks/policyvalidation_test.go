@@ -0,0 +1,73 @@
+package webhooks
+
+import (
+	"testing"
+
+	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
+	"gotest.tools/assert"
+)
+
+func Test_validateNamespacedPolicy(t *testing.T) {
+	secretNamespaces := []string{"kyverno", "team-a"}
+	rule := kyverno.Rule{
+		Name: "check-labels",
+		Validation: kyverno.Validation{
+			Message: "label app is required",
+			Pattern: map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "?*"}}},
+		},
+	}
+	rule.MatchResources.Kinds = []string{"Pod"}
+	policy := kyverno.Policy{Spec: kyverno.Spec{Rules: []kyverno.Rule{rule}}}
+	policy.Name = "require-labels"
+	policy.Namespace = "team-a"
+	assert.NilError(t, validateNamespacedPolicy(policy, "team-a", secretNamespaces))
+
+	// the rules can only match the namespace of the policy
+	policy.Spec.Rules[0].MatchResources.Namespaces = []string{"team-a"}
+	assert.NilError(t, validateNamespacedPolicy(policy, "team-a", secretNamespaces))
+	policy.Spec.Rules[0].MatchResources.Namespaces = []string{"team-b"}
+	assert.ErrorContains(t, validateNamespacedPolicy(policy, "team-a", secretNamespaces), "can only match the resources of the namespace team-a")
+	policy.Spec.Rules[0].MatchResources.Namespaces = nil
+
+	// the generate rules are rejected
+	policy.Spec.Rules[0].Generation = kyverno.Generation{ResourceSpec: kyverno.ResourceSpec{Kind: "ConfigMap", Name: "cm"}}
+	assert.ErrorContains(t, validateNamespacedPolicy(policy, "team-a", secretNamespaces), "generate rules are not supported")
+	policy.Spec.Rules[0].Generation = kyverno.Generation{}
+
+	// the context entries can only read the namespace of the policy
+	policy.Spec.Rules[0].Context = []kyverno.ContextEntry{{Name: "config", ConfigMap: &kyverno.ConfigMapReference{Name: "config", Namespace: "team-a"}}}
+	assert.NilError(t, validateNamespacedPolicy(policy, "team-a", secretNamespaces))
+	policy.Spec.Rules[0].Context = []kyverno.ContextEntry{{Name: "config", ConfigMap: &kyverno.ConfigMapReference{Name: "config", Namespace: "kyverno"}}}
+	assert.ErrorContains(t, validateNamespacedPolicy(policy, "team-a", secretNamespaces), "can only read the ConfigMaps of the namespace team-a")
+	policy.Spec.Rules[0].Context = []kyverno.ContextEntry{{Name: "config", ConfigMap: &kyverno.ConfigMapReference{Name: "config", Namespace: "{{request.object.metadata.labels.ns}}"}}}
+	assert.ErrorContains(t, validateNamespacedPolicy(policy, "team-a", secretNamespaces), "can only read the ConfigMaps of the namespace team-a")
+
+	policy.Spec.Rules[0].Context = []kyverno.ContextEntry{{Name: "services", APICall: &kyverno.APICall{URLPath: "/api/v1/namespaces/team-a/services"}}}
+	assert.ErrorContains(t, validateNamespacedPolicy(policy, "team-a", secretNamespaces), "API calls are not supported in namespaced policies")
+
+	service := &kyverno.ServiceCall{URL: "https://cmdb.corp.com/apps", AuthSecret: &kyverno.SecretKeyReference{Name: "token", Namespace: "team-a", Key: "token"}}
+	policy.Spec.Rules[0].Context = []kyverno.ContextEntry{{Name: "cmdb", Service: service}}
+	assert.NilError(t, validateNamespacedPolicy(policy, "team-a", secretNamespaces))
+	service.AuthSecret.Namespace = "kyverno"
+	assert.ErrorContains(t, validateNamespacedPolicy(policy, "team-a", secretNamespaces), "can only read the Secrets of the namespace team-a")
+
+	// the Secrets of the namespace of the policy must be allowed
+	service.AuthSecret.Namespace = "team-a"
+	assert.ErrorContains(t, validateNamespacedPolicy(policy, "team-a", []string{"kyverno"}), "the authSecret must be in the namespaces kyverno")
+}
+
+func Test_validateAuthSecrets(t *testing.T) {
+	service := &kyverno.ServiceCall{URL: "https://cmdb.corp.com/apps", AuthSecret: &kyverno.SecretKeyReference{Name: "token", Namespace: "kyverno", Key: "token"}}
+	rules := []kyverno.Rule{{Name: "check-owner", Context: []kyverno.ContextEntry{{Name: "cmdb", Service: service}}}}
+	assert.NilError(t, validateAuthSecrets(rules, []string{"kyverno", "cmdb"}))
+	service.AuthSecret.Namespace = "cmdb"
+	assert.NilError(t, validateAuthSecrets(rules, []string{"kyverno", "cmdb"}))
+
+	service.AuthSecret.Namespace = "kube-system"
+	assert.ErrorContains(t, validateAuthSecrets(rules, []string{"kyverno", "cmdb"}), `rule check-owner: context entry cmdb: the authSecret must be in the namespaces kyverno, cmdb, found "kube-system"`)
+	assert.ErrorContains(t, validateAuthSecrets(rules, nil), "the authSecret must be in the namespaces")
+
+	// the entries without Secret are not checked
+	service.AuthSecret = nil
+	assert.NilError(t, validateAuthSecrets(rules, nil))
+}
@@ -10,96 +10,118 @@ import (
 	"github.com/nirmata/kyverno/pkg/event"
 )
 
-//generateEvents generates event info for the engine responses
-func generateEvents(engineResponses []response.EngineResponse, onUpdate bool) []event.Info {
+//generateEvents generates event info for the engine responses, on the resource and on the policy
+// - failed rules: PolicyViolation, or RequestBlocked for the enforce policies of a blocked request
+// - applied rules: PolicyApplied on CREATE
+// the resource of a blocked CREATE does not exist, so only the policy gets the event
+func generateEvents(engineResponses []response.EngineResponse, blocked, onUpdate bool) []event.Info {
 	var events []event.Info
-	if !isResponseSuccesful(engineResponses) {
-		for _, er := range engineResponses {
-			if er.IsSuccesful() {
-				// dont create events on success
-				continue
-			}
-			// default behavior is audit
+	for _, er := range engineResponses {
+		if len(er.PolicyResponse.Rules) == 0 {
+			continue
+		}
+		resource := er.PolicyResponse.Resource
+		if !er.IsSuccesful() {
 			reason := event.PolicyViolation
-			if er.PolicyResponse.ValidationFailureAction == Enforce {
+			if blocked && er.PolicyResponse.ValidationFailureAction == Enforce {
 				reason = event.RequestBlocked
 			}
-			failedRules := er.GetFailedRules()
-			filedRulesStr := strings.Join(failedRules, ";")
-			if onUpdate {
-				var e event.Info
-				// UPDATE
+			failedRulesStr := strings.Join(er.GetFailedRules(), ";")
+			messages := strings.Join(getFailedRuleMessages(er), "; ")
+			if onUpdate || !blocked {
 				// event on resource
-				e = event.NewEvent(
-					er.PolicyResponse.Resource.Kind,
-					er.PolicyResponse.Resource.APIVersion,
-					er.PolicyResponse.Resource.Namespace,
-					er.PolicyResponse.Resource.Name,
-					reason.String(),
-					event.AdmissionController,
-					event.FPolicyApplyBlockUpdate,
-					filedRulesStr,
-					er.PolicyResponse.Policy,
-				)
-				glog.V(4).Infof("UPDATE event on resource %s/%s/%s with policy %s", er.PolicyResponse.Resource.Kind, er.PolicyResponse.Resource.Namespace, er.PolicyResponse.Resource.Name, er.PolicyResponse.Policy)
-				events = append(events, e)
-
-				// event on policy
-				e = event.NewEvent(
-					"ClusterPolicy",
-					kyverno.SchemeGroupVersion.String(),
-					"",
-					er.PolicyResponse.Policy,
-					reason.String(),
-					event.AdmissionController,
-					event.FPolicyBlockResourceUpdate,
-					er.PolicyResponse.Resource.GetKey(),
-					filedRulesStr,
-				)
-				glog.V(4).Infof("UPDATE event on policy %s", er.PolicyResponse.Policy)
-				events = append(events, e)
-
-			} else {
-				// CREATE
-				// event on policy
 				e := event.NewEvent(
-					"ClusterPolicy",
-					kyverno.SchemeGroupVersion.String(),
-					"",
-					er.PolicyResponse.Policy,
+					resource.Kind,
+					resource.APIVersion,
+					resource.Namespace,
+					resource.Name,
 					reason.String(),
 					event.AdmissionController,
-					event.FPolicyApplyBlockCreate,
-					er.PolicyResponse.Resource.GetKey(),
-					filedRulesStr,
+					event.FPolicyViolation,
+					failedRulesStr,
+					er.PolicyResponse.Policy,
+					messages,
 				)
-				glog.V(4).Infof("CREATE event on policy %s", er.PolicyResponse.Policy)
+				glog.V(4).Infof("%s event on resource %s/%s/%s with policy %s", reason, resource.Kind, resource.Namespace, resource.Name, er.PolicyResponse.Policy)
 				events = append(events, e)
 			}
-		}
-		return events
-	}
-	if !onUpdate {
-		// All policies were applied successfully
-		// CREATE
-		for _, er := range engineResponses {
-			successRules := er.GetSuccessRules()
-			successRulesStr := strings.Join(successRules, ";")
-			// event on resource
+			// event on policy
 			e := event.NewEvent(
-				er.PolicyResponse.Resource.Kind,
-				er.PolicyResponse.Resource.APIVersion,
-				er.PolicyResponse.Resource.Namespace,
-				er.PolicyResponse.Resource.Name,
-				event.PolicyApplied.String(),
-				event.AdmissionController,
-				event.SRulesApply,
-				successRulesStr,
+				policyKind(er),
+				kyverno.SchemeGroupVersion.String(),
+				er.PolicyResponse.PolicyNamespace,
 				er.PolicyResponse.Policy,
+				reason.String(),
+				event.AdmissionController,
+				event.FResourceViolation,
+				resource.GetKey(),
+				failedRulesStr,
+				messages,
 			)
+			glog.V(4).Infof("%s event on policy %s", reason, er.PolicyResponse.Policy)
 			events = append(events, e)
+			continue
 		}
-
+		if onUpdate || blocked {
+			continue
+		}
+		// CREATE
+		successRulesStr := strings.Join(er.GetSuccessRules(), ";")
+		// event on resource
+		events = append(events, event.NewEvent(
+			resource.Kind,
+			resource.APIVersion,
+			resource.Namespace,
+			resource.Name,
+			event.PolicyApplied.String(),
+			event.AdmissionController,
+			event.SRulesApply,
+			successRulesStr,
+			er.PolicyResponse.Policy,
+		))
+		// event on policy
+		events = append(events, event.NewEvent(
+			policyKind(er),
+			kyverno.SchemeGroupVersion.String(),
+			er.PolicyResponse.PolicyNamespace,
+			er.PolicyResponse.Policy,
+			event.PolicyApplied.String(),
+			event.AdmissionController,
+			event.SResourceApply,
+			successRulesStr,
+			resource.GetKey(),
+		))
 	}
 	return events
 }
+
+// policyKind returns the kind of the policy of the engine response
+func policyKind(er response.EngineResponse) string {
+	if er.PolicyResponse.PolicyNamespace != "" {
+		return "Policy"
+	}
+	return "ClusterPolicy"
+}
+
+// clusterPolicyResponses returns the engine responses of the cluster policies, the results of the
+// namespaced policies are only reported as events
+func clusterPolicyResponses(engineResponses []response.EngineResponse) []response.EngineResponse {
+	var ret []response.EngineResponse
+	for _, er := range engineResponses {
+		if er.PolicyResponse.PolicyNamespace == "" {
+			ret = append(ret, er)
+		}
+	}
+	return ret
+}
+
+// getFailedRuleMessages returns the messages of the failed rules
+func getFailedRuleMessages(er response.EngineResponse) []string {
+	var messages []string
+	for _, rule := range er.PolicyResponse.Rules {
+		if !rule.Success {
+			messages = append(messages, rule.Message)
+		}
+	}
+	return messages
+}
@@ -0,0 +1,74 @@
+package webhooks
+
+import (
+	"testing"
+
+	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/event"
+	"gotest.tools/assert"
+)
+
+func newValidationResponse(policy, action string, success bool) response.EngineResponse {
+	return response.EngineResponse{
+		PolicyResponse: response.PolicyResponse{
+			Policy:                  policy,
+			Resource:                response.ResourceSpec{Kind: "Deployment", Namespace: "default", Name: "nginx"},
+			ValidationFailureAction: action,
+			Rules: []response.RuleResponse{
+				{Name: "check-labels", Type: "Validation", Message: "label app is required", Success: success},
+			},
+		},
+	}
+}
+
+func Test_generateEvents(t *testing.T) {
+	// audit violation: events on the resource and on the policy
+	events := generateEvents([]response.EngineResponse{newValidationResponse("require-labels", Audit, false)}, false, false)
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[0].Kind, "Deployment")
+	assert.Equal(t, events[0].Reason, event.PolicyViolation.String())
+	assert.Equal(t, events[0].Message, "Rule(s) 'check-labels' of policy 'require-labels' not satisfied: label app is required")
+	assert.Equal(t, events[1].Kind, "ClusterPolicy")
+	assert.Equal(t, events[1].Name, "require-labels")
+	assert.Equal(t, events[1].Message, "Resource Deployment/default/nginx does not satisfy rule(s) check-labels: label app is required")
+
+	// blocked create: the resource does not exist, only the policy gets the event
+	events = generateEvents([]response.EngineResponse{newValidationResponse("require-labels", Enforce, false)}, true, false)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].Kind, "ClusterPolicy")
+	assert.Equal(t, events[0].Reason, event.RequestBlocked.String())
+
+	// blocked update: events on the resource and on the policy
+	events = generateEvents([]response.EngineResponse{newValidationResponse("require-labels", Enforce, false)}, true, true)
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[0].Reason, event.RequestBlocked.String())
+
+	// applied on create, the failure of another policy does not hide it
+	events = generateEvents([]response.EngineResponse{
+		newValidationResponse("require-labels", Audit, true),
+		newValidationResponse("require-probes", Audit, false),
+	}, false, false)
+	assert.Equal(t, len(events), 4)
+	assert.Equal(t, events[0].Reason, event.PolicyApplied.String())
+	assert.Equal(t, events[1].Kind, "ClusterPolicy")
+	assert.Equal(t, events[1].Message, "Rule(s) 'check-labels' applied successfully on the resource Deployment/default/nginx")
+
+	// applied on update: no events
+	events = generateEvents([]response.EngineResponse{newValidationResponse("require-labels", Audit, true)}, false, true)
+	assert.Equal(t, len(events), 0)
+}
+
+func Test_generateEvents_NamespacedPolicy(t *testing.T) {
+	er := newValidationResponse("require-labels", Audit, false)
+	er.PolicyResponse.PolicyNamespace = "default"
+	events := generateEvents([]response.EngineResponse{er}, false, false)
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[1].Kind, "Policy")
+	assert.Equal(t, events[1].Namespace, "default")
+	assert.Equal(t, events[1].Name, "require-labels")
+
+	// the results of the namespaced policies are not reported as policy violations
+	responses := clusterPolicyResponses([]response.EngineResponse{newValidationResponse("require-probes", Audit, false), er})
+	assert.Equal(t, len(responses), 1)
+	assert.Equal(t, responses[0].PolicyResponse.Policy, "require-probes")
+}
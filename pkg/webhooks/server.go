@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/golang/glog"
@@ -16,17 +17,28 @@ import (
 	kyvernoinformer "github.com/nirmata/kyverno/pkg/client/informers/externalversions/kyverno/v1"
 	kyvernolister "github.com/nirmata/kyverno/pkg/client/listers/kyverno/v1"
 	"github.com/nirmata/kyverno/pkg/config"
+	"github.com/nirmata/kyverno/pkg/cosign"
 	client "github.com/nirmata/kyverno/pkg/dclient"
+	"github.com/nirmata/kyverno/pkg/engine"
+	engineutils "github.com/nirmata/kyverno/pkg/engine/utils"
 	"github.com/nirmata/kyverno/pkg/event"
+	"github.com/nirmata/kyverno/pkg/externaldata"
+	"github.com/nirmata/kyverno/pkg/metrics"
+	"github.com/nirmata/kyverno/pkg/notary"
+	"github.com/nirmata/kyverno/pkg/notification"
 	"github.com/nirmata/kyverno/pkg/policy"
+	"github.com/nirmata/kyverno/pkg/policyexception"
 	"github.com/nirmata/kyverno/pkg/policystore"
 	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"github.com/nirmata/kyverno/pkg/registry"
 	tlsutils "github.com/nirmata/kyverno/pkg/tls"
+	"github.com/nirmata/kyverno/pkg/tracing"
 	userinfo "github.com/nirmata/kyverno/pkg/userinfo"
 	"github.com/nirmata/kyverno/pkg/webhookconfig"
 	"github.com/nirmata/kyverno/pkg/webhooks/generate"
 	v1beta1 "k8s.io/api/admission/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	rbacinformer "k8s.io/client-go/informers/rbac/v1"
 	rbaclister "k8s.io/client-go/listers/rbac/v1"
 	"k8s.io/client-go/tools/cache"
@@ -69,6 +81,24 @@ type WebhookServer struct {
 	// generate request generator
 	grGenerator            *generate.Generator
 	resourceWebhookWatcher *webhookconfig.ResourceWebhookRegister
+	// get the ConfigMaps referenced in the rule context
+	configMapResolver engine.ConfigMapResolver
+	// fetch the image data referenced in the rule context
+	registryClient registry.Interface
+	// call the external services referenced in the rule context
+	serviceClient externaldata.Interface
+	// cache the data of the global context entries
+	globalContext *engine.GlobalContext
+	// look up the policy exceptions of the namespace of the resources
+	exceptionStore policyexception.LookupInterface
+	// verify the image signatures of the verifyImages rules
+	imageVerifier cosign.Interface
+	// verify the Notary v2 signatures of the verifyImages rules
+	notaryVerifier notary.Interface
+	// forward the blocked admission requests to the notification sinks
+	notifier notification.Interface
+	// namespaces of the authorization Secrets of the external services
+	serviceSecretNamespaces []string
 }
 
 // NewWebhookServer creates new instance of WebhookServer accordingly to given configuration
@@ -88,6 +118,14 @@ func NewWebhookServer(
 	pvGenerator policyviolation.GeneratorInterface,
 	grGenerator *generate.Generator,
 	resourceWebhookWatcher *webhookconfig.ResourceWebhookRegister,
+	configMapResolver engine.ConfigMapResolver,
+	registryClient registry.Interface,
+	serviceClient externaldata.Interface,
+	globalContext *engine.GlobalContext,
+	exceptionStore policyexception.LookupInterface,
+	imageVerifier cosign.Interface,
+	notaryVerifier notary.Interface,
+	notifier notification.Interface,
 	cleanUp chan<- struct{}) (*WebhookServer, error) {
 
 	if tlsPair == nil {
@@ -120,11 +158,21 @@ func NewWebhookServer(
 		pMetaStore:                pMetaStore,
 		grGenerator:               grGenerator,
 		resourceWebhookWatcher:    resourceWebhookWatcher,
+		configMapResolver:         configMapResolver,
+		registryClient:            registryClient,
+		serviceClient:             serviceClient,
+		globalContext:             globalContext,
+		exceptionStore:            exceptionStore,
+		imageVerifier:             imageVerifier,
+		notaryVerifier:            notaryVerifier,
+		notifier:                  notifier,
 	}
 	mux := http.NewServeMux()
 	mux.HandleFunc(config.MutatingWebhookServicePath, ws.serve)
+	mux.HandleFunc(config.MutatingWebhookFailServicePath, ws.serve)
 	mux.HandleFunc(config.VerifyMutatingWebhookServicePath, ws.serve)
 	mux.HandleFunc(config.PolicyValidatingWebhookServicePath, ws.serve)
+	mux.HandleFunc(config.NamespacedPolicyValidatingWebhookServicePath, ws.serve)
 	mux.HandleFunc(config.PolicyMutatingWebhookServicePath, ws.serve)
 	ws.server = http.Server{
 		Addr:         ":443", // Listen on port for HTTPS requests
@@ -138,6 +186,12 @@ func NewWebhookServer(
 }
 
 // Main server endpoint for all requests
+// SetServiceSecretNamespaces sets the namespaces the authorization Secrets of the external services must be in,
+// the policies referring to the Secrets of the other namespaces are rejected
+func (ws *WebhookServer) SetServiceSecretNamespaces(namespaces []string) {
+	ws.serviceSecretNamespaces = namespaces
+}
+
 func (ws *WebhookServer) serve(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	// for every request received on the ep update last request time,
@@ -162,14 +216,28 @@ func (ws *WebhookServer) serve(w http.ResponseWriter, r *http.Request) {
 		// we do not apply filters as this endpoint is used explicitly
 		// to watch kyveno deployment and verify if admission control is enabled
 		admissionReview.Response = ws.handleVerifyRequest(request)
-	case config.MutatingWebhookServicePath:
+	case config.MutatingWebhookServicePath, config.MutatingWebhookFailServicePath:
 		if !ws.configHandler.ToFilter(request.Kind.Kind, request.Namespace, request.Name) {
-			admissionReview.Response = ws.handleAdmissionRequest(request)
+			done := metrics.AdmissionRequestStarted(string(request.Operation), request.Kind.Kind)
+			span := tracing.StartTrace("admission "+string(request.Operation)+" "+request.Kind.Kind,
+				"k8s.operation", string(request.Operation),
+				"k8s.kind", request.Kind.Kind,
+				"k8s.namespace", request.Namespace,
+				"k8s.name", request.Name,
+				"k8s.uid", string(request.UID))
+			admissionReview.Response = ws.handleAdmissionRequest(request, r.URL.Path == config.MutatingWebhookFailServicePath, span)
+			span.SetAttributes("allowed", strconv.FormatBool(admissionReview.Response.Allowed))
+			span.End()
+			done(admissionReview.Response.Allowed)
 		}
 	case config.PolicyValidatingWebhookServicePath:
 		if !ws.configHandler.ToFilter(request.Kind.Kind, request.Namespace, request.Name) {
 			admissionReview.Response = ws.handlePolicyValidation(request)
 		}
+	case config.NamespacedPolicyValidatingWebhookServicePath:
+		if !ws.configHandler.ToFilter(request.Kind.Kind, request.Namespace, request.Name) {
+			admissionReview.Response = ws.handleNamespacedPolicyValidation(request)
+		}
 	case config.PolicyMutatingWebhookServicePath:
 		if !ws.configHandler.ToFilter(request.Kind.Kind, request.Namespace, request.Name) {
 			admissionReview.Response = ws.handlePolicyMutation(request)
@@ -189,13 +257,17 @@ func (ws *WebhookServer) serve(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (ws *WebhookServer) handleAdmissionRequest(request *v1beta1.AdmissionRequest) *v1beta1.AdmissionResponse {
-	policies, err := ws.pMetaStore.LookUp(request.Kind.Kind, request.Namespace)
+// handleAdmissionRequest applies the policies of the webhook, failWebhook is true for the webhook
+// of the policies with the Fail failure policy
+func (ws *WebhookServer) handleAdmissionRequest(request *v1beta1.AdmissionRequest, failWebhook bool, span *tracing.Span) *v1beta1.AdmissionResponse {
+	gvk := schema.GroupVersionKind{Group: request.Kind.Group, Version: request.Kind.Version, Kind: request.Kind.Kind}
+	policies, err := ws.pMetaStore.LookUpRules(gvk, request.Namespace)
 	if err != nil {
 		// Unable to connect to policy Lister to access policies
 		glog.Errorf("Unable to connect to policy controller to access policies. Policies are NOT being applied: %v", err)
 		return &v1beta1.AdmissionResponse{Allowed: true}
 	}
+	policies = webhookPolicies(policies, failWebhook, ws.resourceWebhookWatcher.IsFailWebhookRegistered())
 
 	var roles, clusterRoles []string
 
@@ -237,13 +309,14 @@ func (ws *WebhookServer) handleAdmissionRequest(request *v1beta1.AdmissionReques
 	// MUTATION
 	// mutation failure should not block the resource creation
 	// any mutation failure is reported as the violation
-	patches := ws.HandleMutation(request, resource, policies, roles, clusterRoles)
+	patches := ws.HandleMutation(request, resource, policies, roles, clusterRoles, span)
 
 	// patch the resource with patches before handling validation rules
-	patchedResource := processResourceWithPatches(patches, request.Object.Raw)
+	patchedResource := processResourceWithPatches(engineutils.JoinPatches(patches), request.Object.Raw)
 
 	// VALIDATION
-	ok, msg := ws.HandleValidation(request, policies, patchedResource, roles, clusterRoles)
+	// the images verified with mutateDigest are replaced by their digests
+	ok, msg, verifyPatches := ws.HandleValidation(request, policies, patchedResource, roles, clusterRoles, span)
 	if !ok {
 		glog.V(4).Infof("Deny admission request: %v/%s/%s", request.Kind, request.Namespace, request.Name)
 		return &v1beta1.AdmissionResponse{
@@ -279,7 +352,7 @@ func (ws *WebhookServer) handleAdmissionRequest(request *v1beta1.AdmissionReques
 		Result: &metav1.Status{
 			Status: "Success",
 		},
-		Patch:     patches,
+		Patch:     engineutils.JoinPatches(append(patches, verifyPatches...)),
 		PatchType: &patchType,
 	}
 }
@@ -297,12 +370,14 @@ func (ws *WebhookServer) RunAsync(stopCh <-chan struct{}) {
 		}
 	}(ws)
 	glog.Info("Started Webhook Server")
-	// verifys if the admission control is enabled and active
-	// resync: 60 seconds
-	// deadline: 60 seconds (send request)
-	// max deadline: deadline*3 (set the deployment annotation as false)
-	go ws.lastReqTime.Run(ws.pLister, ws.eventGen, ws.client, checker.DefaultResync, checker.DefaultDeadline, stopCh)
+}
 
+// RunChecker verifies if the admission control is enabled and active, it runs on the leader of the replicas only
+// resync: 60 seconds
+// deadline: 60 seconds (send request)
+// max deadline: deadline*3 (set the deployment annotation as false)
+func (ws *WebhookServer) RunChecker(stopCh <-chan struct{}) {
+	ws.lastReqTime.Run(ws.pLister, ws.eventGen, ws.client, checker.DefaultResync, checker.DefaultDeadline, stopCh)
 }
 
 // Stop TLS server and returns control after the server is shut down
@@ -310,6 +385,16 @@ func (ws *WebhookServer) Stop(ctx context.Context) {
 	// cleanUp
 	// remove the static webhookconfigurations
 	go ws.webhookRegistrationClient.RemoveWebhookConfigurations(ws.cleanUp)
+	ws.shutdown(ctx)
+}
+
+// StopServing stops the TLS server and keeps the webhook configurations, served by the other replicas
+func (ws *WebhookServer) StopServing(ctx context.Context) {
+	close(ws.cleanUp)
+	ws.shutdown(ctx)
+}
+
+func (ws *WebhookServer) shutdown(ctx context.Context) {
 	// shutdown http.Server with context timeout
 	err := ws.server.Shutdown(ctx)
 	if err != nil {
@@ -8,9 +8,12 @@ import (
 	kyverno "github.com/nirmata/kyverno/pkg/api/kyverno/v1"
 	"github.com/nirmata/kyverno/pkg/engine"
 	"github.com/nirmata/kyverno/pkg/engine/context"
+	enginelog "github.com/nirmata/kyverno/pkg/engine/log"
 	"github.com/nirmata/kyverno/pkg/engine/response"
+	"github.com/nirmata/kyverno/pkg/metrics"
 	policyctr "github.com/nirmata/kyverno/pkg/policy"
 	"github.com/nirmata/kyverno/pkg/policyviolation"
+	"github.com/nirmata/kyverno/pkg/tracing"
 	"github.com/nirmata/kyverno/pkg/utils"
 	v1beta1 "k8s.io/api/admission/v1beta1"
 )
@@ -18,7 +21,8 @@ import (
 // HandleValidation handles validating webhook admission request
 // If there are no errors in validating rule we apply generation rules
 // patchedResource is the (resource + patches) after applying mutation rules
-func (ws *WebhookServer) HandleValidation(request *v1beta1.AdmissionRequest, policies []kyverno.ClusterPolicy, patchedResource []byte, roles, clusterRoles []string) (bool, string) {
+// return value: the patches of the images verified with mutateDigest
+func (ws *WebhookServer) HandleValidation(request *v1beta1.AdmissionRequest, policies []kyverno.ClusterPolicy, patchedResource []byte, roles, clusterRoles []string, span *tracing.Span) (bool, string, [][]byte) {
 	glog.V(4).Infof("Receive request in validating webhook: Kind=%s, Namespace=%s Name=%s UID=%s patchOperation=%s",
 		request.Kind.Kind, request.Namespace, request.Name, request.UID, request.Operation)
 
@@ -26,6 +30,10 @@ func (ws *WebhookServer) HandleValidation(request *v1beta1.AdmissionRequest, pol
 	evalTime := time.Now()
 	// gather stats from the engine response
 	gatherStat := func(policyName string, policyResponse response.PolicyResponse) {
+		if policyResponse.PolicyNamespace != "" {
+			// the namespaced policies have no status
+			return
+		}
 		ps := policyctr.PolicyStat{}
 		ps.PolicyName = policyName
 		ps.Stats.ValidationExecutionTime = policyResponse.ProcessingTime
@@ -58,7 +66,7 @@ func (ws *WebhookServer) HandleValidation(request *v1beta1.AdmissionRequest, pol
 	if err != nil {
 		// as resource cannot be parsed, we skip processing
 		glog.Error(err)
-		return true, ""
+		return true, "", nil
 	}
 	userRequestInfo := kyverno.RequestInfo{
 		Roles:             roles,
@@ -76,6 +84,10 @@ func (ws *WebhookServer) HandleValidation(request *v1beta1.AdmissionRequest, pol
 	if err != nil {
 		glog.Infof("Failed to load userInfo in context:%v", err)
 	}
+	err = ctx.AddRequestInfo(string(request.Operation), request.Namespace)
+	if err != nil {
+		glog.Infof("Failed to load request info in context:%v", err)
+	}
 
 	err = ctx.AddSA(userRequestInfo.AdmissionUserInfo.Username)
 	if err != nil {
@@ -83,12 +95,38 @@ func (ws *WebhookServer) HandleValidation(request *v1beta1.AdmissionRequest, pol
 	}
 
 	policyContext := engine.PolicyContext{
-		NewResource:   newR,
-		OldResource:   oldR,
-		Context:       ctx,
-		AdmissionInfo: userRequestInfo,
+		NewResource:         newR,
+		OldResource:         oldR,
+		Context:             ctx,
+		AdmissionInfo:       userRequestInfo,
+		Client:              ws.client,
+		ConfigMapResolver:   ws.configMapResolver,
+		ImageRegistryClient: ws.registryClient,
+		ServiceClient:       ws.serviceClient,
+		GlobalContext:       ws.globalContext,
+		ImageVerifier:       ws.imageVerifier,
+		NotaryVerifier:      ws.notaryVerifier,
+		Exceptions:          ws.exceptionStore.LookUp(request.Namespace),
+		Span:                span,
+		Log:                 enginelog.Glog,
 	}
 	var engineResponses []response.EngineResponse
+	var patches [][]byte
+	// verify the image signatures, the images are verified on create and on update
+	// the next policies are applied to the resource with the verified digests
+	for _, policy := range policies {
+		policyContext.Policy = policy
+		engineResponse := engine.VerifyImages(policyContext)
+		if len(engineResponse.PolicyResponse.Rules) == 0 {
+			continue
+		}
+		engineResponses = append(engineResponses, engineResponse)
+		gatherStat(policy.Name, engineResponse.PolicyResponse)
+		if verifyPatches := engineResponse.GetPatches(); len(verifyPatches) != 0 {
+			patches = append(patches, verifyPatches...)
+			policyContext.NewResource = engineResponse.PatchedResource
+		}
+	}
 	for _, policy := range policies {
 		glog.V(2).Infof("Handling validation for Kind=%s, Namespace=%s Name=%s UID=%s patchOperation=%s",
 			newR.GetKind(), newR.GetNamespace(), newR.GetName(), request.UID, request.Operation)
@@ -114,22 +152,27 @@ func (ws *WebhookServer) HandleValidation(request *v1beta1.AdmissionRequest, pol
 	// If Validation fails then reject the request
 	// no violations will be created on "enforce"
 	// the event will be reported on owner by k8s
+	metrics.RecordEngineResponses(metrics.SourceAdmission, engineResponses)
 	blocked := toBlockResource(engineResponses)
 	if blocked {
 		glog.V(4).Infof("resource %s/%s/%s is blocked\n", newR.GetKind(), newR.GetNamespace(), newR.GetName())
 		sendStat(true)
-		return false, getEnforceFailureErrorMsg(engineResponses)
+		// the request is rejected, the events tell why on the policy and on the existing resource
+		events := generateEvents(engineResponses, true, (request.Operation == v1beta1.Update))
+		ws.eventGen.Add(events...)
+		ws.notifier.Blocked(engineResponses)
+		return false, getEnforceFailureErrorMsg(engineResponses), nil
 	}
 
 	// ADD POLICY VIOLATIONS
 	// violations are created with resource on "audit"
-	pvInfos := policyviolation.GeneratePVsFromEngineResponse(engineResponses)
+	pvInfos := policyviolation.GeneratePVsFromEngineResponse(clusterPolicyResponses(engineResponses))
 	ws.pvGenerator.Add(pvInfos...)
 	// ADD EVENTS
-	events := generateEvents(engineResponses, (request.Operation == v1beta1.Update))
+	events := generateEvents(engineResponses, false, (request.Operation == v1beta1.Update))
 	ws.eventGen.Add(events...)
 	sendStat(false)
 	// report time end
 	glog.V(4).Infof("report: %v %s/%s/%s", time.Since(reportTime), request.Kind, request.Namespace, request.Name)
-	return true, ""
+	return true, "", patches
 }
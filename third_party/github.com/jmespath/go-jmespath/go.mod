@@ -0,0 +1,3 @@
+module github.com/jmespath/go-jmespath
+
+go 1.13
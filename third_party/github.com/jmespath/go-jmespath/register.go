@@ -0,0 +1,73 @@
+package jmespath
+
+// This file and the registered functions of newFunctionCaller are added to
+// github.com/jmespath/go-jmespath (c2b33e8439af) by Kyverno, the package does
+// not support registering functions.
+
+// Argument is an argument of a registered function.
+type Argument struct {
+	// Types are the accepted types of the argument, e.g. string, number or any.
+	Types []string
+	// Variadic is true if the last argument may be repeated.
+	Variadic bool
+}
+
+// Function is a function of the expressions.
+type Function struct {
+	Name      string
+	Arguments []Argument
+	Handler   func(arguments []interface{}) (interface{}, error)
+}
+
+// registeredFunctions are added to the built-in functions of the interpreters.
+var registeredFunctions = map[string]functionEntry{}
+
+// RegisterFunction adds a function to the functions of the expressions, it
+// replaces the built-in function of the same name. The functions are expected
+// to be registered on initialization, before the expressions are evaluated.
+func RegisterFunction(function Function) {
+	entry := functionEntry{name: function.Name, handler: function.Handler}
+	for _, argument := range function.Arguments {
+		spec := argSpec{variadic: argument.Variadic}
+		for _, t := range argument.Types {
+			spec.types = append(spec.types, jpType(t))
+			if jpType(t) == jpExpref {
+				entry.hasExpRef = true
+			}
+		}
+		entry.arguments = append(entry.arguments, spec)
+	}
+	registeredFunctions[function.Name] = entry
+}
+
+// Functions returns the built-in and the registered functions.
+func Functions() []Function {
+	var functions []Function
+	for _, entry := range newFunctionCaller().functionTable {
+		function := Function{Name: entry.name, Handler: entry.handler}
+		for _, spec := range entry.arguments {
+			argument := Argument{Variadic: spec.variadic}
+			for _, t := range spec.types {
+				argument.Types = append(argument.Types, string(t))
+			}
+			function.Arguments = append(function.Arguments, argument)
+		}
+		functions = append(functions, function)
+	}
+	return functions
+}
+
+// Type returns the type of the node, e.g. ASTField.
+func (node ASTNode) Type() string {
+	return node.nodeType.String()
+}
+
+// Value returns the value of the node, e.g. the name of a field.
+func (node ASTNode) Value() interface{} {
+	return node.value
+}
+
+// Children returns the children of the node.
+func (node ASTNode) Children() []ASTNode {
+	return node.children
+}